@@ -70,6 +70,7 @@ if [ "$drop_all" = "yes" ]; then
     db.update_detections.drop();
     db.update_rollouts.drop();
     db.audit_logs.drop();
+    db.audit_checkpoints.drop();
     print('All collections dropped successfully.');
     "
     echo "✓ All collections dropped."
@@ -137,6 +138,7 @@ else
             db.update_detections.deleteMany({});
             db.update_rollouts.deleteMany({});
             db.audit_logs.deleteMany({});
+            db.audit_checkpoints.deleteMany({});
             "
             echo "✓ Deleted all data:"
             echo "  - $products products"
@@ -10,6 +10,10 @@ import (
 	"time"
 
 	"updatemanager/internal/api/router"
+	"updatemanager/internal/auditchain"
+	"updatemanager/internal/events"
+	"updatemanager/internal/notify"
+	"updatemanager/internal/requestmeta"
 	"updatemanager/internal/service"
 	"updatemanager/pkg/database"
 )
@@ -38,12 +42,97 @@ func main() {
 
 	log.Println("Connected to MongoDB successfully")
 
+	// Live updates stay in this process unless replicas share them through MongoDB
+	var broker events.Broker = events.NewMemoryBroker()
+	if os.Getenv("STREAM_BROKER") == "mongo" {
+		broker = events.NewMongoBroker(db.Database.Collection("stream_events"))
+	}
+
 	// Initialize services
-	services := service.NewServiceFactory(db.Database)
+	services := service.NewServiceFactoryWithBroker(db.Database, broker)
 	log.Println("Services initialized")
 
-	// Setup router
+	// Email notifications are sent when SMTP is configured
+	if smtpCfg, ok := notify.SMTPConfigFromEnv(); ok {
+		emailChannel, err := notify.NewEmailChannel(smtpCfg)
+		if err != nil {
+			log.Fatalf("Invalid SMTP configuration: %v", err)
+		}
+		services.NotificationDeliveryService.RegisterChannel(emailChannel)
+		log.Printf("Email notifications enabled via %s", smtpCfg.Host)
+	}
+	// Chat and incident channels send to the targets of notification routes
+	services.NotificationDeliveryService.RegisterChannel(notify.NewSlackChannel(nil))
+	services.NotificationDeliveryService.RegisterChannel(notify.NewTeamsChannel(nil))
+	services.NotificationDeliveryService.RegisterChannel(notify.NewPagerDutyChannel(os.Getenv("PAGERDUTY_EVENTS_URL"), nil))
+
+	// Fields redacted from audit entries on top of the defaults
+	redactedFields, err := service.ParseAuditRedactedFields(os.Getenv("AUDIT_REDACTED_FIELDS"))
+	if err != nil {
+		log.Fatalf("Invalid AUDIT_REDACTED_FIELDS: %v", err)
+	}
+	services.AuditLogger.SetRedactedFields(redactedFields)
+
+	// Audit entries are hash chained; checkpoints of the chain are signed
+	// and exported when a signing key is configured
+	if err := services.AuditChainService.EnsureSequenceIndex(ctx); err != nil {
+		log.Printf("Failed to create audit log sequence index: %v", err)
+	}
+	auditCheckpoints := false
+	if key := os.Getenv("AUDIT_CHECKPOINT_KEY"); key != "" {
+		signer, err := auditchain.ParseSigningKey(key)
+		if err != nil {
+			log.Fatalf("Invalid AUDIT_CHECKPOINT_KEY: %v", err)
+		}
+		services.AuditChainService.SetCheckpointSigner(signer, os.Getenv("AUDIT_CHECKPOINT_DIR"))
+		auditCheckpoints = true
+		log.Printf("Audit checkpoints enabled with key %s", signer.KeyID())
+	} else {
+		log.Printf("Audit checkpoints disabled: AUDIT_CHECKPOINT_KEY is not set")
+	}
+
+	// Read notifications are purged by a TTL index after the retention
+	retention, err := service.ParseNotificationReadRetention(os.Getenv("NOTIFICATION_READ_RETENTION_DAYS"))
+	if err != nil {
+		log.Fatalf("Invalid NOTIFICATION_READ_RETENTION_DAYS: %v", err)
+	}
+	for _, inbox := range []*service.NotificationService{services.NotificationService, services.InternalNotificationService.Inbox()} {
+		if err := inbox.ApplyReadRetention(ctx, retention); err != nil {
+			log.Printf("Failed to apply notification retention: %v", err)
+		}
+		if err := inbox.EnsureDedupIndex(ctx); err != nil {
+			log.Printf("Failed to create notification dedup index: %v", err)
+		}
+	}
+
+	// Start background schedulers
+	schedulerCtx, stopSchedulers := context.WithCancel(ctx)
+	defer stopSchedulers()
+	go service.NewRolloutCampaignScheduler(services.RolloutCampaignService, time.Minute).Run(schedulerCtx)
+	go service.NewMaintenanceWindowScheduler(services.UpdateRolloutService, time.Minute).Run(schedulerCtx)
+	go service.NewRolloutTimeoutSweeper(services.UpdateRolloutService, time.Minute).Run(schedulerCtx)
+	go service.NewLicenseExpirySweeper(services.LicenseService, 15*time.Minute).Run(schedulerCtx)
+	go services.OutboxDispatcher.Run(schedulerCtx)
+	go service.NewWebhookDeliveryWorker(services.WebhookService, 5*time.Second).Run(schedulerCtx)
+	go service.NewNotificationDeliveryWorker(services.NotificationDeliveryService, 10*time.Second).Run(schedulerCtx)
+	go service.NewNotificationTriggerSweeper(services.NotificationTriggerService, time.Hour).Run(schedulerCtx)
+	if auditCheckpoints {
+		go service.NewAuditCheckpointExporter(services.AuditChainService, time.Hour).Run(schedulerCtx)
+	}
+	go func() {
+		if err := services.StreamBus.Run(schedulerCtx); err != nil && err != context.Canceled {
+			log.Printf("Stream bus stopped: %v", err)
+		}
+	}()
+
+	// Setup router; the client IP is taken from forwarding headers only
+	// when they come from a trusted proxy
+	trustedProxies, err := requestmeta.ParseTrustedProxies(os.Getenv("TRUSTED_PROXIES"))
+	if err != nil {
+		log.Fatalf("Invalid TRUSTED_PROXIES: %v", err)
+	}
 	r := router.NewRouter(services)
+	r.SetTrustedProxies(trustedProxies)
 	handler := r.Handler()
 
 	// Start server
@@ -74,6 +163,7 @@ func main() {
 	<-quit
 
 	log.Println("Shutting down server...")
+	stopSchedulers()
 
 	// Graceful shutdown
 	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"net/http"
+
+	"updatemanager/internal/api/utils"
+	"updatemanager/internal/service"
+)
+
+// AuditChainHandler handles audit log hash chain HTTP requests
+type AuditChainHandler struct {
+	auditChainService *service.AuditChainService
+}
+
+// NewAuditChainHandler creates a new audit chain handler
+func NewAuditChainHandler(auditChainService *service.AuditChainService) *AuditChainHandler {
+	return &AuditChainHandler{
+		auditChainService: auditChainService,
+	}
+}
+
+// VerifyChain handles GET /api/v1/audit-logs/verify. A broken chain is a
+// successful verification: the result reports the first broken link.
+func (h *AuditChainHandler) VerifyChain(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Method not allowed")
+		return
+	}
+
+	result, err := h.auditChainService.VerifyChain(r.Context())
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "VERIFY_FAILED", err.Error())
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, result)
+}
+
+// ListCheckpoints handles GET /api/v1/audit-logs/checkpoints
+func (h *AuditChainHandler) ListCheckpoints(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Method not allowed")
+		return
+	}
+
+	page := utils.GetIntQueryParam(r, "page", 1)
+	limit := utils.GetIntQueryParam(r, "limit", 10)
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+
+	checkpoints, total, err := h.auditChainService.ListCheckpoints(r.Context(), page, limit)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "GET_FAILED", err.Error())
+		return
+	}
+
+	utils.WritePaginated(w, http.StatusOK, checkpoints, page, limit, total)
+}
@@ -2,6 +2,8 @@ package handlers
 
 import (
 	"net/http"
+	"strings"
+	"time"
 
 	"updatemanager/internal/api/utils"
 	"updatemanager/internal/service"
@@ -49,6 +51,9 @@ func (h *AuditLogHandler) GetAuditLogs(w http.ResponseWriter, r *http.Request) {
 	if action := r.URL.Query().Get("action"); action != "" {
 		filter["action"] = action
 	}
+	if requestID := r.URL.Query().Get("request_id"); requestID != "" {
+		filter["request_id"] = requestID
+	}
 
 	logs, total, err := h.auditLogService.ListAuditLogs(r.Context(), filter, page, limit)
 	if err != nil {
@@ -58,3 +63,36 @@ func (h *AuditLogHandler) GetAuditLogs(w http.ResponseWriter, r *http.Request) {
 
 	utils.WritePaginated(w, http.StatusOK, logs, page, limit, total)
 }
+
+// GetResourceState handles GET /api/v1/audit-logs/state
+func (h *AuditLogHandler) GetResourceState(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Method not allowed")
+		return
+	}
+
+	at := time.Now()
+	if value := r.URL.Query().Get("at"); value != "" {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			utils.WriteError(w, http.StatusBadRequest, "INVALID_REQUEST", "at must be an RFC 3339 timestamp")
+			return
+		}
+		at = parsed
+	}
+
+	state, err := h.auditLogService.GetResourceState(r.Context(), r.URL.Query().Get("resource_type"), r.URL.Query().Get("resource_id"), at)
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "invalid audit state request"):
+			utils.WriteError(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		case strings.Contains(err.Error(), "audit history not found"):
+			utils.WriteError(w, http.StatusNotFound, "NOT_FOUND", err.Error())
+		default:
+			utils.WriteError(w, http.StatusInternalServerError, "GET_FAILED", err.Error())
+		}
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, state)
+}
@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"updatemanager/internal/api/utils"
+	"updatemanager/internal/models"
+	"updatemanager/internal/service"
+)
+
+// BulkRolloutHandler handles bulk rollout HTTP requests
+type BulkRolloutHandler struct {
+	bulkRolloutService *service.BulkRolloutService
+}
+
+// NewBulkRolloutHandler creates a new bulk rollout handler
+func NewBulkRolloutHandler(bulkRolloutService *service.BulkRolloutService) *BulkRolloutHandler {
+	return &BulkRolloutHandler{
+		bulkRolloutService: bulkRolloutService,
+	}
+}
+
+// PreviewBulkRollout handles POST /api/v1/update-rollouts/bulk/preview
+func (h *BulkRolloutHandler) PreviewBulkRollout(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Method not allowed")
+		return
+	}
+
+	var req models.BulkRolloutRequest
+	if err := utils.ReadJSON(w, r, &req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "INVALID_REQUEST", "Invalid JSON: "+err.Error())
+		return
+	}
+
+	result, err := h.bulkRolloutService.PreviewBulkRollout(r.Context(), &req)
+	if err != nil {
+		writeBulkRolloutError(w, err)
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, result)
+}
+
+// ExecuteBulkRollout handles POST /api/v1/update-rollouts/bulk
+func (h *BulkRolloutHandler) ExecuteBulkRollout(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Method not allowed")
+		return
+	}
+
+	var req models.BulkRolloutRequest
+	if err := utils.ReadJSON(w, r, &req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "INVALID_REQUEST", "Invalid JSON: "+err.Error())
+		return
+	}
+
+	userID, userEmail := requestUser(r)
+	result, err := h.bulkRolloutService.ExecuteBulkRollout(r.Context(), &req, userID, userEmail)
+	if err != nil {
+		writeBulkRolloutError(w, err)
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusCreated, result)
+}
+
+// writeBulkRolloutError maps a bulk rollout service error to a response
+func writeBulkRolloutError(w http.ResponseWriter, err error) {
+	if strings.Contains(err.Error(), "invalid bulk rollout") {
+		utils.WriteError(w, http.StatusBadRequest, "INVALID_BULK_ROLLOUT", err.Error())
+		return
+	}
+	if strings.Contains(err.Error(), "not found") {
+		utils.WriteError(w, http.StatusNotFound, "VERSION_NOT_FOUND", err.Error())
+		return
+	}
+	utils.WriteError(w, http.StatusInternalServerError, "BULK_ROLLOUT_FAILED", err.Error())
+}
@@ -58,6 +58,14 @@ func (h *CustomerHandler) CreateCustomer(w http.ResponseWriter, r *http.Request)
 			utils.WriteError(w, http.StatusConflict, "DUPLICATE_CUSTOMER", err.Error())
 			return
 		}
+		if strings.Contains(err.Error(), "invalid locale") {
+			utils.WriteError(w, http.StatusBadRequest, "INVALID_LOCALE", err.Error())
+			return
+		}
+		if strings.Contains(err.Error(), "invalid notification preferences") {
+			utils.WriteError(w, http.StatusBadRequest, "INVALID_NOTIFICATION_PREFERENCES", err.Error())
+			return
+		}
 		utils.WriteError(w, http.StatusInternalServerError, "CREATE_FAILED", err.Error())
 		return
 	}
@@ -174,6 +182,14 @@ func (h *CustomerHandler) UpdateCustomer(w http.ResponseWriter, r *http.Request)
 			utils.WriteError(w, http.StatusConflict, "DUPLICATE_CUSTOMER", err.Error())
 			return
 		}
+		if strings.Contains(err.Error(), "invalid locale") {
+			utils.WriteError(w, http.StatusBadRequest, "INVALID_LOCALE", err.Error())
+			return
+		}
+		if strings.Contains(err.Error(), "invalid notification preferences") {
+			utils.WriteError(w, http.StatusBadRequest, "INVALID_NOTIFICATION_PREFERENCES", err.Error())
+			return
+		}
 		utils.WriteError(w, http.StatusInternalServerError, "UPDATE_FAILED", err.Error())
 		return
 	}
@@ -67,6 +67,10 @@ func (h *DeploymentHandler) CreateDeployment(w http.ResponseWriter, r *http.Requ
 
 	deployment, err := h.deploymentService.CreateDeployment(r.Context(), tenantID, &req, userID, userEmail)
 	if err != nil {
+		if strings.Contains(err.Error(), "invalid maintenance schedule") {
+			utils.WriteError(w, http.StatusBadRequest, "INVALID_MAINTENANCE_SCHEDULE", err.Error())
+			return
+		}
 		if strings.Contains(err.Error(), "not found") {
 			utils.WriteError(w, http.StatusNotFound, "TENANT_NOT_FOUND", err.Error())
 			return
@@ -205,6 +209,10 @@ func (h *DeploymentHandler) UpdateDeployment(w http.ResponseWriter, r *http.Requ
 
 	deployment, err := h.deploymentService.UpdateDeployment(r.Context(), deploymentID, &req, userID, userEmail)
 	if err != nil {
+		if strings.Contains(err.Error(), "invalid maintenance schedule") {
+			utils.WriteError(w, http.StatusBadRequest, "INVALID_MAINTENANCE_SCHEDULE", err.Error())
+			return
+		}
 		if strings.Contains(err.Error(), "not found") {
 			utils.WriteError(w, http.StatusNotFound, "DEPLOYMENT_NOT_FOUND", err.Error())
 			return
@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"net/http"
+
+	"updatemanager/internal/api/utils"
+	"updatemanager/internal/service"
+)
+
+// DomainEventMetricsHandler handles domain event metrics HTTP requests
+type DomainEventMetricsHandler struct {
+	metrics *service.DomainEventMetrics
+}
+
+// NewDomainEventMetricsHandler creates a new domain event metrics handler
+func NewDomainEventMetricsHandler(metrics *service.DomainEventMetrics) *DomainEventMetricsHandler {
+	return &DomainEventMetricsHandler{
+		metrics: metrics,
+	}
+}
+
+// GetMetrics handles GET /api/v1/domain-events/metrics
+func (h *DomainEventMetricsHandler) GetMetrics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Method not allowed")
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, h.metrics.Counts())
+}
@@ -0,0 +1,296 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"updatemanager/internal/api/utils"
+	"updatemanager/internal/models"
+	"updatemanager/internal/service"
+)
+
+// EndpointHandler handles endpoint registry HTTP requests
+type EndpointHandler struct {
+	endpointService *service.EndpointService
+}
+
+// NewEndpointHandler creates a new endpoint handler
+func NewEndpointHandler(endpointService *service.EndpointService) *EndpointHandler {
+	return &EndpointHandler{
+		endpointService: endpointService,
+	}
+}
+
+// RegisterEndpoint handles POST /api/v1/endpoints
+func (h *EndpointHandler) RegisterEndpoint(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Method not allowed")
+		return
+	}
+
+	var req models.RegisterEndpointRequest
+	if err := utils.ReadJSON(w, r, &req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "INVALID_REQUEST", "Invalid JSON: "+err.Error())
+		return
+	}
+
+	// Validate required fields
+	if strings.TrimSpace(req.DeploymentID) == "" {
+		utils.WriteError(w, http.StatusBadRequest, "INVALID_REQUEST", "Deployment ID is required")
+		return
+	}
+
+	userID, userEmail := requestUser(r)
+
+	endpoint, err := h.endpointService.RegisterEndpoint(r.Context(), &req, userID, userEmail)
+	if err != nil {
+		if strings.Contains(err.Error(), "invalid endpoint") {
+			utils.WriteError(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+			return
+		}
+		if strings.Contains(err.Error(), "not found") {
+			utils.WriteError(w, http.StatusNotFound, "DEPLOYMENT_NOT_FOUND", err.Error())
+			return
+		}
+		if strings.Contains(err.Error(), "already exists") {
+			utils.WriteError(w, http.StatusConflict, "DUPLICATE_ENDPOINT", err.Error())
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, "CREATE_FAILED", err.Error())
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusCreated, endpoint)
+}
+
+// ListEndpoints handles GET /api/v1/endpoints
+func (h *EndpointHandler) ListEndpoints(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Method not allowed")
+		return
+	}
+
+	values := r.URL.Query()
+	query := &service.ListEndpointsQuery{
+		Search:       values.Get("q"),
+		DeploymentID: values.Get("deployment_id"),
+		TenantID:     values.Get("tenant_id"),
+		ProductID:    values.Get("product_id"),
+		OS:           values.Get("os"),
+		Architecture: values.Get("architecture"),
+		AgentVersion: values.Get("agent_version"),
+		Status:       models.EndpointStatus(values.Get("status")),
+	}
+
+	// Labels are passed as repeated label=key:value parameters
+	for _, label := range values["label"] {
+		key, value, ok := strings.Cut(label, ":")
+		if !ok || key == "" {
+			utils.WriteError(w, http.StatusBadRequest, "INVALID_REQUEST", "Label filters must be in key:value format")
+			return
+		}
+		if query.Labels == nil {
+			query.Labels = make(map[string]string)
+		}
+		query.Labels[key] = value
+	}
+
+	if pageStr := values.Get("page"); pageStr != "" {
+		if page, err := strconv.Atoi(pageStr); err == nil && page > 0 {
+			query.Page = page
+		}
+	}
+	if limitStr := values.Get("limit"); limitStr != "" {
+		if limit, err := strconv.Atoi(limitStr); err == nil && limit > 0 && limit <= 100 {
+			query.Limit = limit
+		}
+	}
+
+	// Default values
+	if query.Page == 0 {
+		query.Page = 1
+	}
+	if query.Limit == 0 {
+		query.Limit = 20
+	}
+
+	response, err := h.endpointService.ListEndpoints(r.Context(), query)
+	if err != nil {
+		if strings.Contains(err.Error(), "invalid") {
+			utils.WriteError(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+			return
+		}
+		if strings.Contains(err.Error(), "not found") {
+			utils.WriteError(w, http.StatusNotFound, "DEPLOYMENT_NOT_FOUND", err.Error())
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, "LIST_FAILED", err.Error())
+		return
+	}
+
+	utils.WritePaginated(w, http.StatusOK, response.Endpoints, response.Pagination.Page, response.Pagination.Limit, response.Pagination.Total)
+}
+
+// GetEndpoint handles GET /api/v1/endpoints/:id
+func (h *EndpointHandler) GetEndpoint(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Method not allowed")
+		return
+	}
+
+	id := extractEndpointIDFromPath(r.URL.Path)
+	if id == "" {
+		utils.WriteError(w, http.StatusBadRequest, "INVALID_PATH", "Endpoint ID is required")
+		return
+	}
+
+	endpoint, err := h.endpointService.GetEndpoint(r.Context(), id)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			utils.WriteError(w, http.StatusNotFound, "ENDPOINT_NOT_FOUND", err.Error())
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, "GET_FAILED", err.Error())
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, endpoint)
+}
+
+// UpdateEndpoint handles PUT /api/v1/endpoints/:id
+func (h *EndpointHandler) UpdateEndpoint(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Method not allowed")
+		return
+	}
+
+	id := extractEndpointIDFromPath(r.URL.Path)
+	if id == "" {
+		utils.WriteError(w, http.StatusBadRequest, "INVALID_PATH", "Endpoint ID is required")
+		return
+	}
+
+	var req models.UpdateEndpointRequest
+	if err := utils.ReadJSON(w, r, &req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "INVALID_REQUEST", "Invalid JSON: "+err.Error())
+		return
+	}
+
+	userID, userEmail := requestUser(r)
+
+	endpoint, err := h.endpointService.UpdateEndpoint(r.Context(), id, &req, userID, userEmail)
+	if err != nil {
+		if strings.Contains(err.Error(), "invalid") {
+			utils.WriteError(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+			return
+		}
+		if strings.Contains(err.Error(), "not found") {
+			utils.WriteError(w, http.StatusNotFound, "ENDPOINT_NOT_FOUND", err.Error())
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, "UPDATE_FAILED", err.Error())
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, endpoint)
+}
+
+// DeleteEndpoint handles DELETE /api/v1/endpoints/:id
+func (h *EndpointHandler) DeleteEndpoint(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Method not allowed")
+		return
+	}
+
+	id := extractEndpointIDFromPath(r.URL.Path)
+	if id == "" {
+		utils.WriteError(w, http.StatusBadRequest, "INVALID_PATH", "Endpoint ID is required")
+		return
+	}
+
+	userID, userEmail := requestUser(r)
+
+	if err := h.endpointService.DeleteEndpoint(r.Context(), id, userID, userEmail); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			utils.WriteError(w, http.StatusNotFound, "ENDPOINT_NOT_FOUND", err.Error())
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, "DELETE_FAILED", err.Error())
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, map[string]string{"message": "Endpoint deleted successfully"})
+}
+
+// Heartbeat handles POST /api/v1/endpoints/:id/heartbeat
+func (h *EndpointHandler) Heartbeat(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Method not allowed")
+		return
+	}
+
+	id := extractEndpointIDFromPath(strings.TrimSuffix(r.URL.Path, "/heartbeat"))
+	if id == "" {
+		utils.WriteError(w, http.StatusBadRequest, "INVALID_PATH", "Endpoint ID is required")
+		return
+	}
+
+	// The body is optional; agents may send inventory changes with the heartbeat
+	var req models.EndpointHeartbeatRequest
+	if r.ContentLength > 0 {
+		if err := utils.ReadJSON(w, r, &req); err != nil {
+			utils.WriteError(w, http.StatusBadRequest, "INVALID_REQUEST", "Invalid JSON: "+err.Error())
+			return
+		}
+	}
+
+	endpoint, err := h.endpointService.RecordHeartbeat(r.Context(), id, &req)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			utils.WriteError(w, http.StatusNotFound, "ENDPOINT_NOT_FOUND", err.Error())
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, "HEARTBEAT_FAILED", err.Error())
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, endpoint)
+}
+
+// GetStaleEndpoints handles GET /api/v1/endpoints/stale
+func (h *EndpointHandler) GetStaleEndpoints(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Method not allowed")
+		return
+	}
+
+	days := 7
+	if daysStr := r.URL.Query().Get("days"); daysStr != "" {
+		parsed, err := strconv.Atoi(daysStr)
+		if err != nil || parsed < 1 {
+			utils.WriteError(w, http.StatusBadRequest, "INVALID_REQUEST", "Days must be a positive integer")
+			return
+		}
+		days = parsed
+	}
+
+	report, err := h.endpointService.GetStaleEndpoints(r.Context(), days, r.URL.Query().Get("product_id"))
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "REPORT_FAILED", err.Error())
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, report)
+}
+
+// extractEndpointIDFromPath returns the ID following "endpoints" in the path
+func extractEndpointIDFromPath(path string) string {
+	pathParts := strings.Split(strings.Trim(path, "/"), "/")
+	for i, part := range pathParts {
+		if part == "endpoints" && i+1 < len(pathParts) {
+			return pathParts[i+1]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,320 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"updatemanager/internal/api/utils"
+	"updatemanager/internal/models"
+	"updatemanager/internal/service"
+)
+
+// InternalRecipientHandler handles the HTTP requests managing internal groups
+// and the notification subscriptions of internal users and groups
+type InternalRecipientHandler struct {
+	internalService *service.InternalNotificationService
+}
+
+// NewInternalRecipientHandler creates a new internal recipient handler
+func NewInternalRecipientHandler(internalService *service.InternalNotificationService) *InternalRecipientHandler {
+	return &InternalRecipientHandler{
+		internalService: internalService,
+	}
+}
+
+// CreateGroup handles POST /api/v1/internal/groups
+func (h *InternalRecipientHandler) CreateGroup(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Method not allowed")
+		return
+	}
+
+	var req models.CreateInternalGroupRequest
+	if err := utils.ReadJSON(w, r, &req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "INVALID_REQUEST", "Invalid JSON: "+err.Error())
+		return
+	}
+
+	userID, userEmail := requestUser(r)
+
+	group, err := h.internalService.CreateGroup(r.Context(), &req, userID, userEmail)
+	if err != nil {
+		writeInternalNotificationError(w, err, "CREATE_FAILED")
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusCreated, group)
+}
+
+// ListGroups handles GET /api/v1/internal/groups
+func (h *InternalRecipientHandler) ListGroups(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Method not allowed")
+		return
+	}
+
+	page, limit := webhookPagination(r)
+
+	filter := bson.M{}
+	if member := r.URL.Query().Get("member"); member != "" {
+		filter["members"] = member
+	}
+
+	groups, total, err := h.internalService.ListGroups(r.Context(), filter, page, limit)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "LIST_FAILED", err.Error())
+		return
+	}
+
+	utils.WritePaginated(w, http.StatusOK, groups, page, limit, total)
+}
+
+// GetGroup handles GET /api/v1/internal/groups/:id
+func (h *InternalRecipientHandler) GetGroup(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Method not allowed")
+		return
+	}
+
+	id, err := extractInternalGroupIDFromPath(r.URL.Path)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "INVALID_ID", "Invalid internal group ID format")
+		return
+	}
+
+	group, err := h.internalService.GetGroup(r.Context(), id)
+	if err != nil {
+		writeInternalNotificationError(w, err, "GET_FAILED")
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, group)
+}
+
+// UpdateGroup handles PUT /api/v1/internal/groups/:id
+func (h *InternalRecipientHandler) UpdateGroup(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Method not allowed")
+		return
+	}
+
+	id, err := extractInternalGroupIDFromPath(r.URL.Path)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "INVALID_ID", "Invalid internal group ID format")
+		return
+	}
+
+	var req models.UpdateInternalGroupRequest
+	if err := utils.ReadJSON(w, r, &req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "INVALID_REQUEST", "Invalid JSON: "+err.Error())
+		return
+	}
+
+	userID, userEmail := requestUser(r)
+
+	group, err := h.internalService.UpdateGroup(r.Context(), id, &req, userID, userEmail)
+	if err != nil {
+		writeInternalNotificationError(w, err, "UPDATE_FAILED")
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, group)
+}
+
+// DeleteGroup handles DELETE /api/v1/internal/groups/:id
+func (h *InternalRecipientHandler) DeleteGroup(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Method not allowed")
+		return
+	}
+
+	id, err := extractInternalGroupIDFromPath(r.URL.Path)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "INVALID_ID", "Invalid internal group ID format")
+		return
+	}
+
+	userID, userEmail := requestUser(r)
+
+	if err := h.internalService.DeleteGroup(r.Context(), id, userID, userEmail); err != nil {
+		writeInternalNotificationError(w, err, "DELETE_FAILED")
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, map[string]string{"message": "Internal group deleted successfully"})
+}
+
+// CreateSubscription handles POST /api/v1/internal/notification-subscriptions
+func (h *InternalRecipientHandler) CreateSubscription(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Method not allowed")
+		return
+	}
+
+	var req models.CreateInternalNotificationSubscriptionRequest
+	if err := utils.ReadJSON(w, r, &req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "INVALID_REQUEST", "Invalid JSON: "+err.Error())
+		return
+	}
+
+	userID, userEmail := requestUser(r)
+
+	subscription, err := h.internalService.CreateSubscription(r.Context(), &req, userID, userEmail)
+	if err != nil {
+		writeInternalNotificationError(w, err, "CREATE_FAILED")
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusCreated, subscription)
+}
+
+// ListSubscriptions handles GET /api/v1/internal/notification-subscriptions
+func (h *InternalRecipientHandler) ListSubscriptions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Method not allowed")
+		return
+	}
+
+	page, limit := webhookPagination(r)
+
+	filter := bson.M{}
+	for _, field := range []string{"recipient_type", "recipient_id", "product_id"} {
+		if value := r.URL.Query().Get(field); value != "" {
+			filter[field] = value
+		}
+	}
+	if notificationType := r.URL.Query().Get("type"); notificationType != "" {
+		filter["types"] = notificationType
+	}
+	if isActive := r.URL.Query().Get("is_active"); isActive != "" {
+		filter["is_active"] = isActive == "true"
+	}
+
+	subscriptions, total, err := h.internalService.ListSubscriptions(r.Context(), filter, page, limit)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "LIST_FAILED", err.Error())
+		return
+	}
+
+	utils.WritePaginated(w, http.StatusOK, subscriptions, page, limit, total)
+}
+
+// GetSubscription handles GET /api/v1/internal/notification-subscriptions/:id
+func (h *InternalRecipientHandler) GetSubscription(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Method not allowed")
+		return
+	}
+
+	id, err := extractInternalSubscriptionIDFromPath(r.URL.Path)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "INVALID_ID", "Invalid subscription ID format")
+		return
+	}
+
+	subscription, err := h.internalService.GetSubscription(r.Context(), id)
+	if err != nil {
+		writeInternalNotificationError(w, err, "GET_FAILED")
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, subscription)
+}
+
+// UpdateSubscription handles PUT /api/v1/internal/notification-subscriptions/:id
+func (h *InternalRecipientHandler) UpdateSubscription(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Method not allowed")
+		return
+	}
+
+	id, err := extractInternalSubscriptionIDFromPath(r.URL.Path)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "INVALID_ID", "Invalid subscription ID format")
+		return
+	}
+
+	var req models.UpdateInternalNotificationSubscriptionRequest
+	if err := utils.ReadJSON(w, r, &req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "INVALID_REQUEST", "Invalid JSON: "+err.Error())
+		return
+	}
+
+	userID, userEmail := requestUser(r)
+
+	subscription, err := h.internalService.UpdateSubscription(r.Context(), id, &req, userID, userEmail)
+	if err != nil {
+		writeInternalNotificationError(w, err, "UPDATE_FAILED")
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, subscription)
+}
+
+// DeleteSubscription handles DELETE /api/v1/internal/notification-subscriptions/:id
+func (h *InternalRecipientHandler) DeleteSubscription(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Method not allowed")
+		return
+	}
+
+	id, err := extractInternalSubscriptionIDFromPath(r.URL.Path)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "INVALID_ID", "Invalid subscription ID format")
+		return
+	}
+
+	userID, userEmail := requestUser(r)
+
+	if err := h.internalService.DeleteSubscription(r.Context(), id, userID, userEmail); err != nil {
+		writeInternalNotificationError(w, err, "DELETE_FAILED")
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, map[string]string{"message": "Subscription deleted successfully"})
+}
+
+// writeInternalNotificationError maps internal group and subscription service
+// errors to responses
+func writeInternalNotificationError(w http.ResponseWriter, err error, fallbackCode string) {
+	switch {
+	case strings.Contains(err.Error(), "invalid internal group"):
+		utils.WriteError(w, http.StatusBadRequest, "INVALID_INTERNAL_GROUP", err.Error())
+	case strings.Contains(err.Error(), "invalid internal notification subscription"):
+		utils.WriteError(w, http.StatusBadRequest, "INVALID_SUBSCRIPTION", err.Error())
+	case strings.Contains(err.Error(), "already exists"):
+		utils.WriteError(w, http.StatusConflict, "ALREADY_EXISTS", err.Error())
+	case strings.Contains(err.Error(), "internal group not found"):
+		utils.WriteError(w, http.StatusNotFound, "INTERNAL_GROUP_NOT_FOUND", "Internal group not found")
+	case strings.Contains(err.Error(), "internal notification subscription not found"):
+		utils.WriteError(w, http.StatusNotFound, "SUBSCRIPTION_NOT_FOUND", "Subscription not found")
+	default:
+		utils.WriteError(w, http.StatusInternalServerError, fallbackCode, err.Error())
+	}
+}
+
+// extractInternalGroupIDFromPath returns the group ID following "groups" in the path
+func extractInternalGroupIDFromPath(path string) (primitive.ObjectID, error) {
+	pathParts := strings.Split(strings.Trim(path, "/"), "/")
+	for i, part := range pathParts {
+		if part == "groups" && i+1 < len(pathParts) {
+			return primitive.ObjectIDFromHex(pathParts[i+1])
+		}
+	}
+	return primitive.NilObjectID, primitive.ErrInvalidHex
+}
+
+// extractInternalSubscriptionIDFromPath returns the subscription ID following
+// "notification-subscriptions" in the path
+func extractInternalSubscriptionIDFromPath(path string) (primitive.ObjectID, error) {
+	pathParts := strings.Split(strings.Trim(path, "/"), "/")
+	for i, part := range pathParts {
+		if part == "notification-subscriptions" && i+1 < len(pathParts) {
+			return primitive.ObjectIDFromHex(pathParts[i+1])
+		}
+	}
+	return primitive.NilObjectID, primitive.ErrInvalidHex
+}
@@ -1,7 +1,11 @@
 package handlers
 
 import (
+	"context"
+	"fmt"
 	"net/http"
+	"strings"
+	"time"
 
 	"updatemanager/internal/api/utils"
 	"updatemanager/internal/models"
@@ -11,6 +15,7 @@ import (
 // NotificationHandler handles notification-related HTTP requests
 type NotificationHandler struct {
 	notificationService *service.NotificationService
+	internal            bool // Serves the requesting user's internal inbox
 }
 
 // NewNotificationHandler creates a new notification handler
@@ -20,6 +25,15 @@ func NewNotificationHandler(notificationService *service.NotificationService) *N
 	}
 }
 
+// NewInternalNotificationHandler creates a handler of the internal inbox. It
+// serves the notifications of the user in X-User-ID instead of a recipient_id.
+func NewInternalNotificationHandler(inbox *service.NotificationService) *NotificationHandler {
+	return &NotificationHandler{
+		notificationService: inbox,
+		internal:            true,
+	}
+}
+
 // CreateNotification handles POST /api/v1/notifications
 func (h *NotificationHandler) CreateNotification(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
@@ -48,30 +62,167 @@ func (h *NotificationHandler) GetNotifications(w http.ResponseWriter, r *http.Re
 		return
 	}
 
-	recipientID := r.URL.Query().Get("recipient_id")
-	if recipientID == "" {
-		utils.WriteError(w, http.StatusBadRequest, "MISSING_RECIPIENT_ID", "recipient_id query parameter is required")
+	recipientID, ok := h.recipientID(w, r, r.URL.Query().Get("recipient_id"))
+	if !ok {
 		return
 	}
 
-	page := utils.GetIntQueryParam(r, "page", 1)
-	limit := utils.GetIntQueryParam(r, "limit", 10)
-	unreadOnly := r.URL.Query().Get("unread_only") == "true"
+	query := &service.ListNotificationsQuery{
+		UnreadOnly: r.URL.Query().Get("unread_only") == "true",
+		Archived:   r.URL.Query().Get("archived") == "true",
+		Type:       models.NotificationType(r.URL.Query().Get("type")),
+		Priority:   models.NotificationPriority(r.URL.Query().Get("priority")),
+		ProductID:  r.URL.Query().Get("product_id"),
+		CustomerID: r.URL.Query().Get("customer_id"),
+		Page:       utils.GetIntQueryParam(r, "page", 1),
+		Limit:      utils.GetIntQueryParam(r, "limit", 10),
+	}
 
-	if page < 1 {
-		page = 1
+	if query.Page < 1 {
+		query.Page = 1
 	}
-	if limit < 1 || limit > 100 {
-		limit = 10
+	if query.Limit < 1 || query.Limit > 100 {
+		query.Limit = 10
 	}
 
-	notifications, total, err := h.notificationService.GetNotifications(r.Context(), recipientID, page, limit, unreadOnly)
+	var err error
+	if query.From, err = timeQueryParam(r, "from"); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+	if query.To, err = timeQueryParam(r, "to"); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+
+	notifications, total, err := h.notificationService.ListNotifications(r.Context(), recipientID, query)
 	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, "GET_FAILED", err.Error())
 		return
 	}
 
-	utils.WritePaginated(w, http.StatusOK, notifications, page, limit, total)
+	utils.WritePaginated(w, http.StatusOK, notifications, query.Page, query.Limit, total)
+}
+
+// GetNotification handles GET /api/v1/notifications/:id
+func (h *NotificationHandler) GetNotification(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Method not allowed")
+		return
+	}
+
+	id, err := h.notificationID(r)
+	if err != nil {
+		writeNotificationError(w, err, "GET_FAILED")
+		return
+	}
+
+	notification, err := h.notificationService.GetNotification(r.Context(), id)
+	if err != nil {
+		writeNotificationError(w, err, "GET_FAILED")
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, notification)
+}
+
+// MarkAsRead handles POST /api/v1/notifications/:id/read
+func (h *NotificationHandler) MarkAsRead(w http.ResponseWriter, r *http.Request) {
+	h.updateNotification(w, r, h.notificationService.MarkAsRead, "Notification marked as read")
+}
+
+// MarkAsUnread handles POST /api/v1/notifications/:id/unread
+func (h *NotificationHandler) MarkAsUnread(w http.ResponseWriter, r *http.Request) {
+	h.updateNotification(w, r, h.notificationService.MarkAsUnread, "Notification marked as unread")
+}
+
+// Archive handles POST /api/v1/notifications/:id/archive
+func (h *NotificationHandler) Archive(w http.ResponseWriter, r *http.Request) {
+	h.updateNotification(w, r, func(ctx context.Context, id string) error {
+		return h.notificationService.SetArchived(ctx, id, true)
+	}, "Notification archived")
+}
+
+// Unarchive handles POST /api/v1/notifications/:id/unarchive
+func (h *NotificationHandler) Unarchive(w http.ResponseWriter, r *http.Request) {
+	h.updateNotification(w, r, func(ctx context.Context, id string) error {
+		return h.notificationService.SetArchived(ctx, id, false)
+	}, "Notification moved to the inbox")
+}
+
+// updateNotification applies a POST action to the notification in the path
+func (h *NotificationHandler) updateNotification(w http.ResponseWriter, r *http.Request, update func(context.Context, string) error, message string) {
+	if r.Method != http.MethodPost {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Method not allowed")
+		return
+	}
+
+	id, err := h.notificationID(r)
+	if err != nil {
+		writeNotificationError(w, err, "UPDATE_FAILED")
+		return
+	}
+
+	if err := update(r.Context(), id); err != nil {
+		writeNotificationError(w, err, "UPDATE_FAILED")
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, map[string]string{"message": message})
+}
+
+// DeleteNotification handles DELETE /api/v1/notifications/:id
+func (h *NotificationHandler) DeleteNotification(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Method not allowed")
+		return
+	}
+
+	id, err := h.notificationID(r)
+	if err != nil {
+		writeNotificationError(w, err, "DELETE_FAILED")
+		return
+	}
+
+	if err := h.notificationService.DeleteNotification(r.Context(), id); err != nil {
+		writeNotificationError(w, err, "DELETE_FAILED")
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, map[string]string{"message": "Notification deleted successfully"})
+}
+
+// BulkUpdate handles POST /api/v1/notifications/bulk
+func (h *NotificationHandler) BulkUpdate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Method not allowed")
+		return
+	}
+
+	var req models.BulkNotificationRequest
+	if err := utils.ReadJSON(w, r, &req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "INVALID_REQUEST", "Invalid JSON: "+err.Error())
+		return
+	}
+
+	if h.internal {
+		userID, ok := h.recipientID(w, r, "")
+		if !ok {
+			return
+		}
+		req.RecipientID = userID
+	}
+
+	count, err := h.notificationService.BulkUpdate(r.Context(), &req)
+	if err != nil {
+		writeNotificationError(w, err, "BULK_FAILED")
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, map[string]interface{}{
+		"action":  req.Action,
+		"matched": count,
+	})
 }
 
 // GetUnreadCount handles GET /api/v1/notifications/unread-count
@@ -81,9 +232,8 @@ func (h *NotificationHandler) GetUnreadCount(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	recipientID := r.URL.Query().Get("recipient_id")
-	if recipientID == "" {
-		utils.WriteError(w, http.StatusBadRequest, "MISSING_RECIPIENT_ID", "recipient_id query parameter is required")
+	recipientID, ok := h.recipientID(w, r, r.URL.Query().Get("recipient_id"))
+	if !ok {
 		return
 	}
 
@@ -114,15 +264,89 @@ func (h *NotificationHandler) MarkAllAsRead(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	if req.RecipientID == "" {
-		utils.WriteError(w, http.StatusBadRequest, "MISSING_RECIPIENT_ID", "recipient_id is required")
+	recipientID, ok := h.recipientID(w, r, req.RecipientID)
+	if !ok {
 		return
 	}
 
-	if err := h.notificationService.MarkAllAsRead(r.Context(), req.RecipientID); err != nil {
+	if err := h.notificationService.MarkAllAsRead(r.Context(), recipientID); err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, "MARK_FAILED", err.Error())
 		return
 	}
 
 	utils.WriteSuccess(w, http.StatusOK, map[string]string{"message": "All notifications marked as read"})
 }
+
+// recipientID returns the recipient a request is about: the requesting user
+// in the internal inbox, else requested. It writes an error and returns false
+// if there is none.
+func (h *NotificationHandler) recipientID(w http.ResponseWriter, r *http.Request, requested string) (string, bool) {
+	if h.internal {
+		userID := r.Header.Get("X-User-ID")
+		if userID == "" {
+			utils.WriteError(w, http.StatusBadRequest, "MISSING_USER_ID", "X-User-ID header is required")
+			return "", false
+		}
+		return userID, true
+	}
+	if requested == "" {
+		utils.WriteError(w, http.StatusBadRequest, "MISSING_RECIPIENT_ID", "recipient_id is required")
+		return "", false
+	}
+	return requested, true
+}
+
+// notificationID returns the notification ID in the path. In the internal
+// inbox other users' notifications are not found.
+func (h *NotificationHandler) notificationID(r *http.Request) (string, error) {
+	id := extractNotificationIDFromPath(r.URL.Path)
+	if !h.internal {
+		return id, nil
+	}
+	notification, err := h.notificationService.GetNotification(r.Context(), id)
+	if err != nil {
+		return "", err
+	}
+	if notification.RecipientID != r.Header.Get("X-User-ID") {
+		return "", fmt.Errorf("notification not found")
+	}
+	return id, nil
+}
+
+// timeQueryParam parses an optional RFC 3339 query parameter
+func timeQueryParam(r *http.Request, name string) (*time.Time, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return nil, nil
+	}
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be an RFC 3339 timestamp", name)
+	}
+	return &parsed, nil
+}
+
+// writeNotificationError maps notification service errors to responses
+func writeNotificationError(w http.ResponseWriter, err error, fallbackCode string) {
+	switch {
+	case strings.Contains(err.Error(), "invalid notification ID"):
+		utils.WriteError(w, http.StatusBadRequest, "INVALID_ID", "Invalid notification ID format")
+	case strings.Contains(err.Error(), "invalid bulk request"):
+		utils.WriteError(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+	case strings.Contains(err.Error(), "notification not found"):
+		utils.WriteError(w, http.StatusNotFound, "NOTIFICATION_NOT_FOUND", "Notification not found")
+	default:
+		utils.WriteError(w, http.StatusInternalServerError, fallbackCode, err.Error())
+	}
+}
+
+// extractNotificationIDFromPath returns the path segment following "notifications"
+func extractNotificationIDFromPath(path string) string {
+	pathParts := strings.Split(strings.Trim(path, "/"), "/")
+	for i, part := range pathParts {
+		if part == "notifications" && i+1 < len(pathParts) {
+			return pathParts[i+1]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"updatemanager/internal/api/utils"
+	"updatemanager/internal/models"
+	"updatemanager/internal/service"
+)
+
+// NotificationRouteHandler handles notification route HTTP requests
+type NotificationRouteHandler struct {
+	routeService *service.NotificationRouteService
+}
+
+// NewNotificationRouteHandler creates a new notification route handler
+func NewNotificationRouteHandler(routeService *service.NotificationRouteService) *NotificationRouteHandler {
+	return &NotificationRouteHandler{
+		routeService: routeService,
+	}
+}
+
+// CreateRoute handles POST /api/v1/notification-routes
+func (h *NotificationRouteHandler) CreateRoute(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Method not allowed")
+		return
+	}
+
+	var req models.CreateNotificationRouteRequest
+	if err := utils.ReadJSON(w, r, &req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "INVALID_REQUEST", "Invalid JSON: "+err.Error())
+		return
+	}
+
+	userID, userEmail := requestUser(r)
+
+	route, err := h.routeService.CreateRoute(r.Context(), &req, userID, userEmail)
+	if err != nil {
+		if strings.Contains(err.Error(), "customer not found") {
+			utils.WriteError(w, http.StatusNotFound, "NOT_FOUND", err.Error())
+			return
+		}
+		writeNotificationRouteError(w, err, "CREATE_FAILED")
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusCreated, route)
+}
+
+// ListRoutes handles GET /api/v1/notification-routes
+func (h *NotificationRouteHandler) ListRoutes(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Method not allowed")
+		return
+	}
+
+	page, limit := webhookPagination(r)
+
+	filter := bson.M{}
+	if customerID := r.URL.Query().Get("customer_id"); customerID != "" {
+		filter["customer_id"] = customerID
+	}
+	if channel := r.URL.Query().Get("channel"); channel != "" {
+		filter["channel"] = channel
+	}
+	if isActive := r.URL.Query().Get("is_active"); isActive != "" {
+		filter["is_active"] = isActive == "true"
+	}
+
+	routes, total, err := h.routeService.ListRoutes(r.Context(), filter, page, limit)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "LIST_FAILED", err.Error())
+		return
+	}
+
+	utils.WritePaginated(w, http.StatusOK, routes, page, limit, total)
+}
+
+// GetRoute handles GET /api/v1/notification-routes/:id
+func (h *NotificationRouteHandler) GetRoute(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Method not allowed")
+		return
+	}
+
+	id, err := extractNotificationRouteIDFromPath(r.URL.Path)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "INVALID_ID", "Invalid notification route ID format")
+		return
+	}
+
+	route, err := h.routeService.GetRoute(r.Context(), id)
+	if err != nil {
+		writeNotificationRouteError(w, err, "GET_FAILED")
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, route)
+}
+
+// UpdateRoute handles PUT /api/v1/notification-routes/:id
+func (h *NotificationRouteHandler) UpdateRoute(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Method not allowed")
+		return
+	}
+
+	id, err := extractNotificationRouteIDFromPath(r.URL.Path)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "INVALID_ID", "Invalid notification route ID format")
+		return
+	}
+
+	var req models.UpdateNotificationRouteRequest
+	if err := utils.ReadJSON(w, r, &req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "INVALID_REQUEST", "Invalid JSON: "+err.Error())
+		return
+	}
+
+	userID, userEmail := requestUser(r)
+
+	route, err := h.routeService.UpdateRoute(r.Context(), id, &req, userID, userEmail)
+	if err != nil {
+		writeNotificationRouteError(w, err, "UPDATE_FAILED")
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, route)
+}
+
+// DeleteRoute handles DELETE /api/v1/notification-routes/:id
+func (h *NotificationRouteHandler) DeleteRoute(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Method not allowed")
+		return
+	}
+
+	id, err := extractNotificationRouteIDFromPath(r.URL.Path)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "INVALID_ID", "Invalid notification route ID format")
+		return
+	}
+
+	userID, userEmail := requestUser(r)
+
+	if err := h.routeService.DeleteRoute(r.Context(), id, userID, userEmail); err != nil {
+		writeNotificationRouteError(w, err, "DELETE_FAILED")
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, map[string]string{"message": "Notification route deleted successfully"})
+}
+
+// writeNotificationRouteError maps notification route service errors to responses
+func writeNotificationRouteError(w http.ResponseWriter, err error, fallbackCode string) {
+	switch {
+	case strings.Contains(err.Error(), "invalid notification route"):
+		utils.WriteError(w, http.StatusBadRequest, "INVALID_NOTIFICATION_ROUTE", err.Error())
+	case strings.Contains(err.Error(), "notification route not found"):
+		utils.WriteError(w, http.StatusNotFound, "NOTIFICATION_ROUTE_NOT_FOUND", "Notification route not found")
+	default:
+		utils.WriteError(w, http.StatusInternalServerError, fallbackCode, err.Error())
+	}
+}
+
+// extractNotificationRouteIDFromPath returns the route ID following "notification-routes" in the path
+func extractNotificationRouteIDFromPath(path string) (primitive.ObjectID, error) {
+	pathParts := strings.Split(strings.Trim(path, "/"), "/")
+	for i, part := range pathParts {
+		if part == "notification-routes" && i+1 < len(pathParts) {
+			return primitive.ObjectIDFromHex(pathParts[i+1])
+		}
+	}
+	return primitive.NilObjectID, primitive.ErrInvalidHex
+}
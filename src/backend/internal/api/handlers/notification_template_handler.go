@@ -0,0 +1,208 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"updatemanager/internal/api/utils"
+	"updatemanager/internal/models"
+	"updatemanager/internal/notify"
+	"updatemanager/internal/service"
+)
+
+// NotificationTemplateHandler handles notification template HTTP requests
+type NotificationTemplateHandler struct {
+	templateService *service.NotificationTemplateService
+}
+
+// NewNotificationTemplateHandler creates a new notification template handler
+func NewNotificationTemplateHandler(templateService *service.NotificationTemplateService) *NotificationTemplateHandler {
+	return &NotificationTemplateHandler{
+		templateService: templateService,
+	}
+}
+
+// CreateTemplate handles POST /api/v1/notification-templates
+func (h *NotificationTemplateHandler) CreateTemplate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Method not allowed")
+		return
+	}
+
+	var req models.CreateNotificationTemplateRequest
+	if err := utils.ReadJSON(w, r, &req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "INVALID_REQUEST", "Invalid JSON: "+err.Error())
+		return
+	}
+
+	userID, userEmail := requestUser(r)
+
+	template, err := h.templateService.CreateTemplate(r.Context(), &req, userID, userEmail)
+	if err != nil {
+		if strings.Contains(err.Error(), "already exists") {
+			utils.WriteError(w, http.StatusConflict, "DUPLICATE_NOTIFICATION_TEMPLATE", err.Error())
+			return
+		}
+		writeNotificationTemplateError(w, err, "CREATE_FAILED")
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusCreated, template)
+}
+
+// ListTemplates handles GET /api/v1/notification-templates
+func (h *NotificationTemplateHandler) ListTemplates(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Method not allowed")
+		return
+	}
+
+	page, limit := webhookPagination(r)
+
+	filter := bson.M{}
+	if notificationType := r.URL.Query().Get("type"); notificationType != "" {
+		filter["type"] = notificationType
+	}
+	if channel := r.URL.Query().Get("channel"); channel != "" {
+		filter["channel"] = channel
+	}
+	if locale := r.URL.Query().Get("locale"); locale != "" {
+		normalized, _ := notify.NormalizeLocale(locale)
+		filter["locale"] = normalized
+	}
+
+	templates, total, err := h.templateService.ListTemplates(r.Context(), filter, page, limit)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "LIST_FAILED", err.Error())
+		return
+	}
+
+	utils.WritePaginated(w, http.StatusOK, templates, page, limit, total)
+}
+
+// GetTemplate handles GET /api/v1/notification-templates/:id
+func (h *NotificationTemplateHandler) GetTemplate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Method not allowed")
+		return
+	}
+
+	id, err := extractNotificationTemplateIDFromPath(r.URL.Path)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "INVALID_ID", "Invalid notification template ID format")
+		return
+	}
+
+	template, err := h.templateService.GetTemplate(r.Context(), id)
+	if err != nil {
+		writeNotificationTemplateError(w, err, "GET_FAILED")
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, template)
+}
+
+// UpdateTemplate handles PUT /api/v1/notification-templates/:id
+func (h *NotificationTemplateHandler) UpdateTemplate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Method not allowed")
+		return
+	}
+
+	id, err := extractNotificationTemplateIDFromPath(r.URL.Path)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "INVALID_ID", "Invalid notification template ID format")
+		return
+	}
+
+	var req models.UpdateNotificationTemplateRequest
+	if err := utils.ReadJSON(w, r, &req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "INVALID_REQUEST", "Invalid JSON: "+err.Error())
+		return
+	}
+
+	userID, userEmail := requestUser(r)
+
+	template, err := h.templateService.UpdateTemplate(r.Context(), id, &req, userID, userEmail)
+	if err != nil {
+		writeNotificationTemplateError(w, err, "UPDATE_FAILED")
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, template)
+}
+
+// DeleteTemplate handles DELETE /api/v1/notification-templates/:id
+func (h *NotificationTemplateHandler) DeleteTemplate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Method not allowed")
+		return
+	}
+
+	id, err := extractNotificationTemplateIDFromPath(r.URL.Path)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "INVALID_ID", "Invalid notification template ID format")
+		return
+	}
+
+	userID, userEmail := requestUser(r)
+
+	if err := h.templateService.DeleteTemplate(r.Context(), id, userID, userEmail); err != nil {
+		writeNotificationTemplateError(w, err, "DELETE_FAILED")
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, map[string]string{"message": "Notification template deleted successfully"})
+}
+
+// PreviewTemplate handles POST /api/v1/notification-templates/preview
+func (h *NotificationTemplateHandler) PreviewTemplate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Method not allowed")
+		return
+	}
+
+	var req models.PreviewNotificationTemplateRequest
+	if err := utils.ReadJSON(w, r, &req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "INVALID_REQUEST", "Invalid JSON: "+err.Error())
+		return
+	}
+
+	preview, err := h.templateService.Preview(r.Context(), &req)
+	if err != nil {
+		if strings.Contains(err.Error(), "customer not found") || strings.Contains(err.Error(), "version not found") {
+			utils.WriteError(w, http.StatusNotFound, "NOT_FOUND", err.Error())
+			return
+		}
+		writeNotificationTemplateError(w, err, "PREVIEW_FAILED")
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, preview)
+}
+
+// writeNotificationTemplateError maps notification template service errors to responses
+func writeNotificationTemplateError(w http.ResponseWriter, err error, fallbackCode string) {
+	switch {
+	case strings.Contains(err.Error(), "invalid notification template"):
+		utils.WriteError(w, http.StatusBadRequest, "INVALID_NOTIFICATION_TEMPLATE", err.Error())
+	case strings.Contains(err.Error(), "notification template not found"):
+		utils.WriteError(w, http.StatusNotFound, "NOTIFICATION_TEMPLATE_NOT_FOUND", "Notification template not found")
+	default:
+		utils.WriteError(w, http.StatusInternalServerError, fallbackCode, err.Error())
+	}
+}
+
+// extractNotificationTemplateIDFromPath returns the template ID following "notification-templates" in the path
+func extractNotificationTemplateIDFromPath(path string) (primitive.ObjectID, error) {
+	pathParts := strings.Split(strings.Trim(path, "/"), "/")
+	for i, part := range pathParts {
+		if part == "notification-templates" && i+1 < len(pathParts) {
+			return primitive.ObjectIDFromHex(pathParts[i+1])
+		}
+	}
+	return primitive.NilObjectID, primitive.ErrInvalidHex
+}
@@ -74,6 +74,10 @@ func (h *ProductHandler) CreateProduct(w http.ResponseWriter, r *http.Request) {
 			utils.WriteError(w, http.StatusConflict, "DUPLICATE_PRODUCT", err.Error())
 			return
 		}
+		if strings.Contains(err.Error(), "invalid rollout timeouts") {
+			utils.WriteError(w, http.StatusBadRequest, "INVALID_ROLLOUT_TIMEOUTS", err.Error())
+			return
+		}
 		utils.WriteError(w, http.StatusInternalServerError, "CREATE_FAILED", err.Error())
 		return
 	}
@@ -194,6 +198,10 @@ func (h *ProductHandler) UpdateProduct(w http.ResponseWriter, r *http.Request) {
 			utils.WriteError(w, http.StatusConflict, "DUPLICATE_PRODUCT", err.Error())
 			return
 		}
+		if strings.Contains(err.Error(), "invalid rollout timeouts") {
+			utils.WriteError(w, http.StatusBadRequest, "INVALID_ROLLOUT_TIMEOUTS", err.Error())
+			return
+		}
 		utils.WriteError(w, http.StatusInternalServerError, "UPDATE_FAILED", err.Error())
 		return
 	}
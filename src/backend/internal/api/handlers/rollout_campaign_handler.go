@@ -0,0 +1,225 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"updatemanager/internal/api/utils"
+	"updatemanager/internal/models"
+	"updatemanager/internal/service"
+)
+
+// RolloutCampaignHandler handles rollout campaign HTTP requests
+type RolloutCampaignHandler struct {
+	campaignService *service.RolloutCampaignService
+}
+
+// NewRolloutCampaignHandler creates a new rollout campaign handler
+func NewRolloutCampaignHandler(campaignService *service.RolloutCampaignService) *RolloutCampaignHandler {
+	return &RolloutCampaignHandler{
+		campaignService: campaignService,
+	}
+}
+
+// CreateCampaign handles POST /api/v1/rollout-campaigns
+func (h *RolloutCampaignHandler) CreateCampaign(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Method not allowed")
+		return
+	}
+
+	var req models.CreateRolloutCampaignRequest
+	if err := utils.ReadJSON(w, r, &req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "INVALID_REQUEST", "Invalid JSON: "+err.Error())
+		return
+	}
+
+	userID, userEmail := requestUser(r)
+
+	campaign, err := h.campaignService.CreateCampaign(r.Context(), &req, userID, userEmail)
+	if err != nil {
+		if strings.Contains(err.Error(), "invalid campaign") {
+			utils.WriteError(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+			return
+		}
+		if strings.Contains(err.Error(), "not found") {
+			utils.WriteError(w, http.StatusNotFound, "NOT_FOUND", err.Error())
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, "CREATE_FAILED", err.Error())
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusCreated, campaign)
+}
+
+// ListCampaigns handles GET /api/v1/rollout-campaigns
+func (h *RolloutCampaignHandler) ListCampaigns(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Method not allowed")
+		return
+	}
+
+	page := utils.GetIntQueryParam(r, "page", 1)
+	limit := utils.GetIntQueryParam(r, "limit", 10)
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+
+	filter := bson.M{}
+	if productID := r.URL.Query().Get("product_id"); productID != "" {
+		filter["product_id"] = productID
+	}
+	if toVersion := r.URL.Query().Get("to_version"); toVersion != "" {
+		filter["to_version"] = toVersion
+	}
+	if status := r.URL.Query().Get("status"); status != "" {
+		filter["status"] = status
+	}
+
+	campaigns, total, err := h.campaignService.ListCampaigns(r.Context(), filter, page, limit)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "LIST_FAILED", err.Error())
+		return
+	}
+
+	utils.WritePaginated(w, http.StatusOK, campaigns, page, limit, total)
+}
+
+// GetCampaign handles GET /api/v1/rollout-campaigns/:id
+func (h *RolloutCampaignHandler) GetCampaign(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Method not allowed")
+		return
+	}
+
+	id, err := extractCampaignIDFromPath(r.URL.Path)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "INVALID_ID", "Invalid campaign ID format")
+		return
+	}
+
+	campaign, err := h.campaignService.GetCampaign(r.Context(), id)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			utils.WriteError(w, http.StatusNotFound, "CAMPAIGN_NOT_FOUND", "Rollout campaign not found")
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, "GET_FAILED", err.Error())
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, campaign)
+}
+
+// ListCampaignRollouts handles GET /api/v1/rollout-campaigns/:id/rollouts
+func (h *RolloutCampaignHandler) ListCampaignRollouts(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Method not allowed")
+		return
+	}
+
+	id, err := extractCampaignIDFromPath(r.URL.Path)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "INVALID_ID", "Invalid campaign ID format")
+		return
+	}
+
+	page := utils.GetIntQueryParam(r, "page", 1)
+	limit := utils.GetIntQueryParam(r, "limit", 10)
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+
+	rollouts, total, err := h.campaignService.ListCampaignRollouts(r.Context(), id, page, limit)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			utils.WriteError(w, http.StatusNotFound, "CAMPAIGN_NOT_FOUND", "Rollout campaign not found")
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, "LIST_FAILED", err.Error())
+		return
+	}
+
+	utils.WritePaginated(w, http.StatusOK, rollouts, page, limit, total)
+}
+
+// PauseCampaign handles POST /api/v1/rollout-campaigns/:id/pause
+func (h *RolloutCampaignHandler) PauseCampaign(w http.ResponseWriter, r *http.Request) {
+	h.changeCampaignState(w, r, func(id primitive.ObjectID, userID, userEmail string) (*models.RolloutCampaign, error) {
+		return h.campaignService.PauseCampaign(r.Context(), id, userID, userEmail)
+	})
+}
+
+// ResumeCampaign handles POST /api/v1/rollout-campaigns/:id/resume
+func (h *RolloutCampaignHandler) ResumeCampaign(w http.ResponseWriter, r *http.Request) {
+	h.changeCampaignState(w, r, func(id primitive.ObjectID, userID, userEmail string) (*models.RolloutCampaign, error) {
+		return h.campaignService.ResumeCampaign(r.Context(), id, userID, userEmail)
+	})
+}
+
+// AbortCampaign handles POST /api/v1/rollout-campaigns/:id/abort
+func (h *RolloutCampaignHandler) AbortCampaign(w http.ResponseWriter, r *http.Request) {
+	h.changeCampaignState(w, r, func(id primitive.ObjectID, userID, userEmail string) (*models.RolloutCampaign, error) {
+		// The reason is optional
+		var req models.AbortRolloutCampaignRequest
+		if r.ContentLength > 0 {
+			if err := utils.ReadJSON(w, r, &req); err != nil {
+				return nil, err
+			}
+		}
+		return h.campaignService.AbortCampaign(r.Context(), id, req.Reason, userID, userEmail)
+	})
+}
+
+// changeCampaignState runs a pause/resume/abort operation and maps its errors
+func (h *RolloutCampaignHandler) changeCampaignState(w http.ResponseWriter, r *http.Request, change func(id primitive.ObjectID, userID, userEmail string) (*models.RolloutCampaign, error)) {
+	if r.Method != http.MethodPost {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Method not allowed")
+		return
+	}
+
+	id, err := extractCampaignIDFromPath(r.URL.Path)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "INVALID_ID", "Invalid campaign ID format")
+		return
+	}
+
+	userID, userEmail := requestUser(r)
+
+	campaign, err := change(id, userID, userEmail)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			utils.WriteError(w, http.StatusNotFound, "CAMPAIGN_NOT_FOUND", "Rollout campaign not found")
+			return
+		}
+		if strings.Contains(err.Error(), "invalid campaign state") {
+			utils.WriteError(w, http.StatusConflict, "INVALID_CAMPAIGN_STATE", err.Error())
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, "UPDATE_FAILED", err.Error())
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, campaign)
+}
+
+// extractCampaignIDFromPath returns the campaign ID following "rollout-campaigns" in the path
+func extractCampaignIDFromPath(path string) (primitive.ObjectID, error) {
+	pathParts := strings.Split(strings.Trim(path, "/"), "/")
+	for i, part := range pathParts {
+		if part == "rollout-campaigns" && i+1 < len(pathParts) {
+			return primitive.ObjectIDFromHex(pathParts[i+1])
+		}
+	}
+	return primitive.NilObjectID, primitive.ErrInvalidHex
+}
@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"updatemanager/internal/api/utils"
+	"updatemanager/internal/models"
+	"updatemanager/internal/service"
+)
+
+// RolloutEventHandler handles rollout timeline HTTP requests
+type RolloutEventHandler struct {
+	eventService *service.RolloutEventService
+}
+
+// NewRolloutEventHandler creates a new rollout event handler
+func NewRolloutEventHandler(eventService *service.RolloutEventService) *RolloutEventHandler {
+	return &RolloutEventHandler{
+		eventService: eventService,
+	}
+}
+
+// RecordEvents handles POST /api/v1/update-rollouts/:id/events
+func (h *RolloutEventHandler) RecordEvents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Method not allowed")
+		return
+	}
+
+	id, ok := rolloutEventsID(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.RecordRolloutEventsRequest
+	if err := utils.ReadJSON(w, r, &req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "INVALID_REQUEST", "Invalid JSON: "+err.Error())
+		return
+	}
+
+	result, err := h.eventService.RecordEvents(r.Context(), id, &req)
+	if err != nil {
+		if strings.Contains(err.Error(), "invalid rollout event") {
+			utils.WriteError(w, http.StatusBadRequest, "INVALID_ROLLOUT_EVENT", err.Error())
+			return
+		}
+		if strings.Contains(err.Error(), "not found") {
+			utils.WriteError(w, http.StatusNotFound, "ROLLOUT_NOT_FOUND", "Update rollout not found")
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, "RECORD_FAILED", err.Error())
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusCreated, result)
+}
+
+// ListEvents handles GET /api/v1/update-rollouts/:id/events
+func (h *RolloutEventHandler) ListEvents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Method not allowed")
+		return
+	}
+
+	id, ok := rolloutEventsID(w, r)
+	if !ok {
+		return
+	}
+
+	page := utils.GetIntQueryParam(r, "page", 1)
+	limit := utils.GetIntQueryParam(r, "limit", 100)
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 100
+	}
+
+	filter := bson.M{}
+	if step := r.URL.Query().Get("step"); step != "" {
+		filter["step"] = step
+	}
+	// level takes a comma-separated list, e.g. warning,error
+	if level := r.URL.Query().Get("level"); level != "" {
+		filter["level"] = bson.M{"$in": strings.Split(level, ",")}
+	}
+
+	events, total, err := h.eventService.ListEvents(r.Context(), id, filter, page, limit)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			utils.WriteError(w, http.StatusNotFound, "ROLLOUT_NOT_FOUND", "Update rollout not found")
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, "LIST_FAILED", err.Error())
+		return
+	}
+
+	utils.WritePaginated(w, http.StatusOK, events, page, limit, total)
+}
+
+// rolloutEventsID parses the rollout ID of an events path, writing the error
+// response if it is invalid
+func rolloutEventsID(w http.ResponseWriter, r *http.Request) (primitive.ObjectID, bool) {
+	idStr := strings.TrimPrefix(r.URL.Path, "/api/v1/update-rollouts/")
+	idStr = strings.TrimSuffix(idStr, "/events")
+	id, err := primitive.ObjectIDFromHex(idStr)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "INVALID_ID", "Invalid rollout ID format")
+		return primitive.NilObjectID, false
+	}
+	return id, true
+}
@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"updatemanager/internal/api/utils"
+	"updatemanager/internal/models"
+	"updatemanager/internal/service"
+)
+
+// RolloutHealthHandler handles resolving versions halted by a rollout health gate
+type RolloutHealthHandler struct {
+	healthService *service.RolloutHealthService
+}
+
+// NewRolloutHealthHandler creates a new rollout health handler
+func NewRolloutHealthHandler(healthService *service.RolloutHealthService) *RolloutHealthHandler {
+	return &RolloutHealthHandler{
+		healthService: healthService,
+	}
+}
+
+// ResumeRollouts handles POST /api/v1/versions/:id/rollouts/resume
+func (h *RolloutHealthHandler) ResumeRollouts(w http.ResponseWriter, r *http.Request) {
+	h.resolveHalt(w, r, "/rollouts/resume", h.healthService.ResumeRollouts)
+}
+
+// RecallRollouts handles POST /api/v1/versions/:id/rollouts/recall
+func (h *RolloutHealthHandler) RecallRollouts(w http.ResponseWriter, r *http.Request) {
+	h.resolveHalt(w, r, "/rollouts/recall", h.healthService.RecallRollouts)
+}
+
+// resolveHalt runs a resume or recall operation and maps its errors
+func (h *RolloutHealthHandler) resolveHalt(w http.ResponseWriter, r *http.Request, suffix string, resolve func(ctx context.Context, id primitive.ObjectID, note, userID, userEmail string) (*models.Version, error)) {
+	if r.Method != http.MethodPost {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Method not allowed")
+		return
+	}
+
+	idStr := strings.TrimPrefix(r.URL.Path, "/api/v1/versions/")
+	idStr = strings.TrimSuffix(idStr, suffix)
+	id, err := primitive.ObjectIDFromHex(idStr)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "INVALID_ID", "Invalid version ID format")
+		return
+	}
+
+	// The note is optional
+	var req models.ResolveRolloutHaltRequest
+	if r.ContentLength > 0 {
+		if err := utils.ReadJSON(w, r, &req); err != nil {
+			utils.WriteError(w, http.StatusBadRequest, "INVALID_REQUEST", "Invalid JSON: "+err.Error())
+			return
+		}
+	}
+
+	userID, userEmail := requestUser(r)
+
+	version, err := resolve(r.Context(), id, req.Note, userID, userEmail)
+	if err != nil {
+		if strings.Contains(err.Error(), "version not found") {
+			utils.WriteError(w, http.StatusNotFound, "VERSION_NOT_FOUND", "Version not found")
+			return
+		}
+		if strings.Contains(err.Error(), "invalid halt state") {
+			utils.WriteError(w, http.StatusConflict, "INVALID_HALT_STATE", err.Error())
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, "UPDATE_FAILED", err.Error())
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, version)
+}
@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"updatemanager/internal/api/utils"
+	"updatemanager/internal/events"
+)
+
+const (
+	// streamHeartbeatInterval keeps idle streams open through proxies
+	streamHeartbeatInterval = 25 * time.Second
+	// streamBufferSize is how many events a slow client may fall behind
+	// before its stream is reset
+	streamBufferSize = 64
+)
+
+// streamEventTypes lists the event types clients may subscribe to
+var streamEventTypes = map[events.Type]bool{
+	events.TypeRolloutStatusChanged: true,
+	events.TypeRolloutProgress:      true,
+	events.TypeNotificationCreated:  true,
+	events.TypeVersionStateChanged:  true,
+}
+
+// StreamHandler serves live updates as Server-Sent Events
+type StreamHandler struct {
+	bus       *events.Bus
+	heartbeat time.Duration
+}
+
+// NewStreamHandler creates a new stream handler
+func NewStreamHandler(bus *events.Bus) *StreamHandler {
+	return &StreamHandler{
+		bus:       bus,
+		heartbeat: streamHeartbeatInterval,
+	}
+}
+
+// Stream handles GET /api/v1/stream
+func (h *StreamHandler) Stream(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Method not allowed")
+		return
+	}
+
+	query := r.URL.Query()
+	filter := events.Filter{
+		RecipientID: query.Get("recipient_id"),
+		CustomerID:  query.Get("customer_id"),
+		ProductID:   query.Get("product_id"),
+	}
+	// types takes a comma-separated list, e.g. rollout.status_changed,notification.created
+	if types := query.Get("types"); types != "" {
+		for _, t := range strings.Split(types, ",") {
+			eventType := events.Type(strings.TrimSpace(t))
+			if !streamEventTypes[eventType] {
+				utils.WriteError(w, http.StatusBadRequest, "INVALID_EVENT_TYPE", fmt.Sprintf("Unknown event type '%s'", eventType))
+				return
+			}
+			filter.Types = append(filter.Types, eventType)
+		}
+	}
+
+	// Streams outlive the server's write timeout
+	rc := http.NewResponseController(w)
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		utils.WriteError(w, http.StatusInternalServerError, "STREAM_FAILED", err.Error())
+		return
+	}
+
+	sub := h.bus.Subscribe(filter, streamBufferSize)
+	defer sub.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, "retry: 5000\n: connected\n\n")
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(h.heartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": ping\n\n")
+		case event, ok := <-sub.C:
+			if !ok {
+				// The client fell behind; it should refetch and reconnect
+				fmt.Fprint(w, "event: stream.reset\ndata: {}\n\n")
+				rc.Flush()
+				return
+			}
+			payload, err := json.Marshal(event)
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", event.ID, event.Type, payload)
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"updatemanager/internal/events"
+)
+
+// readStreamEvent reads SSE lines until a complete event is found, skipping
+// comments and retry hints, and returns its lines
+func readStreamEvent(reader *bufio.Reader) []string {
+	var lines []string
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return nil
+		}
+		line = strings.TrimRight(line, "\n")
+		if line == "" {
+			if len(lines) > 0 && strings.HasPrefix(lines[0], "id: ") {
+				return lines
+			}
+			lines = nil
+			continue
+		}
+		lines = append(lines, line)
+	}
+}
+
+func TestStreamHandler_Stream(t *testing.T) {
+	bus := events.NewBus(events.NewMemoryBroker())
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go bus.Run(ctx)
+
+	server := httptest.NewServer(http.HandlerFunc(NewStreamHandler(bus).Stream))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/api/v1/stream?types=notification.created&recipient_id=user-1")
+	if err != nil {
+		t.Fatalf("Failed to open stream: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("Expected an event stream, got %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	reader := bufio.NewReader(resp.Body)
+	if line, _ := reader.ReadString('\n'); line != "retry: 5000\n" {
+		t.Fatalf("Expected the stream preamble, got %q", line)
+	}
+
+	// Publish until the subscription is registered; only the matching event arrives
+	go func() {
+		for ctx.Err() == nil {
+			bus.Publish(ctx, events.TypeRolloutProgress, events.Scope{}, map[string]int{"progress": 50})
+			bus.Publish(ctx, events.TypeNotificationCreated, events.Scope{RecipientID: "user-2"}, map[string]string{"title": "other"})
+			bus.Publish(ctx, events.TypeNotificationCreated, events.Scope{RecipientID: "user-1"}, map[string]string{"title": "mine"})
+			time.Sleep(10 * time.Millisecond)
+		}
+	}()
+
+	done := make(chan []string)
+	go func() { done <- readStreamEvent(reader) }()
+	select {
+	case lines := <-done:
+		if len(lines) != 3 || lines[1] != "event: notification.created" || !strings.Contains(lines[2], `"title":"mine"`) {
+			t.Errorf("Unexpected event: %q", lines)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timed out waiting for the notification event")
+	}
+}
+
+func TestStreamHandler_InvalidType(t *testing.T) {
+	handler := NewStreamHandler(events.NewBus(events.NewMemoryBroker()))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/stream?types=rollout.deleted", nil)
+	w := httptest.NewRecorder()
+	handler.Stream(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
@@ -59,6 +59,10 @@ func (h *TenantHandler) CreateTenant(w http.ResponseWriter, r *http.Request) {
 
 	tenant, err := h.tenantService.CreateTenant(r.Context(), customerID, &req, userID, userEmail)
 	if err != nil {
+		if strings.Contains(err.Error(), "invalid maintenance schedule") {
+			utils.WriteError(w, http.StatusBadRequest, "INVALID_MAINTENANCE_SCHEDULE", err.Error())
+			return
+		}
 		if strings.Contains(err.Error(), "not found") {
 			utils.WriteError(w, http.StatusNotFound, "CUSTOMER_NOT_FOUND", err.Error())
 			return
@@ -188,6 +192,10 @@ func (h *TenantHandler) UpdateTenant(w http.ResponseWriter, r *http.Request) {
 
 	tenant, err := h.tenantService.UpdateTenant(r.Context(), tenantID, &req, userID, userEmail)
 	if err != nil {
+		if strings.Contains(err.Error(), "invalid maintenance schedule") {
+			utils.WriteError(w, http.StatusBadRequest, "INVALID_MAINTENANCE_SCHEDULE", err.Error())
+			return
+		}
 		if strings.Contains(err.Error(), "not found") {
 			utils.WriteError(w, http.StatusNotFound, "TENANT_NOT_FOUND", err.Error())
 			return
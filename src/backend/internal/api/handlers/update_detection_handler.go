@@ -38,6 +38,10 @@ func (h *UpdateDetectionHandler) DetectUpdate(w http.ResponseWriter, r *http.Req
 
 	result, err := h.updateDetectionService.DetectUpdate(r.Context(), &detection)
 	if err != nil {
+		if strings.Contains(err.Error(), "not registered") {
+			utils.WriteError(w, http.StatusNotFound, "ENDPOINT_NOT_REGISTERED", err.Error())
+			return
+		}
 		utils.WriteError(w, http.StatusInternalServerError, "DETECTION_FAILED", err.Error())
 		return
 	}
@@ -45,6 +49,40 @@ func (h *UpdateDetectionHandler) DetectUpdate(w http.ResponseWriter, r *http.Req
 	utils.WriteSuccess(w, http.StatusCreated, result)
 }
 
+// CheckIn handles POST /api/v1/update-detections/check-in
+func (h *UpdateDetectionHandler) CheckIn(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Method not allowed")
+		return
+	}
+
+	var req models.CheckInRequest
+	if err := utils.ReadJSON(w, r, &req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "INVALID_REQUEST", "Invalid JSON: "+err.Error())
+		return
+	}
+
+	response, err := h.updateDetectionService.CheckIn(r.Context(), &req)
+	if err != nil {
+		if strings.Contains(err.Error(), "invalid check-in") {
+			utils.WriteError(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+			return
+		}
+		if strings.Contains(err.Error(), "not registered") {
+			utils.WriteError(w, http.StatusNotFound, "ENDPOINT_NOT_REGISTERED", err.Error())
+			return
+		}
+		if strings.Contains(err.Error(), "not found") {
+			utils.WriteError(w, http.StatusNotFound, "PRODUCT_NOT_FOUND", err.Error())
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, "CHECK_IN_FAILED", err.Error())
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, response)
+}
+
 // UpdateAvailableVersion handles PUT /api/v1/update-detections/:endpoint_id/:product_id/available-version
 func (h *UpdateDetectionHandler) UpdateAvailableVersion(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPut {
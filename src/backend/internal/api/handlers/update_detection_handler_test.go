@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
@@ -38,6 +39,10 @@ func setupUpdateDetectionHandlerTest(t *testing.T) (*UpdateDetectionHandler, *se
 	_ = db.Collection("update_detections").Drop(ctx)
 	_ = db.Collection("update_rollouts").Drop(ctx)
 	_ = db.Collection("audit_logs").Drop(ctx)
+	_ = db.Collection("customers").Drop(ctx)
+	_ = db.Collection("customer_tenants").Drop(ctx)
+	_ = db.Collection("deployments").Drop(ctx)
+	_ = db.Collection("endpoints").Drop(ctx)
 
 	services := service.NewServiceFactory(db.Database)
 	handler := NewUpdateDetectionHandler(services.UpdateDetectionService)
@@ -52,6 +57,10 @@ func setupUpdateDetectionHandlerTest(t *testing.T) (*UpdateDetectionHandler, *se
 		_ = db.Collection("update_detections").Drop(ctx)
 		_ = db.Collection("update_rollouts").Drop(ctx)
 		_ = db.Collection("audit_logs").Drop(ctx)
+		_ = db.Collection("customers").Drop(ctx)
+		_ = db.Collection("customer_tenants").Drop(ctx)
+		_ = db.Collection("deployments").Drop(ctx)
+		_ = db.Collection("endpoints").Drop(ctx)
 		db.Disconnect(ctx)
 	}
 
@@ -115,6 +124,7 @@ func TestUpdateDetectionHandler_DetectUpdate(t *testing.T) {
 	}
 
 	// Detect update
+	registerTestEndpoints(t, ctx, services, product.ProductID, "endpoint-1")
 	detection := models.UpdateDetection{
 		EndpointID:       "endpoint-1",
 		ProductID:        product.ProductID,
@@ -206,6 +216,7 @@ func TestUpdateDetectionHandler_UpdateAvailableVersion(t *testing.T) {
 	}
 
 	// Create detection
+	registerTestEndpoints(t, ctx, services, product.ProductID, "endpoint-2")
 	detection := models.UpdateDetection{
 		EndpointID:       "endpoint-2",
 		ProductID:        product.ProductID,
@@ -321,6 +332,8 @@ func TestUpdateDetectionHandler_ListDetections(t *testing.T) {
 	}
 
 	// Create multiple detections
+	registerTestEndpoints(t, ctx, services, product.ProductID, "endpoint-list-1", "endpoint-list-2")
+	registerTestEndpoints(t, ctx, services, otherProduct.ProductID, "endpoint-list-3")
 	detections := []models.UpdateDetection{
 		{
 			EndpointID:       "endpoint-list-1",
@@ -442,3 +455,91 @@ func TestUpdateDetectionHandler_ListDetections(t *testing.T) {
 		t.Errorf("Expected at most 2 detections with limit=2, got %d", len(data))
 	}
 }
+
+func TestUpdateDetectionHandler_CheckIn(t *testing.T) {
+	handler, services, cleanup := setupUpdateDetectionHandlerTest(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	productReq := models.CreateProductRequest{
+		ProductID: "checkin-handler-product",
+		Name:      "Check-in Handler Product",
+		Type:      models.ProductTypeServer,
+	}
+	product, err := services.ProductService.CreateProduct(ctx, &productReq, "user1", "user1@example.com")
+	if err != nil {
+		t.Fatalf("Failed to create product: %v", err)
+	}
+
+	// Missing installed_version is rejected
+	body, _ := json.Marshal(map[string]string{
+		"endpoint_id": "endpoint-1",
+		"product_id":  product.ProductID,
+	})
+	httpReq := httptest.NewRequest(http.MethodPost, "/api/v1/update-detections/check-in", bytes.NewBuffer(body))
+	httpReq.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	handler.CheckIn(w, httpReq)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+
+	// Unregistered endpoints are rejected
+	body, _ = json.Marshal(models.CheckInRequest{
+		EndpointID:       "endpoint-1",
+		ProductID:        product.ProductID,
+		InstalledVersion: "1.0.0",
+	})
+	httpReq = httptest.NewRequest(http.MethodPost, "/api/v1/update-detections/check-in", bytes.NewBuffer(body))
+	httpReq.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	handler.CheckIn(w, httpReq)
+
+	if w.Code != http.StatusNotFound || !strings.Contains(w.Body.String(), "ENDPOINT_NOT_REGISTERED") {
+		t.Errorf("Expected ENDPOINT_NOT_REGISTERED, got %d %s", w.Code, w.Body.String())
+	}
+	registerTestEndpoints(t, ctx, services, product.ProductID, "endpoint-1")
+
+	// No released versions: the endpoint is told to do nothing
+	body, _ = json.Marshal(models.CheckInRequest{
+		EndpointID:       "endpoint-1",
+		ProductID:        product.ProductID,
+		InstalledVersion: "1.0.0",
+		OS:               "linux",
+		Architecture:     "amd64",
+	})
+	httpReq = httptest.NewRequest(http.MethodPost, "/api/v1/update-detections/check-in", bytes.NewBuffer(body))
+	httpReq.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	handler.CheckIn(w, httpReq)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var response utils.JSONResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	data, _ := response.Data.(map[string]interface{})
+	if data["action"] != string(models.CheckInActionNone) {
+		t.Errorf("Expected action none, got %v", data["action"])
+	}
+
+	// Unknown product
+	body, _ = json.Marshal(models.CheckInRequest{
+		EndpointID:       "endpoint-1",
+		ProductID:        "missing-product",
+		InstalledVersion: "1.0.0",
+	})
+	httpReq = httptest.NewRequest(http.MethodPost, "/api/v1/update-detections/check-in", bytes.NewBuffer(body))
+	httpReq.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	handler.CheckIn(w, httpReq)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
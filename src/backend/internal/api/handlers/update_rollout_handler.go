@@ -3,6 +3,7 @@ package handlers
 import (
 	"net/http"
 	"strings"
+	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -45,6 +46,18 @@ func (h *UpdateRolloutHandler) InitiateRollout(w http.ResponseWriter, r *http.Re
 
 	result, err := h.updateRolloutService.InitiateRollout(r.Context(), &rollout)
 	if err != nil {
+		if strings.Contains(err.Error(), "not registered") {
+			utils.WriteError(w, http.StatusNotFound, "ENDPOINT_NOT_REGISTERED", err.Error())
+			return
+		}
+		if strings.Contains(err.Error(), "rollouts of version") {
+			utils.WriteError(w, http.StatusConflict, "ROLLOUT_HALTED", err.Error())
+			return
+		}
+		if strings.Contains(err.Error(), "no maintenance window") {
+			utils.WriteError(w, http.StatusConflict, "NO_MAINTENANCE_WINDOW", err.Error())
+			return
+		}
 		utils.WriteError(w, http.StatusInternalServerError, "ROLLOUT_FAILED", err.Error())
 		return
 	}
@@ -82,6 +95,14 @@ func (h *UpdateRolloutHandler) UpdateRolloutStatus(w http.ResponseWriter, r *htt
 			utils.WriteError(w, http.StatusNotFound, "ROLLOUT_NOT_FOUND", "Update rollout not found")
 			return
 		}
+		if strings.Contains(err.Error(), "invalid rollout status") {
+			utils.WriteError(w, http.StatusBadRequest, "INVALID_STATUS", err.Error())
+			return
+		}
+		if strings.Contains(err.Error(), "invalid rollout transition") {
+			h.writeRolloutConflict(w, r, id, "INVALID_ROLLOUT_TRANSITION", err)
+			return
+		}
 		utils.WriteError(w, http.StatusInternalServerError, "UPDATE_FAILED", err.Error())
 		return
 	}
@@ -123,6 +144,18 @@ func (h *UpdateRolloutHandler) UpdateRolloutProgress(w http.ResponseWriter, r *h
 			utils.WriteError(w, http.StatusNotFound, "ROLLOUT_NOT_FOUND", "Update rollout not found")
 			return
 		}
+		if strings.Contains(err.Error(), "invalid rollout transition") {
+			h.writeRolloutConflict(w, r, id, "INVALID_ROLLOUT_TRANSITION", err)
+			return
+		}
+		if strings.Contains(err.Error(), "invalid rollout progress value") {
+			utils.WriteError(w, http.StatusBadRequest, "INVALID_PROGRESS", err.Error())
+			return
+		}
+		if strings.Contains(err.Error(), "invalid rollout progress") {
+			h.writeRolloutConflict(w, r, id, "INVALID_ROLLOUT_PROGRESS", err)
+			return
+		}
 		utils.WriteError(w, http.StatusInternalServerError, "UPDATE_FAILED", err.Error())
 		return
 	}
@@ -130,6 +163,57 @@ func (h *UpdateRolloutHandler) UpdateRolloutProgress(w http.ResponseWriter, r *h
 	utils.WriteSuccess(w, http.StatusOK, rollout)
 }
 
+// RollbackRollout handles POST /api/v1/update-rollouts/:id/rollback
+func (h *UpdateRolloutHandler) RollbackRollout(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Method not allowed")
+		return
+	}
+
+	idStr := strings.TrimPrefix(r.URL.Path, "/api/v1/update-rollouts/")
+	idStr = strings.TrimSuffix(idStr, "/rollback")
+	id, err := primitive.ObjectIDFromHex(idStr)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "INVALID_ID", "Invalid rollout ID format")
+		return
+	}
+
+	// The reason is optional
+	var req models.RollbackRolloutRequest
+	if r.ContentLength > 0 {
+		if err := utils.ReadJSON(w, r, &req); err != nil {
+			utils.WriteError(w, http.StatusBadRequest, "INVALID_REQUEST", "Invalid JSON: "+err.Error())
+			return
+		}
+	}
+
+	userID, userEmail := requestUser(r)
+
+	rollback, err := h.updateRolloutService.RollbackRollout(r.Context(), id, req.Reason, userID, userEmail)
+	if err != nil {
+		if strings.Contains(err.Error(), "invalid rollback") {
+			h.writeRolloutConflict(w, r, id, "INVALID_ROLLBACK", err)
+			return
+		}
+		if strings.Contains(err.Error(), "no maintenance window") {
+			utils.WriteError(w, http.StatusConflict, "NO_MAINTENANCE_WINDOW", err.Error())
+			return
+		}
+		if strings.Contains(err.Error(), "rollback version") {
+			utils.WriteError(w, http.StatusNotFound, "VERSION_NOT_FOUND", err.Error())
+			return
+		}
+		if strings.Contains(err.Error(), "not found") {
+			utils.WriteError(w, http.StatusNotFound, "ROLLOUT_NOT_FOUND", "Update rollout not found")
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, "ROLLBACK_FAILED", err.Error())
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusCreated, rollback)
+}
+
 // GetRollout handles GET /api/v1/update-rollouts/:id
 func (h *UpdateRolloutHandler) GetRollout(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
@@ -186,6 +270,25 @@ func (h *UpdateRolloutHandler) ListRollouts(w http.ResponseWriter, r *http.Reque
 	if status := r.URL.Query().Get("status"); status != "" {
 		filter["status"] = status
 	}
+	if batchID := r.URL.Query().Get("batch_id"); batchID != "" {
+		filter["batch_id"] = batchID
+	}
+	if campaignID := r.URL.Query().Get("campaign_id"); campaignID != "" {
+		id, err := primitive.ObjectIDFromHex(campaignID)
+		if err != nil {
+			utils.WriteError(w, http.StatusBadRequest, "INVALID_ID", "Invalid campaign ID format")
+			return
+		}
+		filter["campaign_id"] = id
+	}
+	if rollbackOf := r.URL.Query().Get("rollback_of"); rollbackOf != "" {
+		id, err := primitive.ObjectIDFromHex(rollbackOf)
+		if err != nil {
+			utils.WriteError(w, http.StatusBadRequest, "INVALID_ID", "Invalid rollout ID format")
+			return
+		}
+		filter["rollback_of"] = id
+	}
 
 	rollouts, total, err := h.updateRolloutService.ListRollouts(r.Context(), filter, page, limit)
 	if err != nil {
@@ -195,3 +298,41 @@ func (h *UpdateRolloutHandler) ListRollouts(w http.ResponseWriter, r *http.Reque
 
 	utils.WritePaginated(w, http.StatusOK, rollouts, page, limit, total)
 }
+
+// GetStuckRollouts handles GET /api/v1/update-rollouts/stuck
+func (h *UpdateRolloutHandler) GetStuckRollouts(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Method not allowed")
+		return
+	}
+
+	now := time.Now()
+	since := now.Add(-service.DefaultStuckRolloutPeriod)
+	if sinceStr := r.URL.Query().Get("since"); sinceStr != "" {
+		parsed, err := time.Parse(time.RFC3339, sinceStr)
+		if err != nil {
+			utils.WriteError(w, http.StatusBadRequest, "INVALID_REQUEST", "since must be an RFC 3339 timestamp")
+			return
+		}
+		since = parsed
+	}
+
+	report, err := h.updateRolloutService.GetStuckRollouts(r.Context(), now, since, r.URL.Query().Get("product_id"))
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "REPORT_FAILED", err.Error())
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, report)
+}
+
+// writeRolloutConflict writes a 409 carrying the rollout's current state so
+// agents can reconcile
+func (h *UpdateRolloutHandler) writeRolloutConflict(w http.ResponseWriter, r *http.Request, id primitive.ObjectID, code string, err error) {
+	current, getErr := h.updateRolloutService.GetRollout(r.Context(), id)
+	if getErr != nil {
+		utils.WriteError(w, http.StatusConflict, code, err.Error())
+		return
+	}
+	utils.WriteErrorWithData(w, http.StatusConflict, code, err.Error(), current)
+}
@@ -38,6 +38,10 @@ func setupUpdateRolloutHandlerTest(t *testing.T) (*UpdateRolloutHandler, *servic
 	_ = db.Collection("update_detections").Drop(ctx)
 	_ = db.Collection("update_rollouts").Drop(ctx)
 	_ = db.Collection("audit_logs").Drop(ctx)
+	_ = db.Collection("customers").Drop(ctx)
+	_ = db.Collection("customer_tenants").Drop(ctx)
+	_ = db.Collection("deployments").Drop(ctx)
+	_ = db.Collection("endpoints").Drop(ctx)
 
 	services := service.NewServiceFactory(db.Database)
 	handler := NewUpdateRolloutHandler(services.UpdateRolloutService)
@@ -52,6 +56,10 @@ func setupUpdateRolloutHandlerTest(t *testing.T) (*UpdateRolloutHandler, *servic
 		_ = db.Collection("update_detections").Drop(ctx)
 		_ = db.Collection("update_rollouts").Drop(ctx)
 		_ = db.Collection("audit_logs").Drop(ctx)
+		_ = db.Collection("customers").Drop(ctx)
+		_ = db.Collection("customer_tenants").Drop(ctx)
+		_ = db.Collection("deployments").Drop(ctx)
+		_ = db.Collection("endpoints").Drop(ctx)
 		db.Disconnect(ctx)
 	}
 
@@ -119,6 +127,7 @@ func TestUpdateRolloutHandler_InitiateRollout(t *testing.T) {
 	}
 
 	// Create detection
+	registerTestEndpoints(t, ctx, services, product.ProductID, "endpoint-rollout")
 	detection := models.UpdateDetection{
 		EndpointID:       "endpoint-rollout",
 		ProductID:        product.ProductID,
@@ -222,6 +231,7 @@ func TestUpdateRolloutHandler_UpdateRolloutStatus(t *testing.T) {
 		t.Fatalf("Failed to release version: %v", err)
 	}
 
+	registerTestEndpoints(t, ctx, services, product.ProductID, "endpoint-status")
 	detection := models.UpdateDetection{
 		EndpointID:       "endpoint-status",
 		ProductID:        product.ProductID,
@@ -271,6 +281,49 @@ func TestUpdateRolloutHandler_UpdateRolloutStatus(t *testing.T) {
 	if updated.Status != models.RolloutStatusInProgress {
 		t.Errorf("Expected status=%s, got %s", models.RolloutStatusInProgress, updated.Status)
 	}
+
+	// Agents retry: a duplicate report succeeds without changing anything
+	w = putRolloutReport(handler.UpdateRolloutStatus, result.ID.Hex(), "status", map[string]interface{}{"status": models.RolloutStatusInProgress})
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected duplicate report to return %d, got %d", http.StatusOK, w.Code)
+	}
+
+	w = putRolloutReport(handler.UpdateRolloutStatus, result.ID.Hex(), "status", map[string]interface{}{"status": models.RolloutStatusCompleted})
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	// Completed is final; the conflict carries the current state
+	w = putRolloutReport(handler.UpdateRolloutStatus, result.ID.Hex(), "status", map[string]interface{}{"status": models.RolloutStatusPending})
+	if w.Code != http.StatusConflict {
+		t.Fatalf("Expected status %d, got %d", http.StatusConflict, w.Code)
+	}
+	var conflict struct {
+		Data  models.UpdateRollout `json:"data"`
+		Error struct {
+			Code string `json:"code"`
+		} `json:"error"`
+	}
+	json.NewDecoder(w.Body).Decode(&conflict)
+	if conflict.Error.Code != "INVALID_ROLLOUT_TRANSITION" || conflict.Data.Status != models.RolloutStatusCompleted || conflict.Data.Progress != 100 {
+		t.Errorf("Expected conflict with completed rollout, got %s %s %d", conflict.Error.Code, conflict.Data.Status, conflict.Data.Progress)
+	}
+
+	w = putRolloutReport(handler.UpdateRolloutStatus, result.ID.Hex(), "status", map[string]interface{}{"status": "rebooting"})
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected unknown status to return %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+// putRolloutReport sends an agent report to a rollout status or progress handler
+func putRolloutReport(handle http.HandlerFunc, rolloutID, report string, payload interface{}) *httptest.ResponseRecorder {
+	body, _ := json.Marshal(payload)
+	httpReq := httptest.NewRequest(http.MethodPut, "/api/v1/update-rollouts/"+rolloutID+"/"+report, bytes.NewBuffer(body))
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	handle(w, httpReq)
+	return w
 }
 
 func TestUpdateRolloutHandler_UpdateRolloutProgress(t *testing.T) {
@@ -333,6 +386,7 @@ func TestUpdateRolloutHandler_UpdateRolloutProgress(t *testing.T) {
 		t.Fatalf("Failed to release version: %v", err)
 	}
 
+	registerTestEndpoints(t, ctx, services, product.ProductID, "endpoint-progress")
 	detection := models.UpdateDetection{
 		EndpointID:       "endpoint-progress",
 		ProductID:        product.ProductID,
@@ -382,6 +436,16 @@ func TestUpdateRolloutHandler_UpdateRolloutProgress(t *testing.T) {
 	if updated.Progress != 50 {
 		t.Errorf("Expected progress=50, got %d", updated.Progress)
 	}
+
+	// Progress is monotonic; repeating the current value is accepted
+	w = putRolloutReport(handler.UpdateRolloutProgress, result.ID.Hex(), "progress", map[string]int{"progress": 50})
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected duplicate progress to return %d, got %d", http.StatusOK, w.Code)
+	}
+	w = putRolloutReport(handler.UpdateRolloutProgress, result.ID.Hex(), "progress", map[string]int{"progress": 30})
+	if w.Code != http.StatusConflict {
+		t.Errorf("Expected decreasing progress to return %d, got %d", http.StatusConflict, w.Code)
+	}
 }
 
 func TestUpdateRolloutHandler_GetRollout(t *testing.T) {
@@ -444,6 +508,7 @@ func TestUpdateRolloutHandler_GetRollout(t *testing.T) {
 		t.Fatalf("Failed to release version: %v", err)
 	}
 
+	registerTestEndpoints(t, ctx, services, product.ProductID, "endpoint-get")
 	detection := models.UpdateDetection{
 		EndpointID:       "endpoint-get",
 		ProductID:        product.ProductID,
@@ -584,6 +649,8 @@ func TestUpdateRolloutHandler_ListRollouts(t *testing.T) {
 	}
 
 	// Create detections
+	registerTestEndpoints(t, ctx, services, product.ProductID, "endpoint-list-rollout-1", "endpoint-list-rollout-2")
+	registerTestEndpoints(t, ctx, services, otherProduct.ProductID, "endpoint-list-rollout-3")
 	detections := []models.UpdateDetection{
 		{
 			EndpointID:       "endpoint-list-rollout-1",
@@ -1,9 +1,13 @@
 package handlers
 
 import (
+	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
 
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
 	"updatemanager/internal/api/utils"
 	"updatemanager/internal/models"
 	"updatemanager/internal/service"
@@ -49,8 +53,10 @@ func (h *UpgradePathHandler) CreateUpgradePath(w http.ResponseWriter, r *http.Re
 		return
 	}
 
+	userID, userEmail := requestUser(r)
+
 	path.ProductID = productID
-	if err := h.upgradePathService.CreateUpgradePath(r.Context(), &path); err != nil {
+	if err := h.upgradePathService.CreateUpgradePath(r.Context(), &path, userID, userEmail); err != nil {
 		if strings.Contains(err.Error(), "not found") {
 			utils.WriteError(w, http.StatusNotFound, "VERSION_NOT_FOUND", err.Error())
 			return
@@ -141,7 +147,9 @@ func (h *UpgradePathHandler) BlockUpgradePath(w http.ResponseWriter, r *http.Req
 		return
 	}
 
-	if err := h.upgradePathService.BlockUpgradePath(r.Context(), productID, fromVersion, toVersion, req.BlockReason); err != nil {
+	userID, userEmail := requestUser(r)
+
+	if err := h.upgradePathService.BlockUpgradePath(r.Context(), productID, fromVersion, toVersion, req.BlockReason, userID, userEmail); err != nil {
 		if strings.Contains(err.Error(), "not found") {
 			utils.WriteError(w, http.StatusNotFound, "UPGRADE_PATH_NOT_FOUND", "Upgrade path not found")
 			return
@@ -159,3 +167,403 @@ func (h *UpgradePathHandler) BlockUpgradePath(w http.ResponseWriter, r *http.Req
 
 	utils.WriteSuccess(w, http.StatusOK, path)
 }
+
+// ListUpgradePaths handles GET /api/v1/products/:product_id/upgrade-paths
+func (h *UpgradePathHandler) ListUpgradePaths(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Method not allowed")
+		return
+	}
+
+	productID := extractProductIDFromPath(r.URL.Path)
+	if productID == "" {
+		utils.WriteError(w, http.StatusBadRequest, "INVALID_PRODUCT_ID", "Product ID is required")
+		return
+	}
+
+	page := utils.GetIntQueryParam(r, "page", 1)
+	limit := utils.GetIntQueryParam(r, "limit", 10)
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+
+	query := &service.ListUpgradePathsQuery{
+		FromVersion: r.URL.Query().Get("from_version"),
+		ToVersion:   r.URL.Query().Get("to_version"),
+		Page:        page,
+		Limit:       limit,
+	}
+	if blocked := r.URL.Query().Get("blocked"); blocked != "" {
+		isBlocked, err := strconv.ParseBool(blocked)
+		if err != nil {
+			utils.WriteError(w, http.StatusBadRequest, "INVALID_FILTER", "blocked must be true or false")
+			return
+		}
+		query.IsBlocked = &isBlocked
+	}
+
+	paths, total, err := h.upgradePathService.ListUpgradePaths(r.Context(), productID, query)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "LIST_FAILED", err.Error())
+		return
+	}
+
+	utils.WritePaginated(w, http.StatusOK, paths, page, limit, total)
+}
+
+// UpdateUpgradePath handles PUT /api/v1/products/:product_id/upgrade-paths/:from_version/:to_version
+func (h *UpgradePathHandler) UpdateUpgradePath(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Method not allowed")
+		return
+	}
+
+	productID, fromVersion, toVersion := extractUpgradePathFromPath(r.URL.Path)
+	if productID == "" || fromVersion == "" || toVersion == "" {
+		utils.WriteError(w, http.StatusBadRequest, "INVALID_PATH", "Product ID, from version, and to version are required")
+		return
+	}
+
+	var req models.UpdateUpgradePathRequest
+	if err := utils.ReadJSON(w, r, &req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "INVALID_REQUEST", "Invalid JSON: "+err.Error())
+		return
+	}
+
+	userID, userEmail := requestUser(r)
+
+	path, err := h.upgradePathService.UpdateUpgradePath(r.Context(), productID, fromVersion, toVersion, &req, userID, userEmail)
+	if err != nil {
+		if strings.Contains(err.Error(), "upgrade path not found") {
+			utils.WriteError(w, http.StatusNotFound, "UPGRADE_PATH_NOT_FOUND", "Upgrade path not found")
+			return
+		}
+		if strings.Contains(err.Error(), "not found") {
+			utils.WriteError(w, http.StatusNotFound, "VERSION_NOT_FOUND", err.Error())
+			return
+		}
+		if strings.Contains(err.Error(), "is blocked") {
+			utils.WriteError(w, http.StatusConflict, "UPGRADE_PATH_BLOCKED", err.Error())
+			return
+		}
+		if strings.Contains(err.Error(), "invalid") {
+			utils.WriteError(w, http.StatusBadRequest, "INVALID_UPGRADE_PATH", err.Error())
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, "UPDATE_FAILED", err.Error())
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, path)
+}
+
+// DeleteUpgradePath handles DELETE /api/v1/products/:product_id/upgrade-paths/:from_version/:to_version
+func (h *UpgradePathHandler) DeleteUpgradePath(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Method not allowed")
+		return
+	}
+
+	productID, fromVersion, toVersion := extractUpgradePathFromPath(r.URL.Path)
+	if productID == "" || fromVersion == "" || toVersion == "" {
+		utils.WriteError(w, http.StatusBadRequest, "INVALID_PATH", "Product ID, from version, and to version are required")
+		return
+	}
+
+	userID, userEmail := requestUser(r)
+
+	if err := h.upgradePathService.DeleteUpgradePath(r.Context(), productID, fromVersion, toVersion, userID, userEmail); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			utils.WriteError(w, http.StatusNotFound, "UPGRADE_PATH_NOT_FOUND", "Upgrade path not found")
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, "DELETE_FAILED", err.Error())
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, map[string]string{"message": "Upgrade path deleted successfully"})
+}
+
+// UnblockUpgradePath handles POST /api/v1/products/:product_id/upgrade-paths/:from_version/:to_version/unblock
+func (h *UpgradePathHandler) UnblockUpgradePath(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Method not allowed")
+		return
+	}
+
+	productID, fromVersion, toVersion := extractUpgradePathFromPath(r.URL.Path)
+	if productID == "" || fromVersion == "" || toVersion == "" {
+		utils.WriteError(w, http.StatusBadRequest, "INVALID_PATH", "Product ID, from version, and to version are required")
+		return
+	}
+
+	userID, userEmail := requestUser(r)
+
+	path, err := h.upgradePathService.UnblockUpgradePath(r.Context(), productID, fromVersion, toVersion, userID, userEmail)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			utils.WriteError(w, http.StatusNotFound, "UPGRADE_PATH_NOT_FOUND", "Upgrade path not found")
+			return
+		}
+		if strings.Contains(err.Error(), "not blocked") {
+			utils.WriteError(w, http.StatusConflict, "NOT_BLOCKED", err.Error())
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, "UNBLOCK_FAILED", err.Error())
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, path)
+}
+
+// ExplainUpgradePath handles GET /api/v1/products/:product_id/upgrade-paths/explain?from_version=x&to_version=y
+func (h *UpgradePathHandler) ExplainUpgradePath(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Method not allowed")
+		return
+	}
+
+	productID := extractProductIDFromPath(r.URL.Path)
+	fromVersion := r.URL.Query().Get("from_version")
+	toVersion := r.URL.Query().Get("to_version")
+
+	if productID == "" || fromVersion == "" || toVersion == "" {
+		utils.WriteError(w, http.StatusBadRequest, "INVALID_REQUEST", "Product ID, from_version, and to_version are required")
+		return
+	}
+
+	resolution, err := h.upgradePathService.ResolveUpgradePath(r.Context(), productID, fromVersion, toVersion)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "RESOLVE_FAILED", err.Error())
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, resolution)
+}
+
+// CreateUpgradePathRule handles POST /api/v1/products/:product_id/upgrade-path-rules
+func (h *UpgradePathHandler) CreateUpgradePathRule(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Method not allowed")
+		return
+	}
+
+	productID := extractProductIDFromPath(r.URL.Path)
+	if productID == "" {
+		utils.WriteError(w, http.StatusBadRequest, "INVALID_PRODUCT_ID", "Product ID is required")
+		return
+	}
+
+	var req models.CreateUpgradePathRuleRequest
+	if err := utils.ReadJSON(w, r, &req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "INVALID_REQUEST", "Invalid JSON: "+err.Error())
+		return
+	}
+
+	userID, userEmail := requestUser(r)
+
+	rule, err := h.upgradePathService.CreateUpgradePathRule(r.Context(), productID, &req, userID, userEmail)
+	if err != nil {
+		if strings.Contains(err.Error(), "product not found") {
+			utils.WriteError(w, http.StatusNotFound, "PRODUCT_NOT_FOUND", "Product not found")
+			return
+		}
+		if strings.Contains(err.Error(), "invalid") {
+			utils.WriteError(w, http.StatusBadRequest, "INVALID_RULE", err.Error())
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, "CREATE_FAILED", err.Error())
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusCreated, rule)
+}
+
+// ListUpgradePathRules handles GET /api/v1/products/:product_id/upgrade-path-rules
+func (h *UpgradePathHandler) ListUpgradePathRules(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Method not allowed")
+		return
+	}
+
+	productID := extractProductIDFromPath(r.URL.Path)
+	if productID == "" {
+		utils.WriteError(w, http.StatusBadRequest, "INVALID_PRODUCT_ID", "Product ID is required")
+		return
+	}
+
+	page := utils.GetIntQueryParam(r, "page", 1)
+	limit := utils.GetIntQueryParam(r, "limit", 10)
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+
+	rules, total, err := h.upgradePathService.ListUpgradePathRules(r.Context(), productID, page, limit)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "LIST_FAILED", err.Error())
+		return
+	}
+
+	utils.WritePaginated(w, http.StatusOK, rules, page, limit, total)
+}
+
+// GetUpgradePathRule handles GET /api/v1/products/:product_id/upgrade-path-rules/:rule_id
+func (h *UpgradePathHandler) GetUpgradePathRule(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Method not allowed")
+		return
+	}
+
+	productID := extractProductIDFromPath(r.URL.Path)
+	if productID == "" {
+		utils.WriteError(w, http.StatusBadRequest, "INVALID_PRODUCT_ID", "Product ID is required")
+		return
+	}
+
+	id, err := extractRuleIDFromPath(r.URL.Path)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "INVALID_ID", "Invalid rule ID format")
+		return
+	}
+
+	rule, err := h.upgradePathService.GetUpgradePathRule(r.Context(), productID, id)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			utils.WriteError(w, http.StatusNotFound, "RULE_NOT_FOUND", "Upgrade path rule not found")
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, "GET_FAILED", err.Error())
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, rule)
+}
+
+// UpdateUpgradePathRule handles PUT /api/v1/products/:product_id/upgrade-path-rules/:rule_id
+func (h *UpgradePathHandler) UpdateUpgradePathRule(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Method not allowed")
+		return
+	}
+
+	productID := extractProductIDFromPath(r.URL.Path)
+	if productID == "" {
+		utils.WriteError(w, http.StatusBadRequest, "INVALID_PRODUCT_ID", "Product ID is required")
+		return
+	}
+
+	id, err := extractRuleIDFromPath(r.URL.Path)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "INVALID_ID", "Invalid rule ID format")
+		return
+	}
+
+	var req models.UpdateUpgradePathRuleRequest
+	if err := utils.ReadJSON(w, r, &req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "INVALID_REQUEST", "Invalid JSON: "+err.Error())
+		return
+	}
+
+	userID, userEmail := requestUser(r)
+
+	rule, err := h.upgradePathService.UpdateUpgradePathRule(r.Context(), productID, id, &req, userID, userEmail)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			utils.WriteError(w, http.StatusNotFound, "RULE_NOT_FOUND", "Upgrade path rule not found")
+			return
+		}
+		if strings.Contains(err.Error(), "invalid") {
+			utils.WriteError(w, http.StatusBadRequest, "INVALID_RULE", err.Error())
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, "UPDATE_FAILED", err.Error())
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, rule)
+}
+
+// DeleteUpgradePathRule handles DELETE /api/v1/products/:product_id/upgrade-path-rules/:rule_id
+func (h *UpgradePathHandler) DeleteUpgradePathRule(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Method not allowed")
+		return
+	}
+
+	productID := extractProductIDFromPath(r.URL.Path)
+	if productID == "" {
+		utils.WriteError(w, http.StatusBadRequest, "INVALID_PRODUCT_ID", "Product ID is required")
+		return
+	}
+
+	id, err := extractRuleIDFromPath(r.URL.Path)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "INVALID_ID", "Invalid rule ID format")
+		return
+	}
+
+	userID, userEmail := requestUser(r)
+
+	if err := h.upgradePathService.DeleteUpgradePathRule(r.Context(), productID, id, userID, userEmail); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			utils.WriteError(w, http.StatusNotFound, "RULE_NOT_FOUND", "Upgrade path rule not found")
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, "DELETE_FAILED", err.Error())
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, map[string]string{"message": "Upgrade path rule deleted successfully"})
+}
+
+// extractProductIDFromPath returns the segment following "products" in the path
+func extractProductIDFromPath(path string) string {
+	pathParts := strings.Split(path, "/")
+	for i, part := range pathParts {
+		if part == "products" && i+1 < len(pathParts) {
+			return pathParts[i+1]
+		}
+	}
+	return ""
+}
+
+// extractUpgradePathFromPath returns the product ID and the from/to versions of an upgrade path URL
+func extractUpgradePathFromPath(path string) (productID, fromVersion, toVersion string) {
+	pathParts := strings.Split(path, "/")
+	for i, part := range pathParts {
+		if part == "products" && i+1 < len(pathParts) {
+			productID = pathParts[i+1]
+		}
+		if part == "upgrade-paths" && i+2 < len(pathParts) {
+			fromVersion = pathParts[i+1]
+			toVersion = pathParts[i+2]
+		}
+	}
+	return productID, fromVersion, toVersion
+}
+
+// requestUser returns the acting user from the X-User-ID and X-User-Email headers
+func requestUser(r *http.Request) (string, string) {
+	userID := r.Header.Get("X-User-ID")
+	if userID == "" {
+		userID = "anonymous"
+	}
+	return userID, r.Header.Get("X-User-Email")
+}
+
+// extractRuleIDFromPath returns the rule ID following "upgrade-path-rules" in the path
+func extractRuleIDFromPath(path string) (primitive.ObjectID, error) {
+	pathParts := strings.Split(path, "/")
+	for i, part := range pathParts {
+		if part == "upgrade-path-rules" && i+1 < len(pathParts) {
+			return primitive.ObjectIDFromHex(pathParts[i+1])
+		}
+	}
+	return primitive.NilObjectID, fmt.Errorf("rule ID is required")
+}
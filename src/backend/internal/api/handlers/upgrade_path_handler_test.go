@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
@@ -34,6 +35,7 @@ func setupUpgradePathHandlerTest(t *testing.T) (*UpgradePathHandler, *service.Se
 	_ = db.Collection("versions").Drop(ctx)
 	_ = db.Collection("compatibility_matrices").Drop(ctx)
 	_ = db.Collection("upgrade_paths").Drop(ctx)
+	_ = db.Collection("upgrade_path_rules").Drop(ctx)
 	_ = db.Collection("notifications").Drop(ctx)
 	_ = db.Collection("update_detections").Drop(ctx)
 	_ = db.Collection("update_rollouts").Drop(ctx)
@@ -48,6 +50,7 @@ func setupUpgradePathHandlerTest(t *testing.T) (*UpgradePathHandler, *service.Se
 		_ = db.Collection("versions").Drop(ctx)
 		_ = db.Collection("compatibility_matrices").Drop(ctx)
 		_ = db.Collection("upgrade_paths").Drop(ctx)
+		_ = db.Collection("upgrade_path_rules").Drop(ctx)
 		_ = db.Collection("notifications").Drop(ctx)
 		_ = db.Collection("update_detections").Drop(ctx)
 		_ = db.Collection("update_rollouts").Drop(ctx)
@@ -167,7 +170,7 @@ func TestUpgradePathHandler_GetUpgradePath(t *testing.T) {
 		ToVersion:   "2.0.0",
 		PathType:    models.UpgradePathTypeDirect,
 	}
-	if err := services.UpgradePathService.CreateUpgradePath(ctx, &path); err != nil {
+	if err := services.UpgradePathService.CreateUpgradePath(ctx, &path, "user-123", ""); err != nil {
 		t.Fatalf("Failed to create upgrade path: %v", err)
 	}
 
@@ -223,7 +226,7 @@ func TestUpgradePathHandler_BlockUpgradePath(t *testing.T) {
 		ToVersion:   "2.0.0",
 		PathType:    models.UpgradePathTypeDirect,
 	}
-	if err := services.UpgradePathService.CreateUpgradePath(ctx, &path); err != nil {
+	if err := services.UpgradePathService.CreateUpgradePath(ctx, &path, "user-123", ""); err != nil {
 		t.Fatalf("Failed to create upgrade path: %v", err)
 	}
 
@@ -252,3 +255,102 @@ func TestUpgradePathHandler_BlockUpgradePath(t *testing.T) {
 		t.Error("Expected upgrade path to be blocked")
 	}
 }
+
+func TestUpgradePathHandler_UnblockAndListUpgradePaths(t *testing.T) {
+	handler, services, cleanup := setupUpgradePathHandlerTest(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	productReq := models.CreateProductRequest{
+		ProductID: "unblock-upgrade-path-product",
+		Name:      "Unblock Upgrade Path Product",
+		Type:      models.ProductTypeServer,
+	}
+	product, err := services.ProductService.CreateProduct(ctx, &productReq, "user1", "user1@example.com")
+	if err != nil {
+		t.Fatalf("Failed to create product: %v", err)
+	}
+
+	for _, v := range []string{"1.0.0", "2.0.0", "3.0.0"} {
+		versionReq := models.CreateVersionRequest{
+			VersionNumber: v,
+			ReleaseType:   models.ReleaseTypeMajor,
+		}
+		if _, err := services.VersionService.CreateVersion(ctx, product.ProductID, &versionReq, "user1"); err != nil {
+			t.Fatalf("Failed to create version %s: %v", v, err)
+		}
+	}
+
+	for _, to := range []string{"2.0.0", "3.0.0"} {
+		path := models.UpgradePath{
+			ProductID:   product.ProductID,
+			FromVersion: "1.0.0",
+			ToVersion:   to,
+			PathType:    models.UpgradePathTypeDirect,
+		}
+		if err := services.UpgradePathService.CreateUpgradePath(ctx, &path, "user1", ""); err != nil {
+			t.Fatalf("Failed to create upgrade path: %v", err)
+		}
+	}
+
+	if err := services.UpgradePathService.BlockUpgradePath(ctx, product.ProductID, "1.0.0", "2.0.0", "Incompatible changes", "user1", ""); err != nil {
+		t.Fatalf("Failed to block upgrade path: %v", err)
+	}
+
+	// List blocked paths only
+	httpReq := httptest.NewRequest(http.MethodGet, "/api/v1/products/"+product.ProductID+"/upgrade-paths?blocked=true", nil)
+	w := httptest.NewRecorder()
+	handler.ListUpgradePaths(w, httpReq)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var response utils.JSONResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if response.Meta == nil || response.Meta.Total != 1 {
+		t.Errorf("Expected 1 blocked upgrade path, got %+v", response.Meta)
+	}
+
+	// An unknown blocked value is rejected rather than ignored
+	httpReq = httptest.NewRequest(http.MethodGet, "/api/v1/products/"+product.ProductID+"/upgrade-paths?blocked=yes", nil)
+	w = httptest.NewRecorder()
+	handler.ListUpgradePaths(w, httpReq)
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "INVALID_FILTER") {
+		t.Errorf("Expected 400 INVALID_FILTER, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// Unblock
+	httpReq = httptest.NewRequest(http.MethodPost, "/api/v1/products/"+product.ProductID+"/upgrade-paths/1.0.0/2.0.0/unblock", nil)
+	w = httptest.NewRecorder()
+	handler.UnblockUpgradePath(w, httpReq)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	// Unblocking again conflicts
+	httpReq = httptest.NewRequest(http.MethodPost, "/api/v1/products/"+product.ProductID+"/upgrade-paths/1.0.0/2.0.0/unblock", nil)
+	w = httptest.NewRecorder()
+	handler.UnblockUpgradePath(w, httpReq)
+
+	if w.Code != http.StatusConflict {
+		t.Errorf("Expected status %d, got %d", http.StatusConflict, w.Code)
+	}
+
+	// Delete
+	httpReq = httptest.NewRequest(http.MethodDelete, "/api/v1/products/"+product.ProductID+"/upgrade-paths/1.0.0/3.0.0", nil)
+	w = httptest.NewRecorder()
+	handler.DeleteUpgradePath(w, httpReq)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	if _, err := services.UpgradePathService.GetUpgradePath(ctx, product.ProductID, "1.0.0", "3.0.0"); err == nil {
+		t.Error("Expected upgrade path to be deleted")
+	}
+}
@@ -21,15 +21,13 @@ import (
 
 // VersionHandler handles version-related HTTP requests
 type VersionHandler struct {
-	versionService      *service.VersionService
-	pendingUpdatesService *service.PendingUpdatesService
+	versionService *service.VersionService
 }
 
 // NewVersionHandler creates a new version handler
-func NewVersionHandler(versionService *service.VersionService, pendingUpdatesService *service.PendingUpdatesService) *VersionHandler {
+func NewVersionHandler(versionService *service.VersionService) *VersionHandler {
 	return &VersionHandler{
-		versionService:       versionService,
-		pendingUpdatesService: pendingUpdatesService,
+		versionService: versionService,
 	}
 }
 
@@ -105,6 +103,10 @@ func (h *VersionHandler) ListVersions(w http.ResponseWriter, r *http.Request) {
 		filter["release_type"] = releaseType
 	}
 
+	if haltStatus := r.URL.Query().Get("rollout_halt_status"); haltStatus != "" {
+		filter["rollout_halt.status"] = haltStatus
+	}
+
 	page := utils.GetIntQueryParam(r, "page", 1)
 	limit := utils.GetIntQueryParam(r, "limit", 25)
 	if page < 1 {
@@ -350,12 +352,6 @@ func (h *VersionHandler) ReleaseVersion(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	// Invalidate pending updates cache for this product when a new version is released
-	// This ensures pending updates are recalculated with the new version
-	if h.pendingUpdatesService != nil {
-		h.pendingUpdatesService.InvalidateCacheForProduct(r.Context(), version.ProductID)
-	}
-
 	utils.WriteSuccess(w, http.StatusOK, version)
 }
 
@@ -527,6 +523,11 @@ func (h *VersionHandler) UploadPackage(w http.ResponseWriter, r *http.Request) {
 		DownloadURL:    fmt.Sprintf("/api/v1/versions/%s/packages/%s/download", id.Hex(), packageID.Hex()),
 	}
 
+	// Rollback packages may name the version they restore
+	if packageType == models.PackageTypeRollback {
+		packageInfo.RestoresVersion = r.FormValue("restores_version")
+	}
+
 	// Add package to version
 	_, err = h.versionService.AddPackageToVersion(r.Context(), id, &packageInfo)
 	if err != nil {
@@ -0,0 +1,321 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"updatemanager/internal/api/utils"
+	"updatemanager/internal/models"
+	"updatemanager/internal/service"
+)
+
+// WebhookHandler handles webhook HTTP requests
+type WebhookHandler struct {
+	webhookService *service.WebhookService
+}
+
+// NewWebhookHandler creates a new webhook handler
+func NewWebhookHandler(webhookService *service.WebhookService) *WebhookHandler {
+	return &WebhookHandler{
+		webhookService: webhookService,
+	}
+}
+
+// CreateWebhook handles POST /api/v1/webhooks
+func (h *WebhookHandler) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Method not allowed")
+		return
+	}
+
+	var req models.CreateWebhookRequest
+	if err := utils.ReadJSON(w, r, &req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "INVALID_REQUEST", "Invalid JSON: "+err.Error())
+		return
+	}
+
+	userID, userEmail := requestUser(r)
+
+	webhook, err := h.webhookService.CreateWebhook(r.Context(), &req, userID, userEmail)
+	if err != nil {
+		if strings.Contains(err.Error(), "invalid webhook") {
+			utils.WriteError(w, http.StatusBadRequest, "INVALID_WEBHOOK", err.Error())
+			return
+		}
+		if strings.Contains(err.Error(), "not found") {
+			utils.WriteError(w, http.StatusNotFound, "NOT_FOUND", err.Error())
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, "CREATE_FAILED", err.Error())
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusCreated, webhook)
+}
+
+// ListWebhooks handles GET /api/v1/webhooks
+func (h *WebhookHandler) ListWebhooks(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Method not allowed")
+		return
+	}
+
+	page, limit := webhookPagination(r)
+
+	filter := bson.M{}
+	if customerID := r.URL.Query().Get("customer_id"); customerID != "" {
+		filter["customer_id"] = customerID
+	}
+	if eventType := r.URL.Query().Get("event_type"); eventType != "" {
+		filter["event_types"] = eventType
+	}
+	if isActive := r.URL.Query().Get("is_active"); isActive != "" {
+		filter["is_active"] = isActive == "true"
+	}
+
+	webhooks, total, err := h.webhookService.ListWebhooks(r.Context(), filter, page, limit)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "LIST_FAILED", err.Error())
+		return
+	}
+
+	utils.WritePaginated(w, http.StatusOK, webhooks, page, limit, total)
+}
+
+// GetWebhook handles GET /api/v1/webhooks/:id
+func (h *WebhookHandler) GetWebhook(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Method not allowed")
+		return
+	}
+
+	id, _, err := extractWebhookIDsFromPath(r.URL.Path)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "INVALID_ID", "Invalid webhook ID format")
+		return
+	}
+
+	webhook, err := h.webhookService.GetWebhook(r.Context(), id)
+	if err != nil {
+		writeWebhookError(w, err, "GET_FAILED")
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, webhook)
+}
+
+// UpdateWebhook handles PUT /api/v1/webhooks/:id
+func (h *WebhookHandler) UpdateWebhook(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Method not allowed")
+		return
+	}
+
+	id, _, err := extractWebhookIDsFromPath(r.URL.Path)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "INVALID_ID", "Invalid webhook ID format")
+		return
+	}
+
+	var req models.UpdateWebhookRequest
+	if err := utils.ReadJSON(w, r, &req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "INVALID_REQUEST", "Invalid JSON: "+err.Error())
+		return
+	}
+
+	userID, userEmail := requestUser(r)
+
+	webhook, err := h.webhookService.UpdateWebhook(r.Context(), id, &req, userID, userEmail)
+	if err != nil {
+		writeWebhookError(w, err, "UPDATE_FAILED")
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, webhook)
+}
+
+// DeleteWebhook handles DELETE /api/v1/webhooks/:id
+func (h *WebhookHandler) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Method not allowed")
+		return
+	}
+
+	id, _, err := extractWebhookIDsFromPath(r.URL.Path)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "INVALID_ID", "Invalid webhook ID format")
+		return
+	}
+
+	userID, userEmail := requestUser(r)
+
+	if err := h.webhookService.DeleteWebhook(r.Context(), id, userID, userEmail); err != nil {
+		writeWebhookError(w, err, "DELETE_FAILED")
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, map[string]string{"message": "Webhook deleted successfully"})
+}
+
+// PingWebhook handles POST /api/v1/webhooks/:id/ping
+func (h *WebhookHandler) PingWebhook(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Method not allowed")
+		return
+	}
+
+	id, _, err := extractWebhookIDsFromPath(r.URL.Path)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "INVALID_ID", "Invalid webhook ID format")
+		return
+	}
+
+	userID, _ := requestUser(r)
+
+	delivery, err := h.webhookService.Ping(r.Context(), id, userID)
+	if err != nil {
+		writeWebhookError(w, err, "PING_FAILED")
+		return
+	}
+
+	// The ping itself failing is reported in the delivery, not as an error
+	utils.WriteSuccess(w, http.StatusOK, delivery)
+}
+
+// ListDeliveries handles GET /api/v1/webhooks/:id/deliveries
+func (h *WebhookHandler) ListDeliveries(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Method not allowed")
+		return
+	}
+
+	id, _, err := extractWebhookIDsFromPath(r.URL.Path)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "INVALID_ID", "Invalid webhook ID format")
+		return
+	}
+
+	page, limit := webhookPagination(r)
+
+	filter := bson.M{}
+	if status := r.URL.Query().Get("status"); status != "" {
+		filter["status"] = status
+	}
+	if eventType := r.URL.Query().Get("event_type"); eventType != "" {
+		filter["event_type"] = eventType
+	}
+
+	deliveries, total, err := h.webhookService.ListDeliveries(r.Context(), id, filter, page, limit)
+	if err != nil {
+		writeWebhookError(w, err, "LIST_FAILED")
+		return
+	}
+
+	utils.WritePaginated(w, http.StatusOK, deliveries, page, limit, total)
+}
+
+// GetDelivery handles GET /api/v1/webhooks/:id/deliveries/:delivery_id
+func (h *WebhookHandler) GetDelivery(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Method not allowed")
+		return
+	}
+
+	id, deliveryID, err := extractWebhookIDsFromPath(r.URL.Path)
+	if err != nil || deliveryID.IsZero() {
+		utils.WriteError(w, http.StatusBadRequest, "INVALID_ID", "Invalid webhook or delivery ID format")
+		return
+	}
+
+	delivery, err := h.webhookService.GetDelivery(r.Context(), id, deliveryID)
+	if err != nil {
+		writeWebhookError(w, err, "GET_FAILED")
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, delivery)
+}
+
+// Redeliver handles POST /api/v1/webhooks/:id/deliveries/:delivery_id/redeliver
+func (h *WebhookHandler) Redeliver(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Method not allowed")
+		return
+	}
+
+	id, deliveryID, err := extractWebhookIDsFromPath(r.URL.Path)
+	if err != nil || deliveryID.IsZero() {
+		utils.WriteError(w, http.StatusBadRequest, "INVALID_ID", "Invalid webhook or delivery ID format")
+		return
+	}
+
+	userID, userEmail := requestUser(r)
+
+	delivery, err := h.webhookService.Redeliver(r.Context(), id, deliveryID, userID, userEmail)
+	if err != nil {
+		writeWebhookError(w, err, "REDELIVER_FAILED")
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusAccepted, delivery)
+}
+
+// writeWebhookError maps webhook service errors to responses
+func writeWebhookError(w http.ResponseWriter, err error, fallbackCode string) {
+	switch {
+	case strings.Contains(err.Error(), "invalid webhook"):
+		utils.WriteError(w, http.StatusBadRequest, "INVALID_WEBHOOK", err.Error())
+	case strings.Contains(err.Error(), "webhook delivery not found"):
+		utils.WriteError(w, http.StatusNotFound, "DELIVERY_NOT_FOUND", "Webhook delivery not found")
+	case strings.Contains(err.Error(), "webhook not found"):
+		utils.WriteError(w, http.StatusNotFound, "WEBHOOK_NOT_FOUND", "Webhook not found")
+	default:
+		utils.WriteError(w, http.StatusInternalServerError, fallbackCode, err.Error())
+	}
+}
+
+// webhookPagination reads the page and limit query parameters
+func webhookPagination(r *http.Request) (int, int) {
+	page := utils.GetIntQueryParam(r, "page", 1)
+	limit := utils.GetIntQueryParam(r, "limit", 10)
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+	return page, limit
+}
+
+// extractWebhookIDsFromPath returns the webhook ID following "webhooks" in the
+// path and, for delivery routes, the delivery ID following "deliveries"
+func extractWebhookIDsFromPath(path string) (primitive.ObjectID, primitive.ObjectID, error) {
+	webhookID := primitive.NilObjectID
+	deliveryID := primitive.NilObjectID
+	found := false
+
+	pathParts := strings.Split(strings.Trim(path, "/"), "/")
+	for i := 0; i+1 < len(pathParts); i++ {
+		var err error
+		switch pathParts[i] {
+		case "webhooks":
+			webhookID, err = primitive.ObjectIDFromHex(pathParts[i+1])
+			found = true
+		case "deliveries":
+			deliveryID, err = primitive.ObjectIDFromHex(pathParts[i+1])
+		default:
+			continue
+		}
+		if err != nil {
+			return primitive.NilObjectID, primitive.NilObjectID, err
+		}
+		i++
+	}
+	if !found {
+		return primitive.NilObjectID, primitive.NilObjectID, primitive.ErrInvalidHex
+	}
+	return webhookID, deliveryID, nil
+}
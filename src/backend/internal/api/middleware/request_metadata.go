@@ -0,0 +1,21 @@
+package middleware
+
+import (
+	"net/http"
+
+	"updatemanager/internal/requestmeta"
+)
+
+// RequestMetadataMiddleware puts the caller's user, email, IP address, user
+// agent and request ID in the request context, where the audit log picks them
+// up, and echoes the request ID in the X-Request-ID response header. The IP
+// address is only taken from forwarding headers set by the trusted proxies.
+func RequestMetadataMiddleware(next http.Handler, trusted requestmeta.TrustedProxies) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		meta := requestmeta.FromRequest(r, trusted)
+		if meta.RequestID != "" {
+			w.Header().Set(requestmeta.HeaderRequestID, meta.RequestID)
+		}
+		next.ServeHTTP(w, r.WithContext(requestmeta.NewContext(r.Context(), meta)))
+	})
+}
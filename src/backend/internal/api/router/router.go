@@ -6,12 +6,14 @@ import (
 
 	"updatemanager/internal/api/handlers"
 	"updatemanager/internal/api/middleware"
+	"updatemanager/internal/requestmeta"
 	"updatemanager/internal/service"
 )
 
 // Router handles HTTP routing
 type Router struct {
-	mux *http.ServeMux
+	mux            *http.ServeMux
+	trustedProxies requestmeta.TrustedProxies
 }
 
 // NewRouter creates a new router
@@ -20,13 +22,15 @@ func NewRouter(services *service.ServiceFactory) *Router {
 
 	// Initialize handlers
 	productHandler := handlers.NewProductHandler(services.ProductService)
-	versionHandler := handlers.NewVersionHandler(services.VersionService, services.PendingUpdatesService)
+	versionHandler := handlers.NewVersionHandler(services.VersionService)
 	compatibilityHandler := handlers.NewCompatibilityHandler(services.CompatibilityService)
 	notificationHandler := handlers.NewNotificationHandler(services.NotificationService)
 	upgradePathHandler := handlers.NewUpgradePathHandler(services.UpgradePathService)
 	updateDetectionHandler := handlers.NewUpdateDetectionHandler(services.UpdateDetectionService)
 	updateRolloutHandler := handlers.NewUpdateRolloutHandler(services.UpdateRolloutService)
 	auditLogHandler := handlers.NewAuditLogHandler(services.AuditLogService)
+	auditChainHandler := handlers.NewAuditChainHandler(services.AuditChainService)
+	domainEventMetricsHandler := handlers.NewDomainEventMetricsHandler(services.DomainEventMetrics)
 	customerHandler := handlers.NewCustomerHandler(services.CustomerService)
 	tenantHandler := handlers.NewTenantHandler(services.TenantService)
 	deploymentHandler := handlers.NewDeploymentHandler(services.DeploymentService)
@@ -34,6 +38,17 @@ func NewRouter(services *service.ServiceFactory) *Router {
 	subscriptionHandler := handlers.NewSubscriptionHandler(services.SubscriptionService)
 	licenseHandler := handlers.NewLicenseHandler(services.LicenseService)
 	licenseAllocationHandler := handlers.NewLicenseAllocationHandler(services.LicenseAllocationService)
+	endpointHandler := handlers.NewEndpointHandler(services.EndpointService)
+	campaignHandler := handlers.NewRolloutCampaignHandler(services.RolloutCampaignService)
+	rolloutHealthHandler := handlers.NewRolloutHealthHandler(services.RolloutHealthService)
+	rolloutEventHandler := handlers.NewRolloutEventHandler(services.RolloutEventService)
+	bulkRolloutHandler := handlers.NewBulkRolloutHandler(services.BulkRolloutService)
+	streamHandler := handlers.NewStreamHandler(services.StreamBus)
+	webhookHandler := handlers.NewWebhookHandler(services.WebhookService)
+	notificationRouteHandler := handlers.NewNotificationRouteHandler(services.NotificationRouteService)
+	notificationTemplateHandler := handlers.NewNotificationTemplateHandler(services.NotificationTemplateService)
+	internalNotificationHandler := handlers.NewInternalNotificationHandler(services.InternalNotificationService.Inbox())
+	internalRecipientHandler := handlers.NewInternalRecipientHandler(services.InternalNotificationService)
 
 	// API v1 routes
 	apiV1 := "/api/v1"
@@ -72,18 +87,60 @@ func NewRouter(services *service.ServiceFactory) *Router {
 
 		// Upgrade Path routes: /api/v1/products/:product_id/upgrade-paths
 		if strings.Contains(path, "/upgrade-paths") {
-			if strings.HasSuffix(path, "/block") {
+			if strings.HasSuffix(path, "/upgrade-paths/explain") {
+				upgradePathHandler.ExplainUpgradePath(w, r)
+			} else if strings.HasSuffix(path, "/unblock") {
+				upgradePathHandler.UnblockUpgradePath(w, r)
+			} else if strings.HasSuffix(path, "/block") {
 				upgradePathHandler.BlockUpgradePath(w, r)
 			} else if strings.Count(path, "/") >= 6 {
-				upgradePathHandler.GetUpgradePath(w, r)
+				switch r.Method {
+				case http.MethodGet:
+					upgradePathHandler.GetUpgradePath(w, r)
+				case http.MethodPut:
+					upgradePathHandler.UpdateUpgradePath(w, r)
+				case http.MethodDelete:
+					upgradePathHandler.DeleteUpgradePath(w, r)
+				default:
+					http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+				}
 			} else if r.Method == http.MethodPost {
 				upgradePathHandler.CreateUpgradePath(w, r)
+			} else if r.Method == http.MethodGet {
+				upgradePathHandler.ListUpgradePaths(w, r)
 			} else {
 				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 			}
 			return
 		}
 
+		// Upgrade Path Rule routes: /api/v1/products/:product_id/upgrade-path-rules
+		if strings.Contains(path, "/upgrade-path-rules") {
+			if strings.HasSuffix(path, "/upgrade-path-rules") {
+				switch r.Method {
+				case http.MethodGet:
+					upgradePathHandler.ListUpgradePathRules(w, r)
+				case http.MethodPost:
+					upgradePathHandler.CreateUpgradePathRule(w, r)
+				default:
+					http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+				}
+				return
+			}
+			// GET/PUT/DELETE /api/v1/products/:product_id/upgrade-path-rules/:rule_id
+			switch r.Method {
+			case http.MethodGet:
+				upgradePathHandler.GetUpgradePathRule(w, r)
+			case http.MethodPut:
+				upgradePathHandler.UpdateUpgradePathRule(w, r)
+			case http.MethodDelete:
+				upgradePathHandler.DeleteUpgradePathRule(w, r)
+			default:
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			}
+			return
+		}
+
 		// Compatibility routes: /api/v1/products/:product_id/versions/:version_number/compatibility
 		if strings.Contains(path, "/versions/") && strings.Contains(path, "/compatibility") {
 			if r.Method == http.MethodPost {
@@ -127,6 +184,8 @@ func NewRouter(services *service.ServiceFactory) *Router {
 	// POST /api/v1/versions/:id/submit
 	// POST /api/v1/versions/:id/approve
 	// POST /api/v1/versions/:id/release
+	// POST /api/v1/versions/:id/rollouts/resume
+	// POST /api/v1/versions/:id/rollouts/recall
 	mux.HandleFunc(apiV1+"/versions", func(w http.ResponseWriter, r *http.Request) {
 		path := r.URL.Path
 		basePath := apiV1 + "/versions"
@@ -148,6 +207,10 @@ func NewRouter(services *service.ServiceFactory) *Router {
 			versionHandler.ApproveVersion(w, r)
 		} else if strings.HasSuffix(path, "/release") {
 			versionHandler.ReleaseVersion(w, r)
+		} else if strings.HasSuffix(path, "/rollouts/resume") {
+			rolloutHealthHandler.ResumeRollouts(w, r)
+		} else if strings.HasSuffix(path, "/rollouts/recall") {
+			rolloutHealthHandler.RecallRollouts(w, r)
 		} else if strings.HasSuffix(path, "/packages") {
 			// Handle GET/POST /api/v1/versions/:id/packages
 			if r.Method == http.MethodGet {
@@ -196,6 +259,10 @@ func NewRouter(services *service.ServiceFactory) *Router {
 			versionHandler.ApproveVersion(w, r)
 		} else if strings.HasSuffix(path, "/release") {
 			versionHandler.ReleaseVersion(w, r)
+		} else if strings.HasSuffix(path, "/rollouts/resume") {
+			rolloutHealthHandler.ResumeRollouts(w, r)
+		} else if strings.HasSuffix(path, "/rollouts/recall") {
+			rolloutHealthHandler.RecallRollouts(w, r)
 		} else if strings.HasSuffix(path, "/packages") {
 			// Handle GET/POST /api/v1/versions/:id/packages
 			if r.Method == http.MethodGet {
@@ -245,10 +312,80 @@ func NewRouter(services *service.ServiceFactory) *Router {
 	// POST /api/v1/notifications/mark-all-read
 	mux.HandleFunc(apiV1+"/notifications/mark-all-read", notificationHandler.MarkAllAsRead)
 
+	// POST /api/v1/notifications/bulk
+	mux.HandleFunc(apiV1+"/notifications/bulk", notificationHandler.BulkUpdate)
+
+	// GET/DELETE /api/v1/notifications/:id
+	// POST /api/v1/notifications/:id/read
+	// POST /api/v1/notifications/:id/unread
+	// POST /api/v1/notifications/:id/archive
+	// POST /api/v1/notifications/:id/unarchive
+	mux.HandleFunc(apiV1+"/notifications/", func(w http.ResponseWriter, r *http.Request) {
+		path := strings.TrimSuffix(r.URL.Path, "/")
+		switch {
+		case strings.HasSuffix(path, "/unread"):
+			notificationHandler.MarkAsUnread(w, r)
+		case strings.HasSuffix(path, "/read"):
+			notificationHandler.MarkAsRead(w, r)
+		case strings.HasSuffix(path, "/unarchive"):
+			notificationHandler.Unarchive(w, r)
+		case strings.HasSuffix(path, "/archive"):
+			notificationHandler.Archive(w, r)
+		default:
+			switch r.Method {
+			case http.MethodGet:
+				notificationHandler.GetNotification(w, r)
+			case http.MethodDelete:
+				notificationHandler.DeleteNotification(w, r)
+			default:
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			}
+		}
+	})
+
 	// Upgrade Path routes are handled in the /api/v1/products/ handler above
+	// GET /api/v1/products/:product_id/upgrade-paths?from_version=x&to_version=y&blocked=true
 	// POST /api/v1/products/:product_id/upgrade-paths
-	// GET /api/v1/products/:product_id/upgrade-paths/:from_version/:to_version
+	// GET/PUT/DELETE /api/v1/products/:product_id/upgrade-paths/:from_version/:to_version
 	// POST /api/v1/products/:product_id/upgrade-paths/:from_version/:to_version/block
+	// POST /api/v1/products/:product_id/upgrade-paths/:from_version/:to_version/unblock
+	// GET /api/v1/products/:product_id/upgrade-paths/explain?from_version=x&to_version=y
+	// GET/POST /api/v1/products/:product_id/upgrade-path-rules
+	// GET/PUT/DELETE /api/v1/products/:product_id/upgrade-path-rules/:rule_id
+
+	// Endpoint Registry routes
+	// GET/POST /api/v1/endpoints
+	mux.HandleFunc(apiV1+"/endpoints", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			endpointHandler.ListEndpoints(w, r)
+		case http.MethodPost:
+			endpointHandler.RegisterEndpoint(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+	// GET /api/v1/endpoints/stale?days=7
+	mux.HandleFunc(apiV1+"/endpoints/stale", endpointHandler.GetStaleEndpoints)
+
+	// GET/PUT/DELETE /api/v1/endpoints/:id
+	// POST /api/v1/endpoints/:id/heartbeat
+	mux.HandleFunc(apiV1+"/endpoints/", func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/heartbeat") {
+			endpointHandler.Heartbeat(w, r)
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			endpointHandler.GetEndpoint(w, r)
+		case http.MethodPut:
+			endpointHandler.UpdateEndpoint(w, r)
+		case http.MethodDelete:
+			endpointHandler.DeleteEndpoint(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
 
 	// Update Detection routes
 	// GET/POST /api/v1/update-detections
@@ -262,6 +399,9 @@ func NewRouter(services *service.ServiceFactory) *Router {
 			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		}
 	})
+	// POST /api/v1/update-detections/check-in
+	mux.HandleFunc(apiV1+"/update-detections/check-in", updateDetectionHandler.CheckIn)
+
 	// PUT /api/v1/update-detections/:id/available-version
 	mux.HandleFunc(apiV1+"/update-detections/", updateDetectionHandler.UpdateAvailableVersion)
 
@@ -280,12 +420,34 @@ func NewRouter(services *service.ServiceFactory) *Router {
 	// GET/PUT /api/v1/update-rollouts/:id
 	// PUT /api/v1/update-rollouts/:id/status
 	// PUT /api/v1/update-rollouts/:id/progress
+	// POST /api/v1/update-rollouts/:id/rollback
+	// GET/POST /api/v1/update-rollouts/:id/events
+	// GET /api/v1/update-rollouts/stuck
+	// POST /api/v1/update-rollouts/bulk
+	// POST /api/v1/update-rollouts/bulk/preview
 	mux.HandleFunc(apiV1+"/update-rollouts/", func(w http.ResponseWriter, r *http.Request) {
 		path := r.URL.Path
-		if strings.HasSuffix(path, "/status") {
+		if path == apiV1+"/update-rollouts/stuck" {
+			updateRolloutHandler.GetStuckRollouts(w, r)
+		} else if path == apiV1+"/update-rollouts/bulk" {
+			bulkRolloutHandler.ExecuteBulkRollout(w, r)
+		} else if path == apiV1+"/update-rollouts/bulk/preview" {
+			bulkRolloutHandler.PreviewBulkRollout(w, r)
+		} else if strings.HasSuffix(path, "/events") {
+			switch r.Method {
+			case http.MethodGet:
+				rolloutEventHandler.ListEvents(w, r)
+			case http.MethodPost:
+				rolloutEventHandler.RecordEvents(w, r)
+			default:
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			}
+		} else if strings.HasSuffix(path, "/status") {
 			updateRolloutHandler.UpdateRolloutStatus(w, r)
 		} else if strings.HasSuffix(path, "/progress") {
 			updateRolloutHandler.UpdateRolloutProgress(w, r)
+		} else if strings.HasSuffix(path, "/rollback") {
+			updateRolloutHandler.RollbackRollout(w, r)
 		} else {
 			// Handle GET/PUT /api/v1/update-rollouts/:id
 			switch r.Method {
@@ -297,9 +459,248 @@ func NewRouter(services *service.ServiceFactory) *Router {
 		}
 	})
 
+	// Live update stream (Server-Sent Events)
+	// GET /api/v1/stream
+	mux.HandleFunc(apiV1+"/stream", streamHandler.Stream)
+
+	// Rollout Campaign routes
+	// GET/POST /api/v1/rollout-campaigns
+	mux.HandleFunc(apiV1+"/rollout-campaigns", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			campaignHandler.ListCampaigns(w, r)
+		case http.MethodPost:
+			campaignHandler.CreateCampaign(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+	// GET /api/v1/rollout-campaigns/:id
+	// GET /api/v1/rollout-campaigns/:id/rollouts
+	// POST /api/v1/rollout-campaigns/:id/pause
+	// POST /api/v1/rollout-campaigns/:id/resume
+	// POST /api/v1/rollout-campaigns/:id/abort
+	mux.HandleFunc(apiV1+"/rollout-campaigns/", func(w http.ResponseWriter, r *http.Request) {
+		path := r.URL.Path
+		switch {
+		case strings.HasSuffix(path, "/rollouts"):
+			campaignHandler.ListCampaignRollouts(w, r)
+		case strings.HasSuffix(path, "/pause"):
+			campaignHandler.PauseCampaign(w, r)
+		case strings.HasSuffix(path, "/resume"):
+			campaignHandler.ResumeCampaign(w, r)
+		case strings.HasSuffix(path, "/abort"):
+			campaignHandler.AbortCampaign(w, r)
+		default:
+			campaignHandler.GetCampaign(w, r)
+		}
+	})
+
+	// Webhook routes
+	// GET/POST /api/v1/webhooks
+	mux.HandleFunc(apiV1+"/webhooks", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			webhookHandler.ListWebhooks(w, r)
+		case http.MethodPost:
+			webhookHandler.CreateWebhook(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+	// GET/PUT/DELETE /api/v1/webhooks/:id
+	// POST /api/v1/webhooks/:id/ping
+	// GET /api/v1/webhooks/:id/deliveries
+	// GET /api/v1/webhooks/:id/deliveries/:delivery_id
+	// POST /api/v1/webhooks/:id/deliveries/:delivery_id/redeliver
+	mux.HandleFunc(apiV1+"/webhooks/", func(w http.ResponseWriter, r *http.Request) {
+		path := strings.TrimSuffix(r.URL.Path, "/")
+		switch {
+		case strings.HasSuffix(path, "/ping"):
+			webhookHandler.PingWebhook(w, r)
+		case strings.HasSuffix(path, "/redeliver"):
+			webhookHandler.Redeliver(w, r)
+		case strings.HasSuffix(path, "/deliveries"):
+			webhookHandler.ListDeliveries(w, r)
+		case strings.Contains(path, "/deliveries/"):
+			webhookHandler.GetDelivery(w, r)
+		default:
+			switch r.Method {
+			case http.MethodGet:
+				webhookHandler.GetWebhook(w, r)
+			case http.MethodPut:
+				webhookHandler.UpdateWebhook(w, r)
+			case http.MethodDelete:
+				webhookHandler.DeleteWebhook(w, r)
+			default:
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			}
+		}
+	})
+
+	// Notification route routes
+	// GET/POST /api/v1/notification-routes
+	mux.HandleFunc(apiV1+"/notification-routes", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			notificationRouteHandler.ListRoutes(w, r)
+		case http.MethodPost:
+			notificationRouteHandler.CreateRoute(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+	// GET/PUT/DELETE /api/v1/notification-routes/:id
+	mux.HandleFunc(apiV1+"/notification-routes/", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			notificationRouteHandler.GetRoute(w, r)
+		case http.MethodPut:
+			notificationRouteHandler.UpdateRoute(w, r)
+		case http.MethodDelete:
+			notificationRouteHandler.DeleteRoute(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	// Notification template routes
+	// GET/POST /api/v1/notification-templates
+	mux.HandleFunc(apiV1+"/notification-templates", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			notificationTemplateHandler.ListTemplates(w, r)
+		case http.MethodPost:
+			notificationTemplateHandler.CreateTemplate(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+	// POST /api/v1/notification-templates/preview
+	// GET/PUT/DELETE /api/v1/notification-templates/:id
+	mux.HandleFunc(apiV1+"/notification-templates/", func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(strings.TrimSuffix(r.URL.Path, "/"), "/preview") {
+			notificationTemplateHandler.PreviewTemplate(w, r)
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			notificationTemplateHandler.GetTemplate(w, r)
+		case http.MethodPut:
+			notificationTemplateHandler.UpdateTemplate(w, r)
+		case http.MethodDelete:
+			notificationTemplateHandler.DeleteTemplate(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	// Internal notification routes, for the user in X-User-ID
+	// GET /api/v1/internal/notifications
+	mux.HandleFunc(apiV1+"/internal/notifications", internalNotificationHandler.GetNotifications)
+
+	// GET /api/v1/internal/notifications/unread-count
+	mux.HandleFunc(apiV1+"/internal/notifications/unread-count", internalNotificationHandler.GetUnreadCount)
+
+	// POST /api/v1/internal/notifications/mark-all-read
+	mux.HandleFunc(apiV1+"/internal/notifications/mark-all-read", internalNotificationHandler.MarkAllAsRead)
+
+	// POST /api/v1/internal/notifications/bulk
+	mux.HandleFunc(apiV1+"/internal/notifications/bulk", internalNotificationHandler.BulkUpdate)
+
+	// GET/DELETE /api/v1/internal/notifications/:id
+	// POST /api/v1/internal/notifications/:id/read
+	// POST /api/v1/internal/notifications/:id/unread
+	// POST /api/v1/internal/notifications/:id/archive
+	// POST /api/v1/internal/notifications/:id/unarchive
+	mux.HandleFunc(apiV1+"/internal/notifications/", func(w http.ResponseWriter, r *http.Request) {
+		path := strings.TrimSuffix(r.URL.Path, "/")
+		switch {
+		case strings.HasSuffix(path, "/unread"):
+			internalNotificationHandler.MarkAsUnread(w, r)
+		case strings.HasSuffix(path, "/read"):
+			internalNotificationHandler.MarkAsRead(w, r)
+		case strings.HasSuffix(path, "/unarchive"):
+			internalNotificationHandler.Unarchive(w, r)
+		case strings.HasSuffix(path, "/archive"):
+			internalNotificationHandler.Archive(w, r)
+		default:
+			switch r.Method {
+			case http.MethodGet:
+				internalNotificationHandler.GetNotification(w, r)
+			case http.MethodDelete:
+				internalNotificationHandler.DeleteNotification(w, r)
+			default:
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			}
+		}
+	})
+
+	// GET/POST /api/v1/internal/groups
+	mux.HandleFunc(apiV1+"/internal/groups", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			internalRecipientHandler.ListGroups(w, r)
+		case http.MethodPost:
+			internalRecipientHandler.CreateGroup(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	// GET/PUT/DELETE /api/v1/internal/groups/:id
+	mux.HandleFunc(apiV1+"/internal/groups/", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			internalRecipientHandler.GetGroup(w, r)
+		case http.MethodPut:
+			internalRecipientHandler.UpdateGroup(w, r)
+		case http.MethodDelete:
+			internalRecipientHandler.DeleteGroup(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	// GET/POST /api/v1/internal/notification-subscriptions
+	mux.HandleFunc(apiV1+"/internal/notification-subscriptions", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			internalRecipientHandler.ListSubscriptions(w, r)
+		case http.MethodPost:
+			internalRecipientHandler.CreateSubscription(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	// GET/PUT/DELETE /api/v1/internal/notification-subscriptions/:id
+	mux.HandleFunc(apiV1+"/internal/notification-subscriptions/", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			internalRecipientHandler.GetSubscription(w, r)
+		case http.MethodPut:
+			internalRecipientHandler.UpdateSubscription(w, r)
+		case http.MethodDelete:
+			internalRecipientHandler.DeleteSubscription(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
 	// Audit Log routes
 	// GET /api/v1/audit-logs
 	mux.HandleFunc(apiV1+"/audit-logs", auditLogHandler.GetAuditLogs)
+	// GET /api/v1/audit-logs/state?resource_type=&resource_id=&at=
+	mux.HandleFunc(apiV1+"/audit-logs/state", auditLogHandler.GetResourceState)
+	// GET /api/v1/audit-logs/verify
+	mux.HandleFunc(apiV1+"/audit-logs/verify", auditChainHandler.VerifyChain)
+	// GET /api/v1/audit-logs/checkpoints
+	mux.HandleFunc(apiV1+"/audit-logs/checkpoints", auditChainHandler.ListCheckpoints)
+
+	// Domain event routes
+	// GET /api/v1/domain-events/metrics
+	mux.HandleFunc(apiV1+"/domain-events/metrics", domainEventMetricsHandler.GetMetrics)
 
 	// Customer Management routes
 	// GET/POST /api/v1/customers
@@ -550,9 +951,16 @@ func NewRouter(services *service.ServiceFactory) *Router {
 	return &Router{mux: mux}
 }
 
+// SetTrustedProxies sets the proxies whose forwarding headers give the
+// client's IP address. With none, it is the connection's address.
+func (r *Router) SetTrustedProxies(proxies requestmeta.TrustedProxies) {
+	r.trustedProxies = proxies
+}
+
 // Handler returns the HTTP handler with middleware
 func (r *Router) Handler() http.Handler {
 	handler := http.Handler(r.mux)
+	handler = middleware.RequestMetadataMiddleware(handler, r.trustedProxies)
 	handler = middleware.RecoveryMiddleware(handler)
 	handler = middleware.LoggingMiddleware(handler)
 	handler = middleware.CORSMiddleware(handler)
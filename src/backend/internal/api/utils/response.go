@@ -55,6 +55,20 @@ func WriteError(w http.ResponseWriter, status int, code, message string) error {
 	return WriteJSON(w, status, response)
 }
 
+// WriteErrorWithData writes an error JSON response that also carries data,
+// such as the current state of a resource a request conflicted with
+func WriteErrorWithData(w http.ResponseWriter, status int, code, message string, data interface{}) error {
+	response := JSONResponse{
+		Success: false,
+		Data:    data,
+		Error: &ErrorInfo{
+			Code:    code,
+			Message: message,
+		},
+	}
+	return WriteJSON(w, status, response)
+}
+
 // WritePaginated writes a paginated JSON response
 func WritePaginated(w http.ResponseWriter, status int, data interface{}, page, limit int, total int64) error {
 	totalPages := int((total + int64(limit) - 1) / int64(limit))
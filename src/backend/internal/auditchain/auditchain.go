@@ -0,0 +1,222 @@
+// Package auditchain links audit log entries into a hash chain, so edited,
+// removed or reordered entries can be detected, and signs checkpoints of the
+// chain's head.
+package auditchain
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"updatemanager/internal/models"
+)
+
+// GenesisHash is the previous hash of the first entry of the chain
+var GenesisHash = strings.Repeat("0", 64)
+
+// ContentHash returns the SHA-256 of an entry's content: every field but its
+// content hash and hash, including its ID, sequence and previous hash. The
+// content is hashed as stored, in BSON with the keys of every document sorted,
+// so an entry read back from the database hashes the same.
+func ContentHash(entry *models.AuditLog) (string, error) {
+	content := *entry
+	content.ContentHash = ""
+	content.Hash = ""
+
+	data, err := bson.Marshal(&content)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode audit log: %w", err)
+	}
+	var doc bson.M
+	if err := bson.Unmarshal(data, &doc); err != nil {
+		return "", fmt.Errorf("failed to decode audit log: %w", err)
+	}
+	canonical, err := bson.Marshal(canonicalValue(doc))
+	if err != nil {
+		return "", fmt.Errorf("failed to encode audit log: %w", err)
+	}
+
+	sum := sha256.Sum256(canonical)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// canonicalValue turns documents into bson.D sorted by key, at any depth
+func canonicalValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case bson.M:
+		return canonicalDocument(v)
+	case map[string]interface{}:
+		return canonicalDocument(v)
+	case bson.D:
+		doc := make(map[string]interface{}, len(v))
+		for _, element := range v {
+			doc[element.Key] = element.Value
+		}
+		return canonicalDocument(doc)
+	case primitive.A:
+		array := make(primitive.A, len(v))
+		for i, item := range v {
+			array[i] = canonicalValue(item)
+		}
+		return array
+	case []interface{}:
+		return canonicalValue(primitive.A(v))
+	}
+	return value
+}
+
+func canonicalDocument(doc map[string]interface{}) bson.D {
+	keys := make([]string, 0, len(doc))
+	for key := range doc {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	sorted := make(bson.D, 0, len(keys))
+	for _, key := range keys {
+		sorted = append(sorted, bson.E{Key: key, Value: canonicalValue(doc[key])})
+	}
+	return sorted
+}
+
+// ChainHash returns the hash of an entry from the previous entry's hash and
+// its content hash
+func ChainHash(prevHash, contentHash string) string {
+	sum := sha256.Sum256([]byte(prevHash + contentHash))
+	return hex.EncodeToString(sum[:])
+}
+
+// Seal appends an entry to the chain after prev, nil for the first entry,
+// setting its sequence, previous hash, content hash and hash. The entry must
+// have its ID and timestamp set, as they are part of the content.
+func Seal(entry, prev *models.AuditLog) error {
+	entry.Sequence = 1
+	entry.PrevHash = GenesisHash
+	if prev != nil {
+		entry.Sequence = prev.Sequence + 1
+		entry.PrevHash = prev.Hash
+	}
+
+	contentHash, err := ContentHash(entry)
+	if err != nil {
+		return err
+	}
+	entry.ContentHash = contentHash
+	entry.Hash = ChainHash(entry.PrevHash, contentHash)
+	return nil
+}
+
+// Check verifies the link from prev, nil for the first entry, to entry. It
+// returns nil when the link is intact.
+func Check(entry, prev *models.AuditLog) *models.AuditChainBreak {
+	broken := func(reason, expected, actual string) *models.AuditChainBreak {
+		return &models.AuditChainBreak{
+			Sequence: entry.Sequence,
+			EntryID:  entry.ID.Hex(),
+			Reason:   reason,
+			Expected: expected,
+			Actual:   actual,
+		}
+	}
+
+	wantSequence, wantPrevHash := int64(1), GenesisHash
+	if prev != nil {
+		wantSequence, wantPrevHash = prev.Sequence+1, prev.Hash
+	}
+	if entry.Sequence != wantSequence {
+		return broken("sequence gap: entries are missing", fmt.Sprint(wantSequence), fmt.Sprint(entry.Sequence))
+	}
+	if entry.PrevHash != wantPrevHash {
+		return broken("previous hash does not match the previous entry", wantPrevHash, entry.PrevHash)
+	}
+
+	contentHash, err := ContentHash(entry)
+	if err != nil {
+		return broken(err.Error(), entry.ContentHash, "")
+	}
+	if entry.ContentHash != contentHash {
+		return broken("content hash does not match: the entry was modified", contentHash, entry.ContentHash)
+	}
+	if hash := ChainHash(entry.PrevHash, entry.ContentHash); entry.Hash != hash {
+		return broken("hash does not match", hash, entry.Hash)
+	}
+	return nil
+}
+
+// Signer signs checkpoints with an Ed25519 key
+type Signer struct {
+	key   ed25519.PrivateKey
+	keyID string
+}
+
+// ParseSigningKey parses a base64 Ed25519 private key, either its 32 byte
+// seed or the 64 byte key
+func ParseSigningKey(value string) (*Signer, error) {
+	data, err := base64.StdEncoding.DecodeString(strings.TrimSpace(value))
+	if err != nil {
+		return nil, fmt.Errorf("invalid checkpoint signing key: %w", err)
+	}
+
+	var key ed25519.PrivateKey
+	switch len(data) {
+	case ed25519.SeedSize:
+		key = ed25519.NewKeyFromSeed(data)
+	case ed25519.PrivateKeySize:
+		key = ed25519.PrivateKey(data)
+	default:
+		return nil, fmt.Errorf("invalid checkpoint signing key: want a %d or %d byte Ed25519 key, got %d bytes", ed25519.SeedSize, ed25519.PrivateKeySize, len(data))
+	}
+	return &Signer{key: key, keyID: KeyID(key.Public().(ed25519.PublicKey))}, nil
+}
+
+// KeyID identifies a public key by the first 16 hex digits of its SHA-256
+func KeyID(publicKey ed25519.PublicKey) string {
+	sum := sha256.Sum256(publicKey)
+	return hex.EncodeToString(sum[:])[:16]
+}
+
+// KeyID returns the ID of the signer's public key
+func (s *Signer) KeyID() string {
+	return s.keyID
+}
+
+// Sign sets a checkpoint's key and signature. The checkpoint's sequence, hash
+// and creation time are signed.
+func (s *Signer) Sign(checkpoint *models.AuditCheckpoint) {
+	checkpoint.KeyID = s.keyID
+	checkpoint.PublicKey = base64.StdEncoding.EncodeToString(s.key.Public().(ed25519.PublicKey))
+	checkpoint.Signature = base64.StdEncoding.EncodeToString(ed25519.Sign(s.key, checkpointMessage(checkpoint)))
+}
+
+// VerifyCheckpoint checks a checkpoint's signature against the public key it
+// carries. Callers decide whether they trust that key, by its ID.
+func VerifyCheckpoint(checkpoint *models.AuditCheckpoint) error {
+	publicKey, err := base64.StdEncoding.DecodeString(checkpoint.PublicKey)
+	if err != nil || len(publicKey) != ed25519.PublicKeySize {
+		return fmt.Errorf("invalid checkpoint public key")
+	}
+	if KeyID(publicKey) != checkpoint.KeyID {
+		return fmt.Errorf("checkpoint key ID does not match its public key")
+	}
+	signature, err := base64.StdEncoding.DecodeString(checkpoint.Signature)
+	if err != nil {
+		return fmt.Errorf("invalid checkpoint signature")
+	}
+	if !ed25519.Verify(publicKey, checkpointMessage(checkpoint), signature) {
+		return fmt.Errorf("checkpoint signature does not match")
+	}
+	return nil
+}
+
+// checkpointMessage is what a checkpoint's signature covers. The creation time
+// is signed in milliseconds, the precision it is stored with.
+func checkpointMessage(checkpoint *models.AuditCheckpoint) []byte {
+	return []byte(fmt.Sprintf("updatemanager-audit-checkpoint:%d:%s:%d", checkpoint.Sequence, checkpoint.Hash, checkpoint.CreatedAt.UnixMilli()))
+}
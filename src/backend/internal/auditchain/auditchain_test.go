@@ -0,0 +1,127 @@
+package auditchain
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"updatemanager/internal/models"
+)
+
+func newEntry(resourceID string) *models.AuditLog {
+	return &models.AuditLog{
+		ID:           primitive.NewObjectID(),
+		Action:       models.AuditActionUpdate,
+		ResourceType: "customer",
+		ResourceID:   resourceID,
+		UserID:       "alice",
+		Details:      map[string]interface{}{"reason": "renamed", "meta": map[string]interface{}{"b": 2, "a": []interface{}{"x", 1.5}}},
+		Changes:      []models.AuditFieldChange{{Field: "name", OldValue: "Acme", NewValue: "Acme Corp"}},
+		Timestamp:    time.Now().UTC().Truncate(time.Millisecond),
+	}
+}
+
+func TestContentHashSurvivesStorage(t *testing.T) {
+	entry := newEntry("c1")
+	if err := Seal(entry, nil); err != nil {
+		t.Fatalf("Seal() error = %v", err)
+	}
+
+	data, err := bson.Marshal(entry)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	var stored models.AuditLog
+	if err := bson.Unmarshal(data, &stored); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	hash, err := ContentHash(&stored)
+	if err != nil {
+		t.Fatalf("ContentHash() error = %v", err)
+	}
+	if hash != entry.ContentHash {
+		t.Errorf("Expected the stored entry to hash the same, got %s, want %s", hash, entry.ContentHash)
+	}
+	if brk := Check(&stored, nil); brk != nil {
+		t.Errorf("Expected the stored entry to verify, got %+v", brk)
+	}
+}
+
+func TestCheckDetectsTampering(t *testing.T) {
+	first, second, third := newEntry("c1"), newEntry("c2"), newEntry("c3")
+	for i, entry := range []*models.AuditLog{first, second, third} {
+		prev := []*models.AuditLog{nil, first, second}[i]
+		if err := Seal(entry, prev); err != nil {
+			t.Fatalf("Seal() error = %v", err)
+		}
+	}
+	if third.Sequence != 3 || third.PrevHash != second.Hash || first.PrevHash != GenesisHash {
+		t.Fatalf("Unexpected chain %+v", third)
+	}
+	if brk := Check(second, first); brk != nil {
+		t.Fatalf("Expected an intact link, got %+v", brk)
+	}
+
+	edited := *second
+	edited.UserID = "mallory"
+	if brk := Check(&edited, first); brk == nil || !strings.Contains(brk.Reason, "modified") {
+		t.Errorf("Expected an edited entry to be detected, got %+v", brk)
+	}
+
+	// Rehashing the edited entry breaks the link to the next one
+	if err := Seal(&edited, first); err != nil {
+		t.Fatalf("Seal() error = %v", err)
+	}
+	if brk := Check(third, &edited); brk == nil || !strings.Contains(brk.Reason, "previous hash") {
+		t.Errorf("Expected a broken link after rehashing, got %+v", brk)
+	}
+
+	// A deleted entry leaves a gap
+	if brk := Check(third, first); brk == nil || brk.Sequence != 3 || !strings.Contains(brk.Reason, "sequence gap") {
+		t.Errorf("Expected a sequence gap, got %+v", brk)
+	}
+}
+
+func TestCheckpointSignature(t *testing.T) {
+	seed := make([]byte, ed25519.SeedSize)
+	for i := range seed {
+		seed[i] = byte(i)
+	}
+	signer, err := ParseSigningKey(base64.StdEncoding.EncodeToString(seed))
+	if err != nil {
+		t.Fatalf("ParseSigningKey() error = %v", err)
+	}
+
+	checkpoint := &models.AuditCheckpoint{Sequence: 42, Hash: strings.Repeat("ab", 32), CreatedAt: time.Now()}
+	signer.Sign(checkpoint)
+	if checkpoint.KeyID != signer.KeyID() || len(checkpoint.KeyID) != 16 {
+		t.Errorf("Unexpected key ID %q", checkpoint.KeyID)
+	}
+	if err := VerifyCheckpoint(checkpoint); err != nil {
+		t.Errorf("VerifyCheckpoint() error = %v", err)
+	}
+
+	// Stored with millisecond precision
+	stored := *checkpoint
+	stored.CreatedAt = stored.CreatedAt.Truncate(time.Millisecond)
+	if err := VerifyCheckpoint(&stored); err != nil {
+		t.Errorf("Expected the stored checkpoint to verify, got %v", err)
+	}
+
+	stored.Sequence = 41
+	if err := VerifyCheckpoint(&stored); err == nil {
+		t.Error("Expected a modified checkpoint to fail verification")
+	}
+
+	for _, value := range []string{"not base64!", base64.StdEncoding.EncodeToString([]byte("short"))} {
+		if _, err := ParseSigningKey(value); err == nil || !strings.Contains(err.Error(), "invalid checkpoint signing key") {
+			t.Errorf("Expected %q to be rejected, got %v", value, err)
+		}
+	}
+}
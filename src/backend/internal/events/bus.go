@@ -0,0 +1,136 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Broker moves events between the buses of all replicas
+type Broker interface {
+	// Publish hands an event to every replica's subscriber
+	Publish(ctx context.Context, event Event) error
+	// Subscribe calls handler for each published event until ctx is done
+	Subscribe(ctx context.Context, handler func(Event)) error
+}
+
+// Bus fans events received from the broker out to local subscribers. A nil
+// Bus discards everything published to it.
+type Bus struct {
+	broker Broker
+
+	mu          sync.Mutex
+	subscribers map[*Subscription]struct{}
+}
+
+// Subscription receives the events matching its filter on C. C is closed when
+// the subscription is closed, or when the subscriber falls too far behind; a
+// closed subscriber should reconnect and refetch the state it shows.
+type Subscription struct {
+	C <-chan Event
+
+	bus    *Bus
+	filter Filter
+	ch     chan Event
+	closed bool
+}
+
+// NewBus creates a bus on top of a broker
+func NewBus(broker Broker) *Bus {
+	return &Bus{
+		broker:      broker,
+		subscribers: make(map[*Subscription]struct{}),
+	}
+}
+
+// Publish sends an event of the given type with data encoded as JSON
+func (b *Bus) Publish(ctx context.Context, eventType Type, scope Scope, data interface{}) error {
+	if b == nil {
+		return nil
+	}
+
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s event: %w", eventType, err)
+	}
+
+	event := Event{
+		ID:          primitive.NewObjectID().Hex(),
+		Type:        eventType,
+		Timestamp:   time.Now(),
+		RecipientID: scope.RecipientID,
+		CustomerID:  scope.CustomerID,
+		ProductID:   scope.ProductID,
+		Data:        payload,
+	}
+	if err := b.broker.Publish(ctx, event); err != nil {
+		return fmt.Errorf("failed to publish %s event: %w", eventType, err)
+	}
+	return nil
+}
+
+// Subscribe registers a local subscriber buffering up to buffer events
+func (b *Bus) Subscribe(filter Filter, buffer int) *Subscription {
+	if buffer < 1 {
+		buffer = 1
+	}
+	ch := make(chan Event, buffer)
+	sub := &Subscription{C: ch, bus: b, filter: filter, ch: ch}
+
+	b.mu.Lock()
+	b.subscribers[sub] = struct{}{}
+	b.mu.Unlock()
+	return sub
+}
+
+// Close unregisters the subscription and closes its channel
+func (s *Subscription) Close() {
+	s.bus.mu.Lock()
+	defer s.bus.mu.Unlock()
+	s.bus.remove(s)
+}
+
+// Run delivers events from the broker to local subscribers until ctx is
+// done, then closes every subscription so streams end on shutdown
+func (b *Bus) Run(ctx context.Context) error {
+	err := b.broker.Subscribe(ctx, b.dispatch)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for sub := range b.subscribers {
+		b.remove(sub)
+	}
+	return err
+}
+
+// dispatch hands an event to every matching subscriber without blocking;
+// subscribers whose buffer is full are dropped
+func (b *Bus) dispatch(event Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for sub := range b.subscribers {
+		if !sub.filter.Matches(event) {
+			continue
+		}
+		select {
+		case sub.ch <- event:
+		default:
+			b.remove(sub)
+		}
+	}
+}
+
+// remove unregisters a subscriber; the caller holds b.mu
+func (b *Bus) remove(sub *Subscription) {
+	if sub.closed {
+		return
+	}
+	sub.closed = true
+	delete(b.subscribers, sub)
+	close(sub.ch)
+}
@@ -0,0 +1,135 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+// runBus starts a bus on an in-memory broker and waits until it is subscribed
+func runBus(t *testing.T) (*Bus, func()) {
+	broker := NewMemoryBroker()
+	bus := NewBus(broker)
+	ctx, cancel := context.WithCancel(context.Background())
+	go bus.Run(ctx)
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		broker.mu.RLock()
+		ready := len(broker.handlers) > 0
+		broker.mu.RUnlock()
+		if ready {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Bus did not subscribe to the broker")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	return bus, cancel
+}
+
+func receive(t *testing.T, sub *Subscription) (Event, bool) {
+	select {
+	case event, ok := <-sub.C:
+		return event, ok
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for an event")
+		return Event{}, false
+	}
+}
+
+func TestFilterMatches(t *testing.T) {
+	rollout := Event{Type: TypeRolloutStatusChanged, CustomerID: "customer-1", ProductID: "product-1"}
+	release := Event{Type: TypeVersionStateChanged, ProductID: "product-1"}
+	notification := Event{Type: TypeNotificationCreated, RecipientID: "user-1", CustomerID: "customer-1"}
+
+	tests := []struct {
+		name   string
+		filter Filter
+		event  Event
+		want   bool
+	}{
+		{"empty filter", Filter{}, rollout, true},
+		{"type listed", Filter{Types: []Type{TypeRolloutProgress, TypeRolloutStatusChanged}}, rollout, true},
+		{"type not listed", Filter{Types: []Type{TypeNotificationCreated}}, rollout, false},
+		{"same customer", Filter{CustomerID: "customer-1"}, rollout, true},
+		{"other customer", Filter{CustomerID: "customer-2"}, rollout, false},
+		{"customer filter passes product-wide event", Filter{CustomerID: "customer-2"}, release, true},
+		{"other product", Filter{ProductID: "product-2"}, release, false},
+		{"own notification", Filter{RecipientID: "user-1"}, notification, true},
+		{"other recipient", Filter{RecipientID: "user-2"}, notification, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.Matches(tt.event); got != tt.want {
+				t.Errorf("Matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBus_PublishSubscribe(t *testing.T) {
+	bus, stop := runBus(t)
+	defer stop()
+
+	mine := bus.Subscribe(Filter{RecipientID: "user-1"}, 10)
+	defer mine.Close()
+	products := bus.Subscribe(Filter{ProductID: "product-2"}, 10)
+	defer products.Close()
+
+	ctx := context.Background()
+	if err := bus.Publish(ctx, TypeNotificationCreated, Scope{RecipientID: "user-2", ProductID: "product-1"}, map[string]string{"title": "other"}); err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+	if err := bus.Publish(ctx, TypeNotificationCreated, Scope{RecipientID: "user-1", ProductID: "product-2"}, map[string]string{"title": "mine"}); err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+
+	event, _ := receive(t, mine)
+	var data map[string]string
+	if err := json.Unmarshal(event.Data, &data); err != nil || data["title"] != "mine" {
+		t.Errorf("Expected only the user's notification, got %s", event.Data)
+	}
+	if event.ID == "" || event.Timestamp.IsZero() {
+		t.Errorf("Expected ID and timestamp to be set, got %+v", event)
+	}
+	if event, _ := receive(t, products); event.RecipientID != "user-1" {
+		t.Errorf("Expected the product-2 event, got %+v", event)
+	}
+
+	mine.Close()
+	if _, ok := <-mine.C; ok {
+		t.Error("Expected a closed subscription channel")
+	}
+	mine.Close() // Closing twice is safe
+}
+
+func TestBus_DropsLaggingSubscriber(t *testing.T) {
+	bus, stop := runBus(t)
+	defer stop()
+
+	sub := bus.Subscribe(Filter{}, 1)
+	defer sub.Close()
+
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		bus.Publish(ctx, TypeRolloutProgress, Scope{}, i)
+	}
+
+	if _, ok := receive(t, sub); !ok {
+		t.Fatal("Expected the buffered event")
+	}
+	if _, ok := receive(t, sub); ok {
+		t.Error("Expected the lagging subscription to be closed")
+	}
+}
+
+func TestBus_NilDiscards(t *testing.T) {
+	var bus *Bus
+	if err := bus.Publish(context.Background(), TypeRolloutProgress, Scope{}, nil); err != nil {
+		t.Errorf("Expected a nil bus to discard events, got %v", err)
+	}
+}
@@ -0,0 +1,71 @@
+// Package events carries live update events from the services to streaming
+// clients. Services publish to a Bus; a Broker moves the events between
+// replicas so every subscriber sees them whichever replica published them.
+package events
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Type identifies the kind of a stream event
+type Type string
+
+const (
+	TypeRolloutStatusChanged Type = "rollout.status_changed"
+	TypeRolloutProgress      Type = "rollout.progress"
+	TypeNotificationCreated  Type = "notification.created"
+	TypeVersionStateChanged  Type = "version.state_changed"
+)
+
+// Event is one update pushed to streaming clients. The scope fields are used
+// for filtering; an event leaves those it has no value for empty.
+type Event struct {
+	ID          string          `bson:"event_id" json:"id"`
+	Type        Type            `bson:"type" json:"type"`
+	Timestamp   time.Time       `bson:"timestamp" json:"timestamp"`
+	RecipientID string          `bson:"recipient_id,omitempty" json:"recipient_id,omitempty"`
+	CustomerID  string          `bson:"customer_id,omitempty" json:"customer_id,omitempty"`
+	ProductID   string          `bson:"product_id,omitempty" json:"product_id,omitempty"`
+	Data        json.RawMessage `bson:"data" json:"data"`
+}
+
+// Scope names who an event concerns
+type Scope struct {
+	RecipientID string
+	CustomerID  string
+	ProductID   string
+}
+
+// Filter selects the events a subscriber receives. Empty fields match
+// everything, and a scope field only excludes events that carry a different
+// value: a customer filter still passes product-wide version events.
+type Filter struct {
+	Types       []Type
+	RecipientID string
+	CustomerID  string
+	ProductID   string
+}
+
+// Matches reports whether an event passes the filter
+func (f Filter) Matches(event Event) bool {
+	if len(f.Types) > 0 {
+		found := false
+		for _, t := range f.Types {
+			if t == event.Type {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return scopeMatches(f.RecipientID, event.RecipientID) &&
+		scopeMatches(f.CustomerID, event.CustomerID) &&
+		scopeMatches(f.ProductID, event.ProductID)
+}
+
+func scopeMatches(want, got string) bool {
+	return want == "" || got == "" || want == got
+}
@@ -0,0 +1,48 @@
+package events
+
+import (
+	"context"
+	"sync"
+)
+
+// MemoryBroker delivers events within one process. It suits single replica
+// deployments and tests.
+type MemoryBroker struct {
+	mu       sync.RWMutex
+	handlers map[int]func(Event)
+	nextID   int
+}
+
+// NewMemoryBroker creates an in-process broker
+func NewMemoryBroker() *MemoryBroker {
+	return &MemoryBroker{
+		handlers: make(map[int]func(Event)),
+	}
+}
+
+// Publish calls every subscribed handler with the event
+func (b *MemoryBroker) Publish(ctx context.Context, event Event) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for _, handler := range b.handlers {
+		handler(event)
+	}
+	return nil
+}
+
+// Subscribe registers handler until ctx is done
+func (b *MemoryBroker) Subscribe(ctx context.Context, handler func(Event)) error {
+	b.mu.Lock()
+	id := b.nextID
+	b.nextID++
+	b.handlers[id] = handler
+	b.mu.Unlock()
+
+	<-ctx.Done()
+
+	b.mu.Lock()
+	delete(b.handlers, id)
+	b.mu.Unlock()
+	return ctx.Err()
+}
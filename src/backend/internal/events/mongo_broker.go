@@ -0,0 +1,114 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// mongoBrokerRetryDelay is how long a subscriber waits before reopening a
+// dead tailable cursor
+const mongoBrokerRetryDelay = time.Second
+
+// MongoBroker shares events between replicas through a capped collection
+// that every replica tails. The collection must be created capped (see the
+// database setup scripts); delivery is best effort and old events age out.
+type MongoBroker struct {
+	collection *mongo.Collection
+}
+
+// mongoEvent is the stored form of an event
+type mongoEvent struct {
+	ID    primitive.ObjectID `bson:"_id,omitempty"`
+	Event `bson:",inline"`
+}
+
+// NewMongoBroker creates a broker on a capped collection
+func NewMongoBroker(collection *mongo.Collection) *MongoBroker {
+	return &MongoBroker{
+		collection: collection,
+	}
+}
+
+// Publish appends the event to the collection
+func (b *MongoBroker) Publish(ctx context.Context, event Event) error {
+	if _, err := b.collection.InsertOne(ctx, mongoEvent{Event: event}); err != nil {
+		return fmt.Errorf("failed to store stream event: %w", err)
+	}
+	return nil
+}
+
+// Subscribe tails the collection from its newest event, calling handler for
+// each event appended afterwards until ctx is done
+func (b *MongoBroker) Subscribe(ctx context.Context, handler func(Event)) error {
+	last, err := b.newestID(ctx)
+	for err != nil {
+		log.Printf("Stream broker: %v", err)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(mongoBrokerRetryDelay):
+		}
+		last, err = b.newestID(ctx)
+	}
+
+	for {
+		filter := bson.M{}
+		if !last.IsZero() {
+			filter["_id"] = bson.M{"$gt": last}
+		}
+		opts := options.Find().
+			SetCursorType(options.TailableAwait).
+			SetMaxAwaitTime(mongoBrokerRetryDelay)
+
+		cursor, err := b.collection.Find(ctx, filter, opts)
+		if err == nil {
+			for cursor.Next(ctx) {
+				var stored mongoEvent
+				if err := cursor.Decode(&stored); err != nil {
+					log.Printf("Stream broker: skipping undecodable event: %v", err)
+					continue
+				}
+				last = stored.ID
+				handler(stored.Event)
+			}
+			err = cursor.Err()
+			cursor.Close(ctx)
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			log.Printf("Stream broker: %v", err)
+		}
+
+		// The cursor dies when the collection is empty or the reader fell
+		// behind the capped size; reopen it after a pause
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(mongoBrokerRetryDelay):
+		}
+	}
+}
+
+// newestID returns the ID of the most recent event, or a zero ID if there is none
+func (b *MongoBroker) newestID(ctx context.Context) (primitive.ObjectID, error) {
+	var stored mongoEvent
+	opts := options.FindOne().SetSort(bson.D{{Key: "$natural", Value: -1}})
+	err := b.collection.FindOne(ctx, bson.M{}, opts).Decode(&stored)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return primitive.NilObjectID, nil
+	}
+	if err != nil {
+		return primitive.NilObjectID, fmt.Errorf("failed to read stream events: %w", err)
+	}
+	return stored.ID, nil
+}
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"time"
 )
@@ -16,6 +17,14 @@ type Product struct {
 	CreatedAt   time.Time          `bson:"created_at" json:"created_at"`
 	UpdatedAt   time.Time          `bson:"updated_at" json:"updated_at"`
 	IsActive    bool               `bson:"is_active" json:"is_active"`
+	RolloutTimeouts *RolloutTimeouts `bson:"rollout_timeouts" json:"rollout_timeouts,omitempty"` // Nil uses the defaults
+}
+
+// RolloutTimeouts bounds how long a rollout of the product may stay in a phase
+// before the sweeper fails it. Zero uses the default for the phase.
+type RolloutTimeouts struct {
+	PendingMinutes    int `bson:"pending_minutes,omitempty" json:"pending_minutes,omitempty"`         // From creation, or from the maintenance window for scheduled rollouts
+	InProgressMinutes int `bson:"in_progress_minutes,omitempty" json:"in_progress_minutes,omitempty"` // From the agent starting the update
 }
 
 type ProductType string
@@ -52,6 +61,50 @@ type Version struct {
 	CreatedBy  string     `bson:"created_by" json:"created_by"`
 	CreatedAt  time.Time  `bson:"created_at" json:"created_at"`
 	UpdatedAt  time.Time  `bson:"updated_at" json:"updated_at"`
+
+	// Rollout health; set when a health gate halts rollouts of this version
+	RolloutHalt *RolloutHalt `bson:"rollout_halt,omitempty" json:"rollout_halt,omitempty"`
+}
+
+// RolloutHalt records a health gate tripping for a version and how operators resolved it
+
+type RolloutHalt struct {
+	Status         RolloutHaltStatus   `bson:"status" json:"status"`
+	Scope          RolloutHaltScope    `bson:"scope" json:"scope"`
+	CampaignID     *primitive.ObjectID `bson:"campaign_id,omitempty" json:"campaign_id,omitempty"`
+	Reason         string              `bson:"reason" json:"reason"`
+	Gate           RolloutHealthGate   `bson:"gate" json:"gate"`
+	Failures       int                 `bson:"failures" json:"failures"`         // Failed rollouts in the window
+	Finished       int                 `bson:"finished" json:"finished"`         // Completed and failed rollouts in the window
+	FailureRate    float64             `bson:"failure_rate" json:"failure_rate"` // Percent
+	HaltedAt       time.Time           `bson:"halted_at" json:"halted_at"`
+	ResolvedBy     string              `bson:"resolved_by,omitempty" json:"resolved_by,omitempty"`
+	ResolvedAt     *time.Time          `bson:"resolved_at,omitempty" json:"resolved_at,omitempty"`
+	ResolutionNote string              `bson:"resolution_note,omitempty" json:"resolution_note,omitempty"`
+}
+
+type RolloutHaltStatus string
+
+const (
+	RolloutHaltStatusHalted   RolloutHaltStatus = "halted"
+	RolloutHaltStatusResumed  RolloutHaltStatus = "resumed"
+	RolloutHaltStatusRecalled RolloutHaltStatus = "recalled"
+)
+
+type RolloutHaltScope string
+
+const (
+	RolloutHaltScopeCampaign RolloutHaltScope = "campaign"
+	RolloutHaltScopeVersion  RolloutHaltScope = "version"
+)
+
+// RolloutHealthGate defines when failing rollouts halt a version. A threshold
+// of zero disables that check.
+type RolloutHealthGate struct {
+	WindowMinutes  int     `bson:"window_minutes" json:"window_minutes"`
+	MaxFailureRate float64 `bson:"max_failure_rate" json:"max_failure_rate"` // Percent of finished rollouts in the window
+	MaxFailures    int     `bson:"max_failures" json:"max_failures"`         // Failed rollouts in the window
+	MinSamples     int     `bson:"min_samples" json:"min_samples"`           // Finished rollouts needed before the rate applies
 }
 
 type ReleaseType string
@@ -74,6 +127,12 @@ const (
 	VersionStateEOL           VersionState = "eol"
 )
 
+// VersionStateChange is the stream payload of a version state transition
+type VersionStateChange struct {
+	Version       *Version     `json:"version"`
+	PreviousState VersionState `json:"previous_state"`
+}
+
 // ReleaseNotes represents release notes for a version
 type ReleaseNotes struct {
 	VersionInfo         VersionInfoSection   `bson:"version_info" json:"version_info"`
@@ -127,6 +186,7 @@ type PackageInfo struct {
 	DigitalSignature string             `bson:"digital_signature,omitempty" json:"digital_signature,omitempty"`
 	OS               string             `bson:"os,omitempty" json:"os,omitempty"`
 	Architecture     string             `bson:"architecture,omitempty" json:"architecture,omitempty"`
+	RestoresVersion  string             `bson:"restores_version,omitempty" json:"restores_version,omitempty"` // Rollback packages: version restored; empty restores any
 	UploadedAt       time.Time          `bson:"uploaded_at" json:"uploaded_at"`
 	UploadedBy       string             `bson:"uploaded_by" json:"uploaded_by"`
 }
@@ -185,6 +245,64 @@ const (
 	UpgradePathTypeBlocked   UpgradePathType = "blocked"
 )
 
+// UpgradePathRule represents a pattern-based upgrade path rule.
+// FromVersion, ToVersion and IntermediateVersions accept version patterns
+// such as "3.x", ">5.2" or "5.1.0 - 5.1.4". ToVersion may also be
+// UpgradeRuleTargetLatestPatch.
+type UpgradePathRule struct {
+	ID                   primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	ProductID            string             `bson:"product_id" json:"product_id" validate:"required"`
+	Name                 string             `bson:"name" json:"name" validate:"required,max=200"`
+	Description          string             `bson:"description,omitempty" json:"description,omitempty" validate:"max=1000"`
+	FromVersion          string             `bson:"from_version" json:"from_version"`
+	ToVersion            string             `bson:"to_version" json:"to_version"`
+	PathType             UpgradePathType    `bson:"path_type" json:"path_type" validate:"required"`
+	IntermediateVersions []string           `bson:"intermediate_versions,omitempty" json:"intermediate_versions,omitempty"`
+	BlockReason          string             `bson:"block_reason,omitempty" json:"block_reason,omitempty"`
+	Priority             int                `bson:"priority" json:"priority"` // Lower values are evaluated first
+	IsActive             bool               `bson:"is_active" json:"is_active"`
+	CreatedBy            string             `bson:"created_by" json:"created_by"`
+	CreatedAt            time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt            time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// UpgradeRuleTargetLatestPatch matches the latest released patch in the same minor as the from version
+const UpgradeRuleTargetLatestPatch = "latest_patch"
+
+// UpgradePathDecisionSource identifies what decided a resolved upgrade route
+type UpgradePathDecisionSource string
+
+const (
+	UpgradePathDecisionExplicit UpgradePathDecisionSource = "explicit_path"
+	UpgradePathDecisionRule     UpgradePathDecisionSource = "rule"
+	UpgradePathDecisionDefault  UpgradePathDecisionSource = "default"
+)
+
+// UpgradePathResolution represents the effective upgrade route between two versions
+type UpgradePathResolution struct {
+	ProductID            string                      `json:"product_id"`
+	FromVersion          string                      `json:"from_version"`
+	ToVersion            string                      `json:"to_version"`
+	PathType             UpgradePathType             `json:"path_type"`
+	IntermediateVersions []string                    `json:"intermediate_versions,omitempty"`
+	IsBlocked            bool                        `json:"is_blocked"`
+	BlockReason          string                      `json:"block_reason,omitempty"`
+	DecidedBy            UpgradePathDecisionSource   `json:"decided_by"`
+	UpgradePathID        string                      `json:"upgrade_path_id,omitempty"`
+	RuleID               string                      `json:"rule_id,omitempty"`
+	RuleName             string                      `json:"rule_name,omitempty"`
+	Evaluations          []UpgradePathRuleEvaluation `json:"evaluations,omitempty"`
+}
+
+// UpgradePathRuleEvaluation explains how a single rule was evaluated for a route
+type UpgradePathRuleEvaluation struct {
+	RuleID   string `json:"rule_id"`
+	RuleName string `json:"rule_name"`
+	Priority int    `json:"priority"`
+	Matched  bool   `json:"matched"`
+	Reason   string `json:"reason"`
+}
+
 // Notification represents a notification
 type Notification struct {
 	ID          primitive.ObjectID   `bson:"_id,omitempty" json:"id"`
@@ -195,21 +313,168 @@ type Notification struct {
 	CustomerID  string               `bson:"customer_id,omitempty" json:"customer_id,omitempty"`
 	TenantID    string               `bson:"tenant_id,omitempty" json:"tenant_id,omitempty"`
 	DeploymentID string             `bson:"deployment_id,omitempty" json:"deployment_id,omitempty"`
+	DeploymentIDs []string          `bson:"deployment_ids,omitempty" json:"deployment_ids,omitempty"` // Deployments the notification is about, for templates
+	Subject     string               `bson:"subject,omitempty" json:"subject,omitempty"` // What an automatic notification is about, e.g. "license:<id>"; deduplicates it
 	Title       string               `bson:"title" json:"title" validate:"required"`
 	Message     string               `bson:"message" json:"message" validate:"required"`
 	Priority    NotificationPriority `bson:"priority" json:"priority"`
 	IsRead      bool                 `bson:"is_read" json:"is_read"`
-	ReadAt      *time.Time           `bson:"read_at,omitempty" json:"read_at,omitempty"`
+	ReadAt      *time.Time           `bson:"read_at,omitempty" json:"read_at,omitempty"` // Read notifications expire by a TTL index on this
+	IsArchived  bool                 `bson:"is_archived" json:"is_archived"`
+	ArchivedAt  *time.Time           `bson:"archived_at,omitempty" json:"archived_at,omitempty"`
+	Deliveries  []NotificationDelivery `bson:"deliveries,omitempty" json:"deliveries,omitempty"`
+	DeliveryDueAt *time.Time         `bson:"delivery_due_at,omitempty" json:"-"` // Set while a channel delivery is pending
+	DigestDueAt *time.Time           `bson:"digest_due_at,omitempty" json:"-"`   // Set while a delivery is held for the recipient's digest
+	CreatedAt   time.Time            `bson:"created_at" json:"created_at"`
+}
+
+// NotificationDedupKey records that a recipient got an automatic notification
+// of a type about a subject. Keys outlive the notifications, which recipients
+// can delete and read retention purges.
+type NotificationDedupKey struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	RecipientID string             `bson:"recipient_id" json:"recipient_id"`
+	Type        NotificationType   `bson:"type" json:"type"`
+	Subject     string             `bson:"subject" json:"subject"`
+	CreatedAt   time.Time          `bson:"created_at" json:"created_at"` // When the recipient was last notified
+}
+
+// NotificationBulkAction is what a bulk request does to notifications
+type NotificationBulkAction string
+
+const (
+	NotificationBulkRead      NotificationBulkAction = "read"
+	NotificationBulkUnread    NotificationBulkAction = "unread"
+	NotificationBulkArchive   NotificationBulkAction = "archive"
+	NotificationBulkUnarchive NotificationBulkAction = "unarchive"
+	NotificationBulkDelete    NotificationBulkAction = "delete"
+)
+
+// BulkNotificationRequest applies an action to some of a recipient's
+// notifications
+type BulkNotificationRequest struct {
+	RecipientID     string                 `json:"recipient_id"`
+	NotificationIDs []string               `json:"notification_ids"`
+	Action          NotificationBulkAction `json:"action"`
+}
+
+// NotificationDelivery is the delivery of a notification over one channel
+type NotificationDelivery struct {
+	Channel       NotificationChannel        `bson:"channel" json:"channel"`
+	Status        NotificationDeliveryStatus `bson:"status" json:"status"`
+	Target        string                     `bson:"target,omitempty" json:"target,omitempty"`     // Address the channel sends to, e.g. an email address
+	RouteID       *primitive.ObjectID        `bson:"route_id,omitempty" json:"route_id,omitempty"` // Routing rule the delivery came from; its target is not copied
+	Attempts      int                        `bson:"attempts" json:"attempts"`
+	NextAttemptAt *time.Time                 `bson:"next_attempt_at,omitempty" json:"next_attempt_at,omitempty"` // While pending
+	LastError     string                     `bson:"last_error,omitempty" json:"last_error,omitempty"`           // Failure or skip reason
+	SentAt        *time.Time                 `bson:"sent_at,omitempty" json:"sent_at,omitempty"`
+}
+
+type NotificationChannel string
+
+const (
+	NotificationChannelInApp     NotificationChannel = "in_app"
+	NotificationChannelEmail     NotificationChannel = "email"
+	NotificationChannelSlack     NotificationChannel = "slack"     // Slack-style incoming webhook
+	NotificationChannelTeams     NotificationChannel = "teams"     // Teams-style incoming webhook
+	NotificationChannelPagerDuty NotificationChannel = "pagerduty" // PagerDuty-style incident events, critical only
+)
+
+// NotificationTemplate is a stored template for one notification type,
+// channel and locale. Subject and Text use text/template and HTML uses
+// html/template; in-app notifications take their title from Subject.
+type NotificationTemplate struct {
+	ID        primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	Type      NotificationType    `bson:"type" json:"type"`
+	Channel   NotificationChannel `bson:"channel" json:"channel"`
+	Locale    string              `bson:"locale" json:"locale"` // Lowercase tag, e.g. "en" or "de-ch"
+	Subject   string              `bson:"subject" json:"subject"`
+	Text      string              `bson:"text" json:"text"`
+	HTML      string              `bson:"html,omitempty" json:"html,omitempty"` // Email only
+	CreatedBy string              `bson:"created_by" json:"created_by"`
+	UpdatedBy string              `bson:"updated_by,omitempty" json:"updated_by,omitempty"`
+	CreatedAt time.Time           `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time           `bson:"updated_at" json:"updated_at"`
+}
+
+// NotificationRoute sends notifications to a chat or incident channel. A
+// route without a customer applies to every notification.
+type NotificationRoute struct {
+	ID          primitive.ObjectID   `bson:"_id,omitempty" json:"id"`
+	Name        string               `bson:"name" json:"name"`
+	CustomerID  string               `bson:"customer_id,omitempty" json:"customer_id,omitempty"`
+	Channel     NotificationChannel  `bson:"channel" json:"channel"`
+	Target      string               `bson:"target" json:"target"`                   // Incoming webhook URL, or incident routing key
+	MinPriority NotificationPriority `bson:"min_priority" json:"min_priority"`       // Lowest priority routed
+	Types       []NotificationType   `bson:"types,omitempty" json:"types,omitempty"` // Empty routes all types
+	IsActive    bool                 `bson:"is_active" json:"is_active"`
+	CreatedBy   string               `bson:"created_by" json:"created_by"`
 	CreatedAt   time.Time            `bson:"created_at" json:"created_at"`
+	UpdatedAt   time.Time            `bson:"updated_at" json:"updated_at"`
 }
 
+// InternalRecipientType is what an internal notification subscription
+// notifies: one user or every member of a group
+type InternalRecipientType string
+
+const (
+	InternalRecipientUser  InternalRecipientType = "user"
+	InternalRecipientGroup InternalRecipientType = "group"
+)
+
+// InternalGroup is a named group of internal users, such as release managers
+// or approvers. Members are user IDs as sent in X-User-ID.
+type InternalGroup struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	GroupID     string             `bson:"group_id" json:"group_id"`
+	Name        string             `bson:"name" json:"name"`
+	Description string             `bson:"description,omitempty" json:"description,omitempty"`
+	Members     []string           `bson:"members" json:"members"`
+	CreatedBy   string             `bson:"created_by" json:"created_by"`
+	CreatedAt   time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt   time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// InternalNotificationSubscription subscribes an internal user or group to
+// internal notifications of some types, about one product or all of them
+type InternalNotificationSubscription struct {
+	ID            primitive.ObjectID    `bson:"_id,omitempty" json:"id"`
+	RecipientType InternalRecipientType `bson:"recipient_type" json:"recipient_type"`
+	RecipientID   string                `bson:"recipient_id" json:"recipient_id"` // User ID, or group ID of a group
+	Types         []NotificationType    `bson:"types" json:"types"`
+	ProductID     string                `bson:"product_id,omitempty" json:"product_id,omitempty"` // Empty subscribes to every product
+	IsActive      bool                  `bson:"is_active" json:"is_active"`
+	CreatedBy     string                `bson:"created_by" json:"created_by"`
+	CreatedAt     time.Time             `bson:"created_at" json:"created_at"`
+	UpdatedAt     time.Time             `bson:"updated_at" json:"updated_at"`
+}
+
+type NotificationDeliveryStatus string
+
+const (
+	NotificationDeliveryPending NotificationDeliveryStatus = "pending"
+	NotificationDeliverySent    NotificationDeliveryStatus = "sent"
+	NotificationDeliveryFailed  NotificationDeliveryStatus = "failed"  // Gave up after the maximum attempts
+	NotificationDeliverySkipped NotificationDeliveryStatus = "skipped" // Disabled by preferences or not configured
+	NotificationDeliveryDigest  NotificationDeliveryStatus = "digest"  // Held for the recipient's next digest
+)
+
 type NotificationType string
 
 const (
-	NotificationTypeNewVersion      NotificationType = "new_version"
-	NotificationTypeSecurityRelease NotificationType = "security_release"
-	NotificationTypeEOLWarning      NotificationType = "eol_warning"
-	NotificationTypeUpdateAvailable NotificationType = "update_available"
+	NotificationTypeNewVersion           NotificationType = "new_version"
+	NotificationTypeSecurityRelease      NotificationType = "security_release"
+	NotificationTypeEOLWarning           NotificationType = "eol_warning"
+	NotificationTypeUpdateAvailable      NotificationType = "update_available"
+	NotificationTypeRolloutHalted        NotificationType = "rollout_halted" // Internal: rollouts of a version were halted
+	NotificationTypeLicenseExpired       NotificationType = "license_expired"
+	NotificationTypeLicenseExpiring      NotificationType = "license_expiring"
+	NotificationTypeSubscriptionExpiring NotificationType = "subscription_expiring"
+	NotificationTypeSeatOverallocation   NotificationType = "seat_overallocation"
+	NotificationTypePendingReview        NotificationType = "pending_review"   // Internal: a version was submitted for review
+	NotificationTypeVersionApproved      NotificationType = "version_approved" // Internal: a version was approved
+	NotificationTypeRolloutFailed        NotificationType = "rollout_failed"   // Internal: a rollout failed
+	NotificationTypeDigest               NotificationType = "digest" // Digest emails; not stored as a notification
 )
 
 type NotificationPriority string
@@ -228,30 +493,92 @@ type UpdateDetection struct {
 	ProductID        string             `bson:"product_id" json:"product_id" validate:"required"`
 	CurrentVersion   string             `bson:"current_version" json:"current_version" validate:"required"`
 	AvailableVersion string             `bson:"available_version" json:"available_version" validate:"required"`
+	OS               string             `bson:"os,omitempty" json:"os,omitempty"`
+	Architecture     string             `bson:"architecture,omitempty" json:"architecture,omitempty"`
+	Channel          ReleaseChannel     `bson:"channel,omitempty" json:"channel,omitempty"`
 	DetectedAt       time.Time          `bson:"detected_at" json:"detected_at"`
 	LastCheckedAt    time.Time          `bson:"last_checked_at" json:"last_checked_at"`
 }
 
-// UpdateRollout represents an update rollout
+// ReleaseChannel controls which released versions an endpoint is offered
+type ReleaseChannel string
+
+const (
+	ReleaseChannelStable ReleaseChannel = "stable" // Released versions without a pre-release tag
+	ReleaseChannelBeta   ReleaseChannel = "beta"   // Released versions including pre-release tags
+)
+
+// CheckInAction tells an endpoint agent what to do after a check-in
+type CheckInAction string
+
+const (
+	CheckInActionNone     CheckInAction = "none"     // Up to date or nothing installable
+	CheckInActionUpdate   CheckInAction = "update"   // Install TargetVersion using Package
+	CheckInActionBlocked  CheckInAction = "blocked"  // Newer versions exist but every route is blocked
+	CheckInActionRollback CheckInAction = "rollback" // Restore TargetVersion using Package
+)
+
+// CheckInResponse is the server's answer to an endpoint check-in
+type CheckInResponse struct {
+	EndpointID         string                     `json:"endpoint_id"`
+	ProductID          string                     `json:"product_id"`
+	InstalledVersion   string                     `json:"installed_version"`
+	Channel            ReleaseChannel             `json:"channel"`
+	Action             CheckInAction              `json:"action"`
+	TargetVersion      string                     `json:"target_version,omitempty"` // Next version to install
+	LatestVersion      string                     `json:"latest_version,omitempty"` // Final version the endpoint is heading to
+	UpgradePath        []string                   `json:"upgrade_path,omitempty"`   // Remaining hops including TargetVersion
+	Package            *PackageInfo               `json:"package,omitempty"`
+	Rollout            *CheckInRolloutInstruction `json:"rollout,omitempty"`
+	Reason             string                     `json:"reason,omitempty"`
+	NextCheckInSeconds int                        `json:"next_check_in_seconds"`
+	CheckedAt          time.Time                  `json:"checked_at"`
+}
+
+// CheckInRolloutInstruction describes the active rollout an endpoint should report against
+type CheckInRolloutInstruction struct {
+	RolloutID         string        `json:"rollout_id"`
+	Status            RolloutStatus `json:"status"`
+	ScheduledFor      *time.Time    `json:"scheduled_for,omitempty"` // Scheduled rollouts: when the maintenance window opens
+	ToVersion         string        `json:"to_version"`
+	RollbackOf        string        `json:"rollback_of,omitempty"` // Rollout being reverted, for rollback rollouts
+	ReportStatusURL   string        `json:"report_status_url"`
+	ReportProgressURL string        `json:"report_progress_url"`
+	ReportEventsURL   string        `json:"report_events_url"`
+}
+
+// UpdateRollout represents an update rollout. A rollback rollout reverts an
+// earlier rollout: its FromVersion is the original's ToVersion and vice versa,
+// and the two are linked through RollbackOf and RollbackRolloutID.
 type UpdateRollout struct {
-	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	EndpointID   string             `bson:"endpoint_id" json:"endpoint_id" validate:"required"`
-	ProductID    string             `bson:"product_id" json:"product_id" validate:"required"`
-	FromVersion  string             `bson:"from_version" json:"from_version" validate:"required"`
-	ToVersion    string             `bson:"to_version" json:"to_version" validate:"required"`
-	Status       RolloutStatus      `bson:"status" json:"status" validate:"required"`
-	InitiatedBy  string             `bson:"initiated_by" json:"initiated_by" validate:"required"`
-	InitiatedAt  time.Time          `bson:"initiated_at" json:"initiated_at"`
-	StartedAt    *time.Time         `bson:"started_at,omitempty" json:"started_at,omitempty"`
-	CompletedAt  *time.Time         `bson:"completed_at,omitempty" json:"completed_at,omitempty"`
-	FailedAt     *time.Time         `bson:"failed_at,omitempty" json:"failed_at,omitempty"`
-	ErrorMessage string             `bson:"error_message,omitempty" json:"error_message,omitempty"`
-	Progress     int                `bson:"progress" json:"progress"` // 0-100
+	ID                primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	EndpointID        string              `bson:"endpoint_id" json:"endpoint_id" validate:"required"`
+	ProductID         string              `bson:"product_id" json:"product_id" validate:"required"`
+	FromVersion       string              `bson:"from_version" json:"from_version" validate:"required"`
+	ToVersion         string              `bson:"to_version" json:"to_version" validate:"required"`
+	Status            RolloutStatus       `bson:"status" json:"status" validate:"required"`
+	InitiatedBy       string              `bson:"initiated_by" json:"initiated_by" validate:"required"`
+	InitiatedAt       time.Time           `bson:"initiated_at" json:"initiated_at"`
+	StartedAt         *time.Time          `bson:"started_at,omitempty" json:"started_at,omitempty"`
+	CompletedAt       *time.Time          `bson:"completed_at,omitempty" json:"completed_at,omitempty"`
+	FailedAt          *time.Time          `bson:"failed_at,omitempty" json:"failed_at,omitempty"`
+	ErrorMessage      string              `bson:"error_message,omitempty" json:"error_message,omitempty"`
+	Progress          int                 `bson:"progress" json:"progress"` // 0-100
+	ScheduledFor      *time.Time          `bson:"scheduled_for,omitempty" json:"scheduled_for,omitempty"` // Maintenance window a scheduled rollout waits for
+	CampaignID        *primitive.ObjectID `bson:"campaign_id,omitempty" json:"campaign_id,omitempty"`
+	WaveIndex         *int                `bson:"wave_index,omitempty" json:"wave_index,omitempty"`
+	RollbackOf        *primitive.ObjectID `bson:"rollback_of,omitempty" json:"rollback_of,omitempty"`                 // Set on rollback rollouts
+	RollbackRolloutID *primitive.ObjectID `bson:"rollback_rollout_id,omitempty" json:"rollback_rollout_id,omitempty"` // Set on rolled back rollouts
+	RollbackTrigger   RollbackTrigger     `bson:"rollback_trigger,omitempty" json:"rollback_trigger,omitempty"`
+	RollbackReason    string              `bson:"rollback_reason,omitempty" json:"rollback_reason,omitempty"`
+	TimedOutPhase     RolloutStatus       `bson:"timed_out_phase,omitempty" json:"timed_out_phase,omitempty"` // Set when the sweeper failed the rollout
+	BatchID           string              `bson:"batch_id,omitempty" json:"batch_id,omitempty"`               // Set on rollouts created by a bulk rollout
 }
 
 type RolloutStatus string
 
 const (
+	RolloutStatusScheduled  RolloutStatus = "scheduled" // Waiting for the endpoint's maintenance window
 	RolloutStatusPending    RolloutStatus = "pending"
 	RolloutStatusInProgress RolloutStatus = "in_progress"
 	RolloutStatusCompleted  RolloutStatus = "completed"
@@ -259,6 +586,204 @@ const (
 	RolloutStatusCancelled  RolloutStatus = "cancelled"
 )
 
+// RollbackTrigger records why a rollback rollout was created
+type RollbackTrigger string
+
+const (
+	RollbackTriggerFailure  RollbackTrigger = "failure"  // The original rollout failed
+	RollbackTriggerOperator RollbackTrigger = "operator" // Requested through the API
+)
+
+// StuckRolloutReport lists rollouts whose agent stopped reporting: active
+// rollouts past their phase timeout and rollouts the sweeper failed
+type StuckRolloutReport struct {
+	GeneratedAt time.Time           `json:"generated_at"`
+	Since       time.Time           `json:"since"`     // Start of the timed out period
+	Overdue     int                 `json:"overdue"`   // Still active past their timeout
+	TimedOut    int                 `json:"timed_out"` // Failed by the sweeper since Since
+	Groups      []StuckRolloutGroup `json:"groups"`
+}
+
+// StuckRolloutGroup collects the stuck rollouts of one product version and tenant
+type StuckRolloutGroup struct {
+	ProductID string         `json:"product_id"`
+	ToVersion string         `json:"to_version"`
+	TenantID  string         `json:"tenant_id,omitempty"` // Empty when the endpoint is no longer registered
+	Overdue   int            `json:"overdue"`
+	TimedOut  int            `json:"timed_out"`
+	Rollouts  []StuckRollout `json:"rollouts"`
+}
+
+// StuckRollout is one entry of a stuck rollout group
+type StuckRollout struct {
+	RolloutID      string        `json:"rollout_id"`
+	EndpointID     string        `json:"endpoint_id"`
+	Status         RolloutStatus `json:"status"`
+	Phase          RolloutStatus `json:"phase"` // The phase that timed out
+	PhaseStartedAt time.Time     `json:"phase_started_at"`
+	TimeoutMinutes int           `json:"timeout_minutes"`
+	FailedAt       *time.Time    `json:"failed_at,omitempty"`
+}
+
+// BulkRolloutRequest selects endpoints with pending updates to roll out in one
+// batch. The filter fields match those of GET /updates/pending.
+type BulkRolloutRequest struct {
+	ProductID      string         `json:"product_id,omitempty"` // Required unless target_version is "latest"
+	DeploymentType DeploymentType `json:"deployment_type,omitempty"`
+	Priority       string         `json:"priority,omitempty"` // critical, high, normal
+	CustomerID     string         `json:"customer_id,omitempty"`
+	TenantID       string         `json:"tenant_id,omitempty"`
+	TargetVersion  string         `json:"target_version" validate:"required"` // A version number or "latest"
+}
+
+// BulkRolloutResult lists what a bulk rollout would do (preview) or did
+// (execute) for every matching endpoint
+type BulkRolloutResult struct {
+	BatchID       string              `json:"batch_id,omitempty"` // Set when executed
+	DryRun        bool                `json:"dry_run"`
+	TargetVersion string              `json:"target_version"`
+	Matched       int                 `json:"matched"`  // Endpoints of deployments matching the filter
+	Eligible      int                 `json:"eligible"` // Preview: endpoints that would get a rollout
+	Created       int                 `json:"created"`  // Execute: rollouts created
+	Skipped       int                 `json:"skipped"`
+	Targets       []BulkRolloutTarget `json:"targets"`
+}
+
+// BulkRolloutTarget is the outcome of a bulk rollout for one endpoint, or for a
+// deployment without registered endpoints
+type BulkRolloutTarget struct {
+	DeploymentID  string         `json:"deployment_id"`
+	CustomerID    string         `json:"customer_id,omitempty"`
+	TenantID      string         `json:"tenant_id,omitempty"`
+	EndpointID    string         `json:"endpoint_id,omitempty"`
+	ProductID     string         `json:"product_id"`
+	FromVersion   string         `json:"from_version,omitempty"`
+	ToVersion     string         `json:"to_version,omitempty"`   // The first hop when the upgrade path is multi-step
+	UpgradePath   []string       `json:"upgrade_path,omitempty"` // Every hop to the target of a multi-step upgrade path
+	SkipReason    BulkSkipReason `json:"skip_reason,omitempty"`
+	SkipDetail    string         `json:"skip_detail,omitempty"`
+	RolloutID     string         `json:"rollout_id,omitempty"`
+	RolloutStatus RolloutStatus  `json:"rollout_status,omitempty"` // pending, or scheduled for a maintenance window
+}
+
+// BulkSkipReason explains why a bulk rollout left an endpoint out
+type BulkSkipReason string
+
+const (
+	BulkSkipNoEndpoints         BulkSkipReason = "no_endpoints"
+	BulkSkipNoDetection         BulkSkipReason = "no_update_detection"
+	BulkSkipUpToDate            BulkSkipReason = "up_to_date"
+	BulkSkipActiveRollout       BulkSkipReason = "active_rollout"
+	BulkSkipVersionNotFound     BulkSkipReason = "version_not_found"
+	BulkSkipRolloutsHalted      BulkSkipReason = "rollouts_halted"
+	BulkSkipIncompatible        BulkSkipReason = "incompatible"
+	BulkSkipPathBlocked         BulkSkipReason = "upgrade_path_blocked"
+	BulkSkipLicenseExpired      BulkSkipReason = "license_expired"
+	BulkSkipNoMaintenanceWindow BulkSkipReason = "no_maintenance_window"
+	BulkSkipFailed              BulkSkipReason = "failed"
+)
+
+// RolloutEvent is one entry of a rollout's append-only timeline, posted by the
+// endpoint agent as it works through the update steps
+type RolloutEvent struct {
+	ID          primitive.ObjectID       `bson:"_id,omitempty" json:"id"`
+	RolloutID   primitive.ObjectID       `bson:"rollout_id" json:"rollout_id"`
+	EventID     string                   `bson:"event_id,omitempty" json:"event_id,omitempty"` // Chosen by the agent; resent events are ignored
+	Step        string                   `bson:"step" json:"step"`                             // e.g. download, verify, install
+	Level       RolloutEventLevel        `bson:"level" json:"level"`
+	Message     string                   `bson:"message" json:"message"`
+	Timestamp   time.Time                `bson:"timestamp" json:"timestamp"` // Agent clock
+	ReceivedAt  time.Time                `bson:"received_at" json:"received_at"`
+	Attachments []RolloutEventAttachment `bson:"attachments,omitempty" json:"attachments,omitempty"`
+}
+
+// RolloutEventAttachment is a text excerpt attached to a rollout event, such as
+// the tail of an installer log. Content over the size cap keeps its end.
+type RolloutEventAttachment struct {
+	Name        string `bson:"name" json:"name"`
+	ContentType string `bson:"content_type" json:"content_type"`
+	Content     string `bson:"content" json:"content"`
+	Size        int    `bson:"size" json:"size"` // Bytes sent by the agent
+	Truncated   bool   `bson:"truncated,omitempty" json:"truncated,omitempty"`
+}
+
+type RolloutEventLevel string
+
+const (
+	RolloutEventLevelDebug   RolloutEventLevel = "debug"
+	RolloutEventLevelInfo    RolloutEventLevel = "info"
+	RolloutEventLevelWarning RolloutEventLevel = "warning"
+	RolloutEventLevelError   RolloutEventLevel = "error"
+)
+
+// RolloutCampaign is a staged rollout of a product version across many endpoints.
+// Waves open in order; each wave creates per-endpoint rollouts for its share of
+// the targets, and must finish and bake before the next wave opens.
+type RolloutCampaign struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Name          string             `bson:"name" json:"name"`
+	ProductID     string             `bson:"product_id" json:"product_id"`
+	ToVersion     string             `bson:"to_version" json:"to_version"`
+	EndpointIDs   []string           `bson:"endpoint_ids" json:"endpoint_ids"` // Resolved targets in wave order
+	DeploymentIDs []string           `bson:"deployment_ids,omitempty" json:"deployment_ids,omitempty"`
+	Waves         []RolloutWave      `bson:"waves" json:"waves"`
+	CurrentWave   int                `bson:"current_wave" json:"current_wave"` // -1 until the first wave opens
+	Status        CampaignStatus     `bson:"status" json:"status"`
+	StartAt       *time.Time         `bson:"start_at,omitempty" json:"start_at,omitempty"`
+	CreatedBy     string             `bson:"created_by" json:"created_by"`
+	CreatedAt     time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt     time.Time          `bson:"updated_at" json:"updated_at"`
+	StartedAt     *time.Time         `bson:"started_at,omitempty" json:"started_at,omitempty"`
+	PausedAt      *time.Time         `bson:"paused_at,omitempty" json:"paused_at,omitempty"`
+	CompletedAt   *time.Time         `bson:"completed_at,omitempty" json:"completed_at,omitempty"`
+	AbortedAt     *time.Time         `bson:"aborted_at,omitempty" json:"aborted_at,omitempty"`
+	AbortReason   string             `bson:"abort_reason,omitempty" json:"abort_reason,omitempty"`
+	HealthGate    *RolloutHealthGate `bson:"health_gate,omitempty" json:"health_gate,omitempty"` // Defaults to the version gate
+	HaltedAt      *time.Time         `bson:"halted_at,omitempty" json:"halted_at,omitempty"`
+	HaltReason    string             `bson:"halt_reason,omitempty" json:"halt_reason,omitempty"`
+	Revision      int64              `bson:"revision" json:"revision"` // Incremented on every write
+}
+
+// RolloutWave is one stage of a rollout campaign
+type RolloutWave struct {
+	Name            string                    `bson:"name" json:"name"`
+	Percentage      int                       `bson:"percentage" json:"percentage"` // Cumulative share of targets, 1-100
+	BakeTimeMinutes int                       `bson:"bake_time_minutes" json:"bake_time_minutes"`
+	Status          WaveStatus                `bson:"status" json:"status"`
+	EndpointIDs     []string                  `bson:"endpoint_ids,omitempty" json:"endpoint_ids,omitempty"`
+	RolloutCount    int                       `bson:"rollout_count" json:"rollout_count"`
+	Skipped         []CampaignSkippedEndpoint `bson:"skipped,omitempty" json:"skipped,omitempty"`
+	OpenedAt        *time.Time                `bson:"opened_at,omitempty" json:"opened_at,omitempty"`
+	BakeUntil       *time.Time                `bson:"bake_until,omitempty" json:"bake_until,omitempty"`
+	CompletedAt     *time.Time                `bson:"completed_at,omitempty" json:"completed_at,omitempty"`
+}
+
+// CampaignSkippedEndpoint records why a wave did not create a rollout for an endpoint
+type CampaignSkippedEndpoint struct {
+	EndpointID string `bson:"endpoint_id" json:"endpoint_id"`
+	Reason     string `bson:"reason" json:"reason"`
+}
+
+type CampaignStatus string
+
+const (
+	CampaignStatusScheduled CampaignStatus = "scheduled"
+	CampaignStatusRunning   CampaignStatus = "running"
+	CampaignStatusPaused    CampaignStatus = "paused"
+	CampaignStatusCompleted CampaignStatus = "completed"
+	CampaignStatusAborted   CampaignStatus = "aborted"
+	CampaignStatusHalted    CampaignStatus = "halted" // Stopped by its health gate
+)
+
+type WaveStatus string
+
+const (
+	WaveStatusPending    WaveStatus = "pending"
+	WaveStatusRollingOut WaveStatus = "rolling_out"
+	WaveStatusBaking     WaveStatus = "baking"
+	WaveStatusCompleted  WaveStatus = "completed"
+)
+
 // AuditLog represents an audit log entry
 type AuditLog struct {
 	ID           primitive.ObjectID     `bson:"_id,omitempty" json:"id"`
@@ -270,7 +795,71 @@ type AuditLog struct {
 	Details      map[string]interface{} `bson:"details" json:"details"`
 	IPAddress    string                 `bson:"ip_address" json:"ip_address"`
 	UserAgent    string                 `bson:"user_agent" json:"user_agent"`
+	RequestID    string                 `bson:"request_id,omitempty" json:"request_id,omitempty"`
+	Changes      []AuditFieldChange     `bson:"changes,omitempty" json:"changes,omitempty"` // Fields an update changed
+	State        map[string]interface{} `bson:"state,omitempty" json:"state,omitempty"`     // State of a created resource
 	Timestamp    time.Time              `bson:"timestamp" json:"timestamp"`
+	Sequence     int64                  `bson:"sequence,omitempty" json:"sequence,omitempty"`         // Position in the hash chain, from 1
+	PrevHash     string                 `bson:"prev_hash,omitempty" json:"prev_hash,omitempty"`       // Hash of the previous entry
+	ContentHash  string                 `bson:"content_hash,omitempty" json:"content_hash,omitempty"` // SHA-256 of the entry's content
+	Hash         string                 `bson:"hash,omitempty" json:"hash,omitempty"`                 // SHA-256 of prev_hash and content_hash
+}
+
+// AuditFieldChange is a field changed by an audited update. Nested fields are
+// named by their dotted path, e.g. "notification_preferences.email_enabled".
+// Removed tells a removed field apart from one set to null.
+type AuditFieldChange struct {
+	Field    string      `bson:"field" json:"field"`
+	OldValue interface{} `bson:"old_value" json:"old_value"`
+	NewValue interface{} `bson:"new_value" json:"new_value"`
+	Removed  bool        `bson:"removed,omitempty" json:"removed,omitempty"`
+}
+
+// AuditCheckpoint is a signed statement of the audit chain's head, exported
+// periodically so removing or rewriting entries up to it can be detected
+type AuditCheckpoint struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Sequence  int64              `bson:"sequence" json:"sequence"`
+	Hash      string             `bson:"hash" json:"hash"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+	KeyID     string             `bson:"key_id" json:"key_id"`         // First 16 hex digits of the SHA-256 of the public key
+	PublicKey string             `bson:"public_key" json:"public_key"` // Base64 Ed25519 public key
+	Signature string             `bson:"signature" json:"signature"`   // Base64 Ed25519 signature
+}
+
+// AuditChainVerification is the result of walking the audit hash chain
+type AuditChainVerification struct {
+	Valid        bool             `json:"valid"`
+	Verified     int64            `json:"verified"`  // Chained entries checked
+	Unchained    int64            `json:"unchained"` // Entries written before chaining, not covered
+	HeadSequence int64            `json:"head_sequence"`
+	HeadHash     string           `json:"head_hash,omitempty"`
+	Checkpoints  int              `json:"checkpoints"` // Checkpoints matched against the chain
+	FirstBroken  *AuditChainBreak `json:"first_broken,omitempty"`
+	CheckedAt    time.Time        `json:"checked_at"`
+}
+
+// AuditChainBreak is the first link of the audit chain that failed verification
+type AuditChainBreak struct {
+	Sequence int64  `json:"sequence"`
+	EntryID  string `json:"entry_id,omitempty"`
+	Reason   string `json:"reason"`
+	Expected string `json:"expected,omitempty"`
+	Actual   string `json:"actual,omitempty"`
+}
+
+// AuditResourceState is the state of a resource as of a point in time,
+// rebuilt from its audit history
+type AuditResourceState struct {
+	ResourceType  string                 `json:"resource_type"`
+	ResourceID    string                 `json:"resource_id"`
+	AsOf          time.Time              `json:"as_of"`
+	Exists        bool                   `json:"exists"`   // False before the resource was created and after it was deleted
+	Complete      bool                   `json:"complete"` // False when the history lacks the created state, so only changed fields are known
+	State         map[string]interface{} `json:"state"`
+	Entries       int                    `json:"entries"` // Audit entries replayed
+	LastChangedAt *time.Time             `json:"last_changed_at,omitempty"`
+	LastChangedBy string                 `json:"last_changed_by,omitempty"`
 }
 
 type AuditAction string
@@ -286,6 +875,98 @@ const (
 	AuditActionDownload AuditAction = "download"
 )
 
+// OutboxEvent is a domain event stored in the outbox with the state change
+// that raised it, until every subscriber has handled it
+type OutboxEvent struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Type          DomainEventType    `bson:"type" json:"type"`
+	AggregateID   string             `bson:"aggregate_id" json:"aggregate_id"`
+	Payload       bson.Raw           `bson:"payload" json:"-"`
+	Status        OutboxStatus       `bson:"status" json:"status"`
+	Attempts      int                `bson:"attempts" json:"attempts"`
+	NextAttemptAt time.Time          `bson:"next_attempt_at" json:"next_attempt_at"`
+	DeliveredTo   []string           `bson:"delivered_to,omitempty" json:"delivered_to,omitempty"` // Subscribers that handled the event
+	LastError     string             `bson:"last_error,omitempty" json:"last_error,omitempty"`
+	CreatedAt     time.Time          `bson:"created_at" json:"created_at"`
+	DispatchedAt  *time.Time         `bson:"dispatched_at,omitempty" json:"dispatched_at,omitempty"`
+}
+
+// DomainEventType identifies a domain event
+type DomainEventType string
+
+const (
+	DomainEventVersionReleased   DomainEventType = "version.released"
+	DomainEventDeploymentUpdated DomainEventType = "deployment.updated"
+	DomainEventLicenseExpired    DomainEventType = "license.expired"
+	DomainEventRolloutFailed     DomainEventType = "rollout.failed"
+	DomainEventVersionSubmitted  DomainEventType = "version.submitted"
+	DomainEventVersionApproved   DomainEventType = "version.approved"
+)
+
+// DomainEventCounts counts the domain events dispatched since the server started
+type DomainEventCounts struct {
+	Counts map[DomainEventType]int64 `json:"counts"`
+	Total  int64                     `json:"total"`
+	Since  time.Time                 `json:"since"`
+}
+
+// OutboxStatus represents the delivery status of an outbox event
+type OutboxStatus string
+
+const (
+	OutboxStatusPending    OutboxStatus = "pending"
+	OutboxStatusDispatched OutboxStatus = "dispatched"
+	OutboxStatusFailed     OutboxStatus = "failed" // Gave up after the maximum attempts
+)
+
+// Webhook sends domain events to a URL. A webhook without a customer is
+// global and receives the events of every customer.
+type Webhook struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	CustomerID  string             `bson:"customer_id,omitempty" json:"customer_id,omitempty"`
+	URL         string             `bson:"url" json:"url"`
+	Secret      string             `bson:"secret" json:"secret,omitempty"`                     // Only returned when the webhook is created
+	EventTypes  []DomainEventType  `bson:"event_types,omitempty" json:"event_types,omitempty"` // Empty receives all types
+	ProductIDs  []string           `bson:"product_ids,omitempty" json:"product_ids,omitempty"` // Empty receives all products
+	Description string             `bson:"description,omitempty" json:"description,omitempty"`
+	IsActive    bool               `bson:"is_active" json:"is_active"`
+	CreatedBy   string             `bson:"created_by" json:"created_by"`
+	CreatedAt   time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt   time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// WebhookDelivery is one event sent, or to be sent, to a webhook with the
+// outcome of its latest attempt
+type WebhookDelivery struct {
+	ID             primitive.ObjectID    `bson:"_id,omitempty" json:"id"`
+	WebhookID      primitive.ObjectID    `bson:"webhook_id" json:"webhook_id"`
+	EventID        string                `bson:"event_id,omitempty" json:"event_id,omitempty"` // Outbox event; empty for pings
+	EventType      string                `bson:"event_type" json:"event_type"`
+	DedupeKey      string                `bson:"dedupe_key,omitempty" json:"-"` // Unique per webhook and event for first deliveries
+	Status         WebhookDeliveryStatus `bson:"status" json:"status"`
+	Attempts       int                   `bson:"attempts" json:"attempts"`
+	MaxAttempts    int                   `bson:"max_attempts" json:"max_attempts"`
+	NextAttemptAt  time.Time             `bson:"next_attempt_at" json:"next_attempt_at"`
+	RequestHeaders map[string]string     `bson:"request_headers,omitempty" json:"request_headers,omitempty"`
+	RequestBody    string                `bson:"request_body" json:"request_body"`
+	ResponseStatus int                   `bson:"response_status,omitempty" json:"response_status,omitempty"`
+	ResponseBody   string                `bson:"response_body,omitempty" json:"response_body,omitempty"` // Capped
+	LastError      string                `bson:"last_error,omitempty" json:"last_error,omitempty"`
+	DurationMs     int64                 `bson:"duration_ms,omitempty" json:"duration_ms,omitempty"`
+	RedeliveryOf   *primitive.ObjectID   `bson:"redelivery_of,omitempty" json:"redelivery_of,omitempty"`
+	CreatedAt      time.Time             `bson:"created_at" json:"created_at"`
+	LastAttemptAt  *time.Time            `bson:"last_attempt_at,omitempty" json:"last_attempt_at,omitempty"`
+	DeliveredAt    *time.Time            `bson:"delivered_at,omitempty" json:"delivered_at,omitempty"`
+}
+
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryPending    WebhookDeliveryStatus = "pending" // Waiting for its first attempt or a retry
+	WebhookDeliverySucceeded  WebhookDeliveryStatus = "succeeded"
+	WebhookDeliveryDeadLetter WebhookDeliveryStatus = "dead_letter" // Failed every attempt
+)
+
 // Request/Response DTOs
 
 // CreateProductRequest represents a request to create a product
@@ -295,6 +976,7 @@ type CreateProductRequest struct {
 	Type        ProductType `json:"type" validate:"required"`
 	Description string      `json:"description" validate:"max=1000"`
 	Vendor      string      `json:"vendor" validate:"max=100"`
+	RolloutTimeouts *RolloutTimeouts `json:"rollout_timeouts,omitempty"`
 }
 
 // CreateVersionRequest represents a request to create a version
@@ -333,6 +1015,224 @@ type ValidateCompatibilityRequest struct {
 	IncompatibleVersions     []string `json:"incompatible_versions"`
 }
 
+// CreateUpgradePathRuleRequest represents a request to create an upgrade path rule
+type CreateUpgradePathRuleRequest struct {
+	Name                 string          `json:"name" validate:"required,max=200"`
+	Description          string          `json:"description,omitempty" validate:"max=1000"`
+	FromVersion          string          `json:"from_version"`
+	ToVersion            string          `json:"to_version"`
+	PathType             UpgradePathType `json:"path_type" validate:"required"`
+	IntermediateVersions []string        `json:"intermediate_versions,omitempty"`
+	BlockReason          string          `json:"block_reason,omitempty"`
+	Priority             int             `json:"priority"`
+	IsActive             *bool           `json:"is_active,omitempty"`
+}
+
+// UpdateUpgradePathRuleRequest represents a request to update an upgrade path rule
+type UpdateUpgradePathRuleRequest struct {
+	Name                 *string          `json:"name,omitempty" validate:"omitempty,max=200"`
+	Description          *string          `json:"description,omitempty" validate:"omitempty,max=1000"`
+	FromVersion          *string          `json:"from_version,omitempty"`
+	ToVersion            *string          `json:"to_version,omitempty"`
+	PathType             *UpgradePathType `json:"path_type,omitempty"`
+	IntermediateVersions []string         `json:"intermediate_versions,omitempty"`
+	BlockReason          *string          `json:"block_reason,omitempty"`
+	Priority             *int             `json:"priority,omitempty"`
+	IsActive             *bool            `json:"is_active,omitempty"`
+}
+
+// UpdateUpgradePathRequest represents a request to update an upgrade path
+type UpdateUpgradePathRequest struct {
+	PathType             *UpgradePathType `json:"path_type,omitempty"`
+	IntermediateVersions []string         `json:"intermediate_versions,omitempty"`
+}
+
+// CreateRolloutCampaignRequest represents a request to create a staged rollout campaign
+type CreateRolloutCampaignRequest struct {
+	Name          string                     `json:"name" validate:"required"`
+	ProductID     string                     `json:"product_id" validate:"required"`
+	ToVersion     string                     `json:"to_version" validate:"required"`
+	EndpointIDs   []string                   `json:"endpoint_ids,omitempty"`
+	DeploymentIDs []string                   `json:"deployment_ids,omitempty"` // All registered endpoints of these deployments
+	Waves         []CreateRolloutWaveRequest `json:"waves" validate:"required"`
+	StartAt       *time.Time                 `json:"start_at,omitempty"` // Defaults to the next scheduler run
+	HealthGate    *RolloutHealthGate         `json:"health_gate,omitempty"`
+}
+
+// CreateRolloutWaveRequest describes one wave of a rollout campaign
+type CreateRolloutWaveRequest struct {
+	Name            string `json:"name,omitempty"`
+	Percentage      int    `json:"percentage" validate:"required,min=1,max=100"`
+	BakeTimeMinutes int    `json:"bake_time_minutes"`
+}
+
+// AbortRolloutCampaignRequest represents a request to abort a rollout campaign
+type AbortRolloutCampaignRequest struct {
+	Reason string `json:"reason"`
+}
+
+// CreateWebhookRequest represents a request to create a webhook
+type CreateWebhookRequest struct {
+	CustomerID  string            `json:"customer_id,omitempty"` // Empty creates a global webhook
+	URL         string            `json:"url" validate:"required"`
+	Secret      string            `json:"secret,omitempty"` // Generated when empty
+	EventTypes  []DomainEventType `json:"event_types,omitempty"`
+	ProductIDs  []string          `json:"product_ids,omitempty"`
+	Description string            `json:"description,omitempty"`
+}
+
+// UpdateWebhookRequest represents a request to update a webhook
+type UpdateWebhookRequest struct {
+	URL         *string            `json:"url,omitempty"`
+	Secret      *string            `json:"secret,omitempty"`
+	EventTypes  *[]DomainEventType `json:"event_types,omitempty"`
+	ProductIDs  *[]string          `json:"product_ids,omitempty"`
+	Description *string            `json:"description,omitempty"`
+	IsActive    *bool              `json:"is_active,omitempty"`
+}
+
+// CreateNotificationRouteRequest represents a request to create a notification route
+type CreateNotificationRouteRequest struct {
+	Name        string               `json:"name" validate:"required"`
+	CustomerID  string               `json:"customer_id,omitempty"` // Empty routes every customer's notifications
+	Channel     NotificationChannel  `json:"channel" validate:"required"`
+	Target      string               `json:"target" validate:"required"`
+	MinPriority NotificationPriority `json:"min_priority,omitempty"` // Defaults to low, or critical for pagerduty
+	Types       []NotificationType   `json:"types,omitempty"`
+}
+
+// CreateInternalGroupRequest represents a request to create an internal group
+type CreateInternalGroupRequest struct {
+	GroupID     string   `json:"group_id" validate:"required"`
+	Name        string   `json:"name" validate:"required"`
+	Description string   `json:"description,omitempty"`
+	Members     []string `json:"members,omitempty"`
+}
+
+// CreateInternalNotificationSubscriptionRequest represents a request to
+// subscribe an internal user or group to internal notifications
+type CreateInternalNotificationSubscriptionRequest struct {
+	RecipientType InternalRecipientType `json:"recipient_type" validate:"required"`
+	RecipientID   string                `json:"recipient_id" validate:"required"`
+	Types         []NotificationType    `json:"types" validate:"required"`
+	ProductID     string                `json:"product_id,omitempty"`
+}
+
+// CreateNotificationTemplateRequest represents a request to create a notification template
+type CreateNotificationTemplateRequest struct {
+	Type    NotificationType    `json:"type" validate:"required"`
+	Channel NotificationChannel `json:"channel" validate:"required"`
+	Locale  string              `json:"locale" validate:"required"`
+	Subject string              `json:"subject" validate:"required"`
+	Text    string              `json:"text" validate:"required"`
+	HTML    string              `json:"html,omitempty"`
+}
+
+// UpdateNotificationTemplateRequest represents a request to update a notification template
+type UpdateNotificationTemplateRequest struct {
+	Subject *string `json:"subject,omitempty"`
+	Text    *string `json:"text,omitempty"`
+	HTML    *string `json:"html,omitempty"`
+}
+
+// PreviewNotificationTemplateRequest represents a request to render a
+// notification template. Without sources the template that would be used for
+// the type, channel and locale is rendered; without a customer, version or
+// deployments sample values are used.
+type PreviewNotificationTemplateRequest struct {
+	Type          NotificationType    `json:"type" validate:"required"`
+	Channel       NotificationChannel `json:"channel" validate:"required"`
+	Locale        string              `json:"locale,omitempty"`
+	Subject       *string             `json:"subject,omitempty"`
+	Text          *string             `json:"text,omitempty"`
+	HTML          *string             `json:"html,omitempty"`
+	CustomerID    string              `json:"customer_id,omitempty"`
+	VersionID     string              `json:"version_id,omitempty"`
+	DeploymentIDs []string            `json:"deployment_ids,omitempty"`
+}
+
+// NotificationTemplatePreview is a rendered notification template
+type NotificationTemplatePreview struct {
+	Source  string `json:"source"` // request, stored or builtin
+	Locale  string `json:"locale"` // Locale of the template rendered
+	Subject string `json:"subject"`
+	Text    string `json:"text"`
+	HTML    string `json:"html,omitempty"`
+}
+
+// UpdateInternalGroupRequest represents a request to update an internal group.
+// Members replaces the whole member list.
+type UpdateInternalGroupRequest struct {
+	Name        *string   `json:"name,omitempty"`
+	Description *string   `json:"description,omitempty"`
+	Members     *[]string `json:"members,omitempty"`
+}
+
+// UpdateInternalNotificationSubscriptionRequest represents a request to
+// update an internal notification subscription
+type UpdateInternalNotificationSubscriptionRequest struct {
+	Types     *[]NotificationType `json:"types,omitempty"`
+	ProductID *string             `json:"product_id,omitempty"`
+	IsActive  *bool               `json:"is_active,omitempty"`
+}
+
+// UpdateNotificationRouteRequest represents a request to update a notification route
+type UpdateNotificationRouteRequest struct {
+	Name        *string               `json:"name,omitempty"`
+	Target      *string               `json:"target,omitempty"`
+	MinPriority *NotificationPriority `json:"min_priority,omitempty"`
+	Types       *[]NotificationType   `json:"types,omitempty"`
+	IsActive    *bool                 `json:"is_active,omitempty"`
+}
+
+// RollbackRolloutRequest represents an operator rolling back a rollout
+type RollbackRolloutRequest struct {
+	Reason string `json:"reason"`
+}
+
+// RecordRolloutEventsRequest represents a batch of timeline events from an agent
+type RecordRolloutEventsRequest struct {
+	Events []RolloutEventRequest `json:"events"`
+}
+
+// RolloutEventRequest represents one event of a batch
+type RolloutEventRequest struct {
+	EventID     string                          `json:"event_id,omitempty"`
+	Step        string                          `json:"step"`
+	Level       RolloutEventLevel               `json:"level,omitempty"`     // Defaults to info
+	Message     string                          `json:"message"`
+	Timestamp   *time.Time                      `json:"timestamp,omitempty"` // Defaults to the time received
+	Attachments []RolloutEventAttachmentRequest `json:"attachments,omitempty"`
+}
+
+// RolloutEventAttachmentRequest represents an attachment of a posted event
+type RolloutEventAttachmentRequest struct {
+	Name        string `json:"name"`
+	ContentType string `json:"content_type,omitempty"` // Defaults to text/plain
+	Content     string `json:"content"`
+}
+
+// RecordRolloutEventsResponse reports how a batch of events was stored
+type RecordRolloutEventsResponse struct {
+	Recorded   int `json:"recorded"`
+	Duplicates int `json:"duplicates"` // Events whose event_id was already recorded
+}
+
+// ResolveRolloutHaltRequest represents an operator resuming or recalling a halted version
+type ResolveRolloutHaltRequest struct {
+	Note string `json:"note,omitempty"`
+}
+
+// CheckInRequest represents an endpoint agent check-in
+type CheckInRequest struct {
+	EndpointID       string         `json:"endpoint_id" validate:"required"`
+	ProductID        string         `json:"product_id" validate:"required"`
+	InstalledVersion string         `json:"installed_version" validate:"required"`
+	OS               string         `json:"os,omitempty"`
+	Architecture     string         `json:"architecture,omitempty"`
+	Channel          ReleaseChannel `json:"channel,omitempty"` // Defaults to stable
+}
+
 // InitiateRolloutRequest represents a request to initiate an update rollout
 type InitiateRolloutRequest struct {
 	ToVersion string `json:"to_version" validate:"required"`
@@ -370,6 +1270,7 @@ type Customer struct {
 	Address               string                  `bson:"address,omitempty" json:"address,omitempty" validate:"max=500"`
 	AccountStatus         CustomerStatus          `bson:"account_status" json:"account_status" validate:"required"`
 	NotificationPreferences NotificationPreferences `bson:"notification_preferences" json:"notification_preferences"`
+	PreferredLocale       string                  `bson:"preferred_locale,omitempty" json:"preferred_locale,omitempty"` // Notification locale; empty uses the default
 	CreatedAt             time.Time               `bson:"created_at" json:"created_at"`
 	UpdatedAt             time.Time               `bson:"updated_at" json:"updated_at"`
 }
@@ -389,8 +1290,21 @@ type NotificationPreferences struct {
 	InAppEnabled           bool `bson:"in_app_enabled" json:"in_app_enabled"`
 	UATNotifications       bool `bson:"uat_notifications" json:"uat_notifications"`
 	ProductionNotifications bool `bson:"production_notifications" json:"production_notifications"`
+	DigestFrequency        NotificationDigestFrequency `bson:"digest_frequency,omitempty" json:"digest_frequency,omitempty"` // Empty is immediate
+	Explicit               bool `bson:"explicit,omitempty" json:"-"` // Set when saved through the API, so all-false is an opt-out rather than never set
 }
 
+// NotificationDigestFrequency is how often a customer's non-critical email
+// notifications are sent, batched into one digest
+type NotificationDigestFrequency string
+
+const (
+	NotificationDigestImmediate NotificationDigestFrequency = "immediate"
+	NotificationDigestHourly    NotificationDigestFrequency = "hourly"
+	NotificationDigestDaily     NotificationDigestFrequency = "daily"
+	NotificationDigestWeekly    NotificationDigestFrequency = "weekly"
+)
+
 // CustomerTenant represents a tenant (independent deployment) for a customer
 type CustomerTenant struct {
 	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
@@ -399,6 +1313,7 @@ type CustomerTenant struct {
 	Name        string             `bson:"name" json:"name" validate:"required,min=1,max=200"`
 	Description string             `bson:"description,omitempty" json:"description,omitempty" validate:"max=1000"`
 	Status      TenantStatus       `bson:"status" json:"status" validate:"required"`
+	MaintenanceSchedule *MaintenanceSchedule `bson:"maintenance_schedule" json:"maintenance_schedule,omitempty"`
 	CreatedAt   time.Time          `bson:"created_at" json:"created_at"`
 	UpdatedAt   time.Time          `bson:"updated_at" json:"updated_at"`
 }
@@ -426,6 +1341,34 @@ type Deployment struct {
 	DeploymentDate   time.Time          `bson:"deployment_date" json:"deployment_date"`
 	LastUpdatedDate  time.Time          `bson:"last_updated_date" json:"last_updated_date"`
 	Status           DeploymentStatus   `bson:"status" json:"status" validate:"required"`
+	MaintenanceSchedule *MaintenanceSchedule `bson:"maintenance_schedule" json:"maintenance_schedule,omitempty"` // Replaces the tenant's schedule
+}
+
+// MaintenanceSchedule limits when rollouts may start for a tenant or
+// deployment. Rollouts created outside a window are scheduled for the next one.
+type MaintenanceSchedule struct {
+	TimeZone      string              `bson:"time_zone,omitempty" json:"time_zone,omitempty"` // IANA name, e.g. "Europe/Berlin"; default UTC
+	Windows       []MaintenanceWindow `bson:"windows" json:"windows"`
+	BlackoutDates []string            `bson:"blackout_dates,omitempty" json:"blackout_dates,omitempty"` // YYYY-MM-DD; no window opens on these days
+}
+
+// MaintenanceWindow is a recurring window, given either as a cron expression
+// for its opening plus a duration, or as weekdays with start and end times
+type MaintenanceWindow struct {
+	Cron            string   `bson:"cron,omitempty" json:"cron,omitempty"` // minute hour day-of-month month day-of-week
+	DurationMinutes int      `bson:"duration_minutes,omitempty" json:"duration_minutes,omitempty"`
+	Weekdays        []string `bson:"weekdays,omitempty" json:"weekdays,omitempty"`     // e.g. "saturday" or "sat"
+	StartTime       string   `bson:"start_time,omitempty" json:"start_time,omitempty"` // HH:MM
+	EndTime         string   `bson:"end_time,omitempty" json:"end_time,omitempty"`     // HH:MM; at or before StartTime ends the next day
+}
+
+// MaintenanceWindowStatus is the window open now, or else the next one to open
+type MaintenanceWindowStatus struct {
+	Source   string     `json:"source"` // deployment or tenant
+	TimeZone string     `json:"time_zone"`
+	Open     bool       `json:"open"`
+	OpensAt  *time.Time `json:"opens_at,omitempty"` // Absent if no window opens within a year
+	ClosesAt *time.Time `json:"closes_at,omitempty"`
 }
 
 // DeploymentType represents the type of deployment
@@ -456,7 +1399,8 @@ type CreateCustomerRequest struct {
 	Phone                 string                  `json:"phone,omitempty" validate:"max=50"`
 	Address               string                  `json:"address,omitempty" validate:"max=500"`
 	AccountStatus         CustomerStatus          `json:"account_status" validate:"required"`
-	NotificationPreferences NotificationPreferences `json:"notification_preferences"`
+	NotificationPreferences *NotificationPreferences `json:"notification_preferences,omitempty"` // Nil stores the defaults
+	PreferredLocale       string                  `json:"preferred_locale,omitempty"`
 }
 
 // UpdateCustomerRequest represents a request to update a customer
@@ -468,6 +1412,7 @@ type UpdateCustomerRequest struct {
 	Address               *string                 `json:"address,omitempty" validate:"omitempty,max=500"`
 	AccountStatus         *CustomerStatus         `json:"account_status,omitempty"`
 	NotificationPreferences *NotificationPreferences `json:"notification_preferences,omitempty"`
+	PreferredLocale       *string                 `json:"preferred_locale,omitempty"`
 }
 
 // CreateTenantRequest represents a request to create a tenant
@@ -476,6 +1421,7 @@ type CreateTenantRequest struct {
 	Name        string `json:"name" validate:"required,min=1,max=200"`
 	Description string `json:"description,omitempty" validate:"max=1000"`
 	Status      TenantStatus `json:"status" validate:"required"`
+	MaintenanceSchedule *MaintenanceSchedule `json:"maintenance_schedule,omitempty"`
 }
 
 // UpdateTenantRequest represents a request to update a tenant
@@ -483,6 +1429,77 @@ type UpdateTenantRequest struct {
 	Name        *string      `json:"name,omitempty" validate:"omitempty,min=1,max=200"`
 	Description *string      `json:"description,omitempty" validate:"omitempty,max=1000"`
 	Status      *TenantStatus `json:"status,omitempty"`
+	MaintenanceSchedule *MaintenanceSchedule `json:"maintenance_schedule,omitempty"` // No windows removes the schedule
+}
+
+// Endpoint represents a registered machine running the update agent for a deployment
+type Endpoint struct {
+	ID              primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	EndpointID      string             `bson:"endpoint_id" json:"endpoint_id" validate:"required,min=1,max=100"`
+	DeploymentID    primitive.ObjectID `bson:"deployment_id" json:"deployment_id" validate:"required"`
+	TenantID        primitive.ObjectID `bson:"tenant_id" json:"tenant_id"`   // Copied from the deployment
+	ProductID       string             `bson:"product_id" json:"product_id"` // Copied from the deployment
+	Hostname        string             `bson:"hostname" json:"hostname" validate:"required,max=255"`
+	OS              string             `bson:"os,omitempty" json:"os,omitempty" validate:"max=50"`
+	OSVersion       string             `bson:"os_version,omitempty" json:"os_version,omitempty" validate:"max=100"`
+	Architecture    string             `bson:"architecture,omitempty" json:"architecture,omitempty" validate:"max=50"`
+	AgentVersion    string             `bson:"agent_version,omitempty" json:"agent_version,omitempty" validate:"max=50"`
+	Labels          map[string]string  `bson:"labels,omitempty" json:"labels,omitempty"`
+	Status          EndpointStatus     `bson:"status" json:"status"`
+	LastHeartbeatAt *time.Time         `bson:"last_heartbeat_at,omitempty" json:"last_heartbeat_at,omitempty"`
+	RegisteredAt    time.Time          `bson:"registered_at" json:"registered_at"`
+	UpdatedAt       time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// EndpointStatus represents the status of an endpoint
+type EndpointStatus string
+
+const (
+	EndpointStatusActive         EndpointStatus = "active"
+	EndpointStatusDecommissioned EndpointStatus = "decommissioned"
+)
+
+// StaleEndpointsReport lists active endpoints that have not sent a heartbeat within the threshold
+type StaleEndpointsReport struct {
+	StaleAfterDays int            `json:"stale_after_days"`
+	Cutoff         time.Time      `json:"cutoff"`
+	Total          int            `json:"total"`
+	ByProduct      map[string]int `json:"by_product"`
+	ByDeployment   map[string]int `json:"by_deployment"`
+	Endpoints      []*Endpoint    `json:"endpoints"`
+	GeneratedAt    time.Time      `json:"generated_at"`
+}
+
+// RegisterEndpointRequest represents a request to register an endpoint
+type RegisterEndpointRequest struct {
+	EndpointID   string            `json:"endpoint_id" validate:"required,min=1,max=100"`
+	DeploymentID string            `json:"deployment_id" validate:"required"` // deployment_id or ObjectID
+	Hostname     string            `json:"hostname" validate:"required,max=255"`
+	OS           string            `json:"os,omitempty" validate:"max=50"`
+	OSVersion    string            `json:"os_version,omitempty" validate:"max=100"`
+	Architecture string            `json:"architecture,omitempty" validate:"max=50"`
+	AgentVersion string            `json:"agent_version,omitempty" validate:"max=50"`
+	Labels       map[string]string `json:"labels,omitempty"`
+}
+
+// UpdateEndpointRequest represents a request to update an endpoint
+type UpdateEndpointRequest struct {
+	Hostname     *string           `json:"hostname,omitempty" validate:"omitempty,max=255"`
+	OS           *string           `json:"os,omitempty" validate:"omitempty,max=50"`
+	OSVersion    *string           `json:"os_version,omitempty" validate:"omitempty,max=100"`
+	Architecture *string           `json:"architecture,omitempty" validate:"omitempty,max=50"`
+	AgentVersion *string           `json:"agent_version,omitempty" validate:"omitempty,max=50"`
+	Labels       map[string]string `json:"labels,omitempty"`
+	Status       *EndpointStatus   `json:"status,omitempty"`
+}
+
+// EndpointHeartbeatRequest represents an agent heartbeat; non-empty fields refresh the inventory
+type EndpointHeartbeatRequest struct {
+	Hostname     string `json:"hostname,omitempty"`
+	OS           string `json:"os,omitempty"`
+	OSVersion    string `json:"os_version,omitempty"`
+	Architecture string `json:"architecture,omitempty"`
+	AgentVersion string `json:"agent_version,omitempty"`
 }
 
 // CreateDeploymentRequest represents a request to create a deployment
@@ -496,6 +1513,7 @@ type CreateDeploymentRequest struct {
 	ServerHostname    string         `json:"server_hostname,omitempty" validate:"max=200"`
 	EnvironmentDetails string        `json:"environment_details,omitempty" validate:"max=500"`
 	Status            DeploymentStatus `json:"status" validate:"required"`
+	MaintenanceSchedule *MaintenanceSchedule `json:"maintenance_schedule,omitempty"`
 }
 
 // UpdateDeploymentRequest represents a request to update a deployment
@@ -507,6 +1525,7 @@ type UpdateDeploymentRequest struct {
 	ServerHostname    *string          `json:"server_hostname,omitempty" validate:"omitempty,max=200"`
 	EnvironmentDetails *string        `json:"environment_details,omitempty" validate:"omitempty,max=500"`
 	Status            *DeploymentStatus `json:"status,omitempty"`
+	MaintenanceSchedule *MaintenanceSchedule `json:"maintenance_schedule,omitempty"` // No windows removes the schedule
 }
 
 // License Management Models
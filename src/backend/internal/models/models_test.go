@@ -56,7 +56,7 @@ func TestCreateCustomerRequest_Valid(t *testing.T) {
 		Name:         "Test Customer",
 		Email:        "test@example.com",
 		AccountStatus: CustomerStatusActive,
-		NotificationPreferences: NotificationPreferences{
+		NotificationPreferences: &NotificationPreferences{
 			EmailEnabled: true,
 		},
 	}
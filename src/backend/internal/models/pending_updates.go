@@ -28,6 +28,7 @@ type PendingUpdatesResponse struct {
 	CustomerID        string            `json:"customer_id,omitempty"`
 	CustomerName      string            `json:"customer_name,omitempty"`
 	DeploymentType    DeploymentType    `json:"deployment_type,omitempty"`
+	MaintenanceWindow *MaintenanceWindowStatus `json:"maintenance_window,omitempty"` // Open or next window; absent if unrestricted
 }
 
 // TenantPendingUpdatesSummary represents aggregated pending updates for a tenant
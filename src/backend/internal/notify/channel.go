@@ -0,0 +1,32 @@
+// Package notify sends notifications outside the application: it holds the
+// Channel interface the notification delivery pipeline sends through, the
+// channel implementations and the built-in message templates.
+package notify
+
+import (
+	"context"
+
+	"updatemanager/internal/models"
+)
+
+// Channel sends rendered notifications to one kind of destination
+type Channel interface {
+	// Name identifies the channel in notification delivery records
+	Name() models.NotificationChannel
+	// Send delivers a message. Errors are retried by the caller, so a
+	// channel should not retry on its own.
+	Send(ctx context.Context, msg *Message) error
+}
+
+// Message is a notification rendered for a channel
+type Message struct {
+	To       string // Channel-specific address, e.g. an email address
+	Subject  string
+	Text     string
+	HTML     string // Optional
+	Priority models.NotificationPriority
+
+	// Notification is the notification being sent, for channels that add
+	// structured fields of their own
+	Notification *models.Notification
+}
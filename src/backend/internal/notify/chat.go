@@ -0,0 +1,130 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"updatemanager/internal/models"
+)
+
+// ChatChannel posts notifications to chat incoming webhooks, in the Slack or
+// Teams message format. The message's To is the incoming webhook URL.
+type ChatChannel struct {
+	name   models.NotificationChannel
+	client *http.Client
+}
+
+// NewSlackChannel creates a channel posting Slack-style messages. client may
+// be nil, in which case a client with a 10 second timeout is used.
+func NewSlackChannel(client *http.Client) *ChatChannel {
+	return &ChatChannel{name: models.NotificationChannelSlack, client: newHTTPClient(client)}
+}
+
+// NewTeamsChannel creates a channel posting Teams-style message cards. client
+// may be nil, in which case a client with a 10 second timeout is used.
+func NewTeamsChannel(client *http.Client) *ChatChannel {
+	return &ChatChannel{name: models.NotificationChannelTeams, client: newHTTPClient(client)}
+}
+
+// Name implements Channel
+func (c *ChatChannel) Name() models.NotificationChannel {
+	return c.name
+}
+
+// Send implements Channel
+func (c *ChatChannel) Send(ctx context.Context, msg *Message) error {
+	if msg.To == "" {
+		return fmt.Errorf("missing incoming webhook URL")
+	}
+
+	var payload interface{}
+	if c.name == models.NotificationChannelTeams {
+		payload = teamsPayload(msg)
+	} else {
+		payload = slackPayload(msg)
+	}
+	return postJSON(ctx, c.client, msg.To, payload)
+}
+
+// chatBody returns the body chat messages show. Chat templates are short,
+// like in-app ones, so this is the rendered text without trailing space.
+func chatBody(msg *Message) string {
+	return strings.TrimSpace(msg.Text)
+}
+
+// chatFacts returns the notification fields listed under chat messages
+func chatFacts(msg *Message) [][2]string {
+	facts := [][2]string{{"Priority", string(msg.Priority)}}
+	if n := msg.Notification; n != nil {
+		for _, fact := range [][2]string{
+			{"Type", string(n.Type)},
+			{"Customer", n.CustomerID},
+			{"Product", n.ProductID},
+			{"Version", n.VersionID},
+			{"Deployment", n.DeploymentID},
+		} {
+			if fact[1] != "" {
+				facts = append(facts, fact)
+			}
+		}
+	}
+	return facts
+}
+
+// priorityColor returns the accent color of a priority, as hex without "#"
+func priorityColor(priority models.NotificationPriority) string {
+	switch priority {
+	case models.NotificationPriorityCritical:
+		return "D32F2F"
+	case models.NotificationPriorityHigh:
+		return "F57C00"
+	case models.NotificationPriorityLow:
+		return "9E9E9E"
+	default:
+		return "1976D2"
+	}
+}
+
+// slackPayload builds a Slack-style incoming webhook message. "text" is the
+// fallback shown in notifications; the attachment carries the details.
+func slackPayload(msg *Message) map[string]interface{} {
+	fields := make([]map[string]interface{}, 0)
+	for _, fact := range chatFacts(msg) {
+		fields = append(fields, map[string]interface{}{"title": fact[0], "value": fact[1], "short": true})
+	}
+	return map[string]interface{}{
+		"text": fmt.Sprintf("*%s*", slackEscape(msg.Subject)),
+		"attachments": []map[string]interface{}{{
+			"color":    "#" + priorityColor(msg.Priority),
+			"fallback": msg.Subject,
+			"text":     slackEscape(chatBody(msg)),
+			"fields":   fields,
+		}},
+	}
+}
+
+// slackEscape escapes the characters Slack treats as markup
+func slackEscape(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
+
+// teamsPayload builds a Teams-style incoming webhook message card
+func teamsPayload(msg *Message) map[string]interface{} {
+	facts := make([]map[string]string, 0)
+	for _, fact := range chatFacts(msg) {
+		facts = append(facts, map[string]string{"name": fact[0], "value": fact[1]})
+	}
+	return map[string]interface{}{
+		"@type":      "MessageCard",
+		"@context":   "https://schema.org/extensions",
+		"summary":    msg.Subject,
+		"themeColor": priorityColor(msg.Priority),
+		"title":      msg.Subject,
+		"sections": []map[string]interface{}{{
+			"text":  chatBody(msg),
+			"facts": facts,
+		}},
+	}
+}
@@ -0,0 +1,160 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"updatemanager/internal/models"
+)
+
+// recordingServer is a local stand-in for chat and incident endpoints that
+// records the JSON bodies it receives and answers with a fixed status
+func recordingServer(t *testing.T, status int) (*httptest.Server, chan map[string]interface{}) {
+	bodies := make(chan map[string]interface{}, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("Expected JSON content type, got %q", r.Header.Get("Content-Type"))
+		}
+		raw, _ := io.ReadAll(r.Body)
+		var body map[string]interface{}
+		if err := json.Unmarshal(raw, &body); err != nil {
+			t.Errorf("Invalid JSON body: %v", err)
+		}
+		bodies <- body
+		w.WriteHeader(status)
+		w.Write([]byte("rejected by stand-in"))
+	}))
+	t.Cleanup(server.Close)
+	return server, bodies
+}
+
+func testChatMessage(to string) *Message {
+	return &Message{
+		To:       to,
+		Subject:  "Security release 2.1.1 for <Orders>",
+		Text:     "Please upgrade\n",
+		Priority: models.NotificationPriorityCritical,
+		Notification: &models.Notification{
+			ID:         primitive.NewObjectID(),
+			Type:       models.NotificationTypeSecurityRelease,
+			CustomerID: "cust-1",
+			ProductID:  "orders",
+		},
+	}
+}
+
+func TestChatChannel_Slack(t *testing.T) {
+	server, bodies := recordingServer(t, http.StatusOK)
+	channel := NewSlackChannel(nil)
+	if channel.Name() != models.NotificationChannelSlack {
+		t.Errorf("Expected slack channel, got %s", channel.Name())
+	}
+
+	if err := channel.Send(context.Background(), testChatMessage(server.URL)); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+
+	body := <-bodies
+	if body["text"] != "*Security release 2.1.1 for &lt;Orders&gt;*" {
+		t.Errorf("Unexpected text %q", body["text"])
+	}
+	attachment := body["attachments"].([]interface{})[0].(map[string]interface{})
+	if attachment["color"] != "#D32F2F" {
+		t.Errorf("Expected critical color, got %v", attachment["color"])
+	}
+	if attachment["text"] != "Please upgrade" {
+		t.Errorf("Expected the trimmed text as body, got %v", attachment["text"])
+	}
+	if fields := attachment["fields"].([]interface{}); len(fields) != 4 {
+		t.Errorf("Expected priority, type, customer and product fields, got %v", fields)
+	}
+}
+
+func TestChatChannel_Teams(t *testing.T) {
+	server, bodies := recordingServer(t, http.StatusOK)
+	channel := NewTeamsChannel(nil)
+	if channel.Name() != models.NotificationChannelTeams {
+		t.Errorf("Expected teams channel, got %s", channel.Name())
+	}
+
+	if err := channel.Send(context.Background(), testChatMessage(server.URL)); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+
+	body := <-bodies
+	if body["@type"] != "MessageCard" || body["themeColor"] != "D32F2F" {
+		t.Errorf("Unexpected card %v", body)
+	}
+	if body["title"] != "Security release 2.1.1 for <Orders>" {
+		t.Errorf("Unexpected title %q", body["title"])
+	}
+}
+
+func TestChatChannel_RejectedResponse(t *testing.T) {
+	server, bodies := recordingServer(t, http.StatusBadRequest)
+
+	err := NewSlackChannel(nil).Send(context.Background(), testChatMessage(server.URL))
+	<-bodies
+	if err == nil {
+		t.Fatal("Expected an error for a 400 response")
+	}
+	if !strings.Contains(err.Error(), "400") || !strings.Contains(err.Error(), "rejected by stand-in") {
+		t.Errorf("Expected status and body in error, got %v", err)
+	}
+
+	if err := NewSlackChannel(nil).Send(context.Background(), testChatMessage("")); err == nil {
+		t.Error("Expected an error without an incoming webhook URL")
+	}
+}
+
+func TestIncidentChannel_PagerDuty(t *testing.T) {
+	server, bodies := recordingServer(t, http.StatusAccepted)
+	channel := NewPagerDutyChannel(server.URL, nil)
+	if channel.Name() != models.NotificationChannelPagerDuty {
+		t.Errorf("Expected pagerduty channel, got %s", channel.Name())
+	}
+
+	msg := testChatMessage("routing-key-1")
+	if err := channel.Send(context.Background(), msg); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+
+	body := <-bodies
+	if body["routing_key"] != "routing-key-1" || body["event_action"] != "trigger" {
+		t.Errorf("Unexpected event %v", body)
+	}
+	if body["dedup_key"] != "updatemanager-notification-"+msg.Notification.ID.Hex() {
+		t.Errorf("Expected dedup key per notification, got %v", body["dedup_key"])
+	}
+	payload := body["payload"].(map[string]interface{})
+	if payload["severity"] != "critical" || payload["summary"] != msg.Subject {
+		t.Errorf("Unexpected payload %v", payload)
+	}
+}
+
+func TestIncidentSeverity(t *testing.T) {
+	tests := map[models.NotificationPriority]string{
+		models.NotificationPriorityCritical: "critical",
+		models.NotificationPriorityHigh:     "error",
+		models.NotificationPriorityNormal:   "warning",
+		models.NotificationPriorityLow:      "info",
+	}
+	for priority, want := range tests {
+		if got := incidentSeverity(priority); got != want {
+			t.Errorf("incidentSeverity(%s) = %s, want %s", priority, got, want)
+		}
+	}
+}
+
+func TestNewPagerDutyChannel_DefaultURL(t *testing.T) {
+	if channel := NewPagerDutyChannel("", nil); channel.eventsURL != DefaultPagerDutyEventsURL {
+		t.Errorf("Expected default events URL, got %s", channel.eventsURL)
+	}
+}
@@ -0,0 +1,164 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+
+	"updatemanager/internal/models"
+)
+
+// smtpSink is a minimal SMTP server that records the messages it receives
+type smtpSink struct {
+	listener net.Listener
+	messages chan string
+}
+
+func newSMTPSink(t *testing.T) *smtpSink {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	sink := &smtpSink{listener: listener, messages: make(chan string, 10)}
+	go sink.serve()
+	return sink
+}
+
+func (s *smtpSink) port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+func (s *smtpSink) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *smtpSink) handle(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+
+	reply("220 localhost ESMTP sink")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		command := strings.ToUpper(strings.TrimSpace(line))
+		switch {
+		case strings.HasPrefix(command, "EHLO"), strings.HasPrefix(command, "HELO"):
+			reply("250 localhost")
+		case strings.HasPrefix(command, "DATA"):
+			reply("354 end with <CRLF>.<CRLF>")
+			var data strings.Builder
+			for {
+				line, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if line == ".\r\n" {
+					break
+				}
+				data.WriteString(line)
+			}
+			s.messages <- data.String()
+			reply("250 queued")
+		case strings.HasPrefix(command, "QUIT"):
+			reply("221 bye")
+			return
+		default:
+			reply("250 ok")
+		}
+	}
+}
+
+func TestEmailChannel_Send(t *testing.T) {
+	sink := newSMTPSink(t)
+	defer sink.listener.Close()
+
+	channel, err := NewEmailChannel(SMTPConfig{Host: "127.0.0.1", Port: sink.port(), From: "Update Manager <updates@example.com>"})
+	if err != nil {
+		t.Fatalf("NewEmailChannel() error = %v", err)
+	}
+
+	err = channel.Send(context.Background(), &Message{
+		To:       "ops@customer.example",
+		Subject:  "New version available for hyworks",
+		Text:     "A new version of hyworks is available.",
+		HTML:     "<p>A new version of <strong>hyworks</strong> is available.</p>",
+		Priority: models.NotificationPriorityHigh,
+	})
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	message := <-sink.messages
+	for _, want := range []string{
+		"To: <ops@customer.example>",
+		"Subject: New version available for hyworks",
+		"Content-Type: multipart/alternative",
+		"Importance: high",
+		"A new version of hyworks is available.",
+		"<strong>hyworks</strong>",
+	} {
+		if !strings.Contains(message, want) {
+			t.Errorf("Expected the email to contain %q, got:\n%s", want, message)
+		}
+	}
+}
+
+func TestEmailChannel_SendFailure(t *testing.T) {
+	// Nothing listens on a closed listener's port
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	port := listener.Addr().(*net.TCPAddr).Port
+	listener.Close()
+
+	channel, err := NewEmailChannel(SMTPConfig{Host: "127.0.0.1", Port: port, From: "updates@example.com"})
+	if err != nil {
+		t.Fatalf("NewEmailChannel() error = %v", err)
+	}
+	if err := channel.Send(context.Background(), &Message{To: "ops@customer.example", Subject: "s", Text: "t"}); err == nil {
+		t.Error("Expected sending without a server to fail")
+	}
+	if err := channel.Send(context.Background(), &Message{To: "not an address", Subject: "s", Text: "t"}); err == nil {
+		t.Error("Expected an invalid recipient to fail")
+	}
+}
+
+func TestNewEmailChannel_InvalidConfig(t *testing.T) {
+	for _, cfg := range []SMTPConfig{
+		{Port: 25, From: "updates@example.com"},
+		{Host: "localhost", Port: 25, From: "not an address"},
+		{Host: "localhost", From: "updates@example.com"},
+	} {
+		if _, err := NewEmailChannel(cfg); err == nil {
+			t.Errorf("Expected config %+v to be rejected", cfg)
+		}
+	}
+}
+
+func TestSMTPConfigFromEnv(t *testing.T) {
+	t.Setenv("SMTP_HOST", "")
+	if _, ok := SMTPConfigFromEnv(); ok {
+		t.Error("Expected email disabled without SMTP_HOST")
+	}
+
+	t.Setenv("SMTP_HOST", "smtp.example.com")
+	t.Setenv("SMTP_PORT", strconv.Itoa(2525))
+	t.Setenv("SMTP_FROM", "updates@example.com")
+	cfg, ok := SMTPConfigFromEnv()
+	if !ok || cfg.Host != "smtp.example.com" || cfg.Port != 2525 || cfg.From != "updates@example.com" {
+		t.Errorf("SMTPConfigFromEnv() = %+v, %v", cfg, ok)
+	}
+}
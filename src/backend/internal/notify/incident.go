@@ -0,0 +1,88 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"updatemanager/internal/models"
+)
+
+// DefaultPagerDutyEventsURL is the PagerDuty Events API v2 endpoint
+const DefaultPagerDutyEventsURL = "https://events.pagerduty.com/v2/enqueue"
+
+// IncidentChannel triggers PagerDuty-style incidents. The message's To is the
+// integration's routing key. Incidents are deduplicated per notification, so
+// a retried send does not open a second incident.
+type IncidentChannel struct {
+	eventsURL string
+	client    *http.Client
+}
+
+// NewPagerDutyChannel creates an incident channel posting to eventsURL, or to
+// DefaultPagerDutyEventsURL when empty. client may be nil, in which case a
+// client with a 10 second timeout is used.
+func NewPagerDutyChannel(eventsURL string, client *http.Client) *IncidentChannel {
+	if eventsURL == "" {
+		eventsURL = DefaultPagerDutyEventsURL
+	}
+	return &IncidentChannel{eventsURL: eventsURL, client: newHTTPClient(client)}
+}
+
+// Name implements Channel
+func (c *IncidentChannel) Name() models.NotificationChannel {
+	return models.NotificationChannelPagerDuty
+}
+
+// Send implements Channel
+func (c *IncidentChannel) Send(ctx context.Context, msg *Message) error {
+	if msg.To == "" {
+		return fmt.Errorf("missing routing key")
+	}
+	return postJSON(ctx, c.client, c.eventsURL, incidentPayload(msg))
+}
+
+// incidentPayload builds a PagerDuty Events API v2 trigger event
+func incidentPayload(msg *Message) map[string]interface{} {
+	details := map[string]string{"message": chatBody(msg)}
+	for _, fact := range chatFacts(msg) {
+		details[fact[0]] = fact[1]
+	}
+
+	event := map[string]interface{}{
+		"routing_key":  msg.To,
+		"event_action": "trigger",
+		"payload": map[string]interface{}{
+			"summary":        truncate(msg.Subject, 1024),
+			"source":         "updatemanager",
+			"severity":       incidentSeverity(msg.Priority),
+			"custom_details": details,
+		},
+	}
+	if msg.Notification != nil && !msg.Notification.ID.IsZero() {
+		event["dedup_key"] = "updatemanager-notification-" + msg.Notification.ID.Hex()
+	}
+	return event
+}
+
+// incidentSeverity maps a notification priority to an incident severity
+func incidentSeverity(priority models.NotificationPriority) string {
+	switch priority {
+	case models.NotificationPriorityCritical:
+		return "critical"
+	case models.NotificationPriorityHigh:
+		return "error"
+	case models.NotificationPriorityNormal:
+		return "warning"
+	default:
+		return "info"
+	}
+}
+
+// truncate shortens s to at most n bytes
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
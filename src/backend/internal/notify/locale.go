@@ -0,0 +1,47 @@
+package notify
+
+import (
+	"regexp"
+	"strings"
+)
+
+// DefaultLocale is the locale of the built-in templates and the last one
+// tried when rendering
+const DefaultLocale = "en"
+
+// localePattern matches BCP 47 style tags such as "en", "de-ch" or "zh-hant-tw"
+var localePattern = regexp.MustCompile(`^[a-z]{2,3}(-[a-z0-9]{2,8})*$`)
+
+// NormalizeLocale lowercases a locale tag and turns underscores into hyphens,
+// so "de_CH" and "de-CH" both become "de-ch". It reports whether the result
+// is a valid tag; the empty locale is valid and means the default.
+func NormalizeLocale(locale string) (string, bool) {
+	locale = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(locale), "_", "-"))
+	if locale == "" {
+		return "", true
+	}
+	return locale, localePattern.MatchString(locale)
+}
+
+// LocaleFallbacks returns the locales to try for a preferred locale, most
+// specific first: "de-ch" gives "de-ch", "de" and then DefaultLocale
+func LocaleFallbacks(locale string) []string {
+	locale, ok := NormalizeLocale(locale)
+	if !ok {
+		locale = ""
+	}
+
+	var locales []string
+	for locale != "" {
+		locales = append(locales, locale)
+		i := strings.LastIndex(locale, "-")
+		if i < 0 {
+			break
+		}
+		locale = locale[:i]
+	}
+	if len(locales) == 0 || locales[len(locales)-1] != DefaultLocale {
+		locales = append(locales, DefaultLocale)
+	}
+	return locales
+}
@@ -0,0 +1,228 @@
+package notify
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"path"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"updatemanager/internal/models"
+)
+
+// defaultTemplate renders notification types without templates of their own
+const defaultTemplate = "default"
+
+//go:embed templates/*/*.tmpl
+var builtinTemplateFS embed.FS
+
+// TemplateData is what notification templates render. Everything but
+// Notification may be missing, so templates should guard optional values
+// with "with" or "if".
+type TemplateData struct {
+	Notification *models.Notification
+	Customer     *models.Customer
+	CustomerName string
+	Version      *models.Version
+	ReleaseNotes *models.ReleaseNotes
+	Deployments  []*models.Deployment
+	Locale       string
+	Digest       *DigestData // Digest emails only
+}
+
+// DigestData is what a digest email batches: a recipient's notifications
+// since the last digest, grouped per product and version
+type DigestData struct {
+	Frequency models.NotificationDigestFrequency
+	Count     int // Notifications in all groups
+	Groups    []DigestGroup
+}
+
+// DigestGroup holds a digest's notifications about one product version.
+// Notifications about no version form a group of their product.
+type DigestGroup struct {
+	ProductID     string
+	VersionID     string
+	Version       *models.Version // nil when unknown or deleted
+	Notifications []*models.Notification
+}
+
+// templateFuncs are the functions available to every template
+var templateFuncs = map[string]interface{}{
+	"join": strings.Join,
+	"date": func(t time.Time) string { return t.Format("2006-01-02") },
+}
+
+// Template is a parsed notification template: a subject and a text body, and
+// for email an optional HTML body
+type Template struct {
+	text *texttemplate.Template // Defines "subject" and "text"
+	html *htmltemplate.Template // Defines "html"; nil sends text only
+}
+
+// ParseTemplate parses a template from its sources. subject and text use
+// text/template, html uses html/template and may be empty.
+func ParseTemplate(subject, text, html string) (*Template, error) {
+	t := texttemplate.New("subject").Funcs(templateFuncs)
+	if _, err := t.Parse(subject); err != nil {
+		return nil, fmt.Errorf("subject: %w", err)
+	}
+	if _, err := t.New("text").Parse(text); err != nil {
+		return nil, fmt.Errorf("text: %w", err)
+	}
+
+	tmpl := &Template{text: t}
+	if html != "" {
+		h, err := htmltemplate.New("html").Funcs(templateFuncs).Parse(html)
+		if err != nil {
+			return nil, fmt.Errorf("html: %w", err)
+		}
+		tmpl.html = h
+	}
+	return tmpl, nil
+}
+
+// Render renders a notification's subject, text and, if the template has
+// one, HTML body
+func (t *Template) Render(data *TemplateData) (*Message, error) {
+	var subject, text, html bytes.Buffer
+	if err := t.text.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return nil, fmt.Errorf("failed to render subject: %w", err)
+	}
+	if err := t.text.ExecuteTemplate(&text, "text", data); err != nil {
+		return nil, fmt.Errorf("failed to render text: %w", err)
+	}
+	if t.html != nil {
+		if err := t.html.ExecuteTemplate(&html, "html", data); err != nil {
+			return nil, fmt.Errorf("failed to render HTML: %w", err)
+		}
+	}
+
+	return &Message{
+		// Subjects are one line whatever the template produced
+		Subject:      strings.Join(strings.Fields(subject.String()), " "),
+		Text:         text.String(),
+		HTML:         html.String(),
+		Priority:     data.Notification.Priority,
+		Notification: data.Notification,
+	}, nil
+}
+
+// Renderer holds the built-in templates, in DefaultLocale. They live in
+// templates/<channel>: a "<type>.txt.tmpl" defining "subject" and "text" per
+// notification type and, for email, a "<type>.html.tmpl" defining "html".
+// Types without templates use "default".
+type Renderer struct {
+	builtin map[models.NotificationChannel]map[string]*Template
+}
+
+// NewRenderer parses the built-in templates
+func NewRenderer() (*Renderer, error) {
+	r := &Renderer{builtin: make(map[models.NotificationChannel]map[string]*Template)}
+
+	paths, err := fs.Glob(builtinTemplateFS, "templates/*/*.txt.tmpl")
+	if err != nil {
+		return nil, err
+	}
+	for _, textPath := range paths {
+		channel := models.NotificationChannel(path.Base(path.Dir(textPath)))
+		name := strings.TrimSuffix(path.Base(textPath), ".txt.tmpl")
+
+		text, err := texttemplate.New(path.Base(textPath)).Funcs(templateFuncs).ParseFS(builtinTemplateFS, textPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", textPath, err)
+		}
+		if text.Lookup("subject") == nil || text.Lookup("text") == nil {
+			return nil, fmt.Errorf("%s must define subject and text", textPath)
+		}
+		tmpl := &Template{text: text}
+
+		htmlPath := strings.TrimSuffix(textPath, ".txt.tmpl") + ".html.tmpl"
+		if _, err := fs.Stat(builtinTemplateFS, htmlPath); err == nil {
+			html, err := htmltemplate.New(path.Base(htmlPath)).Funcs(templateFuncs).ParseFS(builtinTemplateFS, htmlPath)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse %s: %w", htmlPath, err)
+			}
+			tmpl.html = html
+		}
+
+		if r.builtin[channel] == nil {
+			r.builtin[channel] = make(map[string]*Template)
+		}
+		r.builtin[channel][name] = tmpl
+	}
+
+	for _, channel := range []models.NotificationChannel{models.NotificationChannelInApp, models.NotificationChannelEmail} {
+		if r.builtin[channel][defaultTemplate] == nil {
+			return nil, fmt.Errorf("missing default %s templates", channel)
+		}
+	}
+	return r, nil
+}
+
+// Builtin returns the built-in template for a channel and notification type.
+// Channels without templates of their own, such as chat, use the in-app ones.
+func (r *Renderer) Builtin(channel models.NotificationChannel, notificationType models.NotificationType) *Template {
+	templates, ok := r.builtin[channel]
+	if !ok {
+		templates = r.builtin[models.NotificationChannelInApp]
+	}
+	if tmpl, ok := templates[string(notificationType)]; ok {
+		return tmpl
+	}
+	return templates[defaultTemplate]
+}
+
+// SampleTemplateData returns data with every variable set, for previews and
+// for checking templates before they are saved
+func SampleTemplateData(notificationType models.NotificationType, locale string) *TemplateData {
+	now := time.Now()
+	version := &models.Version{
+		ID:            primitive.NewObjectID(),
+		ProductID:     "sample-product",
+		VersionNumber: "2.1.0",
+		ReleaseDate:   now,
+		ReleaseType:   models.ReleaseTypeFeature,
+		ReleaseNotes: &models.ReleaseNotes{
+			VersionInfo: models.VersionInfoSection{VersionNumber: "2.1.0", ReleaseDate: now, ReleaseType: models.ReleaseTypeFeature},
+			WhatsNew:    []string{"Faster updates", "New dashboard"},
+		},
+	}
+	customer := &models.Customer{CustomerID: "sample-customer", Name: "Sample Customer", Email: "ops@example.com", PreferredLocale: locale}
+	notification := &models.Notification{
+		Type:         notificationType,
+		RecipientID:  customer.CustomerID,
+		CustomerID:   customer.CustomerID,
+		ProductID:    version.ProductID,
+		VersionID:    version.ID.Hex(),
+		DeploymentID: "sample-deployment",
+		Title:        "Sample notification",
+		Message:      "This is a sample notification message.",
+		Priority:     models.NotificationPriorityNormal,
+		CreatedAt:    now,
+	}
+	return &TemplateData{
+		Notification: notification,
+		Customer:     customer,
+		CustomerName: customer.Name,
+		Version:      version,
+		ReleaseNotes: version.ReleaseNotes,
+		Deployments: []*models.Deployment{
+			{DeploymentID: "sample-deployment", ProductID: version.ProductID, DeploymentType: models.DeploymentTypeProduction, InstalledVersion: "2.0.0"},
+		},
+		Locale: locale,
+		Digest: &DigestData{
+			Frequency: models.NotificationDigestDaily,
+			Count:     1,
+			Groups: []DigestGroup{
+				{ProductID: version.ProductID, VersionID: version.ID.Hex(), Version: version, Notifications: []*models.Notification{notification}},
+			},
+		},
+	}
+}
@@ -0,0 +1,202 @@
+package notify
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"updatemanager/internal/models"
+)
+
+func TestRenderer_BuiltinEmail(t *testing.T) {
+	renderer, err := NewRenderer()
+	if err != nil {
+		t.Fatalf("NewRenderer() error = %v", err)
+	}
+
+	msg, err := renderer.Builtin(models.NotificationChannelEmail, models.NotificationTypeNewVersion).Render(&TemplateData{
+		Notification: &models.Notification{
+			Type:      models.NotificationTypeNewVersion,
+			ProductID: "hyworks",
+			Title:     "New Version Available",
+			Message:   "Affected deployments: hyworks (production)",
+			Priority:  models.NotificationPriorityHigh,
+		},
+		CustomerName: "Acme <Ops>",
+	})
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if msg.Subject != "New version available for hyworks" {
+		t.Errorf("Unexpected subject %q", msg.Subject)
+	}
+	if !strings.Contains(msg.Text, "Hello Acme <Ops>,") || !strings.Contains(msg.Text, "hyworks (production)") {
+		t.Errorf("Unexpected text body:\n%s", msg.Text)
+	}
+	// HTML escapes the variables; text does not
+	if !strings.Contains(msg.HTML, "Acme &lt;Ops&gt;") {
+		t.Errorf("Expected the HTML body to escape the customer name:\n%s", msg.HTML)
+	}
+	if msg.Priority != models.NotificationPriorityHigh {
+		t.Errorf("Expected the notification priority, got %q", msg.Priority)
+	}
+}
+
+func TestRenderer_BuiltinDigest(t *testing.T) {
+	renderer, err := NewRenderer()
+	if err != nil {
+		t.Fatalf("NewRenderer() error = %v", err)
+	}
+
+	data := SampleTemplateData(models.NotificationTypeDigest, "en")
+	data.Digest.Groups = append(data.Digest.Groups, DigestGroup{
+		ProductID:     "orders",
+		Notifications: []*models.Notification{{Title: "EOL warning", Message: "Orders 1.x reaches end of life."}},
+	})
+	data.Digest.Count = 2
+
+	msg, err := renderer.Builtin(models.NotificationChannelEmail, models.NotificationTypeDigest).Render(data)
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if msg.Subject != "Your daily Update Manager digest: 2 notifications" {
+		t.Errorf("Unexpected subject %q", msg.Subject)
+	}
+	for _, want := range []string{"sample-product 2.1.0", "Sample notification: This is a sample notification message.", "orders\n", "EOL warning: Orders 1.x reaches end of life."} {
+		if !strings.Contains(msg.Text, want) {
+			t.Errorf("Expected %q in text body:\n%s", want, msg.Text)
+		}
+	}
+	if !strings.Contains(msg.HTML, "<h3>orders</h3>") {
+		t.Errorf("Expected a heading per group in HTML body:\n%s", msg.HTML)
+	}
+}
+
+func TestRenderer_BuiltinDefault(t *testing.T) {
+	renderer, err := NewRenderer()
+	if err != nil {
+		t.Fatalf("NewRenderer() error = %v", err)
+	}
+
+	msg, err := renderer.Builtin(models.NotificationChannelEmail, models.NotificationTypeEOLWarning).Render(&TemplateData{
+		Notification: &models.Notification{
+			Type:    models.NotificationTypeEOLWarning,
+			Title:   "End of Life\nApproaching",
+			Message: "Version 1.0 reaches end of life next month.",
+		},
+	})
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if msg.Subject != "End of Life Approaching" {
+		t.Errorf("Expected a one-line subject from the title, got %q", msg.Subject)
+	}
+	if !strings.Contains(msg.Text, "reaches end of life") {
+		t.Errorf("Unexpected text body:\n%s", msg.Text)
+	}
+}
+
+func TestRenderer_BuiltinInApp(t *testing.T) {
+	renderer, err := NewRenderer()
+	if err != nil {
+		t.Fatalf("NewRenderer() error = %v", err)
+	}
+
+	data := SampleTemplateData(models.NotificationTypeNewVersion, "")
+	data.Deployments = append(data.Deployments, &models.Deployment{ProductID: "sample-product", DeploymentType: models.DeploymentTypeUAT})
+
+	msg, err := renderer.Builtin(models.NotificationChannelInApp, models.NotificationTypeNewVersion).Render(data)
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if msg.Subject != "New Version Available" {
+		t.Errorf("Unexpected title %q", msg.Subject)
+	}
+	want := "A new version (2.1.0) is available for product sample-product. Affected deployments: sample-product (production), sample-product (uat)"
+	if msg.Text != want {
+		t.Errorf("Unexpected message:\n got %q\nwant %q", msg.Text, want)
+	}
+	if msg.HTML != "" {
+		t.Errorf("Expected no HTML for in-app templates, got %q", msg.HTML)
+	}
+
+	// Chat channels have no templates of their own and use the in-app ones
+	if renderer.Builtin(models.NotificationChannelSlack, models.NotificationTypeNewVersion) != renderer.Builtin(models.NotificationChannelInApp, models.NotificationTypeNewVersion) {
+		t.Error("Expected slack to use the in-app template")
+	}
+}
+
+func TestParseTemplate(t *testing.T) {
+	tmpl, err := ParseTemplate(
+		"Neue Version {{.Version.VersionNumber}}",
+		"Hallo {{.CustomerName}}, neu: {{join .ReleaseNotes.WhatsNew \", \"}}",
+		"<p>{{.CustomerName}}</p>",
+	)
+	if err != nil {
+		t.Fatalf("ParseTemplate() error = %v", err)
+	}
+
+	data := SampleTemplateData(models.NotificationTypeNewVersion, "de")
+	data.CustomerName = "<Acme>"
+	msg, err := tmpl.Render(data)
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if msg.Subject != "Neue Version 2.1.0" || msg.Text != "Hallo <Acme>, neu: Faster updates, New dashboard" || msg.HTML != "<p>&lt;Acme&gt;</p>" {
+		t.Errorf("Unexpected message %+v", msg)
+	}
+
+	if _, err := ParseTemplate("{{.Version", "text", ""); err == nil || !strings.HasPrefix(err.Error(), "subject:") {
+		t.Errorf("Expected a subject parse error, got %v", err)
+	}
+
+	tmpl, err = ParseTemplate("subject", "{{.Missing}}", "")
+	if err != nil {
+		t.Fatalf("ParseTemplate() error = %v", err)
+	}
+	if _, err := tmpl.Render(data); err == nil {
+		t.Error("Expected an error for an unknown variable")
+	}
+}
+
+func TestLocaleFallbacks(t *testing.T) {
+	tests := []struct {
+		locale string
+		want   []string
+	}{
+		{"", []string{"en"}},
+		{"en", []string{"en"}},
+		{"de", []string{"de", "en"}},
+		{"de_CH", []string{"de-ch", "de", "en"}},
+		{"en-GB", []string{"en-gb", "en"}},
+		{"not a locale", []string{"en"}},
+	}
+
+	for _, tt := range tests {
+		if got := LocaleFallbacks(tt.locale); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("LocaleFallbacks(%q) = %v, want %v", tt.locale, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeLocale(t *testing.T) {
+	tests := []struct {
+		locale string
+		want   string
+		valid  bool
+	}{
+		{"", "", true},
+		{"FR", "fr", true},
+		{" pt_BR ", "pt-br", true},
+		{"zh-Hant-TW", "zh-hant-tw", true},
+		{"english", "english", false},
+		{"de-", "de-", false},
+	}
+
+	for _, tt := range tests {
+		got, valid := NormalizeLocale(tt.locale)
+		if got != tt.want || valid != tt.valid {
+			t.Errorf("NormalizeLocale(%q) = %q, %v; want %q, %v", tt.locale, got, valid, tt.want, tt.valid)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"updatemanager/internal/models"
+)
+
+// AuditCheckpointRepository handles signed audit chain checkpoint database
+// operations. Checkpoints are append-only: there is no update or delete.
+type AuditCheckpointRepository struct {
+	collection *mongo.Collection
+}
+
+// NewAuditCheckpointRepository creates a new audit checkpoint repository
+func NewAuditCheckpointRepository(collection *mongo.Collection) *AuditCheckpointRepository {
+	return &AuditCheckpointRepository{
+		collection: collection,
+	}
+}
+
+// Create stores a signed checkpoint
+func (r *AuditCheckpointRepository) Create(ctx context.Context, checkpoint *models.AuditCheckpoint) error {
+	_, err := r.collection.InsertOne(ctx, checkpoint)
+	if err != nil {
+		return fmt.Errorf("failed to create audit checkpoint: %w", err)
+	}
+	return nil
+}
+
+// Latest retrieves the checkpoint with the highest sequence, or nil when
+// there is none
+func (r *AuditCheckpointRepository) Latest(ctx context.Context) (*models.AuditCheckpoint, error) {
+	opts := options.FindOne().SetSort(bson.D{{Key: "sequence", Value: -1}})
+	var checkpoint models.AuditCheckpoint
+	err := r.collection.FindOne(ctx, bson.M{}, opts).Decode(&checkpoint)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get audit checkpoint: %w", err)
+	}
+	return &checkpoint, nil
+}
+
+// List retrieves checkpoints with optional filters
+func (r *AuditCheckpointRepository) List(ctx context.Context, filter bson.M, opts *options.FindOptions) ([]*models.AuditCheckpoint, error) {
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit checkpoints: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var checkpoints []*models.AuditCheckpoint
+	if err := cursor.All(ctx, &checkpoints); err != nil {
+		return nil, fmt.Errorf("failed to decode audit checkpoints: %w", err)
+	}
+
+	return checkpoints, nil
+}
+
+// Count returns the count of checkpoints matching the filter
+func (r *AuditCheckpointRepository) Count(ctx context.Context, filter bson.M) (int64, error) {
+	count, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count audit checkpoints: %w", err)
+	}
+	return count, nil
+}
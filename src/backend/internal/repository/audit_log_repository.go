@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"fmt"
+	"sync"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
@@ -10,14 +11,28 @@ import (
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 
+	"updatemanager/internal/auditchain"
 	"updatemanager/internal/models"
 )
 
-// AuditLogRepository handles audit log database operations
+// AuditLogRepository handles audit log database operations. Audit logs are
+// append-only: entries are chained by hash when created and there is no way
+// to change or delete them.
 type AuditLogRepository struct {
 	collection *mongo.Collection
+	// appendMu orders appends from this process; appends from other
+	// processes are ordered by the unique sequence index
+	appendMu sync.Mutex
 }
 
+// AuditLogSequenceIndex is the name of the unique index on the chain
+// sequence; the database scripts create it too
+const AuditLogSequenceIndex = "sequence_unique"
+
+// auditLogAppendAttempts bounds the retries of an append that lost the race
+// for its sequence to another process
+const auditLogAppendAttempts = 5
+
 // NewAuditLogRepository creates a new audit log repository
 func NewAuditLogRepository(collection *mongo.Collection) *AuditLogRepository {
 	return &AuditLogRepository{
@@ -25,23 +40,49 @@ func NewAuditLogRepository(collection *mongo.Collection) *AuditLogRepository {
 	}
 }
 
-// Create creates a new audit log entry in the database
+// Create appends a new audit log entry to the hash chain in the database
 func (r *AuditLogRepository) Create(ctx context.Context, log *models.AuditLog) error {
-	// Set timestamp
+	// Set ID and timestamp, both part of the hashed content
+	if log.ID.IsZero() {
+		log.ID = primitive.NewObjectID()
+	}
 	log.Timestamp = time.Now()
 
-	// Insert audit log
-	result, err := r.collection.InsertOne(ctx, log)
-	if err != nil {
-		return fmt.Errorf("failed to create audit log: %w", err)
-	}
+	r.appendMu.Lock()
+	defer r.appendMu.Unlock()
+
+	for attempt := 1; ; attempt++ {
+		prev, err := r.Last(ctx)
+		if err != nil {
+			return err
+		}
+		if err := auditchain.Seal(log, prev); err != nil {
+			return fmt.Errorf("failed to chain audit log: %w", err)
+		}
 
-	// Set the generated ID
-	if oid, ok := result.InsertedID.(primitive.ObjectID); ok {
-		log.ID = oid
+		_, err = r.collection.InsertOne(ctx, log)
+		if err == nil {
+			return nil
+		}
+		if !mongo.IsDuplicateKeyError(err) || attempt == auditLogAppendAttempts {
+			return fmt.Errorf("failed to create audit log: %w", err)
+		}
 	}
+}
 
-	return nil
+// Last retrieves the chained audit log with the highest sequence, or nil when
+// the chain is empty
+func (r *AuditLogRepository) Last(ctx context.Context) (*models.AuditLog, error) {
+	opts := options.FindOne().SetSort(bson.D{{Key: "sequence", Value: -1}})
+	var log models.AuditLog
+	err := r.collection.FindOne(ctx, bson.M{"sequence": bson.M{"$exists": true}}, opts).Decode(&log)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get last audit log: %w", err)
+	}
+	return &log, nil
 }
 
 // GetByID retrieves an audit log by its ID
@@ -57,6 +98,19 @@ func (r *AuditLogRepository) GetByID(ctx context.Context, id primitive.ObjectID)
 	return &log, nil
 }
 
+// GetBySequence retrieves the audit log at a position in the hash chain
+func (r *AuditLogRepository) GetBySequence(ctx context.Context, sequence int64) (*models.AuditLog, error) {
+	var log models.AuditLog
+	err := r.collection.FindOne(ctx, bson.M{"sequence": sequence}).Decode(&log)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("audit log not found")
+		}
+		return nil, fmt.Errorf("failed to get audit log: %w", err)
+	}
+	return &log, nil
+}
+
 // GetByResource retrieves audit logs for a specific resource
 func (r *AuditLogRepository) GetByResource(ctx context.Context, resourceType, resourceID string, opts *options.FindOptions) ([]*models.AuditLog, error) {
 	filter := bson.M{
@@ -134,16 +188,19 @@ func (r *AuditLogRepository) Count(ctx context.Context, filter bson.M) (int64, e
 	return count, nil
 }
 
-// Delete deletes an audit log by ID (rarely used, but available)
-func (r *AuditLogRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
-	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
-	if err != nil {
-		return fmt.Errorf("failed to delete audit log: %w", err)
+// EnsureSequenceIndex creates the unique index on the chain sequence, so two
+// entries can never take the same place in the chain. Entries written before
+// the chain existed have no sequence and are left out of the index.
+func (r *AuditLogRepository) EnsureSequenceIndex(ctx context.Context) error {
+	index := mongo.IndexModel{
+		Keys: bson.D{{Key: "sequence", Value: 1}},
+		Options: options.Index().
+			SetName(AuditLogSequenceIndex).
+			SetUnique(true).
+			SetPartialFilterExpression(bson.M{"sequence": bson.M{"$exists": true}}),
 	}
-
-	if result.DeletedCount == 0 {
-		return fmt.Errorf("audit log not found")
+	if _, err := r.collection.Indexes().CreateOne(ctx, index); err != nil {
+		return fmt.Errorf("failed to create audit log sequence index: %w", err)
 	}
-
 	return nil
 }
@@ -0,0 +1,290 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"updatemanager/internal/models"
+)
+
+// EndpointRepository handles endpoint database operations
+type EndpointRepository struct {
+	collection *mongo.Collection
+}
+
+// NewEndpointRepository creates a new endpoint repository
+func NewEndpointRepository(collection *mongo.Collection) *EndpointRepository {
+	return &EndpointRepository{
+		collection: collection,
+	}
+}
+
+// EndpointFilter represents filters for endpoint queries
+type EndpointFilter struct {
+	Search          string // Case-insensitive match on endpoint_id or hostname
+	EndpointIDs     []string
+	DeploymentID    primitive.ObjectID
+	TenantID        primitive.ObjectID
+	ProductID       string
+	OS              string
+	Architecture    string
+	AgentVersion    string
+	Status          models.EndpointStatus
+	Labels          map[string]string
+	HeartbeatBefore *time.Time // No heartbeat since this time (including never)
+}
+
+// Create creates a new endpoint in the database
+func (r *EndpointRepository) Create(ctx context.Context, endpoint *models.Endpoint) error {
+	// Set timestamps
+	now := time.Now()
+	endpoint.RegisteredAt = now
+	endpoint.UpdatedAt = now
+
+	// Insert endpoint
+	result, err := r.collection.InsertOne(ctx, endpoint)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return fmt.Errorf("endpoint with endpoint_id '%s' already exists", endpoint.EndpointID)
+		}
+		return fmt.Errorf("failed to create endpoint: %w", err)
+	}
+
+	// Set the generated ID
+	if oid, ok := result.InsertedID.(primitive.ObjectID); ok {
+		endpoint.ID = oid
+	}
+
+	return nil
+}
+
+// GetByID retrieves an endpoint by its ID
+func (r *EndpointRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*models.Endpoint, error) {
+	var endpoint models.Endpoint
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&endpoint)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("endpoint not found")
+		}
+		return nil, fmt.Errorf("failed to get endpoint: %w", err)
+	}
+	return &endpoint, nil
+}
+
+// GetByEndpointID retrieves an endpoint by its endpoint_id
+func (r *EndpointRepository) GetByEndpointID(ctx context.Context, endpointID string) (*models.Endpoint, error) {
+	var endpoint models.Endpoint
+	err := r.collection.FindOne(ctx, bson.M{"endpoint_id": endpointID}).Decode(&endpoint)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("endpoint not found")
+		}
+		return nil, fmt.Errorf("failed to get endpoint: %w", err)
+	}
+	return &endpoint, nil
+}
+
+// List retrieves endpoints with filters and pagination
+func (r *EndpointRepository) List(ctx context.Context, filter *EndpointFilter, pagination *Pagination) ([]*models.Endpoint, *PaginationInfo, error) {
+	bsonFilter := buildEndpointFilter(filter)
+
+	// Set up pagination
+	page := 1
+	limit := 20
+	if pagination != nil {
+		if pagination.Page > 0 {
+			page = pagination.Page
+		}
+		if pagination.Limit > 0 && pagination.Limit <= 100 {
+			limit = pagination.Limit
+		}
+	}
+
+	skip := (page - 1) * limit
+
+	// Count total documents
+	total, err := r.collection.CountDocuments(ctx, bsonFilter)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to count endpoints: %w", err)
+	}
+
+	// Find options
+	findOptions := options.Find()
+	findOptions.SetSkip(int64(skip))
+	findOptions.SetLimit(int64(limit))
+	findOptions.SetSort(bson.M{"hostname": 1})
+
+	// Execute query
+	cursor, err := r.collection.Find(ctx, bsonFilter, findOptions)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list endpoints: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var endpoints []*models.Endpoint
+	if err := cursor.All(ctx, &endpoints); err != nil {
+		return nil, nil, fmt.Errorf("failed to decode endpoints: %w", err)
+	}
+
+	// Calculate total pages
+	totalPages := total / int64(limit)
+	if total%int64(limit) > 0 {
+		totalPages++
+	}
+
+	paginationInfo := &PaginationInfo{
+		Page:       page,
+		Limit:      limit,
+		Total:      total,
+		TotalPages: totalPages,
+	}
+
+	return endpoints, paginationInfo, nil
+}
+
+// FindAll retrieves every endpoint matching the filter without pagination
+func (r *EndpointRepository) FindAll(ctx context.Context, filter *EndpointFilter) ([]*models.Endpoint, error) {
+	findOptions := options.Find().SetSort(bson.M{"last_heartbeat_at": 1})
+
+	cursor, err := r.collection.Find(ctx, buildEndpointFilter(filter), findOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find endpoints: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var endpoints []*models.Endpoint
+	if err := cursor.All(ctx, &endpoints); err != nil {
+		return nil, fmt.Errorf("failed to decode endpoints: %w", err)
+	}
+
+	return endpoints, nil
+}
+
+// Update updates an existing endpoint
+func (r *EndpointRepository) Update(ctx context.Context, id primitive.ObjectID, endpoint *models.Endpoint) error {
+	endpoint.UpdatedAt = time.Now()
+
+	filter := bson.M{"_id": id}
+	update := bson.M{"$set": endpoint}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("failed to update endpoint: %w", err)
+	}
+
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("endpoint not found")
+	}
+
+	return nil
+}
+
+// UpdateHeartbeat records a heartbeat and refreshes the given inventory fields
+func (r *EndpointRepository) UpdateHeartbeat(ctx context.Context, id primitive.ObjectID, at time.Time, inventory bson.M) error {
+	set := bson.M{
+		"last_heartbeat_at": at,
+		"updated_at":        time.Now(),
+	}
+	for field, value := range inventory {
+		set[field] = value
+	}
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": set})
+	if err != nil {
+		return fmt.Errorf("failed to update endpoint heartbeat: %w", err)
+	}
+
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("endpoint not found")
+	}
+
+	return nil
+}
+
+// Delete deletes an endpoint by ID
+func (r *EndpointRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return fmt.Errorf("failed to delete endpoint: %w", err)
+	}
+
+	if result.DeletedCount == 0 {
+		return fmt.Errorf("endpoint not found")
+	}
+
+	return nil
+}
+
+// Count returns the count of endpoints matching the filter
+func (r *EndpointRepository) Count(ctx context.Context, filter *EndpointFilter) (int64, error) {
+	count, err := r.collection.CountDocuments(ctx, buildEndpointFilter(filter))
+	if err != nil {
+		return 0, fmt.Errorf("failed to count endpoints: %w", err)
+	}
+	return count, nil
+}
+
+// buildEndpointFilter converts an EndpointFilter into a MongoDB filter
+func buildEndpointFilter(filter *EndpointFilter) bson.M {
+	bsonFilter := bson.M{}
+	if filter == nil {
+		return bsonFilter
+	}
+
+	if filter.Search != "" {
+		pattern := primitive.Regex{Pattern: regexp.QuoteMeta(filter.Search), Options: "i"}
+		bsonFilter["$or"] = []bson.M{
+			{"endpoint_id": pattern},
+			{"hostname": pattern},
+		}
+	}
+	if len(filter.EndpointIDs) > 0 {
+		bsonFilter["endpoint_id"] = bson.M{"$in": filter.EndpointIDs}
+	}
+	if !filter.DeploymentID.IsZero() {
+		bsonFilter["deployment_id"] = filter.DeploymentID
+	}
+	if !filter.TenantID.IsZero() {
+		bsonFilter["tenant_id"] = filter.TenantID
+	}
+	if filter.ProductID != "" {
+		bsonFilter["product_id"] = filter.ProductID
+	}
+	if filter.OS != "" {
+		bsonFilter["os"] = filter.OS
+	}
+	if filter.Architecture != "" {
+		bsonFilter["architecture"] = filter.Architecture
+	}
+	if filter.AgentVersion != "" {
+		bsonFilter["agent_version"] = filter.AgentVersion
+	}
+	if filter.Status != "" {
+		bsonFilter["status"] = filter.Status
+	}
+	for key, value := range filter.Labels {
+		bsonFilter["labels."+key] = value
+	}
+	if filter.HeartbeatBefore != nil {
+		// Endpoints that never sent a heartbeat are stale once registered before the cutoff
+		staleness := []bson.M{
+			{"last_heartbeat_at": bson.M{"$lt": *filter.HeartbeatBefore}},
+			{"last_heartbeat_at": bson.M{"$exists": false}, "registered_at": bson.M{"$lt": *filter.HeartbeatBefore}},
+		}
+		if existing, ok := bsonFilter["$or"]; ok {
+			delete(bsonFilter, "$or")
+			bsonFilter["$and"] = []bson.M{{"$or": existing}, {"$or": staleness}}
+		} else {
+			bsonFilter["$or"] = staleness
+		}
+	}
+
+	return bsonFilter
+}
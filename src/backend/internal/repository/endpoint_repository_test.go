@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+
+	"updatemanager/internal/models"
+)
+
+func TestBuildEndpointFilter(t *testing.T) {
+	cutoff := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	filter := buildEndpointFilter(&EndpointFilter{
+		ProductID: "product-a",
+		Status:    models.EndpointStatusActive,
+		Labels:    map[string]string{"role": "web"},
+	})
+	if filter["product_id"] != "product-a" || filter["status"] != models.EndpointStatusActive || filter["labels.role"] != "web" {
+		t.Errorf("Unexpected filter: %v", filter)
+	}
+
+	// Stale filter alone uses $or
+	filter = buildEndpointFilter(&EndpointFilter{HeartbeatBefore: &cutoff})
+	if clauses, ok := filter["$or"].([]bson.M); !ok || len(clauses) != 2 {
+		t.Errorf("Expected two staleness clauses, got %v", filter)
+	}
+
+	// Search combined with stale filter must not overwrite either $or
+	filter = buildEndpointFilter(&EndpointFilter{Search: "web.01", HeartbeatBefore: &cutoff})
+	if _, ok := filter["$or"]; ok {
+		t.Errorf("Expected $or clauses to be combined under $and, got %v", filter)
+	}
+	if clauses, ok := filter["$and"].([]bson.M); !ok || len(clauses) != 2 {
+		t.Errorf("Expected $and with two clauses, got %v", filter)
+	}
+
+	if len(buildEndpointFilter(nil)) != 0 {
+		t.Error("Expected empty filter for nil input")
+	}
+}
@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"updatemanager/internal/models"
+)
+
+// InternalGroupRepository handles internal group database operations
+type InternalGroupRepository struct {
+	collection *mongo.Collection
+}
+
+// NewInternalGroupRepository creates a new internal group repository
+func NewInternalGroupRepository(collection *mongo.Collection) *InternalGroupRepository {
+	return &InternalGroupRepository{
+		collection: collection,
+	}
+}
+
+// Create creates a new internal group in the database
+func (r *InternalGroupRepository) Create(ctx context.Context, group *models.InternalGroup) error {
+	now := time.Now()
+	group.CreatedAt = now
+	group.UpdatedAt = now
+
+	result, err := r.collection.InsertOne(ctx, group)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return fmt.Errorf("internal group %s already exists", group.GroupID)
+		}
+		return fmt.Errorf("failed to create internal group: %w", err)
+	}
+
+	if oid, ok := result.InsertedID.(primitive.ObjectID); ok {
+		group.ID = oid
+	}
+
+	return nil
+}
+
+// GetByID retrieves an internal group by its ID
+func (r *InternalGroupRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*models.InternalGroup, error) {
+	return r.findOne(ctx, bson.M{"_id": id})
+}
+
+// GetByGroupID retrieves an internal group by its group ID
+func (r *InternalGroupRepository) GetByGroupID(ctx context.Context, groupID string) (*models.InternalGroup, error) {
+	return r.findOne(ctx, bson.M{"group_id": groupID})
+}
+
+// findOne retrieves the internal group matching filter
+func (r *InternalGroupRepository) findOne(ctx context.Context, filter bson.M) (*models.InternalGroup, error) {
+	var group models.InternalGroup
+	err := r.collection.FindOne(ctx, filter).Decode(&group)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("internal group not found")
+		}
+		return nil, fmt.Errorf("failed to get internal group: %w", err)
+	}
+	return &group, nil
+}
+
+// Update updates an existing internal group
+func (r *InternalGroupRepository) Update(ctx context.Context, group *models.InternalGroup) error {
+	group.UpdatedAt = time.Now()
+
+	result, err := r.collection.ReplaceOne(ctx, bson.M{"_id": group.ID}, group)
+	if err != nil {
+		return fmt.Errorf("failed to update internal group: %w", err)
+	}
+
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("internal group not found")
+	}
+
+	return nil
+}
+
+// Delete deletes an internal group by ID
+func (r *InternalGroupRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return fmt.Errorf("failed to delete internal group: %w", err)
+	}
+
+	if result.DeletedCount == 0 {
+		return fmt.Errorf("internal group not found")
+	}
+
+	return nil
+}
+
+// List retrieves internal groups with optional filters
+func (r *InternalGroupRepository) List(ctx context.Context, filter bson.M, opts *options.FindOptions) ([]*models.InternalGroup, error) {
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list internal groups: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var groups []*models.InternalGroup
+	if err := cursor.All(ctx, &groups); err != nil {
+		return nil, fmt.Errorf("failed to decode internal groups: %w", err)
+	}
+
+	return groups, nil
+}
+
+// Count counts internal groups matching the filter
+func (r *InternalGroupRepository) Count(ctx context.Context, filter bson.M) (int64, error) {
+	count, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count internal groups: %w", err)
+	}
+	return count, nil
+}
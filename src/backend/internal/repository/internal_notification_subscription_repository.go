@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"updatemanager/internal/models"
+)
+
+// InternalNotificationSubscriptionRepository handles internal notification
+// subscription database operations
+type InternalNotificationSubscriptionRepository struct {
+	collection *mongo.Collection
+}
+
+// NewInternalNotificationSubscriptionRepository creates a new internal
+// notification subscription repository
+func NewInternalNotificationSubscriptionRepository(collection *mongo.Collection) *InternalNotificationSubscriptionRepository {
+	return &InternalNotificationSubscriptionRepository{
+		collection: collection,
+	}
+}
+
+// Create creates a new internal notification subscription in the database
+func (r *InternalNotificationSubscriptionRepository) Create(ctx context.Context, subscription *models.InternalNotificationSubscription) error {
+	now := time.Now()
+	subscription.CreatedAt = now
+	subscription.UpdatedAt = now
+
+	result, err := r.collection.InsertOne(ctx, subscription)
+	if err != nil {
+		return fmt.Errorf("failed to create internal notification subscription: %w", err)
+	}
+
+	if oid, ok := result.InsertedID.(primitive.ObjectID); ok {
+		subscription.ID = oid
+	}
+
+	return nil
+}
+
+// GetByID retrieves an internal notification subscription by its ID
+func (r *InternalNotificationSubscriptionRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*models.InternalNotificationSubscription, error) {
+	var subscription models.InternalNotificationSubscription
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&subscription)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("internal notification subscription not found")
+		}
+		return nil, fmt.Errorf("failed to get internal notification subscription: %w", err)
+	}
+	return &subscription, nil
+}
+
+// Update updates an existing internal notification subscription
+func (r *InternalNotificationSubscriptionRepository) Update(ctx context.Context, subscription *models.InternalNotificationSubscription) error {
+	subscription.UpdatedAt = time.Now()
+
+	// Replaced whole so a cleared product filter does not linger
+	result, err := r.collection.ReplaceOne(ctx, bson.M{"_id": subscription.ID}, subscription)
+	if err != nil {
+		return fmt.Errorf("failed to update internal notification subscription: %w", err)
+	}
+
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("internal notification subscription not found")
+	}
+
+	return nil
+}
+
+// Delete deletes an internal notification subscription by ID
+func (r *InternalNotificationSubscriptionRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return fmt.Errorf("failed to delete internal notification subscription: %w", err)
+	}
+
+	if result.DeletedCount == 0 {
+		return fmt.Errorf("internal notification subscription not found")
+	}
+
+	return nil
+}
+
+// DeleteByRecipient deletes every subscription of a user or group
+func (r *InternalNotificationSubscriptionRepository) DeleteByRecipient(ctx context.Context, recipientType models.InternalRecipientType, recipientID string) (int64, error) {
+	result, err := r.collection.DeleteMany(ctx, bson.M{
+		"recipient_type": recipientType,
+		"recipient_id":   recipientID,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete internal notification subscriptions: %w", err)
+	}
+	return result.DeletedCount, nil
+}
+
+// ListMatching retrieves the active subscriptions to a notification type
+// about a product, including those to every product
+func (r *InternalNotificationSubscriptionRepository) ListMatching(ctx context.Context, notificationType models.NotificationType, productID string) ([]*models.InternalNotificationSubscription, error) {
+	return r.List(ctx, bson.M{
+		"is_active": true,
+		"types":     notificationType,
+		"$or": bson.A{
+			bson.M{"product_id": bson.M{"$exists": false}},
+			bson.M{"product_id": productID},
+		},
+	}, nil)
+}
+
+// List retrieves internal notification subscriptions with optional filters
+func (r *InternalNotificationSubscriptionRepository) List(ctx context.Context, filter bson.M, opts *options.FindOptions) ([]*models.InternalNotificationSubscription, error) {
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list internal notification subscriptions: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var subscriptions []*models.InternalNotificationSubscription
+	if err := cursor.All(ctx, &subscriptions); err != nil {
+		return nil, fmt.Errorf("failed to decode internal notification subscriptions: %w", err)
+	}
+
+	return subscriptions, nil
+}
+
+// Count counts internal notification subscriptions matching the filter
+func (r *InternalNotificationSubscriptionRepository) Count(ctx context.Context, filter bson.M) (int64, error) {
+	count, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count internal notification subscriptions: %w", err)
+	}
+	return count, nil
+}
@@ -404,3 +404,31 @@ func (r *LicenseAllocationRepository) GetTotalAllocatedSeats(ctx context.Context
 	return 0, nil
 }
 
+
+// GetAllocatedSeatsByLicense sums the seats of active allocations per license
+func (r *LicenseAllocationRepository) GetAllocatedSeatsByLicense(ctx context.Context) (map[primitive.ObjectID]int, error) {
+	pipeline := []bson.M{
+		{"$match": bson.M{"status": models.AllocationStatusActive}},
+		{"$group": bson.M{"_id": "$license_id", "total": bson.M{"$sum": "$number_of_seats_allocated"}}},
+	}
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("failed to calculate allocated seats: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var results []struct {
+		LicenseID primitive.ObjectID `bson:"_id"`
+		Total     int                `bson:"total"`
+	}
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, fmt.Errorf("failed to decode aggregation result: %w", err)
+	}
+
+	seats := make(map[primitive.ObjectID]int, len(results))
+	for _, result := range results {
+		seats[result.LicenseID] = result.Total
+	}
+	return seats, nil
+}
@@ -333,3 +333,42 @@ func (r *LicenseRepository) GetExpiringLicenses(ctx context.Context, days int) (
 	return licenses, nil
 }
 
+
+// GetLapsedLicenses retrieves active time-based licenses whose end date is
+// before now, oldest first
+func (r *LicenseRepository) GetLapsedLicenses(ctx context.Context, now time.Time, limit int64) ([]*models.License, error) {
+	filter := bson.M{
+		"end_date":     bson.M{"$lt": now},
+		"status":       models.LicenseStatusActive,
+		"license_type": models.LicenseTypeTimeBased,
+	}
+
+	findOptions := options.Find().SetSort(bson.M{"end_date": 1}).SetLimit(limit)
+
+	cursor, err := r.collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find lapsed licenses: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var licenses []*models.License
+	if err := cursor.All(ctx, &licenses); err != nil {
+		return nil, fmt.Errorf("failed to decode licenses: %w", err)
+	}
+
+	return licenses, nil
+}
+
+// ExpireIfActive marks a license expired only if it is still active. It
+// returns false without error when the license was changed meanwhile.
+func (r *LicenseRepository) ExpireIfActive(ctx context.Context, id primitive.ObjectID) (bool, error) {
+	result, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": id, "status": models.LicenseStatusActive},
+		bson.M{"$set": bson.M{"status": models.LicenseStatusExpired, "updated_at": time.Now()}},
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to expire license: %w", err)
+	}
+
+	return result.MatchedCount > 0, nil
+}
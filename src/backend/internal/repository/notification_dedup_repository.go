@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"updatemanager/internal/models"
+)
+
+// NotificationDedupRepository handles the keys deduplicating automatic
+// notifications, one per recipient, type and subject
+type NotificationDedupRepository struct {
+	collection *mongo.Collection
+}
+
+// NotificationDedupIndex is the name of the unique index on recipient, type
+// and subject; the database scripts create it too
+const NotificationDedupIndex = "recipient_type_subject_unique"
+
+// NewNotificationDedupRepository creates a new notification dedup repository
+func NewNotificationDedupRepository(collection *mongo.Collection) *NotificationDedupRepository {
+	return &NotificationDedupRepository{
+		collection: collection,
+	}
+}
+
+// Claim records that the key's recipient is notified at the key's time,
+// unless they were already notified of the same type and subject within
+// window before it; a window of zero or less claims a key once for good. It
+// reports whether the key was claimed. Claims are atomic: of concurrent
+// claims, one wins.
+func (r *NotificationDedupRepository) Claim(ctx context.Context, key *models.NotificationDedupKey, window time.Duration) (bool, error) {
+	// Stored with millisecond precision, so Release matches it
+	key.CreatedAt = key.CreatedAt.Truncate(time.Millisecond)
+
+	// An unexpired key matches nothing, so the upsert inserts a duplicate
+	_, err := r.collection.UpdateOne(ctx, dedupClaimFilter(key, window),
+		bson.M{"$set": bson.M{"created_at": key.CreatedAt}},
+		options.Update().SetUpsert(true))
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to claim notification dedup key: %w", err)
+	}
+	return true, nil
+}
+
+// Release drops a claimed key, when its notification could not be created
+func (r *NotificationDedupRepository) Release(ctx context.Context, key *models.NotificationDedupKey) error {
+	filter := dedupKeyFilter(key)
+	filter["created_at"] = key.CreatedAt
+	if _, err := r.collection.DeleteOne(ctx, filter); err != nil {
+		return fmt.Errorf("failed to release notification dedup key: %w", err)
+	}
+	return nil
+}
+
+// EnsureIndex creates the unique index claims rely on
+func (r *NotificationDedupRepository) EnsureIndex(ctx context.Context) error {
+	index := mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "recipient_id", Value: 1},
+			{Key: "type", Value: 1},
+			{Key: "subject", Value: 1},
+		},
+		Options: options.Index().SetName(NotificationDedupIndex).SetUnique(true),
+	}
+	if _, err := r.collection.Indexes().CreateOne(ctx, index); err != nil {
+		return fmt.Errorf("failed to create notification dedup index: %w", err)
+	}
+	return nil
+}
+
+// dedupClaimFilter matches the key a claim may take over: the same
+// recipient, type and subject, last notified before window. Without a window
+// it matches nothing, so an existing key is never taken over.
+func dedupClaimFilter(key *models.NotificationDedupKey, window time.Duration) bson.M {
+	filter := dedupKeyFilter(key)
+	if window > 0 {
+		filter["created_at"] = bson.M{"$lt": key.CreatedAt.Add(-window)}
+	} else {
+		filter["created_at"] = bson.M{"$exists": false}
+	}
+	return filter
+}
+
+func dedupKeyFilter(key *models.NotificationDedupKey) bson.M {
+	return bson.M{
+		"recipient_id": key.RecipientID,
+		"type":         key.Type,
+		"subject":      key.Subject,
+	}
+}
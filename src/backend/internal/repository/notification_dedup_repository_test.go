@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+
+	"updatemanager/internal/models"
+)
+
+func TestDedupClaimFilter(t *testing.T) {
+	now := time.Now()
+	key := &models.NotificationDedupKey{
+		RecipientID: "customer-1",
+		Type:        models.NotificationTypeSeatOverallocation,
+		Subject:     "license:lic-1:seats",
+		CreatedAt:   now,
+	}
+
+	filter := dedupClaimFilter(key, time.Hour)
+	if filter["recipient_id"] != "customer-1" || filter["type"] != models.NotificationTypeSeatOverallocation || filter["subject"] != "license:lic-1:seats" {
+		t.Errorf("Unexpected filter %v", filter)
+	}
+	if before := filter["created_at"].(bson.M)["$lt"].(time.Time); !before.Equal(now.Add(-time.Hour)) {
+		t.Errorf("Expected keys from before an hour ago to be taken over, got %v", before)
+	}
+
+	if _, ok := dedupClaimFilter(key, 0)["created_at"].(bson.M)["$exists"]; !ok {
+		t.Error("Expected no key to be taken over without a window")
+	}
+}
+
+func TestNotificationDedupClaim(t *testing.T) {
+	setupNotificationTestDB(t)
+	defer teardownNotificationTestDB(t)
+
+	dedupRepo := NewNotificationDedupRepository(testDB.Collection("notification_dedup_keys"))
+	defer testDB.Collection("notification_dedup_keys").Drop(testCtx)
+	if err := dedupRepo.EnsureIndex(testCtx); err != nil {
+		t.Fatalf("Failed to create index: %v", err)
+	}
+
+	now := time.Now()
+	newKey := func(at time.Time) *models.NotificationDedupKey {
+		return &models.NotificationDedupKey{RecipientID: "customer-1", Type: models.NotificationTypeEOLWarning, Subject: "version:v1", CreatedAt: at}
+	}
+
+	// Of concurrent claims, one wins
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	claimed := 0
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ok, err := dedupRepo.Claim(testCtx, newKey(now), 0)
+			if err != nil {
+				t.Errorf("Claim() error = %v", err)
+			}
+			if ok {
+				mu.Lock()
+				claimed++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if claimed != 1 {
+		t.Fatalf("Expected one claim to win, got %d", claimed)
+	}
+
+	// With a window, the key is taken over once it expires
+	if ok, _ := dedupRepo.Claim(testCtx, newKey(now.Add(30*time.Minute)), time.Hour); ok {
+		t.Error("Expected a claim within the window to fail")
+	}
+	key := newKey(now.Add(2 * time.Hour))
+	if ok, _ := dedupRepo.Claim(testCtx, key, time.Hour); !ok {
+		t.Error("Expected a claim after the window to succeed")
+	}
+
+	// A released key can be claimed again
+	if err := dedupRepo.Release(testCtx, key); err != nil {
+		t.Fatalf("Release() error = %v", err)
+	}
+	if ok, _ := dedupRepo.Claim(testCtx, newKey(now), 0); !ok {
+		t.Error("Expected a released key to be claimable")
+	}
+}
@@ -18,6 +18,10 @@ type NotificationRepository struct {
 	collection *mongo.Collection
 }
 
+// NotificationReadTTLIndex is the name of the TTL index purging read
+// notifications; the database scripts create it with the default retention
+const NotificationReadTTLIndex = "read_at_ttl"
+
 // NewNotificationRepository creates a new notification repository
 func NewNotificationRepository(collection *mongo.Collection) *NotificationRepository {
 	return &NotificationRepository{
@@ -96,17 +100,23 @@ func (r *NotificationRepository) GetUnreadByRecipientID(ctx context.Context, rec
 
 // MarkAsRead marks a notification as read
 func (r *NotificationRepository) MarkAsRead(ctx context.Context, id primitive.ObjectID) error {
-	now := time.Now()
-	update := bson.M{
-		"$set": bson.M{
-			"is_read": true,
-			"read_at": now,
-		},
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, notificationReadUpdate(true))
+	if err != nil {
+		return fmt.Errorf("failed to mark notification as read: %w", err)
 	}
 
-	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, update)
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("notification not found")
+	}
+
+	return nil
+}
+
+// MarkAsUnread marks a notification as unread, so it no longer expires
+func (r *NotificationRepository) MarkAsUnread(ctx context.Context, id primitive.ObjectID) error {
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, notificationReadUpdate(false))
 	if err != nil {
-		return fmt.Errorf("failed to mark notification as read: %w", err)
+		return fmt.Errorf("failed to mark notification as unread: %w", err)
 	}
 
 	if result.MatchedCount == 0 {
@@ -116,6 +126,124 @@ func (r *NotificationRepository) MarkAsRead(ctx context.Context, id primitive.Ob
 	return nil
 }
 
+// SetArchived moves a notification to or out of its recipient's archive
+func (r *NotificationRepository) SetArchived(ctx context.Context, id primitive.ObjectID, archived bool) error {
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, notificationArchiveUpdate(archived))
+	if err != nil {
+		return fmt.Errorf("failed to archive notification: %w", err)
+	}
+
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("notification not found")
+	}
+
+	return nil
+}
+
+// SetReadMany marks some of a recipient's notifications read or unread and
+// returns how many matched
+func (r *NotificationRepository) SetReadMany(ctx context.Context, recipientID string, ids []primitive.ObjectID, read bool) (int64, error) {
+	result, err := r.collection.UpdateMany(ctx, recipientNotificationsFilter(recipientID, ids), notificationReadUpdate(read))
+	if err != nil {
+		return 0, fmt.Errorf("failed to mark notifications: %w", err)
+	}
+	return result.MatchedCount, nil
+}
+
+// SetArchivedMany archives or unarchives some of a recipient's notifications
+// and returns how many matched
+func (r *NotificationRepository) SetArchivedMany(ctx context.Context, recipientID string, ids []primitive.ObjectID, archived bool) (int64, error) {
+	result, err := r.collection.UpdateMany(ctx, recipientNotificationsFilter(recipientID, ids), notificationArchiveUpdate(archived))
+	if err != nil {
+		return 0, fmt.Errorf("failed to archive notifications: %w", err)
+	}
+	return result.MatchedCount, nil
+}
+
+// DeleteMany deletes some of a recipient's notifications and returns how
+// many were deleted
+func (r *NotificationRepository) DeleteMany(ctx context.Context, recipientID string, ids []primitive.ObjectID) (int64, error) {
+	result, err := r.collection.DeleteMany(ctx, recipientNotificationsFilter(recipientID, ids))
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete notifications: %w", err)
+	}
+	return result.DeletedCount, nil
+}
+
+// recipientNotificationsFilter matches the notifications of ids that belong
+// to a recipient
+func recipientNotificationsFilter(recipientID string, ids []primitive.ObjectID) bson.M {
+	return bson.M{"_id": bson.M{"$in": ids}, "recipient_id": recipientID}
+}
+
+// notificationReadUpdate marks notifications read or unread. Only read
+// notifications have read_at, so only they expire.
+func notificationReadUpdate(read bool) bson.M {
+	if read {
+		return bson.M{"$set": bson.M{"is_read": true, "read_at": time.Now()}}
+	}
+	return bson.M{"$set": bson.M{"is_read": false}, "$unset": bson.M{"read_at": ""}}
+}
+
+// notificationArchiveUpdate archives or unarchives notifications
+func notificationArchiveUpdate(archived bool) bson.M {
+	if archived {
+		return bson.M{"$set": bson.M{"is_archived": true, "archived_at": time.Now()}}
+	}
+	return bson.M{"$set": bson.M{"is_archived": false}, "$unset": bson.M{"archived_at": ""}}
+}
+
+// EnsureReadRetention makes read notifications expire retention after they
+// were read, through a TTL index on read_at that is created, changed or, for
+// a retention of zero, dropped to match
+func (r *NotificationRepository) EnsureReadRetention(ctx context.Context, retention time.Duration) error {
+	seconds := int32(retention / time.Second)
+
+	cursor, err := r.collection.Indexes().List(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list notification indexes: %w", err)
+	}
+	var indexes []bson.M
+	if err := cursor.All(ctx, &indexes); err != nil {
+		return fmt.Errorf("failed to decode notification indexes: %w", err)
+	}
+
+	for _, index := range indexes {
+		if index["name"] != NotificationReadTTLIndex {
+			continue
+		}
+		if seconds <= 0 {
+			if _, err := r.collection.Indexes().DropOne(ctx, NotificationReadTTLIndex); err != nil {
+				return fmt.Errorf("failed to drop notification retention index: %w", err)
+			}
+			return nil
+		}
+		if fmt.Sprint(index["expireAfterSeconds"]) == fmt.Sprint(seconds) {
+			return nil
+		}
+		command := bson.D{
+			{Key: "collMod", Value: r.collection.Name()},
+			{Key: "index", Value: bson.M{"name": NotificationReadTTLIndex, "expireAfterSeconds": seconds}},
+		}
+		if err := r.collection.Database().RunCommand(ctx, command).Err(); err != nil {
+			return fmt.Errorf("failed to change notification retention: %w", err)
+		}
+		return nil
+	}
+
+	if seconds <= 0 {
+		return nil
+	}
+	_, err = r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "read_at", Value: 1}},
+		Options: options.Index().SetName(NotificationReadTTLIndex).SetExpireAfterSeconds(seconds),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create notification retention index: %w", err)
+	}
+	return nil
+}
+
 // MarkAllAsRead marks all notifications for a recipient as read
 func (r *NotificationRepository) MarkAllAsRead(ctx context.Context, recipientID string) error {
 	now := time.Now()
@@ -154,6 +282,98 @@ func (r *NotificationRepository) Update(ctx context.Context, notification *model
 	return nil
 }
 
+// ClaimDueDelivery claims the notification whose channel deliveries are due
+// first at now and leases it until now+lease, so deliveries of a worker that
+// died are retried after the lease. It returns nil without error when nothing
+// is due.
+func (r *NotificationRepository) ClaimDueDelivery(ctx context.Context, now time.Time, lease time.Duration) (*models.Notification, error) {
+	filter := bson.M{"delivery_due_at": bson.M{"$lte": now}}
+	update := bson.M{"$set": bson.M{"delivery_due_at": now.Add(lease)}}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "delivery_due_at", Value: 1}, {Key: "_id", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	var notification models.Notification
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&notification)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to claim notification delivery: %w", err)
+	}
+	return &notification, nil
+}
+
+// UpdateDeliveries stores a notification's channel deliveries and when they
+// are next due; a nil dueAt means none is pending
+func (r *NotificationRepository) UpdateDeliveries(ctx context.Context, id primitive.ObjectID, deliveries []models.NotificationDelivery, dueAt *time.Time) error {
+	set := bson.M{"deliveries": deliveries}
+	update := bson.M{"$set": set}
+	if dueAt != nil {
+		set["delivery_due_at"] = *dueAt
+	} else {
+		update["$unset"] = bson.M{"delivery_due_at": ""}
+	}
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, update)
+	if err != nil {
+		return fmt.Errorf("failed to update notification deliveries: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("notification not found")
+	}
+	return nil
+}
+
+// ClaimDueDigest claims the notifications of the recipient whose digest is
+// due first at now, leasing them until now+lease like ClaimDueDelivery. It
+// returns nil without error when no digest is due.
+func (r *NotificationRepository) ClaimDueDigest(ctx context.Context, now time.Time, lease time.Duration) ([]*models.Notification, error) {
+	leasedUntil := now.Add(lease)
+	due := bson.M{"$lte": now}
+	update := bson.M{"$set": bson.M{"digest_due_at": leasedUntil}}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "digest_due_at", Value: 1}, {Key: "_id", Value: 1}})
+
+	var first models.Notification
+	err := r.collection.FindOneAndUpdate(ctx, bson.M{"digest_due_at": due}, update, opts).Decode(&first)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to claim notification digest: %w", err)
+	}
+
+	// The recipient's other due notifications join the claimed one
+	if _, err := r.collection.UpdateMany(ctx, bson.M{"recipient_id": first.RecipientID, "digest_due_at": due}, update); err != nil {
+		return nil, fmt.Errorf("failed to claim notification digest: %w", err)
+	}
+
+	return r.List(ctx, bson.M{"recipient_id": first.RecipientID, "digest_due_at": leasedUntil},
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}))
+}
+
+// UpdateDigest stores a notification's channel deliveries and when its digest
+// is next due; a nil dueAt means nothing is held for a digest
+func (r *NotificationRepository) UpdateDigest(ctx context.Context, id primitive.ObjectID, deliveries []models.NotificationDelivery, dueAt *time.Time) error {
+	set := bson.M{"deliveries": deliveries}
+	update := bson.M{"$set": set}
+	if dueAt != nil {
+		set["digest_due_at"] = *dueAt
+	} else {
+		update["$unset"] = bson.M{"digest_due_at": ""}
+	}
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, update)
+	if err != nil {
+		return fmt.Errorf("failed to update notification digest: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("notification not found")
+	}
+	return nil
+}
+
 // Delete deletes a notification by ID
 func (r *NotificationRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
 	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
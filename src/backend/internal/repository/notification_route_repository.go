@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"updatemanager/internal/models"
+)
+
+// NotificationRouteRepository handles route database operations
+type NotificationRouteRepository struct {
+	collection *mongo.Collection
+}
+
+// NewNotificationRouteRepository creates a new notification route repository
+func NewNotificationRouteRepository(collection *mongo.Collection) *NotificationRouteRepository {
+	return &NotificationRouteRepository{
+		collection: collection,
+	}
+}
+
+// Create creates a new notification route in the database
+func (r *NotificationRouteRepository) Create(ctx context.Context, route *models.NotificationRoute) error {
+	now := time.Now()
+	route.CreatedAt = now
+	route.UpdatedAt = now
+
+	result, err := r.collection.InsertOne(ctx, route)
+	if err != nil {
+		return fmt.Errorf("failed to create notification route: %w", err)
+	}
+
+	if oid, ok := result.InsertedID.(primitive.ObjectID); ok {
+		route.ID = oid
+	}
+
+	return nil
+}
+
+// GetByID retrieves a notification route by its ID
+func (r *NotificationRouteRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*models.NotificationRoute, error) {
+	var route models.NotificationRoute
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&route)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("notification route not found")
+		}
+		return nil, fmt.Errorf("failed to get notification route: %w", err)
+	}
+	return &route, nil
+}
+
+// Update updates an existing notification route
+func (r *NotificationRouteRepository) Update(ctx context.Context, route *models.NotificationRoute) error {
+	route.UpdatedAt = time.Now()
+
+	// Replaced whole so cleared filters do not linger
+	result, err := r.collection.ReplaceOne(ctx, bson.M{"_id": route.ID}, route)
+	if err != nil {
+		return fmt.Errorf("failed to update notification route: %w", err)
+	}
+
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("notification route not found")
+	}
+
+	return nil
+}
+
+// Delete deletes a notification route by ID
+func (r *NotificationRouteRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return fmt.Errorf("failed to delete notification route: %w", err)
+	}
+
+	if result.DeletedCount == 0 {
+		return fmt.Errorf("notification route not found")
+	}
+
+	return nil
+}
+
+// ListActiveForCustomer retrieves the active global routes and those of
+// customerID; with an empty customerID only the global ones
+func (r *NotificationRouteRepository) ListActiveForCustomer(ctx context.Context, customerID string) ([]*models.NotificationRoute, error) {
+	customers := bson.A{nil}
+	if customerID != "" {
+		customers = append(customers, customerID)
+	}
+	return r.List(ctx, bson.M{
+		"is_active":   true,
+		"customer_id": bson.M{"$in": customers},
+	}, nil)
+}
+
+// List retrieves notification routes with optional filters
+func (r *NotificationRouteRepository) List(ctx context.Context, filter bson.M, opts *options.FindOptions) ([]*models.NotificationRoute, error) {
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list notification routes: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var routes []*models.NotificationRoute
+	if err := cursor.All(ctx, &routes); err != nil {
+		return nil, fmt.Errorf("failed to decode notification routes: %w", err)
+	}
+
+	return routes, nil
+}
+
+// Count counts notification routes matching the filter
+func (r *NotificationRouteRepository) Count(ctx context.Context, filter bson.M) (int64, error) {
+	count, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count notification routes: %w", err)
+	}
+	return count, nil
+}
@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"updatemanager/internal/models"
+)
+
+// NotificationTemplateRepository handles notification template database operations
+type NotificationTemplateRepository struct {
+	collection *mongo.Collection
+}
+
+// NewNotificationTemplateRepository creates a new notification template repository
+func NewNotificationTemplateRepository(collection *mongo.Collection) *NotificationTemplateRepository {
+	return &NotificationTemplateRepository{
+		collection: collection,
+	}
+}
+
+// Create creates a new notification template in the database
+func (r *NotificationTemplateRepository) Create(ctx context.Context, template *models.NotificationTemplate) error {
+	now := time.Now()
+	template.CreatedAt = now
+	template.UpdatedAt = now
+
+	result, err := r.collection.InsertOne(ctx, template)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return fmt.Errorf("notification template for %s %s %s already exists", template.Type, template.Channel, template.Locale)
+		}
+		return fmt.Errorf("failed to create notification template: %w", err)
+	}
+
+	if oid, ok := result.InsertedID.(primitive.ObjectID); ok {
+		template.ID = oid
+	}
+
+	return nil
+}
+
+// GetByID retrieves a notification template by its ID
+func (r *NotificationTemplateRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*models.NotificationTemplate, error) {
+	var template models.NotificationTemplate
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&template)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("notification template not found")
+		}
+		return nil, fmt.Errorf("failed to get notification template: %w", err)
+	}
+	return &template, nil
+}
+
+// FindCandidates retrieves the templates of a notification type for any of
+// the given channels and locales, for the caller to pick the best match
+func (r *NotificationTemplateRepository) FindCandidates(ctx context.Context, notificationType models.NotificationType, channels []models.NotificationChannel, locales []string) ([]*models.NotificationTemplate, error) {
+	return r.List(ctx, bson.M{
+		"type":    notificationType,
+		"channel": bson.M{"$in": channels},
+		"locale":  bson.M{"$in": locales},
+	}, nil)
+}
+
+// Update updates an existing notification template
+func (r *NotificationTemplateRepository) Update(ctx context.Context, template *models.NotificationTemplate) error {
+	template.UpdatedAt = time.Now()
+
+	result, err := r.collection.ReplaceOne(ctx, bson.M{"_id": template.ID}, template)
+	if err != nil {
+		return fmt.Errorf("failed to update notification template: %w", err)
+	}
+
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("notification template not found")
+	}
+
+	return nil
+}
+
+// Delete deletes a notification template by ID
+func (r *NotificationTemplateRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return fmt.Errorf("failed to delete notification template: %w", err)
+	}
+
+	if result.DeletedCount == 0 {
+		return fmt.Errorf("notification template not found")
+	}
+
+	return nil
+}
+
+// List retrieves notification templates with optional filters
+func (r *NotificationTemplateRepository) List(ctx context.Context, filter bson.M, opts *options.FindOptions) ([]*models.NotificationTemplate, error) {
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list notification templates: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var templates []*models.NotificationTemplate
+	if err := cursor.All(ctx, &templates); err != nil {
+		return nil, fmt.Errorf("failed to decode notification templates: %w", err)
+	}
+
+	return templates, nil
+}
+
+// Count counts notification templates matching the filter
+func (r *NotificationTemplateRepository) Count(ctx context.Context, filter bson.M) (int64, error) {
+	count, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count notification templates: %w", err)
+	}
+	return count, nil
+}
@@ -0,0 +1,143 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"updatemanager/internal/models"
+)
+
+// OutboxRepository handles domain event outbox database operations
+type OutboxRepository struct {
+	collection *mongo.Collection
+}
+
+// NewOutboxRepository creates a new outbox repository
+func NewOutboxRepository(collection *mongo.Collection) *OutboxRepository {
+	return &OutboxRepository{
+		collection: collection,
+	}
+}
+
+// Create stores a pending event, due immediately
+func (r *OutboxRepository) Create(ctx context.Context, event *models.OutboxEvent) error {
+	now := time.Now()
+	event.Status = models.OutboxStatusPending
+	event.CreatedAt = now
+	event.NextAttemptAt = now
+
+	result, err := r.collection.InsertOne(ctx, event)
+	if err != nil {
+		return fmt.Errorf("failed to create outbox event: %w", err)
+	}
+
+	if oid, ok := result.InsertedID.(primitive.ObjectID); ok {
+		event.ID = oid
+	}
+
+	return nil
+}
+
+// ClaimDue claims the oldest pending event due at now, counting the attempt
+// and leasing it until now+lease so no other dispatcher claims it meanwhile.
+// An event whose dispatcher dies is claimed again once the lease runs out.
+// It returns nil without error when no event is due.
+func (r *OutboxRepository) ClaimDue(ctx context.Context, now time.Time, lease time.Duration) (*models.OutboxEvent, error) {
+	filter := bson.M{
+		"status":          models.OutboxStatusPending,
+		"next_attempt_at": bson.M{"$lte": now},
+	}
+	update := bson.M{
+		"$set": bson.M{"next_attempt_at": now.Add(lease)},
+		"$inc": bson.M{"attempts": 1},
+	}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "next_attempt_at", Value: 1}, {Key: "_id", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	var event models.OutboxEvent
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&event)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to claim outbox event: %w", err)
+	}
+	return &event, nil
+}
+
+// MarkDelivered records that a subscriber handled an event, so retries skip it
+func (r *OutboxRepository) MarkDelivered(ctx context.Context, id primitive.ObjectID, subscriber string) error {
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{
+		"$addToSet": bson.M{"delivered_to": subscriber},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to mark outbox event delivered: %w", err)
+	}
+	return nil
+}
+
+// MarkDispatched records that every subscriber handled an event
+func (r *OutboxRepository) MarkDispatched(ctx context.Context, id primitive.ObjectID, dispatchedAt time.Time) error {
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{
+		"$set":   bson.M{"status": models.OutboxStatusDispatched, "dispatched_at": dispatchedAt},
+		"$unset": bson.M{"last_error": ""},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to mark outbox event dispatched: %w", err)
+	}
+	return nil
+}
+
+// MarkRetry records a failed attempt and when to try again
+func (r *OutboxRepository) MarkRetry(ctx context.Context, id primitive.ObjectID, nextAttemptAt time.Time, lastError string) error {
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{
+		"$set": bson.M{"next_attempt_at": nextAttemptAt, "last_error": lastError},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to reschedule outbox event: %w", err)
+	}
+	return nil
+}
+
+// MarkFailed gives up on an event after its last attempt
+func (r *OutboxRepository) MarkFailed(ctx context.Context, id primitive.ObjectID, lastError string) error {
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{
+		"$set": bson.M{"status": models.OutboxStatusFailed, "last_error": lastError},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to mark outbox event failed: %w", err)
+	}
+	return nil
+}
+
+// List retrieves outbox events with optional filters
+func (r *OutboxRepository) List(ctx context.Context, filter bson.M, opts *options.FindOptions) ([]*models.OutboxEvent, error) {
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list outbox events: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var events []*models.OutboxEvent
+	if err := cursor.All(ctx, &events); err != nil {
+		return nil, fmt.Errorf("failed to decode outbox events: %w", err)
+	}
+
+	return events, nil
+}
+
+// Count counts outbox events matching the filter
+func (r *OutboxRepository) Count(ctx context.Context, filter bson.M) (int64, error) {
+	count, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count outbox events: %w", err)
+	}
+	return count, nil
+}
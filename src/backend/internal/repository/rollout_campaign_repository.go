@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"updatemanager/internal/models"
+)
+
+// RolloutCampaignRepository handles rollout campaign database operations
+type RolloutCampaignRepository struct {
+	collection *mongo.Collection
+}
+
+// NewRolloutCampaignRepository creates a new rollout campaign repository
+func NewRolloutCampaignRepository(collection *mongo.Collection) *RolloutCampaignRepository {
+	return &RolloutCampaignRepository{
+		collection: collection,
+	}
+}
+
+// Create creates a new rollout campaign in the database
+func (r *RolloutCampaignRepository) Create(ctx context.Context, campaign *models.RolloutCampaign) error {
+	// Set timestamps
+	now := time.Now()
+	campaign.CreatedAt = now
+	campaign.UpdatedAt = now
+	campaign.Revision = 1
+
+	// Insert rollout campaign
+	result, err := r.collection.InsertOne(ctx, campaign)
+	if err != nil {
+		return fmt.Errorf("failed to create rollout campaign: %w", err)
+	}
+
+	// Set the generated ID
+	if oid, ok := result.InsertedID.(primitive.ObjectID); ok {
+		campaign.ID = oid
+	}
+
+	return nil
+}
+
+// GetByID retrieves a rollout campaign by its ID
+func (r *RolloutCampaignRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*models.RolloutCampaign, error) {
+	var campaign models.RolloutCampaign
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&campaign)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("rollout campaign not found")
+		}
+		return nil, fmt.Errorf("failed to get rollout campaign: %w", err)
+	}
+	return &campaign, nil
+}
+
+// Update saves a rollout campaign if nobody else has written it since it was read.
+// It returns false without error when the stored revision has moved on.
+func (r *RolloutCampaignRepository) Update(ctx context.Context, campaign *models.RolloutCampaign) (bool, error) {
+	expected := campaign.Revision
+	campaign.Revision = expected + 1
+	campaign.UpdatedAt = time.Now()
+
+	filter := bson.M{"_id": campaign.ID, "revision": expected}
+	update := bson.M{"$set": campaign}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		campaign.Revision = expected
+		return false, fmt.Errorf("failed to update rollout campaign: %w", err)
+	}
+
+	if result.MatchedCount == 0 {
+		campaign.Revision = expected
+		return false, nil
+	}
+
+	return true, nil
+}
+
+// GetDue retrieves campaigns the scheduler has to look at
+func (r *RolloutCampaignRepository) GetDue(ctx context.Context) ([]*models.RolloutCampaign, error) {
+	filter := bson.M{"status": bson.M{"$in": []models.CampaignStatus{models.CampaignStatusScheduled, models.CampaignStatusRunning}}}
+	return r.List(ctx, filter, options.Find().SetSort(bson.M{"created_at": 1}))
+}
+
+// List retrieves rollout campaigns with optional filters
+func (r *RolloutCampaignRepository) List(ctx context.Context, filter bson.M, opts *options.FindOptions) ([]*models.RolloutCampaign, error) {
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list rollout campaigns: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var campaigns []*models.RolloutCampaign
+	if err := cursor.All(ctx, &campaigns); err != nil {
+		return nil, fmt.Errorf("failed to decode rollout campaigns: %w", err)
+	}
+
+	return campaigns, nil
+}
+
+// Count returns the count of rollout campaigns matching the filter
+func (r *RolloutCampaignRepository) Count(ctx context.Context, filter bson.M) (int64, error) {
+	count, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count rollout campaigns: %w", err)
+	}
+	return count, nil
+}
@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"updatemanager/internal/models"
+)
+
+// RolloutEventRepository handles rollout timeline event database operations.
+// Events are append-only: there is no update or delete.
+type RolloutEventRepository struct {
+	collection *mongo.Collection
+}
+
+// NewRolloutEventRepository creates a new rollout event repository
+func NewRolloutEventRepository(collection *mongo.Collection) *RolloutEventRepository {
+	return &RolloutEventRepository{
+		collection: collection,
+	}
+}
+
+// CreateMany appends events in one batch. Events whose event ID is already
+// stored for the rollout are skipped; it returns how many were inserted.
+func (r *RolloutEventRepository) CreateMany(ctx context.Context, events []*models.RolloutEvent) (int, error) {
+	if len(events) == 0 {
+		return 0, nil
+	}
+
+	now := time.Now()
+	docs := make([]interface{}, len(events))
+	for i, event := range events {
+		event.ID = primitive.NewObjectID()
+		event.ReceivedAt = now
+		docs[i] = event
+	}
+
+	// Unordered so one duplicate does not stop the rest of the batch
+	result, err := r.collection.InsertMany(ctx, docs, options.InsertMany().SetOrdered(false))
+	if err != nil {
+		var bulkErr mongo.BulkWriteException
+		if !errors.As(err, &bulkErr) || bulkErr.WriteConcernError != nil {
+			return 0, fmt.Errorf("failed to create rollout events: %w", err)
+		}
+		for _, writeErr := range bulkErr.WriteErrors {
+			if !mongo.IsDuplicateKeyError(writeErr) {
+				return 0, fmt.Errorf("failed to create rollout events: %w", err)
+			}
+		}
+		return len(events) - len(bulkErr.WriteErrors), nil
+	}
+
+	return len(result.InsertedIDs), nil
+}
+
+// ExistingEventIDs returns which of the given event IDs are stored for a rollout
+func (r *RolloutEventRepository) ExistingEventIDs(ctx context.Context, rolloutID primitive.ObjectID, eventIDs []string) (map[string]bool, error) {
+	existing := make(map[string]bool)
+	if len(eventIDs) == 0 {
+		return existing, nil
+	}
+
+	values, err := r.collection.Distinct(ctx, "event_id", bson.M{
+		"rollout_id": rolloutID,
+		"event_id":   bson.M{"$in": eventIDs},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rollout event IDs: %w", err)
+	}
+
+	for _, value := range values {
+		if eventID, ok := value.(string); ok {
+			existing[eventID] = true
+		}
+	}
+	return existing, nil
+}
+
+// List retrieves rollout events matching a filter
+func (r *RolloutEventRepository) List(ctx context.Context, filter bson.M, opts *options.FindOptions) ([]*models.RolloutEvent, error) {
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list rollout events: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var events []*models.RolloutEvent
+	if err := cursor.All(ctx, &events); err != nil {
+		return nil, fmt.Errorf("failed to decode rollout events: %w", err)
+	}
+
+	return events, nil
+}
+
+// Count returns the number of rollout events matching a filter
+func (r *RolloutEventRepository) Count(ctx context.Context, filter bson.M) (int64, error) {
+	count, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count rollout events: %w", err)
+	}
+	return count, nil
+}
@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// illegalOperationCode is returned by standalone servers, which cannot run
+// transactions
+const illegalOperationCode = 20
+
+// Transactor runs groups of writes in a MongoDB transaction. Standalone
+// servers have no transactions; there the writes run one after another, so a
+// crash between them can lose the later ones.
+type Transactor struct {
+	client *mongo.Client
+
+	mu          sync.Mutex
+	unsupported bool
+}
+
+// NewTransactor creates a new transactor
+func NewTransactor(client *mongo.Client) *Transactor {
+	return &Transactor{
+		client: client,
+	}
+}
+
+// WithTransaction runs fn in a transaction and commits it if fn succeeds.
+// Repository calls inside fn must use the context it is given. fn may run
+// more than once when the transaction is retried.
+func (t *Transactor) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if t == nil || t.transactionsUnsupported() {
+		return fn(ctx)
+	}
+
+	session, err := t.client.StartSession()
+	if err != nil {
+		return fmt.Errorf("failed to start session: %w", err)
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
+		return nil, fn(sessCtx)
+	})
+	if isTransactionsUnsupported(err) {
+		// Nothing was written: the first write of the transaction was refused
+		t.mu.Lock()
+		t.unsupported = true
+		t.mu.Unlock()
+		log.Printf("MongoDB does not support transactions; outbox writes are no longer atomic with their changes")
+		return fn(ctx)
+	}
+	return err
+}
+
+func (t *Transactor) transactionsUnsupported() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.unsupported
+}
+
+// isTransactionsUnsupported reports whether err is a standalone server refusing
+// a transaction
+func isTransactionsUnsupported(err error) bool {
+	var serverErr mongo.ServerError
+	return errors.As(err, &serverErr) && serverErr.HasErrorCode(illegalOperationCode)
+}
@@ -91,6 +91,46 @@ func (r *UpdateRolloutRepository) GetByStatus(ctx context.Context, status models
 
 // UpdateStatus updates the status of a rollout
 func (r *UpdateRolloutRepository) UpdateStatus(ctx context.Context, id primitive.ObjectID, status models.RolloutStatus, errorMessage string) error {
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, rolloutStatusUpdate(status, errorMessage))
+	if err != nil {
+		return fmt.Errorf("failed to update rollout status: %w", err)
+	}
+
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("update rollout not found")
+	}
+
+	return nil
+}
+
+// UpdateStatusFrom moves a rollout to status only if it is still in the from
+// status. It returns false without error when the rollout has moved on.
+func (r *UpdateRolloutRepository) UpdateStatusFrom(ctx context.Context, id primitive.ObjectID, from, status models.RolloutStatus, errorMessage string) (bool, error) {
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id, "status": from}, rolloutStatusUpdate(status, errorMessage))
+	if err != nil {
+		return false, fmt.Errorf("failed to update rollout status: %w", err)
+	}
+
+	return result.MatchedCount > 0, nil
+}
+
+// MarkTimedOut fails a rollout that stayed too long in the from status, only
+// if it is still there. It returns false without error when the rollout has
+// moved on.
+func (r *UpdateRolloutRepository) MarkTimedOut(ctx context.Context, id primitive.ObjectID, from models.RolloutStatus, errorMessage string) (bool, error) {
+	update := rolloutStatusUpdate(models.RolloutStatusFailed, errorMessage)
+	update["$set"].(bson.M)["timed_out_phase"] = from
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id, "status": from}, update)
+	if err != nil {
+		return false, fmt.Errorf("failed to time out rollout: %w", err)
+	}
+
+	return result.MatchedCount > 0, nil
+}
+
+// rolloutStatusUpdate builds the update for a status change and its timestamp
+func rolloutStatusUpdate(status models.RolloutStatus, errorMessage string) bson.M {
 	now := time.Now()
 	update := bson.M{
 		"$set": bson.M{
@@ -103,6 +143,7 @@ func (r *UpdateRolloutRepository) UpdateStatus(ctx context.Context, id primitive
 		update["$set"].(bson.M)["started_at"] = now
 	case models.RolloutStatusCompleted:
 		update["$set"].(bson.M)["completed_at"] = now
+		update["$set"].(bson.M)["progress"] = 100
 	case models.RolloutStatusFailed:
 		update["$set"].(bson.M)["failed_at"] = now
 		if errorMessage != "" {
@@ -110,9 +151,24 @@ func (r *UpdateRolloutRepository) UpdateStatus(ctx context.Context, id primitive
 		}
 	}
 
+	return update
+}
+
+// UpdateProgress updates the progress of a rollout
+func (r *UpdateRolloutRepository) UpdateProgress(ctx context.Context, id primitive.ObjectID, progress int) error {
+	if progress < 0 || progress > 100 {
+		return fmt.Errorf("progress must be between 0 and 100")
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			"progress": progress,
+		},
+	}
+
 	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, update)
 	if err != nil {
-		return fmt.Errorf("failed to update rollout status: %w", err)
+		return fmt.Errorf("failed to update rollout progress: %w", err)
 	}
 
 	if result.MatchedCount == 0 {
@@ -122,25 +178,84 @@ func (r *UpdateRolloutRepository) UpdateStatus(ctx context.Context, id primitive
 	return nil
 }
 
-// UpdateProgress updates the progress of a rollout
-func (r *UpdateRolloutRepository) UpdateProgress(ctx context.Context, id primitive.ObjectID, progress int) error {
+// UpdateProgressFrom raises the progress of an active rollout only if it is
+// still at the from value. It returns false without error when the rollout
+// has moved on.
+func (r *UpdateRolloutRepository) UpdateProgressFrom(ctx context.Context, id primitive.ObjectID, from, progress int) (bool, error) {
 	if progress < 0 || progress > 100 {
-		return fmt.Errorf("progress must be between 0 and 100")
+		return false, fmt.Errorf("progress must be between 0 and 100")
 	}
 
+	filter := bson.M{
+		"_id":      id,
+		"progress": from,
+		"status":   bson.M{"$in": []models.RolloutStatus{models.RolloutStatusPending, models.RolloutStatusInProgress}},
+	}
 	update := bson.M{
 		"$set": bson.M{
 			"progress": progress,
 		},
 	}
 
-	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, update)
+	result, err := r.collection.UpdateOne(ctx, filter, update)
 	if err != nil {
-		return fmt.Errorf("failed to update rollout progress: %w", err)
+		return false, fmt.Errorf("failed to update rollout progress: %w", err)
 	}
 
-	if result.MatchedCount == 0 {
-		return fmt.Errorf("update rollout not found")
+	return result.MatchedCount > 0, nil
+}
+
+// Reschedule moves a scheduled rollout to a later maintenance window. It
+// returns false without error when the rollout is no longer scheduled.
+func (r *UpdateRolloutRepository) Reschedule(ctx context.Context, id primitive.ObjectID, scheduledFor time.Time) (bool, error) {
+	filter := bson.M{
+		"_id":    id,
+		"status": models.RolloutStatusScheduled,
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"scheduled_for": scheduledFor,
+		},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, fmt.Errorf("failed to reschedule rollout: %w", err)
+	}
+
+	return result.MatchedCount > 0, nil
+}
+
+// LinkRollback records rollbackID as the rollback of a rollout, unless the
+// rollout already has one. It returns false without error when it does.
+func (r *UpdateRolloutRepository) LinkRollback(ctx context.Context, id, rollbackID primitive.ObjectID) (bool, error) {
+	filter := bson.M{
+		"_id":                 id,
+		"rollback_rollout_id": bson.M{"$exists": false},
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"rollback_rollout_id": rollbackID,
+		},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, fmt.Errorf("failed to link rollback: %w", err)
+	}
+
+	return result.MatchedCount > 0, nil
+}
+
+// UnlinkRollback removes a rollback link recorded by LinkRollback
+func (r *UpdateRolloutRepository) UnlinkRollback(ctx context.Context, id, rollbackID primitive.ObjectID) error {
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id, "rollback_rollout_id": rollbackID}, bson.M{
+		"$unset": bson.M{
+			"rollback_rollout_id": "",
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to unlink rollback: %w", err)
 	}
 
 	return nil
@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"updatemanager/internal/models"
+)
+
+// UpgradePathRuleRepository handles upgrade path rule database operations
+type UpgradePathRuleRepository struct {
+	collection *mongo.Collection
+}
+
+// NewUpgradePathRuleRepository creates a new upgrade path rule repository
+func NewUpgradePathRuleRepository(collection *mongo.Collection) *UpgradePathRuleRepository {
+	return &UpgradePathRuleRepository{
+		collection: collection,
+	}
+}
+
+// Create creates a new upgrade path rule in the database
+func (r *UpgradePathRuleRepository) Create(ctx context.Context, rule *models.UpgradePathRule) error {
+	// Set timestamps
+	now := time.Now()
+	rule.CreatedAt = now
+	rule.UpdatedAt = now
+
+	// Insert upgrade path rule
+	result, err := r.collection.InsertOne(ctx, rule)
+	if err != nil {
+		return fmt.Errorf("failed to create upgrade path rule: %w", err)
+	}
+
+	// Set the generated ID
+	if oid, ok := result.InsertedID.(primitive.ObjectID); ok {
+		rule.ID = oid
+	}
+
+	return nil
+}
+
+// GetByID retrieves an upgrade path rule by its ID
+func (r *UpgradePathRuleRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*models.UpgradePathRule, error) {
+	var rule models.UpgradePathRule
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&rule)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("upgrade path rule not found")
+		}
+		return nil, fmt.Errorf("failed to get upgrade path rule: %w", err)
+	}
+	return &rule, nil
+}
+
+// GetActiveByProductID retrieves active rules for a product in evaluation order
+func (r *UpgradePathRuleRepository) GetActiveByProductID(ctx context.Context, productID string) ([]*models.UpgradePathRule, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "priority", Value: 1}, {Key: "created_at", Value: 1}})
+	cursor, err := r.collection.Find(ctx, bson.M{"product_id": productID, "is_active": true}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get upgrade path rules: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var rules []*models.UpgradePathRule
+	if err := cursor.All(ctx, &rules); err != nil {
+		return nil, fmt.Errorf("failed to decode upgrade path rules: %w", err)
+	}
+
+	return rules, nil
+}
+
+// Update updates an existing upgrade path rule
+func (r *UpgradePathRuleRepository) Update(ctx context.Context, rule *models.UpgradePathRule) error {
+	rule.UpdatedAt = time.Now()
+
+	filter := bson.M{"_id": rule.ID}
+	update := bson.M{"$set": rule}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("failed to update upgrade path rule: %w", err)
+	}
+
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("upgrade path rule not found")
+	}
+
+	return nil
+}
+
+// Delete deletes an upgrade path rule by ID
+func (r *UpgradePathRuleRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return fmt.Errorf("failed to delete upgrade path rule: %w", err)
+	}
+
+	if result.DeletedCount == 0 {
+		return fmt.Errorf("upgrade path rule not found")
+	}
+
+	return nil
+}
+
+// List retrieves upgrade path rules with optional filters
+func (r *UpgradePathRuleRepository) List(ctx context.Context, filter bson.M, opts *options.FindOptions) ([]*models.UpgradePathRule, error) {
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list upgrade path rules: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var rules []*models.UpgradePathRule
+	if err := cursor.All(ctx, &rules); err != nil {
+		return nil, fmt.Errorf("failed to decode upgrade path rules: %w", err)
+	}
+
+	return rules, nil
+}
+
+// Count returns the count of upgrade path rules matching the filter
+func (r *UpgradePathRuleRepository) Count(ctx context.Context, filter bson.M) (int64, error) {
+	count, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count upgrade path rules: %w", err)
+	}
+	return count, nil
+}
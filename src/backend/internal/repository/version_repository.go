@@ -153,6 +153,37 @@ func (r *VersionRepository) UpdateState(ctx context.Context, id primitive.Object
 	return nil
 }
 
+// HaltRollouts flags a version as halted unless it already is halted or
+// recalled. It returns false without error when the version was already blocked.
+func (r *VersionRepository) HaltRollouts(ctx context.Context, id primitive.ObjectID, halt *models.RolloutHalt) (bool, error) {
+	filter := bson.M{
+		"_id":                 id,
+		"rollout_halt.status": bson.M{"$nin": []models.RolloutHaltStatus{models.RolloutHaltStatusHalted, models.RolloutHaltStatusRecalled}},
+	}
+	update := bson.M{"$set": bson.M{"rollout_halt": halt, "updated_at": time.Now()}}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, fmt.Errorf("failed to halt version rollouts: %w", err)
+	}
+
+	return result.MatchedCount > 0, nil
+}
+
+// ResolveRolloutHalt replaces the halt of a currently halted version. It
+// returns false without error when the version is not halted.
+func (r *VersionRepository) ResolveRolloutHalt(ctx context.Context, id primitive.ObjectID, halt *models.RolloutHalt) (bool, error) {
+	filter := bson.M{"_id": id, "rollout_halt.status": models.RolloutHaltStatusHalted}
+	update := bson.M{"$set": bson.M{"rollout_halt": halt, "updated_at": time.Now()}}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, fmt.Errorf("failed to resolve version rollout halt: %w", err)
+	}
+
+	return result.MatchedCount > 0, nil
+}
+
 // Delete deletes a version by ID
 func (r *VersionRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
 	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"updatemanager/internal/models"
+)
+
+// WebhookDeliveryRepository handles webhook delivery log database operations
+type WebhookDeliveryRepository struct {
+	collection *mongo.Collection
+}
+
+// NewWebhookDeliveryRepository creates a new webhook delivery repository
+func NewWebhookDeliveryRepository(collection *mongo.Collection) *WebhookDeliveryRepository {
+	return &WebhookDeliveryRepository{
+		collection: collection,
+	}
+}
+
+// Create stores a pending delivery, due immediately. It returns false without
+// error when a delivery with the same dedupe key already exists.
+func (r *WebhookDeliveryRepository) Create(ctx context.Context, delivery *models.WebhookDelivery) (bool, error) {
+	now := time.Now()
+	delivery.Status = models.WebhookDeliveryPending
+	delivery.CreatedAt = now
+	delivery.NextAttemptAt = now
+
+	result, err := r.collection.InsertOne(ctx, delivery)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to create webhook delivery: %w", err)
+	}
+
+	if oid, ok := result.InsertedID.(primitive.ObjectID); ok {
+		delivery.ID = oid
+	}
+
+	return true, nil
+}
+
+// GetByID retrieves a webhook delivery by its ID
+func (r *WebhookDeliveryRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&delivery)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("webhook delivery not found")
+		}
+		return nil, fmt.Errorf("failed to get webhook delivery: %w", err)
+	}
+	return &delivery, nil
+}
+
+// ClaimDue claims the oldest pending delivery due at now and leases it until
+// now+lease, so a delivery whose worker dies is retried after the lease. It
+// returns nil without error when no delivery is due.
+func (r *WebhookDeliveryRepository) ClaimDue(ctx context.Context, now time.Time, lease time.Duration) (*models.WebhookDelivery, error) {
+	filter := bson.M{
+		"status":          models.WebhookDeliveryPending,
+		"next_attempt_at": bson.M{"$lte": now},
+	}
+	update := bson.M{
+		"$set": bson.M{"next_attempt_at": now.Add(lease)},
+	}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "next_attempt_at", Value: 1}, {Key: "_id", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	var delivery models.WebhookDelivery
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&delivery)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to claim webhook delivery: %w", err)
+	}
+	return &delivery, nil
+}
+
+// RecordAttempt stores the outcome of an attempt: its status, and for
+// pending deliveries when to try again
+func (r *WebhookDeliveryRepository) RecordAttempt(ctx context.Context, delivery *models.WebhookDelivery) error {
+	set := bson.M{
+		"status":          delivery.Status,
+		"attempts":        delivery.Attempts,
+		"next_attempt_at": delivery.NextAttemptAt,
+		"request_headers": delivery.RequestHeaders,
+		"response_status": delivery.ResponseStatus,
+		"response_body":   delivery.ResponseBody,
+		"last_error":      delivery.LastError,
+		"duration_ms":     delivery.DurationMs,
+		"last_attempt_at": delivery.LastAttemptAt,
+	}
+	if delivery.DeliveredAt != nil {
+		set["delivered_at"] = delivery.DeliveredAt
+	}
+
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": delivery.ID}, bson.M{"$set": set})
+	if err != nil {
+		return fmt.Errorf("failed to record webhook delivery attempt: %w", err)
+	}
+	return nil
+}
+
+// DeleteByWebhookID deletes the delivery log of a webhook
+func (r *WebhookDeliveryRepository) DeleteByWebhookID(ctx context.Context, webhookID primitive.ObjectID) error {
+	if _, err := r.collection.DeleteMany(ctx, bson.M{"webhook_id": webhookID}); err != nil {
+		return fmt.Errorf("failed to delete webhook deliveries: %w", err)
+	}
+	return nil
+}
+
+// List retrieves webhook deliveries with optional filters
+func (r *WebhookDeliveryRepository) List(ctx context.Context, filter bson.M, opts *options.FindOptions) ([]*models.WebhookDelivery, error) {
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook deliveries: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var deliveries []*models.WebhookDelivery
+	if err := cursor.All(ctx, &deliveries); err != nil {
+		return nil, fmt.Errorf("failed to decode webhook deliveries: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+// Count counts webhook deliveries matching the filter
+func (r *WebhookDeliveryRepository) Count(ctx context.Context, filter bson.M) (int64, error) {
+	count, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count webhook deliveries: %w", err)
+	}
+	return count, nil
+}
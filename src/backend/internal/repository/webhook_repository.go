@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"updatemanager/internal/models"
+)
+
+// WebhookRepository handles webhook database operations
+type WebhookRepository struct {
+	collection *mongo.Collection
+}
+
+// NewWebhookRepository creates a new webhook repository
+func NewWebhookRepository(collection *mongo.Collection) *WebhookRepository {
+	return &WebhookRepository{
+		collection: collection,
+	}
+}
+
+// Create creates a new webhook in the database
+func (r *WebhookRepository) Create(ctx context.Context, webhook *models.Webhook) error {
+	now := time.Now()
+	webhook.CreatedAt = now
+	webhook.UpdatedAt = now
+
+	result, err := r.collection.InsertOne(ctx, webhook)
+	if err != nil {
+		return fmt.Errorf("failed to create webhook: %w", err)
+	}
+
+	if oid, ok := result.InsertedID.(primitive.ObjectID); ok {
+		webhook.ID = oid
+	}
+
+	return nil
+}
+
+// GetByID retrieves a webhook by its ID
+func (r *WebhookRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*models.Webhook, error) {
+	var webhook models.Webhook
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&webhook)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("webhook not found")
+		}
+		return nil, fmt.Errorf("failed to get webhook: %w", err)
+	}
+	return &webhook, nil
+}
+
+// Update updates an existing webhook
+func (r *WebhookRepository) Update(ctx context.Context, webhook *models.Webhook) error {
+	webhook.UpdatedAt = time.Now()
+
+	// Replaced whole so cleared filters do not linger
+	result, err := r.collection.ReplaceOne(ctx, bson.M{"_id": webhook.ID}, webhook)
+	if err != nil {
+		return fmt.Errorf("failed to update webhook: %w", err)
+	}
+
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("webhook not found")
+	}
+
+	return nil
+}
+
+// Delete deletes a webhook by ID
+func (r *WebhookRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
+
+	if result.DeletedCount == 0 {
+		return fmt.Errorf("webhook not found")
+	}
+
+	return nil
+}
+
+// ListSubscribed retrieves the active webhooks subscribed to an event type
+// and product. With everyCustomer all webhooks qualify; otherwise only the
+// global ones and those of customerID.
+func (r *WebhookRepository) ListSubscribed(ctx context.Context, eventType models.DomainEventType, productID, customerID string, everyCustomer bool) ([]*models.Webhook, error) {
+	filter := bson.M{
+		"is_active": true,
+		"$and": bson.A{
+			bson.M{"$or": bson.A{
+				bson.M{"event_types": bson.M{"$exists": false}},
+				bson.M{"event_types": eventType},
+			}},
+			bson.M{"$or": bson.A{
+				bson.M{"product_ids": bson.M{"$exists": false}},
+				bson.M{"product_ids": productID},
+			}},
+		},
+	}
+	if !everyCustomer {
+		customers := bson.A{nil}
+		if customerID != "" {
+			customers = append(customers, customerID)
+		}
+		filter["customer_id"] = bson.M{"$in": customers}
+	}
+
+	return r.List(ctx, filter, nil)
+}
+
+// List retrieves webhooks with optional filters
+func (r *WebhookRepository) List(ctx context.Context, filter bson.M, opts *options.FindOptions) ([]*models.Webhook, error) {
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhooks: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var webhooks []*models.Webhook
+	if err := cursor.All(ctx, &webhooks); err != nil {
+		return nil, fmt.Errorf("failed to decode webhooks: %w", err)
+	}
+
+	return webhooks, nil
+}
+
+// Count counts webhooks matching the filter
+func (r *WebhookRepository) Count(ctx context.Context, filter bson.M) (int64, error) {
+	count, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count webhooks: %w", err)
+	}
+	return count, nil
+}
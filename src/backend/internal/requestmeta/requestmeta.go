@@ -0,0 +1,175 @@
+// Package requestmeta carries who made a request, and from where, through
+// context.Context so that services can record it without taking it as
+// arguments.
+package requestmeta
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// Headers identifying the caller and the request
+const (
+	HeaderUserID    = "X-User-ID"
+	HeaderUserEmail = "X-User-Email"
+	HeaderRequestID = "X-Request-ID"
+)
+
+// maxRequestIDLength bounds request IDs taken from callers
+const maxRequestIDLength = 128
+
+// Metadata describes the request a change is made for
+type Metadata struct {
+	UserID    string
+	UserEmail string
+	IPAddress string
+	UserAgent string
+	RequestID string
+}
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying meta
+func NewContext(ctx context.Context, meta Metadata) context.Context {
+	return context.WithValue(ctx, contextKey{}, meta)
+}
+
+// FromContext returns the metadata carried by ctx, if any
+func FromContext(ctx context.Context) (Metadata, bool) {
+	meta, ok := ctx.Value(contextKey{}).(Metadata)
+	return meta, ok
+}
+
+// TrustedProxies are the networks of the proxies in front of the server,
+// whose forwarding headers are believed
+type TrustedProxies []*net.IPNet
+
+// ParseTrustedProxies parses a comma separated list of IP addresses and CIDR
+// ranges. An empty list trusts no proxy.
+func ParseTrustedProxies(value string) (TrustedProxies, error) {
+	var proxies TrustedProxies
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		if strings.Contains(item, "/") {
+			_, network, err := net.ParseCIDR(item)
+			if err != nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q: want an IP address or CIDR range", item)
+			}
+			proxies = append(proxies, network)
+			continue
+		}
+		ip := net.ParseIP(item)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: want an IP address or CIDR range", item)
+		}
+		if ip4 := ip.To4(); ip4 != nil {
+			proxies = append(proxies, &net.IPNet{IP: ip4, Mask: net.CIDRMask(32, 32)})
+		} else {
+			proxies = append(proxies, &net.IPNet{IP: ip, Mask: net.CIDRMask(128, 128)})
+		}
+	}
+	return proxies, nil
+}
+
+// Contains reports whether addr is the address of a trusted proxy
+func (p TrustedProxies) Contains(addr string) bool {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+	for _, network := range p {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// FromRequest reads the metadata of r, believing the forwarding headers of
+// the trusted proxies. The request ID is the caller's X-Request-ID when it
+// sends a usable one, a new random ID otherwise.
+func FromRequest(r *http.Request, trusted TrustedProxies) Metadata {
+	return Metadata{
+		UserID:    strings.TrimSpace(r.Header.Get(HeaderUserID)),
+		UserEmail: strings.TrimSpace(r.Header.Get(HeaderUserEmail)),
+		IPAddress: ClientIP(r, trusted),
+		UserAgent: r.UserAgent(),
+		RequestID: requestID(r.Header.Get(HeaderRequestID)),
+	}
+}
+
+// ClientIP returns the address of the client: the connection's address,
+// unless it is a trusted proxy. X-Forwarded-For is then walked from the
+// right, past the trusted proxies, to the first address none of them is at;
+// without X-Forwarded-For, X-Real-IP is used. Anything further left was
+// written by the client and cannot be believed.
+func ClientIP(r *http.Request, trusted TrustedProxies) string {
+	client := remoteIP(r)
+	if !trusted.Contains(client) {
+		return client
+	}
+
+	if forwarded := r.Header.Values("X-Forwarded-For"); len(forwarded) > 0 {
+		hops := strings.Split(strings.Join(forwarded, ","), ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop := strings.TrimSpace(hops[i])
+			if net.ParseIP(hop) == nil {
+				break
+			}
+			client = hop
+			if !trusted.Contains(hop) {
+				break
+			}
+		}
+		return client
+	}
+	if ip := strings.TrimSpace(r.Header.Get("X-Real-IP")); net.ParseIP(ip) != nil {
+		return ip
+	}
+	return client
+}
+
+// remoteIP returns the address of the connection's peer
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// requestID keeps a caller's request ID made of printable ASCII within the
+// length limit and generates one otherwise
+func requestID(requested string) string {
+	requested = strings.TrimSpace(requested)
+	if requested != "" && len(requested) <= maxRequestIDLength && isPrintableASCII(requested) {
+		return requested
+	}
+	return NewRequestID()
+}
+
+// NewRequestID returns a random 16 byte hex request ID
+func NewRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b)
+}
+
+func isPrintableASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < 0x21 || s[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
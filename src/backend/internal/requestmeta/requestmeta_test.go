@@ -0,0 +1,135 @@
+package requestmeta
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestFromRequest(t *testing.T) {
+	r := httptest.NewRequest("POST", "/api/v1/products", nil)
+	r.RemoteAddr = "10.0.0.5:52114"
+	r.Header.Set("X-User-ID", " alice ")
+	r.Header.Set("X-User-Email", "alice@example.com")
+	r.Header.Set("User-Agent", "curl/8.4.0")
+	r.Header.Set("X-Request-ID", "req-123")
+
+	meta := FromRequest(r, nil)
+	want := Metadata{
+		UserID:    "alice",
+		UserEmail: "alice@example.com",
+		IPAddress: "10.0.0.5",
+		UserAgent: "curl/8.4.0",
+		RequestID: "req-123",
+	}
+	if meta != want {
+		t.Errorf("FromRequest() = %+v, want %+v", meta, want)
+	}
+}
+
+func TestFromRequest_GeneratesRequestID(t *testing.T) {
+	tests := []struct {
+		name      string
+		requestID string
+	}{
+		{"missing", ""},
+		{"with spaces", "req 123"},
+		{"too long", strings.Repeat("a", maxRequestIDLength+1)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/", nil)
+			r.Header.Set("X-Request-ID", tt.requestID)
+			meta := FromRequest(r, nil)
+			if len(meta.RequestID) != 32 {
+				t.Errorf("Expected a generated request ID, got %q", meta.RequestID)
+			}
+		})
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	trusted, err := ParseTrustedProxies("10.0.0.0/8, 192.168.1.1")
+	if err != nil {
+		t.Fatalf("ParseTrustedProxies() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		headers map[string]string
+		remote  string
+		want    string
+	}{
+		{"connection", nil, "192.0.2.1:1234", "192.0.2.1"},
+		{"no port", nil, "192.0.2.1", "192.0.2.1"},
+		{"forwarded by a trusted proxy", map[string]string{"X-Forwarded-For": "203.0.113.7"}, "10.0.0.1:80", "203.0.113.7"},
+		{"forwarded through trusted proxies", map[string]string{"X-Forwarded-For": "203.0.113.7, 192.168.1.1, 10.0.0.2"}, "10.0.0.1:80", "203.0.113.7"},
+		{"spoofed entry left of the client", map[string]string{"X-Forwarded-For": "198.51.100.1, 203.0.113.7, 10.0.0.2"}, "10.0.0.1:80", "203.0.113.7"},
+		{"forwarded by an untrusted peer", map[string]string{"X-Forwarded-For": "203.0.113.7"}, "192.0.2.1:80", "192.0.2.1"},
+		{"real IP from a trusted proxy", map[string]string{"X-Real-IP": "203.0.113.8"}, "10.0.0.1:80", "203.0.113.8"},
+		{"real IP from an untrusted peer", map[string]string{"X-Real-IP": "203.0.113.8"}, "192.0.2.1:80", "192.0.2.1"},
+		{"only trusted hops", map[string]string{"X-Forwarded-For": "10.0.0.3, 10.0.0.2"}, "10.0.0.1:80", "10.0.0.3"},
+		{"malformed hop", map[string]string{"X-Forwarded-For": "unknown, 10.0.0.2"}, "10.0.0.1:80", "10.0.0.2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/", nil)
+			r.RemoteAddr = tt.remote
+			for name, value := range tt.headers {
+				r.Header.Set(name, value)
+			}
+			if got := ClientIP(r, trusted); got != tt.want {
+				t.Errorf("ClientIP() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	// Without trusted proxies forwarding headers are ignored
+	r := httptest.NewRequest("GET", "/", nil)
+	r.RemoteAddr = "10.0.0.1:80"
+	r.Header.Set("X-Forwarded-For", "203.0.113.7")
+	if got := ClientIP(r, nil); got != "10.0.0.1" {
+		t.Errorf("ClientIP() = %q, want the connection's address", got)
+	}
+}
+
+func TestParseTrustedProxies(t *testing.T) {
+	proxies, err := ParseTrustedProxies(" 10.0.0.0/8, ,fd00::/8,192.168.1.1,::1 ")
+	if err != nil {
+		t.Fatalf("ParseTrustedProxies() error = %v", err)
+	}
+	if len(proxies) != 4 {
+		t.Fatalf("Expected 4 proxies, got %d", len(proxies))
+	}
+	for _, addr := range []string{"10.1.2.3", "fd00::1", "192.168.1.1", "::1"} {
+		if !proxies.Contains(addr) {
+			t.Errorf("Expected %s to be trusted", addr)
+		}
+	}
+	for _, addr := range []string{"192.168.1.2", "2001:db8::1", "not an ip"} {
+		if proxies.Contains(addr) {
+			t.Errorf("Expected %s not to be trusted", addr)
+		}
+	}
+
+	for _, value := range []string{"10.0.0.0/33", "proxy.internal"} {
+		if _, err := ParseTrustedProxies(value); err == nil || !strings.Contains(err.Error(), "invalid trusted proxy") {
+			t.Errorf("Expected %q to be rejected, got %v", value, err)
+		}
+	}
+}
+
+func TestContext(t *testing.T) {
+	if _, ok := FromContext(context.Background()); ok {
+		t.Error("Expected no metadata in an empty context")
+	}
+
+	meta := Metadata{UserID: "alice", RequestID: "req-1"}
+	got, ok := FromContext(NewContext(context.Background(), meta))
+	if !ok || got != meta {
+		t.Errorf("FromContext() = %+v, %v, want %+v", got, ok, meta)
+	}
+}
@@ -0,0 +1,265 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"updatemanager/internal/auditchain"
+	"updatemanager/internal/models"
+	"updatemanager/internal/repository"
+)
+
+// auditChainBatchSize is how many entries are read at a time walking the chain
+const auditChainBatchSize = 1000
+
+// AuditChainService verifies the audit log hash chain and signs checkpoints
+// of its head
+type AuditChainService struct {
+	auditRepo      *repository.AuditLogRepository
+	checkpointRepo *repository.AuditCheckpointRepository
+	signer         *auditchain.Signer
+	exportDir      string
+}
+
+// NewAuditChainService creates a new audit chain service
+func NewAuditChainService(auditRepo *repository.AuditLogRepository, checkpointRepo *repository.AuditCheckpointRepository) *AuditChainService {
+	return &AuditChainService{
+		auditRepo:      auditRepo,
+		checkpointRepo: checkpointRepo,
+	}
+}
+
+// SetCheckpointSigner enables checkpoints, signed with the given key. Each
+// checkpoint is also written to exportDir, when set, to be shipped off the
+// database.
+func (s *AuditChainService) SetCheckpointSigner(signer *auditchain.Signer, exportDir string) {
+	s.signer = signer
+	s.exportDir = exportDir
+}
+
+// EnsureSequenceIndex creates the unique index that keeps two entries from
+// taking the same place in the chain
+func (s *AuditChainService) EnsureSequenceIndex(ctx context.Context) error {
+	return s.auditRepo.EnsureSequenceIndex(ctx)
+}
+
+// VerifyChain walks the whole chain and reports its first broken link: an
+// edited, missing or reordered entry, an entry that does not match a
+// checkpoint, or a chain that ends before its latest checkpoint. Entries
+// written before the chain existed are counted but not covered.
+func (s *AuditChainService) VerifyChain(ctx context.Context) (*models.AuditChainVerification, error) {
+	result := &models.AuditChainVerification{CheckedAt: time.Now()}
+
+	unchained, err := s.auditRepo.Count(ctx, bson.M{"sequence": bson.M{"$exists": false}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to count audit logs: %w", err)
+	}
+	result.Unchained = unchained
+
+	checkpoints, err := s.checkpointRepo.List(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "sequence", Value: 1}}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit checkpoints: %w", err)
+	}
+	checkpointsAt := make(map[int64][]*models.AuditCheckpoint)
+	for _, checkpoint := range checkpoints {
+		checkpointsAt[checkpoint.Sequence] = append(checkpointsAt[checkpoint.Sequence], checkpoint)
+	}
+
+	head, broken, err := s.walkChain(ctx, nil, func(entry *models.AuditLog) *models.AuditChainBreak {
+		for _, checkpoint := range checkpointsAt[entry.Sequence] {
+			trusted, brk := s.checkCheckpoint(checkpoint, entry)
+			if brk != nil {
+				return brk
+			}
+			if trusted {
+				result.Checkpoints++
+			}
+		}
+		result.Verified++
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if head != nil {
+		result.HeadSequence = head.Sequence
+		result.HeadHash = head.Hash
+	}
+	result.FirstBroken = broken
+
+	// Entries removed from the end of the chain leave no gap behind
+	if broken == nil && len(checkpoints) > 0 {
+		latest := checkpoints[len(checkpoints)-1]
+		if latest.Sequence > result.HeadSequence {
+			result.FirstBroken = &models.AuditChainBreak{
+				Sequence: result.HeadSequence + 1,
+				Reason:   fmt.Sprintf("chain ends before the checkpoint at sequence %d: entries were removed", latest.Sequence),
+				Expected: latest.Hash,
+			}
+		}
+	}
+
+	result.Valid = result.FirstBroken == nil
+	return result, nil
+}
+
+// CreateCheckpoint signs the chain's head, after checking the entries added
+// since the latest checkpoint. It returns nil when nothing was added since.
+func (s *AuditChainService) CreateCheckpoint(ctx context.Context) (*models.AuditCheckpoint, error) {
+	if s.signer == nil {
+		return nil, fmt.Errorf("audit checkpoints are not configured")
+	}
+
+	latest, err := s.checkpointRepo.Latest(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var start *models.AuditLog
+	if latest != nil {
+		start, err = s.auditRepo.GetBySequence(ctx, latest.Sequence)
+		if err != nil {
+			return nil, fmt.Errorf("audit chain does not reach the checkpoint at sequence %d: %w", latest.Sequence, err)
+		}
+		contentHash, err := auditchain.ContentHash(start)
+		if err != nil || contentHash != start.ContentHash || start.Hash != latest.Hash || start.Hash != auditchain.ChainHash(start.PrevHash, contentHash) {
+			return nil, fmt.Errorf("audit chain does not match the checkpoint at sequence %d", latest.Sequence)
+		}
+	}
+
+	head, broken, err := s.walkChain(ctx, start, nil)
+	if err != nil {
+		return nil, err
+	}
+	if broken != nil {
+		return nil, fmt.Errorf("audit chain is broken at sequence %d: %s", broken.Sequence, broken.Reason)
+	}
+	if head == nil || head == start {
+		return nil, nil
+	}
+
+	checkpoint := &models.AuditCheckpoint{
+		ID:        primitive.NewObjectID(),
+		Sequence:  head.Sequence,
+		Hash:      head.Hash,
+		CreatedAt: time.Now().UTC().Truncate(time.Millisecond),
+	}
+	s.signer.Sign(checkpoint)
+	if err := s.checkpointRepo.Create(ctx, checkpoint); err != nil {
+		return nil, err
+	}
+
+	if s.exportDir != "" {
+		if err := s.exportCheckpoint(checkpoint); err != nil {
+			return checkpoint, err
+		}
+	}
+	return checkpoint, nil
+}
+
+// ListCheckpoints retrieves checkpoints, latest first
+func (s *AuditChainService) ListCheckpoints(ctx context.Context, page, limit int) ([]*models.AuditCheckpoint, int64, error) {
+	opts := options.Find()
+	if limit > 0 {
+		opts.SetLimit(int64(limit))
+		opts.SetSkip(int64((page - 1) * limit))
+	}
+	opts.SetSort(bson.M{"sequence": -1})
+
+	checkpoints, err := s.checkpointRepo.List(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	total, err := s.checkpointRepo.Count(ctx, bson.M{})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return checkpoints, total, nil
+}
+
+// walkChain checks the chain from the entry after prev, or from its start for
+// a nil prev, calling visit, when set, for every intact entry. It returns the
+// last intact entry and the first broken link.
+func (s *AuditChainService) walkChain(ctx context.Context, prev *models.AuditLog, visit func(*models.AuditLog) *models.AuditChainBreak) (*models.AuditLog, *models.AuditChainBreak, error) {
+	opts := options.Find().
+		SetSort(bson.D{{Key: "sequence", Value: 1}}).
+		SetLimit(auditChainBatchSize)
+
+	for {
+		filter := bson.M{"sequence": bson.M{"$exists": true}}
+		if prev != nil {
+			filter["sequence"] = bson.M{"$gt": prev.Sequence}
+		}
+		batch, err := s.auditRepo.List(ctx, filter, opts)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		for _, entry := range batch {
+			if brk := auditchain.Check(entry, prev); brk != nil {
+				return prev, brk, nil
+			}
+			if visit != nil {
+				if brk := visit(entry); brk != nil {
+					return prev, brk, nil
+				}
+			}
+			prev = entry
+		}
+
+		if len(batch) < auditChainBatchSize {
+			return prev, nil, nil
+		}
+	}
+}
+
+// checkCheckpoint matches a checkpoint against the entry at its sequence. A
+// checkpoint signed by another key than the configured one is not trusted
+// and skipped; one that fails its own signature was modified.
+func (s *AuditChainService) checkCheckpoint(checkpoint *models.AuditCheckpoint, entry *models.AuditLog) (bool, *models.AuditChainBreak) {
+	if err := auditchain.VerifyCheckpoint(checkpoint); err != nil {
+		return false, &models.AuditChainBreak{
+			Sequence: checkpoint.Sequence,
+			EntryID:  entry.ID.Hex(),
+			Reason:   fmt.Sprintf("checkpoint %s is invalid: %v", checkpoint.ID.Hex(), err),
+		}
+	}
+	if s.signer != nil && checkpoint.KeyID != s.signer.KeyID() {
+		return false, nil
+	}
+	if checkpoint.Hash != entry.Hash {
+		return false, &models.AuditChainBreak{
+			Sequence: entry.Sequence,
+			EntryID:  entry.ID.Hex(),
+			Reason:   fmt.Sprintf("hash does not match checkpoint %s: the chain was rewritten", checkpoint.ID.Hex()),
+			Expected: checkpoint.Hash,
+			Actual:   entry.Hash,
+		}
+	}
+	return true, nil
+}
+
+// exportCheckpoint writes a checkpoint as JSON to the export directory
+func (s *AuditChainService) exportCheckpoint(checkpoint *models.AuditCheckpoint) error {
+	data, err := json.MarshalIndent(checkpoint, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode audit checkpoint: %w", err)
+	}
+	if err := os.MkdirAll(s.exportDir, 0o755); err != nil {
+		return fmt.Errorf("failed to export audit checkpoint: %w", err)
+	}
+	path := filepath.Join(s.exportDir, fmt.Sprintf("audit-checkpoint-%d.json", checkpoint.Sequence))
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to export audit checkpoint: %w", err)
+	}
+	return nil
+}
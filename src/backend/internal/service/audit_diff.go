@@ -0,0 +1,328 @@
+package service
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"updatemanager/internal/models"
+)
+
+// auditRedactedValue replaces the values of redacted fields
+const auditRedactedValue = "[REDACTED]"
+
+// DefaultAuditRedactedFields are always redacted: webhook signing secrets and
+// notification route targets, which are webhook URLs or routing keys
+var DefaultAuditRedactedFields = []string{"webhook:secret", "notification_route:target"}
+
+// auditIgnoredFields are left out of diffs and states
+var auditIgnoredFields = map[string]bool{"_id": true, "updated_at": true}
+
+var auditFieldPattern = regexp.MustCompile(`^([a-z0-9_]+:)?[a-z0-9_]+(\.[a-z0-9_]+)*$`)
+
+// auditFieldRule redacts a field, and the fields nested in it, of one
+// resource type or, without a resource type, of every resource
+type auditFieldRule struct {
+	resourceType string
+	field        string
+}
+
+// ParseAuditRedactedFields parses a comma separated list of fields to redact
+// from audit entries, each "field" or "resource_type:field" with nested fields
+// named by their dotted path, e.g. "customer:contact.phone"
+func ParseAuditRedactedFields(value string) ([]string, error) {
+	var fields []string
+	for _, field := range strings.Split(value, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		if !auditFieldPattern.MatchString(field) {
+			return nil, fmt.Errorf("invalid audit redacted field %q: want field or resource_type:field", field)
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+func parseAuditFieldRules(fields []string) []auditFieldRule {
+	rules := make([]auditFieldRule, 0, len(fields))
+	for _, field := range fields {
+		rule := auditFieldRule{field: field}
+		if resourceType, name, ok := strings.Cut(field, ":"); ok {
+			rule = auditFieldRule{resourceType: resourceType, field: name}
+		}
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+// auditFieldRedacted reports whether a field of a resource type is redacted
+func auditFieldRedacted(rules []auditFieldRule, resourceType, field string) bool {
+	for _, rule := range rules {
+		if rule.resourceType != "" && rule.resourceType != resourceType {
+			continue
+		}
+		if field == rule.field || strings.HasPrefix(field, rule.field+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// auditState captures the state of a model as stored: its BSON document with
+// nested documents as bson.M. Documents captured earlier are returned as is,
+// so callers can capture a model's state before changing it.
+func auditState(v interface{}) bson.M {
+	switch state := v.(type) {
+	case nil:
+		return nil
+	case bson.M:
+		return state
+	case map[string]interface{}:
+		return bson.M(state)
+	}
+	if value := reflect.ValueOf(v); value.Kind() == reflect.Ptr && value.IsNil() {
+		return nil
+	}
+
+	data, err := bson.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	var state bson.M
+	if err := bson.Unmarshal(data, &state); err != nil {
+		return nil
+	}
+	for field := range auditIgnoredFields {
+		delete(state, field)
+	}
+	return state
+}
+
+// auditChanges lists the fields that differ between two states, sorted by
+// field. Nested documents are compared field by field; arrays as a whole.
+// Fields missing from after are marked removed.
+func auditChanges(before, after bson.M) []models.AuditFieldChange {
+	old := flattenAuditState(before)
+	updated := flattenAuditState(after)
+
+	var changes []models.AuditFieldChange
+	for field, oldValue := range old {
+		newValue, ok := updated[field]
+		if !ok {
+			changes = append(changes, models.AuditFieldChange{Field: field, OldValue: oldValue, Removed: true})
+			continue
+		}
+		if !reflect.DeepEqual(oldValue, newValue) {
+			changes = append(changes, models.AuditFieldChange{Field: field, OldValue: oldValue, NewValue: newValue})
+		}
+	}
+	for field, newValue := range updated {
+		if _, ok := old[field]; !ok {
+			changes = append(changes, models.AuditFieldChange{Field: field, NewValue: newValue})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+	return changes
+}
+
+// flattenAuditState maps the dotted path of every non-document value in a
+// state to the value. Empty documents are kept as values.
+func flattenAuditState(state map[string]interface{}) map[string]interface{} {
+	flat := make(map[string]interface{})
+	var walk func(prefix string, doc map[string]interface{})
+	walk = func(prefix string, doc map[string]interface{}) {
+		for key, value := range doc {
+			if prefix == "" && auditIgnoredFields[key] {
+				continue
+			}
+			path := key
+			if prefix != "" {
+				path = prefix + "." + key
+			}
+			if nested, ok := auditDocument(value); ok && len(nested) > 0 {
+				walk(path, nested)
+				continue
+			}
+			flat[path] = normalizeAuditValue(value)
+		}
+	}
+	walk("", state)
+	return flat
+}
+
+// unflattenAuditState turns dotted paths back into nested documents
+func unflattenAuditState(flat map[string]interface{}) map[string]interface{} {
+	state := make(map[string]interface{})
+	fields := make([]string, 0, len(flat))
+	for field := range flat {
+		fields = append(fields, field)
+	}
+	// Parents before their fields, so a field replaces a stale parent value
+	sort.Strings(fields)
+
+	for _, field := range fields {
+		parts := strings.Split(field, ".")
+		doc := state
+		for _, part := range parts[:len(parts)-1] {
+			next, ok := doc[part].(map[string]interface{})
+			if !ok {
+				next = make(map[string]interface{})
+				doc[part] = next
+			}
+			doc = next
+		}
+		doc[parts[len(parts)-1]] = flat[field]
+	}
+	return state
+}
+
+// redactAuditState replaces redacted fields of a state, wherever they are nested
+func redactAuditState(rules []auditFieldRule, resourceType string, state map[string]interface{}) {
+	var walk func(prefix string, doc map[string]interface{})
+	walk = func(prefix string, doc map[string]interface{}) {
+		for key, value := range doc {
+			path := key
+			if prefix != "" {
+				path = prefix + "." + key
+			}
+			if auditFieldRedacted(rules, resourceType, path) {
+				doc[key] = auditRedactedValue
+				continue
+			}
+			if nested, ok := auditDocument(value); ok {
+				walk(path, nested)
+			}
+		}
+	}
+	walk("", state)
+}
+
+// redactAuditChanges replaces the values of redacted fields. The change
+// itself is kept, so it is still visible that the field changed.
+func redactAuditChanges(rules []auditFieldRule, resourceType string, changes []models.AuditFieldChange) {
+	for i := range changes {
+		if auditFieldRedacted(rules, resourceType, changes[i].Field) {
+			changes[i].OldValue = auditRedactedValue
+			if !changes[i].Removed {
+				changes[i].NewValue = auditRedactedValue
+			}
+		}
+	}
+}
+
+// auditDocument returns a BSON document value as a map
+func auditDocument(value interface{}) (map[string]interface{}, bool) {
+	switch doc := value.(type) {
+	case bson.M:
+		return doc, true
+	case map[string]interface{}:
+		return doc, true
+	case bson.D:
+		m := make(map[string]interface{}, len(doc))
+		for _, element := range doc {
+			m[element.Key] = element.Value
+		}
+		return m, true
+	}
+	return nil, false
+}
+
+// normalizeAuditValue turns documents read back from the database into maps
+// and arrays into slices, so they compare and encode to JSON like the
+// captured ones
+func normalizeAuditValue(value interface{}) interface{} {
+	if doc, ok := auditDocument(value); ok {
+		normalized := make(map[string]interface{}, len(doc))
+		for key, nested := range doc {
+			normalized[key] = normalizeAuditValue(nested)
+		}
+		return normalized
+	}
+	if array, ok := value.(primitive.A); ok {
+		normalized := make([]interface{}, len(array))
+		for i, item := range array {
+			normalized[i] = normalizeAuditValue(item)
+		}
+		return normalized
+	}
+	if array, ok := value.([]interface{}); ok {
+		normalized := make([]interface{}, len(array))
+		for i, item := range array {
+			normalized[i] = normalizeAuditValue(item)
+		}
+		return normalized
+	}
+	return value
+}
+
+// replayAuditEntries rebuilds a resource's state from its audit entries in
+// order. A create carrying the created state starts from it; updates apply
+// their changes; a delete without changes or removing every field removes the
+// resource, while one with other changes is a soft delete.
+func replayAuditEntries(resourceType, resourceID string, entries []*models.AuditLog) *models.AuditResourceState {
+	result := &models.AuditResourceState{ResourceType: resourceType, ResourceID: resourceID}
+	flat := make(map[string]interface{})
+
+	for _, entry := range entries {
+		result.Entries++
+		timestamp := entry.Timestamp
+		result.LastChangedAt = &timestamp
+		result.LastChangedBy = entry.UserID
+
+		switch {
+		case entry.Action == models.AuditActionCreate && entry.State != nil:
+			flat = flattenAuditState(entry.State)
+			result.Exists = true
+			result.Complete = true
+		case entry.Action == models.AuditActionCreate:
+			result.Exists = true
+		case entry.Action == models.AuditActionDelete && auditFieldsRemoved(entry.Changes):
+			result.Exists = false
+		default:
+			result.Exists = true
+		}
+
+		for _, change := range entry.Changes {
+			// A field replaces whatever was nested in it before
+			for field := range flat {
+				if strings.HasPrefix(field, change.Field+".") {
+					delete(flat, field)
+				}
+			}
+			if change.Removed {
+				delete(flat, change.Field)
+				continue
+			}
+			if newValue, ok := auditDocument(change.NewValue); ok && len(newValue) > 0 {
+				for field, value := range flattenAuditState(newValue) {
+					flat[change.Field+"."+field] = value
+				}
+				delete(flat, change.Field)
+				continue
+			}
+			flat[change.Field] = normalizeAuditValue(change.NewValue)
+		}
+	}
+
+	result.State = unflattenAuditState(flat)
+	return result
+}
+
+// auditFieldsRemoved reports whether every change removes its field, as a
+// hard delete's do
+func auditFieldsRemoved(changes []models.AuditFieldChange) bool {
+	for _, change := range changes {
+		if !change.Removed {
+			return false
+		}
+	}
+	return true
+}
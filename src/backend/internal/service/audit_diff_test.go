@@ -0,0 +1,203 @@
+package service
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"updatemanager/internal/models"
+)
+
+func TestAuditChanges(t *testing.T) {
+	customer := &models.Customer{
+		ID:            primitive.NewObjectID(),
+		CustomerID:    "acme",
+		Name:          "Acme",
+		Email:         "ops@acme.example",
+		AccountStatus: models.CustomerStatusActive,
+		NotificationPreferences: models.NotificationPreferences{
+			DigestFrequency: "daily",
+		},
+		UpdatedAt: time.Now(),
+	}
+	before := auditState(customer)
+
+	customer.Name = "Acme Corp"
+	customer.NotificationPreferences.DigestFrequency = "weekly"
+	customer.UpdatedAt = time.Now().Add(time.Minute)
+
+	changes := auditChanges(before, auditState(customer))
+	want := []models.AuditFieldChange{
+		{Field: "name", OldValue: "Acme", NewValue: "Acme Corp"},
+		{Field: "notification_preferences.digest_frequency", OldValue: "daily", NewValue: "weekly"},
+	}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("auditChanges() = %+v, want %+v", changes, want)
+	}
+
+	if changes := auditChanges(before, before); len(changes) != 0 {
+		t.Errorf("Expected no changes between equal states, got %+v", changes)
+	}
+
+	// A removed field is told apart from one set to null
+	changes = auditChanges(map[string]interface{}{"phone": "+49 40 123", "fax": "+49 40 456"}, map[string]interface{}{"fax": nil})
+	want = []models.AuditFieldChange{
+		{Field: "fax", OldValue: "+49 40 456", NewValue: nil},
+		{Field: "phone", OldValue: "+49 40 123", Removed: true},
+	}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("auditChanges() = %+v, want %+v", changes, want)
+	}
+}
+
+func TestParseAuditRedactedFields(t *testing.T) {
+	fields, err := ParseAuditRedactedFields(" customer:email, contact.phone ,,")
+	if err != nil {
+		t.Fatalf("ParseAuditRedactedFields() error = %v", err)
+	}
+	if !reflect.DeepEqual(fields, []string{"customer:email", "contact.phone"}) {
+		t.Errorf("Unexpected fields %v", fields)
+	}
+
+	for _, value := range []string{"Email", "customer:", "customer:email.", "a:b:c"} {
+		if _, err := ParseAuditRedactedFields(value); err == nil || !strings.Contains(err.Error(), "invalid audit redacted field") {
+			t.Errorf("Expected %q to be rejected, got %v", value, err)
+		}
+	}
+}
+
+func TestAuditLogger_RecordChangeRedacts(t *testing.T) {
+	logger := NewAuditLogger(nil)
+	logger.SetRedactedFields([]string{"customer:email"})
+
+	webhook := &models.Webhook{ID: primitive.NewObjectID(), URL: "https://hooks.example/a", Secret: "s3cret"}
+	entry := auditLogEntry(context.Background(), models.AuditActionCreate, "webhook", webhook.ID.Hex(), "alice", "", nil)
+	logger.recordChange(entry, nil, webhook)
+	if entry.State["secret"] != auditRedactedValue || entry.State["url"] != webhook.URL {
+		t.Errorf("Expected the secret redacted and the URL kept, got %v", entry.State)
+	}
+	if _, ok := entry.State["_id"]; ok {
+		t.Error("Expected the ID left out of the state")
+	}
+
+	customer := &models.Customer{Name: "Acme", Email: "old@acme.example"}
+	before := auditState(customer)
+	customer.Name = "Acme Corp"
+	customer.Email = "new@acme.example"
+	entry = auditLogEntry(context.Background(), models.AuditActionUpdate, "customer", "c1", "alice", "", nil)
+	logger.recordChange(entry, before, customer)
+	want := []models.AuditFieldChange{
+		{Field: "email", OldValue: auditRedactedValue, NewValue: auditRedactedValue},
+		{Field: "name", OldValue: "Acme", NewValue: "Acme Corp"},
+	}
+	if !reflect.DeepEqual(entry.Changes, want) {
+		t.Errorf("Changes = %+v, want %+v", entry.Changes, want)
+	}
+
+	// A delete records every field removed, redacted ones without their value
+	entry = auditLogEntry(context.Background(), models.AuditActionDelete, "webhook", webhook.ID.Hex(), "alice", "", nil)
+	logger.recordChange(entry, webhook, nil)
+	for _, change := range entry.Changes {
+		if !change.Removed || change.NewValue != nil {
+			t.Errorf("Expected %s removed, got %+v", change.Field, change)
+		}
+		if change.Field == "secret" && change.OldValue != auditRedactedValue {
+			t.Errorf("Expected the removed secret redacted, got %+v", change)
+		}
+	}
+	if len(entry.Changes) == 0 {
+		t.Error("Expected the deleted webhook's fields recorded")
+	}
+
+	// Email is only redacted for customers
+	entry = auditLogEntry(context.Background(), models.AuditActionUpdate, "internal_group", "g1", "alice", "", nil)
+	logger.recordChange(entry, map[string]interface{}{"email": "a"}, map[string]interface{}{"email": "b"})
+	if len(entry.Changes) != 1 || entry.Changes[0].NewValue != "b" {
+		t.Errorf("Expected email of other resources kept, got %+v", entry.Changes)
+	}
+}
+
+func TestReplayAuditEntries(t *testing.T) {
+	at := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	entries := []*models.AuditLog{
+		{Action: models.AuditActionCreate, UserID: "alice", Timestamp: at, State: map[string]interface{}{
+			"name":    "Acme",
+			"address": map[string]interface{}{"city": "Berlin", "zip": "10115"},
+		}},
+		{Action: models.AuditActionUpdate, UserID: "bob", Timestamp: at.Add(time.Hour), Changes: []models.AuditFieldChange{
+			{Field: "address.city", OldValue: "Berlin", NewValue: "Hamburg"},
+			{Field: "phone", NewValue: "+49 40 123"},
+		}},
+	}
+
+	state := replayAuditEntries("customer", "c1", entries)
+	want := map[string]interface{}{
+		"name":    "Acme",
+		"phone":   "+49 40 123",
+		"address": map[string]interface{}{"city": "Hamburg", "zip": "10115"},
+	}
+	if !reflect.DeepEqual(state.State, want) {
+		t.Errorf("State = %v, want %v", state.State, want)
+	}
+	if !state.Exists || !state.Complete || state.Entries != 2 || state.LastChangedBy != "bob" {
+		t.Errorf("Unexpected replay result %+v", state)
+	}
+
+	// A removed field is gone from the state rather than null
+	removed := append(entries, &models.AuditLog{Action: models.AuditActionUpdate, Timestamp: at.Add(2 * time.Hour), Changes: []models.AuditFieldChange{
+		{Field: "phone", OldValue: "+49 40 123", Removed: true},
+	}})
+	if state := replayAuditEntries("customer", "c1", removed); !reflect.DeepEqual(state.State, map[string]interface{}{
+		"name":    "Acme",
+		"address": map[string]interface{}{"city": "Hamburg", "zip": "10115"},
+	}) {
+		t.Errorf("Expected the phone removed from the state, got %v", state.State)
+	}
+
+	// A soft delete keeps the resource; a hard delete removes it
+	softDeleted := append(entries, &models.AuditLog{Action: models.AuditActionDelete, Timestamp: at.Add(2 * time.Hour), Changes: []models.AuditFieldChange{
+		{Field: "account_status", OldValue: "active", NewValue: "inactive"},
+	}})
+	if state := replayAuditEntries("customer", "c1", softDeleted); !state.Exists || state.State["account_status"] != "inactive" {
+		t.Errorf("Expected a soft deleted customer, got %+v", state)
+	}
+	deleted := append(entries, &models.AuditLog{Action: models.AuditActionDelete, Timestamp: at.Add(2 * time.Hour)})
+	if state := replayAuditEntries("customer", "c1", deleted); state.Exists {
+		t.Error("Expected a deleted customer not to exist")
+	}
+	deleted = append(entries, &models.AuditLog{Action: models.AuditActionDelete, Timestamp: at.Add(2 * time.Hour), Changes: []models.AuditFieldChange{
+		{Field: "address.city", OldValue: "Hamburg", Removed: true},
+		{Field: "address.zip", OldValue: "10115", Removed: true},
+		{Field: "name", OldValue: "Acme", Removed: true},
+		{Field: "phone", OldValue: "+49 40 123", Removed: true},
+	}})
+	if state := replayAuditEntries("customer", "c1", deleted); state.Exists || len(state.State) != 0 {
+		t.Errorf("Expected a deleted customer with every field removed not to exist, got %+v", state)
+	}
+
+	// Without the created state only the changed fields are known
+	if state := replayAuditEntries("customer", "c1", entries[1:]); state.Complete {
+		t.Error("Expected an incomplete state without the create entry")
+	}
+}
+
+func TestUnflattenAuditState(t *testing.T) {
+	state := unflattenAuditState(map[string]interface{}{
+		"a":     1,
+		"b":     nil,
+		"b.c":   2,
+		"d.e.f": 3,
+	})
+	want := map[string]interface{}{
+		"a": 1,
+		"b": map[string]interface{}{"c": 2},
+		"d": map[string]interface{}{"e": map[string]interface{}{"f": 3}},
+	}
+	if !reflect.DeepEqual(state, want) {
+		t.Errorf("unflattenAuditState() = %v, want %v", state, want)
+	}
+}
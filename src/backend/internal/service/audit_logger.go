@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"updatemanager/internal/models"
+	"updatemanager/internal/repository"
+	"updatemanager/internal/requestmeta"
+)
+
+// schedulerUserID is recorded as the actor for changes made by the scheduler
+// and other background work
+const schedulerUserID = "system"
+
+// AuditLogger writes the audit log entries of every service. Entries are
+// stamped with the request metadata carried in the context. A nil
+// AuditLogger, or one without a repository, records nothing.
+type AuditLogger struct {
+	auditRepo *repository.AuditLogRepository
+	redacted  []auditFieldRule
+}
+
+// NewAuditLogger creates an audit logger writing to auditRepo that redacts
+// DefaultAuditRedactedFields
+func NewAuditLogger(auditRepo *repository.AuditLogRepository) *AuditLogger {
+	return &AuditLogger{
+		auditRepo: auditRepo,
+		redacted:  parseAuditFieldRules(DefaultAuditRedactedFields),
+	}
+}
+
+// SetRedactedFields redacts fields, as parsed by ParseAuditRedactedFields, in
+// addition to DefaultAuditRedactedFields. Call it before logging starts.
+func (l *AuditLogger) SetRedactedFields(fields []string) {
+	l.redacted = parseAuditFieldRules(append(append([]string{}, DefaultAuditRedactedFields...), fields...))
+}
+
+// Log records an action on a resource. userID and userEmail default to the
+// request's, and userID to "system" outside of a request. Audit logging is
+// best effort and never fails the change.
+func (l *AuditLogger) Log(ctx context.Context, action models.AuditAction, resourceType, resourceID, userID, userEmail string, details map[string]interface{}) {
+	l.LogChange(ctx, action, resourceType, resourceID, userID, userEmail, nil, nil, details)
+}
+
+// LogChange records an action together with the change it made to the
+// resource: the created state when there is no before, every field removed
+// when there is no after, otherwise the fields that differ between before and
+// after. before and after are models, or states captured with auditState
+// before the model was changed.
+func (l *AuditLogger) LogChange(ctx context.Context, action models.AuditAction, resourceType, resourceID, userID, userEmail string, before, after interface{}, details map[string]interface{}) {
+	if l == nil || l.auditRepo == nil {
+		return
+	}
+
+	entry := auditLogEntry(ctx, action, resourceType, resourceID, userID, userEmail, details)
+	l.recordChange(entry, before, after)
+	if err := l.auditRepo.Create(ctx, entry); err != nil {
+		log.Printf("Failed to write audit log for %s %s %s: %v", action, resourceType, resourceID, err)
+	}
+}
+
+// recordChange adds the created state or the changed fields to an entry
+func (l *AuditLogger) recordChange(entry *models.AuditLog, before, after interface{}) {
+	beforeState := auditState(before)
+	afterState := auditState(after)
+	if afterState == nil {
+		if beforeState != nil {
+			entry.Changes = auditChanges(beforeState, nil)
+			redactAuditChanges(l.redacted, entry.ResourceType, entry.Changes)
+		}
+		return
+	}
+
+	if beforeState == nil {
+		state := normalizeAuditValue(map[string]interface{}(afterState)).(map[string]interface{})
+		for field := range auditIgnoredFields {
+			delete(state, field)
+		}
+		redactAuditState(l.redacted, entry.ResourceType, state)
+		entry.State = state
+		return
+	}
+
+	entry.Changes = auditChanges(beforeState, afterState)
+	redactAuditChanges(l.redacted, entry.ResourceType, entry.Changes)
+}
+
+// auditLogEntry builds an entry, filling in what the caller left out from the
+// request metadata
+func auditLogEntry(ctx context.Context, action models.AuditAction, resourceType, resourceID, userID, userEmail string, details map[string]interface{}) *models.AuditLog {
+	entry := &models.AuditLog{
+		Action:       action,
+		ResourceType: resourceType,
+		ResourceID:   resourceID,
+		UserID:       userID,
+		UserEmail:    userEmail,
+		Details:      details,
+		Timestamp:    time.Now(),
+	}
+
+	if meta, ok := requestmeta.FromContext(ctx); ok {
+		if entry.UserID == "" {
+			entry.UserID = meta.UserID
+		}
+		if entry.UserEmail == "" && entry.UserID == meta.UserID {
+			entry.UserEmail = meta.UserEmail
+		}
+		entry.IPAddress = meta.IPAddress
+		entry.UserAgent = meta.UserAgent
+		entry.RequestID = meta.RequestID
+	}
+	if entry.UserID == "" {
+		entry.UserID = schedulerUserID
+	}
+	return entry
+}
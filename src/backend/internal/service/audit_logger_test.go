@@ -0,0 +1,58 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"updatemanager/internal/models"
+	"updatemanager/internal/requestmeta"
+)
+
+func TestAuditLogEntry(t *testing.T) {
+	requestCtx := requestmeta.NewContext(context.Background(), requestmeta.Metadata{
+		UserID:    "alice",
+		UserEmail: "alice@example.com",
+		IPAddress: "203.0.113.7",
+		UserAgent: "curl/8.4.0",
+		RequestID: "req-123",
+	})
+
+	tests := []struct {
+		name      string
+		ctx       context.Context
+		userID    string
+		userEmail string
+		wantUser  string
+		wantEmail string
+		wantIP    string
+	}{
+		{"request user", requestCtx, "", "", "alice", "alice@example.com", "203.0.113.7"},
+		{"same user without email", requestCtx, "alice", "", "alice", "alice@example.com", "203.0.113.7"},
+		{"other user", requestCtx, "bob", "", "bob", "", "203.0.113.7"},
+		{"explicit email", requestCtx, "alice", "a@example.com", "alice", "a@example.com", "203.0.113.7"},
+		{"background work", context.Background(), "", "", schedulerUserID, "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry := auditLogEntry(tt.ctx, models.AuditActionUpdate, "product", "p1", tt.userID, tt.userEmail, nil)
+			if entry.UserID != tt.wantUser || entry.UserEmail != tt.wantEmail {
+				t.Errorf("Expected user %q <%s>, got %q <%s>", tt.wantUser, tt.wantEmail, entry.UserID, entry.UserEmail)
+			}
+			if entry.IPAddress != tt.wantIP {
+				t.Errorf("Expected IP %q, got %q", tt.wantIP, entry.IPAddress)
+			}
+		})
+	}
+
+	entry := auditLogEntry(requestCtx, models.AuditActionCreate, "product", "p1", "", "", nil)
+	if entry.UserAgent != "curl/8.4.0" || entry.RequestID != "req-123" {
+		t.Errorf("Expected the request's user agent and ID, got %q and %q", entry.UserAgent, entry.RequestID)
+	}
+}
+
+func TestAuditLogger_NilRecordsNothing(t *testing.T) {
+	var logger *AuditLogger
+	logger.Log(context.Background(), models.AuditActionCreate, "product", "p1", "alice", "", nil)
+	NewAuditLogger(nil).Log(context.Background(), models.AuditActionCreate, "product", "p1", "alice", "", nil)
+}
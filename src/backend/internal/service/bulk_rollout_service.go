@@ -0,0 +1,409 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"updatemanager/internal/models"
+	"updatemanager/internal/repository"
+	"updatemanager/internal/utils"
+)
+
+const (
+	// BulkRolloutLatest targets each deployment's latest available version
+	BulkRolloutLatest = "latest"
+	// maxBulkRolloutTargets caps the endpoints one bulk rollout may cover
+	maxBulkRolloutTargets = 1000
+)
+
+// BulkRolloutService rolls out pending updates to every endpoint of the
+// deployments matching a pending updates filter, as one batch
+type BulkRolloutService struct {
+	pendingUpdates    *PendingUpdatesService
+	rolloutService    *UpdateRolloutService
+	upgradePaths      *UpgradePathService
+	rolloutRepo       *repository.UpdateRolloutRepository
+	detectionRepo     *repository.UpdateDetectionRepository
+	endpointRepo      *repository.EndpointRepository
+	versionRepo       *repository.VersionRepository
+	compatibilityRepo *repository.CompatibilityRepository
+	licenseRepo       *repository.LicenseRepository
+	allocationRepo    *repository.LicenseAllocationRepository
+	audit             *AuditLogger
+}
+
+// NewBulkRolloutService creates a new bulk rollout service
+func NewBulkRolloutService(pendingUpdates *PendingUpdatesService, rolloutService *UpdateRolloutService, upgradePaths *UpgradePathService, rolloutRepo *repository.UpdateRolloutRepository, detectionRepo *repository.UpdateDetectionRepository, endpointRepo *repository.EndpointRepository, versionRepo *repository.VersionRepository, compatibilityRepo *repository.CompatibilityRepository, licenseRepo *repository.LicenseRepository, allocationRepo *repository.LicenseAllocationRepository, audit *AuditLogger) *BulkRolloutService {
+	return &BulkRolloutService{
+		pendingUpdates:    pendingUpdates,
+		rolloutService:    rolloutService,
+		upgradePaths:      upgradePaths,
+		rolloutRepo:       rolloutRepo,
+		detectionRepo:     detectionRepo,
+		endpointRepo:      endpointRepo,
+		versionRepo:       versionRepo,
+		compatibilityRepo: compatibilityRepo,
+		licenseRepo:       licenseRepo,
+		allocationRepo:    allocationRepo,
+		audit:             audit,
+	}
+}
+
+// PreviewBulkRollout lists the rollouts a bulk rollout would create and the
+// endpoints it would skip, without creating anything
+func (s *BulkRolloutService) PreviewBulkRollout(ctx context.Context, req *models.BulkRolloutRequest) (*models.BulkRolloutResult, error) {
+	result, err := s.planBulkRollout(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	result.DryRun = true
+	for _, target := range result.Targets {
+		if target.SkipReason == "" {
+			result.Eligible++
+		} else {
+			result.Skipped++
+		}
+	}
+	return result, nil
+}
+
+// ExecuteBulkRollout re-evaluates the filter and creates a rollout for every
+// eligible endpoint, tagging them with a new batch ID. Endpoints that became
+// ineligible since the preview are skipped rather than failing the batch.
+func (s *BulkRolloutService) ExecuteBulkRollout(ctx context.Context, req *models.BulkRolloutRequest, userID, userEmail string) (*models.BulkRolloutResult, error) {
+	result, err := s.planBulkRollout(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	result.BatchID = primitive.NewObjectID().Hex()
+	for i := range result.Targets {
+		target := &result.Targets[i]
+		if target.SkipReason == "" {
+			rollout := &models.UpdateRollout{
+				EndpointID:  target.EndpointID,
+				ProductID:   target.ProductID,
+				FromVersion: target.FromVersion,
+				ToVersion:   target.ToVersion,
+				InitiatedBy: userID,
+				BatchID:     result.BatchID,
+			}
+			if _, err := s.rolloutService.InitiateRollout(ctx, rollout); err != nil {
+				target.SkipReason, target.SkipDetail = initiateSkipReason(err), err.Error()
+			} else {
+				target.RolloutID = rollout.ID.Hex()
+				target.RolloutStatus = rollout.Status
+			}
+		}
+
+		if target.SkipReason == "" {
+			result.Created++
+		} else {
+			result.Skipped++
+		}
+	}
+
+	s.audit.Log(ctx, models.AuditActionCreate, "rollout_batch", result.BatchID, userID, userEmail, map[string]interface{}{
+		"action":          "bulk_rollout",
+		"product_id":      req.ProductID,
+		"deployment_type": req.DeploymentType,
+		"priority":        req.Priority,
+		"customer_id":     req.CustomerID,
+		"tenant_id":       req.TenantID,
+		"target_version":  result.TargetVersion,
+		"matched":         result.Matched,
+		"created":         result.Created,
+		"skipped":         result.Skipped,
+	})
+
+	return result, nil
+}
+
+// planBulkRollout resolves the endpoints matching a bulk rollout request and
+// the target version and skip reason of each
+func (s *BulkRolloutService) planBulkRollout(ctx context.Context, req *models.BulkRolloutRequest) (*models.BulkRolloutResult, error) {
+	targetVersion := strings.TrimSpace(req.TargetVersion)
+	latest := strings.EqualFold(targetVersion, BulkRolloutLatest)
+	if targetVersion == "" {
+		return nil, fmt.Errorf("invalid bulk rollout: target_version is required")
+	}
+	if !latest && req.ProductID == "" {
+		return nil, fmt.Errorf("invalid bulk rollout: product_id is required unless target_version is '%s'", BulkRolloutLatest)
+	}
+	switch req.Priority {
+	case "", "critical", "high", "normal":
+	default:
+		return nil, fmt.Errorf("invalid bulk rollout: unknown priority '%s'", req.Priority)
+	}
+
+	if latest {
+		targetVersion = BulkRolloutLatest
+	} else {
+		version, err := s.versionRepo.GetByProductIDAndVersion(ctx, req.ProductID, targetVersion)
+		if err != nil {
+			return nil, fmt.Errorf("version %s not found for product %s: %w", targetVersion, req.ProductID, err)
+		}
+		if version.State != models.VersionStateReleased {
+			return nil, fmt.Errorf("invalid bulk rollout: version %s must be in Released state, current state: %s", targetVersion, version.State)
+		}
+	}
+
+	deployments, err := s.pendingUpdates.FindPendingDeployments(ctx, &models.PendingUpdatesFilter{
+		ProductID:      req.ProductID,
+		DeploymentType: req.DeploymentType,
+		Priority:       req.Priority,
+		TenantID:       req.TenantID,
+		CustomerID:     req.CustomerID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &bulkRolloutPlan{
+		versions: make(map[string]*models.Version),
+		matrices: make(map[string]*models.CompatibilityMatrix),
+		paths:    make(map[string]*models.UpgradePathResolution),
+	}
+	result := &models.BulkRolloutResult{
+		TargetVersion: targetVersion,
+		Targets:       []models.BulkRolloutTarget{},
+	}
+	now := time.Now()
+
+	for _, pending := range deployments {
+		deployment := pending.Deployment
+		toVersion := targetVersion
+		if latest {
+			toVersion = pending.Updates.LatestVersion
+		}
+
+		endpoints, err := s.endpointRepo.FindAll(ctx, &repository.EndpointFilter{
+			DeploymentID: deployment.ID,
+			Status:       models.EndpointStatusActive,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		base := models.BulkRolloutTarget{
+			DeploymentID: deployment.DeploymentID,
+			CustomerID:   pending.Updates.CustomerID,
+			TenantID:     pending.Updates.TenantID,
+			ProductID:    deployment.ProductID,
+			ToVersion:    toVersion,
+		}
+		if len(endpoints) == 0 {
+			base.SkipReason = models.BulkSkipNoEndpoints
+			base.SkipDetail = "deployment has no registered endpoints"
+			result.Targets = append(result.Targets, base)
+			continue
+		}
+
+		result.Matched += len(endpoints)
+		if result.Matched > maxBulkRolloutTargets {
+			return nil, fmt.Errorf("invalid bulk rollout: filter matches more than %d endpoints, narrow it down", maxBulkRolloutTargets)
+		}
+
+		licenseDetail, err := s.expiredLicense(ctx, deployment, now)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, endpoint := range endpoints {
+			target := base
+			target.EndpointID = endpoint.EndpointID
+			if licenseDetail != "" {
+				target.SkipReason, target.SkipDetail = models.BulkSkipLicenseExpired, licenseDetail
+			} else if err := s.evaluateTarget(ctx, plan, &target); err != nil {
+				return nil, err
+			}
+			result.Targets = append(result.Targets, target)
+		}
+	}
+
+	return result, nil
+}
+
+// bulkRolloutPlan caches the lookups shared by the endpoints of a bulk rollout
+type bulkRolloutPlan struct {
+	versions map[string]*models.Version
+	matrices map[string]*models.CompatibilityMatrix
+	paths    map[string]*models.UpgradePathResolution
+}
+
+// evaluateTarget fills in an endpoint's from version and, if the endpoint
+// cannot take the rollout, its skip reason. A multi-step upgrade path rolls
+// out its first hop.
+func (s *BulkRolloutService) evaluateTarget(ctx context.Context, plan *bulkRolloutPlan, target *models.BulkRolloutTarget) error {
+	detection, err := s.detectionRepo.GetByEndpointIDAndProductID(ctx, target.EndpointID, target.ProductID)
+	if err != nil {
+		target.SkipReason, target.SkipDetail = models.BulkSkipNoDetection, "no update detection for endpoint"
+		return nil
+	}
+	target.FromVersion = detection.CurrentVersion
+	if !utils.IsVersionNewer(target.ToVersion, detection.CurrentVersion) {
+		target.SkipReason, target.SkipDetail = models.BulkSkipUpToDate, fmt.Sprintf("endpoint is already on %s", detection.CurrentVersion)
+		return nil
+	}
+
+	active, err := s.rolloutRepo.Count(ctx, bson.M{
+		"endpoint_id": target.EndpointID,
+		"product_id":  target.ProductID,
+		"status":      bson.M{"$in": activeRolloutStatuses},
+	})
+	if err != nil {
+		return err
+	}
+	if active > 0 {
+		target.SkipReason, target.SkipDetail = models.BulkSkipActiveRollout, "endpoint has an active rollout"
+		return nil
+	}
+
+	pathKey := target.ProductID + "|" + target.FromVersion + "|" + target.ToVersion
+	path, ok := plan.paths[pathKey]
+	if !ok {
+		path, err = s.upgradePaths.ResolveUpgradePath(ctx, target.ProductID, target.FromVersion, target.ToVersion)
+		if err != nil {
+			return err
+		}
+		plan.paths[pathKey] = path
+	}
+	if path.IsBlocked {
+		target.SkipReason, target.SkipDetail = models.BulkSkipPathBlocked, blockedPathDetail(path)
+		return nil
+	}
+	if len(path.IntermediateVersions) > 0 {
+		target.UpgradePath = append(append([]string{}, path.IntermediateVersions...), target.ToVersion)
+		target.ToVersion = path.IntermediateVersions[0]
+	}
+
+	versionKey := target.ProductID + "|" + target.ToVersion
+	version, ok := plan.versions[versionKey]
+	if !ok {
+		version, err = s.versionRepo.GetByProductIDAndVersion(ctx, target.ProductID, target.ToVersion)
+		if err != nil && !strings.Contains(err.Error(), "not found") {
+			return err
+		}
+		plan.versions[versionKey] = version
+	}
+	if version == nil {
+		target.SkipReason, target.SkipDetail = models.BulkSkipVersionNotFound, fmt.Sprintf("version %s not found", target.ToVersion)
+		return nil
+	}
+	if rolloutsBlocked(version) {
+		target.SkipReason = models.BulkSkipRolloutsHalted
+		target.SkipDetail = fmt.Sprintf("rollouts of version %s are %s: %s", version.VersionNumber, version.RolloutHalt.Status, version.RolloutHalt.Reason)
+		return nil
+	}
+
+	matrix, ok := plan.matrices[versionKey]
+	if !ok {
+		matrix, err = s.compatibilityRepo.GetByProductIDAndVersion(ctx, target.ProductID, target.ToVersion)
+		if err != nil && !strings.Contains(err.Error(), "not found") {
+			return err
+		}
+		plan.matrices[versionKey] = matrix
+	}
+	if detail := compatibilitySkipDetail(matrix, target.FromVersion); detail != "" {
+		target.SkipReason, target.SkipDetail = models.BulkSkipIncompatible, detail
+	}
+	return nil
+}
+
+// expiredLicense returns why a deployment's product license has expired, or ""
+// if it holds a valid license. Deployments without allocated licenses for the
+// product are not license managed and are never skipped.
+func (s *BulkRolloutService) expiredLicense(ctx context.Context, deployment *models.Deployment, now time.Time) (string, error) {
+	activeOnly := &repository.LicenseAllocationFilter{Status: models.AllocationStatusActive}
+	allocations, _, err := s.allocationRepo.GetByDeploymentID(ctx, deployment.ID, activeOnly, &repository.Pagination{Page: 1, Limit: 100})
+	if err != nil {
+		return "", err
+	}
+	tenantAllocations, _, err := s.allocationRepo.GetByTenantID(ctx, deployment.TenantID, activeOnly, &repository.Pagination{Page: 1, Limit: 100})
+	if err != nil {
+		return "", err
+	}
+	allocations = append(allocations, tenantAllocations...)
+
+	var expired *models.License
+	seen := make(map[primitive.ObjectID]bool)
+	for _, allocation := range allocations {
+		if seen[allocation.LicenseID] {
+			continue
+		}
+		seen[allocation.LicenseID] = true
+
+		license, err := s.licenseRepo.GetByID(ctx, allocation.LicenseID)
+		if err != nil {
+			continue // Allocation of a deleted license
+		}
+		if license.ProductID != deployment.ProductID {
+			continue
+		}
+		if !licenseExpired(license, now) {
+			return "", nil
+		}
+		expired = license
+	}
+
+	if expired == nil {
+		return "", nil
+	}
+	if expired.EndDate != nil {
+		return fmt.Sprintf("license %s expired on %s", expired.LicenseID, expired.EndDate.Format("2006-01-02")), nil
+	}
+	return fmt.Sprintf("license %s is expired", expired.LicenseID), nil
+}
+
+// licenseExpired reports whether a license no longer covers updates
+func licenseExpired(license *models.License, now time.Time) bool {
+	if license.Status == models.LicenseStatusExpired {
+		return true
+	}
+	return license.LicenseType == models.LicenseTypeTimeBased && license.EndDate != nil && license.EndDate.Before(now)
+}
+
+// compatibilitySkipDetail returns why the compatibility matrix of a target
+// version rules out upgrading from fromVersion, or "" if it does not. Versions
+// without a matrix are not restricted.
+func compatibilitySkipDetail(matrix *models.CompatibilityMatrix, fromVersion string) string {
+	if matrix == nil {
+		return ""
+	}
+	if matrix.ValidationStatus == models.ValidationStatusFailed {
+		return fmt.Sprintf("compatibility validation of version %s failed", matrix.VersionNumber)
+	}
+	for _, incompatible := range matrix.IncompatibleVersions {
+		if incompatible == fromVersion {
+			return fmt.Sprintf("version %s is incompatible with %s", matrix.VersionNumber, fromVersion)
+		}
+	}
+	return ""
+}
+
+// blockedPathDetail describes a blocked upgrade path
+func blockedPathDetail(path *models.UpgradePathResolution) string {
+	detail := fmt.Sprintf("upgrade path %s -> %s is blocked", path.FromVersion, path.ToVersion)
+	if path.BlockReason != "" {
+		detail += ": " + path.BlockReason
+	}
+	return detail
+}
+
+// initiateSkipReason classifies an error creating a bulk rollout's rollout
+func initiateSkipReason(err error) models.BulkSkipReason {
+	switch {
+	case strings.Contains(err.Error(), "no maintenance window"):
+		return models.BulkSkipNoMaintenanceWindow
+	case strings.Contains(err.Error(), "rollouts of version"):
+		return models.BulkSkipRolloutsHalted
+	default:
+		return models.BulkSkipFailed
+	}
+}
@@ -0,0 +1,183 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"updatemanager/internal/models"
+	"updatemanager/internal/repository"
+)
+
+func TestCompatibilitySkipDetail(t *testing.T) {
+	tests := []struct {
+		name     string
+		matrix   *models.CompatibilityMatrix
+		from     string
+		wantSkip bool
+	}{
+		{"no matrix", nil, "1.0.0", false},
+		{"passed", &models.CompatibilityMatrix{VersionNumber: "2.0.0", ValidationStatus: models.ValidationStatusPassed}, "1.0.0", false},
+		{"failed validation", &models.CompatibilityMatrix{VersionNumber: "2.0.0", ValidationStatus: models.ValidationStatusFailed}, "1.0.0", true},
+		{"incompatible from version", &models.CompatibilityMatrix{VersionNumber: "2.0.0", IncompatibleVersions: []string{"1.0.0"}}, "1.0.0", true},
+		{"other incompatible version", &models.CompatibilityMatrix{VersionNumber: "2.0.0", IncompatibleVersions: []string{"0.9.0"}}, "1.0.0", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if detail := compatibilitySkipDetail(tt.matrix, tt.from); (detail != "") != tt.wantSkip {
+				t.Errorf("compatibilitySkipDetail() = %q, wantSkip %v", detail, tt.wantSkip)
+			}
+		})
+	}
+}
+
+func TestLicenseExpired(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	past := now.AddDate(0, -1, 0)
+	future := now.AddDate(0, 1, 0)
+
+	tests := []struct {
+		name    string
+		license models.License
+		want    bool
+	}{
+		{"active perpetual", models.License{LicenseType: models.LicenseTypePerpetual, Status: models.LicenseStatusActive}, false},
+		{"expired status", models.License{LicenseType: models.LicenseTypePerpetual, Status: models.LicenseStatusExpired}, true},
+		{"time based in term", models.License{LicenseType: models.LicenseTypeTimeBased, Status: models.LicenseStatusActive, EndDate: &future}, false},
+		{"time based past end date", models.License{LicenseType: models.LicenseTypeTimeBased, Status: models.LicenseStatusActive, EndDate: &past}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := licenseExpired(&tt.license, now); got != tt.want {
+				t.Errorf("licenseExpired() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInitiateSkipReason(t *testing.T) {
+	tests := []struct {
+		err  error
+		want models.BulkSkipReason
+	}{
+		{errors.New("endpoint e-1 has no maintenance window in the next 30 days"), models.BulkSkipNoMaintenanceWindow},
+		{errors.New("rollouts of version 2.0.0 are halted: too many failures"), models.BulkSkipRolloutsHalted},
+		{errors.New("failed to initiate rollout: connection reset"), models.BulkSkipFailed},
+	}
+
+	for _, tt := range tests {
+		if got := initiateSkipReason(tt.err); got != tt.want {
+			t.Errorf("initiateSkipReason(%q) = %s, want %s", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestBulkRolloutService_PreviewAndExecute(t *testing.T) {
+	setupCampaignServiceTestDB(t)
+	defer teardownCampaignServiceTestDB(t)
+
+	ctx := campaignServiceTestCtx
+	db := campaignServiceTestDB
+	deploymentRepo := repository.NewDeploymentRepository(db.Collection("deployments"))
+	rolloutService := NewUpdateRolloutService(campaignRolloutRepo, campaignDetectionRepo, campaignVersionRepo, campaignProductRepo, campaignEndpointRepo, nil, nil, nil, nil, nil)
+	bulkService := NewBulkRolloutService(
+		NewPendingUpdatesService(deploymentRepo, campaignVersionRepo, repository.NewCustomerRepository(db.Collection("customers")), repository.NewTenantRepository(db.Collection("customer_tenants")), nil),
+		rolloutService,
+		NewUpgradePathService(repository.NewUpgradePathRepository(db.Collection("upgrade_paths")), repository.NewUpgradePathRuleRepository(db.Collection("upgrade_path_rules")), campaignVersionRepo, campaignProductRepo, nil),
+		campaignRolloutRepo,
+		campaignDetectionRepo,
+		campaignEndpointRepo,
+		campaignVersionRepo,
+		repository.NewCompatibilityRepository(db.Collection("compatibility_matrices")),
+		repository.NewLicenseRepository(db.Collection("licenses")),
+		repository.NewLicenseAllocationRepository(db.Collection("license_allocations")),
+		NewAuditLogger(repository.NewAuditLogRepository(db.Collection("audit_logs"))),
+	)
+
+	// One deployment on 1.0.0 with three endpoints: one eligible, one already
+	// updated and one with an active rollout
+	seedCampaignTargets(t, "bulk-product", 0)
+	deployment := &models.Deployment{
+		DeploymentID:     "bulk-deployment",
+		TenantID:         primitive.NewObjectID(),
+		ProductID:        "bulk-product",
+		DeploymentType:   models.DeploymentTypeProduction,
+		InstalledVersion: "1.0.0",
+		Status:           models.DeploymentStatusActive,
+	}
+	if err := deploymentRepo.Create(ctx, deployment); err != nil {
+		t.Fatalf("Failed to create deployment: %v", err)
+	}
+	for endpointID, current := range map[string]string{"bulk-eligible": "1.0.0", "bulk-current": "2.0.0", "bulk-active": "1.0.0"} {
+		campaignEndpointRepo.Create(ctx, &models.Endpoint{
+			EndpointID:   endpointID,
+			DeploymentID: deployment.ID,
+			TenantID:     deployment.TenantID,
+			ProductID:    "bulk-product",
+			Hostname:     endpointID + ".example.com",
+			Status:       models.EndpointStatusActive,
+		})
+		campaignDetectionRepo.Create(ctx, &models.UpdateDetection{EndpointID: endpointID, ProductID: "bulk-product", CurrentVersion: current})
+	}
+	if _, err := rolloutService.InitiateRollout(ctx, &models.UpdateRollout{EndpointID: "bulk-active", ProductID: "bulk-product", FromVersion: "1.0.0", ToVersion: "2.0.0", InitiatedBy: "user-123"}); err != nil {
+		t.Fatalf("Failed to initiate rollout: %v", err)
+	}
+
+	req := &models.BulkRolloutRequest{ProductID: "bulk-product", TargetVersion: "latest"}
+	preview, err := bulkService.PreviewBulkRollout(ctx, req)
+	if err != nil {
+		t.Fatalf("Failed to preview bulk rollout: %v", err)
+	}
+	if !preview.DryRun || preview.Matched != 3 || preview.Eligible != 1 || preview.Skipped != 2 {
+		t.Fatalf("Expected 1 of 3 endpoints eligible, got %+v", preview)
+	}
+	reasons := make(map[string]models.BulkSkipReason)
+	for _, target := range preview.Targets {
+		reasons[target.EndpointID] = target.SkipReason
+	}
+	if reasons["bulk-eligible"] != "" || reasons["bulk-current"] != models.BulkSkipUpToDate || reasons["bulk-active"] != models.BulkSkipActiveRollout {
+		t.Errorf("Unexpected skip reasons: %v", reasons)
+	}
+	if count, _ := campaignRolloutRepo.Count(ctx, bson.M{"product_id": "bulk-product"}); count != 1 {
+		t.Errorf("Expected the preview to create no rollouts, found %d", count)
+	}
+
+	result, err := bulkService.ExecuteBulkRollout(ctx, req, "user-123", "user@example.com")
+	if err != nil {
+		t.Fatalf("Failed to execute bulk rollout: %v", err)
+	}
+	if result.BatchID == "" || result.Created != 1 || result.Skipped != 2 {
+		t.Fatalf("Expected one rollout in a new batch, got %+v", result)
+	}
+	rollouts, _ := campaignRolloutRepo.List(ctx, bson.M{"batch_id": result.BatchID}, nil)
+	if len(rollouts) != 1 || rollouts[0].EndpointID != "bulk-eligible" || rollouts[0].ToVersion != "2.0.0" {
+		t.Errorf("Expected the batch to hold the eligible endpoint's rollout, got %+v", rollouts)
+	}
+
+	// An incompatible target version skips every endpoint
+	repository.NewCompatibilityRepository(db.Collection("compatibility_matrices")).Create(ctx, &models.CompatibilityMatrix{
+		ProductID:            "bulk-product",
+		VersionNumber:        "2.0.0",
+		IncompatibleVersions: []string{"1.0.0"},
+		ValidationStatus:     models.ValidationStatusPassed,
+	})
+	campaignRolloutRepo.UpdateStatus(ctx, rollouts[0].ID, models.RolloutStatusCancelled, "")
+	preview, err = bulkService.PreviewBulkRollout(ctx, &models.BulkRolloutRequest{ProductID: "bulk-product", TargetVersion: "2.0.0"})
+	if err != nil {
+		t.Fatalf("Failed to preview bulk rollout: %v", err)
+	}
+	for _, target := range preview.Targets {
+		if target.EndpointID == "bulk-eligible" && target.SkipReason != models.BulkSkipIncompatible {
+			t.Errorf("Expected incompatible skip, got %+v", target)
+		}
+	}
+
+	if _, err := bulkService.PreviewBulkRollout(ctx, &models.BulkRolloutRequest{TargetVersion: "2.0.0"}); err == nil {
+		t.Error("Expected an explicit target version without product_id to be rejected")
+	}
+}
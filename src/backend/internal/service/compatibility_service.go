@@ -3,7 +3,6 @@ package service
 import (
 	"context"
 	"fmt"
-	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo/options"
@@ -16,15 +15,15 @@ import (
 type CompatibilityService struct {
 	compatibilityRepo *repository.CompatibilityRepository
 	versionRepo       *repository.VersionRepository
-	auditRepo         *repository.AuditLogRepository
+	audit             *AuditLogger
 }
 
 // NewCompatibilityService creates a new compatibility service
-func NewCompatibilityService(compatibilityRepo *repository.CompatibilityRepository, versionRepo *repository.VersionRepository, auditRepo *repository.AuditLogRepository) *CompatibilityService {
+func NewCompatibilityService(compatibilityRepo *repository.CompatibilityRepository, versionRepo *repository.VersionRepository, audit *AuditLogger) *CompatibilityService {
 	return &CompatibilityService{
 		compatibilityRepo: compatibilityRepo,
 		versionRepo:       versionRepo,
-		auditRepo:         auditRepo,
+		audit:             audit,
 	}
 }
 
@@ -40,6 +39,7 @@ func (s *CompatibilityService) ValidateCompatibility(ctx context.Context, produc
 	existing, err := s.compatibilityRepo.GetByProductIDAndVersion(ctx, productID, versionNumber)
 	if err == nil && existing != nil {
 		// Update existing
+		before := auditState(existing)
 		existing.MinServerVersion = req.MinServerVersion
 		existing.MaxServerVersion = req.MaxServerVersion
 		existing.RecommendedServerVersion = req.RecommendedServerVersion
@@ -52,6 +52,12 @@ func (s *CompatibilityService) ValidateCompatibility(ctx context.Context, produc
 			return nil, fmt.Errorf("failed to update compatibility matrix: %w", err)
 		}
 
+		// Log audit
+		s.audit.LogChange(ctx, models.AuditActionUpdate, "compatibility_matrix", existing.ID.Hex(), validatedBy, "", before, existing, map[string]interface{}{
+			"product_id":     productID,
+			"version_number": versionNumber,
+		})
+
 		return existing, nil
 	}
 
@@ -73,7 +79,7 @@ func (s *CompatibilityService) ValidateCompatibility(ctx context.Context, produc
 	}
 
 	// Log audit
-	s.logAudit(ctx, models.AuditActionUpdate, "compatibility_matrix", matrix.ID.Hex(), validatedBy, "", map[string]interface{}{
+	s.audit.LogChange(ctx, models.AuditActionCreate, "compatibility_matrix", matrix.ID.Hex(), validatedBy, "", nil, matrix, map[string]interface{}{
 		"product_id":     productID,
 		"version_number": versionNumber,
 	})
@@ -111,22 +117,3 @@ func (s *CompatibilityService) ListCompatibility(ctx context.Context, filter bso
 
 	return matrices, total, nil
 }
-
-// logAudit logs an audit entry
-func (s *CompatibilityService) logAudit(ctx context.Context, action models.AuditAction, resourceType, resourceID, userID, userEmail string, details map[string]interface{}) {
-	if s.auditRepo == nil {
-		return
-	}
-
-	auditLog := &models.AuditLog{
-		Action:       action,
-		ResourceType: resourceType,
-		ResourceID:   resourceID,
-		UserID:       userID,
-		UserEmail:    userEmail,
-		Details:      details,
-		Timestamp:    time.Now(),
-	}
-
-	_ = s.auditRepo.Create(ctx, auditLog)
-}
@@ -40,7 +40,7 @@ func setupCompatibilityServiceTestDB(t *testing.T) {
 	compatibilityVersionRepo = repository.NewVersionRepository(db.Collection("versions"))
 	compatibilityAuditRepo = repository.NewAuditLogRepository(db.Collection("audit_logs"))
 	compatibilityProductRepo = repository.NewProductRepository(db.Collection("products"))
-	compatibilityService = NewCompatibilityService(compatibilityRepo, compatibilityVersionRepo, compatibilityAuditRepo)
+	compatibilityService = NewCompatibilityService(compatibilityRepo, compatibilityVersionRepo, NewAuditLogger(compatibilityAuditRepo))
 }
 
 func teardownCompatibilityServiceTestDB(t *testing.T) {
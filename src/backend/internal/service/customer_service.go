@@ -9,6 +9,7 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 
 	"updatemanager/internal/models"
+	"updatemanager/internal/notify"
 	"updatemanager/internal/repository"
 )
 
@@ -17,7 +18,7 @@ type CustomerService struct {
 	customerRepo  *repository.CustomerRepository
 	tenantRepo    *repository.TenantRepository
 	deploymentRepo *repository.DeploymentRepository
-	auditRepo     *repository.AuditLogRepository
+	audit          *AuditLogger
 }
 
 // NewCustomerService creates a new customer service
@@ -25,13 +26,13 @@ func NewCustomerService(
 	customerRepo *repository.CustomerRepository,
 	tenantRepo *repository.TenantRepository,
 	deploymentRepo *repository.DeploymentRepository,
-	auditRepo *repository.AuditLogRepository,
+	audit *AuditLogger,
 ) *CustomerService {
 	return &CustomerService{
 		customerRepo:   customerRepo,
 		tenantRepo:     tenantRepo,
 		deploymentRepo: deploymentRepo,
-		auditRepo:      auditRepo,
+		audit:          audit,
 	}
 }
 
@@ -71,6 +72,20 @@ func (s *CustomerService) CreateCustomer(ctx context.Context, req *models.Create
 		}
 	}
 
+	prefs := defaultNotificationPreferences
+	if req.NotificationPreferences != nil {
+		prefs = *req.NotificationPreferences
+	}
+	if !validNotificationDigestFrequency(prefs.DigestFrequency) {
+		return nil, fmt.Errorf("invalid notification preferences: unknown digest frequency %q", prefs.DigestFrequency)
+	}
+	prefs.Explicit = true
+
+	locale, ok := notify.NormalizeLocale(req.PreferredLocale)
+	if !ok {
+		return nil, fmt.Errorf("invalid locale %q", req.PreferredLocale)
+	}
+
 	// Create customer
 	customer := &models.Customer{
 		CustomerID:   req.CustomerID,
@@ -80,7 +95,8 @@ func (s *CustomerService) CreateCustomer(ctx context.Context, req *models.Create
 		Phone:        req.Phone,
 		Address:      req.Address,
 		AccountStatus: req.AccountStatus,
-		NotificationPreferences: req.NotificationPreferences,
+		NotificationPreferences: prefs,
+		PreferredLocale: locale,
 	}
 
 	if err := s.customerRepo.Create(ctx, customer); err != nil {
@@ -88,7 +104,7 @@ func (s *CustomerService) CreateCustomer(ctx context.Context, req *models.Create
 	}
 
 	// Log audit
-	s.logAudit(ctx, models.AuditActionCreate, "customer", customer.ID.Hex(), userID, userEmail, map[string]interface{}{
+	s.audit.LogChange(ctx, models.AuditActionCreate, "customer", customer.ID.Hex(), userID, userEmail, nil, customer, map[string]interface{}{
 		"customer_id": customer.CustomerID,
 		"name":        customer.Name,
 		"email":       customer.Email,
@@ -151,6 +167,7 @@ func (s *CustomerService) UpdateCustomer(ctx context.Context, id string, req *mo
 		return nil, err
 	}
 
+	before := auditState(customer)
 	// Update fields if provided
 	if req.Name != nil {
 		customer.Name = *req.Name
@@ -178,7 +195,18 @@ func (s *CustomerService) UpdateCustomer(ctx context.Context, id string, req *mo
 		customer.AccountStatus = *req.AccountStatus
 	}
 	if req.NotificationPreferences != nil {
+		if !validNotificationDigestFrequency(req.NotificationPreferences.DigestFrequency) {
+			return nil, fmt.Errorf("invalid notification preferences: unknown digest frequency %q", req.NotificationPreferences.DigestFrequency)
+		}
 		customer.NotificationPreferences = *req.NotificationPreferences
+		customer.NotificationPreferences.Explicit = true
+	}
+	if req.PreferredLocale != nil {
+		locale, ok := notify.NormalizeLocale(*req.PreferredLocale)
+		if !ok {
+			return nil, fmt.Errorf("invalid locale %q", *req.PreferredLocale)
+		}
+		customer.PreferredLocale = locale
 	}
 
 	if err := s.customerRepo.Update(ctx, customer.ID, customer); err != nil {
@@ -186,7 +214,7 @@ func (s *CustomerService) UpdateCustomer(ctx context.Context, id string, req *mo
 	}
 
 	// Log audit
-	s.logAudit(ctx, models.AuditActionUpdate, "customer", customer.ID.Hex(), userID, userEmail, map[string]interface{}{
+	s.audit.LogChange(ctx, models.AuditActionUpdate, "customer", customer.ID.Hex(), userID, userEmail, before, customer, map[string]interface{}{
 		"customer_id": customer.CustomerID,
 	})
 
@@ -207,13 +235,14 @@ func (s *CustomerService) DeleteCustomer(ctx context.Context, id string, userID,
 	}
 
 	// Soft delete (set status to inactive)
+	before := auditState(customer)
 	customer.AccountStatus = models.CustomerStatusInactive
 	if err := s.customerRepo.Update(ctx, customer.ID, customer); err != nil {
 		return fmt.Errorf("failed to delete customer: %w", err)
 	}
 
 	// Log audit
-	s.logAudit(ctx, models.AuditActionDelete, "customer", customer.ID.Hex(), userID, userEmail, map[string]interface{}{
+	s.audit.LogChange(ctx, models.AuditActionDelete, "customer", customer.ID.Hex(), userID, userEmail, before, customer, map[string]interface{}{
 		"customer_id": customer.CustomerID,
 	})
 
@@ -312,24 +341,6 @@ func (s *CustomerService) generateCustomerID() string {
 	return fmt.Sprintf("CUST-%d", timestamp)
 }
 
-// logAudit logs an audit entry
-func (s *CustomerService) logAudit(ctx context.Context, action models.AuditAction, resourceType, resourceID, userID, userEmail string, details map[string]interface{}) {
-	if s.auditRepo == nil {
-		return
-	}
-
-	auditLog := &models.AuditLog{
-		Action:       action,
-		ResourceType: resourceType,
-		ResourceID:   resourceID,
-		UserID:       userID,
-		UserEmail:    userEmail,
-		Details:      details,
-		Timestamp:    time.Now(),
-	}
-
-	_ = s.auditRepo.Create(ctx, auditLog) // Ignore errors for audit logging
-}
 
 // validateCustomerData validates customer data
 func (s *CustomerService) validateCustomerData(customer *models.Customer) error {
@@ -344,4 +355,3 @@ func (s *CustomerService) validateCustomerData(customer *models.Customer) error
 	}
 	return nil
 }
-
@@ -19,7 +19,8 @@ type DeploymentService struct {
 	customerRepo   *repository.CustomerRepository
 	productService *ProductService
 	versionService *VersionService
-	auditRepo      *repository.AuditLogRepository
+	audit          *AuditLogger
+	outbox         *DomainEventOutbox
 }
 
 // NewDeploymentService creates a new deployment service
@@ -29,7 +30,8 @@ func NewDeploymentService(
 	customerRepo *repository.CustomerRepository,
 	productService *ProductService,
 	versionService *VersionService,
-	auditRepo *repository.AuditLogRepository,
+	audit *AuditLogger,
+	outbox *DomainEventOutbox, // May be nil; updates then raise no DeploymentUpdated event
 ) *DeploymentService {
 	return &DeploymentService{
 		deploymentRepo: deploymentRepo,
@@ -37,7 +39,8 @@ func NewDeploymentService(
 		customerRepo:   customerRepo,
 		productService: productService,
 		versionService: versionService,
-		auditRepo:      auditRepo,
+		audit:          audit,
+		outbox:         outbox,
 	}
 }
 
@@ -88,6 +91,12 @@ func (s *DeploymentService) CreateDeployment(ctx context.Context, tenantID strin
 		}
 	}
 
+	// Validate maintenance schedule
+	schedule, err := normalizeMaintenanceSchedule(req.MaintenanceSchedule, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
 	// Create deployment
 	deployment := &models.Deployment{
 		DeploymentID:     req.DeploymentID,
@@ -100,6 +109,7 @@ func (s *DeploymentService) CreateDeployment(ctx context.Context, tenantID strin
 		ServerHostname:   req.ServerHostname,
 		EnvironmentDetails: req.EnvironmentDetails,
 		Status:           req.Status,
+		MaintenanceSchedule: schedule,
 	}
 
 	if err := s.deploymentRepo.Create(ctx, deployment); err != nil {
@@ -111,7 +121,7 @@ func (s *DeploymentService) CreateDeployment(ctx context.Context, tenantID strin
 	}
 
 	// Log audit
-	s.logAudit(ctx, models.AuditActionCreate, "deployment", deployment.ID.Hex(), userID, userEmail, map[string]interface{}{
+	s.audit.LogChange(ctx, models.AuditActionCreate, "deployment", deployment.ID.Hex(), userID, userEmail, nil, deployment, map[string]interface{}{
 		"deployment_id":    deployment.DeploymentID,
 		"tenant_id":        tenant.TenantID,
 		"product_id":       deployment.ProductID,
@@ -182,7 +192,7 @@ func (s *DeploymentService) ListDeployments(ctx context.Context, tenantID string
 	}, nil
 }
 
-// UpdateDeployment updates an existing deployment
+// UpdateDeployment updates an existing deployment and raises DeploymentUpdated
 func (s *DeploymentService) UpdateDeployment(ctx context.Context, id string, req *models.UpdateDeploymentRequest, userID, userEmail string) (*models.Deployment, error) {
 	// Get existing deployment
 	deployment, err := s.GetDeployment(ctx, id)
@@ -221,6 +231,8 @@ func (s *DeploymentService) UpdateDeployment(ctx context.Context, id string, req
 	}
 
 	// Update fields if provided
+	before := auditState(deployment)
+	previousVersion := deployment.InstalledVersion
 	if req.DeploymentType != nil {
 		deployment.DeploymentType = *req.DeploymentType
 	}
@@ -242,13 +254,34 @@ func (s *DeploymentService) UpdateDeployment(ctx context.Context, id string, req
 	if req.Status != nil {
 		deployment.Status = *req.Status
 	}
+	if req.MaintenanceSchedule != nil {
+		schedule, err := normalizeMaintenanceSchedule(req.MaintenanceSchedule, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		deployment.MaintenanceSchedule = schedule
+	}
 
-	if err := s.deploymentRepo.Update(ctx, deployment.ID, deployment); err != nil {
+	err = s.outbox.Write(ctx, func(ctx context.Context) ([]DomainEvent, error) {
+		if err := s.deploymentRepo.Update(ctx, deployment.ID, deployment); err != nil {
+			return nil, err
+		}
+		return []DomainEvent{&DeploymentUpdated{
+			ID:               deployment.ID.Hex(),
+			DeploymentID:     deployment.DeploymentID,
+			TenantID:         deployment.TenantID.Hex(),
+			ProductID:        deployment.ProductID,
+			PreviousVersion:  previousVersion,
+			InstalledVersion: deployment.InstalledVersion,
+			UpdatedBy:        userID,
+		}}, nil
+	})
+	if err != nil {
 		return nil, fmt.Errorf("failed to update deployment: %w", err)
 	}
 
 	// Log audit
-	s.logAudit(ctx, models.AuditActionUpdate, "deployment", deployment.ID.Hex(), userID, userEmail, map[string]interface{}{
+	s.audit.LogChange(ctx, models.AuditActionUpdate, "deployment", deployment.ID.Hex(), userID, userEmail, before, deployment, map[string]interface{}{
 		"deployment_id": deployment.DeploymentID,
 	})
 
@@ -268,7 +301,7 @@ func (s *DeploymentService) DeleteDeployment(ctx context.Context, id string, use
 	}
 
 	// Log audit
-	s.logAudit(ctx, models.AuditActionDelete, "deployment", deployment.ID.Hex(), userID, userEmail, map[string]interface{}{
+	s.audit.LogChange(ctx, models.AuditActionDelete, "deployment", deployment.ID.Hex(), userID, userEmail, deployment, nil, map[string]interface{}{
 		"deployment_id": deployment.DeploymentID,
 	})
 
@@ -309,23 +342,3 @@ func (s *DeploymentService) generateDeploymentID() string {
 	timestamp := time.Now().Unix()
 	return fmt.Sprintf("DEPLOY-%d", timestamp)
 }
-
-// logAudit logs an audit entry
-func (s *DeploymentService) logAudit(ctx context.Context, action models.AuditAction, resourceType, resourceID, userID, userEmail string, details map[string]interface{}) {
-	if s.auditRepo == nil {
-		return
-	}
-
-	auditLog := &models.AuditLog{
-		Action:       action,
-		ResourceType: resourceType,
-		ResourceID:   resourceID,
-		UserID:       userID,
-		UserEmail:    userEmail,
-		Details:      details,
-		Timestamp:    time.Now(),
-	}
-
-	_ = s.auditRepo.Create(ctx, auditLog) // Ignore errors for audit logging
-}
-
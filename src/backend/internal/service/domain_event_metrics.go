@@ -0,0 +1,53 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"updatemanager/internal/models"
+)
+
+// DomainEventMetrics counts the domain events the outbox dispatched in this
+// process, per event type. Counts start at zero on every start, and an event
+// redelivered after a crash is counted again.
+type DomainEventMetrics struct {
+	mu      sync.Mutex
+	counts  map[models.DomainEventType]int64
+	started time.Time
+}
+
+// NewDomainEventMetrics creates domain event counters starting at zero
+func NewDomainEventMetrics() *DomainEventMetrics {
+	return &DomainEventMetrics{
+		counts:  make(map[models.DomainEventType]int64),
+		started: time.Now(),
+	}
+}
+
+// HandleDomainEvent is the outbox subscriber that counts events; it never fails
+func (m *DomainEventMetrics) HandleDomainEvent(ctx context.Context, event *models.OutboxEvent) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.counts[event.Type]++
+	return nil
+}
+
+// Counts returns the events counted so far, listing every known type
+func (m *DomainEventMetrics) Counts() *models.DomainEventCounts {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	counts := &models.DomainEventCounts{
+		Counts: make(map[models.DomainEventType]int64, len(DomainEventTypes)),
+		Since:  m.started,
+	}
+	for _, eventType := range DomainEventTypes {
+		counts.Counts[eventType] = 0
+	}
+	for eventType, count := range m.counts {
+		counts.Counts[eventType] = count
+		counts.Total += count
+	}
+	return counts
+}
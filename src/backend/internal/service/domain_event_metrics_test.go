@@ -0,0 +1,28 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"updatemanager/internal/models"
+)
+
+func TestDomainEventMetrics(t *testing.T) {
+	metrics := NewDomainEventMetrics()
+	for _, eventType := range []models.DomainEventType{models.DomainEventVersionReleased, models.DomainEventRolloutFailed, models.DomainEventVersionReleased} {
+		if err := metrics.HandleDomainEvent(context.Background(), &models.OutboxEvent{Type: eventType}); err != nil {
+			t.Fatalf("HandleDomainEvent() error = %v", err)
+		}
+	}
+
+	counts := metrics.Counts()
+	if counts.Total != 3 || counts.Counts[models.DomainEventVersionReleased] != 2 || counts.Counts[models.DomainEventRolloutFailed] != 1 {
+		t.Errorf("Unexpected counts %+v", counts)
+	}
+	if count, ok := counts.Counts[models.DomainEventLicenseExpired]; !ok || count != 0 {
+		t.Errorf("Expected event types not seen yet to be listed at zero, got %+v", counts.Counts)
+	}
+	if len(counts.Counts) != len(DomainEventTypes) {
+		t.Errorf("Expected %d event types, got %d", len(DomainEventTypes), len(counts.Counts))
+	}
+}
@@ -0,0 +1,233 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+
+	"updatemanager/internal/models"
+	"updatemanager/internal/repository"
+)
+
+// DomainEvent is a state change other parts of the system react to. Events
+// are stored in the outbox with the change and delivered to subscribers by
+// the OutboxDispatcher.
+type DomainEvent interface {
+	EventType() models.DomainEventType
+	// EventAggregateID identifies the changed resource
+	EventAggregateID() string
+}
+
+// VersionReleased is raised when a version is released
+type VersionReleased struct {
+	VersionID     string             `bson:"version_id" json:"version_id"`
+	ProductID     string             `bson:"product_id" json:"product_id"`
+	VersionNumber string             `bson:"version_number" json:"version_number"`
+	ReleaseType   models.ReleaseType `bson:"release_type" json:"release_type"`
+	ReleasedBy    string             `bson:"released_by" json:"released_by"`
+	ReleasedAt    time.Time          `bson:"released_at" json:"released_at"`
+}
+
+// EventType implements DomainEvent
+func (e *VersionReleased) EventType() models.DomainEventType {
+	return models.DomainEventVersionReleased
+}
+
+// EventAggregateID implements DomainEvent
+func (e *VersionReleased) EventAggregateID() string {
+	return e.VersionID
+}
+
+// DeploymentUpdated is raised when a deployment is changed
+type DeploymentUpdated struct {
+	ID               string `bson:"id" json:"id"`
+	DeploymentID     string `bson:"deployment_id" json:"deployment_id"`
+	TenantID         string `bson:"tenant_id" json:"tenant_id"`
+	ProductID        string `bson:"product_id" json:"product_id"`
+	PreviousVersion  string `bson:"previous_version" json:"previous_version"`
+	InstalledVersion string `bson:"installed_version" json:"installed_version"`
+	UpdatedBy        string `bson:"updated_by" json:"updated_by"`
+}
+
+// EventType implements DomainEvent
+func (e *DeploymentUpdated) EventType() models.DomainEventType {
+	return models.DomainEventDeploymentUpdated
+}
+
+// EventAggregateID implements DomainEvent
+func (e *DeploymentUpdated) EventAggregateID() string {
+	return e.ID
+}
+
+// LicenseExpired is raised when a time-based license passes its end date
+type LicenseExpired struct {
+	LicenseID  string     `bson:"license_id" json:"license_id"`
+	ProductID  string     `bson:"product_id" json:"product_id"`
+	CustomerID string     `bson:"customer_id,omitempty" json:"customer_id,omitempty"` // Empty when the subscription's customer is gone
+	EndDate    *time.Time `bson:"end_date,omitempty" json:"end_date,omitempty"`
+}
+
+// EventType implements DomainEvent
+func (e *LicenseExpired) EventType() models.DomainEventType {
+	return models.DomainEventLicenseExpired
+}
+
+// EventAggregateID implements DomainEvent
+func (e *LicenseExpired) EventAggregateID() string {
+	return e.LicenseID
+}
+
+// RolloutFailed is raised when a rollout fails, reported by its agent or
+// timed out
+type RolloutFailed struct {
+	RolloutID    string `bson:"rollout_id" json:"rollout_id"`
+	EndpointID   string `bson:"endpoint_id" json:"endpoint_id"`
+	ProductID    string `bson:"product_id" json:"product_id"`
+	FromVersion  string `bson:"from_version" json:"from_version"`
+	ToVersion    string `bson:"to_version" json:"to_version"`
+	ErrorMessage string `bson:"error_message,omitempty" json:"error_message,omitempty"`
+	TimedOut     bool   `bson:"timed_out" json:"timed_out"`
+}
+
+// EventType implements DomainEvent
+func (e *RolloutFailed) EventType() models.DomainEventType {
+	return models.DomainEventRolloutFailed
+}
+
+// EventAggregateID implements DomainEvent
+func (e *RolloutFailed) EventAggregateID() string {
+	return e.RolloutID
+}
+
+// VersionSubmitted is raised when a draft version is submitted for review
+type VersionSubmitted struct {
+	VersionID     string    `bson:"version_id" json:"version_id"`
+	ProductID     string    `bson:"product_id" json:"product_id"`
+	VersionNumber string    `bson:"version_number" json:"version_number"`
+	SubmittedBy   string    `bson:"submitted_by" json:"submitted_by"`
+	SubmittedAt   time.Time `bson:"submitted_at" json:"submitted_at"`
+}
+
+// EventType implements DomainEvent
+func (e *VersionSubmitted) EventType() models.DomainEventType {
+	return models.DomainEventVersionSubmitted
+}
+
+// EventAggregateID implements DomainEvent
+func (e *VersionSubmitted) EventAggregateID() string {
+	return e.VersionID
+}
+
+// VersionApproved is raised when a version under review is approved
+type VersionApproved struct {
+	VersionID     string    `bson:"version_id" json:"version_id"`
+	ProductID     string    `bson:"product_id" json:"product_id"`
+	VersionNumber string    `bson:"version_number" json:"version_number"`
+	ApprovedBy    string    `bson:"approved_by" json:"approved_by"`
+	ApprovedAt    time.Time `bson:"approved_at" json:"approved_at"`
+}
+
+// EventType implements DomainEvent
+func (e *VersionApproved) EventType() models.DomainEventType {
+	return models.DomainEventVersionApproved
+}
+
+// EventAggregateID implements DomainEvent
+func (e *VersionApproved) EventAggregateID() string {
+	return e.VersionID
+}
+
+// DomainEventOutbox stores domain events in the same transaction as the state
+// change that raised them, so an event is recorded if and only if its change
+// is. A nil DomainEventOutbox applies changes and drops their events.
+type DomainEventOutbox struct {
+	outboxRepo *repository.OutboxRepository
+	transactor *repository.Transactor
+}
+
+// NewDomainEventOutbox creates a new domain event outbox
+func NewDomainEventOutbox(outboxRepo *repository.OutboxRepository, transactor *repository.Transactor) *DomainEventOutbox {
+	return &DomainEventOutbox{
+		outboxRepo: outboxRepo,
+		transactor: transactor,
+	}
+}
+
+// Write runs change and stores the events it returns in one transaction.
+// change may run more than once if the transaction is retried, and its error
+// is returned unchanged.
+func (o *DomainEventOutbox) Write(ctx context.Context, change func(ctx context.Context) ([]DomainEvent, error)) error {
+	if o == nil {
+		_, err := change(ctx)
+		return err
+	}
+
+	return o.transactor.WithTransaction(ctx, func(ctx context.Context) error {
+		events, err := change(ctx)
+		if err != nil {
+			return err
+		}
+		for _, event := range events {
+			payload, err := bson.Marshal(event)
+			if err != nil {
+				return fmt.Errorf("failed to encode %s event: %w", event.EventType(), err)
+			}
+			if err := o.outboxRepo.Create(ctx, &models.OutboxEvent{
+				Type:        event.EventType(),
+				AggregateID: event.EventAggregateID(),
+				Payload:     payload,
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// decodeDomainEvent decodes an outbox event's payload into its typed event
+func decodeDomainEvent(event *models.OutboxEvent, into DomainEvent) error {
+	if event.Type != into.EventType() {
+		return fmt.Errorf("cannot decode %s event as %s", event.Type, into.EventType())
+	}
+	if err := bson.Unmarshal(event.Payload, into); err != nil {
+		return fmt.Errorf("failed to decode %s event: %w", event.Type, err)
+	}
+	return nil
+}
+
+// DomainEventTypes lists every domain event type
+var DomainEventTypes = []models.DomainEventType{
+	models.DomainEventVersionReleased,
+	models.DomainEventDeploymentUpdated,
+	models.DomainEventLicenseExpired,
+	models.DomainEventRolloutFailed,
+	models.DomainEventVersionSubmitted,
+	models.DomainEventVersionApproved,
+}
+
+// decodeOutboxEvent decodes an outbox event into the typed event of its type
+func decodeOutboxEvent(event *models.OutboxEvent) (DomainEvent, error) {
+	var typed DomainEvent
+	switch event.Type {
+	case models.DomainEventVersionReleased:
+		typed = &VersionReleased{}
+	case models.DomainEventDeploymentUpdated:
+		typed = &DeploymentUpdated{}
+	case models.DomainEventLicenseExpired:
+		typed = &LicenseExpired{}
+	case models.DomainEventRolloutFailed:
+		typed = &RolloutFailed{}
+	case models.DomainEventVersionSubmitted:
+		typed = &VersionSubmitted{}
+	case models.DomainEventVersionApproved:
+		typed = &VersionApproved{}
+	default:
+		return nil, fmt.Errorf("unknown domain event type: %s", event.Type)
+	}
+	if err := decodeDomainEvent(event, typed); err != nil {
+		return nil, err
+	}
+	return typed, nil
+}
@@ -0,0 +1,357 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"updatemanager/internal/models"
+	"updatemanager/internal/repository"
+)
+
+// EndpointService handles endpoint registry business logic
+type EndpointService struct {
+	endpointRepo      *repository.EndpointRepository
+	deploymentService *DeploymentService
+	audit             *AuditLogger
+}
+
+// NewEndpointService creates a new endpoint service
+func NewEndpointService(
+	endpointRepo *repository.EndpointRepository,
+	deploymentService *DeploymentService,
+	audit *AuditLogger,
+) *EndpointService {
+	return &EndpointService{
+		endpointRepo:      endpointRepo,
+		deploymentService: deploymentService,
+		audit:             audit,
+	}
+}
+
+// ListEndpointsQuery represents query parameters for searching endpoints
+type ListEndpointsQuery struct {
+	Search       string
+	DeploymentID string
+	TenantID     string
+	ProductID    string
+	OS           string
+	Architecture string
+	AgentVersion string
+	Status       models.EndpointStatus
+	Labels       map[string]string
+	Page         int
+	Limit        int
+}
+
+// EndpointListResponse represents a paginated list of endpoints
+type EndpointListResponse struct {
+	Endpoints  []*models.Endpoint
+	Pagination *repository.PaginationInfo
+}
+
+// RegisterEndpoint registers an endpoint under a deployment
+func (s *EndpointService) RegisterEndpoint(ctx context.Context, req *models.RegisterEndpointRequest, userID, userEmail string) (*models.Endpoint, error) {
+	if strings.TrimSpace(req.EndpointID) == "" {
+		return nil, fmt.Errorf("invalid endpoint: endpoint_id is required")
+	}
+	if strings.TrimSpace(req.Hostname) == "" {
+		return nil, fmt.Errorf("invalid endpoint: hostname is required")
+	}
+
+	deployment, err := s.deploymentService.GetDeployment(ctx, req.DeploymentID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Validate endpoint_id uniqueness
+	existing, err := s.endpointRepo.GetByEndpointID(ctx, req.EndpointID)
+	if err == nil && existing != nil {
+		return nil, fmt.Errorf("endpoint with endpoint_id '%s' already exists", req.EndpointID)
+	}
+
+	endpoint := &models.Endpoint{
+		EndpointID:   req.EndpointID,
+		DeploymentID: deployment.ID,
+		TenantID:     deployment.TenantID,
+		ProductID:    deployment.ProductID,
+		Hostname:     req.Hostname,
+		OS:           req.OS,
+		OSVersion:    req.OSVersion,
+		Architecture: req.Architecture,
+		AgentVersion: req.AgentVersion,
+		Labels:       req.Labels,
+		Status:       models.EndpointStatusActive,
+	}
+
+	if err := s.endpointRepo.Create(ctx, endpoint); err != nil {
+		return nil, fmt.Errorf("failed to register endpoint: %w", err)
+	}
+
+	// Log audit
+	s.audit.LogChange(ctx, models.AuditActionCreate, "endpoint", endpoint.ID.Hex(), userID, userEmail, nil, endpoint, map[string]interface{}{
+		"endpoint_id":   endpoint.EndpointID,
+		"deployment_id": deployment.DeploymentID,
+		"product_id":    endpoint.ProductID,
+		"hostname":      endpoint.Hostname,
+	})
+
+	return endpoint, nil
+}
+
+// GetEndpoint retrieves an endpoint by ID (endpoint_id or ObjectID)
+func (s *EndpointService) GetEndpoint(ctx context.Context, id string) (*models.Endpoint, error) {
+	endpoint, err := s.endpointRepo.GetByEndpointID(ctx, id)
+	if err == nil {
+		return endpoint, nil
+	}
+
+	// If not an endpoint_id, try as ObjectID
+	objectID, parseErr := primitive.ObjectIDFromHex(id)
+	if parseErr != nil {
+		return nil, fmt.Errorf("endpoint not found: %w", err)
+	}
+	endpoint, err = s.endpointRepo.GetByID(ctx, objectID)
+	if err != nil {
+		return nil, fmt.Errorf("endpoint not found: %w", err)
+	}
+	return endpoint, nil
+}
+
+// GetRegisteredEndpoint retrieves an active endpoint that belongs to the product.
+// Detections and rollouts may only reference endpoints returned by this method.
+func (s *EndpointService) GetRegisteredEndpoint(ctx context.Context, endpointID, productID string) (*models.Endpoint, error) {
+	return getRegisteredEndpoint(ctx, s.endpointRepo, endpointID, productID)
+}
+
+// getRegisteredEndpoint is shared by the services that must only act on registered endpoints
+func getRegisteredEndpoint(ctx context.Context, endpointRepo *repository.EndpointRepository, endpointID, productID string) (*models.Endpoint, error) {
+	endpoint, err := endpointRepo.GetByEndpointID(ctx, endpointID)
+	if err != nil {
+		return nil, fmt.Errorf("endpoint %s is not registered", endpointID)
+	}
+	if endpoint.Status != models.EndpointStatusActive {
+		return nil, fmt.Errorf("endpoint %s is not registered: status is %s", endpointID, endpoint.Status)
+	}
+	if productID != "" && endpoint.ProductID != productID {
+		return nil, fmt.Errorf("endpoint %s is not registered for product %s", endpointID, productID)
+	}
+	return endpoint, nil
+}
+
+// ListEndpoints searches endpoints with filters and pagination
+func (s *EndpointService) ListEndpoints(ctx context.Context, query *ListEndpointsQuery) (*EndpointListResponse, error) {
+	filter := &repository.EndpointFilter{}
+	pagination := &repository.Pagination{}
+
+	if query != nil {
+		filter.Search = query.Search
+		filter.ProductID = query.ProductID
+		filter.OS = query.OS
+		filter.Architecture = query.Architecture
+		filter.AgentVersion = query.AgentVersion
+		filter.Status = query.Status
+		filter.Labels = query.Labels
+
+		if query.DeploymentID != "" {
+			deployment, err := s.deploymentService.GetDeployment(ctx, query.DeploymentID)
+			if err != nil {
+				return nil, err
+			}
+			filter.DeploymentID = deployment.ID
+		}
+		if query.TenantID != "" {
+			tenantID, err := primitive.ObjectIDFromHex(query.TenantID)
+			if err != nil {
+				return nil, fmt.Errorf("invalid tenant_id '%s'", query.TenantID)
+			}
+			filter.TenantID = tenantID
+		}
+
+		pagination.Page = query.Page
+		pagination.Limit = query.Limit
+	}
+
+	endpoints, paginationInfo, err := s.endpointRepo.List(ctx, filter, pagination)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list endpoints: %w", err)
+	}
+
+	return &EndpointListResponse{
+		Endpoints:  endpoints,
+		Pagination: paginationInfo,
+	}, nil
+}
+
+// UpdateEndpoint updates an existing endpoint
+func (s *EndpointService) UpdateEndpoint(ctx context.Context, id string, req *models.UpdateEndpointRequest, userID, userEmail string) (*models.Endpoint, error) {
+	endpoint, err := s.GetEndpoint(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	before := auditState(endpoint)
+	// Update fields if provided
+	if req.Hostname != nil {
+		if strings.TrimSpace(*req.Hostname) == "" {
+			return nil, fmt.Errorf("invalid endpoint: hostname cannot be empty")
+		}
+		endpoint.Hostname = *req.Hostname
+	}
+	if req.OS != nil {
+		endpoint.OS = *req.OS
+	}
+	if req.OSVersion != nil {
+		endpoint.OSVersion = *req.OSVersion
+	}
+	if req.Architecture != nil {
+		endpoint.Architecture = *req.Architecture
+	}
+	if req.AgentVersion != nil {
+		endpoint.AgentVersion = *req.AgentVersion
+	}
+	if req.Labels != nil {
+		endpoint.Labels = req.Labels
+	}
+	if req.Status != nil {
+		if *req.Status != models.EndpointStatusActive && *req.Status != models.EndpointStatusDecommissioned {
+			return nil, fmt.Errorf("invalid endpoint status '%s'", *req.Status)
+		}
+		endpoint.Status = *req.Status
+	}
+
+	if err := s.endpointRepo.Update(ctx, endpoint.ID, endpoint); err != nil {
+		return nil, fmt.Errorf("failed to update endpoint: %w", err)
+	}
+
+	// Log audit
+	s.audit.LogChange(ctx, models.AuditActionUpdate, "endpoint", endpoint.ID.Hex(), userID, userEmail, before, endpoint, map[string]interface{}{
+		"endpoint_id": endpoint.EndpointID,
+	})
+
+	return endpoint, nil
+}
+
+// DeleteEndpoint deletes an endpoint
+func (s *EndpointService) DeleteEndpoint(ctx context.Context, id string, userID, userEmail string) error {
+	endpoint, err := s.GetEndpoint(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.endpointRepo.Delete(ctx, endpoint.ID); err != nil {
+		return fmt.Errorf("failed to delete endpoint: %w", err)
+	}
+
+	// Log audit
+	s.audit.LogChange(ctx, models.AuditActionDelete, "endpoint", endpoint.ID.Hex(), userID, userEmail, endpoint, nil, map[string]interface{}{
+		"endpoint_id": endpoint.EndpointID,
+		"hostname":    endpoint.Hostname,
+	})
+
+	return nil
+}
+
+// RecordHeartbeat records an agent heartbeat and refreshes any reported inventory
+func (s *EndpointService) RecordHeartbeat(ctx context.Context, id string, req *models.EndpointHeartbeatRequest) (*models.Endpoint, error) {
+	endpoint, err := s.GetEndpoint(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	inventory := bson.M{}
+	if req != nil {
+		if req.Hostname != "" {
+			inventory["hostname"] = req.Hostname
+		}
+		if req.OS != "" {
+			inventory["os"] = req.OS
+		}
+		if req.OSVersion != "" {
+			inventory["os_version"] = req.OSVersion
+		}
+		if req.Architecture != "" {
+			inventory["architecture"] = req.Architecture
+		}
+		if req.AgentVersion != "" {
+			inventory["agent_version"] = req.AgentVersion
+		}
+	}
+
+	if err := s.endpointRepo.UpdateHeartbeat(ctx, endpoint.ID, time.Now(), inventory); err != nil {
+		return nil, fmt.Errorf("failed to record heartbeat: %w", err)
+	}
+
+	updated, err := s.endpointRepo.GetByID(ctx, endpoint.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Heartbeats only refreshing the last seen time are not audited
+	if changed := changedInventory(endpoint, inventory); len(changed) > 0 {
+		s.audit.LogChange(ctx, models.AuditActionUpdate, "endpoint", endpoint.ID.Hex(), "", "", endpoint, updated, map[string]interface{}{
+			"endpoint_id": endpoint.EndpointID,
+			"inventory":   changed,
+		})
+	}
+
+	return updated, nil
+}
+
+// changedInventory returns the reported inventory fields that differ from the
+// endpoint's
+func changedInventory(endpoint *models.Endpoint, inventory bson.M) map[string]interface{} {
+	current := map[string]string{
+		"hostname":      endpoint.Hostname,
+		"os":            endpoint.OS,
+		"os_version":    endpoint.OSVersion,
+		"architecture":  endpoint.Architecture,
+		"agent_version": endpoint.AgentVersion,
+	}
+	changed := make(map[string]interface{})
+	for field, value := range inventory {
+		if current[field] != value {
+			changed[field] = value
+		}
+	}
+	return changed
+}
+
+// GetStaleEndpoints reports active endpoints with no heartbeat for the given number of days
+func (s *EndpointService) GetStaleEndpoints(ctx context.Context, days int, productID string) (*models.StaleEndpointsReport, error) {
+	if days < 1 {
+		return nil, fmt.Errorf("invalid days: must be at least 1")
+	}
+
+	now := time.Now()
+	cutoff := now.AddDate(0, 0, -days)
+
+	endpoints, err := s.endpointRepo.FindAll(ctx, &repository.EndpointFilter{
+		ProductID:       productID,
+		Status:          models.EndpointStatusActive,
+		HeartbeatBefore: &cutoff,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stale endpoints: %w", err)
+	}
+
+	report := &models.StaleEndpointsReport{
+		StaleAfterDays: days,
+		Cutoff:         cutoff,
+		Total:          len(endpoints),
+		ByProduct:      make(map[string]int),
+		ByDeployment:   make(map[string]int),
+		Endpoints:      endpoints,
+		GeneratedAt:    now,
+	}
+	for _, endpoint := range endpoints {
+		report.ByProduct[endpoint.ProductID]++
+		report.ByDeployment[endpoint.DeploymentID.Hex()]++
+	}
+
+	return report, nil
+}
@@ -0,0 +1,484 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"updatemanager/internal/models"
+	"updatemanager/internal/repository"
+)
+
+// internalNotificationDedupWindow keeps a redelivered event from notifying
+// an internal user twice, while a version submitted again after changes
+// notifies again
+const internalNotificationDedupWindow = time.Hour
+
+// internalGroupIDPattern is what internal group IDs look like, e.g.
+// "release-managers"
+var internalGroupIDPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]{0,99}$`)
+
+// internalNotificationTypes are the notification types internal users and
+// groups can subscribe to
+var internalNotificationTypes = []models.NotificationType{
+	models.NotificationTypePendingReview,
+	models.NotificationTypeVersionApproved,
+	models.NotificationTypeRolloutFailed,
+}
+
+// InternalNotificationService notifies internal users, such as release
+// managers and approvers, of review, approval and rollout events according to
+// their subscriptions. Internal notifications live in an inbox of their own,
+// apart from customer notifications, and are in-app only.
+type InternalNotificationService struct {
+	inbox            *NotificationService
+	groupRepo        *repository.InternalGroupRepository
+	subscriptionRepo *repository.InternalNotificationSubscriptionRepository
+	audit            *AuditLogger
+}
+
+// NewInternalNotificationService creates a new internal notification service.
+// inbox stores the internal notifications, apart from customer ones.
+func NewInternalNotificationService(inbox *NotificationService, groupRepo *repository.InternalGroupRepository, subscriptionRepo *repository.InternalNotificationSubscriptionRepository, audit *AuditLogger) *InternalNotificationService {
+	return &InternalNotificationService{
+		inbox:            inbox,
+		groupRepo:        groupRepo,
+		subscriptionRepo: subscriptionRepo,
+		audit:            audit,
+	}
+}
+
+// Inbox returns the notification service of the internal inbox
+func (s *InternalNotificationService) Inbox() *NotificationService {
+	return s.inbox
+}
+
+// CreateGroup creates an internal group
+func (s *InternalNotificationService) CreateGroup(ctx context.Context, req *models.CreateInternalGroupRequest, userID, userEmail string) (*models.InternalGroup, error) {
+	group := &models.InternalGroup{
+		GroupID:     strings.TrimSpace(req.GroupID),
+		Name:        req.Name,
+		Description: req.Description,
+		Members:     req.Members,
+		CreatedBy:   userID,
+	}
+	if err := normalizeInternalGroup(group); err != nil {
+		return nil, err
+	}
+
+	if err := s.groupRepo.Create(ctx, group); err != nil {
+		return nil, err
+	}
+
+	s.audit.LogChange(ctx, models.AuditActionCreate, "internal_group", group.ID.Hex(), userID, userEmail, nil, group, map[string]interface{}{
+		"group_id": group.GroupID,
+		"members":  group.Members,
+	})
+
+	return group, nil
+}
+
+// GetGroup retrieves an internal group
+func (s *InternalNotificationService) GetGroup(ctx context.Context, id primitive.ObjectID) (*models.InternalGroup, error) {
+	return s.groupRepo.GetByID(ctx, id)
+}
+
+// ListGroups lists internal groups
+func (s *InternalNotificationService) ListGroups(ctx context.Context, filter bson.M, page, limit int) ([]*models.InternalGroup, int64, error) {
+	opts := options.Find().
+		SetSort(bson.M{"group_id": 1}).
+		SetSkip(int64((page - 1) * limit)).
+		SetLimit(int64(limit))
+
+	groups, err := s.groupRepo.List(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	if groups == nil {
+		groups = []*models.InternalGroup{}
+	}
+
+	total, err := s.groupRepo.Count(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return groups, total, nil
+}
+
+// UpdateGroup changes an internal group's name, description or members. The
+// group ID cannot change, as subscriptions refer to it.
+func (s *InternalNotificationService) UpdateGroup(ctx context.Context, id primitive.ObjectID, req *models.UpdateInternalGroupRequest, userID, userEmail string) (*models.InternalGroup, error) {
+	group, err := s.groupRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	before := auditState(group)
+	if req.Name != nil {
+		group.Name = *req.Name
+	}
+	if req.Description != nil {
+		group.Description = *req.Description
+	}
+	if req.Members != nil {
+		group.Members = *req.Members
+	}
+	if err := normalizeInternalGroup(group); err != nil {
+		return nil, err
+	}
+
+	if err := s.groupRepo.Update(ctx, group); err != nil {
+		return nil, err
+	}
+
+	s.audit.LogChange(ctx, models.AuditActionUpdate, "internal_group", group.ID.Hex(), userID, userEmail, before, group, map[string]interface{}{
+		"group_id": group.GroupID,
+		"members":  group.Members,
+	})
+
+	return group, nil
+}
+
+// DeleteGroup deletes an internal group and its subscriptions
+func (s *InternalNotificationService) DeleteGroup(ctx context.Context, id primitive.ObjectID, userID, userEmail string) error {
+	group, err := s.groupRepo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.groupRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+	deleted, err := s.subscriptionRepo.DeleteByRecipient(ctx, models.InternalRecipientGroup, group.GroupID)
+	if err != nil {
+		return err
+	}
+
+	s.audit.LogChange(ctx, models.AuditActionDelete, "internal_group", id.Hex(), userID, userEmail, group, nil, map[string]interface{}{
+		"group_id":              group.GroupID,
+		"subscriptions_deleted": deleted,
+	})
+
+	return nil
+}
+
+// CreateSubscription subscribes an internal user or an existing group to
+// internal notification types
+func (s *InternalNotificationService) CreateSubscription(ctx context.Context, req *models.CreateInternalNotificationSubscriptionRequest, userID, userEmail string) (*models.InternalNotificationSubscription, error) {
+	subscription := &models.InternalNotificationSubscription{
+		RecipientType: req.RecipientType,
+		RecipientID:   strings.TrimSpace(req.RecipientID),
+		Types:         req.Types,
+		ProductID:     req.ProductID,
+		IsActive:      true,
+		CreatedBy:     userID,
+	}
+	if err := validateInternalNotificationSubscription(subscription); err != nil {
+		return nil, err
+	}
+	if subscription.RecipientType == models.InternalRecipientGroup {
+		if _, err := s.groupRepo.GetByGroupID(ctx, subscription.RecipientID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.subscriptionRepo.Create(ctx, subscription); err != nil {
+		return nil, err
+	}
+
+	s.audit.LogChange(ctx, models.AuditActionCreate, "internal_notification_subscription", subscription.ID.Hex(), userID, userEmail, nil, subscription, map[string]interface{}{
+		"recipient_type": subscription.RecipientType,
+		"recipient_id":   subscription.RecipientID,
+		"types":          subscription.Types,
+		"product_id":     subscription.ProductID,
+	})
+
+	return subscription, nil
+}
+
+// GetSubscription retrieves an internal notification subscription
+func (s *InternalNotificationService) GetSubscription(ctx context.Context, id primitive.ObjectID) (*models.InternalNotificationSubscription, error) {
+	return s.subscriptionRepo.GetByID(ctx, id)
+}
+
+// ListSubscriptions lists internal notification subscriptions
+func (s *InternalNotificationService) ListSubscriptions(ctx context.Context, filter bson.M, page, limit int) ([]*models.InternalNotificationSubscription, int64, error) {
+	opts := options.Find().
+		SetSort(bson.M{"created_at": -1}).
+		SetSkip(int64((page - 1) * limit)).
+		SetLimit(int64(limit))
+
+	subscriptions, err := s.subscriptionRepo.List(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	if subscriptions == nil {
+		subscriptions = []*models.InternalNotificationSubscription{}
+	}
+
+	total, err := s.subscriptionRepo.Count(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return subscriptions, total, nil
+}
+
+// UpdateSubscription changes a subscription's types, product or active flag
+func (s *InternalNotificationService) UpdateSubscription(ctx context.Context, id primitive.ObjectID, req *models.UpdateInternalNotificationSubscriptionRequest, userID, userEmail string) (*models.InternalNotificationSubscription, error) {
+	subscription, err := s.subscriptionRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	before := auditState(subscription)
+	if req.Types != nil {
+		subscription.Types = *req.Types
+	}
+	if req.ProductID != nil {
+		subscription.ProductID = *req.ProductID
+	}
+	if req.IsActive != nil {
+		subscription.IsActive = *req.IsActive
+	}
+	if err := validateInternalNotificationSubscription(subscription); err != nil {
+		return nil, err
+	}
+
+	if err := s.subscriptionRepo.Update(ctx, subscription); err != nil {
+		return nil, err
+	}
+
+	s.audit.LogChange(ctx, models.AuditActionUpdate, "internal_notification_subscription", subscription.ID.Hex(), userID, userEmail, before, subscription, map[string]interface{}{
+		"types":      subscription.Types,
+		"product_id": subscription.ProductID,
+		"is_active":  subscription.IsActive,
+	})
+
+	return subscription, nil
+}
+
+// DeleteSubscription deletes an internal notification subscription
+func (s *InternalNotificationService) DeleteSubscription(ctx context.Context, id primitive.ObjectID, userID, userEmail string) error {
+	subscription, err := s.subscriptionRepo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.subscriptionRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	s.audit.LogChange(ctx, models.AuditActionDelete, "internal_notification_subscription", id.Hex(), userID, userEmail, subscription, nil, map[string]interface{}{
+		"recipient_type": subscription.RecipientType,
+		"recipient_id":   subscription.RecipientID,
+	})
+
+	return nil
+}
+
+// Notify sends a notification to every internal user subscribed to its type
+// and product, directly or through a group, once each. It returns how many
+// users were notified.
+func (s *InternalNotificationService) Notify(ctx context.Context, notification *models.Notification) (int, error) {
+	recipients, err := s.recipients(ctx, notification.Type, notification.ProductID)
+	if err != nil {
+		return 0, err
+	}
+	return s.NotifyUsers(ctx, notification, recipients)
+}
+
+// NotifyUsers sends a notification to the given internal users, once each,
+// whether or not they subscribed to its type. It returns how many users were
+// notified.
+func (s *InternalNotificationService) NotifyUsers(ctx context.Context, notification *models.Notification, userIDs []string) (int, error) {
+	notified := 0
+	seen := make(map[string]bool)
+	for _, recipientID := range userIDs {
+		if recipientID == "" || seen[recipientID] {
+			continue
+		}
+		seen[recipientID] = true
+
+		userNotification := *notification
+		userNotification.RecipientID = recipientID
+		created, err := s.inbox.NotifyOnce(ctx, &userNotification, internalNotificationDedupWindow)
+		if err != nil {
+			return notified, err
+		}
+		if created {
+			notified++
+		}
+	}
+	return notified, nil
+}
+
+// recipients returns the user IDs subscribed to a notification type about a
+// product, with group members expanded, sorted and without duplicates.
+// Subscriptions of deleted groups are ignored.
+func (s *InternalNotificationService) recipients(ctx context.Context, notificationType models.NotificationType, productID string) ([]string, error) {
+	subscriptions, err := s.subscriptionRepo.ListMatching(ctx, notificationType, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	users := make(map[string]bool)
+	for _, subscription := range subscriptions {
+		switch subscription.RecipientType {
+		case models.InternalRecipientUser:
+			users[subscription.RecipientID] = true
+		case models.InternalRecipientGroup:
+			group, err := s.groupRepo.GetByGroupID(ctx, subscription.RecipientID)
+			if err != nil {
+				if strings.Contains(err.Error(), "not found") {
+					continue
+				}
+				return nil, err
+			}
+			for _, member := range group.Members {
+				users[member] = true
+			}
+		}
+	}
+
+	recipients := make([]string, 0, len(users))
+	for user := range users {
+		recipients = append(recipients, user)
+	}
+	sort.Strings(recipients)
+	return recipients, nil
+}
+
+// DomainEventHandler returns the outbox subscriber that notifies internal
+// users of versions submitted for review or approved and of failed rollouts
+func (s *InternalNotificationService) DomainEventHandler() DomainEventHandler {
+	return func(ctx context.Context, event *models.OutboxEvent) error {
+		typed, err := decodeOutboxEvent(event)
+		if err != nil {
+			return err
+		}
+		notification := internalEventNotification(typed)
+		if notification == nil {
+			return nil
+		}
+		_, err = s.Notify(ctx, notification)
+		return err
+	}
+}
+
+// internalEventNotification returns the internal notification of a domain
+// event, or nil if internal users are not notified of it
+func internalEventNotification(event DomainEvent) *models.Notification {
+	switch e := event.(type) {
+	case *VersionSubmitted:
+		return &models.Notification{
+			Type:      models.NotificationTypePendingReview,
+			ProductID: e.ProductID,
+			VersionID: e.VersionID,
+			Subject:   "version:" + e.VersionID,
+			Title:     "Version Submitted for Review",
+			Message:   fmt.Sprintf("Version %s of product %s was submitted for review by %s.", e.VersionNumber, e.ProductID, e.SubmittedBy),
+			Priority:  models.NotificationPriorityNormal,
+			CreatedAt: time.Now(),
+		}
+	case *VersionApproved:
+		return &models.Notification{
+			Type:      models.NotificationTypeVersionApproved,
+			ProductID: e.ProductID,
+			VersionID: e.VersionID,
+			Subject:   "version:" + e.VersionID,
+			Title:     "Version Approved",
+			Message:   fmt.Sprintf("Version %s of product %s was approved by %s and can be released.", e.VersionNumber, e.ProductID, e.ApprovedBy),
+			Priority:  models.NotificationPriorityNormal,
+			CreatedAt: time.Now(),
+		}
+	case *RolloutFailed:
+		reason := e.ErrorMessage
+		if e.TimedOut {
+			reason = "timed out"
+		}
+		if reason == "" {
+			reason = "no error reported"
+		}
+		return &models.Notification{
+			Type:      models.NotificationTypeRolloutFailed,
+			ProductID: e.ProductID,
+			Subject:   "rollout:" + e.RolloutID,
+			Title:     "Rollout Failed",
+			Message:   fmt.Sprintf("The rollout of product %s from %s to %s on endpoint %s failed: %s.", e.ProductID, e.FromVersion, e.ToVersion, e.EndpointID, reason),
+			Priority:  models.NotificationPriorityHigh,
+			CreatedAt: time.Now(),
+		}
+	}
+	return nil
+}
+
+// normalizeInternalGroup checks an internal group and trims and deduplicates
+// its members, keeping their order
+func normalizeInternalGroup(group *models.InternalGroup) error {
+	if !internalGroupIDPattern.MatchString(group.GroupID) {
+		return fmt.Errorf("invalid internal group: group_id must be lowercase letters, digits, '.', '_' or '-'")
+	}
+	if strings.TrimSpace(group.Name) == "" || len(group.Name) > 200 {
+		return fmt.Errorf("invalid internal group: name must be 1 to 200 characters")
+	}
+	if len(group.Description) > 1000 {
+		return fmt.Errorf("invalid internal group: description must be at most 1000 characters")
+	}
+
+	members := make([]string, 0, len(group.Members))
+	seen := make(map[string]bool)
+	for _, member := range group.Members {
+		member = strings.TrimSpace(member)
+		if member == "" {
+			return fmt.Errorf("invalid internal group: empty member")
+		}
+		if !seen[member] {
+			seen[member] = true
+			members = append(members, member)
+		}
+	}
+	group.Members = members
+	return nil
+}
+
+// validateInternalNotificationSubscription checks a subscription's recipient
+// and types
+func validateInternalNotificationSubscription(subscription *models.InternalNotificationSubscription) error {
+	switch subscription.RecipientType {
+	case models.InternalRecipientUser, models.InternalRecipientGroup:
+	default:
+		return fmt.Errorf("invalid internal notification subscription: recipient_type must be user or group")
+	}
+	if subscription.RecipientID == "" {
+		return fmt.Errorf("invalid internal notification subscription: recipient_id is required")
+	}
+	if len(subscription.Types) == 0 {
+		return fmt.Errorf("invalid internal notification subscription: at least one type is required")
+	}
+	for _, notificationType := range subscription.Types {
+		if !isInternalNotificationType(notificationType) {
+			return fmt.Errorf("invalid internal notification subscription: unsupported type %q", notificationType)
+		}
+	}
+	return nil
+}
+
+// isInternalNotificationType reports whether internal users can subscribe to
+// a notification type
+func isInternalNotificationType(notificationType models.NotificationType) bool {
+	for _, internalType := range internalNotificationTypes {
+		if notificationType == internalType {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,202 @@
+package service
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+
+	"updatemanager/internal/models"
+	"updatemanager/internal/repository"
+)
+
+func TestNormalizeInternalGroup(t *testing.T) {
+	group := &models.InternalGroup{
+		GroupID: "release-managers",
+		Name:    "Release managers",
+		Members: []string{" alice ", "bob", "alice"},
+	}
+	if err := normalizeInternalGroup(group); err != nil {
+		t.Fatalf("normalizeInternalGroup() error = %v", err)
+	}
+	if !reflect.DeepEqual(group.Members, []string{"alice", "bob"}) {
+		t.Errorf("Expected trimmed unique members, got %v", group.Members)
+	}
+
+	tests := []struct {
+		name    string
+		group   models.InternalGroup
+		wantErr string
+	}{
+		{"upper case ID", models.InternalGroup{GroupID: "Release", Name: "Release"}, "group_id"},
+		{"ID with spaces", models.InternalGroup{GroupID: "release managers", Name: "Release"}, "group_id"},
+		{"missing name", models.InternalGroup{GroupID: "release", Name: " "}, "name"},
+		{"empty member", models.InternalGroup{GroupID: "release", Name: "Release", Members: []string{" "}}, "empty member"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := normalizeInternalGroup(&tt.group)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) || !strings.Contains(err.Error(), "invalid internal group") {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestValidateInternalNotificationSubscription(t *testing.T) {
+	valid := func() *models.InternalNotificationSubscription {
+		return &models.InternalNotificationSubscription{
+			RecipientType: models.InternalRecipientGroup,
+			RecipientID:   "approvers",
+			Types:         []models.NotificationType{models.NotificationTypePendingReview},
+			ProductID:     "billing",
+		}
+	}
+
+	tests := []struct {
+		name    string
+		mutate  func(*models.InternalNotificationSubscription)
+		wantErr string
+	}{
+		{"valid group subscription", func(s *models.InternalNotificationSubscription) {}, ""},
+		{"valid user subscription", func(s *models.InternalNotificationSubscription) {
+			s.RecipientType = models.InternalRecipientUser
+			s.Types = internalNotificationTypes
+		}, ""},
+		{"customer recipient", func(s *models.InternalNotificationSubscription) { s.RecipientType = "customer" }, "recipient_type"},
+		{"missing recipient", func(s *models.InternalNotificationSubscription) { s.RecipientID = "" }, "recipient_id"},
+		{"no types", func(s *models.InternalNotificationSubscription) { s.Types = nil }, "at least one type"},
+		{"customer type", func(s *models.InternalNotificationSubscription) {
+			s.Types = []models.NotificationType{models.NotificationTypeNewVersion}
+		}, "unsupported type"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			subscription := valid()
+			tt.mutate(subscription)
+			err := validateInternalNotificationSubscription(subscription)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Expected no error, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) || !strings.Contains(err.Error(), "invalid internal notification subscription") {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestInternalEventNotification(t *testing.T) {
+	tests := []struct {
+		name         string
+		event        DomainEvent
+		wantType     models.NotificationType
+		wantSubject  string
+		wantPriority models.NotificationPriority
+	}{
+		{"submitted", &VersionSubmitted{VersionID: "v1", ProductID: "billing", VersionNumber: "2.0.0", SubmittedBy: "dev"},
+			models.NotificationTypePendingReview, "version:v1", models.NotificationPriorityNormal},
+		{"approved", &VersionApproved{VersionID: "v1", ProductID: "billing", VersionNumber: "2.0.0", ApprovedBy: "lead"},
+			models.NotificationTypeVersionApproved, "version:v1", models.NotificationPriorityNormal},
+		{"rollout failed", &RolloutFailed{RolloutID: "r1", ProductID: "billing", TimedOut: true},
+			models.NotificationTypeRolloutFailed, "rollout:r1", models.NotificationPriorityHigh},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			notification := internalEventNotification(tt.event)
+			if notification == nil {
+				t.Fatal("Expected a notification")
+			}
+			if notification.Type != tt.wantType || notification.Subject != tt.wantSubject || notification.Priority != tt.wantPriority {
+				t.Errorf("Unexpected notification %+v", notification)
+			}
+			if notification.ProductID != "billing" {
+				t.Errorf("Expected product billing, got %q", notification.ProductID)
+			}
+		})
+	}
+
+	if internalEventNotification(&VersionReleased{VersionID: "v1"}) != nil {
+		t.Error("Expected no internal notification of a release")
+	}
+}
+
+func TestInternalNotificationService_Notify(t *testing.T) {
+	setupNotificationServiceTestDB(t)
+	defer teardownNotificationServiceTestDB(t)
+
+	ctx := notificationServiceTestCtx
+	db := notificationServiceTestDB
+	for _, name := range []string{"internal_notifications", "internal_notification_dedup_keys", "internal_groups", "internal_notification_subscriptions"} {
+		defer db.Collection(name).Drop(ctx)
+	}
+
+	inboxRepo := repository.NewNotificationRepository(db.Collection("internal_notifications"))
+	internal := NewInternalNotificationService(
+		NewNotificationService(inboxRepo, repository.NewNotificationDedupRepository(db.Collection("internal_notification_dedup_keys")), nil, nil, nil, nil),
+		repository.NewInternalGroupRepository(db.Collection("internal_groups")),
+		repository.NewInternalNotificationSubscriptionRepository(db.Collection("internal_notification_subscriptions")),
+		nil,
+	)
+
+	if _, err := internal.CreateGroup(ctx, &models.CreateInternalGroupRequest{
+		GroupID: "approvers",
+		Name:    "Approvers",
+		Members: []string{"alice", "bob"},
+	}, "admin", "admin@example.com"); err != nil {
+		t.Fatalf("CreateGroup() error = %v", err)
+	}
+
+	subscriptions := []*models.CreateInternalNotificationSubscriptionRequest{
+		{RecipientType: models.InternalRecipientGroup, RecipientID: "approvers", Types: []models.NotificationType{models.NotificationTypePendingReview}, ProductID: "billing"},
+		{RecipientType: models.InternalRecipientUser, RecipientID: "bob", Types: []models.NotificationType{models.NotificationTypePendingReview}},
+		{RecipientType: models.InternalRecipientUser, RecipientID: "carol", Types: []models.NotificationType{models.NotificationTypeRolloutFailed}},
+	}
+	for _, req := range subscriptions {
+		if _, err := internal.CreateSubscription(ctx, req, "admin", "admin@example.com"); err != nil {
+			t.Fatalf("CreateSubscription() error = %v", err)
+		}
+	}
+
+	if _, err := internal.CreateSubscription(ctx, &models.CreateInternalNotificationSubscriptionRequest{
+		RecipientType: models.InternalRecipientGroup,
+		RecipientID:   "nobody",
+		Types:         []models.NotificationType{models.NotificationTypePendingReview},
+	}, "admin", "admin@example.com"); err == nil || !strings.Contains(err.Error(), "internal group not found") {
+		t.Errorf("Expected an unknown group to be rejected, got %v", err)
+	}
+
+	// The group and bob are subscribed; bob is notified once
+	submitted := &VersionSubmitted{VersionID: "v1", ProductID: "billing", VersionNumber: "2.0.0", SubmittedBy: "dev"}
+	notified, err := internal.Notify(ctx, internalEventNotification(submitted))
+	if err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+	if notified != 2 {
+		t.Errorf("Expected alice and bob to be notified, got %d", notified)
+	}
+
+	// Only bob subscribed to other products
+	submitted = &VersionSubmitted{VersionID: "v2", ProductID: "orders", VersionNumber: "1.0.0", SubmittedBy: "dev"}
+	if notified, _ := internal.Notify(ctx, internalEventNotification(submitted)); notified != 1 {
+		t.Errorf("Expected only bob to be notified, got %d", notified)
+	}
+
+	// A redelivered event notifies nobody twice
+	if notified, _ := internal.Notify(ctx, internalEventNotification(submitted)); notified != 0 {
+		t.Errorf("Expected no duplicate notifications, got %d", notified)
+	}
+
+	if count, _ := inboxRepo.Count(ctx, bson.M{"recipient_id": "bob"}); count != 2 {
+		t.Errorf("Expected 2 notifications for bob, got %d", count)
+	}
+	if count, _ := notificationRepo.Count(ctx, bson.M{}); count != 0 {
+		t.Errorf("Expected no customer notifications, got %d", count)
+	}
+}
@@ -19,7 +19,7 @@ type LicenseAllocationService struct {
 	customerRepo     *repository.CustomerRepository
 	tenantRepo       *repository.TenantRepository
 	deploymentRepo   *repository.DeploymentRepository
-	auditRepo        *repository.AuditLogRepository
+	audit            *AuditLogger
 }
 
 // NewLicenseAllocationService creates a new license allocation service
@@ -30,7 +30,7 @@ func NewLicenseAllocationService(
 	customerRepo *repository.CustomerRepository,
 	tenantRepo *repository.TenantRepository,
 	deploymentRepo *repository.DeploymentRepository,
-	auditRepo *repository.AuditLogRepository,
+	audit *AuditLogger,
 ) *LicenseAllocationService {
 	return &LicenseAllocationService{
 		allocationRepo:   allocationRepo,
@@ -39,7 +39,7 @@ func NewLicenseAllocationService(
 		customerRepo:     customerRepo,
 		tenantRepo:       tenantRepo,
 		deploymentRepo:   deploymentRepo,
-		auditRepo:        auditRepo,
+		audit:            audit,
 	}
 }
 
@@ -154,7 +154,7 @@ func (s *LicenseAllocationService) AllocateLicense(ctx context.Context, customer
 	}
 
 	// Log audit
-	s.logAudit(ctx, models.AuditActionCreate, "license_allocation", allocation.ID.Hex(), userID, "", map[string]interface{}{
+	s.audit.LogChange(ctx, models.AuditActionCreate, "license_allocation", allocation.ID.Hex(), userID, "", nil, allocation, map[string]interface{}{
 		"allocation_id": allocation.AllocationID,
 		"license_id":    licenseID,
 		"seats":          req.NumberOfSeatsAllocated,
@@ -213,7 +213,8 @@ func (s *LicenseAllocationService) ReleaseAllocation(ctx context.Context, custom
 	}
 
 	// Log audit
-	s.logAudit(ctx, models.AuditActionUpdate, "license_allocation", allocation.ID.Hex(), userID, "", map[string]interface{}{
+	released, _ := s.allocationRepo.GetByID(ctx, allocation.ID)
+	s.audit.LogChange(ctx, models.AuditActionUpdate, "license_allocation", allocation.ID.Hex(), userID, "", allocation, released, map[string]interface{}{
 		"allocation_id": allocationID,
 		"action":        "release",
 	})
@@ -423,23 +424,3 @@ func (s *LicenseAllocationService) GetAvailableSeats(ctx context.Context, licens
 
 	return license.NumberOfSeats - totalAllocated, nil
 }
-
-// logAudit logs an audit entry
-func (s *LicenseAllocationService) logAudit(ctx context.Context, action models.AuditAction, resourceType, resourceID, userID, userEmail string, details map[string]interface{}) {
-	if s.auditRepo == nil {
-		return
-	}
-
-	auditLog := &models.AuditLog{
-		Action:       action,
-		ResourceType: resourceType,
-		ResourceID:   resourceID,
-		UserID:       userID,
-		UserEmail:    userEmail,
-		Details:      details,
-		Timestamp:    time.Now(),
-	}
-
-	_ = s.auditRepo.Create(ctx, auditLog)
-}
-
@@ -53,7 +53,7 @@ func setupAllocationServiceTestDB(t *testing.T) {
 		allocationServiceCustomerRepo,
 		allocationServiceTenantRepo,
 		allocationServiceDeploymentRepo,
-		allocationServiceAuditRepo,
+		NewAuditLogger(allocationServiceAuditRepo),
 	)
 }
 
@@ -0,0 +1,32 @@
+package service
+
+import (
+	"context"
+	"time"
+)
+
+// defaultLicenseExpirySweepInterval is how often licenses are checked for expiry
+const defaultLicenseExpirySweepInterval = 15 * time.Minute
+
+// LicenseExpirySweeper periodically expires time-based licenses past their
+// end date
+type LicenseExpirySweeper struct {
+	licenseService *LicenseService
+	interval       time.Duration
+}
+
+// NewLicenseExpirySweeper creates a new license expiry sweeper
+func NewLicenseExpirySweeper(licenseService *LicenseService, interval time.Duration) *LicenseExpirySweeper {
+	if interval <= 0 {
+		interval = defaultLicenseExpirySweepInterval
+	}
+	return &LicenseExpirySweeper{
+		licenseService: licenseService,
+		interval:       interval,
+	}
+}
+
+// Run expires lapsed licenses on every tick until the context is cancelled
+func (s *LicenseExpirySweeper) Run(ctx context.Context) {
+	runPeriodically(ctx, "License expiry sweeper", s.interval, s.licenseService.ExpireLicenses)
+}
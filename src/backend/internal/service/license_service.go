@@ -15,7 +15,8 @@ type LicenseService struct {
 	subscriptionRepo *repository.SubscriptionRepository
 	customerRepo     *repository.CustomerRepository
 	allocationRepo   *repository.LicenseAllocationRepository
-	auditRepo        *repository.AuditLogRepository
+	audit            *AuditLogger
+	outbox           *DomainEventOutbox
 }
 
 // NewLicenseService creates a new license service
@@ -24,14 +25,16 @@ func NewLicenseService(
 	subscriptionRepo *repository.SubscriptionRepository,
 	customerRepo *repository.CustomerRepository,
 	allocationRepo *repository.LicenseAllocationRepository,
-	auditRepo *repository.AuditLogRepository,
+	audit *AuditLogger,
+	outbox *DomainEventOutbox, // May be nil; expiries then raise no LicenseExpired event
 ) *LicenseService {
 	return &LicenseService{
 		licenseRepo:      licenseRepo,
 		subscriptionRepo: subscriptionRepo,
 		customerRepo:     customerRepo,
 		allocationRepo:   allocationRepo,
-		auditRepo:        auditRepo,
+		audit:            audit,
+		outbox:           outbox,
 	}
 }
 
@@ -88,7 +91,7 @@ func (s *LicenseService) AssignLicense(ctx context.Context, customerID, subscrip
 	}
 
 	// Log audit
-	s.logAudit(ctx, models.AuditActionCreate, "license", license.ID.Hex(), userID, "", map[string]interface{}{
+	s.audit.LogChange(ctx, models.AuditActionCreate, "license", license.ID.Hex(), userID, "", nil, license, map[string]interface{}{
 		"license_id":     license.LicenseID,
 		"subscription_id": subscriptionID,
 		"product_id":     license.ProductID,
@@ -164,6 +167,7 @@ func (s *LicenseService) UpdateLicense(ctx context.Context, customerID, subscrip
 		return nil, err
 	}
 
+	before := auditState(license)
 	// Update fields
 	if req.LicenseType != nil {
 		license.LicenseType = *req.LicenseType
@@ -200,7 +204,7 @@ func (s *LicenseService) UpdateLicense(ctx context.Context, customerID, subscrip
 	}
 
 	// Log audit
-	s.logAudit(ctx, models.AuditActionUpdate, "license", license.ID.Hex(), userID, "", map[string]interface{}{
+	s.audit.LogChange(ctx, models.AuditActionUpdate, "license", license.ID.Hex(), userID, "", before, license, map[string]interface{}{
 		"license_id": license.LicenseID,
 	})
 
@@ -222,13 +226,14 @@ func (s *LicenseService) RevokeLicense(ctx context.Context, customerID, subscrip
 	}
 
 	// Update license status
+	before := auditState(license)
 	license.Status = models.LicenseStatusRevoked
 	if err := s.licenseRepo.Update(ctx, license.ID, license); err != nil {
 		return fmt.Errorf("failed to revoke license: %w", err)
 	}
 
 	// Log audit
-	s.logAudit(ctx, models.AuditActionDelete, "license", license.ID.Hex(), userID, "", map[string]interface{}{
+	s.audit.LogChange(ctx, models.AuditActionDelete, "license", license.ID.Hex(), userID, "", before, license, map[string]interface{}{
 		"license_id": license.LicenseID,
 		"action":     "revoke",
 	})
@@ -283,24 +288,88 @@ func (s *LicenseService) ValidateLicenseStatus(ctx context.Context, licenseID st
 	}
 
 	now := time.Now()
-	needsUpdate := false
 
 	// Check if time-based license should be expired
 	if license.LicenseType == models.LicenseTypeTimeBased && license.EndDate != nil && license.EndDate.Before(now) {
-		if license.Status != models.LicenseStatusExpired {
+		if license.Status == models.LicenseStatusActive {
+			if _, err := s.expireLicense(ctx, license); err != nil {
+				return nil, fmt.Errorf("failed to update license status: %w", err)
+			}
 			license.Status = models.LicenseStatusExpired
-			needsUpdate = true
 		}
 	}
 
-	// Update if needed
-	if needsUpdate {
-		if err := s.licenseRepo.Update(ctx, license.ID, license); err != nil {
-			return nil, fmt.Errorf("failed to update license status: %w", err)
+	return license, nil
+}
+
+// maxLicenseExpiryBatch caps how many licenses one expiry sweep handles
+const maxLicenseExpiryBatch = 500
+
+// ExpireLicenses expires active time-based licenses whose end date passed,
+// raising LicenseExpired for each. It returns how many licenses were expired.
+func (s *LicenseService) ExpireLicenses(ctx context.Context, now time.Time) (int, error) {
+	licenses, err := s.licenseRepo.GetLapsedLicenses(ctx, now, maxLicenseExpiryBatch)
+	if err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	for _, license := range licenses {
+		updated, err := s.expireLicense(ctx, license)
+		if err != nil {
+			return expired, err
+		}
+		if !updated {
+			continue
 		}
+		expired++
+
+		before := auditState(license)
+		license.Status = models.LicenseStatusExpired
+		s.audit.LogChange(ctx, models.AuditActionUpdate, "license", license.ID.Hex(), schedulerUserID, "", before, license, map[string]interface{}{
+			"license_id": license.LicenseID,
+			"action":     "expire",
+			"end_date":   license.EndDate,
+		})
 	}
 
-	return license, nil
+	return expired, nil
+}
+
+// expireLicense marks an active license expired and raises LicenseExpired in
+// the same write. It reports false when the license was no longer active.
+func (s *LicenseService) expireLicense(ctx context.Context, license *models.License) (bool, error) {
+	customerID := s.licenseCustomerID(ctx, license)
+
+	var updated bool
+	err := s.outbox.Write(ctx, func(ctx context.Context) ([]DomainEvent, error) {
+		var err error
+		updated, err = s.licenseRepo.ExpireIfActive(ctx, license.ID)
+		if err != nil || !updated {
+			return nil, err
+		}
+		return []DomainEvent{&LicenseExpired{
+			LicenseID:  license.LicenseID,
+			ProductID:  license.ProductID,
+			CustomerID: customerID,
+			EndDate:    license.EndDate,
+		}}, nil
+	})
+	return updated, err
+}
+
+// licenseCustomerID returns the customer ID of a license's subscription, or
+// "" if it cannot be resolved
+func (s *LicenseService) licenseCustomerID(ctx context.Context, license *models.License) string {
+	subscription, err := s.subscriptionRepo.GetByID(ctx, license.SubscriptionID)
+	if err != nil {
+		return ""
+	}
+	customer, err := s.customerRepo.GetByID(ctx, subscription.CustomerID)
+	if err != nil {
+		return ""
+	}
+	return customer.CustomerID
 }
 
 // GetAvailableSeats calculates available seats for a license
@@ -354,6 +423,7 @@ func (s *LicenseService) RenewLicense(ctx context.Context, customerID, subscript
 		return nil, fmt.Errorf("new end date must be after start date")
 	}
 
+	before := auditState(license)
 	// Update end date
 	license.EndDate = &newEndDate
 	if license.Status == models.LicenseStatusExpired {
@@ -366,7 +436,7 @@ func (s *LicenseService) RenewLicense(ctx context.Context, customerID, subscript
 	}
 
 	// Log audit
-	s.logAudit(ctx, models.AuditActionUpdate, "license", license.ID.Hex(), userID, "", map[string]interface{}{
+	s.audit.LogChange(ctx, models.AuditActionUpdate, "license", license.ID.Hex(), userID, "", before, license, map[string]interface{}{
 		"license_id":  license.LicenseID,
 		"action":      "renew",
 		"new_end_date": newEndDate,
@@ -374,23 +444,3 @@ func (s *LicenseService) RenewLicense(ctx context.Context, customerID, subscript
 
 	return license, nil
 }
-
-// logAudit logs an audit entry
-func (s *LicenseService) logAudit(ctx context.Context, action models.AuditAction, resourceType, resourceID, userID, userEmail string, details map[string]interface{}) {
-	if s.auditRepo == nil {
-		return
-	}
-
-	auditLog := &models.AuditLog{
-		Action:       action,
-		ResourceType: resourceType,
-		ResourceID:   resourceID,
-		UserID:       userID,
-		UserEmail:    userEmail,
-		Details:      details,
-		Timestamp:    time.Now(),
-	}
-
-	_ = s.auditRepo.Create(ctx, auditLog)
-}
-
@@ -42,7 +42,7 @@ func setupLicenseServiceTestDB(t *testing.T) {
 	licenseServiceCustomerRepo = repository.NewCustomerRepository(db.Collection("customers"))
 	licenseServiceAllocationRepo = repository.NewLicenseAllocationRepository(db.Collection("license_allocations"))
 	licenseServiceAuditRepo = repository.NewAuditLogRepository(db.Collection("audit_logs"))
-	licenseService = NewLicenseService(licenseServiceRepo, licenseServiceSubscriptionRepo, licenseServiceCustomerRepo, licenseServiceAllocationRepo, licenseServiceAuditRepo)
+	licenseService = NewLicenseService(licenseServiceRepo, licenseServiceSubscriptionRepo, licenseServiceCustomerRepo, licenseServiceAllocationRepo, NewAuditLogger(licenseServiceAuditRepo), nil)
 }
 
 func teardownLicenseServiceTestDB(t *testing.T) {
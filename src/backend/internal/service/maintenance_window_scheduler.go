@@ -0,0 +1,32 @@
+package service
+
+import (
+	"context"
+	"time"
+)
+
+// defaultMaintenanceSchedulerInterval is how often scheduled rollouts are checked
+const defaultMaintenanceSchedulerInterval = time.Minute
+
+// MaintenanceWindowScheduler periodically releases rollouts whose maintenance
+// window has opened
+type MaintenanceWindowScheduler struct {
+	rolloutService *UpdateRolloutService
+	interval       time.Duration
+}
+
+// NewMaintenanceWindowScheduler creates a new maintenance window scheduler
+func NewMaintenanceWindowScheduler(rolloutService *UpdateRolloutService, interval time.Duration) *MaintenanceWindowScheduler {
+	if interval <= 0 {
+		interval = defaultMaintenanceSchedulerInterval
+	}
+	return &MaintenanceWindowScheduler{
+		rolloutService: rolloutService,
+		interval:       interval,
+	}
+}
+
+// Run releases due rollouts on every tick until the context is cancelled
+func (s *MaintenanceWindowScheduler) Run(ctx context.Context) {
+	runPeriodically(ctx, "Maintenance window scheduler", s.interval, s.rolloutService.ReleaseScheduledRollouts)
+}
@@ -0,0 +1,417 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	// Embedded so schedule time zones resolve on hosts without tzdata
+	_ "time/tzdata"
+
+	"updatemanager/internal/models"
+	"updatemanager/internal/repository"
+)
+
+const (
+	// maintenanceLookahead bounds the search for the next window
+	maintenanceLookahead = 366 * 24 * time.Hour
+	// maxMaintenanceWindowMinutes caps cron window durations at a week
+	maxMaintenanceWindowMinutes = 7 * 24 * 60
+	// maxMaintenanceWindows caps the windows of one schedule
+	maxMaintenanceWindows = 20
+)
+
+// MaintenanceWindowService resolves the maintenance windows that gate
+// rollouts. A deployment's schedule replaces its tenant's; without either,
+// rollouts are not restricted.
+type MaintenanceWindowService struct {
+	deploymentRepo *repository.DeploymentRepository
+	tenantRepo     *repository.TenantRepository
+}
+
+// NewMaintenanceWindowService creates a new maintenance window service
+func NewMaintenanceWindowService(deploymentRepo *repository.DeploymentRepository, tenantRepo *repository.TenantRepository) *MaintenanceWindowService {
+	return &MaintenanceWindowService{
+		deploymentRepo: deploymentRepo,
+		tenantRepo:     tenantRepo,
+	}
+}
+
+// WindowForEndpoint returns the maintenance window status of an endpoint's
+// deployment, or nil if the endpoint is not restricted
+func (s *MaintenanceWindowService) WindowForEndpoint(ctx context.Context, endpoint *models.Endpoint, now time.Time) (*models.MaintenanceWindowStatus, error) {
+	deployment, err := s.deploymentRepo.GetByID(ctx, endpoint.DeploymentID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get deployment: %w", err)
+	}
+	return s.WindowForDeployment(ctx, deployment, now)
+}
+
+// WindowForDeployment returns the maintenance window status of a deployment,
+// or nil if the deployment is not restricted
+func (s *MaintenanceWindowService) WindowForDeployment(ctx context.Context, deployment *models.Deployment, now time.Time) (*models.MaintenanceWindowStatus, error) {
+	schedule, source := deployment.MaintenanceSchedule, "deployment"
+	if schedule == nil {
+		tenant, err := s.tenantRepo.GetByID(ctx, deployment.TenantID)
+		if err != nil {
+			if strings.Contains(err.Error(), "not found") {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("failed to get tenant: %w", err)
+		}
+		schedule, source = tenant.MaintenanceSchedule, "tenant"
+	}
+	if schedule == nil {
+		return nil, nil
+	}
+
+	return maintenanceWindowStatus(schedule, source, now)
+}
+
+// maintenanceWindowStatus describes the window open at now, or else the next
+// one to open. OpensAt is nil when no window opens within the lookahead.
+func maintenanceWindowStatus(schedule *models.MaintenanceSchedule, source string, now time.Time) (*models.MaintenanceWindowStatus, error) {
+	status := &models.MaintenanceWindowStatus{
+		Source:   source,
+		TimeZone: schedule.TimeZone,
+	}
+	if status.TimeZone == "" {
+		status.TimeZone = "UTC"
+	}
+
+	opensAt, closesAt, ok, err := nextMaintenanceWindow(schedule, now)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		status.Open = !opensAt.After(now)
+		status.OpensAt = &opensAt
+		status.ClosesAt = &closesAt
+	}
+	return status, nil
+}
+
+// nextMaintenanceWindow returns the window open at now, or else the earliest
+// one opening within the lookahead. Windows opening on a blackout date are
+// skipped.
+func nextMaintenanceWindow(schedule *models.MaintenanceSchedule, now time.Time) (opensAt, closesAt time.Time, ok bool, err error) {
+	loc, err := maintenanceLocation(schedule.TimeZone)
+	if err != nil {
+		return time.Time{}, time.Time{}, false, err
+	}
+
+	windows := make([]compiledMaintenanceWindow, 0, len(schedule.Windows))
+	for i := range schedule.Windows {
+		window, err := compileMaintenanceWindow(&schedule.Windows[i])
+		if err != nil {
+			return time.Time{}, time.Time{}, false, err
+		}
+		windows = append(windows, window)
+	}
+
+	blackout := make(map[string]bool, len(schedule.BlackoutDates))
+	for _, date := range schedule.BlackoutDates {
+		blackout[date] = true
+	}
+
+	// Start far enough back to find a long window that is still open
+	local := now.In(loc)
+	first := time.Date(local.Year(), local.Month(), local.Day()-7, 0, 0, 0, 0, loc)
+	last := now.Add(maintenanceLookahead)
+
+	for day := first; !day.After(last); day = time.Date(day.Year(), day.Month(), day.Day()+1, 0, 0, 0, 0, loc) {
+		// Any window found on an earlier day opens before this day's windows
+		if ok && day.After(opensAt) {
+			break
+		}
+		if blackout[day.Format("2006-01-02")] {
+			continue
+		}
+		for _, window := range windows {
+			occurrence, found := window.firstOccurrence(day, now)
+			if found && (!ok || occurrence.opens.Before(opensAt)) {
+				opensAt, closesAt, ok = occurrence.opens, occurrence.closes, true
+			}
+		}
+	}
+
+	return opensAt, closesAt, ok, nil
+}
+
+// validateMaintenanceSchedule checks a schedule and that it opens a window
+// within the lookahead from now
+func validateMaintenanceSchedule(schedule *models.MaintenanceSchedule, now time.Time) error {
+	if _, err := maintenanceLocation(schedule.TimeZone); err != nil {
+		return err
+	}
+	if len(schedule.Windows) == 0 {
+		return fmt.Errorf("invalid maintenance schedule: at least one window is required")
+	}
+	if len(schedule.Windows) > maxMaintenanceWindows {
+		return fmt.Errorf("invalid maintenance schedule: at most %d windows are allowed", maxMaintenanceWindows)
+	}
+	for i := range schedule.Windows {
+		if _, err := compileMaintenanceWindow(&schedule.Windows[i]); err != nil {
+			return fmt.Errorf("%w (window %d)", err, i+1)
+		}
+	}
+	for _, date := range schedule.BlackoutDates {
+		if _, err := time.Parse("2006-01-02", date); err != nil {
+			return fmt.Errorf("invalid maintenance schedule: blackout date '%s' must be YYYY-MM-DD", date)
+		}
+	}
+
+	_, _, ok, err := nextMaintenanceWindow(schedule, now)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("invalid maintenance schedule: no window opens within the next year")
+	}
+	return nil
+}
+
+// normalizeMaintenanceSchedule validates a requested schedule. A schedule
+// without windows removes the restriction and is returned as nil.
+func normalizeMaintenanceSchedule(schedule *models.MaintenanceSchedule, now time.Time) (*models.MaintenanceSchedule, error) {
+	if schedule == nil || len(schedule.Windows) == 0 {
+		return nil, nil
+	}
+	if err := validateMaintenanceSchedule(schedule, now); err != nil {
+		return nil, err
+	}
+	return schedule, nil
+}
+
+// maintenanceLocation loads a schedule time zone; empty means UTC
+func maintenanceLocation(name string) (*time.Location, error) {
+	if name == "" {
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("invalid maintenance schedule: unknown time zone '%s'", name)
+	}
+	return loc, nil
+}
+
+// maintenanceOccurrence is one opening of a window
+type maintenanceOccurrence struct {
+	opens, closes time.Time
+}
+
+// compiledMaintenanceWindow is a parsed MaintenanceWindow
+type compiledMaintenanceWindow struct {
+	cron     *cronSchedule
+	duration time.Duration
+
+	weekdays               [7]bool
+	startHour, startMinute int
+	endHour, endMinute     int
+}
+
+// compileMaintenanceWindow parses either form of a window
+func compileMaintenanceWindow(window *models.MaintenanceWindow) (compiledMaintenanceWindow, error) {
+	var compiled compiledMaintenanceWindow
+
+	if window.Cron != "" {
+		if len(window.Weekdays) > 0 || window.StartTime != "" || window.EndTime != "" {
+			return compiled, fmt.Errorf("invalid maintenance schedule: a window uses either cron or weekdays with start and end times")
+		}
+		cron, err := parseCron(window.Cron)
+		if err != nil {
+			return compiled, err
+		}
+		if window.DurationMinutes < 1 || window.DurationMinutes > maxMaintenanceWindowMinutes {
+			return compiled, fmt.Errorf("invalid maintenance schedule: cron windows need a duration between 1 and %d minutes", maxMaintenanceWindowMinutes)
+		}
+		compiled.cron = cron
+		compiled.duration = time.Duration(window.DurationMinutes) * time.Minute
+		return compiled, nil
+	}
+
+	if len(window.Weekdays) == 0 || window.StartTime == "" || window.EndTime == "" {
+		return compiled, fmt.Errorf("invalid maintenance schedule: a window needs a cron expression or weekdays with start and end times")
+	}
+	if window.DurationMinutes != 0 {
+		return compiled, fmt.Errorf("invalid maintenance schedule: duration_minutes only applies to cron windows")
+	}
+	for _, name := range window.Weekdays {
+		day, ok := parseWeekday(name)
+		if !ok {
+			return compiled, fmt.Errorf("invalid maintenance schedule: unknown weekday '%s'", name)
+		}
+		compiled.weekdays[day] = true
+	}
+	start, err := time.Parse("15:04", window.StartTime)
+	if err != nil {
+		return compiled, fmt.Errorf("invalid maintenance schedule: start time '%s' must be HH:MM", window.StartTime)
+	}
+	end, err := time.Parse("15:04", window.EndTime)
+	if err != nil {
+		return compiled, fmt.Errorf("invalid maintenance schedule: end time '%s' must be HH:MM", window.EndTime)
+	}
+	if start.Equal(end) {
+		return compiled, fmt.Errorf("invalid maintenance schedule: start and end time must differ")
+	}
+	compiled.startHour, compiled.startMinute = start.Hour(), start.Minute()
+	compiled.endHour, compiled.endMinute = end.Hour(), end.Minute()
+	return compiled, nil
+}
+
+// firstOccurrence returns the earliest opening of the window on a day, given
+// as local midnight, that closes after now
+func (w compiledMaintenanceWindow) firstOccurrence(day, now time.Time) (maintenanceOccurrence, bool) {
+	y, m, d := day.Date()
+	loc := day.Location()
+
+	if w.cron == nil {
+		if !w.weekdays[day.Weekday()] {
+			return maintenanceOccurrence{}, false
+		}
+		opens := time.Date(y, m, d, w.startHour, w.startMinute, 0, 0, loc)
+		closes := time.Date(y, m, d, w.endHour, w.endMinute, 0, 0, loc)
+		// An end at or before the start falls on the next day
+		if !closes.After(opens) {
+			closes = time.Date(y, m, d+1, w.endHour, w.endMinute, 0, 0, loc)
+		}
+		return maintenanceOccurrence{opens: opens, closes: closes}, closes.After(now)
+	}
+
+	if !w.cron.matchesDay(day) {
+		return maintenanceOccurrence{}, false
+	}
+	for hour := 0; hour < 24; hour++ {
+		// Skip hours whose openings have all closed by now
+		if !w.cron.hours[hour] || !time.Date(y, m, d, hour+1, 0, 0, 0, loc).Add(w.duration).After(now) {
+			continue
+		}
+		for minute := 0; minute < 60; minute++ {
+			if !w.cron.minutes[minute] {
+				continue
+			}
+			opens := time.Date(y, m, d, hour, minute, 0, 0, loc)
+			if closes := opens.Add(w.duration); closes.After(now) {
+				return maintenanceOccurrence{opens: opens, closes: closes}, true
+			}
+		}
+	}
+	return maintenanceOccurrence{}, false
+}
+
+// parseWeekday accepts full or three-letter English day names
+func parseWeekday(name string) (time.Weekday, bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		full := strings.ToLower(day.String())
+		if name == full || name == full[:3] {
+			return day, true
+		}
+	}
+	return 0, false
+}
+
+// cronSchedule is a parsed five-field cron expression:
+// minute hour day-of-month month day-of-week
+type cronSchedule struct {
+	minutes     [60]bool
+	hours       [24]bool
+	daysOfMonth [32]bool
+	months      [13]bool
+	daysOfWeek  [7]bool
+
+	// As in cron, when both day fields are restricted either may match
+	domRestricted bool
+	dowRestricted bool
+}
+
+// parseCron parses a cron expression. Fields accept *, numbers, ranges
+// (1-5), lists (1,3,5) and steps (*/15, 8-18/2); day-of-week 7 is Sunday.
+func parseCron(expr string) (*cronSchedule, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("invalid maintenance schedule: cron expression '%s' must have 5 fields", expr)
+	}
+
+	var cron cronSchedule
+	var dow [8]bool
+	if err := parseCronField(fields[0], 0, 59, cron.minutes[:]); err != nil {
+		return nil, err
+	}
+	if err := parseCronField(fields[1], 0, 23, cron.hours[:]); err != nil {
+		return nil, err
+	}
+	if err := parseCronField(fields[2], 1, 31, cron.daysOfMonth[:]); err != nil {
+		return nil, err
+	}
+	if err := parseCronField(fields[3], 1, 12, cron.months[:]); err != nil {
+		return nil, err
+	}
+	if err := parseCronField(fields[4], 0, 7, dow[:]); err != nil {
+		return nil, err
+	}
+	copy(cron.daysOfWeek[:], dow[:7])
+	cron.daysOfWeek[0] = cron.daysOfWeek[0] || dow[7]
+
+	// As in cron, a field starting with * (including */n) is unrestricted
+	cron.domRestricted = !strings.HasPrefix(fields[2], "*")
+	cron.dowRestricted = !strings.HasPrefix(fields[4], "*")
+	return &cron, nil
+}
+
+// parseCronField marks the values a field selects in set
+func parseCronField(field string, min, max int, set []bool) error {
+	for _, part := range strings.Split(field, ",") {
+		rangePart, step := part, 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			n, err := strconv.Atoi(part[i+1:])
+			if err != nil || n < 1 {
+				return fmt.Errorf("invalid maintenance schedule: bad step in cron field '%s'", field)
+			}
+			rangePart, step = part[:i], n
+		}
+
+		lo, hi := min, max
+		if rangePart != "*" {
+			bounds := strings.SplitN(rangePart, "-", 2)
+			var err error
+			if lo, err = strconv.Atoi(bounds[0]); err != nil {
+				return fmt.Errorf("invalid maintenance schedule: bad value in cron field '%s'", field)
+			}
+			hi = lo
+			if len(bounds) == 2 {
+				if hi, err = strconv.Atoi(bounds[1]); err != nil {
+					return fmt.Errorf("invalid maintenance schedule: bad value in cron field '%s'", field)
+				}
+			} else if step > 1 {
+				hi = max
+			}
+		}
+		if lo < min || hi > max || lo > hi {
+			return fmt.Errorf("invalid maintenance schedule: cron field '%s' must be within %d-%d", field, min, max)
+		}
+
+		for v := lo; v <= hi; v += step {
+			set[v] = true
+		}
+	}
+	return nil
+}
+
+// matchesDay reports whether the schedule fires on a day
+func (c *cronSchedule) matchesDay(day time.Time) bool {
+	if !c.months[day.Month()] {
+		return false
+	}
+	dom := c.daysOfMonth[day.Day()]
+	dow := c.daysOfWeek[day.Weekday()]
+	if c.domRestricted && c.dowRestricted {
+		return dom || dow
+	}
+	return dom && dow
+}
@@ -0,0 +1,170 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"updatemanager/internal/models"
+)
+
+func TestParseCron(t *testing.T) {
+	tests := []struct {
+		expr    string
+		wantErr bool
+	}{
+		{"0 2 * * *", false},
+		{"*/15 22-23 * * 1-5", false},
+		{"30 1 1,15 * *", false},
+		{"0 3 * * 7", false},
+		{"0 2 * *", true},
+		{"60 2 * * *", true},
+		{"0 5-3 * * *", true},
+		{"0 2 * * */0", true},
+		{"0 2 0 * *", true},
+	}
+
+	for _, tt := range tests {
+		_, err := parseCron(tt.expr)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseCron(%q) error = %v, wantErr %v", tt.expr, err, tt.wantErr)
+		}
+	}
+
+	// Day-of-week 7 is Sunday; a restricted day-of-month and day-of-week match either
+	cron, _ := parseCron("0 0 13 * 7")
+	sunday := time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+	tuesday13 := time.Date(2026, 10, 13, 0, 0, 0, 0, time.UTC)
+	monday := time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+	if !cron.matchesDay(sunday) || !cron.matchesDay(tuesday13) || cron.matchesDay(monday) {
+		t.Error("Expected cron to match Sundays and the 13th only")
+	}
+
+	// A stepped day-of-month starting with * is unrestricted, so only the day-of-week applies
+	cron, _ = parseCron("0 2 */1 * 1")
+	if !cron.matchesDay(monday) || cron.matchesDay(sunday) || cron.matchesDay(tuesday13) {
+		t.Error("Expected cron with */1 day-of-month to match Mondays only")
+	}
+}
+
+func TestNextMaintenanceWindow(t *testing.T) {
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+	// Sunday 18 October 2026, 12:00 in Berlin
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, berlin)
+
+	tests := []struct {
+		name       string
+		schedule   models.MaintenanceSchedule
+		wantOpens  time.Time
+		wantCloses time.Time
+	}{
+		{
+			name: "weekday window later today",
+			schedule: models.MaintenanceSchedule{TimeZone: "Europe/Berlin", Windows: []models.MaintenanceWindow{
+				{Weekdays: []string{"sun"}, StartTime: "22:00", EndTime: "02:00"},
+			}},
+			wantOpens:  time.Date(2026, 10, 18, 22, 0, 0, 0, berlin),
+			wantCloses: time.Date(2026, 10, 19, 2, 0, 0, 0, berlin),
+		},
+		{
+			name: "open window",
+			schedule: models.MaintenanceSchedule{TimeZone: "Europe/Berlin", Windows: []models.MaintenanceWindow{
+				{Weekdays: []string{"Sunday"}, StartTime: "11:00", EndTime: "13:00"},
+			}},
+			wantOpens:  time.Date(2026, 10, 18, 11, 0, 0, 0, berlin),
+			wantCloses: time.Date(2026, 10, 18, 13, 0, 0, 0, berlin),
+		},
+		{
+			name: "overnight window from yesterday already closed",
+			schedule: models.MaintenanceSchedule{TimeZone: "Europe/Berlin", Windows: []models.MaintenanceWindow{
+				{Weekdays: []string{"saturday"}, StartTime: "23:00", EndTime: "04:00"},
+			}},
+			wantOpens:  time.Date(2026, 10, 24, 23, 0, 0, 0, berlin),
+			wantCloses: time.Date(2026, 10, 25, 4, 0, 0, 0, berlin),
+		},
+		{
+			name: "cron window in UTC",
+			schedule: models.MaintenanceSchedule{Windows: []models.MaintenanceWindow{
+				{Cron: "0 2 * * 1-5", DurationMinutes: 90},
+			}},
+			wantOpens:  time.Date(2026, 10, 19, 2, 0, 0, 0, time.UTC),
+			wantCloses: time.Date(2026, 10, 19, 3, 30, 0, 0, time.UTC),
+		},
+		{
+			name: "blackout date skips a window",
+			schedule: models.MaintenanceSchedule{Windows: []models.MaintenanceWindow{
+				{Cron: "0 2 * * 1-5", DurationMinutes: 90},
+			}, BlackoutDates: []string{"2026-10-19"}},
+			wantOpens:  time.Date(2026, 10, 20, 2, 0, 0, 0, time.UTC),
+			wantCloses: time.Date(2026, 10, 20, 3, 30, 0, 0, time.UTC),
+		},
+		{
+			name: "every minute cron open since an earlier minute",
+			schedule: models.MaintenanceSchedule{Windows: []models.MaintenanceWindow{
+				{Cron: "* * * * *", DurationMinutes: 5},
+			}},
+			wantOpens:  now.Add(-4 * time.Minute),
+			wantCloses: now.Add(time.Minute),
+		},
+		{
+			name: "earliest of several windows",
+			schedule: models.MaintenanceSchedule{Windows: []models.MaintenanceWindow{
+				{Cron: "0 2 * * 1-5", DurationMinutes: 90},
+				{Weekdays: []string{"sun"}, StartTime: "20:00", EndTime: "21:00"},
+			}},
+			wantOpens:  time.Date(2026, 10, 18, 20, 0, 0, 0, time.UTC),
+			wantCloses: time.Date(2026, 10, 18, 21, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opens, closes, ok, err := nextMaintenanceWindow(&tt.schedule, now)
+			if err != nil || !ok {
+				t.Fatalf("nextMaintenanceWindow() = %v, %v", ok, err)
+			}
+			if !opens.Equal(tt.wantOpens) || !closes.Equal(tt.wantCloses) {
+				t.Errorf("nextMaintenanceWindow() = %s - %s, want %s - %s", opens, closes, tt.wantOpens, tt.wantCloses)
+			}
+		})
+	}
+
+	status, _ := maintenanceWindowStatus(&tests[1].schedule, "tenant", now)
+	if !status.Open || status.Source != "tenant" || status.TimeZone != "Europe/Berlin" {
+		t.Errorf("Expected open tenant window, got %+v", status)
+	}
+}
+
+func TestValidateMaintenanceSchedule(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	nightly := models.MaintenanceWindow{Cron: "0 2 * * *", DurationMinutes: 60}
+
+	tests := []struct {
+		name     string
+		schedule models.MaintenanceSchedule
+		wantErr  bool
+	}{
+		{"cron window", models.MaintenanceSchedule{Windows: []models.MaintenanceWindow{nightly}}, false},
+		{"weekday window", models.MaintenanceSchedule{TimeZone: "America/New_York", Windows: []models.MaintenanceWindow{{Weekdays: []string{"sat", "sun"}, StartTime: "01:00", EndTime: "05:00"}}}, false},
+		{"no windows", models.MaintenanceSchedule{}, true},
+		{"unknown time zone", models.MaintenanceSchedule{TimeZone: "Mars/Olympus", Windows: []models.MaintenanceWindow{nightly}}, true},
+		{"cron without duration", models.MaintenanceSchedule{Windows: []models.MaintenanceWindow{{Cron: "0 2 * * *"}}}, true},
+		{"both forms", models.MaintenanceSchedule{Windows: []models.MaintenanceWindow{{Cron: "0 2 * * *", DurationMinutes: 60, Weekdays: []string{"sat"}}}}, true},
+		{"weekday without times", models.MaintenanceSchedule{Windows: []models.MaintenanceWindow{{Weekdays: []string{"sat"}}}}, true},
+		{"unknown weekday", models.MaintenanceSchedule{Windows: []models.MaintenanceWindow{{Weekdays: []string{"caturday"}, StartTime: "01:00", EndTime: "02:00"}}}, true},
+		{"bad blackout date", models.MaintenanceSchedule{Windows: []models.MaintenanceWindow{nightly}, BlackoutDates: []string{"18.10.2026"}}, true},
+		{"never opens", models.MaintenanceSchedule{Windows: []models.MaintenanceWindow{{Cron: "0 2 30 2 *", DurationMinutes: 60}}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateMaintenanceSchedule(&tt.schedule, now)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateMaintenanceSchedule() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	if schedule, err := normalizeMaintenanceSchedule(&models.MaintenanceSchedule{TimeZone: "UTC"}, now); schedule != nil || err != nil {
+		t.Errorf("Expected a schedule without windows to be removed, got %+v, %v", schedule, err)
+	}
+}
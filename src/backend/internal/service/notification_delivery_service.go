@@ -0,0 +1,420 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+
+	"updatemanager/internal/models"
+	"updatemanager/internal/notify"
+	"updatemanager/internal/repository"
+)
+
+const (
+	// defaultNotificationDeliveryInterval is how often due channel deliveries are sent
+	defaultNotificationDeliveryInterval = 10 * time.Second
+
+	// maxNotificationDeliveryAttempts is how often a channel delivery is tried before it is marked failed
+	maxNotificationDeliveryAttempts = 5
+
+	// notificationRetryBaseDelay and notificationRetryMaxDelay bound the backoff between attempts
+	notificationRetryBaseDelay = time.Minute
+	notificationRetryMaxDelay  = time.Hour
+
+	// notificationDeliveryLease reserves a claimed notification for its worker
+	notificationDeliveryLease = 2 * time.Minute
+
+	// notificationSendTimeout bounds one channel send
+	notificationSendTimeout = 30 * time.Second
+)
+
+// defaultNotificationPreferences are stored for customers created without
+// preferences, and apply to customers stored before preferences were explicit
+var defaultNotificationPreferences = models.NotificationPreferences{
+	InAppEnabled:            true,
+	UATNotifications:        true,
+	ProductionNotifications: true,
+}
+
+// NotificationDeliveryService delivers notifications over the channels their
+// recipient enabled. The in-app channel is the notification itself; other
+// channels are sent by DeliverPending and retried with backoff. Customers
+// with a digest frequency get their non-critical emails batched by
+// SendDueDigests instead.
+type NotificationDeliveryService struct {
+	notificationRepo *repository.NotificationRepository
+	customerRepo     *repository.CustomerRepository
+	deploymentRepo   *repository.DeploymentRepository
+	routeRepo        *repository.NotificationRouteRepository
+	templates        *NotificationTemplateService
+
+	mu       sync.RWMutex
+	channels map[models.NotificationChannel]notify.Channel
+}
+
+// NewNotificationDeliveryService creates a new notification delivery service
+// with no channels besides in-app
+func NewNotificationDeliveryService(
+	notificationRepo *repository.NotificationRepository,
+	customerRepo *repository.CustomerRepository,
+	deploymentRepo *repository.DeploymentRepository,
+	routeRepo *repository.NotificationRouteRepository,
+	templates *NotificationTemplateService,
+) *NotificationDeliveryService {
+	return &NotificationDeliveryService{
+		notificationRepo: notificationRepo,
+		customerRepo:     customerRepo,
+		deploymentRepo:   deploymentRepo,
+		routeRepo:        routeRepo,
+		templates:        templates,
+		channels:         make(map[models.NotificationChannel]notify.Channel),
+	}
+}
+
+// RegisterChannel makes a channel available for delivery, replacing any
+// channel of the same name
+func (s *NotificationDeliveryService) RegisterChannel(channel notify.Channel) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.channels[channel.Name()] = channel
+}
+
+// channel returns the registered channel of a name, or nil
+func (s *NotificationDeliveryService) channel(name models.NotificationChannel) notify.Channel {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.channels[name]
+}
+
+// Plan decides a new notification's channel deliveries from its recipient's
+// preferences and the matching routes, before it is stored. Recipients that
+// are not customers get the in-app notification and global routes only.
+func (s *NotificationDeliveryService) Plan(ctx context.Context, notification *models.Notification) {
+	now := time.Now()
+
+	customerID := notification.CustomerID
+	muted := ""
+	customer, err := s.customerRepo.GetByCustomerID(ctx, notification.RecipientID)
+	if err != nil {
+		notification.Deliveries = []models.NotificationDelivery{{
+			Channel:  models.NotificationChannelInApp,
+			Status:   models.NotificationDeliverySent,
+			Attempts: 1,
+			SentAt:   &now,
+		}}
+	} else {
+		var deploymentType models.DeploymentType
+		if notification.DeploymentID != "" {
+			if deployment, err := s.deploymentRepo.GetByDeploymentID(ctx, notification.DeploymentID); err == nil {
+				deploymentType = deployment.DeploymentType
+			}
+		}
+
+		customerID = customer.CustomerID
+		muted = notificationMutedReason(customer.NotificationPreferences, deploymentType)
+		notification.Deliveries = planNotificationDeliveries(customer.NotificationPreferences, customer.Email, deploymentType, s.channel(models.NotificationChannelEmail) != nil, now)
+		notification.DigestDueAt = holdForNotificationDigest(notification.Deliveries, customer.NotificationPreferences.DigestFrequency, notification.Priority, now)
+	}
+
+	routes, err := s.routeRepo.ListActiveForCustomer(ctx, customerID)
+	if err != nil {
+		// Routing is best effort; the notification itself is still stored
+		log.Printf("Notification delivery: failed to load routes for %s: %v", customerID, err)
+	}
+	notification.Deliveries = append(notification.Deliveries, s.planRouteDeliveries(routes, notification, muted, now)...)
+	notification.DeliveryDueAt = nextNotificationDelivery(notification.Deliveries)
+}
+
+// planRouteDeliveries returns a delivery for each route matching the
+// notification, skipped when muted or the route's channel is not registered
+func (s *NotificationDeliveryService) planRouteDeliveries(routes []*models.NotificationRoute, notification *models.Notification, muted string, now time.Time) []models.NotificationDelivery {
+	var deliveries []models.NotificationDelivery
+	seen := make(map[string]bool)
+	for _, route := range routes {
+		// Routes to the same place send once
+		key := string(route.Channel) + " " + route.Target
+		if !notificationRouteMatches(route, notification) || seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		routeID := route.ID
+		delivery := models.NotificationDelivery{
+			Channel: route.Channel,
+			Status:  models.NotificationDeliverySkipped,
+			RouteID: &routeID,
+		}
+		switch {
+		case muted != "":
+			delivery.LastError = muted
+		case s.channel(route.Channel) == nil:
+			delivery.LastError = fmt.Sprintf("%s channel is not configured", route.Channel)
+		default:
+			delivery.Status = models.NotificationDeliveryPending
+			delivery.NextAttemptAt = &now
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries
+}
+
+// notificationRouteMatches reports whether a route takes a notification:
+// the notification's priority is at least the route's minimum and its type
+// is one of the route's types, if any. Incident routes only take critical
+// notifications.
+func notificationRouteMatches(route *models.NotificationRoute, notification *models.Notification) bool {
+	if notificationPriorityRank(notification.Priority) < notificationPriorityRank(route.MinPriority) {
+		return false
+	}
+	if route.Channel == models.NotificationChannelPagerDuty && notification.Priority != models.NotificationPriorityCritical {
+		return false
+	}
+	if len(route.Types) == 0 {
+		return true
+	}
+	for _, notificationType := range route.Types {
+		if notificationType == notification.Type {
+			return true
+		}
+	}
+	return false
+}
+
+// notificationPriorityRank orders priorities from low to critical; unset
+// priorities rank as normal
+func notificationPriorityRank(priority models.NotificationPriority) int {
+	switch priority {
+	case models.NotificationPriorityLow:
+		return 0
+	case models.NotificationPriorityHigh:
+		return 2
+	case models.NotificationPriorityCritical:
+		return 3
+	default:
+		return 1
+	}
+}
+
+// planNotificationDeliveries returns one delivery per channel: sent for
+// in-app, pending for channels to send, skipped with the reason otherwise
+func planNotificationDeliveries(prefs models.NotificationPreferences, email string, deploymentType models.DeploymentType, emailConfigured bool, now time.Time) []models.NotificationDelivery {
+	muted := notificationMutedReason(prefs, deploymentType)
+	prefs = effectiveNotificationPreferences(prefs)
+
+	inApp := models.NotificationDelivery{Channel: models.NotificationChannelInApp}
+	switch {
+	case muted != "":
+		inApp.Status = models.NotificationDeliverySkipped
+		inApp.LastError = muted
+	case !prefs.InAppEnabled:
+		inApp.Status = models.NotificationDeliverySkipped
+		inApp.LastError = "in-app notifications are disabled"
+	default:
+		inApp.Status = models.NotificationDeliverySent
+		inApp.Attempts = 1
+		inApp.SentAt = &now
+	}
+
+	emailDelivery := models.NotificationDelivery{
+		Channel: models.NotificationChannelEmail,
+		Status:  models.NotificationDeliverySkipped,
+		Target:  email,
+	}
+	switch {
+	case muted != "":
+		emailDelivery.LastError = muted
+	case !prefs.EmailEnabled:
+		emailDelivery.LastError = "email notifications are disabled"
+	case email == "":
+		emailDelivery.LastError = "customer has no email address"
+	case !emailConfigured:
+		emailDelivery.LastError = "email channel is not configured"
+	default:
+		emailDelivery.Status = models.NotificationDeliveryPending
+		emailDelivery.NextAttemptAt = &now
+	}
+
+	return []models.NotificationDelivery{inApp, emailDelivery}
+}
+
+// notificationMutedReason returns why a customer does not want notifications
+// about deployments of a type, or "" if they do
+func notificationMutedReason(prefs models.NotificationPreferences, deploymentType models.DeploymentType) string {
+	prefs = effectiveNotificationPreferences(prefs)
+	switch {
+	case deploymentType == models.DeploymentTypeProduction && !prefs.ProductionNotifications:
+		return "production notifications are disabled"
+	case deploymentType == models.DeploymentTypeUAT && !prefs.UATNotifications:
+		return "UAT notifications are disabled"
+	}
+	return ""
+}
+
+// effectiveNotificationPreferences returns explicit prefs as they are, so
+// turning everything off is an opt-out. Customers stored before preferences
+// were explicit get the defaults when their channel and deployment type
+// preferences are all false, keeping their digest frequency.
+func effectiveNotificationPreferences(prefs models.NotificationPreferences) models.NotificationPreferences {
+	if prefs.Explicit {
+		return prefs
+	}
+	unset := prefs
+	unset.DigestFrequency = ""
+	if unset == (models.NotificationPreferences{}) {
+		defaults := defaultNotificationPreferences
+		defaults.DigestFrequency = prefs.DigestFrequency
+		return defaults
+	}
+	return prefs
+}
+
+// notificationVisibleInApp reports whether a notification belongs in its
+// recipient's inbox
+func notificationVisibleInApp(notification *models.Notification) bool {
+	for _, delivery := range notification.Deliveries {
+		if delivery.Channel == models.NotificationChannelInApp {
+			return delivery.Status != models.NotificationDeliverySkipped
+		}
+	}
+	return true
+}
+
+// inAppNotificationFilter matches a recipient's notifications, leaving out
+// those whose in-app delivery was skipped
+func inAppNotificationFilter(recipientID string) bson.M {
+	return bson.M{
+		"recipient_id": recipientID,
+		"deliveries": bson.M{"$not": bson.M{"$elemMatch": bson.M{
+			"channel": models.NotificationChannelInApp,
+			"status":  models.NotificationDeliverySkipped,
+		}}},
+	}
+}
+
+// DeliverPending sends channel deliveries due at now until none is left. It
+// returns how many deliveries were sent.
+func (s *NotificationDeliveryService) DeliverPending(ctx context.Context, now time.Time) (int, error) {
+	sent := 0
+	for ctx.Err() == nil {
+		notification, err := s.notificationRepo.ClaimDueDelivery(ctx, now, notificationDeliveryLease)
+		if err != nil {
+			return sent, err
+		}
+		if notification == nil {
+			break
+		}
+
+		sent += s.deliver(ctx, notification, time.Now())
+		if err := s.notificationRepo.UpdateDeliveries(ctx, notification.ID, notification.Deliveries, nextNotificationDelivery(notification.Deliveries)); err != nil {
+			return sent, err
+		}
+	}
+	return sent, nil
+}
+
+// deliver attempts a notification's due deliveries and returns how many were sent
+func (s *NotificationDeliveryService) deliver(ctx context.Context, notification *models.Notification, now time.Time) int {
+	sent := 0
+	for i := range notification.Deliveries {
+		delivery := &notification.Deliveries[i]
+		if delivery.Status != models.NotificationDeliveryPending || (delivery.NextAttemptAt != nil && delivery.NextAttemptAt.After(now)) {
+			continue
+		}
+
+		channel := s.channel(delivery.Channel)
+		target, skip := s.deliveryTarget(ctx, delivery)
+		if channel == nil {
+			skip = fmt.Sprintf("%s channel is not configured", delivery.Channel)
+		}
+		if skip != "" {
+			delivery.Status = models.NotificationDeliverySkipped
+			delivery.LastError = skip
+			delivery.NextAttemptAt = nil
+			continue
+		}
+
+		delivery.Attempts++
+		if err := s.send(ctx, channel, notification, target); err != nil {
+			delivery.LastError = err.Error()
+			if delivery.Attempts >= maxNotificationDeliveryAttempts {
+				log.Printf("Notification delivery: giving up on %s delivery of notification %s after %d attempts: %v", delivery.Channel, notification.ID.Hex(), delivery.Attempts, err)
+				delivery.Status = models.NotificationDeliveryFailed
+				delivery.NextAttemptAt = nil
+			} else {
+				next := now.Add(notificationRetryDelay(delivery.Attempts))
+				delivery.NextAttemptAt = &next
+			}
+			continue
+		}
+
+		delivery.Status = models.NotificationDeliverySent
+		delivery.LastError = ""
+		delivery.NextAttemptAt = nil
+		delivery.SentAt = &now
+		sent++
+	}
+	return sent
+}
+
+// deliveryTarget returns where a delivery is sent: its own target, or the
+// current target of its route. The reason is set when the delivery must be
+// skipped because its route is gone or disabled.
+func (s *NotificationDeliveryService) deliveryTarget(ctx context.Context, delivery *models.NotificationDelivery) (target, reason string) {
+	if delivery.RouteID == nil {
+		return delivery.Target, ""
+	}
+	route, err := s.routeRepo.GetByID(ctx, *delivery.RouteID)
+	if err != nil {
+		return "", "notification route was deleted"
+	}
+	if !route.IsActive {
+		return "", "notification route is disabled"
+	}
+	return route.Target, ""
+}
+
+// send renders a notification for a channel in its recipient's locale and
+// sends it to target
+func (s *NotificationDeliveryService) send(ctx context.Context, channel notify.Channel, notification *models.Notification, target string) error {
+	msg, err := s.templates.RenderNotification(ctx, notification, channel.Name())
+	if err != nil {
+		return err
+	}
+	msg.To = target
+
+	ctx, cancel := context.WithTimeout(ctx, notificationSendTimeout)
+	defer cancel()
+	return channel.Send(ctx, msg)
+}
+
+// nextNotificationDelivery returns when the earliest pending delivery is due,
+// or nil if none is pending
+func nextNotificationDelivery(deliveries []models.NotificationDelivery) *time.Time {
+	var next *time.Time
+	for _, delivery := range deliveries {
+		if delivery.Status != models.NotificationDeliveryPending || delivery.NextAttemptAt == nil {
+			continue
+		}
+		if next == nil || delivery.NextAttemptAt.Before(*next) {
+			due := *delivery.NextAttemptAt
+			next = &due
+		}
+	}
+	return next
+}
+
+// notificationRetryDelay doubles the delay after each failed attempt, up to the maximum
+func notificationRetryDelay(attempts int) time.Duration {
+	delay := notificationRetryBaseDelay
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= notificationRetryMaxDelay {
+			return notificationRetryMaxDelay
+		}
+	}
+	return delay
+}
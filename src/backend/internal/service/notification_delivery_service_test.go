@@ -0,0 +1,249 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"updatemanager/internal/models"
+	"updatemanager/internal/notify"
+	"updatemanager/internal/repository"
+)
+
+func TestPlanNotificationDeliveries(t *testing.T) {
+	now := time.Now()
+	all := models.NotificationPreferences{EmailEnabled: true, InAppEnabled: true, UATNotifications: true, ProductionNotifications: true}
+
+	tests := []struct {
+		name           string
+		prefs          models.NotificationPreferences
+		email          string
+		deploymentType models.DeploymentType
+		configured     bool
+		wantInApp      models.NotificationDeliveryStatus
+		wantEmail      models.NotificationDeliveryStatus
+	}{
+		{"everything enabled", all, "ops@example.com", models.DeploymentTypeProduction, true, models.NotificationDeliverySent, models.NotificationDeliveryPending},
+		{"preferences never set", models.NotificationPreferences{}, "ops@example.com", "", true, models.NotificationDeliverySent, models.NotificationDeliverySkipped},
+		{"everything disabled", models.NotificationPreferences{Explicit: true}, "ops@example.com", models.DeploymentTypeProduction, true, models.NotificationDeliverySkipped, models.NotificationDeliverySkipped},
+		{"email disabled", models.NotificationPreferences{InAppEnabled: true}, "ops@example.com", "", true, models.NotificationDeliverySent, models.NotificationDeliverySkipped},
+		{"in-app disabled", models.NotificationPreferences{EmailEnabled: true}, "ops@example.com", "", true, models.NotificationDeliverySkipped, models.NotificationDeliveryPending},
+		{"email not configured", all, "ops@example.com", "", false, models.NotificationDeliverySent, models.NotificationDeliverySkipped},
+		{"no email address", all, "", "", true, models.NotificationDeliverySent, models.NotificationDeliverySkipped},
+		{"production muted", models.NotificationPreferences{EmailEnabled: true, InAppEnabled: true, UATNotifications: true}, "ops@example.com", models.DeploymentTypeProduction, true, models.NotificationDeliverySkipped, models.NotificationDeliverySkipped},
+		{"UAT muted", models.NotificationPreferences{EmailEnabled: true, InAppEnabled: true, ProductionNotifications: true}, "ops@example.com", models.DeploymentTypeUAT, true, models.NotificationDeliverySkipped, models.NotificationDeliverySkipped},
+		{"testing never muted", models.NotificationPreferences{EmailEnabled: true, InAppEnabled: true}, "ops@example.com", models.DeploymentTypeTesting, true, models.NotificationDeliverySent, models.NotificationDeliveryPending},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deliveries := planNotificationDeliveries(tt.prefs, tt.email, tt.deploymentType, tt.configured, now)
+			if len(deliveries) != 2 {
+				t.Fatalf("Expected in-app and email deliveries, got %+v", deliveries)
+			}
+			if deliveries[0].Status != tt.wantInApp || deliveries[1].Status != tt.wantEmail {
+				t.Errorf("Got in-app %s, email %s; want %s, %s", deliveries[0].Status, deliveries[1].Status, tt.wantInApp, tt.wantEmail)
+			}
+			for _, delivery := range deliveries {
+				if delivery.Status == models.NotificationDeliverySkipped && delivery.LastError == "" {
+					t.Errorf("Expected a reason for the skipped %s delivery", delivery.Channel)
+				}
+			}
+		})
+	}
+}
+
+func TestEffectiveNotificationPreferences_ExplicitOptOut(t *testing.T) {
+	disabled := models.NotificationPreferences{Explicit: true}
+	if got := effectiveNotificationPreferences(disabled); got != disabled {
+		t.Errorf("Expected explicit all-disabled preferences kept, got %+v", got)
+	}
+	for _, deploymentType := range []models.DeploymentType{models.DeploymentTypeProduction, models.DeploymentTypeUAT} {
+		if reason := notificationMutedReason(disabled, deploymentType); reason == "" {
+			t.Errorf("Expected %s notifications muted", deploymentType)
+		}
+	}
+	if deployments := notifiableDeployments(disabled, []*models.Deployment{{DeploymentType: models.DeploymentTypeProduction}, {DeploymentType: models.DeploymentTypeUAT}}); len(deployments) != 0 {
+		t.Errorf("Expected no notifiable deployments, got %d", len(deployments))
+	}
+
+	// Preferences stored before they were explicit fall back to the defaults
+	if got := effectiveNotificationPreferences(models.NotificationPreferences{}); !got.InAppEnabled || !got.ProductionNotifications {
+		t.Errorf("Expected defaults for never set preferences, got %+v", got)
+	}
+}
+
+func TestNotificationRetryDelay(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, time.Minute},
+		{3, 4 * time.Minute},
+		{maxNotificationDeliveryAttempts, 16 * time.Minute},
+		{10, time.Hour},
+	}
+
+	for _, tt := range tests {
+		if got := notificationRetryDelay(tt.attempts); got != tt.want {
+			t.Errorf("notificationRetryDelay(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+func TestNotificationRouteMatches(t *testing.T) {
+	tests := []struct {
+		name     string
+		route    models.NotificationRoute
+		priority models.NotificationPriority
+		kind     models.NotificationType
+		want     bool
+	}{
+		{"any priority", models.NotificationRoute{Channel: models.NotificationChannelSlack, MinPriority: models.NotificationPriorityLow}, models.NotificationPriorityLow, models.NotificationTypeNewVersion, true},
+		{"below minimum", models.NotificationRoute{Channel: models.NotificationChannelSlack, MinPriority: models.NotificationPriorityHigh}, models.NotificationPriorityNormal, models.NotificationTypeNewVersion, false},
+		{"unset priority ranks normal", models.NotificationRoute{Channel: models.NotificationChannelTeams, MinPriority: models.NotificationPriorityNormal}, "", models.NotificationTypeNewVersion, true},
+		{"type listed", models.NotificationRoute{Channel: models.NotificationChannelSlack, MinPriority: models.NotificationPriorityLow, Types: []models.NotificationType{models.NotificationTypeSecurityRelease}}, models.NotificationPriorityHigh, models.NotificationTypeSecurityRelease, true},
+		{"type not listed", models.NotificationRoute{Channel: models.NotificationChannelSlack, MinPriority: models.NotificationPriorityLow, Types: []models.NotificationType{models.NotificationTypeSecurityRelease}}, models.NotificationPriorityHigh, models.NotificationTypeNewVersion, false},
+		{"incident critical", models.NotificationRoute{Channel: models.NotificationChannelPagerDuty, MinPriority: models.NotificationPriorityCritical}, models.NotificationPriorityCritical, models.NotificationTypeSecurityRelease, true},
+		{"incident high", models.NotificationRoute{Channel: models.NotificationChannelPagerDuty, MinPriority: models.NotificationPriorityLow}, models.NotificationPriorityHigh, models.NotificationTypeSecurityRelease, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			notification := &models.Notification{Priority: tt.priority, Type: tt.kind}
+			if got := notificationRouteMatches(&tt.route, notification); got != tt.want {
+				t.Errorf("notificationRouteMatches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPlanRouteDeliveries(t *testing.T) {
+	s := NewNotificationDeliveryService(nil, nil, nil, nil, nil)
+	s.RegisterChannel(notify.NewSlackChannel(nil))
+	now := time.Now()
+
+	slack := &models.NotificationRoute{ID: primitive.NewObjectID(), Channel: models.NotificationChannelSlack, Target: "https://chat.example.com/hook", MinPriority: models.NotificationPriorityLow}
+	duplicate := &models.NotificationRoute{ID: primitive.NewObjectID(), Channel: models.NotificationChannelSlack, Target: "https://chat.example.com/hook", MinPriority: models.NotificationPriorityLow}
+	teams := &models.NotificationRoute{ID: primitive.NewObjectID(), Channel: models.NotificationChannelTeams, Target: "https://teams.example.com/hook", MinPriority: models.NotificationPriorityLow}
+	incident := &models.NotificationRoute{ID: primitive.NewObjectID(), Channel: models.NotificationChannelPagerDuty, Target: "key", MinPriority: models.NotificationPriorityCritical}
+	routes := []*models.NotificationRoute{slack, duplicate, teams, incident}
+	notification := &models.Notification{Priority: models.NotificationPriorityHigh}
+
+	deliveries := s.planRouteDeliveries(routes, notification, "", now)
+	if len(deliveries) != 2 {
+		t.Fatalf("Expected slack and teams deliveries, got %+v", deliveries)
+	}
+	if deliveries[0].Status != models.NotificationDeliveryPending || *deliveries[0].RouteID != slack.ID {
+		t.Errorf("Expected a pending delivery for the slack route, got %+v", deliveries[0])
+	}
+	if deliveries[0].Target != "" {
+		t.Errorf("Expected the route target not to be copied, got %q", deliveries[0].Target)
+	}
+	if deliveries[1].Status != models.NotificationDeliverySkipped || deliveries[1].LastError == "" {
+		t.Errorf("Expected the unregistered teams channel to be skipped, got %+v", deliveries[1])
+	}
+
+	muted := s.planRouteDeliveries(routes, notification, "production notifications disabled", now)
+	for _, delivery := range muted {
+		if delivery.Status != models.NotificationDeliverySkipped {
+			t.Errorf("Expected muted deliveries to be skipped, got %+v", delivery)
+		}
+	}
+}
+
+// fakeChannel records sent messages after failing its first failures sends
+type fakeChannel struct {
+	failures int
+	sent     []*notify.Message
+}
+
+func (c *fakeChannel) Name() models.NotificationChannel {
+	return models.NotificationChannelEmail
+}
+
+func (c *fakeChannel) Send(ctx context.Context, msg *notify.Message) error {
+	if c.failures > 0 {
+		c.failures--
+		return errors.New("connection refused")
+	}
+	c.sent = append(c.sent, msg)
+	return nil
+}
+
+func TestNotificationDeliveryService_DeliverPending(t *testing.T) {
+	setupNotificationServiceTestDB(t)
+	defer teardownNotificationServiceTestDB(t)
+
+	ctx := notificationServiceTestCtx
+	customers := notificationServiceTestDB.Collection("customers")
+	defer customers.Drop(ctx)
+
+	customerRepo := repository.NewCustomerRepository(customers)
+	deploymentRepo := repository.NewDeploymentRepository(notificationServiceTestDB.Collection("deployments"))
+	renderer, err := notify.NewRenderer()
+	if err != nil {
+		t.Fatalf("NewRenderer() error = %v", err)
+	}
+	templates := NewNotificationTemplateService(
+		repository.NewNotificationTemplateRepository(notificationServiceTestDB.Collection("notification_templates")),
+		customerRepo,
+		repository.NewVersionRepository(notificationServiceTestDB.Collection("versions")),
+		deploymentRepo,
+		nil,
+		renderer,
+	)
+	deliveryService := NewNotificationDeliveryService(notificationRepo, customerRepo, deploymentRepo, repository.NewNotificationRouteRepository(notificationServiceTestDB.Collection("notification_routes")), templates)
+	channel := &fakeChannel{failures: 1}
+	deliveryService.RegisterChannel(channel)
+	notifications := NewNotificationService(notificationRepo, nil, nil, deliveryService, templates, nil)
+
+	if err := customerRepo.Create(ctx, &models.Customer{
+		CustomerID:              "customer-email",
+		Name:                    "Acme",
+		Email:                   "ops@acme.example",
+		AccountStatus:           models.CustomerStatusActive,
+		NotificationPreferences: models.NotificationPreferences{EmailEnabled: true, InAppEnabled: true, UATNotifications: true, ProductionNotifications: true},
+	}); err != nil {
+		t.Fatalf("Failed to create customer: %v", err)
+	}
+
+	notification := &models.Notification{
+		Type:        models.NotificationTypeNewVersion,
+		RecipientID: "customer-email",
+		CustomerID:  "customer-email",
+		ProductID:   "hyworks",
+		Title:       "New Version Available",
+		Message:     "A new version is available for product hyworks.",
+		Priority:    models.NotificationPriorityHigh,
+	}
+	if err := notifications.CreateNotification(ctx, notification); err != nil {
+		t.Fatalf("CreateNotification() error = %v", err)
+	}
+
+	// The first send fails and is retried after a backoff
+	now := time.Now()
+	if sent, err := deliveryService.DeliverPending(ctx, now); err != nil || sent != 0 {
+		t.Fatalf("Expected nothing sent, got %d, %v", sent, err)
+	}
+	stored, _ := notificationRepo.GetByID(ctx, notification.ID)
+	email := stored.Deliveries[1]
+	if email.Status != models.NotificationDeliveryPending || email.Attempts != 1 || email.LastError == "" || stored.DeliveryDueAt == nil || !stored.DeliveryDueAt.After(now) {
+		t.Errorf("Expected the email delivery rescheduled, got %+v due %v", email, stored.DeliveryDueAt)
+	}
+
+	if sent, err := deliveryService.DeliverPending(ctx, now.Add(time.Hour)); err != nil || sent != 1 {
+		t.Fatalf("Expected the retry sent, got %d, %v", sent, err)
+	}
+	stored, _ = notificationRepo.GetByID(ctx, notification.ID)
+	email = stored.Deliveries[1]
+	if email.Status != models.NotificationDeliverySent || email.Attempts != 2 || email.SentAt == nil || stored.DeliveryDueAt != nil {
+		t.Errorf("Expected the email delivery sent, got %+v due %v", email, stored.DeliveryDueAt)
+	}
+	if len(channel.sent) != 1 || channel.sent[0].To != "ops@acme.example" || channel.sent[0].Subject != "New version available for hyworks" {
+		t.Errorf("Unexpected sent messages: %+v", channel.sent)
+	}
+}
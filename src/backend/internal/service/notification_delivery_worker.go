@@ -0,0 +1,47 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// NotificationDeliveryWorker periodically sends due notification deliveries
+// over email and the other non in-app channels, and due digests
+type NotificationDeliveryWorker struct {
+	deliveryService *NotificationDeliveryService
+	interval        time.Duration
+}
+
+// NewNotificationDeliveryWorker creates a new notification delivery worker
+func NewNotificationDeliveryWorker(deliveryService *NotificationDeliveryService, interval time.Duration) *NotificationDeliveryWorker {
+	if interval <= 0 {
+		interval = defaultNotificationDeliveryInterval
+	}
+	return &NotificationDeliveryWorker{
+		deliveryService: deliveryService,
+		interval:        interval,
+	}
+}
+
+// Run sends due deliveries and digests on every tick until the context is
+// cancelled
+func (w *NotificationDeliveryWorker) Run(ctx context.Context) {
+	runPeriodically(ctx, "Notification delivery worker", w.interval, w.deliverDue)
+}
+
+// deliverDue sends due deliveries and then due digests, so a failed delivery
+// pass does not hold back the digests
+func (w *NotificationDeliveryWorker) deliverDue(ctx context.Context, now time.Time) (int, error) {
+	delivered, err := w.deliveryService.DeliverPending(ctx, now)
+	digests, digestErr := w.deliveryService.SendDueDigests(ctx, now)
+	if digestErr != nil {
+		digestErr = fmt.Errorf("digests: %w", digestErr)
+		if err == nil {
+			err = digestErr
+		} else {
+			err = fmt.Errorf("%v; %w", err, digestErr)
+		}
+	}
+	return delivered + digests, err
+}
@@ -0,0 +1,253 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"updatemanager/internal/models"
+	"updatemanager/internal/notify"
+)
+
+// notificationDigestHour is the UTC hour daily and weekly digests are sent at
+const notificationDigestHour = 8
+
+// validNotificationDigestFrequency reports whether a digest frequency is
+// known; empty means immediate
+func validNotificationDigestFrequency(frequency models.NotificationDigestFrequency) bool {
+	switch frequency {
+	case "", models.NotificationDigestImmediate, models.NotificationDigestHourly,
+		models.NotificationDigestDaily, models.NotificationDigestWeekly:
+		return true
+	}
+	return false
+}
+
+// nextNotificationDigestAt returns when the next digest of a frequency after
+// t is sent, in UTC: on the hour, daily at notificationDigestHour, or weekly
+// on Mondays at notificationDigestHour. It returns nil for immediate delivery.
+func nextNotificationDigestAt(frequency models.NotificationDigestFrequency, t time.Time) *time.Time {
+	t = t.UTC()
+	var next time.Time
+	switch frequency {
+	case models.NotificationDigestHourly:
+		next = t.Truncate(time.Hour).Add(time.Hour)
+	case models.NotificationDigestDaily, models.NotificationDigestWeekly:
+		next = time.Date(t.Year(), t.Month(), t.Day(), notificationDigestHour, 0, 0, 0, time.UTC)
+		if !next.After(t) {
+			next = next.AddDate(0, 0, 1)
+		}
+		if frequency == models.NotificationDigestWeekly {
+			next = next.AddDate(0, 0, (int(time.Monday)-int(next.Weekday())+7)%7)
+		}
+	default:
+		return nil
+	}
+	return &next
+}
+
+// holdForNotificationDigest holds a customer's pending email delivery for
+// their next digest and returns when it is due, or nil when the notification
+// is sent right away. Critical notifications are never held.
+func holdForNotificationDigest(deliveries []models.NotificationDelivery, frequency models.NotificationDigestFrequency, priority models.NotificationPriority, now time.Time) *time.Time {
+	if priority == models.NotificationPriorityCritical {
+		return nil
+	}
+	dueAt := nextNotificationDigestAt(frequency, now)
+	if dueAt == nil {
+		return nil
+	}
+
+	held := false
+	for i := range deliveries {
+		delivery := &deliveries[i]
+		if delivery.Channel != models.NotificationChannelEmail || delivery.RouteID != nil || delivery.Status != models.NotificationDeliveryPending {
+			continue
+		}
+		delivery.Status = models.NotificationDeliveryDigest
+		delivery.NextAttemptAt = dueAt
+		held = true
+	}
+	if !held {
+		return nil
+	}
+	return dueAt
+}
+
+// groupNotificationDigest groups a digest's notifications per product and
+// version, in the order each group first appears
+func groupNotificationDigest(notifications []*models.Notification) []notify.DigestGroup {
+	var groups []notify.DigestGroup
+	index := make(map[string]int)
+	for _, notification := range notifications {
+		key := notification.ProductID + "\x00" + notification.VersionID
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, notify.DigestGroup{ProductID: notification.ProductID, VersionID: notification.VersionID})
+		}
+		groups[i].Notifications = append(groups[i].Notifications, notification)
+	}
+	return groups
+}
+
+// SendDueDigests sends the digests due at now until none is left: one email
+// per recipient with all their held notifications. It returns how many
+// digests were sent.
+func (s *NotificationDeliveryService) SendDueDigests(ctx context.Context, now time.Time) (int, error) {
+	sent := 0
+	for ctx.Err() == nil {
+		notifications, err := s.notificationRepo.ClaimDueDigest(ctx, now, notificationDeliveryLease)
+		if err != nil {
+			return sent, err
+		}
+		if len(notifications) == 0 {
+			break
+		}
+
+		if s.sendDigest(ctx, notifications, time.Now()) {
+			sent++
+		}
+		for _, notification := range notifications {
+			if err := s.notificationRepo.UpdateDigest(ctx, notification.ID, notification.Deliveries, nextNotificationDigestAttempt(notification.Deliveries)); err != nil {
+				return sent, err
+			}
+		}
+	}
+	return sent, nil
+}
+
+// sendDigest sends one recipient's held notifications as a digest email to
+// the customer's current address and records the outcome on each held
+// delivery. It reports whether the digest was sent.
+func (s *NotificationDeliveryService) sendDigest(ctx context.Context, notifications []*models.Notification, now time.Time) bool {
+	recipientID := notifications[0].RecipientID
+	skip := ""
+	customer, err := s.customerRepo.GetByCustomerID(ctx, recipientID)
+	switch {
+	case err != nil:
+		skip = "customer was deleted"
+	case customer.Email == "":
+		skip = "customer has no email address"
+	case s.channel(models.NotificationChannelEmail) == nil:
+		skip = "email channel is not configured"
+	}
+
+	attempts := 0
+	for _, notification := range notifications {
+		if delivery := heldDigestDelivery(notification); delivery != nil && delivery.Attempts > attempts {
+			attempts = delivery.Attempts
+		}
+	}
+	attempts++
+
+	var sendErr error
+	if skip == "" {
+		sendErr = s.renderAndSendDigest(ctx, customer, notifications)
+	}
+
+	for _, notification := range notifications {
+		delivery := heldDigestDelivery(notification)
+		if delivery == nil {
+			continue
+		}
+		switch {
+		case skip != "":
+			delivery.Status = models.NotificationDeliverySkipped
+			delivery.LastError = skip
+			delivery.NextAttemptAt = nil
+		case sendErr != nil:
+			delivery.Attempts = attempts
+			delivery.LastError = sendErr.Error()
+			if attempts >= maxNotificationDeliveryAttempts {
+				delivery.Status = models.NotificationDeliveryFailed
+				delivery.NextAttemptAt = nil
+			} else {
+				next := now.Add(notificationRetryDelay(attempts))
+				delivery.NextAttemptAt = &next
+			}
+		default:
+			delivery.Status = models.NotificationDeliverySent
+			delivery.Attempts = attempts
+			delivery.Target = customer.Email
+			delivery.LastError = ""
+			delivery.NextAttemptAt = nil
+			delivery.SentAt = &now
+		}
+	}
+
+	if sendErr != nil && attempts >= maxNotificationDeliveryAttempts {
+		log.Printf("Notification delivery: giving up on digest for %s after %d attempts: %v", recipientID, attempts, sendErr)
+	}
+	return skip == "" && sendErr == nil
+}
+
+// renderAndSendDigest renders the digest email of a customer's notifications
+// in their locale and sends it
+func (s *NotificationDeliveryService) renderAndSendDigest(ctx context.Context, customer *models.Customer, notifications []*models.Notification) error {
+	groups := groupNotificationDigest(notifications)
+	for i := range groups {
+		if groups[i].VersionID != "" {
+			groups[i].Version, _ = s.templates.version(ctx, groups[i].VersionID)
+		}
+	}
+
+	// The digest renders as a notification of its own, as urgent as the most
+	// urgent notification in it
+	digest := &models.Notification{
+		Type:        models.NotificationTypeDigest,
+		RecipientID: customer.CustomerID,
+		CustomerID:  customer.CustomerID,
+		Title:       fmt.Sprintf("%d notifications", len(notifications)),
+		Priority:    models.NotificationPriorityLow,
+		CreatedAt:   time.Now(),
+	}
+	for _, notification := range notifications {
+		if notificationPriorityRank(notification.Priority) > notificationPriorityRank(digest.Priority) {
+			digest.Priority = notification.Priority
+		}
+	}
+
+	data := s.templates.TemplateData(ctx, digest, customer, []*models.Deployment{})
+	data.Digest = &notify.DigestData{
+		Frequency: effectiveNotificationPreferences(customer.NotificationPreferences).DigestFrequency,
+		Count:     len(notifications),
+		Groups:    groups,
+	}
+
+	msg, err := s.templates.Render(ctx, models.NotificationChannelEmail, data)
+	if err != nil {
+		return err
+	}
+	msg.To = customer.Email
+
+	ctx, cancel := context.WithTimeout(ctx, notificationSendTimeout)
+	defer cancel()
+	return s.channel(models.NotificationChannelEmail).Send(ctx, msg)
+}
+
+// heldDigestDelivery returns a notification's email delivery held for a
+// digest, or nil
+func heldDigestDelivery(notification *models.Notification) *models.NotificationDelivery {
+	for i := range notification.Deliveries {
+		delivery := &notification.Deliveries[i]
+		if delivery.Channel == models.NotificationChannelEmail && delivery.Status == models.NotificationDeliveryDigest {
+			return delivery
+		}
+	}
+	return nil
+}
+
+// nextNotificationDigestAttempt returns when a held delivery is next due,
+// or nil if none is held
+func nextNotificationDigestAttempt(deliveries []models.NotificationDelivery) *time.Time {
+	for _, delivery := range deliveries {
+		if delivery.Status == models.NotificationDeliveryDigest && delivery.NextAttemptAt != nil {
+			due := *delivery.NextAttemptAt
+			return &due
+		}
+	}
+	return nil
+}
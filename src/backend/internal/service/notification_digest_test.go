@@ -0,0 +1,212 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"updatemanager/internal/models"
+	"updatemanager/internal/notify"
+	"updatemanager/internal/repository"
+)
+
+func TestNextNotificationDigestAt(t *testing.T) {
+	// A Wednesday
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2024, time.May, day, hour, minute, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name      string
+		frequency models.NotificationDigestFrequency
+		t         time.Time
+		want      *time.Time
+	}{
+		{"immediate", models.NotificationDigestImmediate, at(15, 10, 30), nil},
+		{"unset", "", at(15, 10, 30), nil},
+		{"hourly", models.NotificationDigestHourly, at(15, 10, 30), timePtr(at(15, 11, 0))},
+		{"hourly on the hour", models.NotificationDigestHourly, at(15, 10, 0), timePtr(at(15, 11, 0))},
+		{"daily before the hour", models.NotificationDigestDaily, at(15, 7, 59), timePtr(at(15, 8, 0))},
+		{"daily after the hour", models.NotificationDigestDaily, at(15, 8, 0), timePtr(at(16, 8, 0))},
+		{"weekly", models.NotificationDigestWeekly, at(15, 10, 30), timePtr(at(20, 8, 0))},
+		{"weekly Monday morning", models.NotificationDigestWeekly, at(20, 7, 0), timePtr(at(20, 8, 0))},
+		{"weekly Monday afternoon", models.NotificationDigestWeekly, at(20, 9, 0), timePtr(at(27, 8, 0))},
+		{"other time zone", models.NotificationDigestDaily, at(15, 7, 0).In(time.FixedZone("CEST", 2*3600)), timePtr(at(15, 8, 0))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := nextNotificationDigestAt(tt.frequency, tt.t)
+			if (got == nil) != (tt.want == nil) || (got != nil && !got.Equal(*tt.want)) {
+				t.Errorf("nextNotificationDigestAt() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
+
+func TestHoldForNotificationDigest(t *testing.T) {
+	now := time.Date(2024, time.May, 15, 10, 30, 0, 0, time.UTC)
+	routeID := primitive.NewObjectID()
+	plan := func() []models.NotificationDelivery {
+		return []models.NotificationDelivery{
+			{Channel: models.NotificationChannelInApp, Status: models.NotificationDeliverySent},
+			{Channel: models.NotificationChannelEmail, Status: models.NotificationDeliveryPending, NextAttemptAt: &now},
+			{Channel: models.NotificationChannelSlack, Status: models.NotificationDeliveryPending, NextAttemptAt: &now, RouteID: &routeID},
+		}
+	}
+
+	deliveries := plan()
+	dueAt := holdForNotificationDigest(deliveries, models.NotificationDigestDaily, models.NotificationPriorityHigh, now)
+	if dueAt == nil || !dueAt.Equal(time.Date(2024, time.May, 16, 8, 0, 0, 0, time.UTC)) {
+		t.Fatalf("Expected the next daily digest, got %v", dueAt)
+	}
+	if deliveries[1].Status != models.NotificationDeliveryDigest || !deliveries[1].NextAttemptAt.Equal(*dueAt) {
+		t.Errorf("Expected the email held for the digest, got %+v", deliveries[1])
+	}
+	if deliveries[0].Status != models.NotificationDeliverySent || deliveries[2].Status != models.NotificationDeliveryPending {
+		t.Errorf("Expected in-app and routed deliveries untouched, got %+v", deliveries)
+	}
+
+	// Critical notifications bypass the digest
+	deliveries = plan()
+	if dueAt := holdForNotificationDigest(deliveries, models.NotificationDigestDaily, models.NotificationPriorityCritical, now); dueAt != nil || deliveries[1].Status != models.NotificationDeliveryPending {
+		t.Errorf("Expected a critical email sent right away, got %v, %+v", dueAt, deliveries[1])
+	}
+
+	deliveries = plan()
+	if dueAt := holdForNotificationDigest(deliveries, models.NotificationDigestImmediate, models.NotificationPriorityLow, now); dueAt != nil || deliveries[1].Status != models.NotificationDeliveryPending {
+		t.Errorf("Expected an immediate email, got %v, %+v", dueAt, deliveries[1])
+	}
+
+	// Nothing is held when email is skipped
+	deliveries = plan()
+	deliveries[1].Status = models.NotificationDeliverySkipped
+	if dueAt := holdForNotificationDigest(deliveries, models.NotificationDigestHourly, models.NotificationPriorityLow, now); dueAt != nil {
+		t.Errorf("Expected no digest without a pending email, got %v", dueAt)
+	}
+}
+
+func TestGroupNotificationDigest(t *testing.T) {
+	notifications := []*models.Notification{
+		{ProductID: "orders", VersionID: "v2", Title: "1"},
+		{ProductID: "billing", VersionID: "v7", Title: "2"},
+		{ProductID: "orders", VersionID: "v2", Title: "3"},
+		{ProductID: "orders", Title: "4"},
+	}
+
+	groups := groupNotificationDigest(notifications)
+	if len(groups) != 3 {
+		t.Fatalf("Expected 3 groups, got %+v", groups)
+	}
+	if groups[0].ProductID != "orders" || groups[0].VersionID != "v2" || len(groups[0].Notifications) != 2 || groups[0].Notifications[1].Title != "3" {
+		t.Errorf("Unexpected first group %+v", groups[0])
+	}
+	if groups[1].ProductID != "billing" || groups[2].ProductID != "orders" || groups[2].VersionID != "" {
+		t.Errorf("Expected groups in order of appearance, got %+v", groups)
+	}
+}
+
+func TestEffectiveNotificationPreferences_DigestOnly(t *testing.T) {
+	prefs := effectiveNotificationPreferences(models.NotificationPreferences{DigestFrequency: models.NotificationDigestWeekly})
+	if !prefs.InAppEnabled || prefs.EmailEnabled || prefs.DigestFrequency != models.NotificationDigestWeekly {
+		t.Errorf("Expected the defaults with the weekly digest, got %+v", prefs)
+	}
+}
+
+func TestNotificationDeliveryService_SendDueDigests(t *testing.T) {
+	setupNotificationServiceTestDB(t)
+	defer teardownNotificationServiceTestDB(t)
+
+	ctx := notificationServiceTestCtx
+	customers := notificationServiceTestDB.Collection("customers")
+	defer customers.Drop(ctx)
+
+	customerRepo := repository.NewCustomerRepository(customers)
+	deploymentRepo := repository.NewDeploymentRepository(notificationServiceTestDB.Collection("deployments"))
+	renderer, err := notify.NewRenderer()
+	if err != nil {
+		t.Fatalf("NewRenderer() error = %v", err)
+	}
+	templates := NewNotificationTemplateService(
+		repository.NewNotificationTemplateRepository(notificationServiceTestDB.Collection("notification_templates")),
+		customerRepo,
+		repository.NewVersionRepository(notificationServiceTestDB.Collection("versions")),
+		deploymentRepo,
+		nil,
+		renderer,
+	)
+	deliveryService := NewNotificationDeliveryService(notificationRepo, customerRepo, deploymentRepo, repository.NewNotificationRouteRepository(notificationServiceTestDB.Collection("notification_routes")), templates)
+	channel := &fakeChannel{}
+	deliveryService.RegisterChannel(channel)
+	notifications := NewNotificationService(notificationRepo, nil, nil, deliveryService, templates, nil)
+
+	if err := customerRepo.Create(ctx, &models.Customer{
+		CustomerID:    "customer-digest",
+		Name:          "Acme",
+		Email:         "ops@acme.example",
+		AccountStatus: models.CustomerStatusActive,
+		NotificationPreferences: models.NotificationPreferences{
+			EmailEnabled: true, InAppEnabled: true, UATNotifications: true, ProductionNotifications: true,
+			DigestFrequency: models.NotificationDigestHourly,
+		},
+	}); err != nil {
+		t.Fatalf("Failed to create customer: %v", err)
+	}
+
+	create := func(productID string, priority models.NotificationPriority) *models.Notification {
+		notification := &models.Notification{
+			Type:        models.NotificationTypeEOLWarning,
+			RecipientID: "customer-digest",
+			CustomerID:  "customer-digest",
+			ProductID:   productID,
+			Title:       "End of Life Approaching",
+			Message:     "A version of " + productID + " reaches end of life.",
+			Priority:    priority,
+		}
+		if err := notifications.CreateNotification(ctx, notification); err != nil {
+			t.Fatalf("CreateNotification() error = %v", err)
+		}
+		return notification
+	}
+	first := create("orders", models.NotificationPriorityNormal)
+	create("billing", models.NotificationPriorityHigh)
+	critical := create("orders", models.NotificationPriorityCritical)
+
+	// The critical notification is sent right away; the others wait
+	now := time.Now()
+	if sent, err := deliveryService.DeliverPending(ctx, now); err != nil || sent != 1 {
+		t.Fatalf("Expected the critical email sent, got %d, %v", sent, err)
+	}
+	if sent, err := deliveryService.SendDueDigests(ctx, now); err != nil || sent != 0 {
+		t.Fatalf("Expected no digest due yet, got %d, %v", sent, err)
+	}
+	stored, _ := notificationRepo.GetByID(ctx, critical.ID)
+	if stored.DigestDueAt != nil || stored.Deliveries[1].Status != models.NotificationDeliverySent {
+		t.Errorf("Expected the critical notification outside the digest, got %+v", stored)
+	}
+
+	// At the next hour both are sent in one digest
+	if sent, err := deliveryService.SendDueDigests(ctx, now.Add(time.Hour)); err != nil || sent != 1 {
+		t.Fatalf("Expected one digest sent, got %d, %v", sent, err)
+	}
+	if len(channel.sent) != 2 {
+		t.Fatalf("Expected the critical email and the digest, got %+v", channel.sent)
+	}
+	digest := channel.sent[1]
+	if digest.To != "ops@acme.example" || digest.Subject != "Your hourly Update Manager digest: 2 notifications" || digest.Priority != models.NotificationPriorityHigh {
+		t.Errorf("Unexpected digest %+v", digest)
+	}
+	stored, _ = notificationRepo.GetByID(ctx, first.ID)
+	if stored.DigestDueAt != nil || stored.Deliveries[1].Status != models.NotificationDeliverySent || stored.Deliveries[1].SentAt == nil {
+		t.Errorf("Expected the held email marked sent, got %+v", stored.Deliveries[1])
+	}
+
+	if sent, err := deliveryService.SendDueDigests(ctx, now.Add(2*time.Hour)); err != nil || sent != 0 {
+		t.Errorf("Expected nothing left to send, got %d, %v", sent, err)
+	}
+}
@@ -0,0 +1,198 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"updatemanager/internal/models"
+	"updatemanager/internal/repository"
+)
+
+// NotificationRouteService handles the routing rules that send notifications
+// to chat and incident channels
+type NotificationRouteService struct {
+	routeRepo    *repository.NotificationRouteRepository
+	customerRepo *repository.CustomerRepository
+	audit        *AuditLogger
+}
+
+// NewNotificationRouteService creates a new notification route service
+func NewNotificationRouteService(routeRepo *repository.NotificationRouteRepository, customerRepo *repository.CustomerRepository, audit *AuditLogger) *NotificationRouteService {
+	return &NotificationRouteService{
+		routeRepo:    routeRepo,
+		customerRepo: customerRepo,
+		audit:        audit,
+	}
+}
+
+// CreateRoute creates a notification route. Incident routes default to, and
+// only take, critical notifications; other routes default to every priority.
+func (s *NotificationRouteService) CreateRoute(ctx context.Context, req *models.CreateNotificationRouteRequest, userID, userEmail string) (*models.NotificationRoute, error) {
+	route := &models.NotificationRoute{
+		Name:        req.Name,
+		CustomerID:  req.CustomerID,
+		Channel:     req.Channel,
+		Target:      strings.TrimSpace(req.Target),
+		MinPriority: req.MinPriority,
+		Types:       req.Types,
+		IsActive:    true,
+		CreatedBy:   userID,
+	}
+	if route.MinPriority == "" {
+		route.MinPriority = models.NotificationPriorityLow
+		if route.Channel == models.NotificationChannelPagerDuty {
+			route.MinPriority = models.NotificationPriorityCritical
+		}
+	}
+	if err := validateNotificationRoute(route); err != nil {
+		return nil, err
+	}
+	if route.CustomerID != "" {
+		if _, err := s.customerRepo.GetByCustomerID(ctx, route.CustomerID); err != nil {
+			return nil, fmt.Errorf("customer not found: %w", err)
+		}
+	}
+
+	if err := s.routeRepo.Create(ctx, route); err != nil {
+		return nil, err
+	}
+
+	s.audit.LogChange(ctx, models.AuditActionCreate, "notification_route", route.ID.Hex(), userID, userEmail, nil, route, map[string]interface{}{
+		"customer_id":  route.CustomerID,
+		"channel":      route.Channel,
+		"min_priority": route.MinPriority,
+	})
+
+	return route, nil
+}
+
+// GetRoute retrieves a notification route
+func (s *NotificationRouteService) GetRoute(ctx context.Context, id primitive.ObjectID) (*models.NotificationRoute, error) {
+	return s.routeRepo.GetByID(ctx, id)
+}
+
+// ListRoutes lists notification routes
+func (s *NotificationRouteService) ListRoutes(ctx context.Context, filter bson.M, page, limit int) ([]*models.NotificationRoute, int64, error) {
+	opts := options.Find().
+		SetSort(bson.M{"created_at": -1}).
+		SetSkip(int64((page - 1) * limit)).
+		SetLimit(int64(limit))
+
+	routes, err := s.routeRepo.List(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	if routes == nil {
+		routes = []*models.NotificationRoute{}
+	}
+
+	total, err := s.routeRepo.Count(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return routes, total, nil
+}
+
+// UpdateRoute changes a route's name, target, priority, types or active flag.
+// Pending deliveries of the route are sent to its updated target.
+func (s *NotificationRouteService) UpdateRoute(ctx context.Context, id primitive.ObjectID, req *models.UpdateNotificationRouteRequest, userID, userEmail string) (*models.NotificationRoute, error) {
+	route, err := s.routeRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	before := auditState(route)
+	if req.Name != nil {
+		route.Name = *req.Name
+	}
+	if req.Target != nil {
+		route.Target = strings.TrimSpace(*req.Target)
+	}
+	if req.MinPriority != nil {
+		route.MinPriority = *req.MinPriority
+	}
+	if req.Types != nil {
+		route.Types = *req.Types
+	}
+	if req.IsActive != nil {
+		route.IsActive = *req.IsActive
+	}
+	if err := validateNotificationRoute(route); err != nil {
+		return nil, err
+	}
+
+	if err := s.routeRepo.Update(ctx, route); err != nil {
+		return nil, err
+	}
+
+	s.audit.LogChange(ctx, models.AuditActionUpdate, "notification_route", route.ID.Hex(), userID, userEmail, before, route, map[string]interface{}{
+		"min_priority":   route.MinPriority,
+		"is_active":      route.IsActive,
+		"target_changed": req.Target != nil,
+	})
+
+	return route, nil
+}
+
+// DeleteRoute deletes a notification route. Its pending deliveries are skipped.
+func (s *NotificationRouteService) DeleteRoute(ctx context.Context, id primitive.ObjectID, userID, userEmail string) error {
+	route, err := s.routeRepo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.routeRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	s.audit.LogChange(ctx, models.AuditActionDelete, "notification_route", id.Hex(), userID, userEmail, route, nil, map[string]interface{}{
+		"customer_id": route.CustomerID,
+		"channel":     route.Channel,
+	})
+
+	return nil
+}
+
+// validateNotificationRoute checks a route's name, channel, target and filters
+func validateNotificationRoute(route *models.NotificationRoute) error {
+	if strings.TrimSpace(route.Name) == "" || len(route.Name) > 200 {
+		return fmt.Errorf("invalid notification route: name must be 1 to 200 characters")
+	}
+
+	switch route.Channel {
+	case models.NotificationChannelSlack, models.NotificationChannelTeams:
+		target, err := url.Parse(route.Target)
+		if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+			return fmt.Errorf("invalid notification route: target must be an incoming webhook URL")
+		}
+	case models.NotificationChannelPagerDuty:
+		if route.Target == "" || len(route.Target) > 128 || strings.ContainsAny(route.Target, " \t\r\n") {
+			return fmt.Errorf("invalid notification route: target must be a routing key")
+		}
+		if route.MinPriority != models.NotificationPriorityCritical {
+			return fmt.Errorf("invalid notification route: pagerduty routes only take critical notifications")
+		}
+	default:
+		return fmt.Errorf("invalid notification route: unsupported channel %q", route.Channel)
+	}
+
+	switch route.MinPriority {
+	case models.NotificationPriorityLow, models.NotificationPriorityNormal, models.NotificationPriorityHigh, models.NotificationPriorityCritical:
+	default:
+		return fmt.Errorf("invalid notification route: unknown priority %q", route.MinPriority)
+	}
+
+	for _, notificationType := range route.Types {
+		if strings.TrimSpace(string(notificationType)) == "" {
+			return fmt.Errorf("invalid notification route: empty notification type")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"updatemanager/internal/models"
+)
+
+func TestValidateNotificationRoute(t *testing.T) {
+	valid := func() *models.NotificationRoute {
+		return &models.NotificationRoute{
+			Name:        "Ops channel",
+			Channel:     models.NotificationChannelSlack,
+			Target:      "https://chat.example.com/hooks/T000/B000",
+			MinPriority: models.NotificationPriorityLow,
+		}
+	}
+
+	tests := []struct {
+		name    string
+		mutate  func(*models.NotificationRoute)
+		wantErr string
+	}{
+		{"valid slack route", func(r *models.NotificationRoute) {}, ""},
+		{"valid teams route", func(r *models.NotificationRoute) { r.Channel = models.NotificationChannelTeams }, ""},
+		{"valid pagerduty route", func(r *models.NotificationRoute) {
+			r.Channel = models.NotificationChannelPagerDuty
+			r.Target = "R0UT1NGK3Y"
+			r.MinPriority = models.NotificationPriorityCritical
+		}, ""},
+		{"missing name", func(r *models.NotificationRoute) { r.Name = " " }, "name"},
+		{"email channel", func(r *models.NotificationRoute) { r.Channel = models.NotificationChannelEmail }, "unsupported channel"},
+		{"non-URL target", func(r *models.NotificationRoute) { r.Target = "ops-channel" }, "incoming webhook URL"},
+		{"non-HTTP target", func(r *models.NotificationRoute) { r.Target = "ftp://chat.example.com/hook" }, "incoming webhook URL"},
+		{"pagerduty below critical", func(r *models.NotificationRoute) {
+			r.Channel = models.NotificationChannelPagerDuty
+			r.Target = "R0UT1NGK3Y"
+			r.MinPriority = models.NotificationPriorityHigh
+		}, "only take critical"},
+		{"pagerduty key with spaces", func(r *models.NotificationRoute) {
+			r.Channel = models.NotificationChannelPagerDuty
+			r.Target = "not a key"
+			r.MinPriority = models.NotificationPriorityCritical
+		}, "routing key"},
+		{"unknown priority", func(r *models.NotificationRoute) { r.MinPriority = "urgent" }, "unknown priority"},
+		{"empty type", func(r *models.NotificationRoute) { r.Types = []models.NotificationType{""} }, "empty notification type"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			route := valid()
+			tt.mutate(route)
+			err := validateNotificationRoute(route)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Expected no error, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) || !strings.Contains(err.Error(), "invalid notification route") {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
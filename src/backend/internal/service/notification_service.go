@@ -3,6 +3,8 @@ package service
 import (
 	"context"
 	"fmt"
+	"log"
+	"strconv"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
@@ -13,55 +15,141 @@ import (
 	"updatemanager/internal/repository"
 )
 
+const (
+	// maxBulkNotifications bounds the notifications of one bulk request
+	maxBulkNotifications = 500
+
+	// DefaultNotificationReadRetention is how long read notifications are kept
+	DefaultNotificationReadRetention = 90 * 24 * time.Hour
+)
+
 // NotificationService handles notification business logic
 type NotificationService struct {
 	notificationRepo *repository.NotificationRepository
+	dedupRepo        *repository.NotificationDedupRepository
+	stream           *StreamPublisher
+	delivery         *NotificationDeliveryService
+	templates        *NotificationTemplateService
+	audit            *AuditLogger
 }
 
-// NewNotificationService creates a new notification service
-func NewNotificationService(notificationRepo *repository.NotificationRepository) *NotificationService {
+// NewNotificationService creates a new notification service. dedupRepo
+// holds the keys NotifyOnce deduplicates by. stream may be nil, in which case
+// new notifications are not streamed, and delivery may be nil, in which case
+// notifications are in-app only. templates renders the notifications the
+// service generates.
+func NewNotificationService(notificationRepo *repository.NotificationRepository, dedupRepo *repository.NotificationDedupRepository, stream *StreamPublisher, delivery *NotificationDeliveryService, templates *NotificationTemplateService, audit *AuditLogger) *NotificationService {
 	return &NotificationService{
 		notificationRepo: notificationRepo,
+		dedupRepo:        dedupRepo,
+		stream:           stream,
+		delivery:         delivery,
+		templates:        templates,
+		audit:            audit,
 	}
 }
 
-// CreateNotification creates a new notification
+// CreateNotification creates a new notification and queues its delivery over
+// the channels its recipient enabled
 func (s *NotificationService) CreateNotification(ctx context.Context, notification *models.Notification) error {
+	if s.delivery != nil {
+		s.delivery.Plan(ctx, notification)
+	}
 	if err := s.notificationRepo.Create(ctx, notification); err != nil {
 		return fmt.Errorf("failed to create notification: %w", err)
 	}
+	s.audit.LogChange(ctx, models.AuditActionCreate, "notification", notification.ID.Hex(), "", "", nil, notification, map[string]interface{}{
+		"type":         notification.Type,
+		"recipient_id": notification.RecipientID,
+		"priority":     notification.Priority,
+	})
+	if notificationVisibleInApp(notification) {
+		s.stream.NotificationCreated(ctx, notification)
+	}
 	return nil
 }
 
+// NotifyOnce creates an automatic notification unless its recipient already
+// got one of the same type about the same subject within window; a window of
+// zero or less deduplicates forever. It reports whether the notification was
+// created. Deduplication goes by keys kept apart from the notifications, so a
+// deleted or purged notification is not sent again, and of concurrent calls
+// for the same notification only one creates it.
+func (s *NotificationService) NotifyOnce(ctx context.Context, notification *models.Notification, window time.Duration) (bool, error) {
+	if notification.Subject == "" {
+		return false, fmt.Errorf("notification subject is required for deduplication")
+	}
+	if notification.CreatedAt.IsZero() {
+		notification.CreatedAt = time.Now()
+	}
+
+	key := &models.NotificationDedupKey{
+		RecipientID: notification.RecipientID,
+		Type:        notification.Type,
+		Subject:     notification.Subject,
+		CreatedAt:   notification.CreatedAt,
+	}
+	claimed, err := s.dedupRepo.Claim(ctx, key, window)
+	if err != nil {
+		return false, fmt.Errorf("failed to check notifications: %w", err)
+	}
+	if !claimed {
+		return false, nil
+	}
+	if err := s.CreateNotification(ctx, notification); err != nil {
+		// Let the next attempt notify
+		if releaseErr := s.dedupRepo.Release(ctx, key); releaseErr != nil {
+			log.Printf("Notifications: %v", releaseErr)
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// EnsureDedupIndex creates the unique index NotifyOnce relies on
+func (s *NotificationService) EnsureDedupIndex(ctx context.Context) error {
+	return s.dedupRepo.EnsureIndex(ctx)
+}
+
+// ListNotificationsQuery represents query parameters for listing a
+// recipient's notifications. Empty fields do not filter; From is inclusive
+// and To exclusive.
+type ListNotificationsQuery struct {
+	UnreadOnly bool
+	Archived   bool // List the archive instead of the inbox
+	Type       models.NotificationType
+	Priority   models.NotificationPriority
+	ProductID  string
+	CustomerID string
+	From       *time.Time
+	To         *time.Time
+	Page       int
+	Limit      int
+}
+
 // GetNotifications retrieves notifications for a recipient
 func (s *NotificationService) GetNotifications(ctx context.Context, recipientID string, page, limit int, unreadOnly bool) ([]*models.Notification, int64, error) {
+	return s.ListNotifications(ctx, recipientID, &ListNotificationsQuery{UnreadOnly: unreadOnly, Page: page, Limit: limit})
+}
+
+// ListNotifications retrieves a recipient's notifications matching a query,
+// newest first
+func (s *NotificationService) ListNotifications(ctx context.Context, recipientID string, query *ListNotificationsQuery) ([]*models.Notification, int64, error) {
 	opts := options.Find()
-	if limit > 0 {
-		opts.SetLimit(int64(limit))
-		opts.SetSkip(int64((page - 1) * limit))
+	if query.Limit > 0 {
+		opts.SetLimit(int64(query.Limit))
+		opts.SetSkip(int64((query.Page - 1) * query.Limit))
 	}
 	opts.SetSort(bson.M{"created_at": -1})
 
-	var notifications []*models.Notification
-	var err error
-	var total int64
+	filter := notificationListFilter(recipientID, query)
 
-	if unreadOnly {
-		notifications, err = s.notificationRepo.GetUnreadByRecipientID(ctx, recipientID, opts)
-		if err != nil {
-			return nil, 0, fmt.Errorf("failed to get unread notifications: %w", err)
-		}
-		filter := bson.M{"recipient_id": recipientID, "is_read": false}
-		total, err = s.notificationRepo.Count(ctx, filter)
-	} else {
-		notifications, err = s.notificationRepo.GetByRecipientID(ctx, recipientID, opts)
-		if err != nil {
-			return nil, 0, fmt.Errorf("failed to get notifications: %w", err)
-		}
-		filter := bson.M{"recipient_id": recipientID}
-		total, err = s.notificationRepo.Count(ctx, filter)
+	notifications, err := s.notificationRepo.List(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get notifications: %w", err)
 	}
 
+	total, err := s.notificationRepo.Count(ctx, filter)
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to count notifications: %w", err)
 	}
@@ -69,11 +157,83 @@ func (s *NotificationService) GetNotifications(ctx context.Context, recipientID
 	return notifications, total, nil
 }
 
+// notificationListFilter matches a recipient's notifications for a query.
+// Notifications the recipient turned off in-app are never listed.
+func notificationListFilter(recipientID string, query *ListNotificationsQuery) bson.M {
+	filter := inAppNotificationFilter(recipientID)
+	if query.Archived {
+		filter["is_archived"] = true
+	} else {
+		// Notifications stored before archiving existed have no is_archived
+		filter["is_archived"] = bson.M{"$ne": true}
+	}
+	if query.UnreadOnly {
+		filter["is_read"] = false
+	}
+	if query.Type != "" {
+		filter["type"] = query.Type
+	}
+	if query.Priority != "" {
+		filter["priority"] = query.Priority
+	}
+	if query.ProductID != "" {
+		filter["product_id"] = query.ProductID
+	}
+	if query.CustomerID != "" {
+		filter["customer_id"] = query.CustomerID
+	}
+	if query.From != nil || query.To != nil {
+		createdAt := bson.M{}
+		if query.From != nil {
+			createdAt["$gte"] = *query.From
+		}
+		if query.To != nil {
+			createdAt["$lt"] = *query.To
+		}
+		filter["created_at"] = createdAt
+	}
+	return filter
+}
+
+// GetNotification retrieves a notification by ID
+func (s *NotificationService) GetNotification(ctx context.Context, notificationID string) (*models.Notification, error) {
+	id, err := primitive.ObjectIDFromHex(notificationID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid notification ID: %w", err)
+	}
+	return s.notificationRepo.GetByID(ctx, id)
+}
+
 // MarkAsRead marks a notification as read
 func (s *NotificationService) MarkAsRead(ctx context.Context, notificationID string) error {
-	// This would need to convert string to ObjectID in real implementation
-	// For now, assuming the repository handles it
-	return fmt.Errorf("not implemented: need ObjectID conversion")
+	id, err := primitive.ObjectIDFromHex(notificationID)
+	if err != nil {
+		return fmt.Errorf("invalid notification ID: %w", err)
+	}
+	before, _ := s.notificationRepo.GetByID(ctx, id)
+	if err := s.notificationRepo.MarkAsRead(ctx, id); err != nil {
+		return err
+	}
+	s.logNotificationChange(ctx, id, before, map[string]interface{}{
+		"is_read": true,
+	})
+	return nil
+}
+
+// MarkAsUnread marks a notification as unread
+func (s *NotificationService) MarkAsUnread(ctx context.Context, notificationID string) error {
+	id, err := primitive.ObjectIDFromHex(notificationID)
+	if err != nil {
+		return fmt.Errorf("invalid notification ID: %w", err)
+	}
+	before, _ := s.notificationRepo.GetByID(ctx, id)
+	if err := s.notificationRepo.MarkAsUnread(ctx, id); err != nil {
+		return err
+	}
+	s.logNotificationChange(ctx, id, before, map[string]interface{}{
+		"is_read": false,
+	})
+	return nil
 }
 
 // MarkAllAsRead marks all notifications for a recipient as read
@@ -81,15 +241,121 @@ func (s *NotificationService) MarkAllAsRead(ctx context.Context, recipientID str
 	if err := s.notificationRepo.MarkAllAsRead(ctx, recipientID); err != nil {
 		return fmt.Errorf("failed to mark all notifications as read: %w", err)
 	}
+	s.audit.Log(ctx, models.AuditActionUpdate, "notification_inbox", recipientID, "", "", map[string]interface{}{
+		"action": "mark_all_read",
+	})
+	return nil
+}
+
+// SetArchived moves a notification to or out of its recipient's archive.
+// Archived notifications leave the inbox and the unread count.
+func (s *NotificationService) SetArchived(ctx context.Context, notificationID string, archived bool) error {
+	id, err := primitive.ObjectIDFromHex(notificationID)
+	if err != nil {
+		return fmt.Errorf("invalid notification ID: %w", err)
+	}
+	before, _ := s.notificationRepo.GetByID(ctx, id)
+	if err := s.notificationRepo.SetArchived(ctx, id, archived); err != nil {
+		return err
+	}
+	s.logNotificationChange(ctx, id, before, map[string]interface{}{
+		"is_archived": archived,
+	})
 	return nil
 }
 
+// logNotificationChange audits a change to a notification against its state
+// before the change
+func (s *NotificationService) logNotificationChange(ctx context.Context, id primitive.ObjectID, before *models.Notification, details map[string]interface{}) {
+	after, _ := s.notificationRepo.GetByID(ctx, id)
+	s.audit.LogChange(ctx, models.AuditActionUpdate, "notification", id.Hex(), "", "", before, after, details)
+}
+
+// DeleteNotification deletes a notification
+func (s *NotificationService) DeleteNotification(ctx context.Context, notificationID string) error {
+	id, err := primitive.ObjectIDFromHex(notificationID)
+	if err != nil {
+		return fmt.Errorf("invalid notification ID: %w", err)
+	}
+	before, _ := s.notificationRepo.GetByID(ctx, id)
+	if err := s.notificationRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+	s.audit.LogChange(ctx, models.AuditActionDelete, "notification", notificationID, "", "", before, nil, nil)
+	return nil
+}
+
+// BulkUpdate applies an action to some of a recipient's notifications and
+// returns how many it applied to. IDs of other recipients' notifications
+// are left alone.
+func (s *NotificationService) BulkUpdate(ctx context.Context, req *models.BulkNotificationRequest) (int64, error) {
+	if req.RecipientID == "" {
+		return 0, fmt.Errorf("invalid bulk request: recipient_id is required")
+	}
+	if len(req.NotificationIDs) == 0 {
+		return 0, fmt.Errorf("invalid bulk request: notification_ids is required")
+	}
+	if len(req.NotificationIDs) > maxBulkNotifications {
+		return 0, fmt.Errorf("invalid bulk request: at most %d notifications at once", maxBulkNotifications)
+	}
+
+	ids := make([]primitive.ObjectID, 0, len(req.NotificationIDs))
+	for _, notificationID := range req.NotificationIDs {
+		id, err := primitive.ObjectIDFromHex(notificationID)
+		if err != nil {
+			return 0, fmt.Errorf("invalid bulk request: invalid notification ID %q", notificationID)
+		}
+		ids = append(ids, id)
+	}
+
+	var affected int64
+	var err error
+	action := models.AuditActionUpdate
+	switch req.Action {
+	case models.NotificationBulkRead, models.NotificationBulkUnread:
+		affected, err = s.notificationRepo.SetReadMany(ctx, req.RecipientID, ids, req.Action == models.NotificationBulkRead)
+	case models.NotificationBulkArchive, models.NotificationBulkUnarchive:
+		affected, err = s.notificationRepo.SetArchivedMany(ctx, req.RecipientID, ids, req.Action == models.NotificationBulkArchive)
+	case models.NotificationBulkDelete:
+		action = models.AuditActionDelete
+		affected, err = s.notificationRepo.DeleteMany(ctx, req.RecipientID, ids)
+	default:
+		return 0, fmt.Errorf("invalid bulk request: unknown action %q", req.Action)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	s.audit.Log(ctx, action, "notification_inbox", req.RecipientID, "", "", map[string]interface{}{
+		"action":           req.Action,
+		"notification_ids": req.NotificationIDs,
+		"affected":         affected,
+	})
+	return affected, nil
+}
+
+// ParseNotificationReadRetention parses a read notification retention in
+// days; empty is the default and 0 keeps read notifications
+func ParseNotificationReadRetention(days string) (time.Duration, error) {
+	if days == "" {
+		return DefaultNotificationReadRetention, nil
+	}
+	n, err := strconv.Atoi(days)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid notification retention %q: want a number of days", days)
+	}
+	return time.Duration(n) * 24 * time.Hour, nil
+}
+
+// ApplyReadRetention makes read notifications expire retention after they
+// were read; zero keeps them
+func (s *NotificationService) ApplyReadRetention(ctx context.Context, retention time.Duration) error {
+	return s.notificationRepo.EnsureReadRetention(ctx, retention)
+}
+
 // GetUnreadCount returns the count of unread notifications
 func (s *NotificationService) GetUnreadCount(ctx context.Context, recipientID string) (int64, error) {
-	filter := bson.M{
-		"recipient_id": recipientID,
-		"is_read":      false,
-	}
+	filter := notificationListFilter(recipientID, &ListNotificationsQuery{UnreadOnly: true})
 	count, err := s.notificationRepo.Count(ctx, filter)
 	if err != nil {
 		return 0, fmt.Errorf("failed to count unread notifications: %w", err)
@@ -97,8 +363,10 @@ func (s *NotificationService) GetUnreadCount(ctx context.Context, recipientID st
 	return count, nil
 }
 
-// NotifyCustomersOnVersionRelease generates notifications for customers when a new version is released
-func (s *NotificationService) NotifyCustomersOnVersionRelease(ctx context.Context, productID string, versionID string, deploymentRepo *repository.DeploymentRepository, tenantRepo *repository.TenantRepository, customerRepo *repository.CustomerRepository) error {
+// NotifyCustomersOnVersionRelease generates notifications for customers when a new version is released.
+// Security releases notify with critical priority. Customers already notified of the version are skipped,
+// so a repeated release event notifies nobody twice.
+func (s *NotificationService) NotifyCustomersOnVersionRelease(ctx context.Context, productID string, versionID string, releaseType models.ReleaseType, deploymentRepo *repository.DeploymentRepository, tenantRepo *repository.TenantRepository, customerRepo *repository.CustomerRepository) error {
 	// Get all active deployments for the product
 	deployments, err := deploymentRepo.GetDeploymentsForNotification(ctx, productID)
 	if err != nil {
@@ -124,16 +392,14 @@ func (s *NotificationService) NotifyCustomersOnVersionRelease(ctx context.Contex
 			continue
 		}
 
-		// Build deployment list for notification message
-		deploymentList := ""
-		for i, dep := range deployments {
-			if i > 0 {
-				deploymentList += ", "
-			}
-			deploymentList += fmt.Sprintf("%s (%s)", dep.ProductID, dep.DeploymentType)
+		// Leave out deployments of types the customer muted
+		deployments = notifiableDeployments(customer.NotificationPreferences, deployments)
+		if len(deployments) == 0 {
+			continue
 		}
 
-		// Determine priority based on deployment types
+		// Determine priority based on release and deployment types
+		notificationType := models.NotificationTypeNewVersion
 		priority := models.NotificationPriorityNormal
 		for _, dep := range deployments {
 			if dep.DeploymentType == models.DeploymentTypeProduction {
@@ -141,29 +407,103 @@ func (s *NotificationService) NotifyCustomersOnVersionRelease(ctx context.Contex
 				break
 			}
 		}
+		if releaseType == models.ReleaseTypeSecurity {
+			notificationType = models.NotificationTypeSecurityRelease
+			priority = models.NotificationPriorityCritical
+		}
 
 		notification := &models.Notification{
-			Type:         models.NotificationTypeNewVersion,
-			RecipientID:  customer.CustomerID,
-			CustomerID:   customer.CustomerID,
-			ProductID:    productID,
-			VersionID:    versionID,
-			Title:        "New Version Available",
-			Message:      fmt.Sprintf("A new version is available for product %s. Affected deployments: %s", productID, deploymentList),
-			Priority:     priority,
-			IsRead:       false,
-			CreatedAt:    time.Now(),
+			Type:          notificationType,
+			RecipientID:   customer.CustomerID,
+			CustomerID:    customer.CustomerID,
+			ProductID:     productID,
+			VersionID:     versionID,
+			Subject:       "version:" + versionID,
+			DeploymentIDs: deploymentIDs(deployments),
+			Priority:      priority,
+			IsRead:        false,
+			CreatedAt:     time.Now(),
 		}
 
-		if err := s.CreateNotification(ctx, notification); err != nil {
-			// Log error but continue with other customers
+		// The title and message are the in-app template in the customer's locale
+		msg, err := s.templates.Render(ctx, models.NotificationChannelInApp, s.templates.TemplateData(ctx, notification, customer, deployments))
+		if err != nil {
+			log.Printf("Notifications: failed to render release notification for %s: %v", customer.CustomerID, err)
 			continue
 		}
+		notification.Title = msg.Subject
+		notification.Message = msg.Text
+
+		// A failed event is retried, and customers already notified are skipped then
+		if _, err := s.NotifyOnce(ctx, notification, 0); err != nil {
+			return fmt.Errorf("failed to notify %s of release: %w", customer.CustomerID, err)
+		}
 	}
 
 	return nil
 }
 
+// NotifyLicenseExpired notifies a customer that one of their licenses expired,
+// unless they were already notified of it
+func (s *NotificationService) NotifyLicenseExpired(ctx context.Context, event *LicenseExpired) error {
+	if event.CustomerID == "" {
+		return nil
+	}
+
+	message := fmt.Sprintf("License %s for product %s has expired. Updates are no longer covered until it is renewed.", event.LicenseID, event.ProductID)
+	_, err := s.NotifyOnce(ctx, &models.Notification{
+		Type:        models.NotificationTypeLicenseExpired,
+		RecipientID: event.CustomerID,
+		CustomerID:  event.CustomerID,
+		ProductID:   event.ProductID,
+		Subject:     "license:" + event.LicenseID,
+		Title:       "License Expired",
+		Message:     message,
+		Priority:    models.NotificationPriorityHigh,
+		CreatedAt:   time.Now(),
+	}, 0)
+	return err
+}
+
+// DomainEventHandler returns the outbox subscriber that notifies customers of
+// version releases and license expiries
+func (s *NotificationService) DomainEventHandler(deploymentRepo *repository.DeploymentRepository, tenantRepo *repository.TenantRepository, customerRepo *repository.CustomerRepository) DomainEventHandler {
+	return func(ctx context.Context, event *models.OutboxEvent) error {
+		switch event.Type {
+		case models.DomainEventVersionReleased:
+			var released VersionReleased
+			if err := decodeDomainEvent(event, &released); err != nil {
+				return err
+			}
+			return s.NotifyCustomersOnVersionRelease(ctx, released.ProductID, released.VersionID, released.ReleaseType, deploymentRepo, tenantRepo, customerRepo)
+		case models.DomainEventLicenseExpired:
+			var expired LicenseExpired
+			if err := decodeDomainEvent(event, &expired); err != nil {
+				return err
+			}
+			return s.NotifyLicenseExpired(ctx, &expired)
+		}
+		return nil
+	}
+}
+
+// notifiableDeployments returns the deployments whose type the customer wants
+// notifications for
+func notifiableDeployments(prefs models.NotificationPreferences, deployments []*models.Deployment) []*models.Deployment {
+	prefs = effectiveNotificationPreferences(prefs)
+
+	var notifiable []*models.Deployment
+	for _, deployment := range deployments {
+		switch {
+		case deployment.DeploymentType == models.DeploymentTypeProduction && !prefs.ProductionNotifications:
+		case deployment.DeploymentType == models.DeploymentTypeUAT && !prefs.UATNotifications:
+		default:
+			notifiable = append(notifiable, deployment)
+		}
+	}
+	return notifiable
+}
+
 // getNotificationPriority determines notification priority based on deployment type
 func (s *NotificationService) getNotificationPriority(deploymentType models.DeploymentType) models.NotificationPriority {
 	switch deploymentType {
@@ -5,6 +5,8 @@ import (
 	"testing"
 	"time"
 
+	"go.mongodb.org/mongo-driver/bson"
+
 	"updatemanager/internal/models"
 	"updatemanager/internal/repository"
 	"updatemanager/pkg/database"
@@ -34,12 +36,13 @@ func setupNotificationServiceTestDB(t *testing.T) {
 	notificationServiceTestDB = db
 	notificationServiceTestCtx = ctx
 	notificationRepo = repository.NewNotificationRepository(db.Collection("notifications"))
-	notificationService = NewNotificationService(notificationRepo)
+	notificationService = NewNotificationService(notificationRepo, repository.NewNotificationDedupRepository(db.Collection("notification_dedup_keys")), nil, nil, nil, nil)
 }
 
 func teardownNotificationServiceTestDB(t *testing.T) {
 	if notificationServiceTestDB != nil {
 		_ = notificationServiceTestDB.Collection("notifications").Drop(notificationServiceTestCtx)
+		_ = notificationServiceTestDB.Collection("notification_dedup_keys").Drop(notificationServiceTestCtx)
 		_ = notificationServiceTestDB.Disconnect(notificationServiceTestCtx)
 	}
 }
@@ -172,3 +175,137 @@ func TestNotificationService_MarkAllAsRead(t *testing.T) {
 
 	t.Logf("All notifications marked as read")
 }
+
+func TestNotificationListFilter(t *testing.T) {
+	from := time.Date(2024, time.May, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+
+	filter := notificationListFilter("customer-1", &ListNotificationsQuery{
+		UnreadOnly: true,
+		Type:       models.NotificationTypeEOLWarning,
+		Priority:   models.NotificationPriorityHigh,
+		ProductID:  "orders",
+		CustomerID: "customer-1",
+		From:       &from,
+		To:         &to,
+	})
+	if filter["recipient_id"] != "customer-1" || filter["deliveries"] == nil {
+		t.Errorf("Expected the recipient's in-app notifications, got %v", filter)
+	}
+	if archived, ok := filter["is_archived"].(bson.M); !ok || archived["$ne"] != true {
+		t.Errorf("Expected archived notifications left out, got %v", filter["is_archived"])
+	}
+	if filter["is_read"] != false || filter["type"] != models.NotificationTypeEOLWarning || filter["priority"] != models.NotificationPriorityHigh ||
+		filter["product_id"] != "orders" || filter["customer_id"] != "customer-1" {
+		t.Errorf("Unexpected filter %v", filter)
+	}
+	createdAt := filter["created_at"].(bson.M)
+	if createdAt["$gte"] != from || createdAt["$lt"] != to {
+		t.Errorf("Expected the date range, got %v", createdAt)
+	}
+
+	filter = notificationListFilter("customer-1", &ListNotificationsQuery{Archived: true})
+	if filter["is_archived"] != true {
+		t.Errorf("Expected the archive, got %v", filter["is_archived"])
+	}
+	for _, key := range []string{"is_read", "type", "priority", "product_id", "customer_id", "created_at"} {
+		if _, ok := filter[key]; ok {
+			t.Errorf("Expected no %s filter, got %v", key, filter[key])
+		}
+	}
+}
+
+func TestParseNotificationReadRetention(t *testing.T) {
+	tests := []struct {
+		days    string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"", DefaultNotificationReadRetention, false},
+		{"30", 30 * 24 * time.Hour, false},
+		{"0", 0, false},
+		{"-1", 0, true},
+		{"2w", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseNotificationReadRetention(tt.days)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseNotificationReadRetention(%q) = %v, %v; want %v, error %v", tt.days, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestNotificationService_Inbox(t *testing.T) {
+	setupNotificationServiceTestDB(t)
+	defer teardownNotificationServiceTestDB(t)
+
+	ctx := notificationServiceTestCtx
+	recipientID := "user-inbox"
+	notifications := []*models.Notification{
+		{Type: models.NotificationTypeNewVersion, RecipientID: recipientID, ProductID: "orders", Title: "Notification 1", Message: "Message 1", Priority: models.NotificationPriorityLow},
+		{Type: models.NotificationTypeEOLWarning, RecipientID: recipientID, ProductID: "billing", Title: "Notification 2", Message: "Message 2", Priority: models.NotificationPriorityHigh},
+		{Type: models.NotificationTypeEOLWarning, RecipientID: recipientID, ProductID: "orders", Title: "Notification 3", Message: "Message 3", Priority: models.NotificationPriorityHigh},
+	}
+	for _, n := range notifications {
+		if err := notificationService.CreateNotification(ctx, n); err != nil {
+			t.Fatalf("Failed to create notification: %v", err)
+		}
+	}
+
+	if err := notificationService.MarkAsRead(ctx, "not-an-id"); err == nil {
+		t.Error("Expected an error for an invalid ID")
+	}
+	if err := notificationService.MarkAsRead(ctx, notifications[0].ID.Hex()); err != nil {
+		t.Fatalf("MarkAsRead() error = %v", err)
+	}
+	if err := notificationService.MarkAsUnread(ctx, notifications[0].ID.Hex()); err != nil {
+		t.Fatalf("MarkAsUnread() error = %v", err)
+	}
+	stored, _ := notificationService.GetNotification(ctx, notifications[0].ID.Hex())
+	if stored.IsRead || stored.ReadAt != nil {
+		t.Errorf("Expected the notification unread without read_at, got %+v", stored)
+	}
+
+	// Archived notifications leave the inbox and the unread count
+	if err := notificationService.SetArchived(ctx, notifications[1].ID.Hex(), true); err != nil {
+		t.Fatalf("SetArchived() error = %v", err)
+	}
+	inbox, total, err := notificationService.ListNotifications(ctx, recipientID, &ListNotificationsQuery{Page: 1, Limit: 10})
+	if err != nil || total != 2 || len(inbox) != 2 {
+		t.Errorf("Expected 2 notifications in the inbox, got %d, %v", total, err)
+	}
+	if count, _ := notificationService.GetUnreadCount(ctx, recipientID); count != 2 {
+		t.Errorf("Expected 2 unread, got %d", count)
+	}
+	archive, _, _ := notificationService.ListNotifications(ctx, recipientID, &ListNotificationsQuery{Archived: true})
+	if len(archive) != 1 || archive[0].ID != notifications[1].ID || archive[0].ArchivedAt == nil {
+		t.Errorf("Expected the archived notification, got %+v", archive)
+	}
+
+	filtered, _, _ := notificationService.ListNotifications(ctx, recipientID, &ListNotificationsQuery{Type: models.NotificationTypeEOLWarning, ProductID: "orders"})
+	if len(filtered) != 1 || filtered[0].ID != notifications[2].ID {
+		t.Errorf("Expected the orders EOL warning, got %+v", filtered)
+	}
+
+	// Bulk actions only touch the recipient's own notifications
+	ids := []string{notifications[0].ID.Hex(), notifications[2].ID.Hex()}
+	if n, err := notificationService.BulkUpdate(ctx, &models.BulkNotificationRequest{RecipientID: "someone-else", NotificationIDs: ids, Action: models.NotificationBulkDelete}); err != nil || n != 0 {
+		t.Errorf("Expected nothing deleted for another recipient, got %d, %v", n, err)
+	}
+	if n, err := notificationService.BulkUpdate(ctx, &models.BulkNotificationRequest{RecipientID: recipientID, NotificationIDs: ids, Action: models.NotificationBulkRead}); err != nil || n != 2 {
+		t.Errorf("Expected 2 marked read, got %d, %v", n, err)
+	}
+	if _, err := notificationService.BulkUpdate(ctx, &models.BulkNotificationRequest{RecipientID: recipientID, NotificationIDs: ids, Action: "explode"}); err == nil {
+		t.Error("Expected an error for an unknown action")
+	}
+	if n, err := notificationService.BulkUpdate(ctx, &models.BulkNotificationRequest{RecipientID: recipientID, NotificationIDs: ids, Action: models.NotificationBulkDelete}); err != nil || n != 2 {
+		t.Errorf("Expected 2 deleted, got %d, %v", n, err)
+	}
+
+	if err := notificationService.DeleteNotification(ctx, notifications[1].ID.Hex()); err != nil {
+		t.Fatalf("DeleteNotification() error = %v", err)
+	}
+	if _, err := notificationService.GetNotification(ctx, notifications[1].ID.Hex()); err == nil {
+		t.Error("Expected the notification deleted")
+	}
+}
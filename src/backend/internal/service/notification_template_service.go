@@ -0,0 +1,421 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"updatemanager/internal/models"
+	"updatemanager/internal/notify"
+	"updatemanager/internal/repository"
+)
+
+// notificationTypePattern matches notification type names
+var notificationTypePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,63}$`)
+
+// NotificationTemplateService manages the stored notification templates and
+// renders notifications with them. A notification is rendered with the stored
+// template for its type, channel and the recipient's preferred locale,
+// falling back to less specific locales, then the default locale, then the
+// built-in templates.
+type NotificationTemplateService struct {
+	templateRepo   *repository.NotificationTemplateRepository
+	customerRepo   *repository.CustomerRepository
+	versionRepo    *repository.VersionRepository
+	deploymentRepo *repository.DeploymentRepository
+	audit          *AuditLogger
+	renderer       *notify.Renderer
+}
+
+// NewNotificationTemplateService creates a new notification template service
+func NewNotificationTemplateService(
+	templateRepo *repository.NotificationTemplateRepository,
+	customerRepo *repository.CustomerRepository,
+	versionRepo *repository.VersionRepository,
+	deploymentRepo *repository.DeploymentRepository,
+	audit *AuditLogger,
+	renderer *notify.Renderer,
+) *NotificationTemplateService {
+	return &NotificationTemplateService{
+		templateRepo:   templateRepo,
+		customerRepo:   customerRepo,
+		versionRepo:    versionRepo,
+		deploymentRepo: deploymentRepo,
+		audit:          audit,
+		renderer:       renderer,
+	}
+}
+
+// CreateTemplate stores a template for a notification type, channel and locale
+func (s *NotificationTemplateService) CreateTemplate(ctx context.Context, req *models.CreateNotificationTemplateRequest, userID, userEmail string) (*models.NotificationTemplate, error) {
+	locale, ok := notify.NormalizeLocale(req.Locale)
+	if !ok || locale == "" {
+		return nil, fmt.Errorf("invalid notification template: invalid locale %q", req.Locale)
+	}
+
+	template := &models.NotificationTemplate{
+		Type:      req.Type,
+		Channel:   req.Channel,
+		Locale:    locale,
+		Subject:   req.Subject,
+		Text:      req.Text,
+		HTML:      req.HTML,
+		CreatedBy: userID,
+	}
+	if err := validateNotificationTemplate(template); err != nil {
+		return nil, err
+	}
+
+	// The unique index also catches concurrent creates
+	existing, err := s.templateRepo.FindCandidates(ctx, template.Type, []models.NotificationChannel{template.Channel}, []string{template.Locale})
+	if err != nil {
+		return nil, err
+	}
+	if len(existing) > 0 {
+		return nil, fmt.Errorf("notification template for %s %s %s already exists", template.Type, template.Channel, template.Locale)
+	}
+
+	if err := s.templateRepo.Create(ctx, template); err != nil {
+		return nil, err
+	}
+
+	s.audit.LogChange(ctx, models.AuditActionCreate, "notification_template", template.ID.Hex(), userID, userEmail, nil, template, map[string]interface{}{
+		"type":    template.Type,
+		"channel": template.Channel,
+		"locale":  template.Locale,
+	})
+
+	return template, nil
+}
+
+// GetTemplate retrieves a notification template
+func (s *NotificationTemplateService) GetTemplate(ctx context.Context, id primitive.ObjectID) (*models.NotificationTemplate, error) {
+	return s.templateRepo.GetByID(ctx, id)
+}
+
+// ListTemplates lists notification templates
+func (s *NotificationTemplateService) ListTemplates(ctx context.Context, filter bson.M, page, limit int) ([]*models.NotificationTemplate, int64, error) {
+	opts := options.Find().
+		SetSort(bson.D{{Key: "type", Value: 1}, {Key: "channel", Value: 1}, {Key: "locale", Value: 1}}).
+		SetSkip(int64((page - 1) * limit)).
+		SetLimit(int64(limit))
+
+	templates, err := s.templateRepo.List(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	if templates == nil {
+		templates = []*models.NotificationTemplate{}
+	}
+
+	total, err := s.templateRepo.Count(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return templates, total, nil
+}
+
+// UpdateTemplate changes a template's sources. Its type, channel and locale
+// are fixed; create another template for another key.
+func (s *NotificationTemplateService) UpdateTemplate(ctx context.Context, id primitive.ObjectID, req *models.UpdateNotificationTemplateRequest, userID, userEmail string) (*models.NotificationTemplate, error) {
+	template, err := s.templateRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	before := auditState(template)
+	if req.Subject != nil {
+		template.Subject = *req.Subject
+	}
+	if req.Text != nil {
+		template.Text = *req.Text
+	}
+	if req.HTML != nil {
+		template.HTML = *req.HTML
+	}
+	template.UpdatedBy = userID
+	if err := validateNotificationTemplate(template); err != nil {
+		return nil, err
+	}
+
+	if err := s.templateRepo.Update(ctx, template); err != nil {
+		return nil, err
+	}
+
+	s.audit.LogChange(ctx, models.AuditActionUpdate, "notification_template", template.ID.Hex(), userID, userEmail, before, template, map[string]interface{}{
+		"type":    template.Type,
+		"channel": template.Channel,
+		"locale":  template.Locale,
+	})
+
+	return template, nil
+}
+
+// DeleteTemplate deletes a notification template; its key falls back to the
+// next locale or the built-in template
+func (s *NotificationTemplateService) DeleteTemplate(ctx context.Context, id primitive.ObjectID, userID, userEmail string) error {
+	template, err := s.templateRepo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.templateRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	s.audit.LogChange(ctx, models.AuditActionDelete, "notification_template", id.Hex(), userID, userEmail, template, nil, map[string]interface{}{
+		"type":    template.Type,
+		"channel": template.Channel,
+		"locale":  template.Locale,
+	})
+
+	return nil
+}
+
+// Preview renders a template without sending anything: the sources in the
+// request if given, otherwise the template notifications would be rendered
+// with. The data comes from the requested customer, version and deployments,
+// with sample values for the ones not given.
+func (s *NotificationTemplateService) Preview(ctx context.Context, req *models.PreviewNotificationTemplateRequest) (*models.NotificationTemplatePreview, error) {
+	if err := validateNotificationTemplateKey(req.Type, req.Channel); err != nil {
+		return nil, err
+	}
+	locale, ok := notify.NormalizeLocale(req.Locale)
+	if !ok {
+		return nil, fmt.Errorf("invalid notification template: invalid locale %q", req.Locale)
+	}
+
+	data := notify.SampleTemplateData(req.Type, locale)
+	if req.CustomerID != "" {
+		customer, err := s.customerRepo.GetByCustomerID(ctx, req.CustomerID)
+		if err != nil {
+			return nil, fmt.Errorf("customer not found: %w", err)
+		}
+		data.Customer = customer
+		data.CustomerName = customer.Name
+		data.Notification.RecipientID = customer.CustomerID
+		data.Notification.CustomerID = customer.CustomerID
+		if locale == "" {
+			locale, _ = notify.NormalizeLocale(customer.PreferredLocale)
+		}
+	}
+	if req.VersionID != "" {
+		version, err := s.version(ctx, req.VersionID)
+		if err != nil {
+			return nil, err
+		}
+		data.Version = version
+		data.ReleaseNotes = version.ReleaseNotes
+		data.Notification.ProductID = version.ProductID
+		data.Notification.VersionID = version.ID.Hex()
+	}
+	if len(req.DeploymentIDs) > 0 {
+		data.Deployments = s.deployments(ctx, req.DeploymentIDs)
+		data.Notification.DeploymentIDs = req.DeploymentIDs
+	}
+	data.Locale = locale
+
+	preview := &models.NotificationTemplatePreview{Source: "request", Locale: locale}
+	var tmpl *notify.Template
+	if req.Subject != nil || req.Text != nil || req.HTML != nil {
+		source := &models.NotificationTemplate{Type: req.Type, Channel: req.Channel, Locale: locale}
+		if req.Subject != nil {
+			source.Subject = *req.Subject
+		}
+		if req.Text != nil {
+			source.Text = *req.Text
+		}
+		if req.HTML != nil {
+			source.HTML = *req.HTML
+		}
+		parsed, err := parseNotificationTemplate(source)
+		if err != nil {
+			return nil, err
+		}
+		tmpl = parsed
+	} else {
+		resolved, resolvedLocale, source, err := s.resolve(ctx, req.Channel, req.Type, locale)
+		if err != nil {
+			return nil, err
+		}
+		tmpl = resolved
+		preview.Locale = resolvedLocale
+		preview.Source = source
+	}
+
+	msg, err := tmpl.Render(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid notification template: %w", err)
+	}
+	preview.Subject = msg.Subject
+	preview.Text = msg.Text
+	preview.HTML = msg.HTML
+	return preview, nil
+}
+
+// RenderNotification renders a stored notification for a channel in its
+// recipient's preferred locale
+func (s *NotificationTemplateService) RenderNotification(ctx context.Context, notification *models.Notification, channel models.NotificationChannel) (*notify.Message, error) {
+	return s.Render(ctx, channel, s.TemplateData(ctx, notification, nil, nil))
+}
+
+// Render renders template data for a channel in the data's locale
+func (s *NotificationTemplateService) Render(ctx context.Context, channel models.NotificationChannel, data *notify.TemplateData) (*notify.Message, error) {
+	tmpl, _, _, err := s.resolve(ctx, channel, data.Notification.Type, data.Locale)
+	if err != nil {
+		return nil, err
+	}
+	return tmpl.Render(data)
+}
+
+// TemplateData gathers the variables of a notification's templates. customer
+// and deployments are looked up when nil; the version is looked up from the
+// notification's version ID. Missing records leave their variables empty.
+func (s *NotificationTemplateService) TemplateData(ctx context.Context, notification *models.Notification, customer *models.Customer, deployments []*models.Deployment) *notify.TemplateData {
+	data := &notify.TemplateData{Notification: notification, CustomerName: notification.RecipientID}
+
+	if customer == nil {
+		customer, _ = s.customerRepo.GetByCustomerID(ctx, notification.RecipientID)
+	}
+	if customer != nil {
+		data.Customer = customer
+		data.CustomerName = customer.Name
+		data.Locale, _ = notify.NormalizeLocale(customer.PreferredLocale)
+	}
+
+	if notification.VersionID != "" {
+		if version, err := s.version(ctx, notification.VersionID); err == nil {
+			data.Version = version
+			data.ReleaseNotes = version.ReleaseNotes
+		}
+	}
+
+	if deployments == nil {
+		deploymentIDs := notification.DeploymentIDs
+		if len(deploymentIDs) == 0 && notification.DeploymentID != "" {
+			deploymentIDs = []string{notification.DeploymentID}
+		}
+		deployments = s.deployments(ctx, deploymentIDs)
+	}
+	data.Deployments = deployments
+
+	return data
+}
+
+// resolve returns the template to render a notification type with on a
+// channel, with the locale it is in and whether it is stored or built in.
+// Chat and incident channels without stored templates of their own use the
+// stored in-app ones.
+func (s *NotificationTemplateService) resolve(ctx context.Context, channel models.NotificationChannel, notificationType models.NotificationType, locale string) (*notify.Template, string, string, error) {
+	channels := []models.NotificationChannel{channel}
+	if channel != models.NotificationChannelEmail && channel != models.NotificationChannelInApp {
+		channels = append(channels, models.NotificationChannelInApp)
+	}
+	locales := notify.LocaleFallbacks(locale)
+
+	candidates, err := s.templateRepo.FindCandidates(ctx, notificationType, channels, locales)
+	if err != nil {
+		return nil, "", "", err
+	}
+	if best := bestNotificationTemplate(candidates, channels, locales); best != nil {
+		tmpl, err := parseNotificationTemplate(best)
+		if err == nil {
+			return tmpl, best.Locale, "stored", nil
+		}
+		// Stored templates are checked on save, so this is a template saved
+		// before a change in the variables; the built-in one still works
+	}
+
+	return s.renderer.Builtin(channel, notificationType), notify.DefaultLocale, "builtin", nil
+}
+
+// bestNotificationTemplate picks the candidate for the earliest channel and,
+// within it, the earliest locale
+func bestNotificationTemplate(candidates []*models.NotificationTemplate, channels []models.NotificationChannel, locales []string) *models.NotificationTemplate {
+	for _, channel := range channels {
+		for _, locale := range locales {
+			for _, candidate := range candidates {
+				if candidate.Channel == channel && candidate.Locale == locale {
+					return candidate
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// version retrieves a version by its hex ID
+func (s *NotificationTemplateService) version(ctx context.Context, versionID string) (*models.Version, error) {
+	id, err := primitive.ObjectIDFromHex(versionID)
+	if err != nil {
+		return nil, fmt.Errorf("version not found: invalid version ID %q", versionID)
+	}
+	version, err := s.versionRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("version not found: %w", err)
+	}
+	return version, nil
+}
+
+// deployments retrieves deployments by deployment ID, leaving out missing ones
+func (s *NotificationTemplateService) deployments(ctx context.Context, deploymentIDs []string) []*models.Deployment {
+	var deployments []*models.Deployment
+	for _, deploymentID := range deploymentIDs {
+		if deployment, err := s.deploymentRepo.GetByDeploymentID(ctx, deploymentID); err == nil {
+			deployments = append(deployments, deployment)
+		}
+	}
+	return deployments
+}
+
+// validateNotificationTemplate checks a template's key and that its sources
+// parse and render the sample data
+func validateNotificationTemplate(template *models.NotificationTemplate) error {
+	if err := validateNotificationTemplateKey(template.Type, template.Channel); err != nil {
+		return err
+	}
+	if template.Subject == "" || template.Text == "" {
+		return fmt.Errorf("invalid notification template: subject and text are required")
+	}
+
+	tmpl, err := parseNotificationTemplate(template)
+	if err != nil {
+		return err
+	}
+	// Catches references to variables that do not exist
+	if _, err := tmpl.Render(notify.SampleTemplateData(template.Type, template.Locale)); err != nil {
+		return fmt.Errorf("invalid notification template: %w", err)
+	}
+	return nil
+}
+
+// validateNotificationTemplateKey checks a template's notification type and channel
+func validateNotificationTemplateKey(notificationType models.NotificationType, channel models.NotificationChannel) error {
+	if !notificationTypePattern.MatchString(string(notificationType)) {
+		return fmt.Errorf("invalid notification template: invalid notification type %q", notificationType)
+	}
+	switch channel {
+	case models.NotificationChannelInApp, models.NotificationChannelEmail, models.NotificationChannelSlack,
+		models.NotificationChannelTeams, models.NotificationChannelPagerDuty:
+	default:
+		return fmt.Errorf("invalid notification template: unsupported channel %q", channel)
+	}
+	return nil
+}
+
+// parseNotificationTemplate parses a stored template's sources
+func parseNotificationTemplate(template *models.NotificationTemplate) (*notify.Template, error) {
+	if template.HTML != "" && template.Channel != models.NotificationChannelEmail {
+		return nil, fmt.Errorf("invalid notification template: only email templates have HTML")
+	}
+	tmpl, err := notify.ParseTemplate(template.Subject, template.Text, template.HTML)
+	if err != nil {
+		return nil, fmt.Errorf("invalid notification template: %w", err)
+	}
+	return tmpl, nil
+}
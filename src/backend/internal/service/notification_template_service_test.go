@@ -0,0 +1,170 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"updatemanager/internal/models"
+	"updatemanager/internal/notify"
+	"updatemanager/internal/repository"
+)
+
+func TestBestNotificationTemplate(t *testing.T) {
+	slackDE := &models.NotificationTemplate{Channel: models.NotificationChannelSlack, Locale: "de"}
+	inAppDECH := &models.NotificationTemplate{Channel: models.NotificationChannelInApp, Locale: "de-ch"}
+	slackEN := &models.NotificationTemplate{Channel: models.NotificationChannelSlack, Locale: "en"}
+	channels := []models.NotificationChannel{models.NotificationChannelSlack, models.NotificationChannelInApp}
+	locales := notify.LocaleFallbacks("de-ch")
+
+	tests := []struct {
+		name       string
+		candidates []*models.NotificationTemplate
+		want       *models.NotificationTemplate
+	}{
+		{"own channel before in-app", []*models.NotificationTemplate{inAppDECH, slackDE}, slackDE},
+		{"language before default locale", []*models.NotificationTemplate{slackEN, slackDE}, slackDE},
+		{"default locale", []*models.NotificationTemplate{slackEN}, slackEN},
+		{"in-app fallback", []*models.NotificationTemplate{inAppDECH}, inAppDECH},
+		{"none", nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := bestNotificationTemplate(tt.candidates, channels, locales); got != tt.want {
+				t.Errorf("bestNotificationTemplate() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateNotificationTemplate(t *testing.T) {
+	valid := func() *models.NotificationTemplate {
+		return &models.NotificationTemplate{
+			Type:    models.NotificationTypeNewVersion,
+			Channel: models.NotificationChannelEmail,
+			Locale:  "de",
+			Subject: "Neue Version {{with .Version}}{{.VersionNumber}}{{end}}",
+			Text:    "Hallo {{.CustomerName}},{{range .Deployments}} {{.DeploymentID}}{{end}}",
+			HTML:    "<p>Hallo {{.CustomerName}}</p>",
+		}
+	}
+
+	tests := []struct {
+		name    string
+		mutate  func(*models.NotificationTemplate)
+		wantErr string
+	}{
+		{"valid email template", func(tmpl *models.NotificationTemplate) {}, ""},
+		{"valid in-app template", func(tmpl *models.NotificationTemplate) {
+			tmpl.Channel = models.NotificationChannelInApp
+			tmpl.HTML = ""
+		}, ""},
+		{"HTML outside email", func(tmpl *models.NotificationTemplate) { tmpl.Channel = models.NotificationChannelSlack }, "only email templates have HTML"},
+		{"unknown channel", func(tmpl *models.NotificationTemplate) { tmpl.Channel = "sms" }, "unsupported channel"},
+		{"bad type", func(tmpl *models.NotificationTemplate) { tmpl.Type = "New Version" }, "invalid notification type"},
+		{"missing text", func(tmpl *models.NotificationTemplate) { tmpl.Text = "" }, "subject and text are required"},
+		{"parse error", func(tmpl *models.NotificationTemplate) { tmpl.Subject = "{{.Version" }, "subject:"},
+		{"unknown variable", func(tmpl *models.NotificationTemplate) { tmpl.Text = "{{.Release}}" }, "Release"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl := valid()
+			tt.mutate(tmpl)
+			err := validateNotificationTemplate(tmpl)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Expected no error, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) || !strings.Contains(err.Error(), "invalid notification template") {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestNotificationTemplateService_RenderNotification(t *testing.T) {
+	setupNotificationServiceTestDB(t)
+	defer teardownNotificationServiceTestDB(t)
+
+	ctx := notificationServiceTestCtx
+	templatesCollection := notificationServiceTestDB.Collection("notification_templates")
+	customers := notificationServiceTestDB.Collection("customers")
+	defer templatesCollection.Drop(ctx)
+	defer customers.Drop(ctx)
+
+	renderer, err := notify.NewRenderer()
+	if err != nil {
+		t.Fatalf("NewRenderer() error = %v", err)
+	}
+	customerRepo := repository.NewCustomerRepository(customers)
+	templates := NewNotificationTemplateService(
+		repository.NewNotificationTemplateRepository(templatesCollection),
+		customerRepo,
+		repository.NewVersionRepository(notificationServiceTestDB.Collection("versions")),
+		repository.NewDeploymentRepository(notificationServiceTestDB.Collection("deployments")),
+		nil,
+		renderer,
+	)
+
+	if err := customerRepo.Create(ctx, &models.Customer{
+		CustomerID:      "customer-swiss",
+		Name:            "Bergbahn AG",
+		Email:           "it@bergbahn.example",
+		AccountStatus:   models.CustomerStatusActive,
+		PreferredLocale: "de-ch",
+	}); err != nil {
+		t.Fatalf("Failed to create customer: %v", err)
+	}
+
+	notification := &models.Notification{
+		Type:        models.NotificationTypeLicenseExpired,
+		RecipientID: "customer-swiss",
+		Title:       "License Expired",
+		Message:     "License lic-1 has expired.",
+	}
+
+	// Without stored templates the built-in English one is used
+	msg, err := templates.RenderNotification(ctx, notification, models.NotificationChannelEmail)
+	if err != nil {
+		t.Fatalf("RenderNotification() error = %v", err)
+	}
+	if !strings.Contains(msg.Text, "Hello Bergbahn AG") {
+		t.Errorf("Expected the built-in template, got:\n%s", msg.Text)
+	}
+
+	// A German template serves the Swiss German customer
+	if _, err := templates.CreateTemplate(ctx, &models.CreateNotificationTemplateRequest{
+		Type:    models.NotificationTypeLicenseExpired,
+		Channel: models.NotificationChannelEmail,
+		Locale:  "DE",
+		Subject: "Lizenz abgelaufen",
+		Text:    "Guten Tag {{.CustomerName}}, {{.Notification.Message}}",
+	}, "admin", "admin@example.com"); err != nil {
+		t.Fatalf("CreateTemplate() error = %v", err)
+	}
+
+	msg, err = templates.RenderNotification(ctx, notification, models.NotificationChannelEmail)
+	if err != nil {
+		t.Fatalf("RenderNotification() error = %v", err)
+	}
+	if msg.Subject != "Lizenz abgelaufen" || msg.Text != "Guten Tag Bergbahn AG, License lic-1 has expired." {
+		t.Errorf("Expected the German template, got %+v", msg)
+	}
+	if msg.HTML != "" {
+		t.Errorf("Expected a text-only email, got HTML %q", msg.HTML)
+	}
+
+	// The same key cannot be stored twice
+	if _, err := templates.CreateTemplate(ctx, &models.CreateNotificationTemplateRequest{
+		Type:    models.NotificationTypeLicenseExpired,
+		Channel: models.NotificationChannelEmail,
+		Locale:  "de",
+		Subject: "Lizenz",
+		Text:    "Text",
+	}, "admin", "admin@example.com"); err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Errorf("Expected a duplicate error, got %v", err)
+	}
+}
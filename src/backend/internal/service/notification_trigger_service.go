@@ -0,0 +1,445 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"updatemanager/internal/models"
+	"updatemanager/internal/repository"
+	"updatemanager/internal/utils"
+)
+
+const (
+	// notificationWarningDays is how far ahead end of life and subscription
+	// and license expiry are warned about
+	notificationWarningDays = 30
+
+	// seatOverallocationReminder is how often a customer is reminded of a
+	// license that still has more seats allocated than it covers
+	seatOverallocationReminder = 7 * 24 * time.Hour
+)
+
+// notificationExpiryThresholds are the days before an expiry a warning is
+// sent at: each threshold crossed notifies once
+var notificationExpiryThresholds = []int{1, 7, notificationWarningDays}
+
+// NotificationTriggerService generates the automatic notifications of the
+// product and license lifecycle that no single event triggers: end of life
+// warnings, available updates, expiring subscriptions and licenses, and seat
+// over-allocation. Every notification is deduplicated on its recipient, type
+// and subject, so sweeping again only notifies what changed.
+type NotificationTriggerService struct {
+	notificationService *NotificationService
+	productRepo         *repository.ProductRepository
+	versionRepo         *repository.VersionRepository
+	deploymentRepo      *repository.DeploymentRepository
+	tenantRepo          *repository.TenantRepository
+	customerRepo        *repository.CustomerRepository
+	subscriptionRepo    *repository.SubscriptionRepository
+	licenseRepo         *repository.LicenseRepository
+	allocationRepo      *repository.LicenseAllocationRepository
+}
+
+// NewNotificationTriggerService creates a new notification trigger service
+func NewNotificationTriggerService(
+	notificationService *NotificationService,
+	productRepo *repository.ProductRepository,
+	versionRepo *repository.VersionRepository,
+	deploymentRepo *repository.DeploymentRepository,
+	tenantRepo *repository.TenantRepository,
+	customerRepo *repository.CustomerRepository,
+	subscriptionRepo *repository.SubscriptionRepository,
+	licenseRepo *repository.LicenseRepository,
+	allocationRepo *repository.LicenseAllocationRepository,
+) *NotificationTriggerService {
+	return &NotificationTriggerService{
+		notificationService: notificationService,
+		productRepo:         productRepo,
+		versionRepo:         versionRepo,
+		deploymentRepo:      deploymentRepo,
+		tenantRepo:          tenantRepo,
+		customerRepo:        customerRepo,
+		subscriptionRepo:    subscriptionRepo,
+		licenseRepo:         licenseRepo,
+		allocationRepo:      allocationRepo,
+	}
+}
+
+// Sweep runs every trigger at now and returns how many notifications were
+// created. A failing trigger does not stop the others; the first error is
+// returned.
+func (s *NotificationTriggerService) Sweep(ctx context.Context, now time.Time) (int, error) {
+	triggers := []struct {
+		name string
+		run  func(context.Context, time.Time) (int, error)
+	}{
+		{"product updates", s.NotifyProductUpdates},
+		{"expiring subscriptions", s.NotifyExpiringSubscriptions},
+		{"expiring licenses", s.NotifyExpiringLicenses},
+		{"seat over-allocation", s.NotifySeatOverallocation},
+	}
+
+	created := 0
+	var firstErr error
+	for _, trigger := range triggers {
+		n, err := trigger.run(ctx, now)
+		created += n
+		if err != nil {
+			log.Printf("Notification triggers: %s: %v", trigger.name, err)
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return created, firstErr
+}
+
+// NotifyProductUpdates warns customers of versions they run that reach end
+// of life soon, and notifies them once per deployment of the newest update
+// available to it
+func (s *NotificationTriggerService) NotifyProductUpdates(ctx context.Context, now time.Time) (int, error) {
+	products, err := s.productRepo.List(ctx, bson.M{"is_active": true}, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list products: %w", err)
+	}
+
+	customers := make(map[primitive.ObjectID]*models.Customer)
+	created := 0
+	for _, product := range products {
+		versions, err := s.versionRepo.GetByProductID(ctx, product.ProductID, nil)
+		if err != nil {
+			return created, fmt.Errorf("failed to get versions: %w", err)
+		}
+		deployments, err := s.deploymentRepo.GetDeploymentsForNotification(ctx, product.ProductID)
+		if err != nil {
+			return created, fmt.Errorf("failed to get deployments for notification: %w", err)
+		}
+
+		// Group the deployments each customer wants notifications for
+		customerDeployments := make(map[string][]*models.Deployment)
+		byCustomerID := make(map[string]*models.Customer)
+		for _, deployment := range deployments {
+			customer := s.deploymentCustomer(ctx, customers, deployment)
+			if customer == nil || len(notifiableDeployments(customer.NotificationPreferences, []*models.Deployment{deployment})) == 0 {
+				continue
+			}
+			byCustomerID[customer.CustomerID] = customer
+			customerDeployments[customer.CustomerID] = append(customerDeployments[customer.CustomerID], deployment)
+		}
+
+		for customerID, deployments := range customerDeployments {
+			n, err := s.notifyCustomerProductUpdates(ctx, byCustomerID[customerID], product.ProductID, versions, deployments, now)
+			created += n
+			if err != nil {
+				return created, err
+			}
+		}
+	}
+	return created, nil
+}
+
+// notifyCustomerProductUpdates notifies one customer of the end of life and
+// update notifications due for their deployments of a product
+func (s *NotificationTriggerService) notifyCustomerProductUpdates(ctx context.Context, customer *models.Customer, productID string, versions []*models.Version, deployments []*models.Deployment, now time.Time) (int, error) {
+	created := 0
+	for _, version := range versions {
+		if !versionReachesEOL(version, now) {
+			continue
+		}
+		var affected []*models.Deployment
+		for _, deployment := range deployments {
+			if deployment.InstalledVersion == version.VersionNumber {
+				affected = append(affected, deployment)
+			}
+		}
+		if len(affected) == 0 {
+			continue
+		}
+
+		ok, err := s.notificationService.NotifyOnce(ctx, &models.Notification{
+			Type:          models.NotificationTypeEOLWarning,
+			RecipientID:   customer.CustomerID,
+			CustomerID:    customer.CustomerID,
+			ProductID:     productID,
+			VersionID:     version.ID.Hex(),
+			DeploymentIDs: deploymentIDs(affected),
+			Subject:       "version:" + version.ID.Hex() + ":eol",
+			Title:         "End of Life Approaching",
+			Message: fmt.Sprintf("Version %s of product %s reaches end of life on %s. Deployments still running it: %s.",
+				version.VersionNumber, productID, version.EOLDate.Format("2006-01-02"), strings.Join(deploymentIDs(affected), ", ")),
+			Priority:  deploymentsPriority(affected),
+			CreatedAt: now,
+		}, 0)
+		if err != nil {
+			return created, err
+		}
+		if ok {
+			created++
+		}
+	}
+
+	for _, deployment := range deployments {
+		update := latestAvailableUpdate(versions, deployment.InstalledVersion, now)
+		if update == nil {
+			continue
+		}
+
+		priority := deploymentsPriority([]*models.Deployment{deployment})
+		if update.ReleaseType == models.ReleaseTypeSecurity {
+			priority = models.NotificationPriorityHigh
+		}
+
+		ok, err := s.notificationService.NotifyOnce(ctx, &models.Notification{
+			Type:         models.NotificationTypeUpdateAvailable,
+			RecipientID:  customer.CustomerID,
+			CustomerID:   customer.CustomerID,
+			ProductID:    productID,
+			VersionID:    update.ID.Hex(),
+			DeploymentID: deployment.DeploymentID,
+			Subject:      "deployment:" + deployment.DeploymentID + ":version:" + update.ID.Hex(),
+			Title:        "Update Available",
+			Message: fmt.Sprintf("Version %s of product %s is available for deployment %s, which runs %s.",
+				update.VersionNumber, productID, deployment.DeploymentID, deployment.InstalledVersion),
+			Priority:  priority,
+			CreatedAt: now,
+		}, 0)
+		if err != nil {
+			return created, err
+		}
+		if ok {
+			created++
+		}
+	}
+	return created, nil
+}
+
+// NotifyExpiringSubscriptions warns customers of subscriptions that expire
+// within notificationWarningDays, once per threshold crossed
+func (s *NotificationTriggerService) NotifyExpiringSubscriptions(ctx context.Context, now time.Time) (int, error) {
+	subscriptions, err := s.subscriptionRepo.GetExpiringSubscriptions(ctx, notificationWarningDays)
+	if err != nil {
+		return 0, err
+	}
+
+	created := 0
+	for _, subscription := range subscriptions {
+		customer, err := s.customerRepo.GetByID(ctx, subscription.CustomerID)
+		if err != nil {
+			continue
+		}
+
+		days := daysUntil(now, *subscription.EndDate)
+		ok, err := s.notificationService.NotifyOnce(ctx, &models.Notification{
+			Type:        models.NotificationTypeSubscriptionExpiring,
+			RecipientID: customer.CustomerID,
+			CustomerID:  customer.CustomerID,
+			Subject:     fmt.Sprintf("subscription:%s:%dd", subscription.SubscriptionID, expiryThreshold(days)),
+			Title:       "Subscription Expiring",
+			Message: fmt.Sprintf("Subscription %s expires on %s, in %d day(s). Renew it to keep receiving updates.",
+				subscription.SubscriptionID, subscription.EndDate.Format("2006-01-02"), days),
+			Priority:  expiryPriority(days),
+			CreatedAt: now,
+		}, 0)
+		if err != nil {
+			return created, err
+		}
+		if ok {
+			created++
+		}
+	}
+	return created, nil
+}
+
+// NotifyExpiringLicenses warns customers of time-based licenses that expire
+// within notificationWarningDays, once per threshold crossed
+func (s *NotificationTriggerService) NotifyExpiringLicenses(ctx context.Context, now time.Time) (int, error) {
+	licenses, err := s.licenseRepo.GetExpiringLicenses(ctx, notificationWarningDays)
+	if err != nil {
+		return 0, err
+	}
+
+	customers := make(map[primitive.ObjectID]*models.Customer)
+	created := 0
+	for _, license := range licenses {
+		customer := s.licenseCustomer(ctx, customers, license)
+		if customer == nil {
+			continue
+		}
+
+		days := daysUntil(now, *license.EndDate)
+		ok, err := s.notificationService.NotifyOnce(ctx, &models.Notification{
+			Type:        models.NotificationTypeLicenseExpiring,
+			RecipientID: customer.CustomerID,
+			CustomerID:  customer.CustomerID,
+			ProductID:   license.ProductID,
+			Subject:     fmt.Sprintf("license:%s:%dd", license.LicenseID, expiryThreshold(days)),
+			Title:       "License Expiring",
+			Message: fmt.Sprintf("License %s for product %s expires on %s, in %d day(s). Updates are no longer covered once it expires.",
+				license.LicenseID, license.ProductID, license.EndDate.Format("2006-01-02"), days),
+			Priority:  expiryPriority(days),
+			CreatedAt: now,
+		}, 0)
+		if err != nil {
+			return created, err
+		}
+		if ok {
+			created++
+		}
+	}
+	return created, nil
+}
+
+// NotifySeatOverallocation notifies customers of active licenses with more
+// seats allocated than they cover, again every seatOverallocationReminder
+// until it is resolved
+func (s *NotificationTriggerService) NotifySeatOverallocation(ctx context.Context, now time.Time) (int, error) {
+	allocated, err := s.allocationRepo.GetAllocatedSeatsByLicense(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	customers := make(map[primitive.ObjectID]*models.Customer)
+	created := 0
+	for licenseID, seats := range allocated {
+		license, err := s.licenseRepo.GetByID(ctx, licenseID)
+		if err != nil || license.Status != models.LicenseStatusActive || seats <= license.NumberOfSeats {
+			continue
+		}
+		customer := s.licenseCustomer(ctx, customers, license)
+		if customer == nil {
+			continue
+		}
+
+		ok, err := s.notificationService.NotifyOnce(ctx, &models.Notification{
+			Type:        models.NotificationTypeSeatOverallocation,
+			RecipientID: customer.CustomerID,
+			CustomerID:  customer.CustomerID,
+			ProductID:   license.ProductID,
+			Subject:     "license:" + license.LicenseID + ":seats",
+			Title:       "License Seats Over-allocated",
+			Message: fmt.Sprintf("License %s for product %s has %d seats allocated but covers %d. Release %d seat(s) or extend the license.",
+				license.LicenseID, license.ProductID, seats, license.NumberOfSeats, seats-license.NumberOfSeats),
+			Priority:  models.NotificationPriorityHigh,
+			CreatedAt: now,
+		}, seatOverallocationReminder)
+		if err != nil {
+			return created, err
+		}
+		if ok {
+			created++
+		}
+	}
+	return created, nil
+}
+
+// deploymentCustomer returns the customer owning a deployment's tenant, or
+// nil if either is gone. Customers are cached per tenant in cache.
+func (s *NotificationTriggerService) deploymentCustomer(ctx context.Context, cache map[primitive.ObjectID]*models.Customer, deployment *models.Deployment) *models.Customer {
+	if customer, ok := cache[deployment.TenantID]; ok {
+		return customer
+	}
+	var customer *models.Customer
+	if tenant, err := s.tenantRepo.GetByID(ctx, deployment.TenantID); err == nil {
+		customer, _ = s.customerRepo.GetByID(ctx, tenant.CustomerID)
+	}
+	cache[deployment.TenantID] = customer
+	return customer
+}
+
+// licenseCustomer returns the customer owning a license's subscription, or
+// nil if either is gone. Customers are cached per subscription in cache.
+func (s *NotificationTriggerService) licenseCustomer(ctx context.Context, cache map[primitive.ObjectID]*models.Customer, license *models.License) *models.Customer {
+	if customer, ok := cache[license.SubscriptionID]; ok {
+		return customer
+	}
+	var customer *models.Customer
+	if subscription, err := s.subscriptionRepo.GetByID(ctx, license.SubscriptionID); err == nil {
+		customer, _ = s.customerRepo.GetByID(ctx, subscription.CustomerID)
+	}
+	cache[license.SubscriptionID] = customer
+	return customer
+}
+
+// versionReachesEOL reports whether a version still in use reaches its end
+// of life within notificationWarningDays of now
+func versionReachesEOL(version *models.Version, now time.Time) bool {
+	if version.EOLDate == nil || version.EOLDate.Before(now) {
+		return false
+	}
+	if version.State != models.VersionStateReleased && version.State != models.VersionStateDeprecated {
+		return false
+	}
+	return !version.EOLDate.After(now.AddDate(0, 0, notificationWarningDays))
+}
+
+// latestAvailableUpdate returns the newest released version past neither its
+// end of life nor installed, or nil if the deployment is up to date
+func latestAvailableUpdate(versions []*models.Version, installed string, now time.Time) *models.Version {
+	var latest *models.Version
+	for _, version := range versions {
+		if version.State != models.VersionStateReleased || (version.EOLDate != nil && version.EOLDate.Before(now)) {
+			continue
+		}
+		if !utils.IsVersionNewer(version.VersionNumber, installed) {
+			continue
+		}
+		if latest == nil || utils.IsVersionNewer(version.VersionNumber, latest.VersionNumber) {
+			latest = version
+		}
+	}
+	return latest
+}
+
+// deploymentsPriority is high if any of the deployments is in production
+func deploymentsPriority(deployments []*models.Deployment) models.NotificationPriority {
+	for _, deployment := range deployments {
+		if deployment.DeploymentType == models.DeploymentTypeProduction {
+			return models.NotificationPriorityHigh
+		}
+	}
+	return models.NotificationPriorityNormal
+}
+
+// deploymentIDs returns the IDs of deployments
+func deploymentIDs(deployments []*models.Deployment) []string {
+	ids := make([]string, 0, len(deployments))
+	for _, deployment := range deployments {
+		ids = append(ids, deployment.DeploymentID)
+	}
+	return ids
+}
+
+// daysUntil returns the whole days from now until t, rounded up
+func daysUntil(now, t time.Time) int {
+	days := int(math.Ceil(t.Sub(now).Hours() / 24))
+	if days < 0 {
+		return 0
+	}
+	return days
+}
+
+// expiryThreshold returns the smallest warning threshold an expiry days away
+// has crossed
+func expiryThreshold(days int) int {
+	for _, threshold := range notificationExpiryThresholds {
+		if days <= threshold {
+			return threshold
+		}
+	}
+	return notificationExpiryThresholds[len(notificationExpiryThresholds)-1]
+}
+
+// expiryPriority is high for expiries a week or less away
+func expiryPriority(days int) models.NotificationPriority {
+	if days <= 7 {
+		return models.NotificationPriorityHigh
+	}
+	return models.NotificationPriorityNormal
+}
@@ -0,0 +1,187 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"updatemanager/internal/models"
+	"updatemanager/internal/repository"
+)
+
+func TestExpiryThresholdAndPriority(t *testing.T) {
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name          string
+		expiresAt     time.Time
+		wantDays      int
+		wantThreshold int
+		wantPriority  models.NotificationPriority
+	}{
+		{"a month away", now.AddDate(0, 0, 30), 30, 30, models.NotificationPriorityNormal},
+		{"two weeks away", now.AddDate(0, 0, 14), 14, 30, models.NotificationPriorityNormal},
+		{"a week away", now.AddDate(0, 0, 7), 7, 7, models.NotificationPriorityHigh},
+		{"part of a day is a day", now.Add(30 * time.Hour), 2, 7, models.NotificationPriorityHigh},
+		{"tomorrow", now.Add(time.Hour), 1, 1, models.NotificationPriorityHigh},
+		{"past", now.Add(-time.Hour), 0, 1, models.NotificationPriorityHigh},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			days := daysUntil(now, tt.expiresAt)
+			if days != tt.wantDays {
+				t.Fatalf("daysUntil() = %d, want %d", days, tt.wantDays)
+			}
+			if got := expiryThreshold(days); got != tt.wantThreshold {
+				t.Errorf("expiryThreshold(%d) = %d, want %d", days, got, tt.wantThreshold)
+			}
+			if got := expiryPriority(days); got != tt.wantPriority {
+				t.Errorf("expiryPriority(%d) = %s, want %s", days, got, tt.wantPriority)
+			}
+		})
+	}
+}
+
+func TestLatestAvailableUpdate(t *testing.T) {
+	now := time.Now()
+	past := now.AddDate(0, 0, -1)
+	version := func(number string, state models.VersionState, eol *time.Time) *models.Version {
+		return &models.Version{ID: primitive.NewObjectID(), VersionNumber: number, State: state, EOLDate: eol}
+	}
+	v110 := version("1.1.0", models.VersionStateReleased, nil)
+	v120 := version("1.2.0", models.VersionStateReleased, nil)
+	versions := []*models.Version{
+		v110,
+		v120,
+		version("1.3.0", models.VersionStateApproved, nil),
+		version("1.4.0", models.VersionStateReleased, &past),
+	}
+
+	if got := latestAvailableUpdate(versions, "1.0.0", now); got != v120 {
+		t.Errorf("latestAvailableUpdate(1.0.0) = %+v, want 1.2.0", got)
+	}
+	if got := latestAvailableUpdate(versions, "1.2.0", now); got != nil {
+		t.Errorf("Expected no update for an up to date deployment, got %s", got.VersionNumber)
+	}
+}
+
+func TestVersionReachesEOL(t *testing.T) {
+	now := time.Now()
+	at := func(days int) *time.Time {
+		eol := now.AddDate(0, 0, days)
+		return &eol
+	}
+
+	tests := []struct {
+		name    string
+		version *models.Version
+		want    bool
+	}{
+		{"within the warning window", &models.Version{State: models.VersionStateReleased, EOLDate: at(10)}, true},
+		{"deprecated", &models.Version{State: models.VersionStateDeprecated, EOLDate: at(30)}, true},
+		{"too far ahead", &models.Version{State: models.VersionStateReleased, EOLDate: at(60)}, false},
+		{"already past", &models.Version{State: models.VersionStateReleased, EOLDate: at(-1)}, false},
+		{"no end of life", &models.Version{State: models.VersionStateReleased}, false},
+		{"not released", &models.Version{State: models.VersionStateDraft, EOLDate: at(10)}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := versionReachesEOL(tt.version, now); got != tt.want {
+				t.Errorf("versionReachesEOL() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNotificationTriggerService_Sweep(t *testing.T) {
+	setupNotificationServiceTestDB(t)
+	defer teardownNotificationServiceTestDB(t)
+
+	ctx := notificationServiceTestCtx
+	db := notificationServiceTestDB
+	for _, name := range []string{"customers", "subscriptions", "licenses", "license_allocations", "products"} {
+		defer db.Collection(name).Drop(ctx)
+	}
+
+	customerRepo := repository.NewCustomerRepository(db.Collection("customers"))
+	subscriptionRepo := repository.NewSubscriptionRepository(db.Collection("subscriptions"))
+	licenseRepo := repository.NewLicenseRepository(db.Collection("licenses"))
+	allocationRepo := repository.NewLicenseAllocationRepository(db.Collection("license_allocations"))
+	triggers := NewNotificationTriggerService(
+		notificationService,
+		repository.NewProductRepository(db.Collection("products")),
+		repository.NewVersionRepository(db.Collection("versions")),
+		repository.NewDeploymentRepository(db.Collection("deployments")),
+		repository.NewTenantRepository(db.Collection("tenants")),
+		customerRepo,
+		subscriptionRepo,
+		licenseRepo,
+		allocationRepo,
+	)
+
+	customer := &models.Customer{CustomerID: "customer-triggers", Name: "Triggers Inc", Email: "ops@triggers.example", AccountStatus: models.CustomerStatusActive}
+	if err := customerRepo.Create(ctx, customer); err != nil {
+		t.Fatalf("Failed to create customer: %v", err)
+	}
+	now := time.Now()
+	endsSoon := now.Add(5 * 24 * time.Hour)
+	subscription := &models.Subscription{SubscriptionID: "sub-triggers", CustomerID: customer.ID, StartDate: now.AddDate(-1, 0, 0), EndDate: &endsSoon, Status: models.SubscriptionStatusActive, CreatedBy: "admin"}
+	if err := subscriptionRepo.Create(ctx, subscription); err != nil {
+		t.Fatalf("Failed to create subscription: %v", err)
+	}
+	license := &models.License{LicenseID: "lic-triggers", SubscriptionID: subscription.ID, ProductID: "billing", LicenseType: models.LicenseTypeTimeBased, NumberOfSeats: 5, StartDate: now.AddDate(-1, 0, 0), EndDate: &endsSoon, Status: models.LicenseStatusActive, AssignedBy: "admin", AssignmentDate: now}
+	if err := licenseRepo.Create(ctx, license); err != nil {
+		t.Fatalf("Failed to create license: %v", err)
+	}
+	if err := allocationRepo.Create(ctx, &models.LicenseAllocation{AllocationID: "alloc-triggers", LicenseID: license.ID, NumberOfSeatsAllocated: 8, AllocationDate: now, Status: models.AllocationStatusActive, AllocatedBy: "admin"}); err != nil {
+		t.Fatalf("Failed to create allocation: %v", err)
+	}
+
+	created, err := triggers.Sweep(ctx, now)
+	if err != nil {
+		t.Fatalf("Sweep() error = %v", err)
+	}
+	if created != 3 {
+		t.Errorf("Expected subscription, license and seat notifications, got %d", created)
+	}
+
+	notifications, err := notificationRepo.List(ctx, bson.M{"recipient_id": "customer-triggers"}, nil)
+	if err != nil {
+		t.Fatalf("Failed to list notifications: %v", err)
+	}
+	subjects := make(map[models.NotificationType]string)
+	for _, notification := range notifications {
+		subjects[notification.Type] = notification.Subject
+		if notification.Priority != models.NotificationPriorityHigh {
+			t.Errorf("Expected %s to be high priority, got %s", notification.Type, notification.Priority)
+		}
+	}
+	if subjects[models.NotificationTypeSubscriptionExpiring] != "subscription:sub-triggers:7d" ||
+		subjects[models.NotificationTypeLicenseExpiring] != "license:lic-triggers:7d" ||
+		subjects[models.NotificationTypeSeatOverallocation] != "license:lic-triggers:seats" {
+		t.Errorf("Unexpected notification subjects %v", subjects)
+	}
+
+	// Sweeping again notifies nothing twice, even once the customer deleted
+	// the notifications
+	if _, err := db.Collection("notifications").DeleteMany(ctx, bson.M{"recipient_id": "customer-triggers"}); err != nil {
+		t.Fatalf("Failed to delete notifications: %v", err)
+	}
+	created, err = triggers.Sweep(ctx, now.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("Sweep() error = %v", err)
+	}
+	if created != 0 {
+		t.Errorf("Expected no duplicate notifications, got %d", created)
+	}
+
+	// An over-allocation left unresolved is notified again after the reminder window
+	created, _ = triggers.NotifySeatOverallocation(ctx, now.Add(seatOverallocationReminder+time.Hour))
+	if created != 1 {
+		t.Errorf("Expected a seat over-allocation reminder, got %d", created)
+	}
+}
@@ -0,0 +1,34 @@
+package service
+
+import (
+	"context"
+	"time"
+)
+
+// defaultNotificationTriggerSweepInterval is how often lifecycle
+// notifications are generated
+const defaultNotificationTriggerSweepInterval = time.Hour
+
+// NotificationTriggerSweeper periodically generates the lifecycle
+// notifications no event triggers
+type NotificationTriggerSweeper struct {
+	triggerService *NotificationTriggerService
+	interval       time.Duration
+}
+
+// NewNotificationTriggerSweeper creates a new notification trigger sweeper
+func NewNotificationTriggerSweeper(triggerService *NotificationTriggerService, interval time.Duration) *NotificationTriggerSweeper {
+	if interval <= 0 {
+		interval = defaultNotificationTriggerSweepInterval
+	}
+	return &NotificationTriggerSweeper{
+		triggerService: triggerService,
+		interval:       interval,
+	}
+}
+
+// Run sweeps the notification triggers on every tick until the context is
+// cancelled
+func (s *NotificationTriggerSweeper) Run(ctx context.Context) {
+	runPeriodically(ctx, "Notification trigger sweeper", s.interval, s.triggerService.Sweep)
+}
@@ -0,0 +1,170 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"updatemanager/internal/models"
+	"updatemanager/internal/repository"
+)
+
+const (
+	// defaultOutboxDispatchInterval is how often the outbox is polled for due events
+	defaultOutboxDispatchInterval = 2 * time.Second
+
+	// outboxLease is how long a claimed event is reserved for its dispatcher;
+	// events of a dispatcher that died are retried after it runs out
+	outboxLease = time.Minute
+
+	// maxOutboxAttempts is how often an event is tried before it is marked failed
+	maxOutboxAttempts = 10
+
+	// outboxRetryBaseDelay and outboxRetryMaxDelay bound the backoff between attempts
+	outboxRetryBaseDelay = 5 * time.Second
+	outboxRetryMaxDelay  = time.Hour
+)
+
+// DomainEventHandler handles one outbox event. Delivery is at least once, so
+// handlers must tolerate seeing the same event again.
+type DomainEventHandler func(ctx context.Context, event *models.OutboxEvent) error
+
+// outboxSubscriber is a named handler for a set of event types
+type outboxSubscriber struct {
+	name    string
+	types   map[models.DomainEventType]bool
+	handler DomainEventHandler
+}
+
+// OutboxDispatcher delivers outbox events to subscribers. An event is marked
+// dispatched once every subscriber of its type handled it; subscribers that
+// failed are retried with backoff while the others are not called again.
+type OutboxDispatcher struct {
+	outboxRepo *repository.OutboxRepository
+	interval   time.Duration
+
+	mu          sync.RWMutex
+	subscribers []outboxSubscriber
+}
+
+// NewOutboxDispatcher creates a new outbox dispatcher
+func NewOutboxDispatcher(outboxRepo *repository.OutboxRepository, interval time.Duration) *OutboxDispatcher {
+	if interval <= 0 {
+		interval = defaultOutboxDispatchInterval
+	}
+	return &OutboxDispatcher{
+		outboxRepo: outboxRepo,
+		interval:   interval,
+	}
+}
+
+// Subscribe registers a handler for the given event types. The name records
+// which subscribers handled an event and must stay stable across restarts.
+func (d *OutboxDispatcher) Subscribe(name string, handler DomainEventHandler, types ...models.DomainEventType) {
+	subscriber := outboxSubscriber{
+		name:    name,
+		types:   make(map[models.DomainEventType]bool),
+		handler: handler,
+	}
+	for _, eventType := range types {
+		subscriber.types[eventType] = true
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.subscribers = append(d.subscribers, subscriber)
+}
+
+// Run dispatches due events on every tick until the context is cancelled
+func (d *OutboxDispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if _, err := d.DispatchPending(ctx, now); err != nil {
+				log.Printf("Outbox dispatcher: %v", err)
+			}
+		}
+	}
+}
+
+// DispatchPending claims and delivers events due at now until none is left.
+// It returns how many events were dispatched to all their subscribers.
+func (d *OutboxDispatcher) DispatchPending(ctx context.Context, now time.Time) (int, error) {
+	dispatched := 0
+	for ctx.Err() == nil {
+		event, err := d.outboxRepo.ClaimDue(ctx, now, outboxLease)
+		if err != nil {
+			return dispatched, err
+		}
+		if event == nil {
+			break
+		}
+
+		done, err := d.dispatch(ctx, event, now)
+		if err != nil {
+			return dispatched, err
+		}
+		if done {
+			dispatched++
+		}
+	}
+	return dispatched, nil
+}
+
+// dispatch calls the subscribers of an event that have not handled it yet and
+// records the outcome. It reports whether the event is fully dispatched.
+func (d *OutboxDispatcher) dispatch(ctx context.Context, event *models.OutboxEvent, now time.Time) (bool, error) {
+	delivered := make(map[string]bool, len(event.DeliveredTo))
+	for _, name := range event.DeliveredTo {
+		delivered[name] = true
+	}
+
+	d.mu.RLock()
+	subscribers := d.subscribers
+	d.mu.RUnlock()
+
+	var failures []string
+	for _, subscriber := range subscribers {
+		if !subscriber.types[event.Type] || delivered[subscriber.name] {
+			continue
+		}
+		if err := subscriber.handler(ctx, event); err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", subscriber.name, err))
+			continue
+		}
+		if err := d.outboxRepo.MarkDelivered(ctx, event.ID, subscriber.name); err != nil {
+			return false, err
+		}
+	}
+
+	if len(failures) == 0 {
+		return true, d.outboxRepo.MarkDispatched(ctx, event.ID, now)
+	}
+
+	lastError := strings.Join(failures, "; ")
+	if event.Attempts >= maxOutboxAttempts {
+		log.Printf("Outbox dispatcher: giving up on %s event %s after %d attempts: %s", event.Type, event.ID.Hex(), event.Attempts, lastError)
+		return false, d.outboxRepo.MarkFailed(ctx, event.ID, lastError)
+	}
+	return false, d.outboxRepo.MarkRetry(ctx, event.ID, now.Add(outboxRetryDelay(event.Attempts)), lastError)
+}
+
+// outboxRetryDelay doubles the delay after each failed attempt, up to the maximum
+func outboxRetryDelay(attempts int) time.Duration {
+	delay := outboxRetryBaseDelay
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= outboxRetryMaxDelay {
+			return outboxRetryMaxDelay
+		}
+	}
+	return delay
+}
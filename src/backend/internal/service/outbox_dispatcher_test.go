@@ -0,0 +1,125 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+
+	"updatemanager/internal/models"
+	"updatemanager/internal/repository"
+)
+
+func TestOutboxRetryDelay(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, 5 * time.Second},
+		{2, 10 * time.Second},
+		{4, 40 * time.Second},
+		{maxOutboxAttempts, 5 * time.Second << (maxOutboxAttempts - 1)},
+		{20, time.Hour},
+	}
+
+	for _, tt := range tests {
+		if got := outboxRetryDelay(tt.attempts); got != tt.want {
+			t.Errorf("outboxRetryDelay(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+func TestDecodeDomainEvent(t *testing.T) {
+	payload, err := bson.Marshal(&VersionReleased{VersionID: "v-1", ProductID: "product-1", VersionNumber: "2.0.0"})
+	if err != nil {
+		t.Fatalf("Failed to encode event: %v", err)
+	}
+	event := &models.OutboxEvent{Type: models.DomainEventVersionReleased, Payload: payload}
+
+	var released VersionReleased
+	if err := decodeDomainEvent(event, &released); err != nil || released.ProductID != "product-1" || released.VersionNumber != "2.0.0" {
+		t.Errorf("decodeDomainEvent() = %+v, %v", released, err)
+	}
+
+	var failed RolloutFailed
+	if err := decodeDomainEvent(event, &failed); err == nil {
+		t.Error("Expected decoding into another event type to fail")
+	}
+}
+
+func TestOutboxDispatcher_DispatchPending(t *testing.T) {
+	setupCampaignServiceTestDB(t)
+	defer teardownCampaignServiceTestDB(t)
+
+	ctx := campaignServiceTestCtx
+	outboxRepo := repository.NewOutboxRepository(campaignServiceTestDB.Collection("domain_event_outbox"))
+	outbox := NewDomainEventOutbox(outboxRepo, repository.NewTransactor(campaignServiceTestDB.Client))
+	dispatcher := NewOutboxDispatcher(outboxRepo, time.Second)
+
+	// A failed change stores no event
+	changeErr := errors.New("version not found")
+	err := outbox.Write(ctx, func(ctx context.Context) ([]DomainEvent, error) {
+		return nil, changeErr
+	})
+	if !errors.Is(err, changeErr) {
+		t.Fatalf("Expected the change error, got %v", err)
+	}
+
+	err = outbox.Write(ctx, func(ctx context.Context) ([]DomainEvent, error) {
+		return []DomainEvent{&VersionReleased{VersionID: "v-1", ProductID: "product-1"}}, nil
+	})
+	if err != nil {
+		t.Fatalf("Failed to write event: %v", err)
+	}
+	if count, _ := outboxRepo.Count(ctx, bson.M{}); count != 1 {
+		t.Fatalf("Expected one outbox event, found %d", count)
+	}
+
+	var stableCalls, flakyCalls, otherCalls int
+	dispatcher.Subscribe("stable", func(ctx context.Context, event *models.OutboxEvent) error {
+		stableCalls++
+		return nil
+	}, models.DomainEventVersionReleased)
+	dispatcher.Subscribe("flaky", func(ctx context.Context, event *models.OutboxEvent) error {
+		flakyCalls++
+		if flakyCalls == 1 {
+			return errors.New("temporarily unavailable")
+		}
+		return nil
+	}, models.DomainEventVersionReleased)
+	dispatcher.Subscribe("other", func(ctx context.Context, event *models.OutboxEvent) error {
+		otherCalls++
+		return nil
+	}, models.DomainEventRolloutFailed)
+
+	// The flaky subscriber fails, so the event is retried after a backoff
+	now := time.Now()
+	dispatched, err := dispatcher.DispatchPending(ctx, now)
+	if err != nil || dispatched != 0 {
+		t.Fatalf("Expected no event dispatched, got %d, %v", dispatched, err)
+	}
+	events, _ := outboxRepo.List(ctx, bson.M{}, nil)
+	if events[0].Status != models.OutboxStatusPending || events[0].LastError == "" || !events[0].NextAttemptAt.After(now) {
+		t.Errorf("Expected event rescheduled with its error, got %+v", events[0])
+	}
+
+	// Nothing is due before the backoff ends
+	if dispatched, _ := dispatcher.DispatchPending(ctx, now); dispatched != 0 || flakyCalls != 1 {
+		t.Errorf("Expected no retry before the backoff, got %d dispatched, %d calls", dispatched, flakyCalls)
+	}
+
+	// The retry only calls the subscriber that failed
+	dispatched, err = dispatcher.DispatchPending(ctx, now.Add(time.Minute))
+	if err != nil || dispatched != 1 {
+		t.Fatalf("Expected the event dispatched, got %d, %v", dispatched, err)
+	}
+	if stableCalls != 1 || flakyCalls != 2 || otherCalls != 0 {
+		t.Errorf("Unexpected calls: stable %d, flaky %d, other %d", stableCalls, flakyCalls, otherCalls)
+	}
+	events, _ = outboxRepo.List(ctx, bson.M{}, nil)
+	if events[0].Status != models.OutboxStatusDispatched || events[0].DispatchedAt == nil || events[0].Attempts != 2 {
+		t.Errorf("Expected event dispatched after 2 attempts, got %+v", events[0])
+	}
+}
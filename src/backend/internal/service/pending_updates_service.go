@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"fmt"
+	"log"
 	"sync"
 	"time"
 
@@ -27,6 +28,7 @@ type PendingUpdatesService struct {
 	versionRepo    *repository.VersionRepository
 	customerRepo   *repository.CustomerRepository
 	tenantRepo     *repository.TenantRepository
+	windows        *MaintenanceWindowService
 	
 	// Simple in-memory cache for pending updates
 	cache      map[string]*cacheEntry
@@ -40,19 +42,21 @@ func NewPendingUpdatesService(
 	versionRepo *repository.VersionRepository,
 	customerRepo *repository.CustomerRepository,
 	tenantRepo *repository.TenantRepository,
+	windows *MaintenanceWindowService, // May be nil; responses then carry no maintenance window
 ) *PendingUpdatesService {
 	return &PendingUpdatesService{
 		deploymentRepo: deploymentRepo,
 		versionRepo:    versionRepo,
 		customerRepo:   customerRepo,
 		tenantRepo:     tenantRepo,
+		windows:        windows,
 		cache:          make(map[string]*cacheEntry),
 		cacheTTL:       5 * time.Minute, // Cache for 5 minutes by default
 	}
 }
 
 // InvalidateCacheForProduct invalidates cache for all deployments of a product
-// This should be called when a new version is released for the product
+// It is called through HandleDomainEvent when a new version is released for the product
 func (s *PendingUpdatesService) InvalidateCacheForProduct(ctx context.Context, productID string) {
 	s.cacheMutex.Lock()
 	defer s.cacheMutex.Unlock()
@@ -83,6 +87,28 @@ func (s *PendingUpdatesService) InvalidateCacheForDeployment(deploymentID string
 	}
 }
 
+// HandleDomainEvent is the outbox subscriber that drops cached pending updates
+// when a version is released or a deployment changes
+func (s *PendingUpdatesService) HandleDomainEvent(ctx context.Context, event *models.OutboxEvent) error {
+	switch event.Type {
+	case models.DomainEventVersionReleased:
+		var released VersionReleased
+		if err := decodeDomainEvent(event, &released); err != nil {
+			return err
+		}
+		s.InvalidateCacheForProduct(ctx, released.ProductID)
+	case models.DomainEventDeploymentUpdated:
+		var updated DeploymentUpdated
+		if err := decodeDomainEvent(event, &updated); err != nil {
+			return err
+		}
+		// Deployments are cached under whichever ID they were requested by
+		s.InvalidateCacheForDeployment(updated.ID)
+		s.InvalidateCacheForDeployment(updated.DeploymentID)
+	}
+	return nil
+}
+
 // getCached retrieves a value from cache if it exists and is not expired
 func (s *PendingUpdatesService) getCached(key string) (interface{}, bool) {
 	s.cacheMutex.RLock()
@@ -244,6 +270,9 @@ func (s *PendingUpdatesService) GetPendingUpdatesForDeployment(ctx context.Conte
 		return nil, err
 	}
 
+	// Maintenance window gating rollouts; cached along with the rest of the response
+	window := s.maintenanceWindow(ctx, deployment)
+
 	// Get tenant and customer for context
 	tenant, err := s.tenantRepo.GetByID(ctx, deployment.TenantID)
 	if err == nil {
@@ -261,6 +290,7 @@ func (s *PendingUpdatesService) GetPendingUpdatesForDeployment(ctx context.Conte
 				CustomerID:       customer.CustomerID,
 				CustomerName:     customer.Name,
 				DeploymentType:   deployment.DeploymentType,
+				MaintenanceWindow: window,
 			}
 
 			// Set latest version
@@ -287,6 +317,7 @@ func (s *PendingUpdatesService) GetPendingUpdatesForDeployment(ctx context.Conte
 		UpdateCount:      len(availableUpdates),
 		AvailableUpdates: availableUpdates,
 		DeploymentType:   deployment.DeploymentType,
+		MaintenanceWindow: window,
 	}
 
 	if len(availableUpdates) > 0 {
@@ -302,6 +333,20 @@ func (s *PendingUpdatesService) GetPendingUpdatesForDeployment(ctx context.Conte
 	return response, nil
 }
 
+// maintenanceWindow returns the deployment's open or next maintenance window,
+// or nil if rollouts to it are not restricted
+func (s *PendingUpdatesService) maintenanceWindow(ctx context.Context, deployment *models.Deployment) *models.MaintenanceWindowStatus {
+	if s.windows == nil {
+		return nil
+	}
+	window, err := s.windows.WindowForDeployment(ctx, deployment, time.Now())
+	if err != nil {
+		log.Printf("Failed to resolve maintenance window of deployment %s: %v", deployment.DeploymentID, err)
+		return nil
+	}
+	return window
+}
+
 // GetPendingUpdatesForTenant retrieves pending updates for all deployments in a tenant
 func (s *PendingUpdatesService) GetPendingUpdatesForTenant(ctx context.Context, customerID, tenantID string, filter *models.PendingUpdatesFilter) (*models.TenantPendingUpdatesSummary, error) {
 	// Get tenant
@@ -469,21 +514,8 @@ func (s *PendingUpdatesService) processDeploymentsForPendingUpdates(ctx context.
 			continue // Skip deployments with errors
 		}
 
-		// Apply filters
-		if filter != nil {
-			if filter.CustomerID != "" && pendingUpdates.CustomerID != filter.CustomerID {
-				continue
-			}
-			if filter.TenantID != "" && pendingUpdates.TenantID != filter.TenantID {
-				continue
-			}
-			if filter.Priority != "" && pendingUpdates.Priority != filter.Priority {
-				continue
-			}
-		}
-
 		// Only include deployments with pending updates
-		if pendingUpdates.UpdateCount > 0 {
+		if matchesPendingUpdatesFilter(pendingUpdates, filter) {
 			results = append(results, *pendingUpdates)
 		}
 	}
@@ -501,6 +533,61 @@ func (s *PendingUpdatesService) processDeploymentsForPendingUpdates(ctx context.
 	return results, paginationInfo, nil
 }
 
+// PendingDeployment pairs a deployment with its pending updates
+type PendingDeployment struct {
+	Deployment *models.Deployment
+	Updates    *models.PendingUpdatesResponse
+}
+
+// FindPendingDeployments returns every deployment matching the filter that has
+// pending updates, walking all pages of deployments
+func (s *PendingUpdatesService) FindPendingDeployments(ctx context.Context, filter *models.PendingUpdatesFilter) ([]PendingDeployment, error) {
+	deploymentFilter := &repository.DeploymentFilter{}
+	if filter != nil {
+		deploymentFilter.ProductID = filter.ProductID
+		deploymentFilter.DeploymentType = filter.DeploymentType
+	}
+
+	var results []PendingDeployment
+	for page := 1; ; page++ {
+		deployments, paginationInfo, err := s.deploymentRepo.GetAll(ctx, deploymentFilter, &repository.Pagination{Page: page, Limit: 100})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get deployments: %w", err)
+		}
+
+		for _, deployment := range deployments {
+			pendingUpdates, err := s.GetPendingUpdatesForDeployment(ctx, deployment.ID.Hex())
+			if err != nil {
+				continue // Skip deployments with errors
+			}
+			if matchesPendingUpdatesFilter(pendingUpdates, filter) {
+				results = append(results, PendingDeployment{Deployment: deployment, Updates: pendingUpdates})
+			}
+		}
+
+		if int64(page) >= paginationInfo.TotalPages {
+			return results, nil
+		}
+	}
+}
+
+// matchesPendingUpdatesFilter reports whether a deployment has pending updates
+// and matches the filter fields the deployment query cannot apply
+func matchesPendingUpdatesFilter(pendingUpdates *models.PendingUpdatesResponse, filter *models.PendingUpdatesFilter) bool {
+	if filter != nil {
+		if filter.CustomerID != "" && pendingUpdates.CustomerID != filter.CustomerID {
+			return false
+		}
+		if filter.TenantID != "" && pendingUpdates.TenantID != filter.TenantID {
+			return false
+		}
+		if filter.Priority != "" && pendingUpdates.Priority != filter.Priority {
+			return false
+		}
+	}
+	return pendingUpdates.UpdateCount > 0
+}
+
 // CalculateUpdatePriority calculates the priority level for pending updates
 func (s *PendingUpdatesService) CalculateUpdatePriority(deployment *models.Deployment, availableUpdates []models.AvailableUpdate) string {
 	// Check for security updates
@@ -45,6 +45,7 @@ func setupPendingUpdatesServiceTestDB(t *testing.T) {
 		pendingUpdatesVersionRepo,
 		pendingUpdatesCustomerRepo,
 		pendingUpdatesTenantRepo,
+		NewMaintenanceWindowService(pendingUpdatesDeploymentRepo, pendingUpdatesTenantRepo),
 	)
 }
 
@@ -0,0 +1,29 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// runPeriodically calls tick on every interval until the context is
+// cancelled, logging errors and how many items each tick processed
+func runPeriodically(ctx context.Context, name string, interval time.Duration, tick func(context.Context, time.Time) (int, error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			processed, err := tick(ctx, now)
+			if err != nil {
+				log.Printf("%s: %v", name, err)
+			}
+			if processed > 0 {
+				log.Printf("%s: processed %d item(s)", name, processed)
+			}
+		}
+	}
+}
@@ -3,7 +3,6 @@ package service
 import (
 	"context"
 	"fmt"
-	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -16,19 +15,23 @@ import (
 // ProductService handles product business logic
 type ProductService struct {
 	productRepo *repository.ProductRepository
-	auditRepo   *repository.AuditLogRepository
+	audit       *AuditLogger
 }
 
 // NewProductService creates a new product service
-func NewProductService(productRepo *repository.ProductRepository, auditRepo *repository.AuditLogRepository) *ProductService {
+func NewProductService(productRepo *repository.ProductRepository, audit *AuditLogger) *ProductService {
 	return &ProductService{
 		productRepo: productRepo,
-		auditRepo:   auditRepo,
+		audit:       audit,
 	}
 }
 
 // CreateProduct creates a new product with validation and audit logging
 func (s *ProductService) CreateProduct(ctx context.Context, req *models.CreateProductRequest, userID, userEmail string) (*models.Product, error) {
+	if err := validateRolloutTimeouts(req.RolloutTimeouts); err != nil {
+		return nil, err
+	}
+
 	// Validate product_id uniqueness
 	existing, err := s.productRepo.GetByProductID(ctx, req.ProductID)
 	if err == nil && existing != nil {
@@ -37,12 +40,13 @@ func (s *ProductService) CreateProduct(ctx context.Context, req *models.CreatePr
 
 	// Create product
 	product := &models.Product{
-		ProductID:   req.ProductID,
-		Name:        req.Name,
-		Type:        req.Type,
-		Description: req.Description,
-		Vendor:      req.Vendor,
-		IsActive:    true,
+		ProductID:       req.ProductID,
+		Name:            req.Name,
+		Type:            req.Type,
+		Description:     req.Description,
+		Vendor:          req.Vendor,
+		IsActive:        true,
+		RolloutTimeouts: req.RolloutTimeouts,
 	}
 
 	if err := s.productRepo.Create(ctx, product); err != nil {
@@ -50,7 +54,7 @@ func (s *ProductService) CreateProduct(ctx context.Context, req *models.CreatePr
 	}
 
 	// Log audit
-	s.logAudit(ctx, models.AuditActionCreate, "product", product.ID.Hex(), userID, userEmail, map[string]interface{}{
+	s.audit.LogChange(ctx, models.AuditActionCreate, "product", product.ID.Hex(), userID, userEmail, nil, product, map[string]interface{}{
 		"product_id": product.ProductID,
 		"name":       product.Name,
 		"type":       product.Type,
@@ -85,6 +89,10 @@ func (s *ProductService) UpdateProduct(ctx context.Context, id primitive.ObjectI
 		return nil, fmt.Errorf("product not found: %w", err)
 	}
 
+	if err := validateRolloutTimeouts(req.RolloutTimeouts); err != nil {
+		return nil, err
+	}
+
 	// Check product_id uniqueness if changed
 	if req.ProductID != product.ProductID {
 		existing, err := s.productRepo.GetByProductID(ctx, req.ProductID)
@@ -94,18 +102,20 @@ func (s *ProductService) UpdateProduct(ctx context.Context, id primitive.ObjectI
 	}
 
 	// Update fields
+	before := auditState(product)
 	product.ProductID = req.ProductID
 	product.Name = req.Name
 	product.Type = req.Type
 	product.Description = req.Description
 	product.Vendor = req.Vendor
+	product.RolloutTimeouts = req.RolloutTimeouts
 
 	if err := s.productRepo.Update(ctx, product); err != nil {
 		return nil, fmt.Errorf("failed to update product: %w", err)
 	}
 
 	// Log audit
-	s.logAudit(ctx, models.AuditActionUpdate, "product", product.ID.Hex(), userID, userEmail, map[string]interface{}{
+	s.audit.LogChange(ctx, models.AuditActionUpdate, "product", product.ID.Hex(), userID, userEmail, before, product, map[string]interface{}{
 		"product_id": product.ProductID,
 		"name":       product.Name,
 	})
@@ -121,13 +131,14 @@ func (s *ProductService) DeleteProduct(ctx context.Context, id primitive.ObjectI
 	}
 
 	// Soft delete
+	before := auditState(product)
 	product.IsActive = false
 	if err := s.productRepo.Update(ctx, product); err != nil {
 		return fmt.Errorf("failed to delete product: %w", err)
 	}
 
 	// Log audit
-	s.logAudit(ctx, models.AuditActionDelete, "product", product.ID.Hex(), userID, userEmail, map[string]interface{}{
+	s.audit.LogChange(ctx, models.AuditActionDelete, "product", product.ID.Hex(), userID, userEmail, before, product, map[string]interface{}{
 		"product_id": product.ProductID,
 	})
 
@@ -165,22 +176,3 @@ func (s *ProductService) GetActiveProducts(ctx context.Context) ([]*models.Produ
 	}
 	return products, nil
 }
-
-// logAudit logs an audit entry
-func (s *ProductService) logAudit(ctx context.Context, action models.AuditAction, resourceType, resourceID, userID, userEmail string, details map[string]interface{}) {
-	if s.auditRepo == nil {
-		return
-	}
-
-	auditLog := &models.AuditLog{
-		Action:       action,
-		ResourceType: resourceType,
-		ResourceID:   resourceID,
-		UserID:       userID,
-		UserEmail:    userEmail,
-		Details:      details,
-		Timestamp:    time.Now(),
-	}
-
-	_ = s.auditRepo.Create(ctx, auditLog) // Ignore errors for audit logging
-}
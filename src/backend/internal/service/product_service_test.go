@@ -38,7 +38,7 @@ func setupProductServiceTestDB(t *testing.T) {
 	productServiceTestCtx = ctx
 	productRepo = repository.NewProductRepository(db.Collection("products"))
 	auditRepo = repository.NewAuditLogRepository(db.Collection("audit_logs"))
-	productService = NewProductService(productRepo, auditRepo)
+	productService = NewProductService(productRepo, NewAuditLogger(auditRepo))
 }
 
 func teardownProductServiceTestDB(t *testing.T) {
@@ -0,0 +1,31 @@
+package service
+
+import (
+	"context"
+	"time"
+)
+
+// defaultCampaignSchedulerInterval is how often campaigns are advanced
+const defaultCampaignSchedulerInterval = time.Minute
+
+// RolloutCampaignScheduler periodically advances rollout campaign waves
+type RolloutCampaignScheduler struct {
+	campaignService *RolloutCampaignService
+	interval        time.Duration
+}
+
+// NewRolloutCampaignScheduler creates a new rollout campaign scheduler
+func NewRolloutCampaignScheduler(campaignService *RolloutCampaignService, interval time.Duration) *RolloutCampaignScheduler {
+	if interval <= 0 {
+		interval = defaultCampaignSchedulerInterval
+	}
+	return &RolloutCampaignScheduler{
+		campaignService: campaignService,
+		interval:        interval,
+	}
+}
+
+// Run advances campaigns on every tick until the context is cancelled
+func (s *RolloutCampaignScheduler) Run(ctx context.Context) {
+	runPeriodically(ctx, "Rollout campaign scheduler", s.interval, s.campaignService.AdvanceCampaigns)
+}
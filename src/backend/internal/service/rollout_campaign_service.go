@@ -0,0 +1,628 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"updatemanager/internal/models"
+	"updatemanager/internal/repository"
+	"updatemanager/internal/utils"
+)
+
+// maxCampaignWaves limits how many waves a campaign may define
+const maxCampaignWaves = 10
+
+// activeRolloutStatuses are the rollout statuses that still need agent work
+var activeRolloutStatuses = []models.RolloutStatus{models.RolloutStatusScheduled, models.RolloutStatusPending, models.RolloutStatusInProgress}
+
+// RolloutCampaignService handles staged rollout campaign business logic
+type RolloutCampaignService struct {
+	campaignRepo   *repository.RolloutCampaignRepository
+	rolloutRepo    *repository.UpdateRolloutRepository
+	detectionRepo  *repository.UpdateDetectionRepository
+	endpointRepo   *repository.EndpointRepository
+	deploymentRepo *repository.DeploymentRepository
+	versionRepo    *repository.VersionRepository
+	rolloutService *UpdateRolloutService
+	audit          *AuditLogger
+}
+
+// NewRolloutCampaignService creates a new rollout campaign service
+func NewRolloutCampaignService(
+	campaignRepo *repository.RolloutCampaignRepository,
+	rolloutRepo *repository.UpdateRolloutRepository,
+	detectionRepo *repository.UpdateDetectionRepository,
+	endpointRepo *repository.EndpointRepository,
+	deploymentRepo *repository.DeploymentRepository,
+	versionRepo *repository.VersionRepository,
+	rolloutService *UpdateRolloutService,
+	audit *AuditLogger,
+) *RolloutCampaignService {
+	return &RolloutCampaignService{
+		campaignRepo:   campaignRepo,
+		rolloutRepo:    rolloutRepo,
+		detectionRepo:  detectionRepo,
+		endpointRepo:   endpointRepo,
+		deploymentRepo: deploymentRepo,
+		versionRepo:    versionRepo,
+		rolloutService: rolloutService,
+		audit:          audit,
+	}
+}
+
+// CreateCampaign validates and schedules a new rollout campaign. Targets are
+// resolved to registered endpoints up front so waves are stable.
+func (s *RolloutCampaignService) CreateCampaign(ctx context.Context, req *models.CreateRolloutCampaignRequest, userID, userEmail string) (*models.RolloutCampaign, error) {
+	if strings.TrimSpace(req.Name) == "" || req.ProductID == "" || req.ToVersion == "" {
+		return nil, fmt.Errorf("invalid campaign: name, product_id and to_version are required")
+	}
+	if err := validateCampaignWaves(req.Waves); err != nil {
+		return nil, err
+	}
+	if req.HealthGate != nil {
+		if err := validateHealthGate(req.HealthGate); err != nil {
+			return nil, fmt.Errorf("invalid campaign: %v", err)
+		}
+	}
+
+	version, err := s.versionRepo.GetByProductIDAndVersion(ctx, req.ProductID, req.ToVersion)
+	if err != nil {
+		return nil, fmt.Errorf("to version %s not found for product %s: %w", req.ToVersion, req.ProductID, err)
+	}
+	if version.State != models.VersionStateReleased {
+		return nil, fmt.Errorf("invalid campaign: to version %s must be in Released state, current state: %s", req.ToVersion, version.State)
+	}
+	if rolloutsBlocked(version) {
+		return nil, fmt.Errorf("invalid campaign: rollouts of version %s are %s", req.ToVersion, version.RolloutHalt.Status)
+	}
+
+	endpointIDs, err := s.resolveCampaignTargets(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	campaign := &models.RolloutCampaign{
+		Name:          req.Name,
+		ProductID:     req.ProductID,
+		ToVersion:     req.ToVersion,
+		EndpointIDs:   endpointIDs,
+		DeploymentIDs: req.DeploymentIDs,
+		Waves:         buildCampaignWaves(req.Waves),
+		CurrentWave:   -1,
+		Status:        models.CampaignStatusScheduled,
+		StartAt:       req.StartAt,
+		HealthGate:    req.HealthGate,
+		CreatedBy:     userID,
+	}
+
+	if err := s.campaignRepo.Create(ctx, campaign); err != nil {
+		return nil, fmt.Errorf("failed to create rollout campaign: %w", err)
+	}
+
+	// Log audit
+	s.audit.LogChange(ctx, models.AuditActionCreate, "rollout_campaign", campaign.ID.Hex(), userID, userEmail, nil, campaign, map[string]interface{}{
+		"name":       campaign.Name,
+		"product_id": campaign.ProductID,
+		"to_version": campaign.ToVersion,
+		"endpoints":  len(campaign.EndpointIDs),
+		"waves":      len(campaign.Waves),
+	})
+
+	return campaign, nil
+}
+
+// resolveCampaignTargets returns the de-duplicated registered endpoints targeted by the request
+func (s *RolloutCampaignService) resolveCampaignTargets(ctx context.Context, req *models.CreateRolloutCampaignRequest) ([]string, error) {
+	seen := make(map[string]bool)
+	var endpointIDs []string
+
+	for _, endpointID := range req.EndpointIDs {
+		if seen[endpointID] {
+			continue
+		}
+		if _, err := getRegisteredEndpoint(ctx, s.endpointRepo, endpointID, req.ProductID); err != nil {
+			return nil, fmt.Errorf("invalid campaign: %v", err)
+		}
+		seen[endpointID] = true
+		endpointIDs = append(endpointIDs, endpointID)
+	}
+
+	for _, deploymentID := range req.DeploymentIDs {
+		deployment, err := s.deploymentRepo.GetByDeploymentID(ctx, deploymentID)
+		if err != nil {
+			objectID, parseErr := primitive.ObjectIDFromHex(deploymentID)
+			if parseErr != nil {
+				return nil, fmt.Errorf("deployment %s not found: %w", deploymentID, err)
+			}
+			if deployment, err = s.deploymentRepo.GetByID(ctx, objectID); err != nil {
+				return nil, fmt.Errorf("deployment %s not found: %w", deploymentID, err)
+			}
+		}
+		if deployment.ProductID != req.ProductID {
+			return nil, fmt.Errorf("invalid campaign: deployment %s is for product %s", deploymentID, deployment.ProductID)
+		}
+
+		endpoints, err := s.endpointRepo.FindAll(ctx, &repository.EndpointFilter{
+			DeploymentID: deployment.ID,
+			Status:       models.EndpointStatusActive,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve deployment endpoints: %w", err)
+		}
+		sort.Slice(endpoints, func(i, j int) bool {
+			return endpoints[i].EndpointID < endpoints[j].EndpointID
+		})
+		for _, endpoint := range endpoints {
+			if !seen[endpoint.EndpointID] {
+				seen[endpoint.EndpointID] = true
+				endpointIDs = append(endpointIDs, endpoint.EndpointID)
+			}
+		}
+	}
+
+	if len(endpointIDs) == 0 {
+		return nil, fmt.Errorf("invalid campaign: no registered endpoints targeted")
+	}
+
+	return endpointIDs, nil
+}
+
+// GetCampaign retrieves a rollout campaign by ID
+func (s *RolloutCampaignService) GetCampaign(ctx context.Context, id primitive.ObjectID) (*models.RolloutCampaign, error) {
+	campaign, err := s.campaignRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("rollout campaign not found: %w", err)
+	}
+	return campaign, nil
+}
+
+// ListCampaigns lists rollout campaigns with filters
+func (s *RolloutCampaignService) ListCampaigns(ctx context.Context, filter bson.M, page, limit int) ([]*models.RolloutCampaign, int64, error) {
+	opts := options.Find()
+	if limit > 0 {
+		opts.SetLimit(int64(limit))
+		opts.SetSkip(int64((page - 1) * limit))
+	}
+	opts.SetSort(bson.M{"created_at": -1})
+
+	campaigns, err := s.campaignRepo.List(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list rollout campaigns: %w", err)
+	}
+
+	total, err := s.campaignRepo.Count(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count rollout campaigns: %w", err)
+	}
+
+	return campaigns, total, nil
+}
+
+// ListCampaignRollouts lists the per-endpoint rollouts created by a campaign
+func (s *RolloutCampaignService) ListCampaignRollouts(ctx context.Context, id primitive.ObjectID, page, limit int) ([]*models.UpdateRollout, int64, error) {
+	if _, err := s.GetCampaign(ctx, id); err != nil {
+		return nil, 0, err
+	}
+	return s.rolloutService.ListRollouts(ctx, bson.M{"campaign_id": id}, page, limit)
+}
+
+// PauseCampaign stops the scheduler from opening further waves. Rollouts of the
+// open wave keep running.
+func (s *RolloutCampaignService) PauseCampaign(ctx context.Context, id primitive.ObjectID, userID, userEmail string) (*models.RolloutCampaign, error) {
+	campaign, err := s.GetCampaign(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if campaign.Status != models.CampaignStatusScheduled && campaign.Status != models.CampaignStatusRunning {
+		return nil, fmt.Errorf("invalid campaign state: cannot pause a %s campaign", campaign.Status)
+	}
+
+	now := time.Now()
+	before := auditState(campaign)
+	campaign.Status = models.CampaignStatusPaused
+	campaign.PausedAt = &now
+	if err := s.saveCampaign(ctx, campaign); err != nil {
+		return nil, err
+	}
+
+	// Log audit
+	s.audit.LogChange(ctx, models.AuditActionUpdate, "rollout_campaign", campaign.ID.Hex(), userID, userEmail, before, campaign, map[string]interface{}{
+		"action":       "pause",
+		"current_wave": campaign.CurrentWave,
+	})
+
+	return campaign, nil
+}
+
+// ResumeCampaign hands a paused campaign back to the scheduler
+func (s *RolloutCampaignService) ResumeCampaign(ctx context.Context, id primitive.ObjectID, userID, userEmail string) (*models.RolloutCampaign, error) {
+	campaign, err := s.GetCampaign(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if campaign.Status == models.CampaignStatusHalted {
+		return nil, fmt.Errorf("invalid campaign state: campaign was halted by its health gate, resume or recall version %s instead", campaign.ToVersion)
+	}
+	if campaign.Status != models.CampaignStatusPaused {
+		return nil, fmt.Errorf("invalid campaign state: cannot resume a %s campaign", campaign.Status)
+	}
+
+	before := auditState(campaign)
+	campaign.Status = models.CampaignStatusRunning
+	if campaign.StartedAt == nil {
+		campaign.Status = models.CampaignStatusScheduled
+	}
+	campaign.PausedAt = nil
+	if err := s.saveCampaign(ctx, campaign); err != nil {
+		return nil, err
+	}
+
+	// Log audit
+	s.audit.LogChange(ctx, models.AuditActionUpdate, "rollout_campaign", campaign.ID.Hex(), userID, userEmail, before, campaign, map[string]interface{}{
+		"action":       "resume",
+		"current_wave": campaign.CurrentWave,
+	})
+
+	return campaign, nil
+}
+
+// AbortCampaign stops a campaign for good and cancels its unfinished rollouts
+func (s *RolloutCampaignService) AbortCampaign(ctx context.Context, id primitive.ObjectID, reason, userID, userEmail string) (*models.RolloutCampaign, error) {
+	campaign, err := s.GetCampaign(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if campaign.Status == models.CampaignStatusCompleted || campaign.Status == models.CampaignStatusAborted {
+		return nil, fmt.Errorf("invalid campaign state: cannot abort a %s campaign", campaign.Status)
+	}
+
+	now := time.Now()
+	before := auditState(campaign)
+	campaign.Status = models.CampaignStatusAborted
+	campaign.AbortedAt = &now
+	campaign.AbortReason = reason
+	if err := s.saveCampaign(ctx, campaign); err != nil {
+		return nil, err
+	}
+
+	cancelled, err := s.cancelCampaignRollouts(ctx, campaign.ID, reason, userID, userEmail)
+	if err != nil {
+		return nil, err
+	}
+
+	// Log audit
+	s.audit.LogChange(ctx, models.AuditActionUpdate, "rollout_campaign", campaign.ID.Hex(), userID, userEmail, before, campaign, map[string]interface{}{
+		"action":             "abort",
+		"reason":             reason,
+		"current_wave":       campaign.CurrentWave,
+		"cancelled_rollouts": cancelled,
+	})
+
+	return campaign, nil
+}
+
+// cancelCampaignRollouts cancels the campaign's rollouts that have not finished
+func (s *RolloutCampaignService) cancelCampaignRollouts(ctx context.Context, campaignID primitive.ObjectID, reason, userID, userEmail string) (int, error) {
+	rollouts, err := s.rolloutRepo.List(ctx, bson.M{
+		"campaign_id": campaignID,
+		"status":      bson.M{"$in": activeRolloutStatuses},
+	}, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list campaign rollouts: %w", err)
+	}
+
+	cancelled := 0
+	for _, rollout := range rollouts {
+		// Rollouts that finished in the meantime keep their result
+		updated, err := s.rolloutRepo.UpdateStatusFrom(ctx, rollout.ID, rollout.Status, models.RolloutStatusCancelled, "")
+		if err != nil {
+			return 0, fmt.Errorf("failed to cancel rollout %s: %w", rollout.ID.Hex(), err)
+		}
+		if !updated {
+			continue
+		}
+		cancelled++
+
+		after, err := s.rolloutRepo.GetByID(ctx, rollout.ID)
+		if err != nil {
+			log.Printf("Failed to reload cancelled rollout %s: %v", rollout.ID.Hex(), err)
+			continue
+		}
+		s.rolloutService.stream.RolloutStatusChanged(ctx, after)
+
+		s.audit.LogChange(ctx, models.AuditActionUpdate, "update_rollout", rollout.ID.Hex(), userID, userEmail, rollout, after, map[string]interface{}{
+			"status":      models.RolloutStatusCancelled,
+			"reason":      "campaign aborted",
+			"note":        reason,
+			"campaign_id": campaignID.Hex(),
+		})
+	}
+
+	return cancelled, nil
+}
+
+// AdvanceCampaigns moves every scheduled or running campaign forward as far as
+// it can go at the given time. It is called periodically by the scheduler and
+// returns the number of campaigns that changed.
+func (s *RolloutCampaignService) AdvanceCampaigns(ctx context.Context, now time.Time) (int, error) {
+	campaigns, err := s.campaignRepo.GetDue(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	advanced := 0
+	var errs []string
+	for _, campaign := range campaigns {
+		changed, err := s.advanceCampaign(ctx, campaign, now)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("campaign %s: %v", campaign.ID.Hex(), err))
+			continue
+		}
+		if changed {
+			advanced++
+		}
+	}
+
+	if len(errs) > 0 {
+		return advanced, fmt.Errorf("failed to advance campaigns: %s", strings.Join(errs, "; "))
+	}
+	return advanced, nil
+}
+
+// advanceCampaign performs at most one step of the campaign's wave lifecycle:
+// start, finish rolling out (begin baking), or finish baking (open the next
+// wave or complete the campaign).
+func (s *RolloutCampaignService) advanceCampaign(ctx context.Context, campaign *models.RolloutCampaign, now time.Time) (bool, error) {
+	before := auditState(campaign)
+	switch campaign.Status {
+	case models.CampaignStatusScheduled:
+		if campaign.StartAt != nil && now.Before(*campaign.StartAt) {
+			return false, nil
+		}
+		campaign.Status = models.CampaignStatusRunning
+		campaign.StartedAt = &now
+		return s.openWave(ctx, campaign, before, 0, now)
+
+	case models.CampaignStatusRunning:
+		if campaign.CurrentWave < 0 {
+			return s.openWave(ctx, campaign, before, 0, now)
+		}
+
+		wave := &campaign.Waves[campaign.CurrentWave]
+		switch wave.Status {
+		case models.WaveStatusRollingOut:
+			active, err := s.rolloutRepo.Count(ctx, bson.M{
+				"campaign_id": campaign.ID,
+				"wave_index":  campaign.CurrentWave,
+				"status":      bson.M{"$in": activeRolloutStatuses},
+			})
+			if err != nil {
+				return false, fmt.Errorf("failed to count wave rollouts: %w", err)
+			}
+			if active > 0 {
+				return false, nil
+			}
+			bakeUntil := now.Add(time.Duration(wave.BakeTimeMinutes) * time.Minute)
+			wave.Status = models.WaveStatusBaking
+			wave.BakeUntil = &bakeUntil
+			saved, err := s.campaignRepo.Update(ctx, campaign)
+			if saved {
+				s.audit.LogChange(ctx, models.AuditActionUpdate, "rollout_campaign", campaign.ID.Hex(), schedulerUserID, "", before, campaign, map[string]interface{}{
+					"action": "bake",
+					"wave":   campaign.CurrentWave,
+				})
+			}
+			return saved, err
+
+		case models.WaveStatusBaking:
+			if wave.BakeUntil != nil && now.Before(*wave.BakeUntil) {
+				return false, nil
+			}
+			wave.Status = models.WaveStatusCompleted
+			wave.CompletedAt = &now
+			if campaign.CurrentWave+1 < len(campaign.Waves) {
+				return s.openWave(ctx, campaign, before, campaign.CurrentWave+1, now)
+			}
+
+			campaign.Status = models.CampaignStatusCompleted
+			campaign.CompletedAt = &now
+			saved, err := s.campaignRepo.Update(ctx, campaign)
+			if saved {
+				s.audit.LogChange(ctx, models.AuditActionUpdate, "rollout_campaign", campaign.ID.Hex(), schedulerUserID, "", before, campaign, map[string]interface{}{
+					"action": "complete",
+				})
+			}
+			return saved, err
+		}
+	}
+
+	return false, nil
+}
+
+// openWave claims the wave by saving the campaign first, so a concurrent
+// scheduler cannot open it twice, then creates the wave's rollouts. before is
+// the campaign's audit state before this step.
+func (s *RolloutCampaignService) openWave(ctx context.Context, campaign *models.RolloutCampaign, before bson.M, index int, now time.Time) (bool, error) {
+	start := 0
+	if index > 0 {
+		start = waveTargetCount(len(campaign.EndpointIDs), campaign.Waves[index-1].Percentage)
+	}
+	end := waveTargetCount(len(campaign.EndpointIDs), campaign.Waves[index].Percentage)
+
+	wave := &campaign.Waves[index]
+	campaign.CurrentWave = index
+	wave.Status = models.WaveStatusRollingOut
+	wave.OpenedAt = &now
+	wave.EndpointIDs = campaign.EndpointIDs[start:end]
+
+	claimed, err := s.campaignRepo.Update(ctx, campaign)
+	if err != nil || !claimed {
+		return false, err
+	}
+
+	var skipped []models.CampaignSkippedEndpoint
+	created := 0
+	for _, endpointID := range wave.EndpointIDs {
+		if reason := s.createWaveRollout(ctx, campaign, index, endpointID); reason != "" {
+			skipped = append(skipped, models.CampaignSkippedEndpoint{EndpointID: endpointID, Reason: reason})
+			continue
+		}
+		created++
+	}
+
+	if err := s.recordWaveResult(ctx, campaign, index, created, skipped); err != nil {
+		return true, err
+	}
+
+	// Log audit
+	s.audit.LogChange(ctx, models.AuditActionUpdate, "rollout_campaign", campaign.ID.Hex(), schedulerUserID, "", before, campaign, map[string]interface{}{
+		"action":     "open_wave",
+		"wave":       index,
+		"percentage": wave.Percentage,
+		"rollouts":   created,
+		"skipped":    len(skipped),
+	})
+
+	return true, nil
+}
+
+// createWaveRollout creates the campaign rollout for one endpoint. It returns
+// the reason the endpoint was skipped, or an empty string on success.
+func (s *RolloutCampaignService) createWaveRollout(ctx context.Context, campaign *models.RolloutCampaign, index int, endpointID string) string {
+	detection, err := s.detectionRepo.GetByEndpointIDAndProductID(ctx, endpointID, campaign.ProductID)
+	if err != nil {
+		return "no update detection for endpoint"
+	}
+	if !utils.IsVersionNewer(campaign.ToVersion, detection.CurrentVersion) {
+		return fmt.Sprintf("endpoint is already on %s", detection.CurrentVersion)
+	}
+
+	active, err := s.rolloutRepo.Count(ctx, bson.M{
+		"endpoint_id": endpointID,
+		"product_id":  campaign.ProductID,
+		"status":      bson.M{"$in": activeRolloutStatuses},
+	})
+	if err != nil {
+		return err.Error()
+	}
+	if active > 0 {
+		return "endpoint has an active rollout"
+	}
+
+	campaignID := campaign.ID
+	waveIndex := index
+	rollout := &models.UpdateRollout{
+		EndpointID:  endpointID,
+		ProductID:   campaign.ProductID,
+		FromVersion: detection.CurrentVersion,
+		ToVersion:   campaign.ToVersion,
+		InitiatedBy: campaign.CreatedBy,
+		CampaignID:  &campaignID,
+		WaveIndex:   &waveIndex,
+	}
+	if _, err := s.rolloutService.InitiateRollout(ctx, rollout); err != nil {
+		return err.Error()
+	}
+
+	return ""
+}
+
+// recordWaveResult stores the outcome of opening a wave, re-reading the
+// campaign if an operator changed it while rollouts were being created.
+func (s *RolloutCampaignService) recordWaveResult(ctx context.Context, campaign *models.RolloutCampaign, index, created int, skipped []models.CampaignSkippedEndpoint) error {
+	for attempt := 0; attempt < 3; attempt++ {
+		campaign.Waves[index].RolloutCount = created
+		campaign.Waves[index].Skipped = skipped
+
+		saved, err := s.campaignRepo.Update(ctx, campaign)
+		if err != nil || saved {
+			return err
+		}
+
+		latest, err := s.campaignRepo.GetByID(ctx, campaign.ID)
+		if err != nil {
+			return err
+		}
+		*campaign = *latest
+	}
+	return fmt.Errorf("failed to record wave %d result: campaign was modified concurrently", index)
+}
+
+// saveCampaign saves an operator change, failing if the scheduler wrote first
+func (s *RolloutCampaignService) saveCampaign(ctx context.Context, campaign *models.RolloutCampaign) error {
+	saved, err := s.campaignRepo.Update(ctx, campaign)
+	if err != nil {
+		return err
+	}
+	if !saved {
+		return fmt.Errorf("invalid campaign state: campaign was modified concurrently, retry")
+	}
+	return nil
+}
+
+// validateCampaignWaves checks that waves cover a strictly increasing share of
+// the targets and end at 100%
+func validateCampaignWaves(waves []models.CreateRolloutWaveRequest) error {
+	if len(waves) == 0 {
+		return fmt.Errorf("invalid campaign: at least one wave is required")
+	}
+	if len(waves) > maxCampaignWaves {
+		return fmt.Errorf("invalid campaign: at most %d waves are allowed", maxCampaignWaves)
+	}
+
+	previous := 0
+	for i, wave := range waves {
+		if wave.Percentage <= previous || wave.Percentage > 100 {
+			return fmt.Errorf("invalid campaign: wave %d percentage must be greater than %d and at most 100", i+1, previous)
+		}
+		if wave.BakeTimeMinutes < 0 {
+			return fmt.Errorf("invalid campaign: wave %d bake time cannot be negative", i+1)
+		}
+		previous = wave.Percentage
+	}
+	if previous != 100 {
+		return fmt.Errorf("invalid campaign: the last wave must reach 100%%")
+	}
+
+	return nil
+}
+
+// buildCampaignWaves converts wave requests into pending waves
+func buildCampaignWaves(requests []models.CreateRolloutWaveRequest) []models.RolloutWave {
+	waves := make([]models.RolloutWave, len(requests))
+	for i, req := range requests {
+		name := req.Name
+		if name == "" {
+			name = fmt.Sprintf("wave-%d", i+1)
+		}
+		waves[i] = models.RolloutWave{
+			Name:            name,
+			Percentage:      req.Percentage,
+			BakeTimeMinutes: req.BakeTimeMinutes,
+			Status:          models.WaveStatusPending,
+		}
+	}
+	return waves
+}
+
+// waveTargetCount returns how many of total targets are covered once a wave
+// with the given cumulative percentage has opened. Any non-zero percentage
+// covers at least one target so small canaries are never empty.
+func waveTargetCount(total, percentage int) int {
+	if total == 0 || percentage <= 0 {
+		return 0
+	}
+	count := (total*percentage + 99) / 100
+	if count > total {
+		count = total
+	}
+	return count
+}
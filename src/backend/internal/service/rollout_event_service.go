@@ -0,0 +1,215 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"updatemanager/internal/models"
+	"updatemanager/internal/repository"
+)
+
+const (
+	// maxRolloutEventsPerBatch caps the events of one agent post
+	maxRolloutEventsPerBatch = 100
+	// maxRolloutEventMessageLength caps an event message in bytes
+	maxRolloutEventMessageLength = 4096
+	// maxRolloutEventAttachments caps the attachments of one event
+	maxRolloutEventAttachments = 5
+	// MaxRolloutEventAttachmentBytes caps the stored content of one attachment;
+	// longer content keeps its end, where installer logs record the failure
+	MaxRolloutEventAttachmentBytes = 64 << 10
+)
+
+// RolloutEventService records the timeline agents report while applying a
+// rollout and serves it to support
+type RolloutEventService struct {
+	eventRepo   *repository.RolloutEventRepository
+	rolloutRepo *repository.UpdateRolloutRepository
+}
+
+// NewRolloutEventService creates a new rollout event service
+func NewRolloutEventService(eventRepo *repository.RolloutEventRepository, rolloutRepo *repository.UpdateRolloutRepository) *RolloutEventService {
+	return &RolloutEventService{
+		eventRepo:   eventRepo,
+		rolloutRepo: rolloutRepo,
+	}
+}
+
+// RecordEvents appends a batch of agent events to a rollout's timeline. The
+// batch is validated as a whole; events resent with a recorded event ID are
+// counted as duplicates so agents can retry a batch safely.
+func (s *RolloutEventService) RecordEvents(ctx context.Context, rolloutID primitive.ObjectID, req *models.RecordRolloutEventsRequest) (*models.RecordRolloutEventsResponse, error) {
+	if len(req.Events) == 0 {
+		return nil, fmt.Errorf("invalid rollout event: at least one event is required")
+	}
+	if len(req.Events) > maxRolloutEventsPerBatch {
+		return nil, fmt.Errorf("invalid rollout event: at most %d events per batch", maxRolloutEventsPerBatch)
+	}
+
+	if _, err := s.rolloutRepo.GetByID(ctx, rolloutID); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	events := make([]*models.RolloutEvent, 0, len(req.Events))
+	var eventIDs []string
+	seen := make(map[string]bool)
+	duplicates := 0
+	for i := range req.Events {
+		event, err := buildRolloutEvent(rolloutID, &req.Events[i], now)
+		if err != nil {
+			return nil, fmt.Errorf("%w (event %d)", err, i+1)
+		}
+		if event.EventID != "" {
+			if seen[event.EventID] {
+				duplicates++
+				continue
+			}
+			seen[event.EventID] = true
+			eventIDs = append(eventIDs, event.EventID)
+		}
+		events = append(events, event)
+	}
+
+	// Drop events recorded by an earlier attempt of the batch
+	existing, err := s.eventRepo.ExistingEventIDs(ctx, rolloutID, eventIDs)
+	if err != nil {
+		return nil, err
+	}
+	if len(existing) > 0 {
+		fresh := events[:0]
+		for _, event := range events {
+			if existing[event.EventID] {
+				duplicates++
+				continue
+			}
+			fresh = append(fresh, event)
+		}
+		events = fresh
+	}
+
+	recorded, err := s.eventRepo.CreateMany(ctx, events)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.RecordRolloutEventsResponse{
+		Recorded:   recorded,
+		Duplicates: duplicates + len(events) - recorded,
+	}, nil
+}
+
+// ListEvents returns a page of a rollout's timeline in the order the agent
+// reported it. filter may narrow it by step and level.
+func (s *RolloutEventService) ListEvents(ctx context.Context, rolloutID primitive.ObjectID, filter bson.M, page, limit int) ([]*models.RolloutEvent, int64, error) {
+	if _, err := s.rolloutRepo.GetByID(ctx, rolloutID); err != nil {
+		return nil, 0, err
+	}
+
+	if filter == nil {
+		filter = bson.M{}
+	}
+	filter["rollout_id"] = rolloutID
+
+	skip := int64((page - 1) * limit)
+	opts := options.Find().
+		SetSkip(skip).
+		SetLimit(int64(limit)).
+		SetSort(bson.D{{Key: "timestamp", Value: 1}, {Key: "_id", Value: 1}})
+
+	events, err := s.eventRepo.List(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	total, err := s.eventRepo.Count(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return events, total, nil
+}
+
+// buildRolloutEvent validates a posted event and applies its defaults
+func buildRolloutEvent(rolloutID primitive.ObjectID, req *models.RolloutEventRequest, now time.Time) (*models.RolloutEvent, error) {
+	step := strings.TrimSpace(req.Step)
+	if step == "" {
+		return nil, fmt.Errorf("invalid rollout event: step is required")
+	}
+	if len(step) > 100 {
+		return nil, fmt.Errorf("invalid rollout event: step must be at most 100 characters")
+	}
+	if len(req.EventID) > 100 {
+		return nil, fmt.Errorf("invalid rollout event: event_id must be at most 100 characters")
+	}
+	if len(req.Message) > maxRolloutEventMessageLength {
+		return nil, fmt.Errorf("invalid rollout event: message must be at most %d bytes", maxRolloutEventMessageLength)
+	}
+
+	level := req.Level
+	switch level {
+	case "":
+		level = models.RolloutEventLevelInfo
+	case models.RolloutEventLevelDebug, models.RolloutEventLevelInfo, models.RolloutEventLevelWarning, models.RolloutEventLevelError:
+	default:
+		return nil, fmt.Errorf("invalid rollout event: unknown level '%s'", level)
+	}
+
+	timestamp := now
+	if req.Timestamp != nil {
+		timestamp = *req.Timestamp
+	}
+
+	if len(req.Attachments) > maxRolloutEventAttachments {
+		return nil, fmt.Errorf("invalid rollout event: at most %d attachments per event", maxRolloutEventAttachments)
+	}
+	var attachments []models.RolloutEventAttachment
+	for _, a := range req.Attachments {
+		name := strings.TrimSpace(a.Name)
+		if name == "" {
+			return nil, fmt.Errorf("invalid rollout event: attachment name is required")
+		}
+		contentType := a.ContentType
+		if contentType == "" {
+			contentType = "text/plain"
+		}
+		content, truncated := truncateAttachment(a.Content, MaxRolloutEventAttachmentBytes)
+		attachments = append(attachments, models.RolloutEventAttachment{
+			Name:        name,
+			ContentType: contentType,
+			Content:     content,
+			Size:        len(a.Content),
+			Truncated:   truncated,
+		})
+	}
+
+	return &models.RolloutEvent{
+		RolloutID:   rolloutID,
+		EventID:     req.EventID,
+		Step:        step,
+		Level:       level,
+		Message:     req.Message,
+		Timestamp:   timestamp,
+		Attachments: attachments,
+	}, nil
+}
+
+// truncateAttachment keeps at most max bytes from the end of content without
+// splitting a UTF-8 character
+func truncateAttachment(content string, max int) (string, bool) {
+	if len(content) <= max {
+		return content, false
+	}
+	start := len(content) - max
+	for start < len(content) && !utf8.RuneStart(content[start]) {
+		start++
+	}
+	return content[start:], true
+}
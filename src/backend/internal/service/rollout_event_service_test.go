@@ -0,0 +1,119 @@
+package service
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"updatemanager/internal/models"
+	"updatemanager/internal/repository"
+)
+
+func TestBuildRolloutEvent(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	rolloutID := primitive.NewObjectID()
+
+	event, err := buildRolloutEvent(rolloutID, &models.RolloutEventRequest{
+		Step:    " install ",
+		Message: "running installer",
+		Attachments: []models.RolloutEventAttachmentRequest{
+			{Name: "installer.log", Content: "ok"},
+		},
+	}, now)
+	if err != nil {
+		t.Fatalf("buildRolloutEvent() error = %v", err)
+	}
+	if event.Step != "install" || event.Level != models.RolloutEventLevelInfo || !event.Timestamp.Equal(now) || event.RolloutID != rolloutID {
+		t.Errorf("Unexpected defaults: %+v", event)
+	}
+	if a := event.Attachments[0]; a.ContentType != "text/plain" || a.Size != 2 || a.Truncated {
+		t.Errorf("Unexpected attachment: %+v", a)
+	}
+
+	invalid := []models.RolloutEventRequest{
+		{Message: "no step"},
+		{Step: "install", Level: "fatal"},
+		{Step: "install", Message: strings.Repeat("x", maxRolloutEventMessageLength+1)},
+		{Step: "install", Attachments: []models.RolloutEventAttachmentRequest{{Content: "unnamed"}}},
+		{Step: "install", Attachments: make([]models.RolloutEventAttachmentRequest, maxRolloutEventAttachments+1)},
+	}
+	for i := range invalid {
+		if _, err := buildRolloutEvent(rolloutID, &invalid[i], now); err == nil || !strings.Contains(err.Error(), "invalid rollout event") {
+			t.Errorf("Expected invalid rollout event for %+v, got %v", invalid[i], err)
+		}
+	}
+}
+
+func TestTruncateAttachment(t *testing.T) {
+	if content, truncated := truncateAttachment("short", 10); content != "short" || truncated {
+		t.Errorf("Expected content within the cap to be kept, got %q, %v", content, truncated)
+	}
+
+	// The end is kept and a multi-byte character is not split
+	content, truncated := truncateAttachment("ÜÜ", 3)
+	if !truncated || content != "Ü" {
+		t.Errorf("truncateAttachment() = %q, %v", content, truncated)
+	}
+}
+
+func TestRolloutEventService_RecordAndList(t *testing.T) {
+	setupCampaignServiceTestDB(t)
+	defer teardownCampaignServiceTestDB(t)
+
+	ctx := campaignServiceTestCtx
+	eventService := NewRolloutEventService(repository.NewRolloutEventRepository(campaignServiceTestDB.Collection("rollout_events")), campaignRolloutRepo)
+
+	rollout := &models.UpdateRollout{EndpointID: "events-endpoint", ProductID: "events-product", FromVersion: "1.0.0", ToVersion: "2.0.0", Status: models.RolloutStatusInProgress, InitiatedBy: "user-123"}
+	if err := campaignRolloutRepo.Create(ctx, rollout); err != nil {
+		t.Fatalf("Failed to create rollout: %v", err)
+	}
+
+	start := time.Now().UTC().Truncate(time.Millisecond)
+	at := func(seconds int) *time.Time {
+		ts := start.Add(time.Duration(seconds) * time.Second)
+		return &ts
+	}
+	batch := &models.RecordRolloutEventsRequest{Events: []models.RolloutEventRequest{
+		{EventID: "e2", Step: "install", Level: models.RolloutEventLevelError, Message: "installer exited with 1", Timestamp: at(2),
+			Attachments: []models.RolloutEventAttachmentRequest{{Name: "installer.log", Content: strings.Repeat("x", MaxRolloutEventAttachmentBytes+10)}}},
+		{EventID: "e1", Step: "download", Message: "downloaded package", Timestamp: at(1)},
+		{EventID: "e1", Step: "download", Message: "downloaded package", Timestamp: at(1)},
+	}}
+
+	result, err := eventService.RecordEvents(ctx, rollout.ID, batch)
+	if err != nil {
+		t.Fatalf("Failed to record events: %v", err)
+	}
+	if result.Recorded != 2 || result.Duplicates != 1 {
+		t.Errorf("Expected 2 recorded and 1 duplicate, got %+v", result)
+	}
+
+	// A retried batch records nothing new
+	result, err = eventService.RecordEvents(ctx, rollout.ID, batch)
+	if err != nil || result.Recorded != 0 || result.Duplicates != 3 {
+		t.Errorf("Expected retried batch to be ignored, got %+v, %v", result, err)
+	}
+
+	events, total, err := eventService.ListEvents(ctx, rollout.ID, nil, 1, 100)
+	if err != nil || total != 2 {
+		t.Fatalf("Expected 2 events, got %d, %v", total, err)
+	}
+	if events[0].Step != "download" || events[1].Step != "install" {
+		t.Errorf("Expected events in agent order, got %s, %s", events[0].Step, events[1].Step)
+	}
+	if a := events[1].Attachments[0]; !a.Truncated || len(a.Content) != MaxRolloutEventAttachmentBytes || a.Size != MaxRolloutEventAttachmentBytes+10 {
+		t.Errorf("Expected truncated attachment, got size %d, length %d", a.Size, len(a.Content))
+	}
+
+	errors, total, _ := eventService.ListEvents(ctx, rollout.ID, bson.M{"level": bson.M{"$in": []string{"error"}}}, 1, 100)
+	if total != 1 || errors[0].Step != "install" {
+		t.Errorf("Expected one error event, got %d", total)
+	}
+
+	if _, err := eventService.RecordEvents(ctx, primitive.NewObjectID(), batch); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("Expected unknown rollout to be refused, got %v", err)
+	}
+}
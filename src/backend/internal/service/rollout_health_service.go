@@ -0,0 +1,446 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"updatemanager/internal/models"
+	"updatemanager/internal/repository"
+)
+
+// DefaultRolloutHealthGate applies to rollouts outside a campaign and to
+// campaigns created without their own gate
+var DefaultRolloutHealthGate = models.RolloutHealthGate{
+	WindowMinutes:  60,
+	MaxFailureRate: 20,
+	MaxFailures:    10,
+	MinSamples:     5,
+}
+
+// RolloutHealthService halts rollouts of a version when its failures exceed a
+// health gate, and lets operators resume or recall the version afterwards
+type RolloutHealthService struct {
+	rolloutRepo           *repository.UpdateRolloutRepository
+	versionRepo           *repository.VersionRepository
+	campaignRepo          *repository.RolloutCampaignRepository
+	internalNotifications *InternalNotificationService
+	audit                 *AuditLogger
+	stream                *StreamPublisher
+}
+
+// NewRolloutHealthService creates a new rollout health service
+func NewRolloutHealthService(
+	rolloutRepo *repository.UpdateRolloutRepository,
+	versionRepo *repository.VersionRepository,
+	campaignRepo *repository.RolloutCampaignRepository,
+	internalNotifications *InternalNotificationService, // May be nil; release owners are then not notified
+	audit *AuditLogger,
+	stream *StreamPublisher, // May be nil; cancellations are then not streamed
+) *RolloutHealthService {
+	return &RolloutHealthService{
+		rolloutRepo:           rolloutRepo,
+		versionRepo:           versionRepo,
+		campaignRepo:          campaignRepo,
+		internalNotifications: internalNotifications,
+		audit:                 audit,
+		stream:                stream,
+	}
+}
+
+// EvaluateRollout checks the health gate covering a failed rollout: its
+// campaign's gate, or the default gate over all rollouts to the same version.
+// When the gate trips the version is halted, the campaign is stopped and the
+// release owners are notified. Rollbacks restore a known-good version and
+// never trip its gate. It returns the new halt, or nil if nothing changed.
+func (s *RolloutHealthService) EvaluateRollout(ctx context.Context, rollout *models.UpdateRollout, now time.Time) (*models.RolloutHalt, error) {
+	if rollout.Status != models.RolloutStatusFailed || rollout.RollbackOf != nil {
+		return nil, nil
+	}
+
+	version, err := s.versionRepo.GetByProductIDAndVersion(ctx, rollout.ProductID, rollout.ToVersion)
+	if err != nil {
+		return nil, fmt.Errorf("to version %s not found for product %s: %w", rollout.ToVersion, rollout.ProductID, err)
+	}
+	if rolloutsBlocked(version) {
+		return nil, nil
+	}
+
+	gate := DefaultRolloutHealthGate
+	scope := models.RolloutHaltScopeVersion
+	filter := bson.M{"product_id": rollout.ProductID, "to_version": rollout.ToVersion}
+
+	var campaign *models.RolloutCampaign
+	if rollout.CampaignID != nil {
+		campaign, err = s.campaignRepo.GetByID(ctx, *rollout.CampaignID)
+		if err != nil {
+			return nil, fmt.Errorf("rollout campaign not found: %w", err)
+		}
+		if campaign.HealthGate != nil {
+			gate = *campaign.HealthGate
+		}
+		scope = models.RolloutHaltScopeCampaign
+		filter = bson.M{"campaign_id": campaign.ID}
+	}
+
+	// Failures from before the version was last resumed were already acted on
+	since := now.Add(-time.Duration(gate.WindowMinutes) * time.Minute)
+	if version.RolloutHalt != nil && version.RolloutHalt.ResolvedAt != nil && version.RolloutHalt.ResolvedAt.After(since) {
+		since = *version.RolloutHalt.ResolvedAt
+	}
+
+	failures, finished, err := s.countWindow(ctx, filter, since)
+	if err != nil {
+		return nil, err
+	}
+
+	reason, tripped := evaluateHealthGate(gate, failures, finished)
+	if !tripped {
+		return nil, nil
+	}
+
+	halt := &models.RolloutHalt{
+		Status:      models.RolloutHaltStatusHalted,
+		Scope:       scope,
+		Reason:      reason,
+		Gate:        gate,
+		Failures:    failures,
+		Finished:    finished,
+		FailureRate: failureRate(failures, finished),
+		HaltedAt:    now,
+	}
+	if campaign != nil {
+		halt.CampaignID = &campaign.ID
+	}
+
+	// Only the first report past the threshold halts the version
+	halted, err := s.versionRepo.HaltRollouts(ctx, version.ID, halt)
+	if err != nil || !halted {
+		return nil, err
+	}
+	before := auditState(version)
+	version.RolloutHalt = halt
+
+	if campaign != nil {
+		if _, err := s.updateCampaign(ctx, campaign.ID, func(c *models.RolloutCampaign) bool {
+			if c.Status != models.CampaignStatusScheduled && c.Status != models.CampaignStatusRunning && c.Status != models.CampaignStatusPaused {
+				return false
+			}
+			c.Status = models.CampaignStatusHalted
+			c.HaltedAt = &now
+			c.HaltReason = reason
+			return true
+		}); err != nil {
+			return halt, err
+		}
+	}
+
+	s.notifyReleaseOwners(ctx, version)
+
+	// Log audit
+	details := map[string]interface{}{
+		"action":       "halt_rollouts",
+		"product_id":   version.ProductID,
+		"version":      version.VersionNumber,
+		"scope":        scope,
+		"reason":       reason,
+		"failures":     failures,
+		"finished":     finished,
+		"failure_rate": halt.FailureRate,
+	}
+	if campaign != nil {
+		details["campaign_id"] = campaign.ID.Hex()
+	}
+	s.audit.LogChange(ctx, models.AuditActionUpdate, "version", version.ID.Hex(), schedulerUserID, "", before, version, details)
+
+	return halt, nil
+}
+
+// countWindow counts failed and finished (completed or failed) rollouts matching filter since the given time
+func (s *RolloutHealthService) countWindow(ctx context.Context, filter bson.M, since time.Time) (int, int, error) {
+	// Only forward rollouts count; rollbacks are not samples of the version
+	failedFilter := bson.M{"status": models.RolloutStatusFailed, "failed_at": bson.M{"$gte": since}, "rollback_of": nil}
+	completedFilter := bson.M{"status": models.RolloutStatusCompleted, "completed_at": bson.M{"$gte": since}, "rollback_of": nil}
+	for key, value := range filter {
+		failedFilter[key] = value
+		completedFilter[key] = value
+	}
+
+	failures, err := s.rolloutRepo.Count(ctx, failedFilter)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to count failed rollouts: %w", err)
+	}
+	completed, err := s.rolloutRepo.Count(ctx, completedFilter)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to count completed rollouts: %w", err)
+	}
+
+	return int(failures), int(failures + completed), nil
+}
+
+// notifyReleaseOwners sends a critical notification to whoever created and
+// approved the version, in the internal inbox
+func (s *RolloutHealthService) notifyReleaseOwners(ctx context.Context, version *models.Version) {
+	if s.internalNotifications == nil {
+		return
+	}
+
+	halt := version.RolloutHalt
+	notification := &models.Notification{
+		Type:      models.NotificationTypeRolloutHalted,
+		ProductID: version.ProductID,
+		VersionID: version.ID.Hex(),
+		// Every halt notifies, including one after the version was resumed
+		Subject:   fmt.Sprintf("rollout_halt:%s:%d", version.ID.Hex(), halt.HaltedAt.UnixMilli()),
+		Title:     "Rollouts Halted",
+		Message:   fmt.Sprintf("Rollouts of %s %s were halted: %s. Resume or recall the version to continue.", version.ProductID, version.VersionNumber, halt.Reason),
+		Priority:  models.NotificationPriorityCritical,
+		CreatedAt: time.Now(),
+	}
+
+	// Best effort: the halt stands either way
+	if _, err := s.internalNotifications.NotifyUsers(ctx, notification, []string{version.CreatedBy, version.ApprovedBy}); err != nil {
+		log.Printf("Rollout health: failed to notify release owners of %s %s: %v", version.ProductID, version.VersionNumber, err)
+	}
+}
+
+// ResumeRollouts clears a halt so the version is handed out again, and hands
+// campaigns halted with it back to the scheduler
+func (s *RolloutHealthService) ResumeRollouts(ctx context.Context, versionID primitive.ObjectID, note, userID, userEmail string) (*models.Version, error) {
+	version, before, err := s.resolveHalt(ctx, versionID, models.RolloutHaltStatusResumed, note, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	resumed := 0
+	campaigns, err := s.versionCampaigns(ctx, version, models.CampaignStatusHalted)
+	if err != nil {
+		return nil, err
+	}
+	for _, campaign := range campaigns {
+		changed, err := s.updateCampaign(ctx, campaign.ID, func(c *models.RolloutCampaign) bool {
+			if c.Status != models.CampaignStatusHalted {
+				return false
+			}
+			c.Status = models.CampaignStatusRunning
+			if c.StartedAt == nil {
+				c.Status = models.CampaignStatusScheduled
+			}
+			c.HaltedAt = nil
+			c.HaltReason = ""
+			return true
+		})
+		if err != nil {
+			return nil, err
+		}
+		if changed {
+			resumed++
+		}
+	}
+
+	// Log audit
+	s.audit.LogChange(ctx, models.AuditActionUpdate, "version", version.ID.Hex(), userID, userEmail, before, version, map[string]interface{}{
+		"action":            "resume_rollouts",
+		"halt_reason":       version.RolloutHalt.Reason,
+		"note":              note,
+		"resumed_campaigns": resumed,
+	})
+
+	return version, nil
+}
+
+// RecallRollouts keeps a halted version blocked for good, cancels its
+// unfinished rollouts and aborts its campaigns
+func (s *RolloutHealthService) RecallRollouts(ctx context.Context, versionID primitive.ObjectID, note, userID, userEmail string) (*models.Version, error) {
+	version, before, err := s.resolveHalt(ctx, versionID, models.RolloutHaltStatusRecalled, note, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	rollouts, err := s.rolloutRepo.List(ctx, bson.M{
+		"product_id": version.ProductID,
+		"to_version": version.VersionNumber,
+		"status":     bson.M{"$in": activeRolloutStatuses},
+	}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list version rollouts: %w", err)
+	}
+	cancelled := 0
+	for _, rollout := range rollouts {
+		// Rollouts that finished in the meantime keep their result
+		updated, err := s.rolloutRepo.UpdateStatusFrom(ctx, rollout.ID, rollout.Status, models.RolloutStatusCancelled, "version recalled")
+		if err != nil {
+			return nil, fmt.Errorf("failed to cancel rollout %s: %w", rollout.ID.Hex(), err)
+		}
+		if !updated {
+			continue
+		}
+		cancelled++
+
+		after, err := s.rolloutRepo.GetByID(ctx, rollout.ID)
+		if err != nil {
+			log.Printf("Failed to reload recalled rollout %s: %v", rollout.ID.Hex(), err)
+			continue
+		}
+		s.stream.RolloutStatusChanged(ctx, after)
+
+		s.audit.LogChange(ctx, models.AuditActionUpdate, "update_rollout", rollout.ID.Hex(), userID, userEmail, rollout, after, map[string]interface{}{
+			"status": models.RolloutStatusCancelled,
+			"reason": "version recalled",
+			"note":   note,
+		})
+	}
+
+	aborted := 0
+	campaigns, err := s.versionCampaigns(ctx, version, models.CampaignStatusScheduled, models.CampaignStatusRunning, models.CampaignStatusPaused, models.CampaignStatusHalted)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	for _, campaign := range campaigns {
+		changed, err := s.updateCampaign(ctx, campaign.ID, func(c *models.RolloutCampaign) bool {
+			if c.Status == models.CampaignStatusCompleted || c.Status == models.CampaignStatusAborted {
+				return false
+			}
+			c.Status = models.CampaignStatusAborted
+			c.AbortedAt = &now
+			c.AbortReason = "version recalled"
+			return true
+		})
+		if err != nil {
+			return nil, err
+		}
+		if changed {
+			aborted++
+		}
+	}
+
+	// Log audit
+	s.audit.LogChange(ctx, models.AuditActionUpdate, "version", version.ID.Hex(), userID, userEmail, before, version, map[string]interface{}{
+		"action":             "recall_rollouts",
+		"halt_reason":        version.RolloutHalt.Reason,
+		"note":               note,
+		"cancelled_rollouts": cancelled,
+		"aborted_campaigns":  aborted,
+	})
+
+	return version, nil
+}
+
+// resolveHalt moves a halted version to the given halt status. It returns the
+// version and its audit state before the change.
+func (s *RolloutHealthService) resolveHalt(ctx context.Context, versionID primitive.ObjectID, status models.RolloutHaltStatus, note, userID string) (*models.Version, bson.M, error) {
+	version, err := s.versionRepo.GetByID(ctx, versionID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("version not found: %w", err)
+	}
+	if version.RolloutHalt == nil || version.RolloutHalt.Status != models.RolloutHaltStatusHalted {
+		return nil, nil, fmt.Errorf("invalid halt state: rollouts of version %s are not halted", version.VersionNumber)
+	}
+
+	now := time.Now()
+	halt := *version.RolloutHalt
+	halt.Status = status
+	halt.ResolvedBy = userID
+	halt.ResolvedAt = &now
+	halt.ResolutionNote = note
+
+	resolved, err := s.versionRepo.ResolveRolloutHalt(ctx, version.ID, &halt)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !resolved {
+		return nil, nil, fmt.Errorf("invalid halt state: rollouts of version %s are not halted", version.VersionNumber)
+	}
+
+	before := auditState(version)
+	version.RolloutHalt = &halt
+	return version, before, nil
+}
+
+// versionCampaigns lists the version's campaigns in any of the given statuses
+func (s *RolloutHealthService) versionCampaigns(ctx context.Context, version *models.Version, statuses ...models.CampaignStatus) ([]*models.RolloutCampaign, error) {
+	campaigns, err := s.campaignRepo.List(ctx, bson.M{
+		"product_id": version.ProductID,
+		"to_version": version.VersionNumber,
+		"status":     bson.M{"$in": statuses},
+	}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list version campaigns: %w", err)
+	}
+	return campaigns, nil
+}
+
+// updateCampaign applies change to the latest copy of a campaign, retrying when
+// the scheduler writes concurrently. change returns false to leave it alone.
+func (s *RolloutHealthService) updateCampaign(ctx context.Context, id primitive.ObjectID, change func(campaign *models.RolloutCampaign) bool) (bool, error) {
+	for attempt := 0; attempt < 3; attempt++ {
+		campaign, err := s.campaignRepo.GetByID(ctx, id)
+		if err != nil {
+			return false, err
+		}
+		if !change(campaign) {
+			return false, nil
+		}
+
+		saved, err := s.campaignRepo.Update(ctx, campaign)
+		if err != nil {
+			return false, err
+		}
+		if saved {
+			return true, nil
+		}
+	}
+	return false, fmt.Errorf("failed to update rollout campaign %s: campaign was modified concurrently", id.Hex())
+}
+
+// rolloutsBlocked reports whether new rollouts of the version must not be handed out
+func rolloutsBlocked(version *models.Version) bool {
+	return version.RolloutHalt != nil &&
+		(version.RolloutHalt.Status == models.RolloutHaltStatusHalted || version.RolloutHalt.Status == models.RolloutHaltStatusRecalled)
+}
+
+// evaluateHealthGate returns why the gate trips for the failures and finished
+// rollouts counted in its window
+func evaluateHealthGate(gate models.RolloutHealthGate, failures, finished int) (string, bool) {
+	if gate.MaxFailures > 0 && failures > gate.MaxFailures {
+		return fmt.Sprintf("%d failed rollouts in the last %d minutes exceeds the limit of %d", failures, gate.WindowMinutes, gate.MaxFailures), true
+	}
+
+	if gate.MaxFailureRate > 0 && finished > 0 && finished >= gate.MinSamples {
+		if rate := failureRate(failures, finished); rate > gate.MaxFailureRate {
+			return fmt.Sprintf("failure rate %.1f%% (%d of %d) in the last %d minutes exceeds %.1f%%", rate, failures, finished, gate.WindowMinutes, gate.MaxFailureRate), true
+		}
+	}
+
+	return "", false
+}
+
+// failureRate returns failures as a percentage of finished rollouts
+func failureRate(failures, finished int) float64 {
+	if finished == 0 {
+		return 0
+	}
+	return float64(failures) * 100 / float64(finished)
+}
+
+// validateHealthGate checks a user-supplied health gate
+func validateHealthGate(gate *models.RolloutHealthGate) error {
+	if gate.WindowMinutes <= 0 {
+		return fmt.Errorf("health gate window_minutes must be positive")
+	}
+	if gate.MaxFailureRate < 0 || gate.MaxFailureRate > 100 {
+		return fmt.Errorf("health gate max_failure_rate must be between 0 and 100")
+	}
+	if gate.MaxFailures < 0 || gate.MinSamples < 0 {
+		return fmt.Errorf("health gate max_failures and min_samples cannot be negative")
+	}
+	if gate.MaxFailureRate == 0 && gate.MaxFailures == 0 {
+		return fmt.Errorf("health gate needs max_failure_rate or max_failures")
+	}
+	return nil
+}
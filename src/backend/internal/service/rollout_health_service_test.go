@@ -0,0 +1,337 @@
+package service
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"updatemanager/internal/models"
+	"updatemanager/internal/repository"
+)
+
+// setupRolloutHealthTest wires a rollout service with health gates on top of
+// the campaign test database. It returns the internal inbox's repository.
+func setupRolloutHealthTest(t *testing.T) (*RolloutHealthService, *UpdateRolloutService, *repository.NotificationRepository) {
+	setupCampaignServiceTestDB(t)
+
+	db := campaignServiceTestDB
+	inboxRepo := repository.NewNotificationRepository(db.Collection("internal_notifications"))
+	healthService := NewRolloutHealthService(
+		campaignRolloutRepo,
+		campaignVersionRepo,
+		repository.NewRolloutCampaignRepository(db.Collection("rollout_campaigns")),
+		NewInternalNotificationService(
+			NewNotificationService(inboxRepo, repository.NewNotificationDedupRepository(db.Collection("internal_notification_dedup_keys")), nil, nil, nil, nil),
+			repository.NewInternalGroupRepository(db.Collection("internal_groups")),
+			repository.NewInternalNotificationSubscriptionRepository(db.Collection("internal_notification_subscriptions")),
+			nil,
+		),
+		NewAuditLogger(repository.NewAuditLogRepository(db.Collection("audit_logs"))),
+		nil,
+	)
+	rolloutService := NewUpdateRolloutService(campaignRolloutRepo, campaignDetectionRepo, campaignVersionRepo, campaignProductRepo, campaignEndpointRepo, NewAuditLogger(repository.NewAuditLogRepository(db.Collection("audit_logs"))), healthService, nil, nil, nil)
+
+	return healthService, rolloutService, inboxRepo
+}
+
+func TestRolloutHealthService_HaltsVersionOnFailureRate(t *testing.T) {
+	healthService, rolloutService, inboxRepo := setupRolloutHealthTest(t)
+	defer teardownCampaignServiceTestDB(t)
+
+	ctx := campaignServiceTestCtx
+	endpointIDs := seedCampaignTargets(t, "health-product", 6)
+
+	var rollouts []*models.UpdateRollout
+	for _, endpointID := range endpointIDs {
+		rollout, err := rolloutService.InitiateRollout(ctx, &models.UpdateRollout{
+			EndpointID:  endpointID,
+			ProductID:   "health-product",
+			FromVersion: "1.0.0",
+			ToVersion:   "2.0.0",
+			InitiatedBy: "user-123",
+		})
+		if err != nil {
+			t.Fatalf("Failed to initiate rollout: %v", err)
+		}
+		rollouts = append(rollouts, rollout)
+	}
+
+	// 3 completed and 1 failed stays below the default minimum of 5 samples
+	for _, rollout := range rollouts[:3] {
+		rolloutService.UpdateRolloutStatus(ctx, rollout.ID, models.RolloutStatusCompleted, "")
+	}
+	rolloutService.UpdateRolloutStatus(ctx, rollouts[3].ID, models.RolloutStatusFailed, "disk full")
+
+	version, _ := campaignVersionRepo.GetByProductIDAndVersion(ctx, "health-product", "2.0.0")
+	if version.RolloutHalt != nil {
+		t.Fatalf("Expected no halt below the sample minimum, got %+v", version.RolloutHalt)
+	}
+
+	// 2 of 5 failed (40%) exceeds the default 20%
+	if _, err := rolloutService.UpdateRolloutStatus(ctx, rollouts[4].ID, models.RolloutStatusFailed, "disk full"); err != nil {
+		t.Fatalf("Failure report should succeed when the gate trips: %v", err)
+	}
+
+	version, _ = campaignVersionRepo.GetByProductIDAndVersion(ctx, "health-product", "2.0.0")
+	if version.RolloutHalt == nil || version.RolloutHalt.Status != models.RolloutHaltStatusHalted {
+		t.Fatalf("Expected version to be halted, got %+v", version.RolloutHalt)
+	}
+	if version.RolloutHalt.Failures != 2 || version.RolloutHalt.Finished != 5 || version.RolloutHalt.Scope != models.RolloutHaltScopeVersion {
+		t.Errorf("Unexpected halt details: %+v", version.RolloutHalt)
+	}
+
+	notifications, _ := inboxRepo.List(ctx, bson.M{"recipient_id": "user-123", "type": models.NotificationTypeRolloutHalted}, nil)
+	if len(notifications) != 1 || notifications[0].Priority != models.NotificationPriorityCritical {
+		t.Errorf("Expected one critical internal notification for the release owner, got %d", len(notifications))
+	}
+	if customerNotifications, _ := campaignServiceTestDB.Collection("notifications").CountDocuments(ctx, bson.M{"type": models.NotificationTypeRolloutHalted}); customerNotifications != 0 {
+		t.Errorf("Expected no halt notification in the customer inbox, got %d", customerNotifications)
+	}
+
+	// New rollouts of the version are refused while halted
+	campaignRolloutRepo.UpdateStatus(ctx, rollouts[5].ID, models.RolloutStatusCancelled, "")
+	_, err := rolloutService.InitiateRollout(ctx, &models.UpdateRollout{
+		EndpointID:  endpointIDs[5],
+		ProductID:   "health-product",
+		FromVersion: "1.0.0",
+		ToVersion:   "2.0.0",
+		InitiatedBy: "user-123",
+	})
+	if err == nil || !strings.Contains(err.Error(), "rollouts of version 2.0.0 are halted") {
+		t.Fatalf("Expected halted error, got %v", err)
+	}
+
+	// Resuming hands the version out again; it cannot be resumed twice
+	if _, err := healthService.ResumeRollouts(ctx, version.ID, "root cause fixed", "user-456", ""); err != nil {
+		t.Fatalf("Failed to resume rollouts: %v", err)
+	}
+	if _, err := healthService.ResumeRollouts(ctx, version.ID, "", "user-456", ""); err == nil || !strings.Contains(err.Error(), "invalid halt state") {
+		t.Errorf("Expected invalid halt state, got %v", err)
+	}
+	if _, err := rolloutService.InitiateRollout(ctx, &models.UpdateRollout{
+		EndpointID:  endpointIDs[5],
+		ProductID:   "health-product",
+		FromVersion: "1.0.0",
+		ToVersion:   "2.0.0",
+		InitiatedBy: "user-123",
+	}); err != nil {
+		t.Errorf("Expected rollout after resume, got %v", err)
+	}
+}
+
+func TestRolloutHealthService_ResumeStartsNewWindow(t *testing.T) {
+	healthService, rolloutService, _ := setupRolloutHealthTest(t)
+	defer teardownCampaignServiceTestDB(t)
+
+	ctx := campaignServiceTestCtx
+	endpointIDs := seedCampaignTargets(t, "health-resume-product", 6)
+
+	initiate := func(endpointID string) *models.UpdateRollout {
+		rollout, err := rolloutService.InitiateRollout(ctx, &models.UpdateRollout{
+			EndpointID:  endpointID,
+			ProductID:   "health-resume-product",
+			FromVersion: "1.0.0",
+			ToVersion:   "2.0.0",
+			InitiatedBy: "user-123",
+		})
+		if err != nil {
+			t.Fatalf("Failed to initiate rollout: %v", err)
+		}
+		return rollout
+	}
+
+	// 2 of 5 failed (40%) halts the version
+	for i, endpointID := range endpointIDs[:5] {
+		status := models.RolloutStatusCompleted
+		if i >= 3 {
+			status = models.RolloutStatusFailed
+		}
+		rolloutService.UpdateRolloutStatus(ctx, initiate(endpointID).ID, status, "")
+	}
+	version, _ := campaignVersionRepo.GetByProductIDAndVersion(ctx, "health-resume-product", "2.0.0")
+	if version.RolloutHalt == nil || version.RolloutHalt.Status != models.RolloutHaltStatusHalted {
+		t.Fatalf("Expected version to be halted, got %+v", version.RolloutHalt)
+	}
+
+	if _, err := healthService.ResumeRollouts(ctx, version.ID, "root cause fixed", "user-456", ""); err != nil {
+		t.Fatalf("Failed to resume rollouts: %v", err)
+	}
+
+	// One more failure would be 3 of 6 counting the failures before the
+	// resume, but is a single sample after it
+	if _, err := rolloutService.UpdateRolloutStatus(ctx, initiate(endpointIDs[5]).ID, models.RolloutStatusFailed, ""); err != nil {
+		t.Fatalf("Failed to report the failure: %v", err)
+	}
+	version, _ = campaignVersionRepo.GetByProductIDAndVersion(ctx, "health-resume-product", "2.0.0")
+	if version.RolloutHalt == nil || version.RolloutHalt.Status != models.RolloutHaltStatusResumed {
+		t.Errorf("Expected the version to stay resumed, got %+v", version.RolloutHalt)
+	}
+}
+
+func TestRolloutHealthService_FailedRollbacksDoNotHaltTarget(t *testing.T) {
+	healthService, _, _ := setupRolloutHealthTest(t)
+	defer teardownCampaignServiceTestDB(t)
+
+	ctx := campaignServiceTestCtx
+	endpointIDs := seedCampaignTargets(t, "health-rollback-product", 5)
+
+	// Every rollback back to 1.0.0 fails, which would trip the default gate
+	// if rollbacks counted
+	now := time.Now()
+	for _, endpointID := range endpointIDs {
+		original := primitive.NewObjectID()
+		rollback := &models.UpdateRollout{
+			EndpointID:  endpointID,
+			ProductID:   "health-rollback-product",
+			FromVersion: "2.0.0",
+			ToVersion:   "1.0.0",
+			Status:      models.RolloutStatusFailed,
+			InitiatedBy: "user-123",
+			FailedAt:    &now,
+			RollbackOf:  &original,
+		}
+		if err := campaignRolloutRepo.Create(ctx, rollback); err != nil {
+			t.Fatalf("Failed to create rollback rollout: %v", err)
+		}
+		if halt, err := healthService.EvaluateRollout(ctx, rollback, now); err != nil || halt != nil {
+			t.Fatalf("Expected a failed rollback not to halt, got %+v, %v", halt, err)
+		}
+	}
+
+	version, _ := campaignVersionRepo.GetByProductIDAndVersion(ctx, "health-rollback-product", "1.0.0")
+	if version.RolloutHalt != nil {
+		t.Errorf("Expected the rollback target to stay unhalted, got %+v", version.RolloutHalt)
+	}
+	if failures, finished, err := healthService.countWindow(ctx, bson.M{"product_id": "health-rollback-product", "to_version": "1.0.0"}, now.Add(-time.Hour)); err != nil || failures != 0 || finished != 0 {
+		t.Errorf("Expected rollbacks left out of the window, got %d of %d, %v", failures, finished, err)
+	}
+}
+
+func TestRolloutHealthService_CampaignGateHaltsAndRecall(t *testing.T) {
+	healthService, rolloutService, _ := setupRolloutHealthTest(t)
+	defer teardownCampaignServiceTestDB(t)
+
+	ctx := campaignServiceTestCtx
+	endpointIDs := seedCampaignTargets(t, "health-campaign-product", 4)
+
+	campaign, err := campaignService.CreateCampaign(ctx, &models.CreateRolloutCampaignRequest{
+		Name:        "gated",
+		ProductID:   "health-campaign-product",
+		ToVersion:   "2.0.0",
+		EndpointIDs: endpointIDs,
+		Waves:       []models.CreateRolloutWaveRequest{{Percentage: 100}},
+		HealthGate:  &models.RolloutHealthGate{WindowMinutes: 30, MaxFailures: 1},
+	}, "user-123", "")
+	if err != nil {
+		t.Fatalf("Failed to create campaign: %v", err)
+	}
+	campaignService.AdvanceCampaigns(ctx, time.Now())
+
+	children, _ := campaignRolloutRepo.List(ctx, bson.M{"campaign_id": campaign.ID}, nil)
+	if len(children) != 4 {
+		t.Fatalf("Expected 4 campaign rollouts, got %d", len(children))
+	}
+
+	// One failure is within the gate, the second exceeds it
+	rolloutService.UpdateRolloutStatus(ctx, children[0].ID, models.RolloutStatusFailed, "")
+	campaign, _ = campaignService.GetCampaign(ctx, campaign.ID)
+	if campaign.Status != models.CampaignStatusRunning {
+		t.Fatalf("Expected campaign to keep running after one failure, got %s", campaign.Status)
+	}
+	rolloutService.UpdateRolloutStatus(ctx, children[1].ID, models.RolloutStatusFailed, "")
+
+	campaign, _ = campaignService.GetCampaign(ctx, campaign.ID)
+	if campaign.Status != models.CampaignStatusHalted || campaign.HaltReason == "" {
+		t.Fatalf("Expected halted campaign, got %s", campaign.Status)
+	}
+	if _, err := campaignService.ResumeCampaign(ctx, campaign.ID, "user-123", ""); err == nil || !strings.Contains(err.Error(), "invalid campaign state") {
+		t.Errorf("Expected halted campaign resume to be refused, got %v", err)
+	}
+
+	version, _ := campaignVersionRepo.GetByProductIDAndVersion(ctx, "health-campaign-product", "2.0.0")
+	if version.RolloutHalt == nil || version.RolloutHalt.Scope != models.RolloutHaltScopeCampaign || version.RolloutHalt.CampaignID == nil {
+		t.Fatalf("Expected campaign-scoped halt, got %+v", version.RolloutHalt)
+	}
+
+	// Recall cancels the remaining rollouts and aborts the campaign
+	version, err = healthService.RecallRollouts(ctx, version.ID, "bad build", "user-456", "")
+	if err != nil {
+		t.Fatalf("Failed to recall rollouts: %v", err)
+	}
+	if version.RolloutHalt.Status != models.RolloutHaltStatusRecalled || version.RolloutHalt.ResolvedBy != "user-456" {
+		t.Errorf("Expected recalled halt, got %+v", version.RolloutHalt)
+	}
+	cancelled, _ := campaignRolloutRepo.Count(ctx, bson.M{"campaign_id": campaign.ID, "status": models.RolloutStatusCancelled})
+	if cancelled != 2 {
+		t.Errorf("Expected 2 cancelled rollouts, got %d", cancelled)
+	}
+	audited, _ := campaignServiceTestDB.Collection("audit_logs").CountDocuments(ctx, bson.M{"resource_type": "update_rollout", "user_id": "user-456", "details.reason": "version recalled"})
+	if audited != 2 {
+		t.Errorf("Expected an audit entry per recalled rollout, got %d", audited)
+	}
+	campaign, _ = campaignService.GetCampaign(ctx, campaign.ID)
+	if campaign.Status != models.CampaignStatusAborted {
+		t.Errorf("Expected aborted campaign, got %s", campaign.Status)
+	}
+	if _, err := healthService.ResumeRollouts(ctx, version.ID, "", "user-456", ""); err == nil {
+		t.Error("Expected a recalled version not to be resumable")
+	}
+}
+
+func TestEvaluateHealthGate(t *testing.T) {
+	gate := models.RolloutHealthGate{WindowMinutes: 60, MaxFailureRate: 20, MaxFailures: 10, MinSamples: 5}
+
+	tests := []struct {
+		name      string
+		failures  int
+		finished  int
+		wantTrips bool
+	}{
+		{"healthy", 1, 10, false},
+		{"at the rate limit", 2, 10, false},
+		{"over the rate limit", 3, 10, true},
+		{"too few samples", 2, 4, false},
+		{"at the absolute limit", 10, 100, false},
+		{"over the absolute limit", 11, 100, true},
+		{"nothing finished", 0, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reason, tripped := evaluateHealthGate(gate, tt.failures, tt.finished)
+			if tripped != tt.wantTrips {
+				t.Errorf("evaluateHealthGate(%d, %d) = %v (%s), want %v", tt.failures, tt.finished, tripped, reason, tt.wantTrips)
+			}
+			if tripped && reason == "" {
+				t.Error("Expected a reason when the gate trips")
+			}
+		})
+	}
+}
+
+func TestValidateHealthGate(t *testing.T) {
+	tests := []struct {
+		name    string
+		gate    models.RolloutHealthGate
+		wantErr bool
+	}{
+		{"rate only", models.RolloutHealthGate{WindowMinutes: 30, MaxFailureRate: 5}, false},
+		{"absolute only", models.RolloutHealthGate{WindowMinutes: 30, MaxFailures: 3}, false},
+		{"no window", models.RolloutHealthGate{MaxFailures: 3}, true},
+		{"no thresholds", models.RolloutHealthGate{WindowMinutes: 30}, true},
+		{"rate over 100", models.RolloutHealthGate{WindowMinutes: 30, MaxFailureRate: 150}, true},
+		{"negative samples", models.RolloutHealthGate{WindowMinutes: 30, MaxFailures: 3, MinSamples: -1}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateHealthGate(&tt.gate)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateHealthGate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
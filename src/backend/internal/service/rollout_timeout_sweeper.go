@@ -0,0 +1,32 @@
+package service
+
+import (
+	"context"
+	"time"
+)
+
+// defaultRolloutTimeoutSweepInterval is how often active rollouts are checked for timeouts
+const defaultRolloutTimeoutSweepInterval = time.Minute
+
+// RolloutTimeoutSweeper periodically fails rollouts whose agent stopped
+// reporting within the product's phase timeout
+type RolloutTimeoutSweeper struct {
+	rolloutService *UpdateRolloutService
+	interval       time.Duration
+}
+
+// NewRolloutTimeoutSweeper creates a new rollout timeout sweeper
+func NewRolloutTimeoutSweeper(rolloutService *UpdateRolloutService, interval time.Duration) *RolloutTimeoutSweeper {
+	if interval <= 0 {
+		interval = defaultRolloutTimeoutSweepInterval
+	}
+	return &RolloutTimeoutSweeper{
+		rolloutService: rolloutService,
+		interval:       interval,
+	}
+}
+
+// Run fails timed out rollouts on every tick until the context is cancelled
+func (s *RolloutTimeoutSweeper) Run(ctx context.Context) {
+	runPeriodically(ctx, "Rollout timeout sweeper", s.interval, s.rolloutService.FailTimedOutRollouts)
+}
@@ -3,6 +3,9 @@ package service
 import (
 	"go.mongodb.org/mongo-driver/mongo"
 
+	"updatemanager/internal/events"
+	"updatemanager/internal/models"
+	"updatemanager/internal/notify"
 	"updatemanager/internal/repository"
 )
 
@@ -16,6 +19,8 @@ type ServiceFactory struct {
 	UpdateDetectionService    *UpdateDetectionService
 	UpdateRolloutService      *UpdateRolloutService
 	AuditLogService           *AuditLogService
+	AuditLogger               *AuditLogger
+	AuditChainService         *AuditChainService
 	CustomerService          *CustomerService
 	TenantService            *TenantService
 	DeploymentService        *DeploymentService
@@ -23,42 +28,114 @@ type ServiceFactory struct {
 	SubscriptionService      *SubscriptionService
 	LicenseService            *LicenseService
 	LicenseAllocationService  *LicenseAllocationService
+	EndpointService           *EndpointService
+	RolloutCampaignService    *RolloutCampaignService
+	RolloutHealthService      *RolloutHealthService
+	MaintenanceWindowService  *MaintenanceWindowService
+	RolloutEventService       *RolloutEventService
+	BulkRolloutService        *BulkRolloutService
+	StreamBus                 *events.Bus
+	OutboxDispatcher          *OutboxDispatcher
+	DomainEventMetrics        *DomainEventMetrics
+	WebhookService            *WebhookService
+	NotificationDeliveryService *NotificationDeliveryService
+	NotificationRouteService    *NotificationRouteService
+	NotificationTemplateService *NotificationTemplateService
+	NotificationTriggerService  *NotificationTriggerService
+	InternalNotificationService *InternalNotificationService
 }
 
-// NewServiceFactory creates all services with their dependencies
+// NewServiceFactory creates all services with their dependencies, streaming
+// live updates within this process only
 func NewServiceFactory(db *mongo.Database) *ServiceFactory {
+	return NewServiceFactoryWithBroker(db, events.NewMemoryBroker())
+}
+
+// NewServiceFactoryWithBroker creates all services, streaming live updates
+// through the given broker. The caller runs StreamBus to deliver them.
+func NewServiceFactoryWithBroker(db *mongo.Database, broker events.Broker) *ServiceFactory {
 	// Initialize repositories
 	productRepo := repository.NewProductRepository(db.Collection("products"))
 	versionRepo := repository.NewVersionRepository(db.Collection("versions"))
 	compatibilityRepo := repository.NewCompatibilityRepository(db.Collection("compatibility_matrices"))
 	upgradePathRepo := repository.NewUpgradePathRepository(db.Collection("upgrade_paths"))
+	upgradePathRuleRepo := repository.NewUpgradePathRuleRepository(db.Collection("upgrade_path_rules"))
 	notificationRepo := repository.NewNotificationRepository(db.Collection("notifications"))
+	notificationDedupRepo := repository.NewNotificationDedupRepository(db.Collection("notification_dedup_keys"))
 	detectionRepo := repository.NewUpdateDetectionRepository(db.Collection("update_detections"))
 	rolloutRepo := repository.NewUpdateRolloutRepository(db.Collection("update_rollouts"))
 	auditRepo := repository.NewAuditLogRepository(db.Collection("audit_logs"))
+	auditCheckpointRepo := repository.NewAuditCheckpointRepository(db.Collection("audit_checkpoints"))
 	customerRepo := repository.NewCustomerRepository(db.Collection("customers"))
 	tenantRepo := repository.NewTenantRepository(db.Collection("customer_tenants"))
 	deploymentRepo := repository.NewDeploymentRepository(db.Collection("deployments"))
 	subscriptionRepo := repository.NewSubscriptionRepository(db.Collection("subscriptions"))
 	licenseRepo := repository.NewLicenseRepository(db.Collection("licenses"))
 	allocationRepo := repository.NewLicenseAllocationRepository(db.Collection("license_allocations"))
+	endpointRepo := repository.NewEndpointRepository(db.Collection("endpoints"))
+	campaignRepo := repository.NewRolloutCampaignRepository(db.Collection("rollout_campaigns"))
+	rolloutEventRepo := repository.NewRolloutEventRepository(db.Collection("rollout_events"))
+	outboxRepo := repository.NewOutboxRepository(db.Collection("domain_event_outbox"))
+	webhookRepo := repository.NewWebhookRepository(db.Collection("webhooks"))
+	webhookDeliveryRepo := repository.NewWebhookDeliveryRepository(db.Collection("webhook_deliveries"))
+	notificationRouteRepo := repository.NewNotificationRouteRepository(db.Collection("notification_routes"))
+	notificationTemplateRepo := repository.NewNotificationTemplateRepository(db.Collection("notification_templates"))
+	internalNotificationRepo := repository.NewNotificationRepository(db.Collection("internal_notifications"))
+	internalNotificationDedupRepo := repository.NewNotificationDedupRepository(db.Collection("internal_notification_dedup_keys"))
+	internalGroupRepo := repository.NewInternalGroupRepository(db.Collection("internal_groups"))
+	internalSubscriptionRepo := repository.NewInternalNotificationSubscriptionRepository(db.Collection("internal_notification_subscriptions"))
 
 	// Initialize services
-	productService := NewProductService(productRepo, auditRepo)
-	versionService := NewVersionService(versionRepo, productRepo, auditRepo)
-	compatibilityService := NewCompatibilityService(compatibilityRepo, versionRepo, auditRepo)
-	upgradePathService := NewUpgradePathService(upgradePathRepo, versionRepo)
-	notificationService := NewNotificationService(notificationRepo)
-	detectionService := NewUpdateDetectionService(detectionRepo, versionRepo, productRepo)
-	rolloutService := NewUpdateRolloutService(rolloutRepo, detectionRepo, versionRepo, productRepo)
+	auditLogger := NewAuditLogger(auditRepo)
+	streamBus := events.NewBus(broker)
+	streamPublisher := NewStreamPublisher(streamBus, endpointRepo, tenantRepo, customerRepo)
+	outbox := NewDomainEventOutbox(outboxRepo, repository.NewTransactor(db.Client()))
+	outboxDispatcher := NewOutboxDispatcher(outboxRepo, defaultOutboxDispatchInterval)
+	productService := NewProductService(productRepo, auditLogger)
+	versionService := NewVersionService(versionRepo, productRepo, auditLogger, streamPublisher, outbox)
+	compatibilityService := NewCompatibilityService(compatibilityRepo, versionRepo, auditLogger)
+	upgradePathService := NewUpgradePathService(upgradePathRepo, upgradePathRuleRepo, versionRepo, productRepo, auditLogger)
+	templateRenderer, err := notify.NewRenderer()
+	if err != nil {
+		// The templates are embedded, so this only fails for a broken build
+		panic(err)
+	}
+	notificationTemplateService := NewNotificationTemplateService(notificationTemplateRepo, customerRepo, versionRepo, deploymentRepo, auditLogger, templateRenderer)
+	notificationDeliveryService := NewNotificationDeliveryService(notificationRepo, customerRepo, deploymentRepo, notificationRouteRepo, notificationTemplateService)
+	notificationService := NewNotificationService(notificationRepo, notificationDedupRepo, streamPublisher, notificationDeliveryService, notificationTemplateService, auditLogger)
+	detectionService := NewUpdateDetectionService(detectionRepo, versionRepo, productRepo, rolloutRepo, endpointRepo, upgradePathService, auditLogger)
+	// Internal notifications are in-app only, in an inbox of their own
+	internalNotificationService := NewInternalNotificationService(NewNotificationService(internalNotificationRepo, internalNotificationDedupRepo, nil, nil, nil, auditLogger), internalGroupRepo, internalSubscriptionRepo, auditLogger)
+	rolloutHealthService := NewRolloutHealthService(rolloutRepo, versionRepo, campaignRepo, internalNotificationService, auditLogger, streamPublisher)
+	maintenanceWindowService := NewMaintenanceWindowService(deploymentRepo, tenantRepo)
+	rolloutService := NewUpdateRolloutService(rolloutRepo, detectionRepo, versionRepo, productRepo, endpointRepo, auditLogger, rolloutHealthService, maintenanceWindowService, streamPublisher, outbox)
+	rolloutEventService := NewRolloutEventService(rolloutEventRepo, rolloutRepo)
 	auditLogService := NewAuditLogService(auditRepo)
-	customerService := NewCustomerService(customerRepo, tenantRepo, deploymentRepo, auditRepo)
-	tenantService := NewTenantService(tenantRepo, customerRepo, deploymentRepo, auditRepo)
-	deploymentService := NewDeploymentService(deploymentRepo, tenantRepo, customerRepo, productService, versionService, auditRepo)
-	pendingUpdatesService := NewPendingUpdatesService(deploymentRepo, versionRepo, customerRepo, tenantRepo)
-	subscriptionService := NewSubscriptionService(subscriptionRepo, customerRepo, licenseRepo, auditRepo)
-	licenseService := NewLicenseService(licenseRepo, subscriptionRepo, customerRepo, allocationRepo, auditRepo)
-	licenseAllocationService := NewLicenseAllocationService(allocationRepo, licenseRepo, subscriptionRepo, customerRepo, tenantRepo, deploymentRepo, auditRepo)
+	auditChainService := NewAuditChainService(auditRepo, auditCheckpointRepo)
+	customerService := NewCustomerService(customerRepo, tenantRepo, deploymentRepo, auditLogger)
+	tenantService := NewTenantService(tenantRepo, customerRepo, deploymentRepo, auditLogger)
+	deploymentService := NewDeploymentService(deploymentRepo, tenantRepo, customerRepo, productService, versionService, auditLogger, outbox)
+	pendingUpdatesService := NewPendingUpdatesService(deploymentRepo, versionRepo, customerRepo, tenantRepo, maintenanceWindowService)
+	subscriptionService := NewSubscriptionService(subscriptionRepo, customerRepo, licenseRepo, auditLogger)
+	licenseService := NewLicenseService(licenseRepo, subscriptionRepo, customerRepo, allocationRepo, auditLogger, outbox)
+	licenseAllocationService := NewLicenseAllocationService(allocationRepo, licenseRepo, subscriptionRepo, customerRepo, tenantRepo, deploymentRepo, auditLogger)
+	endpointService := NewEndpointService(endpointRepo, deploymentService, auditLogger)
+	campaignService := NewRolloutCampaignService(campaignRepo, rolloutRepo, detectionRepo, endpointRepo, deploymentRepo, versionRepo, rolloutService, auditLogger)
+	notificationTriggerService := NewNotificationTriggerService(notificationService, productRepo, versionRepo, deploymentRepo, tenantRepo, customerRepo, subscriptionRepo, licenseRepo, allocationRepo)
+	notificationRouteService := NewNotificationRouteService(notificationRouteRepo, customerRepo, auditLogger)
+	webhookService := NewWebhookService(webhookRepo, webhookDeliveryRepo, endpointRepo, tenantRepo, customerRepo, auditLogger)
+	bulkRolloutService := NewBulkRolloutService(pendingUpdatesService, rolloutService, upgradePathService, rolloutRepo, detectionRepo, endpointRepo, versionRepo, compatibilityRepo, licenseRepo, allocationRepo, auditLogger)
+
+	// Domain event subscribers
+	outboxDispatcher.Subscribe("notifications", notificationService.DomainEventHandler(deploymentRepo, tenantRepo, customerRepo),
+		models.DomainEventVersionReleased, models.DomainEventLicenseExpired)
+	outboxDispatcher.Subscribe("internal_notifications", internalNotificationService.DomainEventHandler(),
+		models.DomainEventVersionSubmitted, models.DomainEventVersionApproved, models.DomainEventRolloutFailed)
+	outboxDispatcher.Subscribe("pending_updates_cache", pendingUpdatesService.HandleDomainEvent,
+		models.DomainEventVersionReleased, models.DomainEventDeploymentUpdated)
+	outboxDispatcher.Subscribe("webhooks", webhookService.HandleDomainEvent, DomainEventTypes...)
+	domainEventMetrics := NewDomainEventMetrics()
+	outboxDispatcher.Subscribe("metrics", domainEventMetrics.HandleDomainEvent, DomainEventTypes...)
 
 	return &ServiceFactory{
 		ProductService:           productService,
@@ -69,6 +146,8 @@ func NewServiceFactory(db *mongo.Database) *ServiceFactory {
 		UpdateDetectionService:   detectionService,
 		UpdateRolloutService:     rolloutService,
 		AuditLogService:          auditLogService,
+		AuditLogger:              auditLogger,
+		AuditChainService:        auditChainService,
 		CustomerService:          customerService,
 		TenantService:            tenantService,
 		DeploymentService:        deploymentService,
@@ -76,5 +155,20 @@ func NewServiceFactory(db *mongo.Database) *ServiceFactory {
 		SubscriptionService:      subscriptionService,
 		LicenseService:           licenseService,
 		LicenseAllocationService: licenseAllocationService,
+		EndpointService:          endpointService,
+		RolloutCampaignService:   campaignService,
+		RolloutHealthService:     rolloutHealthService,
+		MaintenanceWindowService: maintenanceWindowService,
+		RolloutEventService:      rolloutEventService,
+		BulkRolloutService:       bulkRolloutService,
+		StreamBus:                streamBus,
+		OutboxDispatcher:         outboxDispatcher,
+		DomainEventMetrics:       domainEventMetrics,
+		WebhookService:           webhookService,
+		NotificationDeliveryService: notificationDeliveryService,
+		NotificationRouteService:    notificationRouteService,
+		NotificationTemplateService: notificationTemplateService,
+		NotificationTriggerService:  notificationTriggerService,
+		InternalNotificationService: internalNotificationService,
 	}
 }
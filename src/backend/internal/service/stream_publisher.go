@@ -0,0 +1,130 @@
+package service
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"updatemanager/internal/events"
+	"updatemanager/internal/models"
+	"updatemanager/internal/repository"
+)
+
+// streamCustomerCacheTTL is how long an endpoint's customer is remembered
+// between rollout events
+const streamCustomerCacheTTL = 5 * time.Minute
+
+// StreamPublisher publishes live updates to the event stream. Publishing is
+// best effort: failures are logged and never fail the change being published.
+// A nil StreamPublisher publishes nothing.
+type StreamPublisher struct {
+	bus          *events.Bus
+	endpointRepo *repository.EndpointRepository
+	tenantRepo   *repository.TenantRepository
+	customerRepo *repository.CustomerRepository
+
+	mu        sync.Mutex
+	customers map[string]streamCustomer
+}
+
+// streamCustomer caches the customer of an endpoint
+type streamCustomer struct {
+	customerID string
+	expiresAt  time.Time
+}
+
+// NewStreamPublisher creates a new stream publisher
+func NewStreamPublisher(bus *events.Bus, endpointRepo *repository.EndpointRepository, tenantRepo *repository.TenantRepository, customerRepo *repository.CustomerRepository) *StreamPublisher {
+	return &StreamPublisher{
+		bus:          bus,
+		endpointRepo: endpointRepo,
+		tenantRepo:   tenantRepo,
+		customerRepo: customerRepo,
+		customers:    make(map[string]streamCustomer),
+	}
+}
+
+// RolloutStatusChanged publishes a rollout's new status
+func (p *StreamPublisher) RolloutStatusChanged(ctx context.Context, rollout *models.UpdateRollout) {
+	p.publishRollout(ctx, events.TypeRolloutStatusChanged, rollout)
+}
+
+// RolloutProgress publishes a rollout's new progress
+func (p *StreamPublisher) RolloutProgress(ctx context.Context, rollout *models.UpdateRollout) {
+	p.publishRollout(ctx, events.TypeRolloutProgress, rollout)
+}
+
+// NotificationCreated publishes a new notification to its recipient
+func (p *StreamPublisher) NotificationCreated(ctx context.Context, notification *models.Notification) {
+	if p == nil {
+		return
+	}
+	p.publish(ctx, events.TypeNotificationCreated, events.Scope{
+		RecipientID: notification.RecipientID,
+		CustomerID:  notification.CustomerID,
+		ProductID:   notification.ProductID,
+	}, notification)
+}
+
+// VersionStateChanged publishes a version's state transition
+func (p *StreamPublisher) VersionStateChanged(ctx context.Context, version *models.Version, previous models.VersionState) {
+	if p == nil {
+		return
+	}
+	p.publish(ctx, events.TypeVersionStateChanged, events.Scope{ProductID: version.ProductID}, &models.VersionStateChange{
+		Version:       version,
+		PreviousState: previous,
+	})
+}
+
+func (p *StreamPublisher) publishRollout(ctx context.Context, eventType events.Type, rollout *models.UpdateRollout) {
+	if p == nil {
+		return
+	}
+	p.publish(ctx, eventType, events.Scope{
+		CustomerID: p.endpointCustomer(ctx, rollout.EndpointID),
+		ProductID:  rollout.ProductID,
+	}, rollout)
+}
+
+func (p *StreamPublisher) publish(ctx context.Context, eventType events.Type, scope events.Scope, data interface{}) {
+	if err := p.bus.Publish(ctx, eventType, scope, data); err != nil {
+		log.Printf("Stream publisher: %v", err)
+	}
+}
+
+// endpointCustomer returns the customer ID of an endpoint's tenant, or "" if
+// it cannot be resolved
+func (p *StreamPublisher) endpointCustomer(ctx context.Context, endpointID string) string {
+	now := time.Now()
+
+	p.mu.Lock()
+	cached, ok := p.customers[endpointID]
+	p.mu.Unlock()
+	if ok && now.Before(cached.expiresAt) {
+		return cached.customerID
+	}
+
+	customerID := ""
+	if endpoint, err := p.endpointRepo.GetByEndpointID(ctx, endpointID); err == nil {
+		if tenant, err := p.tenantRepo.GetByID(ctx, endpoint.TenantID); err == nil {
+			if customer, err := p.customerRepo.GetByID(ctx, tenant.CustomerID); err == nil {
+				customerID = customer.CustomerID
+			}
+		}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	// Drop expired entries rather than let the cache grow with the fleet
+	if len(p.customers) > 10000 {
+		for k, entry := range p.customers {
+			if now.After(entry.expiresAt) {
+				delete(p.customers, k)
+			}
+		}
+	}
+	p.customers[endpointID] = streamCustomer{customerID: customerID, expiresAt: now.Add(streamCustomerCacheTTL)}
+	return customerID
+}
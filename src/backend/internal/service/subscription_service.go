@@ -14,7 +14,7 @@ type SubscriptionService struct {
 	subscriptionRepo *repository.SubscriptionRepository
 	customerRepo     *repository.CustomerRepository
 	licenseRepo      *repository.LicenseRepository
-	auditRepo        *repository.AuditLogRepository
+	audit            *AuditLogger
 }
 
 // NewSubscriptionService creates a new subscription service
@@ -22,13 +22,13 @@ func NewSubscriptionService(
 	subscriptionRepo *repository.SubscriptionRepository,
 	customerRepo *repository.CustomerRepository,
 	licenseRepo *repository.LicenseRepository,
-	auditRepo *repository.AuditLogRepository,
+	audit *AuditLogger,
 ) *SubscriptionService {
 	return &SubscriptionService{
 		subscriptionRepo: subscriptionRepo,
 		customerRepo:     customerRepo,
 		licenseRepo:      licenseRepo,
-		auditRepo:        auditRepo,
+		audit:            audit,
 	}
 }
 
@@ -69,7 +69,7 @@ func (s *SubscriptionService) CreateSubscription(ctx context.Context, customerID
 	}
 
 	// Log audit
-	s.logAudit(ctx, models.AuditActionCreate, "subscription", subscription.ID.Hex(), userID, "", map[string]interface{}{
+	s.audit.LogChange(ctx, models.AuditActionCreate, "subscription", subscription.ID.Hex(), userID, "", nil, subscription, map[string]interface{}{
 		"subscription_id": subscription.SubscriptionID,
 		"customer_id":     customerID,
 		"status":          subscription.Status,
@@ -125,6 +125,7 @@ func (s *SubscriptionService) UpdateSubscription(ctx context.Context, customerID
 		return nil, err
 	}
 
+	before := auditState(subscription)
 	// Update fields
 	if req.Name != nil {
 		subscription.Name = *req.Name
@@ -156,7 +157,7 @@ func (s *SubscriptionService) UpdateSubscription(ctx context.Context, customerID
 	}
 
 	// Log audit
-	s.logAudit(ctx, models.AuditActionUpdate, "subscription", subscription.ID.Hex(), userID, "", map[string]interface{}{
+	s.audit.LogChange(ctx, models.AuditActionUpdate, "subscription", subscription.ID.Hex(), userID, "", before, subscription, map[string]interface{}{
 		"subscription_id": subscription.SubscriptionID,
 		"customer_id":     customerID,
 	})
@@ -185,7 +186,7 @@ func (s *SubscriptionService) DeleteSubscription(ctx context.Context, customerID
 	}
 
 	// Log audit
-	s.logAudit(ctx, models.AuditActionDelete, "subscription", subscription.ID.Hex(), userID, "", map[string]interface{}{
+	s.audit.LogChange(ctx, models.AuditActionDelete, "subscription", subscription.ID.Hex(), userID, "", subscription, nil, map[string]interface{}{
 		"subscription_id": subscription.SubscriptionID,
 		"customer_id":     customerID,
 	})
@@ -250,6 +251,7 @@ func (s *SubscriptionService) RenewSubscription(ctx context.Context, customerID,
 		return nil, fmt.Errorf("new end date must be after start date")
 	}
 
+	before := auditState(subscription)
 	// Update end date
 	subscription.EndDate = &newEndDate
 	if subscription.Status == models.SubscriptionStatusExpired {
@@ -262,7 +264,7 @@ func (s *SubscriptionService) RenewSubscription(ctx context.Context, customerID,
 	}
 
 	// Log audit
-	s.logAudit(ctx, models.AuditActionUpdate, "subscription", subscription.ID.Hex(), userID, "", map[string]interface{}{
+	s.audit.LogChange(ctx, models.AuditActionUpdate, "subscription", subscription.ID.Hex(), userID, "", before, subscription, map[string]interface{}{
 		"subscription_id": subscription.SubscriptionID,
 		"action":          "renew",
 		"new_end_date":    newEndDate,
@@ -298,19 +300,3 @@ func (s *SubscriptionService) ValidateSubscriptionStatus(ctx context.Context, su
 
 	return subscription, nil
 }
-
-// logAudit logs an audit entry
-func (s *SubscriptionService) logAudit(ctx context.Context, action models.AuditAction, resourceType, resourceID, userID, userEmail string, details map[string]interface{}) {
-	auditLog := &models.AuditLog{
-		Action:       action,
-		ResourceType: resourceType,
-		ResourceID:   resourceID,
-		UserID:       userID,
-		UserEmail:    userEmail,
-		Details:      details,
-		Timestamp:    time.Now(),
-	}
-
-	_ = s.auditRepo.Create(ctx, auditLog)
-}
-
@@ -40,7 +40,7 @@ func setupSubscriptionServiceTestDB(t *testing.T) {
 	subscriptionServiceCustomerRepo = repository.NewCustomerRepository(db.Collection("customers"))
 	subscriptionServiceLicenseRepo = repository.NewLicenseRepository(db.Collection("licenses"))
 	subscriptionServiceAuditRepo = repository.NewAuditLogRepository(db.Collection("audit_logs"))
-	subscriptionService = NewSubscriptionService(subscriptionServiceRepo, subscriptionServiceCustomerRepo, subscriptionServiceLicenseRepo, subscriptionServiceAuditRepo)
+	subscriptionService = NewSubscriptionService(subscriptionServiceRepo, subscriptionServiceCustomerRepo, subscriptionServiceLicenseRepo, NewAuditLogger(subscriptionServiceAuditRepo))
 }
 
 func teardownSubscriptionServiceTestDB(t *testing.T) {
@@ -16,7 +16,7 @@ type TenantService struct {
 	tenantRepo     *repository.TenantRepository
 	customerRepo   *repository.CustomerRepository
 	deploymentRepo *repository.DeploymentRepository
-	auditRepo      *repository.AuditLogRepository
+	audit          *AuditLogger
 }
 
 // NewTenantService creates a new tenant service
@@ -24,13 +24,13 @@ func NewTenantService(
 	tenantRepo *repository.TenantRepository,
 	customerRepo *repository.CustomerRepository,
 	deploymentRepo *repository.DeploymentRepository,
-	auditRepo *repository.AuditLogRepository,
+	audit *AuditLogger,
 ) *TenantService {
 	return &TenantService{
 		tenantRepo:     tenantRepo,
 		customerRepo:   customerRepo,
 		deploymentRepo: deploymentRepo,
-		auditRepo:      auditRepo,
+		audit:          audit,
 	}
 }
 
@@ -90,6 +90,12 @@ func (s *TenantService) CreateTenant(ctx context.Context, customerID string, req
 		req.TenantID = s.generateTenantID()
 	}
 
+	// Validate maintenance schedule
+	schedule, err := normalizeMaintenanceSchedule(req.MaintenanceSchedule, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
 	// Create tenant
 	tenant := &models.CustomerTenant{
 		TenantID:    req.TenantID,
@@ -97,6 +103,7 @@ func (s *TenantService) CreateTenant(ctx context.Context, customerID string, req
 		Name:        req.Name,
 		Description: req.Description,
 		Status:      req.Status,
+		MaintenanceSchedule: schedule,
 	}
 
 	if err := s.tenantRepo.Create(ctx, tenant); err != nil {
@@ -104,7 +111,7 @@ func (s *TenantService) CreateTenant(ctx context.Context, customerID string, req
 	}
 
 	// Log audit
-	s.logAudit(ctx, models.AuditActionCreate, "tenant", tenant.ID.Hex(), userID, userEmail, map[string]interface{}{
+	s.audit.LogChange(ctx, models.AuditActionCreate, "tenant", tenant.ID.Hex(), userID, userEmail, nil, tenant, map[string]interface{}{
 		"tenant_id":  tenant.TenantID,
 		"customer_id": customer.CustomerID,
 		"name":        tenant.Name,
@@ -178,6 +185,7 @@ func (s *TenantService) UpdateTenant(ctx context.Context, id string, req *models
 		return nil, err
 	}
 
+	before := auditState(tenant)
 	// Update fields if provided
 	if req.Name != nil {
 		tenant.Name = *req.Name
@@ -188,13 +196,20 @@ func (s *TenantService) UpdateTenant(ctx context.Context, id string, req *models
 	if req.Status != nil {
 		tenant.Status = *req.Status
 	}
+	if req.MaintenanceSchedule != nil {
+		schedule, err := normalizeMaintenanceSchedule(req.MaintenanceSchedule, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		tenant.MaintenanceSchedule = schedule
+	}
 
 	if err := s.tenantRepo.Update(ctx, tenant.ID, tenant); err != nil {
 		return nil, fmt.Errorf("failed to update tenant: %w", err)
 	}
 
 	// Log audit
-	s.logAudit(ctx, models.AuditActionUpdate, "tenant", tenant.ID.Hex(), userID, userEmail, map[string]interface{}{
+	s.audit.LogChange(ctx, models.AuditActionUpdate, "tenant", tenant.ID.Hex(), userID, userEmail, before, tenant, map[string]interface{}{
 		"tenant_id": tenant.TenantID,
 	})
 
@@ -220,7 +235,7 @@ func (s *TenantService) DeleteTenant(ctx context.Context, id string, userID, use
 	}
 
 	// Log audit
-	s.logAudit(ctx, models.AuditActionDelete, "tenant", tenant.ID.Hex(), userID, userEmail, map[string]interface{}{
+	s.audit.LogChange(ctx, models.AuditActionDelete, "tenant", tenant.ID.Hex(), userID, userEmail, tenant, nil, map[string]interface{}{
 		"tenant_id": tenant.TenantID,
 	})
 
@@ -308,23 +323,3 @@ func (s *TenantService) generateTenantID() string {
 	timestamp := time.Now().Unix()
 	return fmt.Sprintf("TENANT-%d", timestamp)
 }
-
-// logAudit logs an audit entry
-func (s *TenantService) logAudit(ctx context.Context, action models.AuditAction, resourceType, resourceID, userID, userEmail string, details map[string]interface{}) {
-	if s.auditRepo == nil {
-		return
-	}
-
-	auditLog := &models.AuditLog{
-		Action:       action,
-		ResourceType: resourceType,
-		ResourceID:   resourceID,
-		UserID:       userID,
-		UserEmail:    userEmail,
-		Details:      details,
-		Timestamp:    time.Now(),
-	}
-
-	_ = s.auditRepo.Create(ctx, auditLog) // Ignore errors for audit logging
-}
-
@@ -3,28 +3,423 @@ package service
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strings"
+	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo/options"
 
 	"updatemanager/internal/models"
 	"updatemanager/internal/repository"
+	"updatemanager/internal/utils"
 )
 
+// checkInInterval is how long an endpoint should wait before its next check-in
+const checkInInterval = time.Hour
+
 // UpdateDetectionService handles update detection business logic
 type UpdateDetectionService struct {
-	detectionRepo *repository.UpdateDetectionRepository
-	versionRepo   *repository.VersionRepository
-	productRepo   *repository.ProductRepository
+	detectionRepo      *repository.UpdateDetectionRepository
+	versionRepo        *repository.VersionRepository
+	productRepo        *repository.ProductRepository
+	rolloutRepo        *repository.UpdateRolloutRepository
+	endpointRepo       *repository.EndpointRepository
+	upgradePathService *UpgradePathService
+	audit              *AuditLogger
 }
 
 // NewUpdateDetectionService creates a new update detection service
-func NewUpdateDetectionService(detectionRepo *repository.UpdateDetectionRepository, versionRepo *repository.VersionRepository, productRepo *repository.ProductRepository) *UpdateDetectionService {
+func NewUpdateDetectionService(detectionRepo *repository.UpdateDetectionRepository, versionRepo *repository.VersionRepository, productRepo *repository.ProductRepository, rolloutRepo *repository.UpdateRolloutRepository, endpointRepo *repository.EndpointRepository, upgradePathService *UpgradePathService, audit *AuditLogger) *UpdateDetectionService {
 	return &UpdateDetectionService{
-		detectionRepo: detectionRepo,
-		versionRepo:   versionRepo,
-		productRepo:   productRepo,
+		detectionRepo:      detectionRepo,
+		versionRepo:        versionRepo,
+		productRepo:        productRepo,
+		rolloutRepo:        rolloutRepo,
+		endpointRepo:       endpointRepo,
+		upgradePathService: upgradePathService,
+		audit:              audit,
+	}
+}
+
+// CheckIn handles an endpoint agent check-in. The server picks the newest
+// released version on the endpoint's channel that has an unblocked route from
+// the installed version, selects the next hop and a package for the
+// endpoint's platform, and upserts the endpoint's detection. An active
+// rollout for the endpoint takes precedence over the computed target, and an
+// active rollback rollout turns the answer into a rollback instruction. A
+// rollout waiting for a maintenance window holds the endpoint until it opens.
+func (s *UpdateDetectionService) CheckIn(ctx context.Context, req *models.CheckInRequest) (*models.CheckInResponse, error) {
+	if req.EndpointID == "" || req.ProductID == "" || req.InstalledVersion == "" {
+		return nil, fmt.Errorf("invalid check-in: endpoint_id, product_id and installed_version are required")
+	}
+
+	channel := req.Channel
+	if channel == "" {
+		channel = models.ReleaseChannelStable
+	}
+	if channel != models.ReleaseChannelStable && channel != models.ReleaseChannelBeta {
+		return nil, fmt.Errorf("invalid check-in: unknown channel '%s'", channel)
+	}
+
+	// Validate product exists
+	if _, err := s.productRepo.GetByProductID(ctx, req.ProductID); err != nil {
+		return nil, fmt.Errorf("product %s not found: %w", req.ProductID, err)
+	}
+
+	// Only registered endpoints may check in; the check-in doubles as a heartbeat
+	endpoint, err := getRegisteredEndpoint(ctx, s.endpointRepo, req.EndpointID, req.ProductID)
+	if err != nil {
+		return nil, err
+	}
+	inventory := bson.M{}
+	if req.OS != "" {
+		inventory["os"] = req.OS
+	}
+	if req.Architecture != "" {
+		inventory["architecture"] = req.Architecture
+	}
+	if err := s.endpointRepo.UpdateHeartbeat(ctx, endpoint.ID, time.Now(), inventory); err != nil {
+		return nil, fmt.Errorf("failed to record heartbeat: %w", err)
+	}
+
+	response := &models.CheckInResponse{
+		EndpointID:         req.EndpointID,
+		ProductID:          req.ProductID,
+		InstalledVersion:   req.InstalledVersion,
+		Channel:            channel,
+		Action:             models.CheckInActionNone,
+		NextCheckInSeconds: int(checkInInterval.Seconds()),
+		CheckedAt:          time.Now(),
+	}
+
+	versions, err := s.versionRepo.List(ctx, bson.M{"product_id": req.ProductID, "state": models.VersionStateReleased}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get released versions: %w", err)
+	}
+
+	// Candidates are newer versions on the channel, newest first. Versions
+	// halted by a health gate are not handed out.
+	var candidates []*models.Version
+	haltedReason := ""
+	for _, v := range versions {
+		if channelAllowsVersion(channel, v.VersionNumber) && utils.IsVersionNewer(v.VersionNumber, req.InstalledVersion) {
+			if rolloutsBlocked(v) {
+				if haltedReason == "" {
+					haltedReason = fmt.Sprintf("rollouts of version %s are %s", v.VersionNumber, v.RolloutHalt.Status)
+				}
+				continue
+			}
+			candidates = append(candidates, v)
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return utils.CompareVersions(candidates[i].VersionNumber, candidates[j].VersionNumber) > 0
+	})
+
+	// An active rollout is authoritative for the target version, unless that
+	// version has been halted
+	rollout := s.findActiveRollout(ctx, req.EndpointID, req.ProductID)
+	// Nothing is installed until the rollout's maintenance window opens
+	if rollout != nil && rollout.Status == models.RolloutStatusScheduled {
+		response.Rollout = rolloutInstruction(rollout)
+		response.TargetVersion = rollout.ToVersion
+		response.Reason = fmt.Sprintf("rollout to %s is scheduled for the maintenance window opening at %s", rollout.ToVersion, rollout.ScheduledFor.Format(time.RFC3339))
+		availableVersion := rollout.ToVersion
+		if rollout.RollbackOf != nil {
+			availableVersion = req.InstalledVersion
+		}
+		if err := s.upsertCheckInDetection(ctx, req, channel, availableVersion); err != nil {
+			return nil, err
+		}
+		return response, nil
+	}
+	if rollout != nil && rollout.RollbackOf != nil {
+		return s.rollbackCheckIn(ctx, req, channel, response, rollout)
+	}
+	if rollout != nil {
+		if v := findVersion(versions, rollout.ToVersion); v != nil && rolloutsBlocked(v) {
+			haltedReason = fmt.Sprintf("rollouts of version %s are %s", v.VersionNumber, v.RolloutHalt.Status)
+			rollout = nil
+			candidates = nil
+		}
+	}
+	if rollout != nil {
+		response.Rollout = rolloutInstruction(rollout)
+		candidates = nil
+		if v := findVersion(versions, rollout.ToVersion); v != nil && utils.IsVersionNewer(v.VersionNumber, req.InstalledVersion) {
+			candidates = append(candidates, v)
+		}
+	}
+
+	var target *models.Version
+	for _, candidate := range candidates {
+		resolution, err := s.upgradePathService.ResolveUpgradePath(ctx, req.ProductID, req.InstalledVersion, candidate.VersionNumber)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve upgrade path: %w", err)
+		}
+		if resolution.IsBlocked {
+			if response.Reason == "" {
+				response.Reason = fmt.Sprintf("upgrade from %s to %s is blocked: %s", req.InstalledVersion, candidate.VersionNumber, resolution.BlockReason)
+			}
+			continue
+		}
+
+		if len(resolution.IntermediateVersions) > 0 {
+			if hop := findVersion(versions, resolution.IntermediateVersions[0]); hop != nil && rolloutsBlocked(hop) {
+				if haltedReason == "" {
+					haltedReason = fmt.Sprintf("rollouts of version %s are %s", hop.VersionNumber, hop.RolloutHalt.Status)
+				}
+				continue
+			}
+		}
+
+		response.LatestVersion = candidate.VersionNumber
+		response.UpgradePath = append(append([]string{}, resolution.IntermediateVersions...), candidate.VersionNumber)
+		response.TargetVersion = response.UpgradePath[0]
+		response.Reason = ""
+		target = candidate
+		if response.TargetVersion != candidate.VersionNumber {
+			target = findVersion(versions, response.TargetVersion)
+		}
+		break
+	}
+
+	switch {
+	case target != nil:
+		response.Package = selectPackage(target.Packages, req.OS, req.Architecture)
+		if response.Package != nil {
+			response.Action = models.CheckInActionUpdate
+		} else {
+			response.Reason = fmt.Sprintf("no package for %s available for os '%s' and architecture '%s'", response.TargetVersion, req.OS, req.Architecture)
+		}
+	case response.TargetVersion != "":
+		response.Reason = fmt.Sprintf("intermediate version %s is not released", response.TargetVersion)
+	case len(candidates) > 0:
+		response.Action = models.CheckInActionBlocked
+		if response.Reason == "" {
+			response.Reason = haltedReason
+		}
+	case haltedReason != "":
+		response.Reason = haltedReason
+	default:
+		response.Reason = "endpoint is up to date"
+	}
+
+	// Upsert the detection; up-to-date endpoints report their installed version as available
+	availableVersion := response.TargetVersion
+	if availableVersion == "" {
+		availableVersion = req.InstalledVersion
+	}
+	if err := s.upsertCheckInDetection(ctx, req, channel, availableVersion); err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// rollbackCheckIn answers a check-in while a rollback rollout is active for
+// the endpoint. The endpoint is told to restore the rollback's target version
+// whether or not newer versions exist.
+func (s *UpdateDetectionService) rollbackCheckIn(ctx context.Context, req *models.CheckInRequest, channel models.ReleaseChannel, response *models.CheckInResponse, rollout *models.UpdateRollout) (*models.CheckInResponse, error) {
+	response.Rollout = rolloutInstruction(rollout)
+	response.TargetVersion = rollout.ToVersion
+	response.LatestVersion = rollout.ToVersion
+	response.UpgradePath = []string{rollout.ToVersion}
+
+	to, err := s.versionRepo.GetByProductIDAndVersion(ctx, req.ProductID, rollout.ToVersion)
+	if err != nil {
+		return nil, fmt.Errorf("rollback version %s not found for product %s: %w", rollout.ToVersion, req.ProductID, err)
+	}
+	// Without the version being removed only the full installer fallback applies
+	from, _ := s.versionRepo.GetByProductIDAndVersion(ctx, req.ProductID, rollout.FromVersion)
+
+	response.Package = selectRollbackPackage(from, to, req.OS, req.Architecture)
+	if response.Package != nil {
+		response.Action = models.CheckInActionRollback
+	} else {
+		response.Reason = fmt.Sprintf("no rollback package from %s to %s available for os '%s' and architecture '%s'", rollout.FromVersion, rollout.ToVersion, req.OS, req.Architecture)
 	}
+
+	// No update is available while the endpoint is being rolled back
+	if err := s.upsertCheckInDetection(ctx, req, channel, req.InstalledVersion); err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// rolloutInstruction describes a rollout for the agent to report against
+func rolloutInstruction(rollout *models.UpdateRollout) *models.CheckInRolloutInstruction {
+	instruction := &models.CheckInRolloutInstruction{
+		RolloutID:         rollout.ID.Hex(),
+		Status:            rollout.Status,
+		ToVersion:         rollout.ToVersion,
+		ScheduledFor:      rollout.ScheduledFor,
+		ReportStatusURL:   "/api/v1/update-rollouts/" + rollout.ID.Hex() + "/status",
+		ReportProgressURL: "/api/v1/update-rollouts/" + rollout.ID.Hex() + "/progress",
+		ReportEventsURL:   "/api/v1/update-rollouts/" + rollout.ID.Hex() + "/events",
+	}
+	if rollout.RollbackOf != nil {
+		instruction.RollbackOf = rollout.RollbackOf.Hex()
+	}
+	return instruction
+}
+
+// upsertCheckInDetection records the result of a check-in
+func (s *UpdateDetectionService) upsertCheckInDetection(ctx context.Context, req *models.CheckInRequest, channel models.ReleaseChannel, availableVersion string) error {
+	existing, err := s.detectionRepo.GetByEndpointIDAndProductID(ctx, req.EndpointID, req.ProductID)
+	if err == nil && existing != nil {
+		before := auditState(existing)
+		previousVersion := existing.CurrentVersion
+		previousAvailable := existing.AvailableVersion
+		if existing.AvailableVersion != availableVersion {
+			existing.DetectedAt = time.Now()
+		}
+		existing.CurrentVersion = req.InstalledVersion
+		existing.AvailableVersion = availableVersion
+		existing.OS = req.OS
+		existing.Architecture = req.Architecture
+		existing.Channel = channel
+		if err := s.detectionRepo.Update(ctx, existing); err != nil {
+			return fmt.Errorf("failed to update detection: %w", err)
+		}
+		// Check-ins that change nothing are not audited
+		if previousVersion != existing.CurrentVersion || previousAvailable != existing.AvailableVersion {
+			s.audit.LogChange(ctx, models.AuditActionUpdate, "update_detection", existing.ID.Hex(), "", "", before, existing, map[string]interface{}{
+				"endpoint_id":       req.EndpointID,
+				"product_id":        existing.ProductID,
+				"current_version":   existing.CurrentVersion,
+				"available_version": existing.AvailableVersion,
+				"source":            "check_in",
+			})
+		}
+		return nil
+	}
+
+	detection := &models.UpdateDetection{
+		EndpointID:       req.EndpointID,
+		ProductID:        req.ProductID,
+		CurrentVersion:   req.InstalledVersion,
+		AvailableVersion: availableVersion,
+		OS:               req.OS,
+		Architecture:     req.Architecture,
+		Channel:          channel,
+	}
+	if err := s.detectionRepo.Create(ctx, detection); err != nil {
+		return fmt.Errorf("failed to create detection: %w", err)
+	}
+	s.audit.LogChange(ctx, models.AuditActionCreate, "update_detection", detection.ID.Hex(), "", "", nil, detection, map[string]interface{}{
+		"endpoint_id":       req.EndpointID,
+		"product_id":        detection.ProductID,
+		"current_version":   detection.CurrentVersion,
+		"available_version": detection.AvailableVersion,
+		"source":            "check_in",
+	})
+	return nil
+}
+
+// findActiveRollout returns the scheduled, pending or in-progress rollout for an endpoint, if any
+func (s *UpdateDetectionService) findActiveRollout(ctx context.Context, endpointID, productID string) *models.UpdateRollout {
+	if s.rolloutRepo == nil {
+		return nil
+	}
+
+	opts := options.Find().SetSort(bson.M{"initiated_at": -1}).SetLimit(1)
+	rollouts, err := s.rolloutRepo.List(ctx, bson.M{
+		"endpoint_id": endpointID,
+		"product_id":  productID,
+		"status":      bson.M{"$in": activeRolloutStatuses},
+	}, opts)
+	if err != nil || len(rollouts) == 0 {
+		return nil
+	}
+	return rollouts[0]
+}
+
+// channelAllowsVersion reports whether a version is offered on a channel.
+// The stable channel excludes pre-release versions such as "2.0.0-beta.1".
+func channelAllowsVersion(channel models.ReleaseChannel, versionNumber string) bool {
+	if channel == models.ReleaseChannelBeta {
+		return true
+	}
+	return !strings.Contains(versionNumber, "-")
+}
+
+// selectPackage picks the package to install for a platform. Packages without
+// an OS or architecture match any platform; update packages are preferred over
+// full installers. Delta and rollback packages are never selected.
+func selectPackage(packages []models.PackageInfo, os, arch string) *models.PackageInfo {
+	var selected *models.PackageInfo
+	for i := range packages {
+		pkg := &packages[i]
+		if pkg.PackageType != models.PackageTypeUpdate && pkg.PackageType != models.PackageTypeFullInstaller {
+			continue
+		}
+		if !packageMatchesPlatform(pkg, os, arch) {
+			continue
+		}
+		if selected == nil || (selected.PackageType == models.PackageTypeFullInstaller && pkg.PackageType == models.PackageTypeUpdate) {
+			selected = pkg
+		}
+	}
+	return selected
+}
+
+// selectRollbackPackage picks the package that takes a platform from one
+// version back to an earlier one. Rollback packages of the version being
+// removed are preferred, those naming the restored version over generic
+// ones; otherwise a full installer of the restored version is used. from may
+// be nil.
+func selectRollbackPackage(from, to *models.Version, os, arch string) *models.PackageInfo {
+	if from != nil {
+		var generic *models.PackageInfo
+		for i := range from.Packages {
+			pkg := &from.Packages[i]
+			if pkg.PackageType != models.PackageTypeRollback || !packageMatchesPlatform(pkg, os, arch) {
+				continue
+			}
+			if pkg.RestoresVersion == to.VersionNumber {
+				return pkg
+			}
+			if pkg.RestoresVersion == "" && generic == nil {
+				generic = pkg
+			}
+		}
+		if generic != nil {
+			return generic
+		}
+	}
+
+	for i := range to.Packages {
+		pkg := &to.Packages[i]
+		if pkg.PackageType == models.PackageTypeFullInstaller && packageMatchesPlatform(pkg, os, arch) {
+			return pkg
+		}
+	}
+	return nil
+}
+
+// packageMatchesPlatform reports whether a package installs on a platform.
+// Packages without an OS or architecture match any platform.
+func packageMatchesPlatform(pkg *models.PackageInfo, os, arch string) bool {
+	if pkg.OS != "" && os != "" && !strings.EqualFold(pkg.OS, os) {
+		return false
+	}
+	if pkg.Architecture != "" && arch != "" && !strings.EqualFold(pkg.Architecture, arch) {
+		return false
+	}
+	return true
+}
+
+// findVersion returns the version with the given number
+func findVersion(versions []*models.Version, versionNumber string) *models.Version {
+	for _, v := range versions {
+		if v.VersionNumber == versionNumber {
+			return v
+		}
+	}
+	return nil
 }
 
 // DetectUpdate detects or updates detection for an endpoint
@@ -35,6 +430,11 @@ func (s *UpdateDetectionService) DetectUpdate(ctx context.Context, detection *mo
 		return nil, fmt.Errorf("product %s not found: %w", detection.ProductID, err)
 	}
 
+	// Validate endpoint is registered for the product
+	if _, err := getRegisteredEndpoint(ctx, s.endpointRepo, detection.EndpointID, detection.ProductID); err != nil {
+		return nil, err
+	}
+
 	// Validate that versions exist for the product
 	_, err = s.versionRepo.GetByProductIDAndVersion(ctx, detection.ProductID, detection.CurrentVersion)
 	if err != nil {
@@ -55,11 +455,18 @@ func (s *UpdateDetectionService) DetectUpdate(ctx context.Context, detection *mo
 	existing, err := s.detectionRepo.GetByEndpointIDAndProductID(ctx, detection.EndpointID, detection.ProductID)
 	if err == nil && existing != nil {
 		// Update existing detection
+		before := auditState(existing)
 		existing.CurrentVersion = detection.CurrentVersion
 		existing.AvailableVersion = detection.AvailableVersion
 		if err := s.detectionRepo.Update(ctx, existing); err != nil {
 			return nil, fmt.Errorf("failed to update detection: %w", err)
 		}
+		s.audit.LogChange(ctx, models.AuditActionUpdate, "update_detection", existing.ID.Hex(), "", "", before, existing, map[string]interface{}{
+			"endpoint_id":       existing.EndpointID,
+			"product_id":        existing.ProductID,
+			"current_version":   existing.CurrentVersion,
+			"available_version": existing.AvailableVersion,
+		})
 		return existing, nil
 	}
 
@@ -68,6 +475,13 @@ func (s *UpdateDetectionService) DetectUpdate(ctx context.Context, detection *mo
 		return nil, fmt.Errorf("failed to create detection: %w", err)
 	}
 
+	s.audit.LogChange(ctx, models.AuditActionCreate, "update_detection", detection.ID.Hex(), "", "", nil, detection, map[string]interface{}{
+		"endpoint_id":       detection.EndpointID,
+		"product_id":        detection.ProductID,
+		"current_version":   detection.CurrentVersion,
+		"available_version": detection.AvailableVersion,
+	})
+
 	return detection, nil
 }
 
@@ -102,6 +516,14 @@ func (s *UpdateDetectionService) UpdateAvailableVersion(ctx context.Context, end
 		return fmt.Errorf("failed to update available version: %w", err)
 	}
 
+	updated, _ := s.detectionRepo.GetByID(ctx, detection.ID)
+	s.audit.LogChange(ctx, models.AuditActionUpdate, "update_detection", detection.ID.Hex(), "", "", detection, updated, map[string]interface{}{
+		"endpoint_id":       endpointID,
+		"product_id":        productID,
+		"previous_version":  detection.AvailableVersion,
+		"available_version": availableVersion,
+	})
+
 	return nil
 }
 
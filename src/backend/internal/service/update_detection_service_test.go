@@ -2,9 +2,13 @@ package service
 
 import (
 	"context"
+	"strings"
 	"testing"
 	"time"
 
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
 	"updatemanager/internal/models"
 	"updatemanager/internal/repository"
 	"updatemanager/pkg/database"
@@ -17,6 +21,8 @@ var (
 	detectionRepo           *repository.UpdateDetectionRepository
 	detectionVersionRepo    *repository.VersionRepository
 	detectionProductRepo    *repository.ProductRepository
+	detectionRolloutRepo    *repository.UpdateRolloutRepository
+	detectionEndpointRepo   *repository.EndpointRepository
 )
 
 func setupDetectionServiceTestDB(t *testing.T) {
@@ -38,20 +44,49 @@ func setupDetectionServiceTestDB(t *testing.T) {
 	detectionRepo = repository.NewUpdateDetectionRepository(db.Collection("update_detections"))
 	detectionVersionRepo = repository.NewVersionRepository(db.Collection("versions"))
 	detectionProductRepo = repository.NewProductRepository(db.Collection("products"))
-	detectionService = NewUpdateDetectionService(detectionRepo, detectionVersionRepo, detectionProductRepo)
+	detectionRolloutRepo = repository.NewUpdateRolloutRepository(db.Collection("update_rollouts"))
+	detectionEndpointRepo = repository.NewEndpointRepository(db.Collection("endpoints"))
+	detectionUpgradePathService := NewUpgradePathService(
+		repository.NewUpgradePathRepository(db.Collection("upgrade_paths")),
+		repository.NewUpgradePathRuleRepository(db.Collection("upgrade_path_rules")),
+		detectionVersionRepo,
+		detectionProductRepo,
+		nil,
+	)
+	detectionService = NewUpdateDetectionService(detectionRepo, detectionVersionRepo, detectionProductRepo, detectionRolloutRepo, detectionEndpointRepo, detectionUpgradePathService, nil)
 }
 
 func teardownDetectionServiceTestDB(t *testing.T) {
 	if detectionServiceTestDB != nil {
 		_ = detectionServiceTestDB.Collection("update_detections").Drop(detectionServiceTestCtx)
 		_ = detectionServiceTestDB.Collection("versions").Drop(detectionServiceTestCtx)
+		_ = detectionServiceTestDB.Collection("products").Drop(detectionServiceTestCtx)
+		_ = detectionServiceTestDB.Collection("update_rollouts").Drop(detectionServiceTestCtx)
+		_ = detectionServiceTestDB.Collection("upgrade_paths").Drop(detectionServiceTestCtx)
+		_ = detectionServiceTestDB.Collection("endpoints").Drop(detectionServiceTestCtx)
 		_ = detectionServiceTestDB.Disconnect(detectionServiceTestCtx)
 	}
 }
 
+// registerDetectionTestEndpoint registers an endpoint so detections may reference it
+func registerDetectionTestEndpoint(t *testing.T, endpointID, productID string) {
+	err := detectionEndpointRepo.Create(detectionServiceTestCtx, &models.Endpoint{
+		EndpointID:   endpointID,
+		DeploymentID: primitive.NewObjectID(),
+		TenantID:     primitive.NewObjectID(),
+		ProductID:    productID,
+		Hostname:     endpointID + ".example.com",
+		Status:       models.EndpointStatusActive,
+	})
+	if err != nil {
+		t.Fatalf("Failed to register endpoint: %v", err)
+	}
+}
+
 func TestUpdateDetectionService_DetectUpdate(t *testing.T) {
 	setupDetectionServiceTestDB(t)
 	defer teardownDetectionServiceTestDB(t)
+	registerDetectionTestEndpoint(t, "endpoint-123", "test-product")
 
 	detection := &models.UpdateDetection{
 		EndpointID:       "endpoint-123",
@@ -78,6 +113,7 @@ func TestUpdateDetectionService_DetectUpdate(t *testing.T) {
 func TestUpdateDetectionService_DetectUpdate_UpdateExisting(t *testing.T) {
 	setupDetectionServiceTestDB(t)
 	defer teardownDetectionServiceTestDB(t)
+	registerDetectionTestEndpoint(t, "endpoint-456", "test-product")
 
 	// Create initial detection
 	detection := &models.UpdateDetection{
@@ -111,6 +147,7 @@ func TestUpdateDetectionService_DetectUpdate_UpdateExisting(t *testing.T) {
 func TestUpdateDetectionService_UpdateAvailableVersion(t *testing.T) {
 	setupDetectionServiceTestDB(t)
 	defer teardownDetectionServiceTestDB(t)
+	registerDetectionTestEndpoint(t, "endpoint-789", "test-product")
 
 	// Create detection
 	detection := &models.UpdateDetection{
@@ -135,3 +172,143 @@ func TestUpdateDetectionService_UpdateAvailableVersion(t *testing.T) {
 
 	t.Logf("Updated available version: %+v", retrieved)
 }
+
+func TestUpdateDetectionService_CheckIn(t *testing.T) {
+	setupDetectionServiceTestDB(t)
+	defer teardownDetectionServiceTestDB(t)
+
+	product := &models.Product{
+		ProductID: "checkin-product",
+		Name:      "Check-in Product",
+		Type:      models.ProductTypeServer,
+		IsActive:  true,
+	}
+	detectionProductRepo.Create(detectionServiceTestCtx, product)
+
+	packages := []models.PackageInfo{
+		{PackageType: models.PackageTypeFullInstaller, FileName: "installer-linux.tar.gz", OS: "linux", Architecture: "amd64", ChecksumSHA256: "a"},
+		{PackageType: models.PackageTypeUpdate, FileName: "update-linux.tar.gz", OS: "linux", Architecture: "amd64", ChecksumSHA256: "b"},
+		{PackageType: models.PackageTypeUpdate, FileName: "update-windows.zip", OS: "windows", Architecture: "amd64", ChecksumSHA256: "c"},
+	}
+	for _, v := range []string{"1.0.0", "1.1.0", "2.0.0-beta.1"} {
+		detectionVersionRepo.Create(detectionServiceTestCtx, &models.Version{
+			ProductID:     product.ProductID,
+			VersionNumber: v,
+			ReleaseDate:   time.Now(),
+			ReleaseType:   models.ReleaseTypeFeature,
+			State:         models.VersionStateReleased,
+			Packages:      packages,
+			CreatedBy:     "user-123",
+		})
+	}
+
+	req := &models.CheckInRequest{
+		EndpointID:       "endpoint-checkin",
+		ProductID:        product.ProductID,
+		InstalledVersion: "1.0.0",
+		OS:               "linux",
+		Architecture:     "amd64",
+	}
+
+	// Unregistered endpoints are rejected
+	if _, err := detectionService.CheckIn(detectionServiceTestCtx, req); err == nil || !strings.Contains(err.Error(), "not registered") {
+		t.Fatalf("Expected not registered error, got %v", err)
+	}
+	registerDetectionTestEndpoint(t, req.EndpointID, product.ProductID)
+
+	response, err := detectionService.CheckIn(detectionServiceTestCtx, req)
+	if err != nil {
+		t.Fatalf("Failed to check in: %v", err)
+	}
+	if response.Action != models.CheckInActionUpdate || response.TargetVersion != "1.1.0" {
+		t.Errorf("Expected update to 1.1.0 on stable channel, got %s %s", response.Action, response.TargetVersion)
+	}
+	if response.Package == nil || response.Package.FileName != "update-linux.tar.gz" {
+		t.Errorf("Expected linux update package, got %+v", response.Package)
+	}
+
+	detection, err := detectionRepo.GetByEndpointIDAndProductID(detectionServiceTestCtx, req.EndpointID, req.ProductID)
+	if err != nil {
+		t.Fatalf("Expected detection to be upserted: %v", err)
+	}
+	if detection.AvailableVersion != "1.1.0" || detection.LastCheckedAt.IsZero() {
+		t.Errorf("Unexpected detection: %+v", detection)
+	}
+
+	// The check-in is recorded as a heartbeat with the reported platform
+	endpoint, err := detectionEndpointRepo.GetByEndpointID(detectionServiceTestCtx, req.EndpointID)
+	if err != nil {
+		t.Fatalf("Failed to get endpoint: %v", err)
+	}
+	if endpoint.LastHeartbeatAt == nil || endpoint.OS != "linux" || endpoint.Architecture != "amd64" {
+		t.Errorf("Expected heartbeat with platform to be recorded, got %+v", endpoint)
+	}
+
+	// Beta channel is offered pre-release versions
+	req.Channel = models.ReleaseChannelBeta
+	response, err = detectionService.CheckIn(detectionServiceTestCtx, req)
+	if err != nil {
+		t.Fatalf("Failed to check in: %v", err)
+	}
+	if response.TargetVersion != "2.0.0-beta.1" {
+		t.Errorf("Expected beta target 2.0.0-beta.1, got %s", response.TargetVersion)
+	}
+
+	// Up to date endpoints get no action
+	req.Channel = ""
+	req.InstalledVersion = "1.1.0"
+	response, err = detectionService.CheckIn(detectionServiceTestCtx, req)
+	if err != nil {
+		t.Fatalf("Failed to check in: %v", err)
+	}
+	if response.Action != models.CheckInActionNone {
+		t.Errorf("Expected no action for up to date endpoint, got %s", response.Action)
+	}
+
+	count, _ := detectionRepo.Count(detectionServiceTestCtx, bson.M{"endpoint_id": req.EndpointID})
+	if count != 1 {
+		t.Errorf("Expected check-ins to upsert a single detection, got %d", count)
+	}
+}
+
+func TestSelectPackage(t *testing.T) {
+	packages := []models.PackageInfo{
+		{PackageType: models.PackageTypeFullInstaller, FileName: "full-any"},
+		{PackageType: models.PackageTypeRollback, FileName: "rollback-linux", OS: "linux"},
+		{PackageType: models.PackageTypeUpdate, FileName: "update-windows", OS: "windows"},
+		{PackageType: models.PackageTypeUpdate, FileName: "update-linux-arm64", OS: "linux", Architecture: "arm64"},
+	}
+
+	tests := []struct {
+		os, arch string
+		expected string
+	}{
+		{"windows", "amd64", "update-windows"},
+		{"linux", "arm64", "update-linux-arm64"},
+		{"linux", "amd64", "full-any"},
+		{"darwin", "arm64", "full-any"},
+	}
+
+	for _, tt := range tests {
+		pkg := selectPackage(packages, tt.os, tt.arch)
+		if pkg == nil || pkg.FileName != tt.expected {
+			t.Errorf("selectPackage(%s, %s) = %+v, expected %s", tt.os, tt.arch, pkg, tt.expected)
+		}
+	}
+
+	if pkg := selectPackage(packages[1:2], "linux", "amd64"); pkg != nil {
+		t.Errorf("Rollback packages should never be selected, got %+v", pkg)
+	}
+}
+
+func TestChannelAllowsVersion(t *testing.T) {
+	if channelAllowsVersion(models.ReleaseChannelStable, "2.0.0-beta.1") {
+		t.Error("Stable channel should not offer pre-release versions")
+	}
+	if !channelAllowsVersion(models.ReleaseChannelStable, "2.0.0") {
+		t.Error("Stable channel should offer released versions")
+	}
+	if !channelAllowsVersion(models.ReleaseChannelBeta, "2.0.0-beta.1") {
+		t.Error("Beta channel should offer pre-release versions")
+	}
+}
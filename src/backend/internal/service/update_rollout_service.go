@@ -3,6 +3,10 @@ package service
 import (
 	"context"
 	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -18,19 +22,36 @@ type UpdateRolloutService struct {
 	detectionRepo *repository.UpdateDetectionRepository
 	versionRepo   *repository.VersionRepository
 	productRepo   *repository.ProductRepository
+	endpointRepo  *repository.EndpointRepository
+	audit         *AuditLogger
+	healthService *RolloutHealthService
+	windows       *MaintenanceWindowService
+	stream        *StreamPublisher
+	outbox        *DomainEventOutbox
 }
 
-// NewUpdateRolloutService creates a new update rollout service
-func NewUpdateRolloutService(rolloutRepo *repository.UpdateRolloutRepository, detectionRepo *repository.UpdateDetectionRepository, versionRepo *repository.VersionRepository, productRepo *repository.ProductRepository) *UpdateRolloutService {
+// NewUpdateRolloutService creates a new update rollout service. healthService
+// may be nil, in which case failures never halt a version; windows may be nil,
+// in which case rollouts are never held for a maintenance window; stream may
+// be nil, in which case rollout changes are not streamed; outbox may be nil,
+// in which case failures raise no RolloutFailed event.
+func NewUpdateRolloutService(rolloutRepo *repository.UpdateRolloutRepository, detectionRepo *repository.UpdateDetectionRepository, versionRepo *repository.VersionRepository, productRepo *repository.ProductRepository, endpointRepo *repository.EndpointRepository, audit *AuditLogger, healthService *RolloutHealthService, windows *MaintenanceWindowService, stream *StreamPublisher, outbox *DomainEventOutbox) *UpdateRolloutService {
 	return &UpdateRolloutService{
 		rolloutRepo:   rolloutRepo,
 		detectionRepo: detectionRepo,
 		versionRepo:   versionRepo,
 		productRepo:   productRepo,
+		endpointRepo:  endpointRepo,
+		audit:         audit,
+		healthService: healthService,
+		windows:       windows,
+		stream:        stream,
+		outbox:        outbox,
 	}
 }
 
-// InitiateRollout initiates a new update rollout
+// InitiateRollout initiates a new update rollout. Outside the endpoint's
+// maintenance window the rollout is scheduled for the next window.
 func (s *UpdateRolloutService) InitiateRollout(ctx context.Context, rollout *models.UpdateRollout) (*models.UpdateRollout, error) {
 	// Validate product exists
 	_, err := s.productRepo.GetByProductID(ctx, rollout.ProductID)
@@ -38,6 +59,12 @@ func (s *UpdateRolloutService) InitiateRollout(ctx context.Context, rollout *mod
 		return nil, fmt.Errorf("product %s not found: %w", rollout.ProductID, err)
 	}
 
+	// Validate endpoint is registered for the product
+	endpoint, err := getRegisteredEndpoint(ctx, s.endpointRepo, rollout.EndpointID, rollout.ProductID)
+	if err != nil {
+		return nil, err
+	}
+
 	// Validate that versions exist for the product
 	_, err = s.versionRepo.GetByProductIDAndVersion(ctx, rollout.ProductID, rollout.FromVersion)
 	if err != nil {
@@ -54,6 +81,11 @@ func (s *UpdateRolloutService) InitiateRollout(ctx context.Context, rollout *mod
 		return nil, fmt.Errorf("to version %s must be in Released state, current state: %s", rollout.ToVersion, toVersion.State)
 	}
 
+	// Versions halted by a health gate are not handed out until resumed
+	if rolloutsBlocked(toVersion) {
+		return nil, fmt.Errorf("rollouts of version %s are %s: %s", rollout.ToVersion, toVersion.RolloutHalt.Status, toVersion.RolloutHalt.Reason)
+	}
+
 	// Verify detection exists
 	_, err = s.detectionRepo.GetByEndpointIDAndProductID(ctx, rollout.EndpointID, rollout.ProductID)
 	if err != nil {
@@ -62,10 +94,21 @@ func (s *UpdateRolloutService) InitiateRollout(ctx context.Context, rollout *mod
 
 	rollout.Status = models.RolloutStatusPending
 	rollout.Progress = 0
+	if err := s.holdForMaintenanceWindow(ctx, rollout, endpoint, time.Now()); err != nil {
+		return nil, err
+	}
 
 	if err := s.rolloutRepo.Create(ctx, rollout); err != nil {
 		return nil, fmt.Errorf("failed to initiate rollout: %w", err)
 	}
+	s.audit.LogChange(ctx, models.AuditActionCreate, "update_rollout", rollout.ID.Hex(), "", "", nil, rollout, map[string]interface{}{
+		"endpoint_id":  rollout.EndpointID,
+		"product_id":   rollout.ProductID,
+		"from_version": rollout.FromVersion,
+		"to_version":   rollout.ToVersion,
+		"status":       rollout.Status,
+	})
+	s.stream.RolloutStatusChanged(ctx, rollout)
 
 	return rollout, nil
 }
@@ -79,20 +122,686 @@ func (s *UpdateRolloutService) GetRollout(ctx context.Context, id primitive.Obje
 	return rollout, nil
 }
 
-// UpdateRolloutStatus updates the status of a rollout
+// holdForMaintenanceWindow schedules a new rollout for the endpoint's next
+// maintenance window when none is open
+func (s *UpdateRolloutService) holdForMaintenanceWindow(ctx context.Context, rollout *models.UpdateRollout, endpoint *models.Endpoint, now time.Time) error {
+	if s.windows == nil {
+		return nil
+	}
+
+	window, err := s.windows.WindowForEndpoint(ctx, endpoint, now)
+	if err != nil {
+		return err
+	}
+	if window == nil || window.Open {
+		return nil
+	}
+	if window.OpensAt == nil {
+		return fmt.Errorf("no maintenance window: the %s schedule of endpoint %s opens no window within a year", window.Source, endpoint.EndpointID)
+	}
+
+	rollout.Status = models.RolloutStatusScheduled
+	rollout.ScheduledFor = window.OpensAt
+	return nil
+}
+
+// ReleaseScheduledRollouts hands scheduled rollouts whose window has opened to
+// their agents. Rollouts whose schedule changed in the meantime are moved to
+// their new next window.
+func (s *UpdateRolloutService) ReleaseScheduledRollouts(ctx context.Context, now time.Time) (int, error) {
+	rollouts, err := s.rolloutRepo.List(ctx, bson.M{
+		"status":        models.RolloutStatusScheduled,
+		"scheduled_for": bson.M{"$lte": now},
+	}, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list scheduled rollouts: %w", err)
+	}
+
+	released := 0
+	for _, rollout := range rollouts {
+		window, err := s.scheduledRolloutWindow(ctx, rollout, now)
+		if err != nil {
+			log.Printf("Failed to check maintenance window of rollout %s: %v", rollout.ID.Hex(), err)
+			continue
+		}
+
+		if window != nil && !window.Open {
+			if window.OpensAt == nil {
+				log.Printf("Rollout %s stays scheduled: no maintenance window opens within a year", rollout.ID.Hex())
+				continue
+			}
+			if _, err := s.rolloutRepo.Reschedule(ctx, rollout.ID, *window.OpensAt); err != nil {
+				log.Printf("Failed to reschedule rollout %s: %v", rollout.ID.Hex(), err)
+				continue
+			}
+			before := auditState(rollout)
+			rollout.ScheduledFor = window.OpensAt
+			s.audit.LogChange(ctx, models.AuditActionUpdate, "update_rollout", rollout.ID.Hex(), schedulerUserID, "", before, rollout, map[string]interface{}{
+				"scheduled_for": *window.OpensAt,
+				"reason":        "maintenance window changed",
+			})
+			continue
+		}
+
+		// Rollouts cancelled in the meantime stay cancelled
+		updated, err := s.rolloutRepo.UpdateStatusFrom(ctx, rollout.ID, models.RolloutStatusScheduled, models.RolloutStatusPending, "")
+		if err != nil {
+			return released, fmt.Errorf("failed to release rollout %s: %w", rollout.ID.Hex(), err)
+		}
+		if updated {
+			released++
+			before := auditState(rollout)
+			rollout.Status = models.RolloutStatusPending
+			s.audit.LogChange(ctx, models.AuditActionUpdate, "update_rollout", rollout.ID.Hex(), schedulerUserID, "", before, rollout, map[string]interface{}{
+				"old_status": models.RolloutStatusScheduled,
+				"new_status": models.RolloutStatusPending,
+				"reason":     "maintenance window opened",
+			})
+			s.stream.RolloutStatusChanged(ctx, rollout)
+		}
+	}
+
+	return released, nil
+}
+
+// scheduledRolloutWindow returns the current maintenance window of a
+// scheduled rollout's endpoint, or nil if it is no longer restricted
+func (s *UpdateRolloutService) scheduledRolloutWindow(ctx context.Context, rollout *models.UpdateRollout, now time.Time) (*models.MaintenanceWindowStatus, error) {
+	if s.windows == nil {
+		return nil, nil
+	}
+	endpoint, err := getRegisteredEndpoint(ctx, s.endpointRepo, rollout.EndpointID, rollout.ProductID)
+	if err != nil {
+		return nil, err
+	}
+	return s.windows.WindowForEndpoint(ctx, endpoint, now)
+}
+
+// rolloutTransitions lists the statuses a rollout may move to from each
+// status. Scheduled rollouts are released to pending by the scheduler (or an
+// operator); completed, failed and cancelled rollouts are final.
+var rolloutTransitions = map[models.RolloutStatus][]models.RolloutStatus{
+	models.RolloutStatusScheduled:  {models.RolloutStatusPending, models.RolloutStatusCancelled},
+	models.RolloutStatusPending:    {models.RolloutStatusInProgress, models.RolloutStatusCompleted, models.RolloutStatusFailed, models.RolloutStatusCancelled},
+	models.RolloutStatusInProgress: {models.RolloutStatusCompleted, models.RolloutStatusFailed, models.RolloutStatusCancelled},
+	models.RolloutStatusCompleted:  {},
+	models.RolloutStatusFailed:     {},
+	models.RolloutStatusCancelled:  {},
+}
+
+// maxRolloutUpdateAttempts bounds retries when a rollout changes between read and write
+const maxRolloutUpdateAttempts = 3
+
+// UpdateRolloutStatus moves a rollout to a new status. Repeating the current
+// status is a no-op so agents can retry safely; transitions not allowed from
+// the current status are rejected. Failures are checked against the health
+// gate covering the rollout, and a failed update is rolled back.
 func (s *UpdateRolloutService) UpdateRolloutStatus(ctx context.Context, id primitive.ObjectID, status models.RolloutStatus, errorMessage string) (*models.UpdateRollout, error) {
-	if err := s.rolloutRepo.UpdateStatus(ctx, id, status, errorMessage); err != nil {
-		return nil, fmt.Errorf("failed to update rollout status: %w", err)
+	if _, ok := rolloutTransitions[status]; !ok {
+		return nil, fmt.Errorf("invalid rollout status: %s", status)
+	}
+
+	for attempt := 0; attempt < maxRolloutUpdateAttempts; attempt++ {
+		rollout, err := s.GetRollout(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+
+		// Duplicate report of the transition that already happened
+		if rollout.Status == status {
+			return rollout, nil
+		}
+		if !canTransitionRollout(rollout.Status, status) {
+			return nil, fmt.Errorf("invalid rollout transition: cannot move from %s to %s", rollout.Status, status)
+		}
+
+		var updated bool
+		err = s.outbox.Write(ctx, func(ctx context.Context) ([]DomainEvent, error) {
+			var err error
+			updated, err = s.rolloutRepo.UpdateStatusFrom(ctx, id, rollout.Status, status, errorMessage)
+			if err != nil || !updated || status != models.RolloutStatusFailed {
+				return nil, err
+			}
+			return []DomainEvent{rolloutFailedEvent(rollout, errorMessage, false)}, nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to update rollout status: %w", err)
+		}
+		if !updated {
+			// Another report got there first; re-check against its result
+			continue
+		}
+		details := map[string]interface{}{
+			"old_status": rollout.Status,
+			"new_status": status,
+		}
+		if errorMessage != "" {
+			details["error_message"] = errorMessage
+		}
+
+		previous := rollout
+		rollout, err = s.GetRollout(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		s.audit.LogChange(ctx, models.AuditActionUpdate, "update_rollout", id.Hex(), "", "", previous, rollout, details)
+		s.stream.RolloutStatusChanged(ctx, rollout)
+
+		if status == models.RolloutStatusFailed {
+			s.handleRolloutFailure(ctx, rollout)
+		}
+
+		return rollout, nil
+	}
+
+	return nil, fmt.Errorf("invalid rollout transition: rollout was modified concurrently, retry")
+}
+
+// handleRolloutFailure checks a failed rollout against the health gate and
+// rolls it back. The failure itself is already recorded, so follow-up errors
+// are logged rather than returned.
+func (s *UpdateRolloutService) handleRolloutFailure(ctx context.Context, rollout *models.UpdateRollout) {
+	if s.healthService != nil {
+		if _, err := s.healthService.EvaluateRollout(ctx, rollout, time.Now()); err != nil {
+			log.Printf("Rollout health check for %s failed: %v", rollout.ID.Hex(), err)
+		}
+	}
+	// Failed rollbacks are left for an operator rather than reverted again
+	if rollout.RollbackOf == nil {
+		if _, err := s.createRollback(ctx, rollout, models.RollbackTriggerFailure, rollout.ErrorMessage, schedulerUserID, ""); err != nil {
+			log.Printf("Automatic rollback of rollout %s failed: %v", rollout.ID.Hex(), err)
+		}
+	}
+}
+
+// rolloutFailedEvent builds the RolloutFailed event of a rollout
+func rolloutFailedEvent(rollout *models.UpdateRollout, errorMessage string, timedOut bool) *RolloutFailed {
+	return &RolloutFailed{
+		RolloutID:    rollout.ID.Hex(),
+		EndpointID:   rollout.EndpointID,
+		ProductID:    rollout.ProductID,
+		FromVersion:  rollout.FromVersion,
+		ToVersion:    rollout.ToVersion,
+		ErrorMessage: errorMessage,
+		TimedOut:     timedOut,
 	}
-	return s.GetRollout(ctx, id)
 }
 
-// UpdateRolloutProgress updates the progress of a rollout
+// UpdateRolloutProgress raises the progress of an active rollout. Progress
+// only moves forward; repeating the current value is a no-op.
 func (s *UpdateRolloutService) UpdateRolloutProgress(ctx context.Context, id primitive.ObjectID, progress int) (*models.UpdateRollout, error) {
-	if err := s.rolloutRepo.UpdateProgress(ctx, id, progress); err != nil {
-		return nil, fmt.Errorf("failed to update rollout progress: %w", err)
+	if progress < 0 || progress > 100 {
+		return nil, fmt.Errorf("invalid rollout progress value: progress must be between 0 and 100")
+	}
+
+	for attempt := 0; attempt < maxRolloutUpdateAttempts; attempt++ {
+		rollout, err := s.GetRollout(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+
+		// Duplicate report of the current progress
+		if rollout.Progress == progress {
+			return rollout, nil
+		}
+		if rollout.Status != models.RolloutStatusPending && rollout.Status != models.RolloutStatusInProgress {
+			return nil, fmt.Errorf("invalid rollout transition: cannot report progress on a %s rollout", rollout.Status)
+		}
+		if progress < rollout.Progress {
+			return nil, fmt.Errorf("invalid rollout progress: cannot go back from %d to %d", rollout.Progress, progress)
+		}
+
+		updated, err := s.rolloutRepo.UpdateProgressFrom(ctx, id, rollout.Progress, progress)
+		if err != nil {
+			return nil, fmt.Errorf("failed to update rollout progress: %w", err)
+		}
+		if updated {
+			previous := rollout
+			rollout, err := s.GetRollout(ctx, id)
+			if err != nil {
+				return nil, err
+			}
+			s.audit.LogChange(ctx, models.AuditActionUpdate, "update_rollout", id.Hex(), "", "", previous, rollout, map[string]interface{}{
+				"old_progress": previous.Progress,
+				"new_progress": progress,
+			})
+			s.stream.RolloutProgress(ctx, rollout)
+			return rollout, nil
+		}
+	}
+
+	return nil, fmt.Errorf("invalid rollout transition: rollout was modified concurrently, retry")
+}
+
+// RollbackRollout creates a rollout returning the endpoint of a failed or
+// completed rollout to its previous version. Rolling back a rollout that
+// already has a rollback returns the existing rollback.
+func (s *UpdateRolloutService) RollbackRollout(ctx context.Context, id primitive.ObjectID, reason, userID, userEmail string) (*models.UpdateRollout, error) {
+	original, err := s.GetRollout(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if original.RollbackOf != nil {
+		return nil, fmt.Errorf("invalid rollback: rollout %s is itself a rollback", original.ID.Hex())
+	}
+	if original.Status != models.RolloutStatusFailed && original.Status != models.RolloutStatusCompleted {
+		return nil, fmt.Errorf("invalid rollback: cannot roll back a %s rollout", original.Status)
+	}
+
+	return s.createRollback(ctx, original, models.RollbackTriggerOperator, reason, userID, userEmail)
+}
+
+// createRollback creates and links the rollback rollout of original. The link
+// is claimed on the original first so concurrent requests create only one
+// rollback; losers get the rollback that won.
+func (s *UpdateRolloutService) createRollback(ctx context.Context, original *models.UpdateRollout, trigger models.RollbackTrigger, reason, userID, userEmail string) (*models.UpdateRollout, error) {
+	if original.RollbackRolloutID != nil {
+		return s.existingRollback(ctx, *original.RollbackRolloutID)
+	}
+
+	if _, err := s.versionRepo.GetByProductIDAndVersion(ctx, original.ProductID, original.FromVersion); err != nil {
+		return nil, fmt.Errorf("rollback version %s not found for product %s: %w", original.FromVersion, original.ProductID, err)
+	}
+
+	// The rollback must not compete with another rollout for the endpoint
+	active, err := s.rolloutRepo.Count(ctx, bson.M{
+		"endpoint_id": original.EndpointID,
+		"product_id":  original.ProductID,
+		"status":      bson.M{"$in": activeRolloutStatuses},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to check active rollouts: %w", err)
+	}
+	if active > 0 {
+		return nil, fmt.Errorf("invalid rollback: endpoint %s has an active rollout", original.EndpointID)
+	}
+
+	rollbackID := primitive.NewObjectID()
+	linked, err := s.rolloutRepo.LinkRollback(ctx, original.ID, rollbackID)
+	if err != nil {
+		return nil, err
+	}
+	if !linked {
+		current, err := s.GetRollout(ctx, original.ID)
+		if err != nil {
+			return nil, err
+		}
+		return s.existingRollback(ctx, *current.RollbackRolloutID)
+	}
+
+	originalID := original.ID
+	rollback := &models.UpdateRollout{
+		ID:              rollbackID,
+		EndpointID:      original.EndpointID,
+		ProductID:       original.ProductID,
+		FromVersion:     original.ToVersion,
+		ToVersion:       original.FromVersion,
+		Status:          models.RolloutStatusPending,
+		InitiatedBy:     userID,
+		RollbackOf:      &originalID,
+		RollbackTrigger: trigger,
+		RollbackReason:  reason,
+	}
+	// Failed updates are reverted at once; operator rollbacks wait for the window
+	if trigger == models.RollbackTriggerOperator {
+		if err := s.holdRollback(ctx, rollback); err != nil {
+			if unlinkErr := s.rolloutRepo.UnlinkRollback(ctx, original.ID, rollbackID); unlinkErr != nil {
+				log.Printf("Failed to release rollback link of rollout %s: %v", original.ID.Hex(), unlinkErr)
+			}
+			return nil, err
+		}
+	}
+	if err := s.rolloutRepo.Create(ctx, rollback); err != nil {
+		if unlinkErr := s.rolloutRepo.UnlinkRollback(ctx, original.ID, rollbackID); unlinkErr != nil {
+			log.Printf("Failed to release rollback link of rollout %s: %v", original.ID.Hex(), unlinkErr)
+		}
+		return nil, fmt.Errorf("failed to create rollback rollout: %w", err)
+	}
+	s.stream.RolloutStatusChanged(ctx, rollback)
+
+	s.audit.LogChange(ctx, models.AuditActionCreate, "update_rollout", rollback.ID.Hex(), userID, userEmail, nil, rollback, map[string]interface{}{
+		"action":       "rollback",
+		"rollback_of":  original.ID.Hex(),
+		"trigger":      trigger,
+		"reason":       reason,
+		"from_version": rollback.FromVersion,
+		"to_version":   rollback.ToVersion,
+	})
+	before := auditState(original)
+	original.RollbackRolloutID = &rollbackID
+	s.audit.LogChange(ctx, models.AuditActionUpdate, "update_rollout", original.ID.Hex(), userID, userEmail, before, original, map[string]interface{}{
+		"action":              "rolled_back",
+		"rollback_rollout_id": rollback.ID.Hex(),
+		"trigger":             trigger,
+	})
+
+	return rollback, nil
+}
+
+// holdRollback schedules an operator rollback for the endpoint's maintenance window
+func (s *UpdateRolloutService) holdRollback(ctx context.Context, rollback *models.UpdateRollout) error {
+	if s.windows == nil {
+		return nil
+	}
+	endpoint, err := getRegisteredEndpoint(ctx, s.endpointRepo, rollback.EndpointID, rollback.ProductID)
+	if err != nil {
+		return err
+	}
+	return s.holdForMaintenanceWindow(ctx, rollback, endpoint, time.Now())
+}
+
+// existingRollback returns a rollback linked by an earlier request
+func (s *UpdateRolloutService) existingRollback(ctx context.Context, rollbackID primitive.ObjectID) (*models.UpdateRollout, error) {
+	rollback, err := s.rolloutRepo.GetByID(ctx, rollbackID)
+	if err != nil {
+		// Linked but not yet stored by a concurrent request
+		return nil, fmt.Errorf("invalid rollback: rollback %s is still being created, retry", rollbackID.Hex())
+	}
+	return rollback, nil
+}
+
+// DefaultRolloutTimeouts apply to products without their own rollout timeouts
+var DefaultRolloutTimeouts = models.RolloutTimeouts{
+	PendingMinutes:    24 * 60,
+	InProgressMinutes: 2 * 60,
+}
+
+const (
+	// minRolloutTimeoutMinutes and maxRolloutTimeoutMinutes bound configured timeouts
+	minRolloutTimeoutMinutes = 5
+	maxRolloutTimeoutMinutes = 30 * 24 * 60
+	// DefaultStuckRolloutPeriod is how far back the stuck report lists timed out rollouts
+	DefaultStuckRolloutPeriod = 7 * 24 * time.Hour
+)
+
+// overdueRollout is an active rollout past its phase timeout
+type overdueRollout struct {
+	rollout        *models.UpdateRollout
+	phaseStartedAt time.Time
+	timeoutMinutes int
+}
+
+// FailTimedOutRollouts fails pending and in-progress rollouts that stayed in
+// their phase longer than their product allows. Like agent-reported failures
+// they are checked against the health gate and rolled back. It returns how
+// many rollouts were failed.
+func (s *UpdateRolloutService) FailTimedOutRollouts(ctx context.Context, now time.Time) (int, error) {
+	overdue, err := s.overdueRollouts(ctx, now, "")
+	if err != nil {
+		return 0, err
+	}
+
+	failed := 0
+	for _, o := range overdue {
+		phase := o.rollout.Status
+		message := fmt.Sprintf("timed out: no agent report finished the %s phase within %d minutes", phase, o.timeoutMinutes)
+
+		// Rollouts the agent moved on in the meantime are left alone
+		var updated bool
+		err := s.outbox.Write(ctx, func(ctx context.Context) ([]DomainEvent, error) {
+			var err error
+			updated, err = s.rolloutRepo.MarkTimedOut(ctx, o.rollout.ID, phase, message)
+			if err != nil || !updated {
+				return nil, err
+			}
+			return []DomainEvent{rolloutFailedEvent(o.rollout, message, true)}, nil
+		})
+		if err != nil {
+			return failed, err
+		}
+		if !updated {
+			continue
+		}
+		failed++
+
+		rollout, err := s.GetRollout(ctx, o.rollout.ID)
+		if err != nil {
+			log.Printf("Failed to reload timed out rollout %s: %v", o.rollout.ID.Hex(), err)
+			continue
+		}
+		s.stream.RolloutStatusChanged(ctx, rollout)
+
+		s.audit.LogChange(ctx, models.AuditActionUpdate, "update_rollout", rollout.ID.Hex(), schedulerUserID, "", o.rollout, rollout, map[string]interface{}{
+			"action":           "timed_out",
+			"phase":            phase,
+			"phase_started_at": o.phaseStartedAt,
+			"timeout_minutes":  o.timeoutMinutes,
+		})
+
+		s.handleRolloutFailure(ctx, rollout)
+	}
+
+	return failed, nil
+}
+
+// GetStuckRollouts reports active rollouts past their phase timeout and
+// rollouts timed out since the given time, grouped by product, target version
+// and tenant. productID optionally narrows the report to one product.
+func (s *UpdateRolloutService) GetStuckRollouts(ctx context.Context, now, since time.Time, productID string) (*models.StuckRolloutReport, error) {
+	overdue, err := s.overdueRollouts(ctx, now, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := bson.M{
+		"timed_out_phase": bson.M{"$exists": true},
+		"failed_at":       bson.M{"$gte": since},
+	}
+	if productID != "" {
+		filter["product_id"] = productID
+	}
+	timedOut, err := s.rolloutRepo.List(ctx, filter, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list timed out rollouts: %w", err)
+	}
+
+	endpointIDs := make([]string, 0, len(overdue)+len(timedOut))
+	for _, o := range overdue {
+		endpointIDs = append(endpointIDs, o.rollout.EndpointID)
+	}
+	for _, rollout := range timedOut {
+		endpointIDs = append(endpointIDs, rollout.EndpointID)
+	}
+	tenants, err := s.endpointTenants(ctx, endpointIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.StuckRolloutReport{
+		GeneratedAt: now,
+		Since:       since,
+		Overdue:     len(overdue),
+		TimedOut:    len(timedOut),
+		Groups:      []models.StuckRolloutGroup{},
+	}
+	groups := make(map[string]int)
+	group := func(rollout *models.UpdateRollout) *models.StuckRolloutGroup {
+		tenantID := tenants[rollout.ProductID+"/"+rollout.EndpointID]
+		key := rollout.ProductID + "/" + rollout.ToVersion + "/" + tenantID
+		i, ok := groups[key]
+		if !ok {
+			i = len(report.Groups)
+			groups[key] = i
+			report.Groups = append(report.Groups, models.StuckRolloutGroup{
+				ProductID: rollout.ProductID,
+				ToVersion: rollout.ToVersion,
+				TenantID:  tenantID,
+			})
+		}
+		return &report.Groups[i]
+	}
+
+	for _, o := range overdue {
+		g := group(o.rollout)
+		g.Overdue++
+		g.Rollouts = append(g.Rollouts, models.StuckRollout{
+			RolloutID:      o.rollout.ID.Hex(),
+			EndpointID:     o.rollout.EndpointID,
+			Status:         o.rollout.Status,
+			Phase:          o.rollout.Status,
+			PhaseStartedAt: o.phaseStartedAt,
+			TimeoutMinutes: o.timeoutMinutes,
+		})
+	}
+	for _, rollout := range timedOut {
+		g := group(rollout)
+		g.TimedOut++
+		g.Rollouts = append(g.Rollouts, models.StuckRollout{
+			RolloutID:      rollout.ID.Hex(),
+			EndpointID:     rollout.EndpointID,
+			Status:         rollout.Status,
+			Phase:          rollout.TimedOutPhase,
+			PhaseStartedAt: rolloutPhaseStart(rollout, rollout.TimedOutPhase),
+			FailedAt:       rollout.FailedAt,
+		})
+	}
+
+	sort.Slice(report.Groups, func(i, j int) bool {
+		a, b := report.Groups[i], report.Groups[j]
+		if a.ProductID != b.ProductID {
+			return a.ProductID < b.ProductID
+		}
+		if a.ToVersion != b.ToVersion {
+			return a.ToVersion < b.ToVersion
+		}
+		return a.TenantID < b.TenantID
+	})
+	for i := range report.Groups {
+		rollouts := report.Groups[i].Rollouts
+		sort.Slice(rollouts, func(a, b int) bool {
+			return rollouts[a].PhaseStartedAt.Before(rollouts[b].PhaseStartedAt)
+		})
+	}
+
+	return report, nil
+}
+
+// overdueRollouts finds pending and in-progress rollouts past their product's
+// phase timeout
+func (s *UpdateRolloutService) overdueRollouts(ctx context.Context, now time.Time, productID string) ([]overdueRollout, error) {
+	// No phase can time out sooner than the shortest allowed timeout
+	filter := bson.M{
+		"status":       bson.M{"$in": []models.RolloutStatus{models.RolloutStatusPending, models.RolloutStatusInProgress}},
+		"initiated_at": bson.M{"$lte": now.Add(-minRolloutTimeoutMinutes * time.Minute)},
+	}
+	if productID != "" {
+		filter["product_id"] = productID
+	}
+	rollouts, err := s.rolloutRepo.List(ctx, filter, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list active rollouts: %w", err)
+	}
+
+	timeouts := make(map[string]models.RolloutTimeouts)
+	var overdue []overdueRollout
+	for _, rollout := range rollouts {
+		t, ok := timeouts[rollout.ProductID]
+		if !ok {
+			t, err = s.productRolloutTimeouts(ctx, rollout.ProductID)
+			if err != nil {
+				return nil, err
+			}
+			timeouts[rollout.ProductID] = t
+		}
+
+		minutes := t.PendingMinutes
+		if rollout.Status == models.RolloutStatusInProgress {
+			minutes = t.InProgressMinutes
+		}
+		started := rolloutPhaseStart(rollout, rollout.Status)
+		if now.Sub(started) > time.Duration(minutes)*time.Minute {
+			overdue = append(overdue, overdueRollout{rollout: rollout, phaseStartedAt: started, timeoutMinutes: minutes})
+		}
+	}
+
+	return overdue, nil
+}
+
+// productRolloutTimeouts returns a product's rollout timeouts with defaults
+// filled in
+func (s *UpdateRolloutService) productRolloutTimeouts(ctx context.Context, productID string) (models.RolloutTimeouts, error) {
+	product, err := s.productRepo.GetByProductID(ctx, productID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return DefaultRolloutTimeouts, nil
+		}
+		return models.RolloutTimeouts{}, fmt.Errorf("failed to get product: %w", err)
+	}
+	return effectiveRolloutTimeouts(product.RolloutTimeouts), nil
+}
+
+// effectiveRolloutTimeouts fills unset phases of configured timeouts with the defaults
+func effectiveRolloutTimeouts(configured *models.RolloutTimeouts) models.RolloutTimeouts {
+	timeouts := DefaultRolloutTimeouts
+	if configured != nil {
+		if configured.PendingMinutes > 0 {
+			timeouts.PendingMinutes = configured.PendingMinutes
+		}
+		if configured.InProgressMinutes > 0 {
+			timeouts.InProgressMinutes = configured.InProgressMinutes
+		}
+	}
+	return timeouts
+}
+
+// validateRolloutTimeouts checks configured rollout timeouts
+func validateRolloutTimeouts(timeouts *models.RolloutTimeouts) error {
+	if timeouts == nil {
+		return nil
+	}
+	phases := []struct {
+		name    string
+		minutes int
+	}{
+		{"pending_minutes", timeouts.PendingMinutes},
+		{"in_progress_minutes", timeouts.InProgressMinutes},
+	}
+	for _, phase := range phases {
+		if phase.minutes != 0 && (phase.minutes < minRolloutTimeoutMinutes || phase.minutes > maxRolloutTimeoutMinutes) {
+			return fmt.Errorf("invalid rollout timeouts: %s must be between %d and %d", phase.name, minRolloutTimeoutMinutes, maxRolloutTimeoutMinutes)
+		}
+	}
+	return nil
+}
+
+// rolloutPhaseStart returns when a rollout entered a phase. Pending starts at
+// creation, or when the maintenance window of a scheduled rollout opened.
+func rolloutPhaseStart(rollout *models.UpdateRollout, phase models.RolloutStatus) time.Time {
+	if phase == models.RolloutStatusInProgress && rollout.StartedAt != nil {
+		return *rollout.StartedAt
+	}
+	if rollout.ScheduledFor != nil && rollout.ScheduledFor.After(rollout.InitiatedAt) {
+		return *rollout.ScheduledFor
+	}
+	return rollout.InitiatedAt
+}
+
+// endpointTenants maps product/endpoint keys to the tenant of the registered endpoint
+func (s *UpdateRolloutService) endpointTenants(ctx context.Context, endpointIDs []string) (map[string]string, error) {
+	tenants := make(map[string]string)
+	if len(endpointIDs) == 0 {
+		return tenants, nil
+	}
+
+	endpoints, err := s.endpointRepo.FindAll(ctx, &repository.EndpointFilter{EndpointIDs: endpointIDs})
+	if err != nil {
+		return nil, err
+	}
+	for _, endpoint := range endpoints {
+		tenants[endpoint.ProductID+"/"+endpoint.EndpointID] = endpoint.TenantID.Hex()
+	}
+	return tenants, nil
+}
+
+// canTransitionRollout reports whether a rollout may move from one status to another
+func canTransitionRollout(from, to models.RolloutStatus) bool {
+	for _, allowed := range rolloutTransitions[from] {
+		if allowed == to {
+			return true
+		}
 	}
-	return s.GetRollout(ctx, id)
+	return false
 }
 
 // ListRollouts lists rollouts with filters
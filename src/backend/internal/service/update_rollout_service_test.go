@@ -0,0 +1,315 @@
+package service
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"updatemanager/internal/models"
+	"updatemanager/internal/repository"
+)
+
+func TestCanTransitionRollout(t *testing.T) {
+	tests := []struct {
+		from, to models.RolloutStatus
+		want     bool
+	}{
+		{models.RolloutStatusPending, models.RolloutStatusInProgress, true},
+		{models.RolloutStatusPending, models.RolloutStatusCompleted, true},
+		{models.RolloutStatusPending, models.RolloutStatusCancelled, true},
+		{models.RolloutStatusInProgress, models.RolloutStatusCompleted, true},
+		{models.RolloutStatusInProgress, models.RolloutStatusFailed, true},
+		{models.RolloutStatusInProgress, models.RolloutStatusPending, false},
+		{models.RolloutStatusCompleted, models.RolloutStatusPending, false},
+		{models.RolloutStatusFailed, models.RolloutStatusInProgress, false},
+		{models.RolloutStatusCancelled, models.RolloutStatusCompleted, false},
+		{models.RolloutStatusScheduled, models.RolloutStatusPending, true},
+		{models.RolloutStatusScheduled, models.RolloutStatusCancelled, true},
+		{models.RolloutStatusScheduled, models.RolloutStatusInProgress, false},
+		{models.RolloutStatusPending, models.RolloutStatusScheduled, false},
+	}
+
+	for _, tt := range tests {
+		if got := canTransitionRollout(tt.from, tt.to); got != tt.want {
+			t.Errorf("canTransitionRollout(%s, %s) = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestUpdateRolloutService_Rollback(t *testing.T) {
+	_, rolloutService, _ := setupRolloutHealthTest(t)
+	defer teardownCampaignServiceTestDB(t)
+
+	ctx := campaignServiceTestCtx
+	endpointIDs := seedCampaignTargets(t, "rollback-product", 2)
+
+	initiate := func(endpointID string) *models.UpdateRollout {
+		rollout, err := rolloutService.InitiateRollout(ctx, &models.UpdateRollout{
+			EndpointID:  endpointID,
+			ProductID:   "rollback-product",
+			FromVersion: "1.0.0",
+			ToVersion:   "2.0.0",
+			InitiatedBy: "user-123",
+		})
+		if err != nil {
+			t.Fatalf("Failed to initiate rollout: %v", err)
+		}
+		return rollout
+	}
+
+	// A failure creates a linked rollback back to the previous version
+	failed := initiate(endpointIDs[0])
+	if _, err := rolloutService.UpdateRolloutStatus(ctx, failed.ID, models.RolloutStatusFailed, "service did not start"); err != nil {
+		t.Fatalf("Failed to report failure: %v", err)
+	}
+	failed, _ = rolloutService.GetRollout(ctx, failed.ID)
+	if failed.RollbackRolloutID == nil {
+		t.Fatal("Expected failed rollout to be linked to a rollback")
+	}
+	rollback, err := rolloutService.GetRollout(ctx, *failed.RollbackRolloutID)
+	if err != nil {
+		t.Fatalf("Failed to get rollback rollout: %v", err)
+	}
+	if rollback.RollbackOf == nil || *rollback.RollbackOf != failed.ID || rollback.FromVersion != "2.0.0" || rollback.ToVersion != "1.0.0" {
+		t.Errorf("Unexpected rollback rollout: %+v", rollback)
+	}
+	if rollback.Status != models.RolloutStatusPending || rollback.RollbackTrigger != models.RollbackTriggerFailure || rollback.RollbackReason != "service did not start" {
+		t.Errorf("Unexpected rollback state: %+v", rollback)
+	}
+
+	// Asking again returns the same rollback; a rollback is not rolled back
+	again, err := rolloutService.RollbackRollout(ctx, failed.ID, "", "user-456", "")
+	if err != nil || again.ID != rollback.ID {
+		t.Errorf("Expected existing rollback, got %+v, %v", again, err)
+	}
+	if _, err := rolloutService.RollbackRollout(ctx, rollback.ID, "", "user-456", ""); err == nil || !strings.Contains(err.Error(), "invalid rollback") {
+		t.Errorf("Expected rollback of a rollback to be refused, got %v", err)
+	}
+
+	// A failed rollback is left for an operator
+	rolloutService.UpdateRolloutStatus(ctx, rollback.ID, models.RolloutStatusFailed, "")
+	count, _ := campaignRolloutRepo.Count(ctx, bson.M{"endpoint_id": endpointIDs[0]})
+	if count != 2 {
+		t.Errorf("Expected no rollback of the failed rollback, got %d rollouts", count)
+	}
+
+	// Operators can roll back completed rollouts, but not active ones
+	completed := initiate(endpointIDs[1])
+	if _, err := rolloutService.RollbackRollout(ctx, completed.ID, "", "user-456", ""); err == nil || !strings.Contains(err.Error(), "invalid rollback") {
+		t.Errorf("Expected rollback of a pending rollout to be refused, got %v", err)
+	}
+	rolloutService.UpdateRolloutStatus(ctx, completed.ID, models.RolloutStatusCompleted, "")
+	operatorRollback, err := rolloutService.RollbackRollout(ctx, completed.ID, "customer report", "user-456", "ops@example.com")
+	if err != nil {
+		t.Fatalf("Failed to roll back completed rollout: %v", err)
+	}
+	if operatorRollback.RollbackTrigger != models.RollbackTriggerOperator || operatorRollback.InitiatedBy != "user-456" {
+		t.Errorf("Unexpected operator rollback: %+v", operatorRollback)
+	}
+
+	// Both sides of the link are audited
+	for _, id := range []string{completed.ID.Hex(), operatorRollback.ID.Hex()} {
+		n, _ := campaignServiceTestDB.Collection("audit_logs").CountDocuments(ctx, bson.M{"resource_id": id})
+		if n != 1 {
+			t.Errorf("Expected one audit entry for %s, got %d", id, n)
+		}
+	}
+}
+
+func TestUpdateRolloutService_MaintenanceWindow(t *testing.T) {
+	setupCampaignServiceTestDB(t)
+	defer teardownCampaignServiceTestDB(t)
+
+	ctx := campaignServiceTestCtx
+	seedCampaignTargets(t, "window-product", 0)
+
+	deploymentRepo := repository.NewDeploymentRepository(campaignServiceTestDB.Collection("deployments"))
+	tenantRepo := repository.NewTenantRepository(campaignServiceTestDB.Collection("customer_tenants"))
+	rolloutService := NewUpdateRolloutService(campaignRolloutRepo, campaignDetectionRepo, campaignVersionRepo, campaignProductRepo, campaignEndpointRepo, nil, nil, NewMaintenanceWindowService(deploymentRepo, tenantRepo), nil, nil)
+
+	// The deployment's only window opens in two hours
+	opens := time.Now().UTC().Add(2 * time.Hour).Truncate(time.Minute)
+	deployment := &models.Deployment{
+		DeploymentID:     "window-deployment",
+		TenantID:         primitive.NewObjectID(),
+		ProductID:        "window-product",
+		DeploymentType:   models.DeploymentTypeProduction,
+		InstalledVersion: "1.0.0",
+		Status:           models.DeploymentStatusActive,
+		MaintenanceSchedule: &models.MaintenanceSchedule{Windows: []models.MaintenanceWindow{{
+			Weekdays:  []string{opens.Weekday().String()},
+			StartTime: opens.Format("15:04"),
+			EndTime:   opens.Add(time.Hour).Format("15:04"),
+		}}},
+	}
+	if err := deploymentRepo.Create(ctx, deployment); err != nil {
+		t.Fatalf("Failed to create deployment: %v", err)
+	}
+	campaignEndpointRepo.Create(ctx, &models.Endpoint{
+		EndpointID:   "window-endpoint",
+		DeploymentID: deployment.ID,
+		TenantID:     deployment.TenantID,
+		ProductID:    "window-product",
+		Hostname:     "window-endpoint.example.com",
+		Status:       models.EndpointStatusActive,
+	})
+
+	rollout, err := rolloutService.InitiateRollout(ctx, &models.UpdateRollout{
+		EndpointID:  "window-endpoint",
+		ProductID:   "window-product",
+		FromVersion: "1.0.0",
+		ToVersion:   "2.0.0",
+		InitiatedBy: "user-123",
+	})
+	if err != nil {
+		t.Fatalf("Failed to initiate rollout: %v", err)
+	}
+	if rollout.Status != models.RolloutStatusScheduled || rollout.ScheduledFor == nil || !rollout.ScheduledFor.Equal(opens) {
+		t.Fatalf("Expected rollout scheduled for %s, got %s %v", opens, rollout.Status, rollout.ScheduledFor)
+	}
+
+	// Agents cannot start a scheduled rollout
+	if _, err := rolloutService.UpdateRolloutStatus(ctx, rollout.ID, models.RolloutStatusInProgress, ""); err == nil || !strings.Contains(err.Error(), "invalid rollout transition") {
+		t.Errorf("Expected invalid transition, got %v", err)
+	}
+
+	// Nothing is released before the window opens
+	if released, _ := rolloutService.ReleaseScheduledRollouts(ctx, time.Now()); released != 0 {
+		t.Errorf("Expected no released rollouts, got %d", released)
+	}
+	released, err := rolloutService.ReleaseScheduledRollouts(ctx, opens.Add(time.Minute))
+	if err != nil || released != 1 {
+		t.Fatalf("Expected one released rollout, got %d, %v", released, err)
+	}
+	rollout, _ = rolloutService.GetRollout(ctx, rollout.ID)
+	if rollout.Status != models.RolloutStatusPending {
+		t.Errorf("Expected pending rollout, got %s", rollout.Status)
+	}
+}
+
+func TestUpdateRolloutService_TimesOutStuckRollouts(t *testing.T) {
+	_, rolloutService, _ := setupRolloutHealthTest(t)
+	defer teardownCampaignServiceTestDB(t)
+
+	ctx := campaignServiceTestCtx
+	endpointIDs := seedCampaignTargets(t, "timeout-product", 2)
+	product, _ := campaignProductRepo.GetByProductID(ctx, "timeout-product")
+	product.RolloutTimeouts = &models.RolloutTimeouts{InProgressMinutes: 60}
+	campaignProductRepo.Update(ctx, product)
+
+	var rollouts []*models.UpdateRollout
+	for _, endpointID := range endpointIDs {
+		rollout, err := rolloutService.InitiateRollout(ctx, &models.UpdateRollout{
+			EndpointID:  endpointID,
+			ProductID:   "timeout-product",
+			FromVersion: "1.0.0",
+			ToVersion:   "2.0.0",
+			InitiatedBy: "user-123",
+		})
+		if err != nil {
+			t.Fatalf("Failed to initiate rollout: %v", err)
+		}
+		rollouts = append(rollouts, rollout)
+	}
+	rolloutService.UpdateRolloutStatus(ctx, rollouts[0].ID, models.RolloutStatusInProgress, "")
+
+	// Only the in-progress rollout is past its product's timeout
+	now := time.Now()
+	if failed, err := rolloutService.FailTimedOutRollouts(ctx, now.Add(30*time.Minute)); err != nil || failed != 0 {
+		t.Errorf("Expected no timed out rollouts, got %d, %v", failed, err)
+	}
+	failed, err := rolloutService.FailTimedOutRollouts(ctx, now.Add(3*time.Hour))
+	if err != nil || failed != 1 {
+		t.Fatalf("Expected one timed out rollout, got %d, %v", failed, err)
+	}
+	timedOut, _ := rolloutService.GetRollout(ctx, rollouts[0].ID)
+	if timedOut.Status != models.RolloutStatusFailed || timedOut.TimedOutPhase != models.RolloutStatusInProgress || !strings.Contains(timedOut.ErrorMessage, "timed out") {
+		t.Errorf("Unexpected timed out rollout: %+v", timedOut)
+	}
+	if timedOut.RollbackRolloutID == nil {
+		t.Error("Expected timed out rollout to be rolled back")
+	}
+
+	// A day later the pending rollout and the rollback are overdue as well
+	report, err := rolloutService.GetStuckRollouts(ctx, now.Add(25*time.Hour), now.Add(-time.Hour), "timeout-product")
+	if err != nil {
+		t.Fatalf("Failed to get stuck rollouts: %v", err)
+	}
+	if report.Overdue != 2 || report.TimedOut != 1 || len(report.Groups) != 3 {
+		t.Fatalf("Unexpected report: %+v", report)
+	}
+	if report.Groups[0].ToVersion != "1.0.0" || report.Groups[0].Overdue != 1 || report.Groups[0].TenantID == "" {
+		t.Errorf("Expected rollback group first, got %+v", report.Groups[0])
+	}
+}
+
+func TestRolloutTimeouts(t *testing.T) {
+	timeouts := effectiveRolloutTimeouts(&models.RolloutTimeouts{InProgressMinutes: 30})
+	if timeouts.PendingMinutes != DefaultRolloutTimeouts.PendingMinutes || timeouts.InProgressMinutes != 30 {
+		t.Errorf("Unexpected effective timeouts: %+v", timeouts)
+	}
+
+	if err := validateRolloutTimeouts(&models.RolloutTimeouts{PendingMinutes: 60}); err != nil {
+		t.Errorf("Expected valid timeouts, got %v", err)
+	}
+	for _, invalid := range []models.RolloutTimeouts{{PendingMinutes: 1}, {InProgressMinutes: maxRolloutTimeoutMinutes + 1}} {
+		if err := validateRolloutTimeouts(&invalid); err == nil {
+			t.Errorf("Expected %+v to be invalid", invalid)
+		}
+	}
+
+	initiated := time.Date(2026, 10, 18, 10, 0, 0, 0, time.UTC)
+	scheduled := initiated.Add(12 * time.Hour)
+	started := scheduled.Add(time.Hour)
+	rollout := &models.UpdateRollout{InitiatedAt: initiated}
+	if got := rolloutPhaseStart(rollout, models.RolloutStatusPending); !got.Equal(initiated) {
+		t.Errorf("Expected pending phase to start at creation, got %s", got)
+	}
+	rollout.ScheduledFor = &scheduled
+	if got := rolloutPhaseStart(rollout, models.RolloutStatusPending); !got.Equal(scheduled) {
+		t.Errorf("Expected pending phase to start when the window opened, got %s", got)
+	}
+	rollout.StartedAt = &started
+	if got := rolloutPhaseStart(rollout, models.RolloutStatusInProgress); !got.Equal(started) {
+		t.Errorf("Expected in-progress phase to start when the agent started, got %s", got)
+	}
+}
+
+func TestSelectRollbackPackage(t *testing.T) {
+	from := &models.Version{VersionNumber: "2.0.0", Packages: []models.PackageInfo{
+		{PackageType: models.PackageTypeUpdate, FileName: "update"},
+		{PackageType: models.PackageTypeRollback, FileName: "rollback-any"},
+		{PackageType: models.PackageTypeRollback, FileName: "rollback-1.5-linux", OS: "linux", RestoresVersion: "1.5.0"},
+		{PackageType: models.PackageTypeRollback, FileName: "rollback-1.0-windows", OS: "windows", RestoresVersion: "1.0.0"},
+	}}
+	to := &models.Version{VersionNumber: "1.0.0", Packages: []models.PackageInfo{
+		{PackageType: models.PackageTypeUpdate, FileName: "update-1.0"},
+		{PackageType: models.PackageTypeFullInstaller, FileName: "full-1.0"},
+	}}
+
+	tests := []struct {
+		name     string
+		from     *models.Version
+		os       string
+		expected string
+	}{
+		{"package for the pair", from, "windows", "rollback-1.0-windows"},
+		{"generic rollback package", from, "linux", "rollback-any"},
+		{"full installer fallback", &models.Version{VersionNumber: "2.0.0"}, "linux", "full-1.0"},
+		{"unknown from version", nil, "linux", "full-1.0"},
+	}
+
+	for _, tt := range tests {
+		pkg := selectRollbackPackage(tt.from, to, tt.os, "amd64")
+		if pkg == nil || pkg.FileName != tt.expected {
+			t.Errorf("%s: selectRollbackPackage() = %+v, expected %s", tt.name, pkg, tt.expected)
+		}
+	}
+
+	if pkg := selectRollbackPackage(nil, &models.Version{VersionNumber: "1.0.0", Packages: to.Packages[:1]}, "linux", "amd64"); pkg != nil {
+		t.Errorf("Update packages cannot restore a version, got %+v", pkg)
+	}
+}
@@ -3,30 +3,48 @@ package service
 import (
 	"context"
 	"fmt"
+	"sort"
 
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo/options"
 
 	"updatemanager/internal/models"
 	"updatemanager/internal/repository"
+	"updatemanager/internal/utils"
 )
 
 // UpgradePathService handles upgrade path business logic
 type UpgradePathService struct {
 	upgradePathRepo *repository.UpgradePathRepository
+	ruleRepo        *repository.UpgradePathRuleRepository
 	versionRepo     *repository.VersionRepository
+	productRepo     *repository.ProductRepository
+	audit           *AuditLogger
 }
 
 // NewUpgradePathService creates a new upgrade path service
-func NewUpgradePathService(upgradePathRepo *repository.UpgradePathRepository, versionRepo *repository.VersionRepository) *UpgradePathService {
+func NewUpgradePathService(upgradePathRepo *repository.UpgradePathRepository, ruleRepo *repository.UpgradePathRuleRepository, versionRepo *repository.VersionRepository, productRepo *repository.ProductRepository, audit *AuditLogger) *UpgradePathService {
 	return &UpgradePathService{
 		upgradePathRepo: upgradePathRepo,
+		ruleRepo:        ruleRepo,
 		versionRepo:     versionRepo,
+		productRepo:     productRepo,
+		audit:           audit,
 	}
 }
 
+// ListUpgradePathsQuery represents query parameters for listing upgrade paths
+type ListUpgradePathsQuery struct {
+	FromVersion string
+	ToVersion   string
+	IsBlocked   *bool
+	Page        int
+	Limit       int
+}
+
 // CreateUpgradePath creates a new upgrade path with validation
-func (s *UpgradePathService) CreateUpgradePath(ctx context.Context, path *models.UpgradePath) error {
+func (s *UpgradePathService) CreateUpgradePath(ctx context.Context, path *models.UpgradePath, userID, userEmail string) error {
 	// Validate versions exist
 	_, err := s.versionRepo.GetByProductIDAndVersion(ctx, path.ProductID, path.FromVersion)
 	if err != nil {
@@ -48,6 +66,15 @@ func (s *UpgradePathService) CreateUpgradePath(ctx context.Context, path *models
 		return fmt.Errorf("failed to create upgrade path: %w", err)
 	}
 
+	// Log audit
+	s.audit.LogChange(ctx, models.AuditActionCreate, "upgrade_path", path.ID.Hex(), userID, userEmail, nil, path, map[string]interface{}{
+		"product_id":            path.ProductID,
+		"from_version":          path.FromVersion,
+		"to_version":            path.ToVersion,
+		"path_type":             path.PathType,
+		"intermediate_versions": path.IntermediateVersions,
+	})
+
 	return nil
 }
 
@@ -82,13 +109,123 @@ func (s *UpgradePathService) GetUpgradePathsByProduct(ctx context.Context, produ
 	return paths, total, nil
 }
 
+// ListUpgradePaths lists upgrade paths for a product with optional from/to/blocked filters
+func (s *UpgradePathService) ListUpgradePaths(ctx context.Context, productID string, query *ListUpgradePathsQuery) ([]*models.UpgradePath, int64, error) {
+	filter := bson.M{"product_id": productID}
+	if query.FromVersion != "" {
+		filter["from_version"] = query.FromVersion
+	}
+	if query.ToVersion != "" {
+		filter["to_version"] = query.ToVersion
+	}
+	if query.IsBlocked != nil {
+		filter["is_blocked"] = *query.IsBlocked
+	}
+
+	opts := options.Find()
+	if query.Limit > 0 {
+		opts.SetLimit(int64(query.Limit))
+		opts.SetSkip(int64((query.Page - 1) * query.Limit))
+	}
+	opts.SetSort(bson.D{{Key: "from_version", Value: 1}, {Key: "to_version", Value: 1}})
+
+	paths, err := s.upgradePathRepo.List(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list upgrade paths: %w", err)
+	}
+
+	total, err := s.upgradePathRepo.Count(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count upgrade paths: %w", err)
+	}
+
+	return paths, total, nil
+}
+
+// UpdateUpgradePath updates the path type and intermediate versions of an upgrade path.
+// Blocking is managed through BlockUpgradePath and UnblockUpgradePath.
+func (s *UpgradePathService) UpdateUpgradePath(ctx context.Context, productID, fromVersion, toVersion string, req *models.UpdateUpgradePathRequest, userID, userEmail string) (*models.UpgradePath, error) {
+	path, err := s.upgradePathRepo.GetByProductIDAndVersions(ctx, productID, fromVersion, toVersion)
+	if err != nil {
+		return nil, fmt.Errorf("upgrade path not found: %w", err)
+	}
+
+	before := auditState(path)
+
+	if req.PathType != nil {
+		if *req.PathType == models.UpgradePathTypeBlocked {
+			return nil, fmt.Errorf("invalid path_type: use the block endpoint to block an upgrade path")
+		}
+		if *req.PathType != models.UpgradePathTypeDirect && *req.PathType != models.UpgradePathTypeMultiStep {
+			return nil, fmt.Errorf("invalid path_type '%s'", *req.PathType)
+		}
+		if path.IsBlocked {
+			return nil, fmt.Errorf("upgrade path is blocked; unblock it before changing path_type")
+		}
+		path.PathType = *req.PathType
+	}
+
+	if req.IntermediateVersions != nil {
+		for _, v := range req.IntermediateVersions {
+			if utils.CompareVersions(v, path.FromVersion) <= 0 || utils.CompareVersions(v, path.ToVersion) >= 0 {
+				return nil, fmt.Errorf("invalid intermediate version '%s': must be between '%s' and '%s'", v, path.FromVersion, path.ToVersion)
+			}
+			if _, err := s.versionRepo.GetByProductIDAndVersion(ctx, productID, v); err != nil {
+				return nil, fmt.Errorf("intermediate version '%s' not found: %w", v, err)
+			}
+		}
+		path.IntermediateVersions = req.IntermediateVersions
+	}
+
+	if path.PathType == models.UpgradePathTypeMultiStep && len(path.IntermediateVersions) == 0 {
+		return nil, fmt.Errorf("invalid upgrade path: multi_step paths require intermediate_versions")
+	}
+
+	if err := s.upgradePathRepo.Update(ctx, path); err != nil {
+		return nil, fmt.Errorf("failed to update upgrade path: %w", err)
+	}
+
+	// Log audit
+	s.audit.LogChange(ctx, models.AuditActionUpdate, "upgrade_path", path.ID.Hex(), userID, userEmail, before, path, map[string]interface{}{
+		"product_id":   path.ProductID,
+		"from_version": path.FromVersion,
+		"to_version":   path.ToVersion,
+	})
+
+	return path, nil
+}
+
+// DeleteUpgradePath deletes an upgrade path
+func (s *UpgradePathService) DeleteUpgradePath(ctx context.Context, productID, fromVersion, toVersion, userID, userEmail string) error {
+	path, err := s.upgradePathRepo.GetByProductIDAndVersions(ctx, productID, fromVersion, toVersion)
+	if err != nil {
+		return fmt.Errorf("upgrade path not found: %w", err)
+	}
+
+	if err := s.upgradePathRepo.Delete(ctx, path.ID); err != nil {
+		return fmt.Errorf("failed to delete upgrade path: %w", err)
+	}
+
+	// Log audit
+	s.audit.LogChange(ctx, models.AuditActionDelete, "upgrade_path", path.ID.Hex(), userID, userEmail, path, nil, map[string]interface{}{
+		"product_id":   path.ProductID,
+		"from_version": path.FromVersion,
+		"to_version":   path.ToVersion,
+		"path_type":    path.PathType,
+		"is_blocked":   path.IsBlocked,
+	})
+
+	return nil
+}
+
 // BlockUpgradePath blocks an upgrade path
-func (s *UpgradePathService) BlockUpgradePath(ctx context.Context, productID, fromVersion, toVersion, reason string) error {
+func (s *UpgradePathService) BlockUpgradePath(ctx context.Context, productID, fromVersion, toVersion, reason, userID, userEmail string) error {
 	path, err := s.upgradePathRepo.GetByProductIDAndVersions(ctx, productID, fromVersion, toVersion)
 	if err != nil {
 		return fmt.Errorf("upgrade path not found: %w", err)
 	}
 
+	before := auditState(path)
 	path.IsBlocked = true
 	path.BlockReason = reason
 	path.PathType = models.UpgradePathTypeBlocked
@@ -97,5 +234,372 @@ func (s *UpgradePathService) BlockUpgradePath(ctx context.Context, productID, fr
 		return fmt.Errorf("failed to block upgrade path: %w", err)
 	}
 
+	// Log audit
+	s.audit.LogChange(ctx, models.AuditActionUpdate, "upgrade_path", path.ID.Hex(), userID, userEmail, before, path, map[string]interface{}{
+		"product_id":   path.ProductID,
+		"from_version": path.FromVersion,
+		"to_version":   path.ToVersion,
+		"action":       "block",
+		"block_reason": reason,
+	})
+
+	return nil
+}
+
+// UnblockUpgradePath unblocks an upgrade path. The path type is restored to
+// multi_step when intermediate versions are set and direct otherwise.
+func (s *UpgradePathService) UnblockUpgradePath(ctx context.Context, productID, fromVersion, toVersion, userID, userEmail string) (*models.UpgradePath, error) {
+	path, err := s.upgradePathRepo.GetByProductIDAndVersions(ctx, productID, fromVersion, toVersion)
+	if err != nil {
+		return nil, fmt.Errorf("upgrade path not found: %w", err)
+	}
+
+	if !path.IsBlocked {
+		return nil, fmt.Errorf("upgrade path is not blocked")
+	}
+
+	before := auditState(path)
+	previousReason := path.BlockReason
+	path.IsBlocked = false
+	path.BlockReason = ""
+	if len(path.IntermediateVersions) > 0 {
+		path.PathType = models.UpgradePathTypeMultiStep
+	} else {
+		path.PathType = models.UpgradePathTypeDirect
+	}
+
+	if err := s.upgradePathRepo.Update(ctx, path); err != nil {
+		return nil, fmt.Errorf("failed to unblock upgrade path: %w", err)
+	}
+
+	// Log audit
+	s.audit.LogChange(ctx, models.AuditActionUpdate, "upgrade_path", path.ID.Hex(), userID, userEmail, before, path, map[string]interface{}{
+		"product_id":            path.ProductID,
+		"from_version":          path.FromVersion,
+		"to_version":            path.ToVersion,
+		"action":                "unblock",
+		"previous_block_reason": previousReason,
+		"path_type":             path.PathType,
+	})
+
+	return path, nil
+}
+
+// CreateUpgradePathRule creates a new pattern-based upgrade path rule
+func (s *UpgradePathService) CreateUpgradePathRule(ctx context.Context, productID string, req *models.CreateUpgradePathRuleRequest, userID, userEmail string) (*models.UpgradePathRule, error) {
+	// Validate product exists
+	if _, err := s.productRepo.GetByProductID(ctx, productID); err != nil {
+		return nil, fmt.Errorf("product not found: %w", err)
+	}
+
+	rule := &models.UpgradePathRule{
+		ProductID:            productID,
+		Name:                 req.Name,
+		Description:          req.Description,
+		FromVersion:          req.FromVersion,
+		ToVersion:            req.ToVersion,
+		PathType:             req.PathType,
+		IntermediateVersions: req.IntermediateVersions,
+		BlockReason:          req.BlockReason,
+		Priority:             req.Priority,
+		IsActive:             true,
+		CreatedBy:            userID,
+	}
+	if req.IsActive != nil {
+		rule.IsActive = *req.IsActive
+	}
+
+	if err := validateUpgradePathRule(rule); err != nil {
+		return nil, err
+	}
+
+	if err := s.ruleRepo.Create(ctx, rule); err != nil {
+		return nil, fmt.Errorf("failed to create upgrade path rule: %w", err)
+	}
+
+	// Log audit
+	s.audit.LogChange(ctx, models.AuditActionCreate, "upgrade_path_rule", rule.ID.Hex(), userID, userEmail, nil, rule, map[string]interface{}{
+		"product_id":   rule.ProductID,
+		"name":         rule.Name,
+		"from_version": rule.FromVersion,
+		"to_version":   rule.ToVersion,
+		"path_type":    rule.PathType,
+	})
+
+	return rule, nil
+}
+
+// GetUpgradePathRule retrieves an upgrade path rule of a product by ID. A rule
+// of another product is not found.
+func (s *UpgradePathService) GetUpgradePathRule(ctx context.Context, productID string, id primitive.ObjectID) (*models.UpgradePathRule, error) {
+	rule, err := s.ruleRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("upgrade path rule not found: %w", err)
+	}
+	if rule.ProductID != productID {
+		return nil, fmt.Errorf("upgrade path rule not found for product '%s'", productID)
+	}
+	return rule, nil
+}
+
+// ListUpgradePathRules lists upgrade path rules for a product in evaluation order
+func (s *UpgradePathService) ListUpgradePathRules(ctx context.Context, productID string, page, limit int) ([]*models.UpgradePathRule, int64, error) {
+	opts := options.Find()
+	if limit > 0 {
+		opts.SetLimit(int64(limit))
+		opts.SetSkip(int64((page - 1) * limit))
+	}
+	opts.SetSort(bson.D{{Key: "priority", Value: 1}, {Key: "created_at", Value: 1}})
+
+	filter := bson.M{"product_id": productID}
+	rules, err := s.ruleRepo.List(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list upgrade path rules: %w", err)
+	}
+
+	total, err := s.ruleRepo.Count(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count upgrade path rules: %w", err)
+	}
+
+	return rules, total, nil
+}
+
+// UpdateUpgradePathRule updates an existing upgrade path rule
+func (s *UpgradePathService) UpdateUpgradePathRule(ctx context.Context, productID string, id primitive.ObjectID, req *models.UpdateUpgradePathRuleRequest, userID, userEmail string) (*models.UpgradePathRule, error) {
+	rule, err := s.GetUpgradePathRule(ctx, productID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	before := auditState(rule)
+	if req.Name != nil {
+		rule.Name = *req.Name
+	}
+	if req.Description != nil {
+		rule.Description = *req.Description
+	}
+	if req.FromVersion != nil {
+		rule.FromVersion = *req.FromVersion
+	}
+	if req.ToVersion != nil {
+		rule.ToVersion = *req.ToVersion
+	}
+	if req.PathType != nil {
+		rule.PathType = *req.PathType
+	}
+	if req.IntermediateVersions != nil {
+		rule.IntermediateVersions = req.IntermediateVersions
+	}
+	if req.BlockReason != nil {
+		rule.BlockReason = *req.BlockReason
+	}
+	if req.Priority != nil {
+		rule.Priority = *req.Priority
+	}
+	if req.IsActive != nil {
+		rule.IsActive = *req.IsActive
+	}
+
+	if err := validateUpgradePathRule(rule); err != nil {
+		return nil, err
+	}
+
+	if err := s.ruleRepo.Update(ctx, rule); err != nil {
+		return nil, fmt.Errorf("failed to update upgrade path rule: %w", err)
+	}
+
+	// Log audit
+	s.audit.LogChange(ctx, models.AuditActionUpdate, "upgrade_path_rule", rule.ID.Hex(), userID, userEmail, before, rule, map[string]interface{}{
+		"product_id": rule.ProductID,
+		"name":       rule.Name,
+	})
+
+	return rule, nil
+}
+
+// DeleteUpgradePathRule deletes an upgrade path rule
+func (s *UpgradePathService) DeleteUpgradePathRule(ctx context.Context, productID string, id primitive.ObjectID, userID, userEmail string) error {
+	rule, err := s.GetUpgradePathRule(ctx, productID, id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.ruleRepo.Delete(ctx, id); err != nil {
+		return fmt.Errorf("failed to delete upgrade path rule: %w", err)
+	}
+
+	// Log audit
+	s.audit.LogChange(ctx, models.AuditActionDelete, "upgrade_path_rule", id.Hex(), userID, userEmail, rule, nil, map[string]interface{}{
+		"product_id": rule.ProductID,
+		"name":       rule.Name,
+	})
+
+	return nil
+}
+
+// ResolveUpgradePath determines the effective route between two versions.
+// An explicit UpgradePath document always wins; otherwise active rules are
+// evaluated in priority order and the first match decides. Without a match
+// the route is direct. The result records what decided the route.
+func (s *UpgradePathService) ResolveUpgradePath(ctx context.Context, productID, fromVersion, toVersion string) (*models.UpgradePathResolution, error) {
+	resolution := &models.UpgradePathResolution{
+		ProductID:   productID,
+		FromVersion: fromVersion,
+		ToVersion:   toVersion,
+	}
+
+	// Explicit paths take precedence over rules
+	path, err := s.upgradePathRepo.GetByProductIDAndVersions(ctx, productID, fromVersion, toVersion)
+	if err == nil && path != nil {
+		resolution.PathType = path.PathType
+		resolution.IntermediateVersions = path.IntermediateVersions
+		resolution.IsBlocked = path.IsBlocked
+		resolution.BlockReason = path.BlockReason
+		resolution.DecidedBy = models.UpgradePathDecisionExplicit
+		resolution.UpgradePathID = path.ID.Hex()
+		return resolution, nil
+	}
+
+	rules, err := s.ruleRepo.GetActiveByProductID(ctx, productID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get upgrade path rules: %w", err)
+	}
+
+	var releasedVersions []string
+	if len(rules) > 0 {
+		versions, err := s.versionRepo.List(ctx, bson.M{"product_id": productID, "state": models.VersionStateReleased}, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get released versions: %w", err)
+		}
+		for _, v := range versions {
+			releasedVersions = append(releasedVersions, v.VersionNumber)
+		}
+	}
+
+	for _, rule := range rules {
+		matched, intermediates, reason := evaluateUpgradePathRule(rule, fromVersion, toVersion, releasedVersions)
+		resolution.Evaluations = append(resolution.Evaluations, models.UpgradePathRuleEvaluation{
+			RuleID:   rule.ID.Hex(),
+			RuleName: rule.Name,
+			Priority: rule.Priority,
+			Matched:  matched,
+			Reason:   reason,
+		})
+		if !matched {
+			continue
+		}
+
+		resolution.DecidedBy = models.UpgradePathDecisionRule
+		resolution.RuleID = rule.ID.Hex()
+		resolution.RuleName = rule.Name
+
+		switch {
+		case rule.PathType == models.UpgradePathTypeBlocked:
+			resolution.PathType = models.UpgradePathTypeBlocked
+			resolution.IsBlocked = true
+			resolution.BlockReason = rule.BlockReason
+		case rule.PathType == models.UpgradePathTypeMultiStep && intermediates == nil:
+			// A required intermediate has no released version yet
+			resolution.PathType = models.UpgradePathTypeBlocked
+			resolution.IsBlocked = true
+			resolution.BlockReason = reason
+		case len(intermediates) > 0:
+			resolution.PathType = models.UpgradePathTypeMultiStep
+			resolution.IntermediateVersions = intermediates
+		default:
+			resolution.PathType = models.UpgradePathTypeDirect
+		}
+		return resolution, nil
+	}
+
+	resolution.PathType = models.UpgradePathTypeDirect
+	resolution.DecidedBy = models.UpgradePathDecisionDefault
+	return resolution, nil
+}
+
+// evaluateUpgradePathRule checks whether a rule applies to a route. For
+// multi-step rules it resolves each intermediate pattern to the highest
+// released version between from and to; intermediates the from version
+// already satisfies are skipped. A nil intermediates slice on a matched
+// multi-step rule means a pattern could not be resolved.
+func evaluateUpgradePathRule(rule *models.UpgradePathRule, fromVersion, toVersion string, releasedVersions []string) (bool, []string, string) {
+	if !utils.MatchVersionPattern(rule.FromVersion, fromVersion) {
+		return false, nil, fmt.Sprintf("from version %s does not match '%s'", fromVersion, rule.FromVersion)
+	}
+
+	if rule.ToVersion == models.UpgradeRuleTargetLatestPatch {
+		if !utils.IsSameMinor(fromVersion, toVersion) || !utils.IsVersionNewer(toVersion, fromVersion) {
+			return false, nil, fmt.Sprintf("to version %s is not a newer patch of %s", toVersion, fromVersion)
+		}
+		for _, v := range releasedVersions {
+			if utils.IsSameMinor(v, fromVersion) && utils.IsVersionNewer(v, toVersion) {
+				return false, nil, fmt.Sprintf("to version %s is not the latest patch (%s is newer)", toVersion, v)
+			}
+		}
+	} else if !utils.MatchVersionPattern(rule.ToVersion, toVersion) {
+		return false, nil, fmt.Sprintf("to version %s does not match '%s'", toVersion, rule.ToVersion)
+	}
+
+	if rule.PathType != models.UpgradePathTypeMultiStep {
+		return true, []string{}, fmt.Sprintf("matched: %s", rule.PathType)
+	}
+
+	intermediates := []string{}
+	for _, pattern := range rule.IntermediateVersions {
+		if utils.MatchVersionPattern(pattern, fromVersion) {
+			continue
+		}
+		best := ""
+		for _, v := range releasedVersions {
+			if !utils.MatchVersionPattern(pattern, v) || !utils.IsVersionNewer(v, fromVersion) || !utils.IsVersionOlder(v, toVersion) {
+				continue
+			}
+			if best == "" || utils.IsVersionNewer(v, best) {
+				best = v
+			}
+		}
+		if best == "" {
+			return true, nil, fmt.Sprintf("no released version matches required intermediate '%s'", pattern)
+		}
+		intermediates = append(intermediates, best)
+	}
+	sort.Slice(intermediates, func(i, j int) bool {
+		return utils.IsVersionOlder(intermediates[i], intermediates[j])
+	})
+
+	return true, intermediates, fmt.Sprintf("matched: %s via %v", rule.PathType, intermediates)
+}
+
+// validateUpgradePathRule validates rule patterns and path type
+func validateUpgradePathRule(rule *models.UpgradePathRule) error {
+	if rule.Name == "" {
+		return fmt.Errorf("invalid rule: name is required")
+	}
+
+	if _, err := utils.ParseVersionPattern(rule.FromVersion); err != nil {
+		return fmt.Errorf("invalid from_version: %w", err)
+	}
+	if rule.ToVersion != models.UpgradeRuleTargetLatestPatch {
+		if _, err := utils.ParseVersionPattern(rule.ToVersion); err != nil {
+			return fmt.Errorf("invalid to_version: %w", err)
+		}
+	}
+
+	switch rule.PathType {
+	case models.UpgradePathTypeDirect, models.UpgradePathTypeBlocked:
+	case models.UpgradePathTypeMultiStep:
+		if len(rule.IntermediateVersions) == 0 {
+			return fmt.Errorf("invalid rule: multi_step rules require intermediate_versions")
+		}
+		for _, pattern := range rule.IntermediateVersions {
+			if _, err := utils.ParseVersionPattern(pattern); err != nil {
+				return fmt.Errorf("invalid intermediate version: %w", err)
+			}
+		}
+	default:
+		return fmt.Errorf("invalid path_type '%s'", rule.PathType)
+	}
+
 	return nil
 }
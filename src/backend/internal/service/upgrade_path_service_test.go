@@ -2,9 +2,12 @@ package service
 
 import (
 	"context"
+	"strings"
 	"testing"
 	"time"
 
+	"go.mongodb.org/mongo-driver/bson"
+
 	"updatemanager/internal/models"
 	"updatemanager/internal/repository"
 	"updatemanager/pkg/database"
@@ -15,8 +18,10 @@ var (
 	upgradePathServiceTestCtx context.Context
 	upgradePathService        *UpgradePathService
 	upgradePathRepo           *repository.UpgradePathRepository
+	upgradePathRuleRepo       *repository.UpgradePathRuleRepository
 	upgradePathVersionRepo    *repository.VersionRepository
 	upgradePathProductRepo    *repository.ProductRepository
+	upgradePathAuditRepo      *repository.AuditLogRepository
 )
 
 func setupUpgradePathServiceTestDB(t *testing.T) {
@@ -38,14 +43,18 @@ func setupUpgradePathServiceTestDB(t *testing.T) {
 	upgradePathRepo = repository.NewUpgradePathRepository(db.Collection("upgrade_paths"))
 	upgradePathVersionRepo = repository.NewVersionRepository(db.Collection("versions"))
 	upgradePathProductRepo = repository.NewProductRepository(db.Collection("products"))
-	upgradePathService = NewUpgradePathService(upgradePathRepo, upgradePathVersionRepo)
+	upgradePathRuleRepo = repository.NewUpgradePathRuleRepository(db.Collection("upgrade_path_rules"))
+	upgradePathAuditRepo = repository.NewAuditLogRepository(db.Collection("audit_logs"))
+	upgradePathService = NewUpgradePathService(upgradePathRepo, upgradePathRuleRepo, upgradePathVersionRepo, upgradePathProductRepo, NewAuditLogger(upgradePathAuditRepo))
 }
 
 func teardownUpgradePathServiceTestDB(t *testing.T) {
 	if upgradePathServiceTestDB != nil {
 		_ = upgradePathServiceTestDB.Collection("upgrade_paths").Drop(upgradePathServiceTestCtx)
+		_ = upgradePathServiceTestDB.Collection("upgrade_path_rules").Drop(upgradePathServiceTestCtx)
 		_ = upgradePathServiceTestDB.Collection("versions").Drop(upgradePathServiceTestCtx)
 		_ = upgradePathServiceTestDB.Collection("products").Drop(upgradePathServiceTestCtx)
+		_ = upgradePathServiceTestDB.Collection("audit_logs").Drop(upgradePathServiceTestCtx)
 		_ = upgradePathServiceTestDB.Disconnect(upgradePathServiceTestCtx)
 	}
 }
@@ -92,7 +101,7 @@ func TestUpgradePathService_CreateUpgradePath(t *testing.T) {
 		IsBlocked:   false,
 	}
 
-	err := upgradePathService.CreateUpgradePath(upgradePathServiceTestCtx, path)
+	err := upgradePathService.CreateUpgradePath(upgradePathServiceTestCtx, path, "user-123", "")
 	if err != nil {
 		t.Fatalf("Failed to create upgrade path: %v", err)
 	}
@@ -124,7 +133,7 @@ func TestUpgradePathService_CreateUpgradePath_VersionNotFound(t *testing.T) {
 		PathType:    models.UpgradePathTypeDirect,
 	}
 
-	err := upgradePathService.CreateUpgradePath(upgradePathServiceTestCtx, path)
+	err := upgradePathService.CreateUpgradePath(upgradePathServiceTestCtx, path, "user-123", "")
 	if err == nil {
 		t.Error("Expected error for non-existent version, got nil")
 	}
@@ -172,11 +181,11 @@ func TestUpgradePathService_BlockUpgradePath(t *testing.T) {
 		PathType:    models.UpgradePathTypeDirect,
 		IsBlocked:   false,
 	}
-	upgradePathService.CreateUpgradePath(upgradePathServiceTestCtx, path)
+	upgradePathService.CreateUpgradePath(upgradePathServiceTestCtx, path, "user-123", "")
 
 	// Block the path
 	reason := "Breaking changes detected"
-	err := upgradePathService.BlockUpgradePath(upgradePathServiceTestCtx, product.ProductID, "1.0.0", "2.0.0", reason)
+	err := upgradePathService.BlockUpgradePath(upgradePathServiceTestCtx, product.ProductID, "1.0.0", "2.0.0", reason, "user-123", "")
 	if err != nil {
 		t.Fatalf("Failed to block upgrade path: %v", err)
 	}
@@ -195,3 +204,294 @@ func TestUpgradePathService_BlockUpgradePath(t *testing.T) {
 
 	t.Logf("Blocked upgrade path: %+v", retrieved)
 }
+
+func TestUpgradePathService_UpgradePathLifecycle(t *testing.T) {
+	setupUpgradePathServiceTestDB(t)
+	defer teardownUpgradePathServiceTestDB(t)
+
+	productID := "lifecycle-path-product"
+	for _, v := range []string{"1.0.0", "1.5.0", "2.0.0", "3.0.0"} {
+		upgradePathVersionRepo.Create(upgradePathServiceTestCtx, &models.Version{
+			ProductID:     productID,
+			VersionNumber: v,
+			ReleaseDate:   time.Now(),
+			ReleaseType:   models.ReleaseTypeFeature,
+			State:         models.VersionStateReleased,
+			CreatedBy:     "user-123",
+		})
+	}
+
+	for _, to := range []string{"2.0.0", "3.0.0"} {
+		path := &models.UpgradePath{
+			ProductID:   productID,
+			FromVersion: "1.0.0",
+			ToVersion:   to,
+			PathType:    models.UpgradePathTypeDirect,
+		}
+		if err := upgradePathService.CreateUpgradePath(upgradePathServiceTestCtx, path, "user-123", ""); err != nil {
+			t.Fatalf("Failed to create upgrade path: %v", err)
+		}
+	}
+
+	// Edit intermediate versions
+	multiStep := models.UpgradePathTypeMultiStep
+	updated, err := upgradePathService.UpdateUpgradePath(upgradePathServiceTestCtx, productID, "1.0.0", "2.0.0", &models.UpdateUpgradePathRequest{
+		PathType:             &multiStep,
+		IntermediateVersions: []string{"1.5.0"},
+	}, "user-123", "")
+	if err != nil {
+		t.Fatalf("Failed to update upgrade path: %v", err)
+	}
+	if updated.PathType != models.UpgradePathTypeMultiStep || len(updated.IntermediateVersions) != 1 {
+		t.Errorf("Unexpected updated path: %+v", updated)
+	}
+
+	// Intermediate versions outside the range are rejected
+	if _, err := upgradePathService.UpdateUpgradePath(upgradePathServiceTestCtx, productID, "1.0.0", "2.0.0", &models.UpdateUpgradePathRequest{
+		IntermediateVersions: []string{"3.0.0"},
+	}, "user-123", ""); err == nil {
+		t.Error("Expected error for intermediate version outside the path range")
+	}
+
+	// Block, filter, unblock
+	if err := upgradePathService.BlockUpgradePath(upgradePathServiceTestCtx, productID, "1.0.0", "2.0.0", "bad migration", "user-123", ""); err != nil {
+		t.Fatalf("Failed to block upgrade path: %v", err)
+	}
+
+	blocked := true
+	paths, total, err := upgradePathService.ListUpgradePaths(upgradePathServiceTestCtx, productID, &ListUpgradePathsQuery{IsBlocked: &blocked, Page: 1, Limit: 10})
+	if err != nil {
+		t.Fatalf("Failed to list upgrade paths: %v", err)
+	}
+	if total != 1 || len(paths) != 1 || paths[0].ToVersion != "2.0.0" {
+		t.Errorf("Expected only the blocked path, got %d paths", total)
+	}
+
+	unblocked, err := upgradePathService.UnblockUpgradePath(upgradePathServiceTestCtx, productID, "1.0.0", "2.0.0", "user-123", "")
+	if err != nil {
+		t.Fatalf("Failed to unblock upgrade path: %v", err)
+	}
+	if unblocked.IsBlocked || unblocked.BlockReason != "" || unblocked.PathType != models.UpgradePathTypeMultiStep {
+		t.Errorf("Unexpected unblocked path: %+v", unblocked)
+	}
+
+	if _, err := upgradePathService.UnblockUpgradePath(upgradePathServiceTestCtx, productID, "1.0.0", "2.0.0", "user-123", ""); err == nil {
+		t.Error("Expected error when unblocking a path that is not blocked")
+	}
+
+	// Delete
+	if err := upgradePathService.DeleteUpgradePath(upgradePathServiceTestCtx, productID, "1.0.0", "3.0.0", "user-123", ""); err != nil {
+		t.Fatalf("Failed to delete upgrade path: %v", err)
+	}
+	paths, total, _ = upgradePathService.ListUpgradePaths(upgradePathServiceTestCtx, productID, &ListUpgradePathsQuery{FromVersion: "1.0.0", Page: 1, Limit: 10})
+	if total != 1 || len(paths) != 1 {
+		t.Errorf("Expected 1 upgrade path after delete, got %d", total)
+	}
+
+	// Every mutation is audited: 2 creates, update, block, unblock, delete
+	auditCount, err := upgradePathAuditRepo.Count(upgradePathServiceTestCtx, bson.M{"resource_type": "upgrade_path"})
+	if err != nil {
+		t.Fatalf("Failed to count audit logs: %v", err)
+	}
+	if auditCount != 6 {
+		t.Errorf("Expected 6 audit entries, got %d", auditCount)
+	}
+}
+
+func TestEvaluateUpgradePathRule(t *testing.T) {
+	released := []string{"3.8.0", "3.9.0", "3.9.2", "4.0.0", "5.1.2", "5.1.5", "5.2.0", "5.3.0"}
+
+	tests := []struct {
+		name                  string
+		rule                  *models.UpgradePathRule
+		from, to              string
+		expectMatch           bool
+		expectedIntermediates []string
+	}{
+		{
+			name: "3.x to 4.0 via latest 3.9.x",
+			rule: &models.UpgradePathRule{FromVersion: "3.x", ToVersion: "4.0", PathType: models.UpgradePathTypeMultiStep,
+				IntermediateVersions: []string{"3.9.x"}},
+			from: "3.8.0", to: "4.0.0",
+			expectMatch: true, expectedIntermediates: []string{"3.9.2"},
+		},
+		{
+			name: "intermediate skipped when from already satisfies it",
+			rule: &models.UpgradePathRule{FromVersion: "3.x", ToVersion: "4.0", PathType: models.UpgradePathTypeMultiStep,
+				IntermediateVersions: []string{"3.9.x"}},
+			from: "3.9.0", to: "4.0.0",
+			expectMatch: true, expectedIntermediates: []string{},
+		},
+		{
+			name: "blocked range",
+			rule: &models.UpgradePathRule{FromVersion: "5.1.0 - 5.1.4", ToVersion: ">5.2", PathType: models.UpgradePathTypeBlocked},
+			from: "5.1.2", to: "5.3.0",
+			expectMatch: true, expectedIntermediates: []string{},
+		},
+		{
+			name: "blocked range does not apply outside range",
+			rule: &models.UpgradePathRule{FromVersion: "5.1.0 - 5.1.4", ToVersion: ">5.2", PathType: models.UpgradePathTypeBlocked},
+			from: "5.1.5", to: "5.3.0",
+			expectMatch: false,
+		},
+		{
+			name: "latest patch is direct",
+			rule: &models.UpgradePathRule{FromVersion: "*", ToVersion: models.UpgradeRuleTargetLatestPatch, PathType: models.UpgradePathTypeDirect},
+			from: "3.9.0", to: "3.9.2",
+			expectMatch: true, expectedIntermediates: []string{},
+		},
+		{
+			name: "older patch is not latest patch",
+			rule: &models.UpgradePathRule{FromVersion: "*", ToVersion: models.UpgradeRuleTargetLatestPatch, PathType: models.UpgradePathTypeDirect},
+			from: "5.1.2", to: "5.1.4",
+			expectMatch: false,
+		},
+		{
+			name: "unresolvable intermediate",
+			rule: &models.UpgradePathRule{FromVersion: "3.x", ToVersion: "4.0", PathType: models.UpgradePathTypeMultiStep,
+				IntermediateVersions: []string{"3.10.x"}},
+			from: "3.8.0", to: "4.0.0",
+			expectMatch: true, expectedIntermediates: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matched, intermediates, reason := evaluateUpgradePathRule(tt.rule, tt.from, tt.to, released)
+			if matched != tt.expectMatch {
+				t.Fatalf("matched = %v, expected %v (%s)", matched, tt.expectMatch, reason)
+			}
+			if !matched {
+				return
+			}
+			if (intermediates == nil) != (tt.expectedIntermediates == nil) || len(intermediates) != len(tt.expectedIntermediates) {
+				t.Fatalf("intermediates = %v, expected %v", intermediates, tt.expectedIntermediates)
+			}
+			for i := range intermediates {
+				if intermediates[i] != tt.expectedIntermediates[i] {
+					t.Errorf("intermediates = %v, expected %v", intermediates, tt.expectedIntermediates)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateUpgradePathRule(t *testing.T) {
+	valid := &models.UpgradePathRule{Name: "r", FromVersion: "3.x", ToVersion: "4.0.0", PathType: models.UpgradePathTypeDirect}
+	if err := validateUpgradePathRule(valid); err != nil {
+		t.Errorf("Expected valid rule, got %v", err)
+	}
+
+	invalid := []*models.UpgradePathRule{
+		{FromVersion: "3.x", ToVersion: "4.0.0", PathType: models.UpgradePathTypeDirect},
+		{Name: "r", FromVersion: "abc", ToVersion: "4.0.0", PathType: models.UpgradePathTypeDirect},
+		{Name: "r", FromVersion: "3.x", ToVersion: "4.0.0", PathType: models.UpgradePathTypeMultiStep},
+		{Name: "r", FromVersion: "3.x", ToVersion: "4.0.0", PathType: "sideways"},
+	}
+	for _, rule := range invalid {
+		if err := validateUpgradePathRule(rule); err == nil {
+			t.Errorf("Expected validation error for rule %+v", rule)
+		}
+	}
+}
+
+func TestUpgradePathService_ResolveUpgradePath(t *testing.T) {
+	setupUpgradePathServiceTestDB(t)
+	defer teardownUpgradePathServiceTestDB(t)
+
+	productID := "resolve-path-product"
+	upgradePathProductRepo.Create(upgradePathServiceTestCtx, &models.Product{ProductID: productID, Name: "Resolve Path Product", Type: models.ProductTypeServer, IsActive: true})
+	for _, v := range []string{"3.8.0", "3.9.0", "3.9.4", "4.0.0"} {
+		upgradePathVersionRepo.Create(upgradePathServiceTestCtx, &models.Version{
+			ProductID:     productID,
+			VersionNumber: v,
+			ReleaseDate:   time.Now(),
+			ReleaseType:   models.ReleaseTypeFeature,
+			State:         models.VersionStateReleased,
+			CreatedBy:     "user-123",
+		})
+	}
+
+	rule, err := upgradePathService.CreateUpgradePathRule(upgradePathServiceTestCtx, productID, &models.CreateUpgradePathRuleRequest{
+		Name:                 "3.x must pass through 3.9.x",
+		FromVersion:          "3.x",
+		ToVersion:            "4.0",
+		PathType:             models.UpgradePathTypeMultiStep,
+		IntermediateVersions: []string{"3.9.x"},
+	}, "user-123", "")
+	if err != nil {
+		t.Fatalf("Failed to create rule: %v", err)
+	}
+
+	resolution, err := upgradePathService.ResolveUpgradePath(upgradePathServiceTestCtx, productID, "3.8.0", "4.0.0")
+	if err != nil {
+		t.Fatalf("Failed to resolve upgrade path: %v", err)
+	}
+	if resolution.DecidedBy != models.UpgradePathDecisionRule || resolution.RuleID != rule.ID.Hex() {
+		t.Errorf("Expected route decided by rule %s, got %s (%s)", rule.ID.Hex(), resolution.DecidedBy, resolution.RuleID)
+	}
+	if len(resolution.IntermediateVersions) != 1 || resolution.IntermediateVersions[0] != "3.9.4" {
+		t.Errorf("Expected intermediate [3.9.4], got %v", resolution.IntermediateVersions)
+	}
+
+	// An explicit path overrides the rule
+	explicit := &models.UpgradePath{
+		ProductID:   productID,
+		FromVersion: "3.8.0",
+		ToVersion:   "4.0.0",
+		PathType:    models.UpgradePathTypeDirect,
+	}
+	if err := upgradePathService.CreateUpgradePath(upgradePathServiceTestCtx, explicit, "user-123", ""); err != nil {
+		t.Fatalf("Failed to create explicit path: %v", err)
+	}
+
+	resolution, err = upgradePathService.ResolveUpgradePath(upgradePathServiceTestCtx, productID, "3.8.0", "4.0.0")
+	if err != nil {
+		t.Fatalf("Failed to resolve upgrade path: %v", err)
+	}
+	if resolution.DecidedBy != models.UpgradePathDecisionExplicit || resolution.PathType != models.UpgradePathTypeDirect {
+		t.Errorf("Expected explicit direct path, got %s %s", resolution.DecidedBy, resolution.PathType)
+	}
+}
+
+func TestUpgradePathService_RulesScopedToProduct(t *testing.T) {
+	setupUpgradePathServiceTestDB(t)
+	defer teardownUpgradePathServiceTestDB(t)
+
+	ctx := upgradePathServiceTestCtx
+	req := &models.CreateUpgradePathRuleRequest{
+		Name:        "Block 1.x to 3.x",
+		FromVersion: "1.x",
+		ToVersion:   "3.x",
+		PathType:    models.UpgradePathTypeBlocked,
+		BlockReason: "Data migration required",
+	}
+
+	// Rules need an existing product
+	if _, err := upgradePathService.CreateUpgradePathRule(ctx, "missing-rule-product", req, "user-123", ""); err == nil || !strings.Contains(err.Error(), "product not found") {
+		t.Fatalf("Expected product not found, got %v", err)
+	}
+
+	for _, productID := range []string{"rule-product-a", "rule-product-b"} {
+		upgradePathProductRepo.Create(ctx, &models.Product{ProductID: productID, Name: productID, Type: models.ProductTypeServer, IsActive: true})
+	}
+	rule, err := upgradePathService.CreateUpgradePathRule(ctx, "rule-product-b", req, "user-123", "")
+	if err != nil {
+		t.Fatalf("Failed to create rule: %v", err)
+	}
+
+	// A rule of product B is not found through product A
+	if _, err := upgradePathService.GetUpgradePathRule(ctx, "rule-product-a", rule.ID); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("Expected not found through another product, got %v", err)
+	}
+	name := "Renamed"
+	if _, err := upgradePathService.UpdateUpgradePathRule(ctx, "rule-product-a", rule.ID, &models.UpdateUpgradePathRuleRequest{Name: &name}, "user-123", ""); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("Expected update through another product to be refused, got %v", err)
+	}
+	if err := upgradePathService.DeleteUpgradePathRule(ctx, "rule-product-a", rule.ID, "user-123", ""); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("Expected delete through another product to be refused, got %v", err)
+	}
+	if _, err := upgradePathService.GetUpgradePathRule(ctx, "rule-product-b", rule.ID); err != nil {
+		t.Errorf("Expected the rule kept for its own product, got %v", err)
+	}
+}
@@ -17,15 +17,21 @@ import (
 type VersionService struct {
 	versionRepo *repository.VersionRepository
 	productRepo *repository.ProductRepository
-	auditRepo   *repository.AuditLogRepository
+	audit       *AuditLogger
+	stream      *StreamPublisher
+	outbox      *DomainEventOutbox
 }
 
-// NewVersionService creates a new version service
-func NewVersionService(versionRepo *repository.VersionRepository, productRepo *repository.ProductRepository, auditRepo *repository.AuditLogRepository) *VersionService {
+// NewVersionService creates a new version service. stream may be nil, in
+// which case state transitions are not streamed; outbox may be nil, in which
+// case releases raise no VersionReleased event.
+func NewVersionService(versionRepo *repository.VersionRepository, productRepo *repository.ProductRepository, audit *AuditLogger, stream *StreamPublisher, outbox *DomainEventOutbox) *VersionService {
 	return &VersionService{
 		versionRepo: versionRepo,
 		productRepo: productRepo,
-		auditRepo:   auditRepo,
+		audit:       audit,
+		stream:      stream,
+		outbox:      outbox,
 	}
 }
 
@@ -63,7 +69,7 @@ func (s *VersionService) CreateVersion(ctx context.Context, productID string, re
 	}
 
 	// Log audit
-	s.logAudit(ctx, models.AuditActionCreate, "version", version.ID.Hex(), createdBy, "", map[string]interface{}{
+	s.audit.LogChange(ctx, models.AuditActionCreate, "version", version.ID.Hex(), createdBy, "", nil, version, map[string]interface{}{
 		"product_id":     productID,
 		"version_number": req.VersionNumber,
 		"release_type":   req.ReleaseType,
@@ -124,6 +130,7 @@ func (s *VersionService) UpdateVersion(ctx context.Context, id primitive.ObjectI
 		return nil, fmt.Errorf("can only update draft versions, current state: %s", version.State)
 	}
 
+	before := auditState(version)
 	// Update fields
 	if req.ReleaseDate != nil {
 		version.ReleaseDate = *req.ReleaseDate
@@ -152,7 +159,7 @@ func (s *VersionService) UpdateVersion(ctx context.Context, id primitive.ObjectI
 	}
 
 	// Log audit
-	s.logAudit(ctx, models.AuditActionUpdate, "version", version.ID.Hex(), userID, "", map[string]interface{}{
+	s.audit.LogChange(ctx, models.AuditActionUpdate, "version", version.ID.Hex(), userID, "", before, version, map[string]interface{}{
 		"product_id":     version.ProductID,
 		"version_number": version.VersionNumber,
 	})
@@ -160,7 +167,7 @@ func (s *VersionService) UpdateVersion(ctx context.Context, id primitive.ObjectI
 	return version, nil
 }
 
-// ApproveVersion approves a version
+// ApproveVersion approves a version and raises VersionApproved
 func (s *VersionService) ApproveVersion(ctx context.Context, id primitive.ObjectID, req *models.ApproveVersionRequest) (*models.Version, error) {
 	version, err := s.versionRepo.GetByID(ctx, id)
 	if err != nil {
@@ -173,18 +180,33 @@ func (s *VersionService) ApproveVersion(ctx context.Context, id primitive.Object
 	}
 
 	// Update state
-	if err := s.versionRepo.UpdateState(ctx, id, models.VersionStateApproved, req.ApprovedBy); err != nil {
+	err = s.outbox.Write(ctx, func(ctx context.Context) ([]DomainEvent, error) {
+		if err := s.versionRepo.UpdateState(ctx, id, models.VersionStateApproved, req.ApprovedBy); err != nil {
+			return nil, err
+		}
+		return []DomainEvent{&VersionApproved{
+			VersionID:     version.ID.Hex(),
+			ProductID:     version.ProductID,
+			VersionNumber: version.VersionNumber,
+			ApprovedBy:    req.ApprovedBy,
+			ApprovedAt:    time.Now(),
+		}}, nil
+	})
+	if err != nil {
 		return nil, fmt.Errorf("failed to approve version: %w", err)
 	}
 
 	// Get updated version
+	previous := version
 	version, err = s.versionRepo.GetByID(ctx, id)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get updated version: %w", err)
 	}
 
+	s.stream.VersionStateChanged(ctx, version, models.VersionStatePendingReview)
+
 	// Log audit
-	s.logAudit(ctx, models.AuditActionApprove, "version", version.ID.Hex(), req.ApprovedBy, "", map[string]interface{}{
+	s.audit.LogChange(ctx, models.AuditActionApprove, "version", version.ID.Hex(), req.ApprovedBy, "", previous, version, map[string]interface{}{
 		"product_id":     version.ProductID,
 		"version_number": version.VersionNumber,
 	})
@@ -192,7 +214,7 @@ func (s *VersionService) ApproveVersion(ctx context.Context, id primitive.Object
 	return version, nil
 }
 
-// SubmitForReview submits a draft version for review
+// SubmitForReview submits a draft version for review and raises VersionSubmitted
 func (s *VersionService) SubmitForReview(ctx context.Context, id primitive.ObjectID, userID string) (*models.Version, error) {
 	version, err := s.versionRepo.GetByID(ctx, id)
 	if err != nil {
@@ -203,16 +225,31 @@ func (s *VersionService) SubmitForReview(ctx context.Context, id primitive.Objec
 		return nil, fmt.Errorf("can only submit draft versions for review, current state: %s", version.State)
 	}
 
-	if err := s.versionRepo.UpdateState(ctx, id, models.VersionStatePendingReview, ""); err != nil {
+	err = s.outbox.Write(ctx, func(ctx context.Context) ([]DomainEvent, error) {
+		if err := s.versionRepo.UpdateState(ctx, id, models.VersionStatePendingReview, ""); err != nil {
+			return nil, err
+		}
+		return []DomainEvent{&VersionSubmitted{
+			VersionID:     version.ID.Hex(),
+			ProductID:     version.ProductID,
+			VersionNumber: version.VersionNumber,
+			SubmittedBy:   userID,
+			SubmittedAt:   time.Now(),
+		}}, nil
+	})
+	if err != nil {
 		return nil, fmt.Errorf("failed to submit version for review: %w", err)
 	}
 
+	previous := version
 	version, err = s.versionRepo.GetByID(ctx, id)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get updated version: %w", err)
 	}
 
-	s.logAudit(ctx, models.AuditActionUpdate, "version", version.ID.Hex(), userID, "", map[string]interface{}{
+	s.stream.VersionStateChanged(ctx, version, models.VersionStateDraft)
+
+	s.audit.LogChange(ctx, models.AuditActionUpdate, "version", version.ID.Hex(), userID, "", previous, version, map[string]interface{}{
 		"action":         "submit_for_review",
 		"product_id":     version.ProductID,
 		"version_number": version.VersionNumber,
@@ -221,7 +258,7 @@ func (s *VersionService) SubmitForReview(ctx context.Context, id primitive.Objec
 	return version, nil
 }
 
-// ReleaseVersion releases an approved version
+// ReleaseVersion releases an approved version and raises VersionReleased
 func (s *VersionService) ReleaseVersion(ctx context.Context, id primitive.ObjectID, userID string) (*models.Version, error) {
 	version, err := s.versionRepo.GetByID(ctx, id)
 	if err != nil {
@@ -232,16 +269,32 @@ func (s *VersionService) ReleaseVersion(ctx context.Context, id primitive.Object
 		return nil, fmt.Errorf("can only release approved versions, current state: %s", version.State)
 	}
 
-	if err := s.versionRepo.UpdateState(ctx, id, models.VersionStateReleased, ""); err != nil {
+	err = s.outbox.Write(ctx, func(ctx context.Context) ([]DomainEvent, error) {
+		if err := s.versionRepo.UpdateState(ctx, id, models.VersionStateReleased, ""); err != nil {
+			return nil, err
+		}
+		return []DomainEvent{&VersionReleased{
+			VersionID:     version.ID.Hex(),
+			ProductID:     version.ProductID,
+			VersionNumber: version.VersionNumber,
+			ReleaseType:   version.ReleaseType,
+			ReleasedBy:    userID,
+			ReleasedAt:    time.Now(),
+		}}, nil
+	})
+	if err != nil {
 		return nil, fmt.Errorf("failed to release version: %w", err)
 	}
 
+	previous := version
 	version, err = s.versionRepo.GetByID(ctx, id)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get updated version: %w", err)
 	}
 
-	s.logAudit(ctx, models.AuditActionRelease, "version", version.ID.Hex(), userID, "", map[string]interface{}{
+	s.stream.VersionStateChanged(ctx, version, models.VersionStateApproved)
+
+	s.audit.LogChange(ctx, models.AuditActionRelease, "version", version.ID.Hex(), userID, "", previous, version, map[string]interface{}{
 		"product_id":     version.ProductID,
 		"version_number": version.VersionNumber,
 	})
@@ -298,25 +351,6 @@ func (s *VersionService) ListVersions(ctx context.Context, filter bson.M, page,
 	return versions, total, nil
 }
 
-// logAudit logs an audit entry
-func (s *VersionService) logAudit(ctx context.Context, action models.AuditAction, resourceType, resourceID, userID, userEmail string, details map[string]interface{}) {
-	if s.auditRepo == nil {
-		return
-	}
-
-	auditLog := &models.AuditLog{
-		Action:       action,
-		ResourceType: resourceType,
-		ResourceID:   resourceID,
-		UserID:       userID,
-		UserEmail:    userEmail,
-		Details:      details,
-		Timestamp:    time.Now(),
-	}
-
-	_ = s.auditRepo.Create(ctx, auditLog)
-}
-
 // AddPackageToVersion adds a package to a version
 func (s *VersionService) AddPackageToVersion(ctx context.Context, versionID primitive.ObjectID, packageInfo *models.PackageInfo) (*models.Version, error) {
 	version, err := s.versionRepo.GetByID(ctx, versionID)
@@ -329,6 +363,7 @@ func (s *VersionService) AddPackageToVersion(ctx context.Context, versionID prim
 		return nil, fmt.Errorf("packages can only be added to draft versions")
 	}
 
+	before := auditState(version)
 	// Add package to version
 	if version.Packages == nil {
 		version.Packages = []models.PackageInfo{}
@@ -341,6 +376,12 @@ func (s *VersionService) AddPackageToVersion(ctx context.Context, versionID prim
 		return nil, fmt.Errorf("failed to update version: %w", err)
 	}
 
+	s.audit.LogChange(ctx, models.AuditActionUpload, "version", versionID.Hex(), packageInfo.UploadedBy, "", before, version, map[string]interface{}{
+		"package_type":    packageInfo.PackageType,
+		"file_name":       packageInfo.FileName,
+		"checksum_sha256": packageInfo.ChecksumSHA256,
+	})
+
 	// Reload version to get the updated data
 	version, err = s.versionRepo.GetByID(ctx, versionID)
 	if err != nil {
@@ -38,7 +38,7 @@ func setupVersionServiceTestDB(t *testing.T) {
 	versionRepo = repository.NewVersionRepository(db.Collection("versions"))
 	versionProductRepo = repository.NewProductRepository(db.Collection("products"))
 	versionAuditRepo = repository.NewAuditLogRepository(db.Collection("audit_logs"))
-	versionService = NewVersionService(versionRepo, versionProductRepo, versionAuditRepo)
+	versionService = NewVersionService(versionRepo, versionProductRepo, NewAuditLogger(versionAuditRepo), nil, nil)
 }
 
 func teardownVersionServiceTestDB(t *testing.T) {
@@ -0,0 +1,32 @@
+package service
+
+import (
+	"context"
+	"time"
+)
+
+// defaultWebhookDeliveryInterval is how often due webhook deliveries are sent
+const defaultWebhookDeliveryInterval = 5 * time.Second
+
+// WebhookDeliveryWorker periodically sends due webhook deliveries, including
+// retries and redeliveries
+type WebhookDeliveryWorker struct {
+	webhookService *WebhookService
+	interval       time.Duration
+}
+
+// NewWebhookDeliveryWorker creates a new webhook delivery worker
+func NewWebhookDeliveryWorker(webhookService *WebhookService, interval time.Duration) *WebhookDeliveryWorker {
+	if interval <= 0 {
+		interval = defaultWebhookDeliveryInterval
+	}
+	return &WebhookDeliveryWorker{
+		webhookService: webhookService,
+		interval:       interval,
+	}
+}
+
+// Run sends due deliveries on every tick until the context is cancelled
+func (w *WebhookDeliveryWorker) Run(ctx context.Context) {
+	runPeriodically(ctx, "Webhook delivery worker", w.interval, w.webhookService.DeliverPending)
+}
@@ -0,0 +1,158 @@
+package service
+
+import (
+	"crypto/hmac"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"updatemanager/internal/models"
+	"updatemanager/internal/repository"
+)
+
+func TestSignWebhookPayload(t *testing.T) {
+	body := []byte(`{"id":"1","type":"ping"}`)
+	signature := SignWebhookPayload("whsec_test_secret", "1700000000", body)
+
+	// HMAC-SHA256("whsec_test_secret", "1700000000." + body)
+	want := "sha256=c26c3c09888fd409992a42018732d420b55789c24bc88b3366d855cc2fb3511f"
+	if signature != want {
+		t.Errorf("SignWebhookPayload() = %q, want %q", signature, want)
+	}
+	if signature == SignWebhookPayload("whsec_other_secret", "1700000000", body) {
+		t.Error("Expected the secret to change the signature")
+	}
+	if signature == SignWebhookPayload("whsec_test_secret", "1700000001", body) {
+		t.Error("Expected the timestamp to change the signature")
+	}
+}
+
+func TestWebhookRetryDelay(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{5, 8 * time.Minute},
+		{maxWebhookAttempts, 30 * time.Second << (maxWebhookAttempts - 1)},
+		{20, 6 * time.Hour},
+	}
+
+	for _, tt := range tests {
+		if got := webhookRetryDelay(tt.attempts); got != tt.want {
+			t.Errorf("webhookRetryDelay(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+func TestValidateWebhook(t *testing.T) {
+	tests := []struct {
+		name    string
+		webhook models.Webhook
+		wantErr bool
+	}{
+		{"https URL", models.Webhook{URL: "https://hooks.example.com/um"}, false},
+		{"known event types", models.Webhook{URL: "http://hooks.example.com", EventTypes: []models.DomainEventType{models.DomainEventRolloutFailed}}, false},
+		{"relative URL", models.Webhook{URL: "/hooks"}, true},
+		{"unsupported scheme", models.Webhook{URL: "ftp://hooks.example.com"}, true},
+		{"short secret", models.Webhook{URL: "https://hooks.example.com", Secret: "short"}, true},
+		{"unknown event type", models.Webhook{URL: "https://hooks.example.com", EventTypes: []models.DomainEventType{"version.deleted"}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateWebhook(&tt.webhook); (err != nil) != tt.wantErr {
+				t.Errorf("validateWebhook() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWebhookService_DeliverPending(t *testing.T) {
+	setupCampaignServiceTestDB(t)
+	defer teardownCampaignServiceTestDB(t)
+
+	ctx := campaignServiceTestCtx
+	webhookRepo := repository.NewWebhookRepository(campaignServiceTestDB.Collection("webhooks"))
+	deliveryRepo := repository.NewWebhookDeliveryRepository(campaignServiceTestDB.Collection("webhook_deliveries"))
+	webhookService := NewWebhookService(webhookRepo, deliveryRepo, nil, nil, nil, nil)
+
+	// The receiver fails the first request and verifies the signature
+	calls := 0
+	var verified bool
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		body, _ := io.ReadAll(r.Body)
+		want := SignWebhookPayload("whsec_test_secret_value", r.Header.Get(WebhookTimestampHeader), body)
+		verified = hmac.Equal([]byte(r.Header.Get(WebhookSignatureHeader)), []byte(want))
+		if calls == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer receiver.Close()
+
+	webhook := &models.Webhook{
+		URL:        receiver.URL,
+		Secret:     "whsec_test_secret_value",
+		EventTypes: []models.DomainEventType{models.DomainEventVersionReleased},
+		ProductIDs: []string{"product-1"},
+		IsActive:   true,
+	}
+	if err := webhookRepo.Create(ctx, webhook); err != nil {
+		t.Fatalf("Failed to create webhook: %v", err)
+	}
+
+	event := newTestOutboxEvent(t, &VersionReleased{VersionID: "v-1", ProductID: "product-1", VersionNumber: "2.0.0"})
+	if err := webhookService.HandleDomainEvent(ctx, event); err != nil {
+		t.Fatalf("HandleDomainEvent() error = %v", err)
+	}
+	// Other products' events are filtered out
+	other := newTestOutboxEvent(t, &VersionReleased{VersionID: "v-2", ProductID: "product-2"})
+	if err := webhookService.HandleDomainEvent(ctx, other); err != nil {
+		t.Fatalf("HandleDomainEvent() error = %v", err)
+	}
+	if count, _ := deliveryRepo.Count(ctx, bson.M{}); count != 1 {
+		t.Fatalf("Expected one delivery, found %d", count)
+	}
+
+	// The first attempt fails and is retried after a backoff
+	now := time.Now()
+	if succeeded, err := webhookService.DeliverPending(ctx, now); err != nil || succeeded != 0 {
+		t.Fatalf("Expected no delivery to succeed, got %d, %v", succeeded, err)
+	}
+	deliveries, _ := deliveryRepo.List(ctx, bson.M{}, nil)
+	delivery := deliveries[0]
+	if !verified || delivery.Status != models.WebhookDeliveryPending || delivery.ResponseStatus != http.StatusServiceUnavailable || !delivery.NextAttemptAt.After(now) {
+		t.Errorf("Expected a verified, rescheduled delivery, got %+v", delivery)
+	}
+
+	if succeeded, err := webhookService.DeliverPending(ctx, now.Add(time.Hour)); err != nil || succeeded != 1 {
+		t.Fatalf("Expected the retry to succeed, got %d, %v", succeeded, err)
+	}
+	delivery, _ = deliveryRepo.GetByID(ctx, delivery.ID)
+	if delivery.Status != models.WebhookDeliverySucceeded || delivery.Attempts != 2 || delivery.DeliveredAt == nil {
+		t.Errorf("Expected delivery succeeded after 2 attempts, got %+v", delivery)
+	}
+}
+
+// newTestOutboxEvent builds an outbox event carrying the given domain event
+func newTestOutboxEvent(t *testing.T, event DomainEvent) *models.OutboxEvent {
+	payload, err := bson.Marshal(event)
+	if err != nil {
+		t.Fatalf("Failed to encode event: %v", err)
+	}
+	return &models.OutboxEvent{
+		ID:        primitive.NewObjectID(),
+		Type:      event.EventType(),
+		Payload:   payload,
+		CreatedAt: time.Now(),
+	}
+}
@@ -0,0 +1,169 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// VersionPattern is a parsed version selector used by upgrade path rules.
+//
+// Supported syntax:
+//   - "" or "*" or "x"        matches any version
+//   - "3", "3.x", "3.9.*"     wildcard match; missing components are wildcards
+//   - "4.0.0"                 exact match
+//   - ">5.2", ">=5.1.0 <6"    comparators, whitespace separated constraints are ANDed
+//   - "5.1.0 - 5.1.4"         inclusive range (spaces around the hyphen are required)
+//
+// Partial versions used in comparators are zero-filled, so ">5.2" means ">5.2.0".
+type VersionPattern struct {
+	raw         string
+	any         bool
+	wildcard    []int // -1 marks a wildcard component
+	constraints []versionConstraint
+}
+
+type versionConstraint struct {
+	op      string
+	version string
+}
+
+// ParseVersionPattern parses a version pattern
+func ParseVersionPattern(pattern string) (*VersionPattern, error) {
+	raw := strings.TrimSpace(pattern)
+	p := &VersionPattern{raw: raw}
+
+	if raw == "" || raw == "*" || raw == "x" || raw == "X" {
+		p.any = true
+		return p, nil
+	}
+
+	// Hyphen range: "5.1.0 - 5.1.4"
+	if parts := strings.Split(raw, " - "); len(parts) == 2 {
+		low := strings.TrimSpace(parts[0])
+		high := strings.TrimSpace(parts[1])
+		if !isNumericVersion(low) || !isNumericVersion(high) {
+			return nil, fmt.Errorf("invalid version range '%s'", raw)
+		}
+		p.constraints = []versionConstraint{{op: ">=", version: low}, {op: "<=", version: high}}
+		return p, nil
+	}
+
+	// Comparator list: ">=5.1.0 <=5.1.4"
+	if strings.ContainsAny(raw[:1], "<>=") {
+		for _, field := range strings.Fields(raw) {
+			op := ""
+			for _, candidate := range []string{">=", "<=", ">", "<", "="} {
+				if strings.HasPrefix(field, candidate) {
+					op = candidate
+					break
+				}
+			}
+			version := strings.TrimPrefix(field, op)
+			if op == "" || !isNumericVersion(version) {
+				return nil, fmt.Errorf("invalid version constraint '%s' in pattern '%s'", field, raw)
+			}
+			p.constraints = append(p.constraints, versionConstraint{op: op, version: version})
+		}
+		return p, nil
+	}
+
+	// Wildcard or exact: "3.x", "3.9.*", "4.0.0"
+	components := strings.Split(raw, ".")
+	if len(components) > 3 {
+		return nil, fmt.Errorf("invalid version pattern '%s'", raw)
+	}
+	p.wildcard = []int{-1, -1, -1}
+	for i, component := range components {
+		if component == "x" || component == "X" || component == "*" {
+			continue
+		}
+		num, err := strconv.Atoi(component)
+		if err != nil || num < 0 {
+			return nil, fmt.Errorf("invalid version pattern '%s'", raw)
+		}
+		p.wildcard[i] = num
+	}
+
+	return p, nil
+}
+
+// Matches reports whether the version satisfies the pattern
+func (p *VersionPattern) Matches(version string) bool {
+	if p.any {
+		return true
+	}
+
+	if p.wildcard != nil {
+		parts := parseVersion(normalizeVersion(version))
+		for i, want := range p.wildcard {
+			if want >= 0 && parts[i] != want {
+				return false
+			}
+		}
+		return true
+	}
+
+	for _, c := range p.constraints {
+		cmp := CompareVersions(version, c.version)
+		switch c.op {
+		case ">":
+			if cmp <= 0 {
+				return false
+			}
+		case ">=":
+			if cmp < 0 {
+				return false
+			}
+		case "<":
+			if cmp >= 0 {
+				return false
+			}
+		case "<=":
+			if cmp > 0 {
+				return false
+			}
+		case "=":
+			if cmp != 0 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// String returns the pattern as it was written
+func (p *VersionPattern) String() string {
+	return p.raw
+}
+
+// MatchVersionPattern reports whether version matches pattern.
+// Invalid patterns never match.
+func MatchVersionPattern(pattern, version string) bool {
+	p, err := ParseVersionPattern(pattern)
+	if err != nil {
+		return false
+	}
+	return p.Matches(version)
+}
+
+// IsSameMinor checks if two versions share major and minor components
+func IsSameMinor(v1, v2 string) bool {
+	parts1 := parseVersion(normalizeVersion(v1))
+	parts2 := parseVersion(normalizeVersion(v2))
+	return parts1[0] == parts2[0] && parts1[1] == parts2[1]
+}
+
+// isNumericVersion checks that every dot-separated component is a number
+func isNumericVersion(version string) bool {
+	components := strings.Split(version, ".")
+	if len(components) == 0 || len(components) > 3 {
+		return false
+	}
+	for _, component := range components {
+		if _, err := strconv.Atoi(component); err != nil {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,61 @@
+package utils
+
+import "testing"
+
+func TestMatchVersionPattern(t *testing.T) {
+	tests := []struct {
+		name     string
+		pattern  string
+		version  string
+		expected bool
+	}{
+		{"empty matches any", "", "1.2.3", true},
+		{"star matches any", "*", "9.0.0", true},
+		{"major wildcard", "3.x", "3.4.1", true},
+		{"major wildcard other major", "3.x", "4.0.0", false},
+		{"minor wildcard", "3.9.*", "3.9.7", true},
+		{"minor wildcard other minor", "3.9.*", "3.8.7", false},
+		{"partial version is prefix", "4.0", "4.0.2", true},
+		{"exact match", "4.0.0", "4.0.0", true},
+		{"exact mismatch", "4.0.0", "4.0.1", false},
+		{"greater than partial", ">5.2", "5.2.1", true},
+		{"greater than partial equal", ">5.2", "5.2.0", false},
+		{"comparator list inside", ">=5.1.0 <=5.1.4", "5.1.3", true},
+		{"comparator list outside", ">=5.1.0 <=5.1.4", "5.1.5", false},
+		{"hyphen range low bound", "5.1.0 - 5.1.4", "5.1.0", true},
+		{"hyphen range high bound", "5.1.0 - 5.1.4", "5.1.4", true},
+		{"hyphen range outside", "5.1.0 - 5.1.4", "5.2.0", false},
+		{"pre-release ignored", "3.x", "3.1.0-beta", true},
+		{"invalid pattern", "abc", "1.0.0", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := MatchVersionPattern(tt.pattern, tt.version)
+			if result != tt.expected {
+				t.Errorf("MatchVersionPattern(%q, %q) = %v, expected %v", tt.pattern, tt.version, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseVersionPattern_Invalid(t *testing.T) {
+	patterns := []string{"abc", "1.2.3.4", ">=foo", "1.0 - bar", "~1.2"}
+
+	for _, pattern := range patterns {
+		t.Run(pattern, func(t *testing.T) {
+			if _, err := ParseVersionPattern(pattern); err == nil {
+				t.Errorf("ParseVersionPattern(%q) expected error, got nil", pattern)
+			}
+		})
+	}
+}
+
+func TestIsSameMinor(t *testing.T) {
+	if !IsSameMinor("3.9.1", "3.9.7") {
+		t.Error("3.9.1 and 3.9.7 should share a minor")
+	}
+	if IsSameMinor("3.9.1", "3.10.0") {
+		t.Error("3.9.1 and 3.10.0 should not share a minor")
+	}
+}
@@ -16,10 +16,27 @@ db.createCollection("products");
 db.createCollection("versions");
 db.createCollection("compatibility_matrices");
 db.createCollection("upgrade_paths");
+db.createCollection("upgrade_path_rules");
 db.createCollection("notifications");
+db.createCollection("notification_dedup_keys");
+db.createCollection("endpoints");
 db.createCollection("update_detections");
 db.createCollection("update_rollouts");
+db.createCollection("rollout_campaigns");
+db.createCollection("rollout_events");
 db.createCollection("audit_logs");
+db.createCollection("audit_checkpoints");
+// Capped: the live update stream tails it across replicas (STREAM_BROKER=mongo)
+db.createCollection("stream_events", { capped: true, size: 16777216 });
+db.createCollection("domain_event_outbox");
+db.createCollection("webhooks");
+db.createCollection("webhook_deliveries");
+db.createCollection("notification_routes");
+db.createCollection("notification_templates");
+db.createCollection("internal_notifications");
+db.createCollection("internal_notification_dedup_keys");
+db.createCollection("internal_groups");
+db.createCollection("internal_notification_subscriptions");
 
 print("Database 'updatemanager' setup complete!");
 print("Collections created successfully.");
@@ -21,6 +21,7 @@ db.versions.createIndex({ "release_date": -1 });
 db.versions.createIndex({ "created_at": -1 });
 db.versions.createIndex({ "approved_by": 1 });
 db.versions.createIndex({ "eol_date": 1 }, { sparse: true });
+db.versions.createIndex({ "rollout_halt.status": 1 }, { sparse: true });
 db.versions.createIndex({ "product_id": 1, "state": 1, "release_date": -1 });
 db.versions.createIndex({ "state": 1, "created_at": 1 });
 
@@ -38,6 +39,9 @@ db.upgrade_paths.createIndex({ "to_version": 1 });
 db.upgrade_paths.createIndex({ "path_type": 1 });
 db.upgrade_paths.createIndex({ "is_blocked": 1 });
 
+// Upgrade Path Rules Collection
+db.upgrade_path_rules.createIndex({ "product_id": 1, "is_active": 1, "priority": 1 });
+
 // Notifications Collection
 db.notifications.createIndex({ "recipient_id": 1, "is_read": 1, "created_at": -1 });
 db.notifications.createIndex({ "recipient_id": 1, "type": 1 });
@@ -46,6 +50,19 @@ db.notifications.createIndex({ "product_id": 1 });
 db.notifications.createIndex({ "version_id": 1 });
 db.notifications.createIndex({ "created_at": -1 });
 db.notifications.createIndex({ "is_read": 1 });
+db.notifications.createIndex({ "delivery_due_at": 1 }, { sparse: true }); // Pending channel deliveries
+db.notifications.createIndex({ "digest_due_at": 1, "recipient_id": 1 }, { sparse: true }); // Deliveries held for digests
+db.notifications.createIndex({ "recipient_id": 1, "is_archived": 1, "created_at": -1 }); // Inbox and archive
+db.notifications.createIndex({ "read_at": 1 }, { name: "read_at_ttl", expireAfterSeconds: 7776000 }); // Read notifications purged after 90 days; the server applies NOTIFICATION_READ_RETENTION_DAYS
+db.notification_dedup_keys.createIndex({ "recipient_id": 1, "type": 1, "subject": 1 }, { name: "recipient_type_subject_unique", unique: true }); // Deduplicating automatic notifications
+
+// Endpoints Collection
+db.endpoints.createIndex({ "endpoint_id": 1 }, { unique: true });
+db.endpoints.createIndex({ "deployment_id": 1 });
+db.endpoints.createIndex({ "tenant_id": 1 });
+db.endpoints.createIndex({ "product_id": 1, "status": 1 });
+db.endpoints.createIndex({ "hostname": 1 });
+db.endpoints.createIndex({ "status": 1, "last_heartbeat_at": 1 });
 
 // Update Detections Collection
 db.update_detections.createIndex({ "endpoint_id": 1, "product_id": 1 }, { unique: true });
@@ -63,16 +80,71 @@ db.update_rollouts.createIndex({ "initiated_at": -1 });
 db.update_rollouts.createIndex({ "initiated_by": 1 });
 db.update_rollouts.createIndex({ "from_version": 1, "to_version": 1 });
 db.update_rollouts.createIndex({ "endpoint_id": 1, "status": 1, "initiated_at": -1 });
+db.update_rollouts.createIndex({ "campaign_id": 1, "wave_index": 1, "status": 1 }, { sparse: true });
+db.update_rollouts.createIndex({ "product_id": 1, "to_version": 1, "status": 1 });
+db.update_rollouts.createIndex({ "rollback_of": 1 }, { unique: true, sparse: true });
+db.update_rollouts.createIndex({ "status": 1, "scheduled_for": 1 }, { sparse: true });
+db.update_rollouts.createIndex({ "status": 1, "initiated_at": 1 });
+db.update_rollouts.createIndex({ "timed_out_phase": 1, "failed_at": -1 }, { sparse: true });
+db.update_rollouts.createIndex({ "batch_id": 1, "status": 1 }, { sparse: true });
+
+// Rollout Campaigns Collection
+db.rollout_campaigns.createIndex({ "status": 1, "created_at": 1 });
+db.rollout_campaigns.createIndex({ "product_id": 1, "to_version": 1 });
+
+// Rollout Events Collection (append-only agent timeline)
+db.rollout_events.createIndex({ "rollout_id": 1, "timestamp": 1 });
+db.rollout_events.createIndex({ "rollout_id": 1, "level": 1 });
+db.rollout_events.createIndex(
+  { "rollout_id": 1, "event_id": 1 },
+  { unique: true, partialFilterExpression: { "event_id": { $exists: true } } }
+);
+
+// Domain Event Outbox Collection
+db.domain_event_outbox.createIndex({ "status": 1, "next_attempt_at": 1 });
+db.domain_event_outbox.createIndex({ "dispatched_at": 1 }, { expireAfterSeconds: 604800 }); // Dispatched events kept 7 days
+
+// Webhooks Collection
+db.webhooks.createIndex({ "customer_id": 1 });
+db.webhooks.createIndex({ "is_active": 1 });
+
+// Webhook Deliveries Collection
+db.webhook_deliveries.createIndex({ "webhook_id": 1, "created_at": -1 });
+db.webhook_deliveries.createIndex({ "status": 1, "next_attempt_at": 1 });
+db.webhook_deliveries.createIndex({ "dedupe_key": 1 }, { unique: true, sparse: true }); // One delivery per webhook and event
+
+// Notification Routes Collection
+db.notification_routes.createIndex({ "customer_id": 1, "is_active": 1 });
+
+// Notification Templates Collection
+db.notification_templates.createIndex({ "type": 1, "channel": 1, "locale": 1 }, { unique: true });
+
+// Internal Notifications Collection (inbox of internal users, apart from customer notifications)
+db.internal_notifications.createIndex({ "recipient_id": 1, "is_archived": 1, "created_at": -1 }); // Inbox and archive
+db.internal_notifications.createIndex({ "recipient_id": 1, "is_read": 1, "created_at": -1 });
+db.internal_notification_dedup_keys.createIndex({ "recipient_id": 1, "type": 1, "subject": 1 }, { name: "recipient_type_subject_unique", unique: true }); // Deduplicating notifications
+db.internal_notifications.createIndex({ "read_at": 1 }, { name: "read_at_ttl", expireAfterSeconds: 7776000 }); // Read notifications purged after 90 days; the server applies NOTIFICATION_READ_RETENTION_DAYS
+
+// Internal Groups Collection
+db.internal_groups.createIndex({ "group_id": 1 }, { unique: true });
+db.internal_groups.createIndex({ "members": 1 });
+
+// Internal Notification Subscriptions Collection
+db.internal_notification_subscriptions.createIndex({ "types": 1, "is_active": 1, "product_id": 1 });
+db.internal_notification_subscriptions.createIndex({ "recipient_type": 1, "recipient_id": 1 });
 
 // Audit Logs Collection
 db.audit_logs.createIndex({ "resource_type": 1, "resource_id": 1 });
 db.audit_logs.createIndex({ "user_id": 1 });
 db.audit_logs.createIndex({ "action": 1 });
 db.audit_logs.createIndex({ "timestamp": -1 });
+db.audit_logs.createIndex({ "request_id": 1 }, { sparse: true });
 db.audit_logs.createIndex({ "product_id": 1 }, { sparse: true });
 db.audit_logs.createIndex({ "created_at": -1 });
 db.audit_logs.createIndex({ "user_id": 1, "timestamp": -1 });
 db.audit_logs.createIndex({ "resource_type": 1, "resource_id": 1, "timestamp": -1 });
+db.audit_logs.createIndex({ "sequence": 1 }, { name: "sequence_unique", unique: true, partialFilterExpression: { "sequence": { $exists: true } } });
+db.audit_checkpoints.createIndex({ "sequence": -1 });
 
 print("All indexes created successfully!");
 